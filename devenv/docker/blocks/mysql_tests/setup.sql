@@ -1,2 +1,4 @@
 CREATE DATABASE grafana_ds_tests;
 GRANT ALL PRIVILEGES ON grafana_ds_tests.* TO 'grafana';
+# The isolated test databases of sqlstore.InitIsolatedTestDB are created and dropped by the tests.
+GRANT ALL PRIVILEGES ON `grafana\_test\_%`.* TO 'grafana';
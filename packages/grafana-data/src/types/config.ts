@@ -198,6 +198,9 @@ export interface GrafanaConfig {
   theme2: GrafanaTheme2;
   anonymousEnabled: boolean;
   featureToggles: FeatureToggles;
+  featureToggleConfigs: Record<string, Record<string, string>>;
+  experimentVariants: Record<string, string>;
+  featureTogglesVersion: number;
   licenseInfo: LicenseInfo;
   http2Enabled: boolean;
   dateFormats?: SystemDateFormatSettings;
@@ -91,6 +91,9 @@ export class GrafanaBootConfig implements GrafanaConfig {
   theme: GrafanaTheme;
   theme2: GrafanaTheme2;
   featureToggles: FeatureToggles = {};
+  featureToggleConfigs: Record<string, Record<string, string>> = {};
+  experimentVariants: Record<string, string> = {};
+  featureTogglesVersion = 0;
   anonymousEnabled = false;
   licenseInfo: LicenseInfo = {} as LicenseInfo;
   rendererAvailable = false;
@@ -1,22 +1,36 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/secrets"
 	skv "github.com/grafana/grafana/pkg/services/secrets/kvstore"
 )
 
 func (hs *HTTPServer) AdminRotateDataEncryptionKeys(c *contextmodel.ReqContext) response.Response {
-	if err := hs.SecretsService.RotateDataKeys(c.Req.Context()); err != nil {
+	if err := hs.secretsMigrator.RotateDataKeys(c.Req.Context(), c.QueryBool("reencrypt")); err != nil {
+		if errors.Is(err, secrets.ErrReEncryptionJobInProgress) {
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
 		return response.Error(http.StatusInternalServerError, "Failed to rotate data keys", err)
 	}
 
 	return response.Respond(http.StatusNoContent, "")
 }
 
+func (hs *HTTPServer) AdminGetRetiredDataEncryptionKeys(c *contextmodel.ReqContext) response.Response {
+	usage, err := hs.secretsMigrator.GetRetiredDataKeysUsage(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get retired data keys", err)
+	}
+
+	return response.JSON(http.StatusOK, usage)
+}
+
 func (hs *HTTPServer) AdminReEncryptEncryptionKeys(c *contextmodel.ReqContext) response.Response {
 	if err := hs.SecretsService.ReEncryptDataKeys(c.Req.Context()); err != nil {
 		return response.Error(http.StatusInternalServerError, "Failed to re-encrypt data keys", err)
@@ -28,6 +42,9 @@ func (hs *HTTPServer) AdminReEncryptEncryptionKeys(c *contextmodel.ReqContext) r
 func (hs *HTTPServer) AdminReEncryptSecrets(c *contextmodel.ReqContext) response.Response {
 	success, err := hs.secretsMigrator.ReEncryptSecrets(c.Req.Context())
 	if err != nil {
+		if errors.Is(err, secrets.ErrReEncryptionJobInProgress) {
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
 		return response.Error(http.StatusInternalServerError, "Failed to re-encrypt secrets", err)
 	}
 
@@ -38,6 +55,37 @@ func (hs *HTTPServer) AdminReEncryptSecrets(c *contextmodel.ReqContext) response
 	return response.Respond(http.StatusOK, "Secrets re-encrypted successfully")
 }
 
+func (hs *HTTPServer) AdminStartReEncryptSecretsJob(c *contextmodel.ReqContext) response.Response {
+	if err := hs.secretsMigrator.StartReEncryptionJob(c.Req.Context()); err != nil {
+		if errors.Is(err, secrets.ErrReEncryptionJobInProgress) {
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to start secrets re-encryption job", err)
+	}
+
+	return response.JSON(http.StatusAccepted, hs.secretsMigrator.ReEncryptionJobStatus())
+}
+
+func (hs *HTTPServer) AdminPauseReEncryptSecretsJob(c *contextmodel.ReqContext) response.Response {
+	if err := hs.secretsMigrator.PauseReEncryptionJob(); err != nil {
+		return response.Error(http.StatusConflict, err.Error(), err)
+	}
+
+	return response.JSON(http.StatusOK, hs.secretsMigrator.ReEncryptionJobStatus())
+}
+
+func (hs *HTTPServer) AdminResumeReEncryptSecretsJob(c *contextmodel.ReqContext) response.Response {
+	if err := hs.secretsMigrator.ResumeReEncryptionJob(); err != nil {
+		return response.Error(http.StatusConflict, err.Error(), err)
+	}
+
+	return response.JSON(http.StatusOK, hs.secretsMigrator.ReEncryptionJobStatus())
+}
+
+func (hs *HTTPServer) AdminGetReEncryptSecretsJobStatus(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.secretsMigrator.ReEncryptionJobStatus())
+}
+
 func (hs *HTTPServer) AdminRollbackSecrets(c *contextmodel.ReqContext) response.Response {
 	success, err := hs.secretsMigrator.RollBackSecrets(c.Req.Context())
 	if err != nil {
@@ -434,6 +434,25 @@ func (hs *HTTPServer) registerRoutes() {
 				featuremgmtRoute.Post("/", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), hs.UpdateFeatureToggle)
 			})
 		}
+		apiRoute.Group("/featuremgmt/runtime", func(runtimeRoute routing.RouteRegister) {
+			runtimeRoute.Get("/", authorize(ac.EvalPermission(ac.ActionFeatureManagementRead)), routing.Wrap(hs.GetRuntimeFeatureToggles))
+			runtimeRoute.Get("/:name", authorize(ac.EvalPermission(ac.ActionFeatureManagementRead)), routing.Wrap(hs.GetRuntimeFeatureToggle))
+			runtimeRoute.Put("/:name", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.SetRuntimeFeatureToggle))
+			runtimeRoute.Delete("/:name", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.ClearRuntimeFeatureToggle))
+			runtimeRoute.Post("/:name/request", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.RequestFeatureToggleChange))
+		})
+		apiRoute.Get("/featuremgmt/stale", authorize(ac.EvalPermission(ac.ActionFeatureManagementRead)), routing.Wrap(hs.GetStaleFeatureToggles))
+		apiRoute.Group("/featuremgmt/pending", func(pendingRoute routing.RouteRegister) {
+			pendingRoute.Get("/", authorize(ac.EvalPermission(ac.ActionFeatureManagementRead)), routing.Wrap(hs.GetPendingFeatureToggleChanges))
+			pendingRoute.Delete("/:name", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.CancelPendingFeatureToggleChange))
+		})
+		apiRoute.Group("/featuremgmt/snapshots", func(snapshotRoute routing.RouteRegister) {
+			snapshotRoute.Get("/", authorize(ac.EvalPermission(ac.ActionFeatureManagementRead)), routing.Wrap(hs.GetFeatureToggleSnapshots))
+			snapshotRoute.Post("/", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.CreateFeatureToggleSnapshot))
+			snapshotRoute.Get("/:name", authorize(ac.EvalPermission(ac.ActionFeatureManagementRead)), routing.Wrap(hs.GetFeatureToggleSnapshot))
+			snapshotRoute.Delete("/:name", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.DeleteFeatureToggleSnapshot))
+			snapshotRoute.Post("/:name/restore", authorize(ac.EvalPermission(ac.ActionFeatureManagementWrite)), routing.Wrap(hs.RestoreFeatureToggleSnapshot))
+		})
 
 		apiRoute.Get("/frontend/settings/", hs.GetFrontendSettings)
 		apiRoute.Any("/datasources/proxy/:id/*", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), authorize(ac.EvalPermission(datasources.ActionQuery)), hs.ProxyDataSourceRequest)
@@ -464,6 +483,8 @@ func (hs *HTTPServer) registerRoutes() {
 				folderUidRoute.Post("/move", authorize(ac.EvalPermission(dashboards.ActionFoldersWrite, uidScope)), routing.Wrap(hs.MoveFolder))
 				folderUidRoute.Delete("/", authorize(ac.EvalPermission(dashboards.ActionFoldersDelete, uidScope)), routing.Wrap(hs.DeleteFolder))
 				folderUidRoute.Get("/counts", authorize(ac.EvalPermission(dashboards.ActionFoldersRead, uidScope)), routing.Wrap(hs.GetFolderDescendantCounts))
+				folderUidRoute.Get("/quotas", authorize(ac.EvalAll(ac.EvalPermission(dashboards.ActionFoldersRead, uidScope), ac.EvalPermission(ac.ActionOrgsQuotasRead))), routing.Wrap(hs.GetFolderQuotas))
+				folderUidRoute.Put("/quotas/:target", authorize(ac.EvalAll(ac.EvalPermission(dashboards.ActionFoldersRead, uidScope), ac.EvalPermission(ac.ActionOrgsQuotasWrite))), routing.Wrap(hs.UpdateFolderQuota))
 
 				folderUidRoute.Group("/permissions", func(folderPermissionRoute routing.RouteRegister) {
 					folderPermissionRoute.Get("/", authorize(ac.EvalPermission(dashboards.ActionFoldersPermissionsRead, uidScope)), routing.Wrap(hs.GetFolderPermissionList))
@@ -608,11 +629,17 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Get("/settings", authorize(ac.EvalPermission(ac.ActionSettingsRead)), routing.Wrap(hs.AdminGetSettings))
 		adminRoute.Get("/settings-verbose", authorize(ac.EvalPermission(ac.ActionSettingsRead)), routing.Wrap(hs.AdminGetVerboseSettings))
 		adminRoute.Get("/stats", authorize(ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(hs.AdminGetStats))
+		adminRoute.Get("/quotas/usage", authorize(ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(hs.GetQuotaUsageReport))
 		adminRoute.Post("/pause-all-alerts", reqGrafanaAdmin, routing.Wrap(hs.PauseAllAlerts(setting.AlertingEnabled)))
 
 		adminRoute.Post("/encryption/rotate-data-keys", reqGrafanaAdmin, routing.Wrap(hs.AdminRotateDataEncryptionKeys))
+		adminRoute.Get("/encryption/retired-data-keys", reqGrafanaAdmin, routing.Wrap(hs.AdminGetRetiredDataEncryptionKeys))
 		adminRoute.Post("/encryption/reencrypt-data-keys", reqGrafanaAdmin, routing.Wrap(hs.AdminReEncryptEncryptionKeys))
 		adminRoute.Post("/encryption/reencrypt-secrets", reqGrafanaAdmin, routing.Wrap(hs.AdminReEncryptSecrets))
+		adminRoute.Get("/encryption/reencrypt-secrets/job", reqGrafanaAdmin, routing.Wrap(hs.AdminGetReEncryptSecretsJobStatus))
+		adminRoute.Post("/encryption/reencrypt-secrets/job", reqGrafanaAdmin, routing.Wrap(hs.AdminStartReEncryptSecretsJob))
+		adminRoute.Post("/encryption/reencrypt-secrets/job/pause", reqGrafanaAdmin, routing.Wrap(hs.AdminPauseReEncryptSecretsJob))
+		adminRoute.Post("/encryption/reencrypt-secrets/job/resume", reqGrafanaAdmin, routing.Wrap(hs.AdminResumeReEncryptSecretsJob))
 		adminRoute.Post("/encryption/rollback-secrets", reqGrafanaAdmin, routing.Wrap(hs.AdminRollbackSecrets))
 		adminRoute.Post("/encryption/migrate-secrets/to-plugin", reqGrafanaAdmin, routing.Wrap(hs.AdminMigrateSecretsToPlugin))
 		adminRoute.Post("/encryption/migrate-secrets/from-plugin", reqGrafanaAdmin, routing.Wrap(hs.AdminMigrateSecretsFromPlugin))
@@ -186,6 +186,9 @@ type FrontendSettingsDTO struct {
 	LicenseInfo FrontendSettingsLicenseInfoDTO `json:"licenseInfo"`
 
 	FeatureToggles                   map[string]bool                `json:"featureToggles"`
+	FeatureToggleConfigs             map[string]map[string]string   `json:"featureToggleConfigs"`
+	ExperimentVariants               map[string]string              `json:"experimentVariants"`
+	FeatureTogglesVersion            int64                          `json:"featureTogglesVersion"`
 	AnonymousEnabled                 bool                           `json:"anonymousEnabled"`
 	RendererAvailable                bool                           `json:"rendererAvailable"`
 	RendererVersion                  string                         `json:"rendererVersion"`
@@ -3,10 +3,13 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -19,20 +22,30 @@ import (
 func (hs *HTTPServer) GetFeatureToggles(ctx *contextmodel.ReqContext) response.Response {
 	cfg := hs.Cfg.FeatureManagement
 	enabledFeatures := hs.Features.GetEnabled(ctx.Req.Context())
+	filter, err := parseFeatureToggleFilter(ctx)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
 
 	// object being returned
 	dtos := make([]featuremgmt.FeatureToggleDTO, 0)
 
 	// loop through features an add features that should be visible to dtos
 	for _, ft := range hs.Features.GetFlags() {
-		if isFeatureHidden(ft, cfg.HiddenToggles) {
+		if isFeatureHidden(ft, cfg.HiddenToggles) || !filter.matches(ft, enabledFeatures[ft.Name]) {
 			continue
 		}
 		dto := featuremgmt.FeatureToggleDTO{
-			Name:        ft.Name,
-			Description: ft.Description,
-			Enabled:     enabledFeatures[ft.Name],
-			ReadOnly:    !isFeatureWriteable(ft, cfg.ReadOnlyToggles) || !isFeatureEditingAllowed(*hs.Cfg),
+			Name:         ft.Name,
+			Description:  ft.Description,
+			Enabled:      enabledFeatures[ft.Name],
+			ReadOnly:     !isFeatureWriteable(ft, cfg.ReadOnlyToggles) || !isFeatureEditingAllowed(*hs.Cfg),
+			Owner:        string(ft.Owner),
+			FrontendOnly: ft.FrontendOnly,
+
+			Deprecated:     ft.IsDeprecated(),
+			RemovalVersion: ft.RemovalVersion,
+			ReplacedBy:     ft.ReplacedBy,
 		}
 
 		dtos = append(dtos, dto)
@@ -81,10 +94,395 @@ func (hs *HTTPServer) UpdateFeatureToggle(ctx *contextmodel.ReqContext) response
 	return response.Respond(http.StatusOK, "feature toggles updated successfully")
 }
 
+// GetRuntimeFeatureToggles returns the state of every feature toggle that is not hidden, and whether it can be changed
+// at runtime.
+func (hs *HTTPServer) GetRuntimeFeatureToggles(ctx *contextmodel.ReqContext) response.Response {
+	cfg := hs.Cfg.FeatureManagement
+	filter, err := parseFeatureToggleFilter(ctx)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	dtos := make([]featuremgmt.RuntimeFeatureToggleDTO, 0)
+	for _, state := range hs.Features.GetRuntimeStates() {
+		if isRuntimeFeatureHidden(state.FeatureFlag, cfg.HiddenToggles) || !filter.matches(state.FeatureFlag, state.Enabled) {
+			continue
+		}
+		dtos = append(dtos, hs.runtimeFeatureToggleDTO(state))
+	}
+	sort.Slice(dtos, func(i, j int) bool { return dtos[i].Name < dtos[j].Name })
+	return response.JSON(http.StatusOK, dtos)
+}
+
+// GetRuntimeFeatureToggle returns the state of a feature toggle.
+func (hs *HTTPServer) GetRuntimeFeatureToggle(ctx *contextmodel.ReqContext) response.Response {
+	name := web.Params(ctx.Req)[":name"]
+	state, ok := hs.Features.GetRuntimeState(name)
+	if !ok || isRuntimeFeatureHidden(state.FeatureFlag, hs.Cfg.FeatureManagement.HiddenToggles) {
+		return response.Error(http.StatusNotFound, "feature toggle not found", nil)
+	}
+	return response.JSON(http.StatusOK, hs.runtimeFeatureToggleDTO(state))
+}
+
+// SetRuntimeFeatureToggle enables or disables a feature toggle without restarting the server. Toggles that require a
+// restart, and toggles that are read-only, cannot be changed.
+func (hs *HTTPServer) SetRuntimeFeatureToggle(ctx *contextmodel.ReqContext) response.Response {
+	cfg := hs.Cfg.FeatureManagement
+	if !cfg.AllowEditing {
+		return response.Error(http.StatusForbidden, "feature toggles are read-only", fmt.Errorf("feature toggles are configured to be read-only"))
+	}
+
+	cmd := featuremgmt.SetRuntimeFeatureToggleCommand{}
+	if err := web.Bind(ctx.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	name := web.Params(ctx.Req)[":name"]
+	flag, ok := hs.Features.LookupFlag(name)
+	if !ok || isRuntimeFeatureHidden(flag, cfg.HiddenToggles) {
+		return response.Error(http.StatusNotFound, "feature toggle not found", nil)
+	}
+	if isFeatureReadOnly(flag, cfg.ReadOnlyToggles) {
+		return response.Error(http.StatusForbidden, "feature toggle is read-only", fmt.Errorf("feature toggle %s is read-only", name))
+	}
+
+	if err := hs.Features.SetRuntimeState(ctx.Req.Context(), name, cmd.Enabled); err != nil {
+		switch {
+		case errors.Is(err, featuremgmt.ErrFeatureToggleNotFound), errors.Is(err, featuremgmt.ErrFeatureToggleInternal):
+			return response.Error(http.StatusNotFound, "feature toggle not found", err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleRequiresRestart), errors.Is(err, featuremgmt.ErrFeatureToggleUnavailable):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleConflict):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to update feature toggle", err)
+	}
+	hs.log.Info("SetRuntimeFeatureToggle: updated toggle", "toggle_name", name, "enabled", cmd.Enabled, "username", ctx.SignedInUser.Login)
+
+	state, _ := hs.Features.GetRuntimeState(name)
+	return response.JSON(http.StatusOK, hs.runtimeFeatureToggleDTO(state))
+}
+
+// ClearRuntimeFeatureToggle removes the state of a feature toggle changed at runtime, so that it follows its
+// configuration again.
+func (hs *HTTPServer) ClearRuntimeFeatureToggle(ctx *contextmodel.ReqContext) response.Response {
+	cfg := hs.Cfg.FeatureManagement
+	if !cfg.AllowEditing {
+		return response.Error(http.StatusForbidden, "feature toggles are read-only", fmt.Errorf("feature toggles are configured to be read-only"))
+	}
+
+	name := web.Params(ctx.Req)[":name"]
+	flag, ok := hs.Features.LookupFlag(name)
+	if !ok || isRuntimeFeatureHidden(flag, cfg.HiddenToggles) {
+		return response.Error(http.StatusNotFound, "feature toggle not found", nil)
+	}
+	if isFeatureReadOnly(flag, cfg.ReadOnlyToggles) {
+		return response.Error(http.StatusForbidden, "feature toggle is read-only", fmt.Errorf("feature toggle %s is read-only", name))
+	}
+
+	if err := hs.Features.ClearRuntimeState(ctx.Req.Context(), name); err != nil {
+		switch {
+		case errors.Is(err, featuremgmt.ErrFeatureToggleNotFound), errors.Is(err, featuremgmt.ErrFeatureToggleInternal):
+			return response.Error(http.StatusNotFound, "feature toggle not found", err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleRequiresRestart):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleConflict):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to clear feature toggle", err)
+	}
+	hs.log.Info("ClearRuntimeFeatureToggle: cleared toggle", "toggle_name", name, "username", ctx.SignedInUser.Login)
+
+	state, _ := hs.Features.GetRuntimeState(name)
+	return response.JSON(http.StatusOK, hs.runtimeFeatureToggleDTO(state))
+}
+
+// RequestFeatureToggleChange changes a feature toggle at runtime, or queues the change until the next restart if the
+// toggle requires one. Queued changes are sent to the update webhook, if configured, to be applied to the
+// configuration.
+func (hs *HTTPServer) RequestFeatureToggleChange(ctx *contextmodel.ReqContext) response.Response {
+	cfg := hs.Cfg.FeatureManagement
+	if !cfg.AllowEditing {
+		return response.Error(http.StatusForbidden, "feature toggles are read-only", fmt.Errorf("feature toggles are configured to be read-only"))
+	}
+
+	cmd := featuremgmt.SetRuntimeFeatureToggleCommand{}
+	if err := web.Bind(ctx.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	name := web.Params(ctx.Req)[":name"]
+	flag, ok := hs.Features.LookupFlag(name)
+	if !ok || isRuntimeFeatureHidden(flag, cfg.HiddenToggles) {
+		return response.Error(http.StatusNotFound, "feature toggle not found", nil)
+	}
+	if isFeatureReadOnly(flag, cfg.ReadOnlyToggles) {
+		return response.Error(http.StatusForbidden, "feature toggle is read-only", fmt.Errorf("feature toggle %s is read-only", name))
+	}
+
+	queued, err := hs.Features.RequestChange(ctx.Req.Context(), name, cmd.Enabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, featuremgmt.ErrFeatureToggleNotFound), errors.Is(err, featuremgmt.ErrFeatureToggleInternal):
+			return response.Error(http.StatusNotFound, "feature toggle not found", err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleRequiresRestart), errors.Is(err, featuremgmt.ErrFeatureToggleUnavailable):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleConflict):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to update feature toggle", err)
+	}
+
+	state, _ := hs.Features.GetRuntimeState(name)
+	if !queued {
+		hs.log.Info("RequestFeatureToggleChange: updated toggle", "toggle_name", name, "enabled", cmd.Enabled, "username", ctx.SignedInUser.Login)
+		return response.JSON(http.StatusOK, hs.runtimeFeatureToggleDTO(state))
+	}
+
+	hs.log.Info("RequestFeatureToggleChange: queued toggle change until restart", "toggle_name", name, "enabled", cmd.Enabled, "username", ctx.SignedInUser.Login)
+	if cfg.UpdateWebhook != "" {
+		payload := UpdatePayload{
+			FeatureToggles: map[string]string{name: strconv.FormatBool(cmd.Enabled)},
+			User:           ctx.SignedInUser.Email,
+		}
+		if err := sendWebhookUpdate(cfg, payload, hs.log); err != nil {
+			hs.log.Error("RequestFeatureToggleChange: Failed to perform webhook request", "error", err)
+		}
+	}
+	return response.JSON(http.StatusAccepted, hs.runtimeFeatureToggleDTO(state))
+}
+
+// GetStaleFeatureToggles returns the toggles past their stability window in this version of Grafana, and where they
+// are configured, so their owners graduate or remove them.
+func (hs *HTTPServer) GetStaleFeatureToggles(ctx *contextmodel.ReqContext) response.Response {
+	cfg := hs.Cfg.FeatureManagement
+	stale, err := hs.Features.GetStaleToggles(hs.Cfg.BuildVersion, featuremgmt.StaleToggleWindows{
+		Experimental: cfg.StaleExperimentalReleases,
+		Preview:      cfg.StalePreviewReleases,
+	})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to find stale feature toggles", err)
+	}
+	toggles := make([]featuremgmt.StaleToggle, 0, len(stale))
+	for _, toggle := range stale {
+		if !hs.isRuntimeFeatureNameHidden(toggle.Name) {
+			toggles = append(toggles, toggle)
+		}
+	}
+	return response.JSON(http.StatusOK, toggles)
+}
+
+// GetPendingFeatureToggleChanges returns the changes of feature toggles queued until the next restart.
+func (hs *HTTPServer) GetPendingFeatureToggleChanges(ctx *contextmodel.ReqContext) response.Response {
+	changes := make([]featuremgmt.PendingToggleChange, 0)
+	for _, change := range hs.Features.GetPendingChanges() {
+		if !hs.isRuntimeFeatureNameHidden(change.Name) {
+			changes = append(changes, change)
+		}
+	}
+	return response.JSON(http.StatusOK, changes)
+}
+
+// CancelPendingFeatureToggleChange removes the change of a feature toggle queued until the next restart.
+func (hs *HTTPServer) CancelPendingFeatureToggleChange(ctx *contextmodel.ReqContext) response.Response {
+	if err := hs.Features.CancelPendingChange(ctx.Req.Context(), web.Params(ctx.Req)[":name"]); err != nil {
+		switch {
+		case errors.Is(err, featuremgmt.ErrNoPendingToggleChange):
+			return response.Error(http.StatusNotFound, err.Error(), err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleConflict):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to cancel feature toggle change", err)
+	}
+	return response.Success("Feature toggle change cancelled")
+}
+
+// GetFeatureToggleSnapshots returns the saved snapshots of the state of the toggles.
+func (hs *HTTPServer) GetFeatureToggleSnapshots(ctx *contextmodel.ReqContext) response.Response {
+	snapshots, err := hs.Features.GetSnapshots(ctx.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to get feature toggle snapshots", err)
+	}
+	for i := range snapshots {
+		snapshots[i] = hs.visibleSnapshot(snapshots[i])
+	}
+	return response.JSON(http.StatusOK, snapshots)
+}
+
+// GetFeatureToggleSnapshot returns a saved snapshot of the state of the toggles.
+func (hs *HTTPServer) GetFeatureToggleSnapshot(ctx *contextmodel.ReqContext) response.Response {
+	snapshot, err := hs.Features.GetSnapshot(ctx.Req.Context(), web.Params(ctx.Req)[":name"])
+	if err != nil {
+		if errors.Is(err, featuremgmt.ErrToggleSnapshotNotFound) {
+			return response.Error(http.StatusNotFound, "feature toggle snapshot not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to get feature toggle snapshot", err)
+	}
+	return response.JSON(http.StatusOK, hs.visibleSnapshot(snapshot))
+}
+
+// CreateFeatureToggleSnapshot saves the current state of the toggles under a name.
+func (hs *HTTPServer) CreateFeatureToggleSnapshot(ctx *contextmodel.ReqContext) response.Response {
+	cmd := featuremgmt.CreateToggleSnapshotCommand{}
+	if err := web.Bind(ctx.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	snapshot, err := hs.Features.SaveSnapshot(ctx.Req.Context(), cmd.Name)
+	if err != nil {
+		if errors.Is(err, featuremgmt.ErrToggleSnapshotExists) {
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to save feature toggle snapshot", err)
+	}
+	hs.log.Info("CreateFeatureToggleSnapshot: saved snapshot", "snapshot", cmd.Name, "username", ctx.SignedInUser.Login)
+	return response.JSON(http.StatusOK, hs.visibleSnapshot(snapshot))
+}
+
+// DeleteFeatureToggleSnapshot deletes a saved snapshot of the state of the toggles.
+func (hs *HTTPServer) DeleteFeatureToggleSnapshot(ctx *contextmodel.ReqContext) response.Response {
+	name := web.Params(ctx.Req)[":name"]
+	if err := hs.Features.DeleteSnapshot(ctx.Req.Context(), name); err != nil {
+		if errors.Is(err, featuremgmt.ErrToggleSnapshotNotFound) {
+			return response.Error(http.StatusNotFound, "feature toggle snapshot not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to delete feature toggle snapshot", err)
+	}
+	return response.Success("Feature toggle snapshot deleted")
+}
+
+// RestoreFeatureToggleSnapshot sets the toggles back to the states of a snapshot in one operation. Like the runtime
+// changes, it requires editing to be allowed.
+func (hs *HTTPServer) RestoreFeatureToggleSnapshot(ctx *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.FeatureManagement.AllowEditing {
+		return response.Error(http.StatusForbidden, "feature toggles are read-only", fmt.Errorf("feature toggles are configured to be read-only"))
+	}
+
+	name := web.Params(ctx.Req)[":name"]
+	changes, err := hs.Features.RestoreSnapshot(ctx.Req.Context(), name)
+	if err != nil {
+		switch {
+		case errors.Is(err, featuremgmt.ErrToggleSnapshotNotFound):
+			return response.Error(http.StatusNotFound, "feature toggle snapshot not found", err)
+		case errors.Is(err, featuremgmt.ErrFeatureToggleConflict):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "failed to restore feature toggle snapshot", err)
+	}
+	hs.log.Info("RestoreFeatureToggleSnapshot: restored snapshot", "snapshot", name, "changes", len(changes), "username", ctx.SignedInUser.Login)
+	if changes == nil {
+		changes = []featuremgmt.ToggleChange{}
+	}
+	return response.JSON(http.StatusOK, featuremgmt.RestoreToggleSnapshotResponse{Changes: changes})
+}
+
+// visibleSnapshot returns a copy of the snapshot without the hidden toggles.
+func (hs *HTTPServer) visibleSnapshot(snapshot featuremgmt.ToggleSnapshot) featuremgmt.ToggleSnapshot {
+	states := make(map[string]bool, len(snapshot.States))
+	for name, enabled := range snapshot.States {
+		if !hs.isRuntimeFeatureNameHidden(name) {
+			states[name] = enabled
+		}
+	}
+	snapshot.States = states
+	return snapshot
+}
+
+func (hs *HTTPServer) runtimeFeatureToggleDTO(state featuremgmt.RuntimeToggleState) featuremgmt.RuntimeFeatureToggleDTO {
+	cfg := hs.Cfg.FeatureManagement
+	return featuremgmt.RuntimeFeatureToggleDTO{
+		Name:            state.Name,
+		Description:     state.Description,
+		Stage:           state.Stage,
+		Enabled:         state.Enabled,
+		RequiresRestart: state.RequiresRestart,
+		Changed:         state.Changed,
+		Pending:         state.Pending,
+		Config:          state.Config,
+		ReadOnly:        !cfg.AllowEditing || state.RequiresRestart || isFeatureReadOnly(state.FeatureFlag, cfg.ReadOnlyToggles),
+		Deprecated:      state.IsDeprecated(),
+		RemovalVersion:  state.RemovalVersion,
+		ReplacedBy:      state.ReplacedBy,
+		Owner:           string(state.Owner),
+		FrontendOnly:    state.FrontendOnly,
+	}
+}
+
+// featureToggleFilter filters the toggle listings by the owner, state and frontend query parameters.
+type featureToggleFilter struct {
+	owner    string
+	enabled  *bool
+	frontend *bool
+}
+
+func parseFeatureToggleFilter(ctx *contextmodel.ReqContext) (featureToggleFilter, error) {
+	filter := featureToggleFilter{owner: strings.TrimSpace(ctx.Query("owner"))}
+	switch state := ctx.Query("state"); state {
+	case "":
+	case "enabled", "disabled":
+		enabled := state == "enabled"
+		filter.enabled = &enabled
+	default:
+		return filter, fmt.Errorf("invalid state %q, expected enabled or disabled", state)
+	}
+	if v := ctx.Query("frontend"); v != "" {
+		frontend, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid frontend %q, expected true or false", v)
+		}
+		filter.frontend = &frontend
+	}
+	return filter, nil
+}
+
+// matches checks if the toggle matches the filter. The owner matches with or without the @grafana/ prefix of the
+// squads.
+func (f featureToggleFilter) matches(flag featuremgmt.FeatureFlag, enabled bool) bool {
+	if f.owner != "" {
+		owner := string(flag.Owner)
+		if !strings.EqualFold(owner, f.owner) && !strings.EqualFold(strings.TrimPrefix(owner, "@grafana/"), f.owner) {
+			return false
+		}
+	}
+	if f.enabled != nil && *f.enabled != enabled {
+		return false
+	}
+	if f.frontend != nil && *f.frontend != flag.FrontendOnly {
+		return false
+	}
+	return true
+}
+
+// isFeatureReadOnly returns whether a toggle cannot be changed at runtime because of the configuration. Unlike
+// isFeatureWriteable it allows toggles of every stage, as they are changed by operators.
+func isFeatureReadOnly(flag featuremgmt.FeatureFlag, readOnlyCfg map[string]struct{}) bool {
+	if _, ok := readOnlyCfg[flag.Name]; ok {
+		return true
+	}
+	return flag.Name == featuremgmt.FlagFeatureToggleAdminPage
+}
+
+// isRuntimeFeatureHidden returns whether a toggle is hidden from the runtime toggles API, either by the configuration
+// or because it is internal, as internal toggles can only be configured in the configuration files.
+func isRuntimeFeatureHidden(flag featuremgmt.FeatureFlag, hideCfg map[string]struct{}) bool {
+	if _, ok := hideCfg[flag.Name]; ok {
+		return true
+	}
+	return flag.Internal
+}
+
+// isRuntimeFeatureNameHidden is isRuntimeFeatureHidden for toggles known by name only.
+func (hs *HTTPServer) isRuntimeFeatureNameHidden(name string) bool {
+	flag, ok := hs.Features.LookupFlag(name)
+	if !ok {
+		flag = featuremgmt.FeatureFlag{Name: name}
+	}
+	return isRuntimeFeatureHidden(flag, hs.Cfg.FeatureManagement.HiddenToggles)
+}
+
 // isFeatureHidden returns whether a toggle should be hidden from the admin page.
 // filters out statuses Unknown, Experimental, and Private Preview
 func isFeatureHidden(flag featuremgmt.FeatureFlag, hideCfg map[string]struct{}) bool {
-	if _, ok := hideCfg[flag.Name]; ok {
+	if _, ok := hideCfg[flag.Name]; ok || flag.Internal {
 		return true
 	}
 	return flag.Stage == featuremgmt.FeatureStageUnknown || flag.Stage == featuremgmt.FeatureStageExperimental || flag.Stage == featuremgmt.FeatureStagePrivatePreview
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -50,6 +51,30 @@ func TestGetFeatureToggles(t *testing.T) {
 		assert.False(t, t2.Enabled)
 	})
 
+	t.Run("deprecated toggles have their lifecycle in the response", func(t *testing.T) {
+		features := []*featuremgmt.FeatureFlag{
+			{
+				Name:    "toggle1",
+				Enabled: true,
+				Stage:   featuremgmt.FeatureStageGeneralAvailability,
+			}, {
+				Name:           "toggle2",
+				Enabled:        true,
+				Stage:          featuremgmt.FeatureStageDeprecated,
+				RemovalVersion: "11.0.0",
+				ReplacedBy:     "toggle1",
+			},
+		}
+
+		result := runGetScenario(t, features, setting.FeatureMgmtSettings{}, readPermissions, http.StatusOK)
+		t1, _ := findResult(t, result, "toggle1")
+		assert.False(t, t1.Deprecated)
+		t2, _ := findResult(t, result, "toggle2")
+		assert.True(t, t2.Deprecated)
+		assert.Equal(t, "11.0.0", t2.RemovalVersion)
+		assert.Equal(t, "toggle1", t2.ReplacedBy)
+	})
+
 	t.Run("toggles hidden by config are not present in the response", func(t *testing.T) {
 		features := []*featuremgmt.FeatureFlag{
 			{
@@ -71,6 +96,25 @@ func TestGetFeatureToggles(t *testing.T) {
 		assert.Equal(t, "toggle2", result[0].Name)
 	})
 
+	t.Run("internal toggles are not present in the response", func(t *testing.T) {
+		features := []*featuremgmt.FeatureFlag{
+			{
+				Name:     "toggle1",
+				Enabled:  true,
+				Stage:    featuremgmt.FeatureStageGeneralAvailability,
+				Internal: true,
+			}, {
+				Name:    "toggle2",
+				Enabled: false,
+				Stage:   featuremgmt.FeatureStageGeneralAvailability,
+			},
+		}
+
+		result := runGetScenario(t, features, setting.FeatureMgmtSettings{}, readPermissions, http.StatusOK)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "toggle2", result[0].Name)
+	})
+
 	t.Run("toggles that are read-only by config have the readOnly field set", func(t *testing.T) {
 		features := []*featuremgmt.FeatureFlag{
 			{
@@ -365,6 +409,380 @@ func TestSetFeatureToggles(t *testing.T) {
 	})
 }
 
+// persistentToggleStore stands for a runtime store that keeps the states across restarts, so changes can be queued.
+type persistentToggleStore struct {
+	featuremgmt.RuntimeToggleStore
+}
+
+// conflictingToggleStore rejects every change as made on another instance.
+type conflictingToggleStore struct {
+	featuremgmt.RuntimeToggleStore
+}
+
+func (conflictingToggleStore) Set(context.Context, string, bool) error {
+	return featuremgmt.ErrFeatureToggleConflict
+}
+
+func TestRuntimeFeatureToggles(t *testing.T) {
+	readPermissions := []accesscontrol.Permission{{Action: accesscontrol.ActionFeatureManagementRead}}
+	writePermissions := []accesscontrol.Permission{{Action: accesscontrol.ActionFeatureManagementWrite}}
+	features := []*featuremgmt.FeatureFlag{
+		{Name: "toggle1", Stage: featuremgmt.FeatureStageExperimental, Owner: "@grafana/squad-a", FrontendOnly: true, RuntimeMutable: true},
+		{Name: "toggle2", Enabled: true, Stage: featuremgmt.FeatureStageGeneralAvailability, RequiresRestart: true, Owner: "@grafana/squad-a"},
+		{Name: "toggle3", Stage: featuremgmt.FeatureStageGeneralAvailability},
+		{Name: "toggle4", Stage: featuremgmt.FeatureStagePublicPreview, RuntimeMutable: true},
+		{Name: "toggle5", Stage: featuremgmt.FeatureStageGeneralAvailability, Internal: true},
+		{Name: "toggle6", Stage: featuremgmt.FeatureStageGeneralAvailability},
+	}
+	settings := setting.FeatureMgmtSettings{
+		AllowEditing:    true,
+		HiddenToggles:   map[string]struct{}{"toggle3": {}},
+		ReadOnlyToggles: map[string]struct{}{"toggle4": {}},
+	}
+
+	setupServer := func(t *testing.T, settings setting.FeatureMgmtSettings) *webtest.Server {
+		cfg := setting.NewCfg()
+		cfg.FeatureManagement = settings
+		return SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = cfg
+			hs.Features = featuremgmt.WithFeatureFlags(features)
+			hs.orgService = orgtest.NewOrgServiceFake()
+			hs.userService = &usertest.FakeUserService{
+				ExpectedUser: &user.User{ID: 1},
+			}
+			hs.log = log.New("test")
+		})
+	}
+	send := func(t *testing.T, server *webtest.Server, req *http.Request, permissions []accesscontrol.Permission, expectedCode int, result any) {
+		res, err := server.SendJSON(webtest.RequestWithSignedInUser(req, userWithPermissions(1, permissions)))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		require.Equal(t, expectedCode, res.StatusCode)
+		if result != nil {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(result))
+		}
+	}
+	put := func(server *webtest.Server, name string, enabled bool) *http.Request {
+		b, _ := json.Marshal(featuremgmt.SetRuntimeFeatureToggleCommand{Enabled: enabled})
+		req := server.NewRequest(http.MethodPut, "/api/featuremgmt/runtime/"+name, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("should not list toggles without permissions", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime"), []accesscontrol.Permission{}, http.StatusForbidden, nil)
+	})
+
+	t.Run("should list toggles that are not hidden", func(t *testing.T) {
+		server := setupServer(t, settings)
+		var result []featuremgmt.RuntimeFeatureToggleDTO
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime"), readPermissions, http.StatusOK, &result)
+		require.Len(t, result, 4)
+		assert.Equal(t, "toggle1", result[0].Name)
+		assert.False(t, result[0].ReadOnly)
+		assert.Equal(t, "toggle2", result[1].Name)
+		assert.True(t, result[1].Enabled)
+		assert.True(t, result[1].ReadOnly)
+		assert.Equal(t, "toggle4", result[2].Name)
+		assert.True(t, result[2].ReadOnly)
+		// Toggles that do not opt in to runtime changes require a restart
+		assert.Equal(t, "toggle6", result[3].Name)
+		assert.True(t, result[3].RequiresRestart)
+		assert.True(t, result[3].ReadOnly)
+	})
+
+	t.Run("should filter toggles by owner, state and frontend", func(t *testing.T) {
+		server := setupServer(t, settings)
+		list := func(query string) []string {
+			var result []featuremgmt.RuntimeFeatureToggleDTO
+			send(t, server, server.NewGetRequest("/api/featuremgmt/runtime?"+query), readPermissions, http.StatusOK, &result)
+			names := make([]string, 0, len(result))
+			for _, r := range result {
+				names = append(names, r.Name)
+			}
+			return names
+		}
+		assert.Equal(t, []string{"toggle1", "toggle2"}, list("owner=squad-a"))
+		assert.Equal(t, []string{"toggle1", "toggle2"}, list("owner=@grafana/squad-a"))
+		assert.Equal(t, []string{"toggle2"}, list("owner=squad-a&state=enabled"))
+		assert.Equal(t, []string{"toggle1", "toggle4", "toggle6"}, list("state=disabled"))
+		assert.Equal(t, []string{"toggle1"}, list("frontend=true"))
+		assert.Empty(t, list("owner=squad-b"))
+
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime?state=on"), readPermissions, http.StatusBadRequest, nil)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime?frontend=maybe"), readPermissions, http.StatusBadRequest, nil)
+	})
+
+	t.Run("should not get hidden, internal or unknown toggles", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime/toggle3"), readPermissions, http.StatusNotFound, nil)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime/toggle5"), readPermissions, http.StatusNotFound, nil)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime/unknown"), readPermissions, http.StatusNotFound, nil)
+	})
+
+	t.Run("should update toggles at runtime", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, put(server, "toggle1", true), readPermissions, http.StatusForbidden, nil)
+
+		var result featuremgmt.RuntimeFeatureToggleDTO
+		send(t, server, put(server, "toggle1", true), writePermissions, http.StatusOK, &result)
+		assert.True(t, result.Enabled)
+		assert.True(t, result.Changed)
+
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime/toggle1"), readPermissions, http.StatusOK, &result)
+		assert.True(t, result.Enabled)
+	})
+
+	t.Run("should clear toggles changed at runtime", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, put(server, "toggle1", true), writePermissions, http.StatusOK, nil)
+
+		var result featuremgmt.RuntimeFeatureToggleDTO
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/runtime/toggle1", nil), writePermissions, http.StatusOK, &result)
+		assert.False(t, result.Enabled)
+		assert.False(t, result.Changed)
+
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/runtime/toggle2", nil), writePermissions, http.StatusBadRequest, nil)
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/runtime/toggle3", nil), writePermissions, http.StatusNotFound, nil)
+	})
+
+	t.Run("should return a conflict if the toggle was changed on another instance", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.FeatureManagement = settings
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = cfg
+			hs.Features = featuremgmt.WithFeatureFlags(features)
+			require.NoError(t, hs.Features.SetRuntimeStore(context.Background(), conflictingToggleStore{featuremgmt.NewMemoryToggleStore()}))
+			hs.log = log.New("test")
+		})
+		send(t, server, put(server, "toggle1", true), writePermissions, http.StatusConflict, nil)
+
+		var result featuremgmt.RuntimeFeatureToggleDTO
+		send(t, server, server.NewGetRequest("/api/featuremgmt/runtime/toggle1"), readPermissions, http.StatusOK, &result)
+		assert.False(t, result.Enabled)
+	})
+
+	t.Run("should not update toggles that cannot be changed at runtime", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, put(server, "toggle2", false), writePermissions, http.StatusBadRequest, nil)
+		send(t, server, put(server, "toggle3", true), writePermissions, http.StatusNotFound, nil)
+		send(t, server, put(server, "toggle4", true), writePermissions, http.StatusForbidden, nil)
+		send(t, server, put(server, "toggle5", true), writePermissions, http.StatusNotFound, nil)
+		send(t, server, put(server, "toggle6", true), writePermissions, http.StatusBadRequest, nil)
+	})
+
+	t.Run("should not update toggles if editing is not allowed", func(t *testing.T) {
+		server := setupServer(t, setting.FeatureMgmtSettings{})
+		send(t, server, put(server, "toggle1", true), writePermissions, http.StatusForbidden, nil)
+	})
+
+	request := func(server *webtest.Server, name string, enabled bool) *http.Request {
+		b, _ := json.Marshal(featuremgmt.SetRuntimeFeatureToggleCommand{Enabled: enabled})
+		req := server.NewRequest(http.MethodPost, "/api/featuremgmt/runtime/"+name+"/request", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("should apply requested changes of toggles that do not require a restart", func(t *testing.T) {
+		server := setupServer(t, settings)
+		var result featuremgmt.RuntimeFeatureToggleDTO
+		send(t, server, request(server, "toggle1", true), writePermissions, http.StatusOK, &result)
+		assert.True(t, result.Enabled)
+		assert.Nil(t, result.Pending)
+	})
+
+	t.Run("should not queue requested changes in memory", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, request(server, "toggle2", false), writePermissions, http.StatusBadRequest, nil)
+	})
+
+	t.Run("should queue requested changes of toggles that require a restart", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.FeatureManagement = settings
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = cfg
+			hs.Features = featuremgmt.WithFeatureFlags(features)
+			require.NoError(t, hs.Features.SetRuntimeStore(context.Background(), persistentToggleStore{featuremgmt.NewMemoryToggleStore()}))
+			hs.log = log.New("test")
+		})
+		var result featuremgmt.RuntimeFeatureToggleDTO
+		send(t, server, request(server, "toggle2", false), writePermissions, http.StatusAccepted, &result)
+		assert.True(t, result.Enabled)
+		require.NotNil(t, result.Pending)
+		assert.False(t, *result.Pending)
+
+		var pending []featuremgmt.PendingToggleChange
+		send(t, server, server.NewGetRequest("/api/featuremgmt/pending"), readPermissions, http.StatusOK, &pending)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "toggle2", pending[0].Name)
+		assert.False(t, pending[0].Enabled)
+
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/pending/toggle2", nil), writePermissions, http.StatusOK, nil)
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/pending/toggle2", nil), writePermissions, http.StatusNotFound, nil)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/pending"), readPermissions, http.StatusOK, &pending)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("should not request changes of hidden, internal or read-only toggles", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, request(server, "toggle3", true), writePermissions, http.StatusNotFound, nil)
+		send(t, server, request(server, "toggle5", true), writePermissions, http.StatusNotFound, nil)
+		send(t, server, request(server, "toggle4", true), writePermissions, http.StatusForbidden, nil)
+		send(t, server, request(server, "toggle1", true), readPermissions, http.StatusForbidden, nil)
+	})
+}
+
+func TestFeatureToggleSnapshots(t *testing.T) {
+	readPermissions := []accesscontrol.Permission{{Action: accesscontrol.ActionFeatureManagementRead}}
+	writePermissions := []accesscontrol.Permission{{Action: accesscontrol.ActionFeatureManagementWrite}}
+	features := []*featuremgmt.FeatureFlag{
+		{Name: "toggle1", Stage: featuremgmt.FeatureStageGeneralAvailability, RuntimeMutable: true},
+		{Name: "toggle2", Enabled: true, Stage: featuremgmt.FeatureStageGeneralAvailability, RuntimeMutable: true},
+		{Name: "toggle3", Stage: featuremgmt.FeatureStageGeneralAvailability, RuntimeMutable: true},
+		{Name: "toggle4", Stage: featuremgmt.FeatureStageGeneralAvailability, Internal: true},
+	}
+	settings := setting.FeatureMgmtSettings{
+		AllowEditing:  true,
+		HiddenToggles: map[string]struct{}{"toggle3": {}},
+	}
+
+	setupServer := func(t *testing.T, settings setting.FeatureMgmtSettings) *webtest.Server {
+		cfg := setting.NewCfg()
+		cfg.FeatureManagement = settings
+		return SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = cfg
+			hs.Features = featuremgmt.WithFeatureFlags(features)
+			hs.orgService = orgtest.NewOrgServiceFake()
+			hs.userService = &usertest.FakeUserService{
+				ExpectedUser: &user.User{ID: 1},
+			}
+			hs.log = log.New("test")
+		})
+	}
+	send := func(t *testing.T, server *webtest.Server, req *http.Request, permissions []accesscontrol.Permission, expectedCode int, result any) {
+		res, err := server.SendJSON(webtest.RequestWithSignedInUser(req, userWithPermissions(1, permissions)))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		require.Equal(t, expectedCode, res.StatusCode)
+		if result != nil {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(result))
+		}
+	}
+	create := func(server *webtest.Server, name string) *http.Request {
+		b, _ := json.Marshal(featuremgmt.CreateToggleSnapshotCommand{Name: name})
+		req := server.NewRequest(http.MethodPost, "/api/featuremgmt/snapshots", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	restore := func(server *webtest.Server, name string) *http.Request {
+		return server.NewRequest(http.MethodPost, "/api/featuremgmt/snapshots/"+name+"/restore", nil)
+	}
+	put := func(server *webtest.Server, name string, enabled bool) *http.Request {
+		b, _ := json.Marshal(featuremgmt.SetRuntimeFeatureToggleCommand{Enabled: enabled})
+		req := server.NewRequest(http.MethodPut, "/api/featuremgmt/runtime/"+name, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("should not create snapshots without permissions", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, create(server, "good"), readPermissions, http.StatusForbidden, nil)
+	})
+
+	t.Run("should create and list snapshots without the hidden and internal toggles", func(t *testing.T) {
+		server := setupServer(t, settings)
+		var snapshot featuremgmt.ToggleSnapshot
+		send(t, server, create(server, "good"), writePermissions, http.StatusOK, &snapshot)
+		assert.Equal(t, "good", snapshot.Name)
+		assert.Equal(t, map[string]bool{"toggle1": false, "toggle2": true}, snapshot.States)
+		send(t, server, create(server, "good"), writePermissions, http.StatusConflict, nil)
+
+		var snapshots []featuremgmt.ToggleSnapshot
+		send(t, server, server.NewGetRequest("/api/featuremgmt/snapshots"), readPermissions, http.StatusOK, &snapshots)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, "good", snapshots[0].Name)
+
+		send(t, server, server.NewGetRequest("/api/featuremgmt/snapshots/good"), readPermissions, http.StatusOK, &snapshot)
+		assert.Equal(t, "good", snapshot.Name)
+		send(t, server, server.NewGetRequest("/api/featuremgmt/snapshots/missing"), readPermissions, http.StatusNotFound, nil)
+	})
+
+	t.Run("should restore the states of a snapshot", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, create(server, "good"), writePermissions, http.StatusOK, nil)
+		send(t, server, put(server, "toggle1", true), writePermissions, http.StatusOK, nil)
+		send(t, server, put(server, "toggle2", false), writePermissions, http.StatusOK, nil)
+
+		var result featuremgmt.RestoreToggleSnapshotResponse
+		send(t, server, restore(server, "good"), writePermissions, http.StatusOK, &result)
+		require.Len(t, result.Changes, 2)
+		assert.Equal(t, "toggle1", result.Changes[0].Name)
+		assert.False(t, result.Changes[0].Enabled)
+		assert.Equal(t, "toggle2", result.Changes[1].Name)
+		assert.True(t, result.Changes[1].Enabled)
+		assert.Equal(t, featuremgmt.ToggleChangeSourceSnapshot, result.Changes[1].Source)
+
+		send(t, server, restore(server, "missing"), writePermissions, http.StatusNotFound, nil)
+	})
+
+	t.Run("should not restore snapshots if editing is not allowed", func(t *testing.T) {
+		server := setupServer(t, setting.FeatureMgmtSettings{})
+		send(t, server, create(server, "good"), writePermissions, http.StatusOK, nil)
+		send(t, server, restore(server, "good"), writePermissions, http.StatusForbidden, nil)
+	})
+
+	t.Run("should delete snapshots", func(t *testing.T) {
+		server := setupServer(t, settings)
+		send(t, server, create(server, "good"), writePermissions, http.StatusOK, nil)
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/snapshots/good", nil), writePermissions, http.StatusOK, nil)
+		send(t, server, server.NewRequest(http.MethodDelete, "/api/featuremgmt/snapshots/good", nil), writePermissions, http.StatusNotFound, nil)
+	})
+}
+
+func TestStaleFeatureToggles(t *testing.T) {
+	features := []*featuremgmt.FeatureFlag{
+		{Name: "toggle1", Stage: featuremgmt.FeatureStageDeprecated, RemovalVersion: "10.0.0"},
+		{Name: "toggle2", Stage: featuremgmt.FeatureStageDeprecated, RemovalVersion: "10.0.0"},
+		{Name: "toggle3", Stage: featuremgmt.FeatureStageDeprecated, RemovalVersion: "11.0.0"},
+		{Name: "toggle4", Stage: featuremgmt.FeatureStageDeprecated, RemovalVersion: "10.0.0", Internal: true},
+	}
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.Cfg.BuildVersion = "10.1.0"
+		hs.Cfg.FeatureManagement.HiddenToggles = map[string]struct{}{"toggle2": {}}
+		hs.Features = featuremgmt.WithFeatureFlags(features)
+		hs.orgService = orgtest.NewOrgServiceFake()
+		hs.userService = &usertest.FakeUserService{
+			ExpectedUser: &user.User{ID: 1},
+		}
+		hs.log = log.New("test")
+	})
+
+	t.Run("should not list the stale toggles without permissions", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest("/api/featuremgmt/stale"), userWithPermissions(1, nil))
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("should list the stale toggles that are not hidden or internal", func(t *testing.T) {
+		readPermissions := []accesscontrol.Permission{{Action: accesscontrol.ActionFeatureManagementRead}}
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest("/api/featuremgmt/stale"), userWithPermissions(1, readPermissions))
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var stale []featuremgmt.StaleToggle
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&stale))
+		require.Len(t, stale, 1)
+		assert.Equal(t, "toggle1", stale[0].Name)
+		assert.Equal(t, featuremgmt.StaleReasonPastRemovalVersion, stale[0].Reason)
+	})
+}
+
 func findResult(t *testing.T, result []featuremgmt.FeatureToggleDTO, name string) (featuremgmt.FeatureToggleDTO, bool) {
 	t.Helper()
 
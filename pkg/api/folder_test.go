@@ -1,17 +1,24 @@
 package api
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
@@ -20,6 +27,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/folder/foldertest"
 	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/licensing"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/search/model"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -520,3 +528,139 @@ func TestFolderGetAPIEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestFolderGetAPIEndpointWithFeatureToggleTargeting(t *testing.T) {
+	cfg := setting.NewCfg()
+	_, err := cfg.Raw.Section("feature_toggles.targeting").NewKey("nestedFolders_users", "2")
+	require.NoError(t, err)
+	features, err := featuremgmt.ProvideManagerService(cfg, &licensing.OSSLicensingService{})
+	require.NoError(t, err)
+	require.False(t, features.IsEnabled(featuremgmt.FlagNestedFolders))
+
+	srv := setupFolderGetAPIEndpointWithFeatures(t, cfg, features)
+
+	// Nested folders are also checked by the folder service for the whole instance, so they cannot be targeted.
+	t.Run("should not return parent folders to the targeted users", func(t *testing.T) {
+		require.Empty(t, getFolderParentUIDs(t, srv, srv.NewGetRequest("/api/folders/uid"), 2))
+	})
+}
+
+func TestFolderGetAPIEndpointWithFeatureToggleOverrides(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.FeatureManagement.RequestOverridesSecret = "secret"
+	features, err := featuremgmt.ProvideManagerService(cfg, &licensing.OSSLicensingService{})
+	require.NoError(t, err)
+	require.True(t, features.RequestOverridesEnabled())
+	require.False(t, features.IsEnabled(featuremgmt.FlagNestedFolders))
+
+	srv := setupFolderGetAPIEndpointWithFeatures(t, cfg, features)
+	srv.Mux.UseMiddleware(middleware.FeatureToggleOverrides(features))
+
+	withOverrides := func(value string) *http.Request {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(value))
+		req := srv.NewGetRequest("/api/folders/uid")
+		req.Header.Set(featuremgmt.RequestOverridesHeader, value)
+		req.Header.Set(featuremgmt.RequestOverridesSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+
+	t.Run("should return parent folders if the request enables nested folders", func(t *testing.T) {
+		require.Equal(t, []string{"parent"}, getFolderParentUIDs(t, srv, withOverrides("nestedFolders=true"), 1))
+	})
+
+	t.Run("should not return parent folders without overrides", func(t *testing.T) {
+		require.Empty(t, getFolderParentUIDs(t, srv, srv.NewGetRequest("/api/folders/uid"), 1))
+	})
+
+	t.Run("should not return parent folders if the request disables nested folders", func(t *testing.T) {
+		enabledCfg := setting.NewCfg()
+		enabledCfg.FeatureManagement.RequestOverridesSecret = "secret"
+		_, err := enabledCfg.Raw.Section("feature_toggles").NewKey(featuremgmt.FlagNestedFolders, "true")
+		require.NoError(t, err)
+		enabledFeatures, err := featuremgmt.ProvideManagerService(enabledCfg, &licensing.OSSLicensingService{})
+		require.NoError(t, err)
+		require.True(t, enabledFeatures.IsEnabled(featuremgmt.FlagNestedFolders))
+
+		enabledSrv := setupFolderGetAPIEndpointWithFeatures(t, enabledCfg, enabledFeatures)
+		enabledSrv.Mux.UseMiddleware(middleware.FeatureToggleOverrides(enabledFeatures))
+		require.Equal(t, []string{"parent"}, getFolderParentUIDs(t, enabledSrv, enabledSrv.NewGetRequest("/api/folders/uid"), 1))
+
+		req := withOverrides("nestedFolders=false")
+		enabledReq := enabledSrv.NewGetRequest("/api/folders/uid")
+		enabledReq.Header = req.Header
+		require.Empty(t, getFolderParentUIDs(t, enabledSrv, enabledReq, 1))
+	})
+}
+
+func TestFolderGetAPIEndpointWithFeatureToggleEvaluationTracing(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracer := tracing.InitializeTracerForTest(tracing.WithSpanProcessor(spanRecorder))
+
+	cfg := setting.NewCfg()
+	features := featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders)
+	srv := setupFolderGetAPIEndpointWithFeatures(t, cfg, features)
+	srv.Mux.UseMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx, span := tracer.Start(req.Context(), "request")
+			defer span.End()
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	})
+	srv.Mux.UseMiddleware(middleware.FeatureToggleEvaluationTracing(features))
+
+	require.Equal(t, []string{"parent"}, getFolderParentUIDs(t, srv, srv.NewGetRequest("/api/folders/uid"), 1))
+
+	// The span ends once the response is written.
+	require.Eventually(t, func() bool { return len(spanRecorder.Ended()) == 1 }, time.Second, 10*time.Millisecond)
+	spans := spanRecorder.Ended()
+	var evaluated string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "feature_toggles.evaluated" {
+			evaluated = attr.Value.AsString()
+		}
+	}
+	require.Contains(t, strings.Split(evaluated, ","), "nestedFolders=true")
+}
+
+// setupFolderGetAPIEndpointWithFeatures sets up a server returning the folder "uid", with the parent folders only if
+// nested folders are enabled for the request.
+func setupFolderGetAPIEndpointWithFeatures(t *testing.T, cfg *setting.Cfg, features *featuremgmt.FeatureManager) *webtest.Server {
+	t.Helper()
+
+	origNewGuardian := guardian.New
+	t.Cleanup(func() {
+		guardian.New = origNewGuardian
+	})
+	guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+
+	return SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = cfg
+		hs.Features = features
+		hs.folderService = &foldertest.FakeService{
+			ExpectedFolder:  &folder.Folder{ID: 1, UID: "uid", Title: "uid title"},
+			ExpectedFolders: []*folder.Folder{{UID: "parent", Title: "parent title"}},
+		}
+	})
+}
+
+func getFolderParentUIDs(t *testing.T, srv *webtest.Server, req *http.Request, userID int64) []string {
+	t.Helper()
+
+	req = webtest.RequestWithSignedInUser(req, authedUserWithPermissions(userID, 1, []accesscontrol.Permission{
+		{Action: dashboards.ActionFoldersRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID("uid")},
+	}))
+	resp, err := srv.Send(req)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, resp.Body.Close()) }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	folder := dtos.Folder{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&folder))
+
+	uids := make([]string, 0, len(folder.Parents))
+	for _, parent := range folder.Parents {
+		uids = append(uids, parent.Uid)
+	}
+	return uids
+}
@@ -614,6 +614,14 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	m.Use(hs.pluginMetricsEndpoint)
 	m.Use(hs.frontendLogEndpoints())
 
+	// needs to be before context handler, so the request context of the handlers has the overrides
+	if hs.Features.RequestOverridesEnabled() {
+		m.UseMiddleware(middleware.FeatureToggleOverrides(hs.Features))
+	}
+	if hs.Features.EvaluationTracingEnabled() {
+		m.UseMiddleware(middleware.FeatureToggleEvaluationTracing(hs.Features))
+	}
+
 	m.UseMiddleware(hs.ContextHandler.Middleware)
 	m.Use(middleware.OrgRedirect(hs.Cfg, hs.userService))
 
@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/grafana/grafana/pkg/api/apierrors"
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -160,6 +162,87 @@ func (hs *HTTPServer) UpdateUserQuota(c *contextmodel.ReqContext) response.Respo
 	return response.Success("Organization quota updated")
 }
 
+// swagger:route GET /folders/{folder_uid}/quotas folders getFolderQuota
+//
+// Fetch folder quota.
+//
+// Returns the limits of the folder and the number of resources in the folder and its subfolders.
+//
+// Responses:
+// 200: getQuotaResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetFolderQuotas(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	f, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{OrgID: c.SignedInUser.GetOrgID(), UID: &uid, SignedInUser: c.SignedInUser})
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	q, err := hs.QuotaService.GetFolderQuotas(c.Req.Context(), f.OrgID, f.UID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get folder quotas", err)
+	}
+	return response.JSON(http.StatusOK, q)
+}
+
+// swagger:route PUT /folders/{folder_uid}/quotas/{quota_target} folders updateFolderQuota
+//
+// Update folder quota.
+//
+// The limit applies to the folder and its subfolders.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateFolderQuota(c *contextmodel.ReqContext) response.Response {
+	cmd := quota.UpdateQuotaCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Err(quota.ErrBadRequest.Errorf("bad request data: %w", err))
+	}
+	uid := web.Params(c.Req)[":uid"]
+	f, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{OrgID: c.SignedInUser.GetOrgID(), UID: &uid, SignedInUser: c.SignedInUser})
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+	cmd.OrgID = f.OrgID
+	cmd.FolderUID = f.UID
+	cmd.Target = web.Params(c.Req)[":target"]
+
+	if err := hs.QuotaService.Update(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update folder quotas", err)
+	}
+	return response.Success("Folder quota updated")
+}
+
+// swagger:route GET /admin/quotas/usage admin getQuotaUsageReport
+//
+// Fetch the quota usage report.
+//
+// Returns the usage and limit of every quota target globally and for each organization.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `server.stats:read`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: getQuotaUsageReportResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetQuotaUsageReport(c *contextmodel.ReqContext) response.Response {
+	report, err := hs.QuotaService.GetUsageReport(c.Req.Context())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get quota usage report", err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
 // swagger:parameters updateUserQuota
 type UpdateUserQuotaParams struct {
 	// in:body
@@ -200,8 +283,34 @@ type UpdateOrgQuotaParam struct {
 	OrgID int64 `json:"org_id"`
 }
 
+// swagger:parameters getFolderQuota
+type GetFolderQuotaParams struct {
+	// in:path
+	// required:true
+	FolderUID string `json:"folder_uid"`
+}
+
+// swagger:parameters updateFolderQuota
+type UpdateFolderQuotaParams struct {
+	// in:body
+	// required:true
+	Body quota.UpdateQuotaCmd `json:"body"`
+	// in:path
+	// required:true
+	QuotaTarget string `json:"quota_target"`
+	// in:path
+	// required:true
+	FolderUID string `json:"folder_uid"`
+}
+
 // swagger:response getQuotaResponse
 type GetQuotaResponseResponse struct {
 	// in:body
 	Body []*quota.QuotaDTO `json:"body"`
 }
+
+// swagger:response getQuotaUsageReportResponse
+type GetQuotaUsageReportResponse struct {
+	// in:body
+	Body quota.UsageReport `json:"body"`
+}
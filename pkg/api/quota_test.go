@@ -11,6 +11,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/usertest"
 	"github.com/grafana/grafana/pkg/setting"
@@ -21,6 +22,7 @@ var (
 	getCurrentOrgQuotasURL = "/api/org/quotas"
 	getOrgsQuotasURL       = "/api/orgs/%v/quotas"
 	putOrgsQuotasURL       = "/api/orgs/%v/quotas/%v"
+	getQuotaUsageReportURL = "/api/admin/quotas/usage"
 
 	testUpdateOrgQuotaCmd = `{ "limit": 20 }`
 )
@@ -153,3 +155,25 @@ func TestAPIEndpoint_PutOrgQuotas(t *testing.T) {
 		require.NoError(t, response.Body.Close())
 	})
 }
+
+func TestAPIEndpoint_GetQuotaUsageReport(t *testing.T) {
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.QuotaService = quotatest.New(false, nil)
+	})
+
+	t.Run("AccessControl allows viewing the usage report with correct permissions", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest(getQuotaUsageReportURL), userWithPermissions(1, []accesscontrol.Permission{{Action: accesscontrol.ActionServerStatsRead}}))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+	t.Run("AccessControl prevents viewing the usage report with incorrect permissions", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest(getQuotaUsageReportURL), userWithPermissions(1, []accesscontrol.Permission{{Action: accesscontrol.ActionOrgsQuotasRead}}))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+}
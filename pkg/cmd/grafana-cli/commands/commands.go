@@ -143,6 +143,41 @@ var adminCommands = []*cli.Command{
 				Usage:  "Migrates passwords from unsecured fields to secure_json_data field. Return ok unless there is an error. Safe to execute multiple times.",
 				Action: runDbCommand(datamigrations.EncryptDatasourcePasswords),
 			},
+			{
+				Name:   "validate-alerting",
+				Usage:  "Reports legacy alerts and notification channels that cannot be migrated to unified alerting. Does not modify the database.",
+				Action: runRunnerCommand(datamigrations.ValidateAlertingMigration),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format of the report: text, json or csv",
+						Value: "text",
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Usage: "Write the json or csv report to this file instead of stdout",
+					},
+				},
+			},
+			{
+				Name:   "restore-alerting",
+				Usage:  "Restores the unified alerting data of an organization from a backup taken before it was rolled back or migrated again.",
+				Action: runRunnerCommand(datamigrations.RestoreAlertingBackup),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "org-id",
+						Usage: "The ID of the organization",
+					},
+					&cli.IntFlag{
+						Name:  "backup-id",
+						Usage: "The ID of the backup to restore, the latest backup of the organization if not set",
+					},
+					&cli.BoolFlag{
+						Name:  "list",
+						Usage: "List the backups of the organization instead of restoring one",
+					},
+				},
+			},
 		},
 	},
 	{
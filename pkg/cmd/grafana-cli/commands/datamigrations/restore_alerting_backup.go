@@ -0,0 +1,58 @@
+package datamigrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// RestoreAlertingBackup restores the unified alerting data of an organization from a backup taken before it was
+// removed to roll back or re-migrate the organization. With the list flag the backups are listed instead.
+func RestoreAlertingBackup(cmd utils.CommandLine, runner server.Runner) error {
+	orgID := int64(cmd.Int("org-id"))
+	if orgID <= 0 {
+		return errors.New("the org-id flag is required")
+	}
+
+	if cmd.Bool("list") {
+		var backups []ualert.OrgBackup
+		err := runner.SQLStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			var err error
+			backups, err = ualert.ListOrgBackups(sess.Session, orgID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			logger.Infof("No unified alerting backups of organization %d\n", orgID)
+			return nil
+		}
+		for _, b := range backups {
+			logger.Infof("Backup %d taken at %s: %d alert rules, %d folders, %d Alertmanager data sources\n", b.ID, b.Created.Format("2006-01-02 15:04:05"), b.Rules, b.Folders, b.Datasources)
+		}
+		return nil
+	}
+
+	var restored *ualert.OrgBackup
+	err := runner.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *db.Session) error {
+		var err error
+		restored, err = ualert.RestoreOrgBackup(sess.Session, orgID, int64(cmd.Int("backup-id")), runner.Cfg.UnifiedAlerting.Upgrade.MaxBackupsToKeep)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore unified alerting backup: %w", err)
+	}
+
+	logger.Infof("%s Restored backup %d of organization %d: %d alert rules, %d folders, %d Alertmanager data sources\n", color.GreenString("✔"), restored.ID, restored.OrgID, restored.Rules, restored.Folders, restored.Datasources)
+	logger.Info("Restart Grafana to apply the restored Alertmanager configuration, silences and alert instances\n")
+	return nil
+}
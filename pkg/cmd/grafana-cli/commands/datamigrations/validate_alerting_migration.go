@@ -0,0 +1,96 @@
+package datamigrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// ValidateAlertingMigration checks whether the legacy alerts and notification channels can be migrated
+// to unified alerting without writing anything to the database.
+// With the json or csv format the full report is written for post-migration review instead of the summary.
+func ValidateAlertingMigration(cmd utils.CommandLine, runner server.Runner) error {
+	format := cmd.String("format")
+	if format != "" && format != "text" && format != "json" && format != "csv" {
+		return fmt.Errorf("unsupported report format %q, must be one of text, json or csv", format)
+	}
+
+	var report *ualert.ValidationReport
+	err := runner.SQLStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		var err error
+		report, err = ualert.ValidateDashAlertMigration(sess.Session, runner.SQLStore.GetDialect(), runner.Cfg)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to validate legacy alerting: %w", err)
+	}
+
+	if format == "json" || format == "csv" {
+		return exportValidationReport(report, format, cmd.String("output"))
+	}
+
+	logger.Info("\n")
+	for _, p := range report.AlertWarnings {
+		logger.Infof("%s Alert %q (ID: %d, org: %d, dashboard: %d, panel: %d): %s\n", color.YellowString("!"), p.Name, p.AlertID, p.OrgID, p.DashboardID, p.PanelID, p.Reason)
+	}
+	for _, p := range report.ChannelWarnings {
+		logger.Infof("%s Notification channel %q (UID: %s, type: %s, org: %d): %s\n", color.YellowString("!"), p.Name, p.UID, p.Type, p.OrgID, p.Reason)
+	}
+	for _, c := range report.Conflicts {
+		logger.Infof("%s Conflict in org %d, handled with conflict_policy %s: %s\n", color.YellowString("!"), c.OrgID, runner.Cfg.UnifiedAlerting.Upgrade.ConflictPolicy, c.Reason)
+	}
+	if !report.HasProblems() {
+		logger.Infof("%s All legacy alerts and notification channels can be migrated\n", color.GreenString("✔"))
+		return nil
+	}
+
+	for _, p := range report.Alerts {
+		logger.Infof("%s Alert %q (ID: %d, org: %d, dashboard: %d, panel: %d): %s\n", color.RedString("✗"), p.Name, p.AlertID, p.OrgID, p.DashboardID, p.PanelID, p.Reason)
+	}
+	for _, p := range report.Channels {
+		logger.Infof("%s Notification channel %q (UID: %s, type: %s, org: %d): %s\n", color.RedString("✗"), p.Name, p.UID, p.Type, p.OrgID, p.Reason)
+	}
+	for _, d := range report.Datasources {
+		logger.Infof("%s Data source type %q (org: %d, alerts: %v): %s\n", color.RedString("✗"), d.Type, d.OrgID, d.AlertIDs, d.Reason)
+	}
+
+	logger.Info("\n")
+	logger.Warnf("Found %d alert, %d notification channel and %d data source problems\n", len(report.Alerts), len(report.Channels), len(report.Datasources))
+	return nil
+}
+
+func exportValidationReport(report *ualert.ValidationReport, format, output string) (err error) {
+	var w io.Writer = os.Stdout
+	if output != "" {
+		// nolint:gosec
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer func() {
+			if cerr := f.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("failed to close report file: %w", cerr)
+			}
+		}()
+		w = f
+	}
+
+	if format == "csv" {
+		err = report.WriteCSV(w)
+	} else {
+		err = report.WriteJSON(w)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
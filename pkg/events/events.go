@@ -78,3 +78,28 @@ type FolderTitleUpdated struct {
 	UID       string    `json:"uid"`
 	OrgID     int64     `json:"org_id"`
 }
+
+// AlertingMigrationStarted is published when the migration from legacy alerting starts, with OrgID 0 when it
+// migrates all organizations.
+type AlertingMigrationStarted struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+}
+
+type AlertingOrgMigrated struct {
+	Timestamp     time.Time `json:"timestamp"`
+	OrgID         int64     `json:"org_id"`
+	AlertRules    int       `json:"alert_rules"`
+	ContactPoints int       `json:"contact_points"`
+}
+
+type AlertingOrgMigrationReverted struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+}
+
+type AlertingMigrationFailed struct {
+	Timestamp time.Time `json:"timestamp"`
+	OrgID     int64     `json:"org_id"`
+	Error     string    `json:"error"`
+}
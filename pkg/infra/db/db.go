@@ -24,6 +24,10 @@ type DB interface {
 	// WithNewDbSession behaves like [DB.WithDbSession] without picking up a transaction
 	// from the context.
 	WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
+	// WithReadReplicaDbSession behaves like [DB.WithDbSession], but runs the read-only database
+	// operations on the read replica of the database if one is configured and the context allows
+	// it, see [WithReadReplica].
+	WithReadReplicaDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
 	// GetDialect returns an object that contains information about the peculiarities of
 	// the particular database type available to the runtime.
 	GetDialect() migrator.Dialect
@@ -46,9 +50,13 @@ type Session = sqlstore.DBSession
 type InitTestDBOpt = sqlstore.InitTestDBOpt
 
 var InitTestDB = sqlstore.InitTestDB
+var InitIsolatedTestDB = sqlstore.InitIsolatedTestDB
 var InitTestDBwithCfg = sqlstore.InitTestDBWithCfg
 var ProvideService = sqlstore.ProvideService
 
+// WithReadReplica allows the database reads made with the returned context to be served by the read replica.
+var WithReadReplica = sqlstore.WithReadReplica
+
 func IsTestDbSQLite() bool {
 	if db, present := os.LookupEnv("GRAFANA_TEST_DB"); !present || db == "sqlite" {
 		return true
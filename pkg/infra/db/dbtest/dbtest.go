@@ -31,6 +31,10 @@ func (f *FakeDB) WithNewDbSession(ctx context.Context, callback sqlstore.DBTrans
 	return f.ExpectedError
 }
 
+func (f *FakeDB) WithReadReplicaDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return f.ExpectedError
+}
+
 func (f *FakeDB) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
 	return f.ExpectedError
 }
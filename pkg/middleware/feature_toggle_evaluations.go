@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var evaluationsLogger = log.New("featuremgmt.evaluations")
+
+// FeatureToggleEvaluationTracing records the feature toggles evaluated during the request and their values, and
+// attaches them to the trace span of the request and the debug logs, to compare the behavior of environments with
+// different toggles.
+func FeatureToggleEvaluationTracing(features *featuremgmt.FeatureManager) web.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx, evaluations, done := features.TraceEvaluations(req.Context())
+			defer done()
+			next.ServeHTTP(rw, req.WithContext(ctx))
+
+			evaluated := evaluations.String()
+			if evaluated == "" {
+				return
+			}
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("feature_toggles.evaluated", evaluated))
+			evaluationsLogger.FromContext(ctx).Debug("Feature toggles evaluated", "method", req.Method, "path", req.URL.Path, "toggles", evaluated)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// FeatureToggleOverrides evaluates the feature toggles of the request with the signed overrides of its header or
+// query parameters, so both paths of a toggle can be tested against the same instance. It must only be used in
+// development mode.
+func FeatureToggleOverrides(features *featuremgmt.FeatureManager) web.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			value, signature := req.Header.Get(featuremgmt.RequestOverridesHeader), req.Header.Get(featuremgmt.RequestOverridesSignatureHeader)
+			if value == "" {
+				query := req.URL.Query()
+				value, signature = query.Get(featuremgmt.RequestOverridesQueryParam), query.Get(featuremgmt.RequestOverridesSignatureQueryParam)
+			}
+			if value == "" {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			overrides, err := features.ParseRequestOverrides(value, signature)
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(rw, req.WithContext(featuremgmt.WithRequestOverrides(req.Context(), overrides)))
+		})
+	}
+}
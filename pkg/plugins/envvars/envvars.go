@@ -86,7 +86,7 @@ func (s *Service) GetConfigMap(ctx context.Context, _ string, _ *auth.ExternalSe
 	//}
 
 	if s.cfg.Features != nil {
-		enabledFeatures := s.cfg.Features.GetEnabled(ctx)
+		enabledFeatures := s.cfg.Features.GetEnabledForPlugins(ctx)
 		if len(enabledFeatures) > 0 {
 			features := make([]string, 0, len(enabledFeatures))
 			for feat := range enabledFeatures {
@@ -157,6 +157,8 @@ func (fn ClientMiddlewareFunc) CreateClientMiddleware(next Client) Client {
 type FeatureToggles interface {
 	IsEnabled(flag string) bool
 	GetEnabled(ctx context.Context) map[string]bool
+	// GetEnabledForPlugins returns the enabled toggles that are relevant to backend plugins.
+	GetEnabledForPlugins(ctx context.Context) map[string]bool
 }
 
 type SignatureCalculator interface {
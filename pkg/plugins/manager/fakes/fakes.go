@@ -578,6 +578,10 @@ func (f *FakeFeatureToggles) GetEnabled(_ context.Context) map[string]bool {
 	return f.features
 }
 
+func (f *FakeFeatureToggles) GetEnabledForPlugins(_ context.Context) map[string]bool {
+	return f.features
+}
+
 func (f *FakeFeatureToggles) IsEnabled(feature string) bool {
 	return f.features[feature]
 }
@@ -47,6 +47,9 @@ type AlertQuery struct {
 // Eval evaluates the `QueryCondition`.
 func (c *QueryCondition) Eval(context *alerting.EvalContext, requestHandler legacydata.RequestHandler) (*alerting.ConditionResult, error) {
 	timeRange := legacydata.NewDataTimeRange(c.Query.From, c.Query.To)
+	if !context.EvalTime.IsZero() {
+		timeRange.Now = context.EvalTime
+	}
 
 	seriesList, err := c.executeQuery(context, timeRange, requestHandler)
 	if err != nil {
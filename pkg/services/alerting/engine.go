@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/infra/usagestats/validator"
+	"github.com/grafana/grafana/pkg/services/alerting/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
@@ -57,7 +58,15 @@ type AlertEngine struct {
 
 // IsDisabled returns true if the alerting service is disabled for this instance.
 func (e *AlertEngine) IsDisabled() bool {
-	return setting.AlertingEnabled == nil || !*setting.AlertingEnabled || !setting.ExecuteAlerts || e.Cfg.UnifiedAlerting.IsEnabled()
+	if !setting.ExecuteAlerts {
+		return true
+	}
+	if e.Cfg.UnifiedAlerting.IsEnabled() {
+		// The organizations skipped by the migration and the ones migrated in shadow mode keep their legacy alerts
+		// evaluated while unified alerting is enabled.
+		return len(e.Cfg.UnifiedAlerting.Upgrade.SkipOrgIDs) == 0 && !e.Cfg.UnifiedAlerting.Upgrade.ShadowMode
+	}
+	return setting.AlertingEnabled == nil || !*setting.AlertingEnabled
 }
 
 // ProvideAlertEngine returns a new AlertEngine.
@@ -82,7 +91,7 @@ func ProvideAlertEngine(renderer rendering.Service, requestValidator validations
 	e.execQueue = make(chan *Job, 1000)
 	e.scheduler = newScheduler()
 	e.evalHandler = NewEvalHandler(e.DataService)
-	e.ruleReader = newRuleReader(store)
+	e.ruleReader = newRuleReader(store, legacyAlertsQuery(cfg))
 	e.log = log.New("alerting.engine")
 	e.resultHandler = newResultHandler(e.RenderService, store, notificationService, encryptionService.GetDecryptedValue)
 
@@ -91,6 +100,16 @@ func ProvideAlertEngine(renderer rendering.Service, requestValidator validations
 	return e
 }
 
+// legacyAlertsQuery returns the query of the alerts that the engine evaluates. While unified alerting is enabled, they
+// are the alerts of the organizations skipped by the migration and of the organizations migrated in shadow mode, until
+// their migration is activated.
+func legacyAlertsQuery(cfg *setting.Cfg) *models.GetAllAlertsQuery {
+	if cfg == nil || !cfg.UnifiedAlerting.IsEnabled() {
+		return &models.GetAllAlertsQuery{}
+	}
+	return &models.GetAllAlertsQuery{LegacyOrgsOnly: true, OrgIDs: cfg.UnifiedAlerting.Upgrade.SkipOrgIDs}
+}
+
 // Run starts the alerting service background process.
 func (e *AlertEngine) Run(ctx context.Context) error {
 	reg := prometheus.WrapRegistererWithPrefix("legacy_", prometheus.DefaultRegisterer)
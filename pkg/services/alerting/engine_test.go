@@ -229,3 +229,46 @@ func TestEngineProcessJob(t *testing.T) {
 		})
 	})
 }
+
+func TestEngineLegacyOrgs(t *testing.T) {
+	alertingEnabled, executeAlerts := setting.AlertingEnabled, setting.ExecuteAlerts
+	t.Cleanup(func() { setting.AlertingEnabled, setting.ExecuteAlerts = alertingEnabled, executeAlerts })
+	enabled, disabled := true, false
+	// Legacy alerting is disabled when unified alerting is enabled.
+	setting.AlertingEnabled = &disabled
+	setting.ExecuteAlerts = true
+
+	t.Run("is disabled with unified alerting if no organization is skipped nor migrated in shadow mode", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UnifiedAlerting.Enabled = &enabled
+		engine := &AlertEngine{Cfg: cfg}
+		require.True(t, engine.IsDisabled())
+	})
+
+	t.Run("evaluates the alerts of the skipped organizations with unified alerting", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UnifiedAlerting.Enabled = &enabled
+		cfg.UnifiedAlerting.Upgrade.SkipOrgIDs = []int64{2}
+		engine := &AlertEngine{Cfg: cfg}
+		require.False(t, engine.IsDisabled())
+		require.Equal(t, &models.GetAllAlertsQuery{LegacyOrgsOnly: true, OrgIDs: []int64{2}}, legacyAlertsQuery(cfg))
+	})
+
+	t.Run("evaluates the alerts of the organizations migrated in shadow mode with unified alerting", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UnifiedAlerting.Enabled = &enabled
+		cfg.UnifiedAlerting.Upgrade.ShadowMode = true
+		engine := &AlertEngine{Cfg: cfg}
+		require.False(t, engine.IsDisabled())
+		require.Equal(t, &models.GetAllAlertsQuery{LegacyOrgsOnly: true}, legacyAlertsQuery(cfg))
+	})
+
+	t.Run("evaluates the alerts of all organizations without unified alerting", func(t *testing.T) {
+		setting.AlertingEnabled = &enabled
+		cfg := setting.NewCfg()
+		cfg.UnifiedAlerting.Enabled = &disabled
+		engine := &AlertEngine{Cfg: cfg}
+		require.False(t, engine.IsDisabled())
+		require.Equal(t, &models.GetAllAlertsQuery{}, legacyAlertsQuery(cfg))
+	})
+}
@@ -37,6 +37,9 @@ type EvalContext struct {
 	NoDataFound     bool
 	PrevAlertState  alertmodels.AlertStateType
 
+	// EvalTime is the time the conditions are evaluated at, the current time if zero.
+	EvalTime time.Time
+
 	RequestValidator validations.PluginRequestValidator
 
 	Ctx context.Context
@@ -171,7 +171,12 @@ type GetAlertsQuery struct {
 	User         *user.SignedInUser
 }
 
-type GetAllAlertsQuery struct{}
+type GetAllAlertsQuery struct {
+	// LegacyOrgsOnly restricts the alerts to those of OrgIDs and of the organizations migrated to unified alerting in
+	// shadow mode, which keep their legacy alerts evaluated until their migration is activated.
+	LegacyOrgsOnly bool
+	OrgIDs         []int64
+}
 
 type GetAlertByIdQuery struct {
 	ID int64 `xorm:"id"`
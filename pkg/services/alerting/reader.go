@@ -16,12 +16,16 @@ type ruleReader interface {
 type defaultRuleReader struct {
 	sync.RWMutex
 	sqlStore AlertStore
-	log      log.Logger
+	// query selects the alerts to evaluate. It is run on every fetch, so that the organizations whose migration is
+	// activated stop being evaluated.
+	query *models.GetAllAlertsQuery
+	log   log.Logger
 }
 
-func newRuleReader(sqlStore AlertStore) *defaultRuleReader {
+func newRuleReader(sqlStore AlertStore, query *models.GetAllAlertsQuery) *defaultRuleReader {
 	ruleReader := &defaultRuleReader{
 		sqlStore: sqlStore,
+		query:    query,
 		log:      log.New("alerting.ruleReader"),
 	}
 
@@ -29,9 +33,7 @@ func newRuleReader(sqlStore AlertStore) *defaultRuleReader {
 }
 
 func (arr *defaultRuleReader) fetch(ctx context.Context) []*Rule {
-	cmd := &models.GetAllAlertsQuery{}
-
-	alerts, err := arr.sqlStore.GetAllAlertQueryHandler(ctx, cmd)
+	alerts, err := arr.sqlStore.GetAllAlertQueryHandler(ctx, arr.query)
 	if err != nil {
 		arr.log.Error("Could not load alerts", "error", err)
 		return []*Rule{}
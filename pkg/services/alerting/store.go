@@ -75,7 +75,19 @@ func (ss *sqlStore) GetAlertById(ctx context.Context, query *alertmodels.GetAler
 func (ss *sqlStore) GetAllAlertQueryHandler(ctx context.Context, query *alertmodels.GetAllAlertsQuery) (res []*alertmodels.Alert, err error) {
 	err = ss.db.WithDbSession(ctx, func(sess *db.Session) error {
 		var alerts []*alertmodels.Alert
-		err := sess.SQL("select * from alert").Find(&alerts)
+		sql := "select * from alert"
+		params := make([]any, 0)
+		if query.LegacyOrgsOnly {
+			sql += " WHERE org_id IN (SELECT org_id FROM alert_migration_org_state WHERE migrated = ? AND shadow = ?)"
+			params = append(params, true, true)
+			if len(query.OrgIDs) > 0 {
+				sql += " OR org_id IN (?" + strings.Repeat(",?", len(query.OrgIDs)-1) + ")"
+				for _, orgID := range query.OrgIDs {
+					params = append(params, orgID)
+				}
+			}
+		}
+		err := sess.SQL(sql, params...).Find(&alerts)
 		if err != nil {
 			return err
 		}
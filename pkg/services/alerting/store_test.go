@@ -294,6 +294,34 @@ func TestIntegrationAlertingDataAccess(t *testing.T) {
 		})
 	})
 
+	t.Run("Can get the alerts of the legacy organizations", func(t *testing.T) {
+		setup(t)
+
+		query := &models.GetAllAlertsQuery{LegacyOrgsOnly: true}
+		res, err := store.GetAllAlertQueryHandler(context.Background(), query)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		query.OrgIDs = []int64{testDash.OrgID}
+		res, err = store.GetAllAlertQueryHandler(context.Background(), query)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		err = store.db.WithDbSession(context.Background(), func(sess *db.Session) error {
+			if _, err := sess.Exec("DELETE FROM alert_migration_org_state WHERE org_id = ?", testDash.OrgID); err != nil {
+				return err
+			}
+			_, err := sess.Exec("INSERT INTO alert_migration_org_state (org_id, migrated, grafana_version, updated, shadow) VALUES (?, ?, ?, ?, ?)",
+				testDash.OrgID, true, "", time.Now(), true)
+			return err
+		})
+		require.NoError(t, err)
+
+		res, err = store.GetAllAlertQueryHandler(context.Background(), &models.GetAllAlertsQuery{LegacyOrgsOnly: true})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+	})
+
 	t.Run("When dashboard is removed", func(t *testing.T) {
 		setup(t)
 		items := []*models.Alert{
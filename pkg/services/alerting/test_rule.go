@@ -3,8 +3,10 @@ package alerting
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	alertmodels "github.com/grafana/grafana/pkg/services/alerting/models"
 	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -45,3 +47,28 @@ func (e *AlertEngine) AlertTest(orgID int64, dashboard *simplejson.Json, panelID
 
 	return nil, fmt.Errorf("could not find alert with panel ID %d", panelID)
 }
+
+// EvalAlertAt evaluates the conditions of the alert of the organization as if the current time was at. The state of
+// the alert is not changed and no notifications are sent.
+func (e *AlertEngine) EvalAlertAt(ctx context.Context, orgID, alertID int64, at time.Time) (*EvalContext, error) {
+	alert, err := e.AlertStore.GetAlertById(ctx, &alertmodels.GetAlertByIdQuery{ID: alertID})
+	if err != nil {
+		return nil, err
+	}
+	if alert.OrgID != orgID {
+		return nil, fmt.Errorf("could not find alert with ID %d", alertID)
+	}
+	rule, err := NewRuleFromDBAlert(ctx, e.AlertStore, alert, false)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := NewEvalHandler(e.DataService)
+
+	context := NewEvalContext(ctx, rule, fakeRequestValidator{}, e.AlertStore, nil, e.datasourceService, annotationstest.NewFakeAnnotationsRepo())
+	context.IsTestRun = true
+	context.EvalTime = at
+
+	handler.Eval(context)
+	return context, nil
+}
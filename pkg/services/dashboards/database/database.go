@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"xorm.io/xorm"
@@ -58,6 +59,21 @@ func ProvideDashboardStore(sqlStore db.DB, cfg *setting.Cfg, features featuremgm
 		TargetSrv:     dashboards.QuotaTargetSrv,
 		DefaultLimits: defaultLimits,
 		Reporter:      s.Count,
+		OrgsReporter:  s.CountInOrgs,
+	}); err != nil {
+		return nil, err
+	}
+
+	folderLimits, err := folderQuotaLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
+		TargetSrv:     dashboards.FolderQuotaTargetSrv,
+		DefaultLimits: folderLimits,
+		Reporter:      s.CountFolders,
+		OrgsReporter:  s.CountFoldersInOrgs,
 	}); err != nil {
 		return nil, err
 	}
@@ -295,9 +311,110 @@ func (d *dashboardStore) Count(ctx context.Context, scopeParams *quota.ScopePara
 		}
 	}
 
+	if scopeParams != nil && scopeParams.OrgID != 0 && len(scopeParams.FolderUIDs) > 0 {
+		if err := d.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			dialect := d.store.GetDialect()
+			args := []any{scopeParams.OrgID, scopeParams.OrgID}
+			for _, uid := range scopeParams.FolderUIDs {
+				args = append(args, uid)
+			}
+			rawSQL := fmt.Sprintf("SELECT COUNT(*) AS count FROM dashboard WHERE org_id=? AND is_folder=%s AND folder_id IN (SELECT id FROM dashboard WHERE org_id=? AND is_folder=%s AND uid IN (?%s))",
+				dialect.BooleanStr(false), dialect.BooleanStr(true), strings.Repeat(",?", len(scopeParams.FolderUIDs)-1))
+			if _, err := sess.SQL(rawSQL, args...).Get(&r); err != nil {
+				return err
+			}
+			return nil
+		}); err != nil {
+			return u, err
+		} else {
+			tag, err := quota.NewTag(dashboards.QuotaTargetSrv, dashboards.QuotaTarget, quota.FolderScope)
+			if err != nil {
+				return nil, err
+			}
+			u.Set(tag, r.Count)
+		}
+	}
+
 	return u, nil
 }
 
+// CountFolders reports the number of folders, globally and in the organization of the scope.
+func (d *dashboardStore) CountFolders(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	u := &quota.Map{}
+	type result struct {
+		Count int64
+	}
+
+	r := result{}
+	if err := d.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := fmt.Sprintf("SELECT COUNT(*) AS count FROM dashboard WHERE is_folder=%s", d.store.GetDialect().BooleanStr(true))
+		_, err := sess.SQL(rawSQL).Get(&r)
+		return err
+	}); err != nil {
+		return u, err
+	}
+	tag, err := quota.NewTag(dashboards.FolderQuotaTargetSrv, dashboards.FolderQuotaTarget, quota.GlobalScope)
+	if err != nil {
+		return nil, err
+	}
+	u.Set(tag, r.Count)
+
+	if scopeParams != nil && scopeParams.OrgID != 0 {
+		if err := d.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			rawSQL := fmt.Sprintf("SELECT COUNT(*) AS count FROM dashboard WHERE org_id=? AND is_folder=%s", d.store.GetDialect().BooleanStr(true))
+			_, err := sess.SQL(rawSQL, scopeParams.OrgID).Get(&r)
+			return err
+		}); err != nil {
+			return u, err
+		}
+		tag, err := quota.NewTag(dashboards.FolderQuotaTargetSrv, dashboards.FolderQuotaTarget, quota.OrgScope)
+		if err != nil {
+			return nil, err
+		}
+		u.Set(tag, r.Count)
+	}
+
+	return u, nil
+}
+
+// CountInOrgs reports the number of dashboards of every organization.
+func (d *dashboardStore) CountInOrgs(ctx context.Context) (map[int64]*quota.Map, error) {
+	return d.countInOrgs(ctx, false, dashboards.QuotaTargetSrv, dashboards.QuotaTarget)
+}
+
+// CountFoldersInOrgs reports the number of folders of every organization.
+func (d *dashboardStore) CountFoldersInOrgs(ctx context.Context) (map[int64]*quota.Map, error) {
+	return d.countInOrgs(ctx, true, dashboards.FolderQuotaTargetSrv, dashboards.FolderQuotaTarget)
+}
+
+// countInOrgs counts the dashboards, or the folders, of every organization with a single query.
+func (d *dashboardStore) countInOrgs(ctx context.Context, isFolder bool, targetSrv quota.TargetSrv, target quota.Target) (map[int64]*quota.Map, error) {
+	type result struct {
+		OrgID int64 `xorm:"org_id"`
+		Count int64
+	}
+
+	var results []result
+	if err := d.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := fmt.Sprintf("SELECT org_id, COUNT(*) AS count FROM dashboard WHERE is_folder=%s GROUP BY org_id", d.store.GetDialect().BooleanStr(isFolder))
+		return sess.SQL(rawSQL).Find(&results)
+	}); err != nil {
+		return nil, err
+	}
+
+	tag, err := quota.NewTag(targetSrv, target, quota.OrgScope)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[int64]*quota.Map, len(results))
+	for _, r := range results {
+		u := &quota.Map{}
+		u.Set(tag, r.Count)
+		usage[r.OrgID] = u
+	}
+	return usage, nil
+}
+
 func getExistingDashboardByIDOrUIDForUpdate(sess *db.Session, dash *dashboards.Dashboard, dialect migrator.Dialect, overwrite bool) (bool, error) {
 	dashWithIdExists := false
 	isParentFolderChanged := false
@@ -725,6 +842,11 @@ func (d *dashboardStore) deleteDashboard(cmd *dashboards.DeleteDashboardCommand,
 		if err := deleteFolderAlertRules(sess, dashboard, cmd.ForceDeleteFolderRules); err != nil {
 			return err
 		}
+
+		// remove the quota of the folder, they would otherwise apply to a new folder with the same UID
+		if _, err := sess.Exec("DELETE FROM quota_folder WHERE org_id = ? AND folder_uid = ?", dashboard.OrgID, dashboard.UID); err != nil {
+			return err
+		}
 	} else {
 		if err := d.deleteResourcePermissions(sess, dashboard.OrgID, ac.GetResourceScopeUID("dashboards", dashboard.UID)); err != nil {
 			return err
@@ -1113,7 +1235,32 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return &quota.Map{}, err
 	}
 
+	folderQuotaTag, err := quota.NewTag(dashboards.QuotaTargetSrv, dashboards.QuotaTarget, quota.FolderScope)
+	if err != nil {
+		return &quota.Map{}, err
+	}
+
 	limits.Set(globalQuotaTag, cfg.Quota.Global.Dashboard)
 	limits.Set(orgQuotaTag, cfg.Quota.Org.Dashboard)
+	limits.Set(folderQuotaTag, cfg.Quota.Folder.Dashboard)
+	return limits, nil
+}
+
+// folderQuotaLimits returns the limits of the folder target, which are unlimited: the number of folders is reported
+// with the usage of the other targets, but it is not limited.
+func folderQuotaLimits() (*quota.Map, error) {
+	limits := &quota.Map{}
+
+	globalQuotaTag, err := quota.NewTag(dashboards.FolderQuotaTargetSrv, dashboards.FolderQuotaTarget, quota.GlobalScope)
+	if err != nil {
+		return &quota.Map{}, err
+	}
+	orgQuotaTag, err := quota.NewTag(dashboards.FolderQuotaTargetSrv, dashboards.FolderQuotaTarget, quota.OrgScope)
+	if err != nil {
+		return &quota.Map{}, err
+	}
+
+	limits.Set(globalQuotaTag, -1)
+	limits.Set(orgQuotaTag, -1)
 	return limits, nil
 }
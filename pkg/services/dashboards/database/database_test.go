@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/search/model"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -249,9 +250,29 @@ func TestIntegrationDashboardDataAccess(t *testing.T) {
 	t.Run("Should be able to delete empty folder", func(t *testing.T) {
 		setup()
 		emptyFolder := insertTestDashboard(t, dashboardStore, "2 test dash folder", 1, 0, true, "prod", "webapp")
+		err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Table("quota_folder").Insert(&quota.FolderQuota{
+				OrgId:     emptyFolder.OrgID,
+				FolderUid: emptyFolder.UID,
+				Target:    string(dashboards.QuotaTarget),
+				Limit:     1,
+				Created:   time.Now(),
+				Updated:   time.Now(),
+			})
+			return err
+		})
+		require.NoError(t, err)
 
 		deleteCmd := &dashboards.DeleteDashboardCommand{ID: emptyFolder.ID}
-		err := dashboardStore.DeleteDashboard(context.Background(), deleteCmd)
+		err = dashboardStore.DeleteDashboard(context.Background(), deleteCmd)
+		require.NoError(t, err)
+
+		err = sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			count, err := sess.Table("quota_folder").Where("org_id = ? AND folder_uid = ?", emptyFolder.OrgID, emptyFolder.UID).Count()
+			require.NoError(t, err)
+			require.Zero(t, count)
+			return nil
+		})
 		require.NoError(t, err)
 	})
 
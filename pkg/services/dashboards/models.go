@@ -361,6 +361,9 @@ type DashboardSearchProjection struct {
 const (
 	QuotaTargetSrv quota.TargetSrv = "dashboard"
 	QuotaTarget    quota.Target    = "dashboard"
+
+	FolderQuotaTargetSrv quota.TargetSrv = "folder"
+	FolderQuotaTarget    quota.Target    = "folder"
 )
 
 type CountDashboardsInFolderQuery struct {
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/search/model"
 	"github.com/grafana/grafana/pkg/services/store/entity"
 	"github.com/grafana/grafana/pkg/setting"
@@ -48,6 +49,7 @@ type DashboardServiceImpl struct {
 	folderPermissions    accesscontrol.FolderPermissionsService
 	dashboardPermissions accesscontrol.DashboardPermissionsService
 	ac                   accesscontrol.AccessControl
+	quotaService         quota.Service
 }
 
 // This is the uber service that implements a three smaller services
@@ -55,7 +57,7 @@ func ProvideDashboardServiceImpl(
 	cfg *setting.Cfg, dashboardStore dashboards.Store, folderStore folder.FolderStore, dashAlertExtractor alerting.DashAlertExtractor,
 	features featuremgmt.FeatureToggles, folderPermissionsService accesscontrol.FolderPermissionsService,
 	dashboardPermissionsService accesscontrol.DashboardPermissionsService, ac accesscontrol.AccessControl,
-	folderSvc folder.Service,
+	folderSvc folder.Service, quotaService quota.Service,
 ) (*DashboardServiceImpl, error) {
 	dashSvc := &DashboardServiceImpl{
 		cfg:                  cfg,
@@ -68,6 +70,7 @@ func ProvideDashboardServiceImpl(
 		ac:                   ac,
 		folderStore:          folderStore,
 		folderService:        folderSvc,
+		quotaService:         quotaService,
 	}
 
 	ac.RegisterScopeAttributeResolver(dashboards.NewDashboardIDScopeResolver(folderStore, dashSvc, folderSvc))
@@ -149,6 +152,13 @@ func (dr *DashboardServiceImpl) BuildSaveDashboardCommand(ctx context.Context, d
 		}
 	}
 
+	// a dashboard is added to the folder when it is created in it or moved to it
+	if !dash.IsFolder && dash.FolderID > 0 && (dash.ID == 0 || isParentFolderChanged) {
+		if err := dr.checkFolderQuota(ctx, dto.OrgID, dash.FolderID); err != nil {
+			return nil, err
+		}
+	}
+
 	if validateProvisionedDashboard {
 		provisionedData, err := dr.GetProvisionedDashboardDataByDashboardID(ctx, dash.ID)
 		if err != nil {
@@ -366,6 +376,20 @@ func (dr *DashboardServiceImpl) SaveFolderForProvisionedDashboards(ctx context.C
 	return dash, nil
 }
 
+// checkFolderQuota returns quota.ErrFolderQuotaReached if the folder, or one of its parents, cannot contain more
+// dashboards.
+func (dr *DashboardServiceImpl) checkFolderQuota(ctx context.Context, orgID int64, folderID int64) error {
+	f, err := dr.folderStore.GetFolderByID(ctx, orgID, folderID)
+	if err != nil {
+		return err
+	}
+	return dr.quotaService.CheckFolderQuotaReached(ctx, dashboards.QuotaTargetSrv, &quota.ScopeParameters{
+		OrgID:     orgID,
+		FolderUID: f.UID,
+		Count:     1,
+	})
+}
+
 func (dr *DashboardServiceImpl) SaveDashboard(ctx context.Context, dto *dashboards.SaveDashboardDTO,
 	allowUiUpdate bool) (*dashboards.Dashboard, error) {
 	if err := validateDashboardRefreshInterval(dto.Dashboard); err != nil {
@@ -870,7 +870,7 @@ func permissionScenario(t *testing.T, desc string, canSave bool, fn permissionSc
 			folderPermissions,
 			dashboardPermissions,
 			ac,
-			foldertest.NewFakeService(),
+			foldertest.NewFakeService(), quotatest.New(false, nil),
 		)
 		require.NoError(t, err)
 		guardian.InitAccessControlGuardian(cfg, ac, dashboardService)
@@ -936,7 +936,7 @@ func callSaveWithResult(t *testing.T, cmd dashboards.SaveDashboardCommand, sqlSt
 		folderPermissions,
 		dashboardPermissions,
 		actest.FakeAccessControl{},
-		foldertest.NewFakeService(),
+		foldertest.NewFakeService(), quotatest.New(false, nil),
 	)
 	require.NoError(t, err)
 	res, err := service.SaveDashboard(context.Background(), &dto, false)
@@ -959,7 +959,7 @@ func callSaveWithError(t *testing.T, cmd dashboards.SaveDashboardCommand, sqlSto
 		accesscontrolmock.NewMockedPermissionsService(),
 		accesscontrolmock.NewMockedPermissionsService(),
 		actest.FakeAccessControl{},
-		foldertest.NewFakeService(),
+		foldertest.NewFakeService(), quotatest.New(false, nil),
 	)
 	require.NoError(t, err)
 	_, err = service.SaveDashboard(context.Background(), &dto, false)
@@ -1001,7 +1001,7 @@ func saveTestDashboard(t *testing.T, title string, orgID, folderID int64, sqlSto
 		accesscontrolmock.NewMockedPermissionsService(),
 		dashboardPermissions,
 		actest.FakeAccessControl{},
-		foldertest.NewFakeService(),
+		foldertest.NewFakeService(), quotatest.New(false, nil),
 	)
 	require.NoError(t, err)
 	res, err := service.SaveDashboard(context.Background(), &dto, false)
@@ -1050,7 +1050,7 @@ func saveTestFolder(t *testing.T, title string, orgID int64, sqlStore db.DB) *da
 		folderPermissions,
 		accesscontrolmock.NewMockedPermissionsService(),
 		actest.FakeAccessControl{},
-		foldertest.NewFakeService(),
+		foldertest.NewFakeService(), quotatest.New(false, nil),
 	)
 	require.NoError(t, err)
 	res, err := service.SaveDashboard(context.Background(), &dto, false)
@@ -0,0 +1,42 @@
+package featuremgmt
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// editionOf returns the edition of Grafana the server runs. Cloud stacks are identified by their stack id.
+func editionOf(cfg *setting.Cfg) Edition {
+	switch {
+	case cfg.StackID != "":
+		return EditionCloud
+	case cfg.IsEnterprise:
+		return EditionEnterprise
+	}
+	return EditionOSS
+}
+
+// applyEditionDefaults replaces the default states of the toggles with the ones declared for the edition, so
+// services do not have to check the edition themselves. It must be called before the configured values are read, as
+// they take precedence over the defaults.
+func (fm *FeatureManager) applyEditionDefaults(edition Edition) {
+	fm.edition = edition
+	fm.defaults = make(map[string]string, len(fm.flags))
+	for name, flag := range fm.flags {
+		if on, ok := flag.EditionDefaults[edition]; ok {
+			flag.Expression = fmt.Sprintf("%t", on)
+		}
+		// Kept so the toggles that are not configured anymore get the default of the edition back on reload.
+		fm.defaults[name] = flag.Expression
+	}
+	fm.update()
+}
+
+// Edition returns the edition the default states of the toggles were picked for.
+func (fm *FeatureManager) Edition() Edition {
+	if fm.edition == "" {
+		return EditionOSS
+	}
+	return fm.edition
+}
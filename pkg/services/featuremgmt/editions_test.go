@@ -0,0 +1,49 @@
+package featuremgmt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestEditionDefaults(t *testing.T) {
+	newManager := func() *FeatureManager {
+		fm := &FeatureManager{
+			flags: map[string]*FeatureFlag{},
+			log:   log.NewNopLogger(),
+		}
+		fm.registerFlags(FeatureFlag{
+			Name:            "cloudOnly",
+			EditionDefaults: map[Edition]bool{EditionCloud: true},
+		}, FeatureFlag{
+			Name:            "notInCloud",
+			Expression:      "true",
+			EditionDefaults: map[Edition]bool{EditionCloud: false},
+		})
+		return fm
+	}
+
+	t.Run("should keep the defaults of the registry in other editions", func(t *testing.T) {
+		fm := newManager()
+		fm.applyEditionDefaults(EditionEnterprise)
+		require.Equal(t, EditionEnterprise, fm.Edition())
+		require.False(t, fm.IsEnabled("cloudOnly"))
+		require.True(t, fm.IsEnabled("notInCloud"))
+	})
+
+	t.Run("should pick the defaults of the edition", func(t *testing.T) {
+		fm := newManager()
+		fm.applyEditionDefaults(EditionCloud)
+		require.True(t, fm.IsEnabled("cloudOnly"))
+		require.False(t, fm.IsEnabled("notInCloud"))
+	})
+
+	t.Run("should identify the edition from the settings", func(t *testing.T) {
+		require.Equal(t, EditionOSS, editionOf(&setting.Cfg{}))
+		require.Equal(t, EditionEnterprise, editionOf(&setting.Cfg{IsEnterprise: true}))
+		require.Equal(t, EditionCloud, editionOf(&setting.Cfg{IsEnterprise: true, StackID: "12"}))
+	})
+}
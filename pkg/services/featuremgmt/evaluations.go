@@ -0,0 +1,99 @@
+package featuremgmt
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type evaluationsKey struct{}
+
+// ToggleEvaluations records the toggles evaluated during a request and their values, see WithEvaluationTracing.
+type ToggleEvaluations struct {
+	mu     sync.Mutex
+	values map[string]bool
+}
+
+// EvaluationTracingEnabled checks if the toggles evaluated during each request are recorded.
+func (fm *FeatureManager) EvaluationTracingEnabled() bool {
+	return fm.evaluationTracing
+}
+
+// WithEvaluationTracing returns a copy of the context that records the toggles evaluated with it by IsEnabledForUser.
+// The listings of GetEnabled are not recorded, see TraceEvaluations to also record the toggles evaluated with IsEnabled.
+func WithEvaluationTracing(ctx context.Context) (context.Context, *ToggleEvaluations) {
+	evaluations := &ToggleEvaluations{values: make(map[string]bool)}
+	return context.WithValue(ctx, evaluationsKey{}, evaluations), evaluations
+}
+
+// TraceEvaluations returns a copy of the context that records the toggles evaluated during a request, like
+// WithEvaluationTracing, and also the toggles evaluated with IsEnabled until the returned function is called. IsEnabled
+// has no context, so its evaluations are recorded for every request traced at the time, including the concurrent ones.
+func (fm *FeatureManager) TraceEvaluations(ctx context.Context) (context.Context, *ToggleEvaluations, func()) {
+	ctx, evaluations := WithEvaluationTracing(ctx)
+
+	fm.tracedMu.Lock()
+	if fm.traced == nil {
+		fm.traced = make(map[*ToggleEvaluations]struct{})
+	}
+	fm.traced[evaluations] = struct{}{}
+	fm.tracedCount.Store(int32(len(fm.traced)))
+	fm.tracedMu.Unlock()
+
+	return ctx, evaluations, func() {
+		fm.tracedMu.Lock()
+		delete(fm.traced, evaluations)
+		fm.tracedCount.Store(int32(len(fm.traced)))
+		fm.tracedMu.Unlock()
+	}
+}
+
+// recordEvaluation records the value of the toggle evaluated with the context, if it traces the evaluations.
+func recordEvaluation(ctx context.Context, name string, on bool) {
+	if evaluations, ok := ctx.Value(evaluationsKey{}).(*ToggleEvaluations); ok {
+		evaluations.record(name, on)
+	}
+}
+
+// recordTracedEvaluation records the value of the toggle evaluated with IsEnabled for the requests traced with
+// TraceEvaluations.
+func (fm *FeatureManager) recordTracedEvaluation(name string, on bool) {
+	if fm.tracedCount.Load() == 0 {
+		return
+	}
+	fm.tracedMu.Lock()
+	defer fm.tracedMu.Unlock()
+	for evaluations := range fm.traced {
+		evaluations.record(name, on)
+	}
+}
+
+func (e *ToggleEvaluations) record(name string, on bool) {
+	e.mu.Lock()
+	e.values[name] = on
+	e.mu.Unlock()
+}
+
+// Values returns the evaluated toggles and their values.
+func (e *ToggleEvaluations) Values() map[string]bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	values := make(map[string]bool, len(e.values))
+	for name, on := range e.values {
+		values[name] = on
+	}
+	return values
+}
+
+// String returns the evaluated toggles as a sorted, comma separated list of toggle=true|false.
+func (e *ToggleEvaluations) String() string {
+	values := e.Values()
+	pairs := make([]string, 0, len(values))
+	for name, on := range values {
+		pairs = append(pairs, name+"="+strconv.FormatBool(on))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,46 @@
+package featuremgmt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluationTracing(t *testing.T) {
+	fm := WithFeatureFlags([]*FeatureFlag{
+		{Name: "a", Enabled: true},
+		{Name: "b"},
+		{Name: "c"},
+	})
+
+	t.Run("should record the toggles evaluated with the context", func(t *testing.T) {
+		ctx, evaluations := WithEvaluationTracing(context.Background())
+		require.True(t, fm.IsEnabledForUser(ctx, "a"))
+		require.False(t, fm.IsEnabledForUser(ctx, "b"))
+		require.False(t, fm.IsEnabled("c"))
+
+		require.Equal(t, map[string]bool{"a": true, "b": false}, evaluations.Values())
+		require.Equal(t, "a=true,b=false", evaluations.String())
+	})
+
+	t.Run("should record the values of the overrides of the request", func(t *testing.T) {
+		ctx, evaluations := WithEvaluationTracing(WithRequestOverrides(context.Background(), map[string]bool{"a": false}))
+		require.False(t, fm.IsEnabledForUser(ctx, "a"))
+		require.Equal(t, "a=false", evaluations.String())
+	})
+
+	t.Run("should record the toggles evaluated with IsEnabled while the request is traced", func(t *testing.T) {
+		ctx, evaluations, done := fm.TraceEvaluations(context.Background())
+		require.True(t, fm.IsEnabledForUser(ctx, "a"))
+		require.False(t, fm.IsEnabled("c"))
+		done()
+		require.False(t, fm.IsEnabled("b"))
+
+		require.Equal(t, "a=true,c=false", evaluations.String())
+	})
+
+	t.Run("should not record without tracing", func(t *testing.T) {
+		require.True(t, fm.IsEnabledForUser(context.Background(), "a"))
+	})
+}
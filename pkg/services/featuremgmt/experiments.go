@@ -0,0 +1,123 @@
+package featuremgmt
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	experimentExposures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "feature_toggle_experiment_exposures_total",
+		Help:      "number of times a variant of a feature toggle experiment was exposed to the frontend",
+		Namespace: "grafana",
+	}, []string{"toggle", "variant"})
+
+	// exposureLog records the exposure events, so they can be joined with usage data for analysis.
+	exposureLog = log.New("featuremgmt.experiments")
+)
+
+// setExperiments registers the experiments of the toggles configured in [feature_toggles.experiments].
+func (fm *FeatureManager) setExperiments(experiments map[string]setting.FeatureToggleExperiment) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.experiments = make(map[string]setting.FeatureToggleExperiment, len(experiments))
+	for name, experiment := range experiments {
+		if _, ok := fm.flags[name]; !ok {
+			fm.log.Warn("Ignoring the experiment of an unknown feature toggle", "toggle", name)
+			continue
+		}
+		fm.experiments[name] = experiment
+	}
+}
+
+// AssignVariant returns the variant of the experiment of the toggle the user of the request in the context is
+// assigned to. Users, or organizations, are always assigned to the same variant as long as the variants of the
+// experiment do not change. Nothing is assigned if the toggle is not enabled for the user.
+func (fm *FeatureManager) AssignVariant(ctx context.Context, flag string) (string, bool) {
+	fm.mu.RLock()
+	experiment, ok := fm.experiments[flag]
+	fm.mu.RUnlock()
+	if !ok || !fm.IsEnabledForUser(ctx, flag) {
+		return "", false
+	}
+	usr, err := appcontext.User(ctx)
+	if err != nil || usr == nil {
+		return "", false
+	}
+
+	var unitID int64
+	switch experiment.Unit {
+	case setting.FeatureToggleExperimentUnitOrg:
+		unitID = usr.OrgID
+	default:
+		unitID = usr.UserID
+	}
+	// Anonymous users and users without an organization cannot be assigned consistently.
+	if unitID <= 0 {
+		return "", false
+	}
+	return assignVariant(flag, unitID, experiment.Variants)
+}
+
+// assignVariant hashes the toggle with the unit, so units get different variants in different experiments, and
+// picks the variant the hash falls into, in proportion to the weights of the variants.
+func assignVariant(flag string, unitID int64, variants []setting.FeatureToggleVariant) (string, bool) {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return "", false
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(flag + ":" + strconv.FormatInt(unitID, 10)))
+	bucket := int(h.Sum64() % uint64(total))
+	for _, v := range variants {
+		if bucket < v.Weight {
+			return v.Name, true
+		}
+		bucket -= v.Weight
+	}
+	return "", false
+}
+
+// GetExperimentAssignments returns the variants the user of the request in the context is assigned to, by toggle.
+func (fm *FeatureManager) GetExperimentAssignments(ctx context.Context) map[string]string {
+	fm.mu.RLock()
+	names := make([]string, 0, len(fm.experiments))
+	for name := range fm.experiments {
+		names = append(names, name)
+	}
+	fm.mu.RUnlock()
+	sort.Strings(names)
+
+	assignments := make(map[string]string, len(names))
+	for _, name := range names {
+		if variant, ok := fm.AssignVariant(ctx, name); ok {
+			assignments[name] = variant
+		}
+	}
+	return assignments
+}
+
+// RecordExposures records that the user of the request in the context was exposed to the variants.
+func (fm *FeatureManager) RecordExposures(ctx context.Context, assignments map[string]string) {
+	var userID, orgID int64
+	if usr, err := appcontext.User(ctx); err == nil && usr != nil {
+		userID, orgID = usr.UserID, usr.OrgID
+	}
+	for toggle, variant := range assignments {
+		experimentExposures.WithLabelValues(toggle, variant).Inc()
+		exposureLog.Info("Feature toggle experiment exposure", "toggle", toggle, "variant", variant, "userId", userID, "orgId", orgID)
+	}
+}
@@ -0,0 +1,88 @@
+package featuremgmt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestExperiments(t *testing.T) {
+	fm := &FeatureManager{
+		flags: map[string]*FeatureFlag{},
+		log:   log.NewNopLogger(),
+	}
+	fm.registerFlags(FeatureFlag{
+		Name:       "a",
+		Expression: "true",
+	}, FeatureFlag{
+		Name:       "b",
+		Expression: "true",
+	}, FeatureFlag{
+		Name: "c",
+	})
+	variants := []setting.FeatureToggleVariant{{Name: "control", Weight: 1}, {Name: "treatment", Weight: 1}}
+	fm.setExperiments(map[string]setting.FeatureToggleExperiment{
+		"a":       {Variants: variants, Unit: setting.FeatureToggleExperimentUnitUser},
+		"b":       {Variants: variants, Unit: setting.FeatureToggleExperimentUnitOrg},
+		"c":       {Variants: variants, Unit: setting.FeatureToggleExperimentUnitUser},
+		"unknown": {Variants: variants, Unit: setting.FeatureToggleExperimentUnitUser},
+	})
+	withUser := func(userID, orgID int64) context.Context {
+		return appcontext.WithUser(context.Background(), &user.SignedInUser{UserID: userID, OrgID: orgID})
+	}
+
+	t.Run("should assign the same variant to the same user", func(t *testing.T) {
+		for i := int64(1); i <= 20; i++ {
+			first, ok := fm.AssignVariant(withUser(i, 1), "a")
+			require.True(t, ok)
+			second, ok := fm.AssignVariant(withUser(i, 2), "a")
+			require.True(t, ok)
+			require.Equal(t, first, second)
+		}
+	})
+
+	t.Run("should assign the same variant to the users of an organization", func(t *testing.T) {
+		first, ok := fm.AssignVariant(withUser(1, 3), "b")
+		require.True(t, ok)
+		for i := int64(2); i <= 20; i++ {
+			variant, ok := fm.AssignVariant(withUser(i, 3), "b")
+			require.True(t, ok)
+			require.Equal(t, first, variant)
+		}
+	})
+
+	t.Run("should split the users between the variants", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := int64(1); i <= 1000; i++ {
+			variant, _ := fm.AssignVariant(withUser(i, 1), "a")
+			counts[variant]++
+		}
+		require.Len(t, counts, 2)
+		require.InDelta(t, 500, counts["control"], 100)
+		require.InDelta(t, 500, counts["treatment"], 100)
+	})
+
+	t.Run("should not assign disabled toggles, anonymous users or unknown toggles", func(t *testing.T) {
+		_, ok := fm.AssignVariant(withUser(1, 1), "c")
+		require.False(t, ok)
+		_, ok = fm.AssignVariant(withUser(0, 1), "a")
+		require.False(t, ok)
+		_, ok = fm.AssignVariant(context.Background(), "a")
+		require.False(t, ok)
+		_, ok = fm.AssignVariant(withUser(1, 1), "unknown")
+		require.False(t, ok)
+	})
+
+	t.Run("should list the assignments of the user", func(t *testing.T) {
+		assignments := fm.GetExperimentAssignments(withUser(1, 1))
+		require.Len(t, assignments, 2)
+		require.Contains(t, assignments, "a")
+		require.Contains(t, assignments, "b")
+	})
+}
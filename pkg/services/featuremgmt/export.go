@@ -0,0 +1,104 @@
+package featuremgmt
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/supportbundles"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ToggleStateExport is the effective state of a toggle with every layer it was computed from. The layers that do not
+// set the toggle are omitted.
+type ToggleStateExport struct {
+	Name            string           `json:"name"`
+	Stage           FeatureFlagStage `json:"stage"`
+	Enabled         bool             `json:"enabled"`
+	Available       bool             `json:"available"`
+	RequiresRestart bool             `json:"requiresRestart,omitempty"`
+	RequiresLicense bool             `json:"requiresLicense,omitempty"`
+	RequiresDevMode bool             `json:"requiresDevMode,omitempty"`
+
+	// Default is the state of the toggle in the registry.
+	Default bool `json:"default"`
+	// Configured is the state configured on startup, in the configuration files or features.yaml.
+	Configured bool `json:"configured"`
+	// Reloaded is the state read when the configuration files were reloaded.
+	Reloaded *bool `json:"reloaded,omitempty"`
+	// Remote is the state set by the remote source.
+	Remote *bool `json:"remote,omitempty"`
+	// Runtime is the state changed at runtime, which takes precedence over the other layers.
+	Runtime *bool `json:"runtime,omitempty"`
+	// StartAt and EndAt are the schedule of the toggle.
+	StartAt *time.Time `json:"startAt,omitempty"`
+	EndAt   *time.Time `json:"endAt,omitempty"`
+	// Targeting lists the users, teams and roles the toggle is enabled for, when it is not enabled for the instance.
+	Targeting *setting.FeatureToggleTargeting `json:"targeting,omitempty"`
+}
+
+// ExportState returns the effective state of every toggle, sorted by name.
+func (fm *FeatureManager) ExportState() []ToggleStateExport {
+	defaults := make(map[string]bool, len(standardFeatureFlags))
+	for _, flag := range standardFeatureFlags {
+		defaults[flag.Name] = flag.Expression == "true"
+	}
+
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	states := make([]ToggleStateExport, 0, len(fm.flags))
+	for name, flag := range fm.flags {
+		state := ToggleStateExport{
+			Name:            name,
+			Stage:           flag.Stage,
+			Enabled:         fm.enabled[name],
+			Available:       fm.meetsRequirements(flag),
+			RequiresRestart: flag.RequiresRestart,
+			RequiresLicense: flag.RequiresLicense,
+			RequiresDevMode: flag.RequiresDevMode,
+			Default:         defaults[name],
+			Configured:      flag.Expression == "true",
+			Reloaded:        layerState(fm.reloaded, name),
+			Remote:          layerState(fm.remote, name),
+			Runtime:         layerState(fm.runtime, name),
+			StartAt:         flag.StartAt,
+			EndAt:           flag.EndAt,
+		}
+		if t, ok := fm.targeting[name]; ok {
+			state.Targeting = &t
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+func layerState(layer map[string]bool, name string) *bool {
+	on, ok := layer[name]
+	if !ok {
+		return nil
+	}
+	return &on
+}
+
+// SupportBundleCollector returns the collector of the effective state of the toggles for support bundles.
+func (fm *FeatureManager) SupportBundleCollector() supportbundles.Collector {
+	return supportbundles.Collector{
+		UID:               "feature-toggles",
+		DisplayName:       "Feature toggles",
+		Description:       "Effective state of the feature toggles, with their default, configured, remote and runtime states",
+		IncludedByDefault: false,
+		Default:           true,
+		Fn: func(ctx context.Context) (*supportbundles.SupportItem, error) {
+			data, err := json.MarshalIndent(fm.ExportState(), "", " ")
+			if err != nil {
+				return nil, err
+			}
+			return &supportbundles.SupportItem{
+				Filename:  "feature-toggles.json",
+				FileBytes: data,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package featuremgmt
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestExportState(t *testing.T) {
+	fm := &FeatureManager{
+		flags: map[string]*FeatureFlag{},
+		log:   log.NewNopLogger(),
+	}
+	fm.registerFlags(FeatureFlag{
+		Name:           "b",
+		Expression:     "true",
+		RuntimeMutable: true,
+	}, FeatureFlag{
+		Name:            "a",
+		RequiresLicense: true,
+	})
+	fm.setRemoteStates(map[string]bool{"b": false})
+	require.NoError(t, fm.SetRuntimeState(context.Background(), "b", true))
+	fm.setTargeting(map[string]setting.FeatureToggleTargeting{"a": {UserIDs: []int64{1}}})
+
+	states := fm.ExportState()
+	require.Len(t, states, 2)
+
+	a := states[0]
+	require.Equal(t, "a", a.Name)
+	require.False(t, a.Enabled)
+	require.False(t, a.Available)
+	require.Nil(t, a.Remote)
+	require.Nil(t, a.Runtime)
+	require.Equal(t, []int64{1}, a.Targeting.UserIDs)
+
+	b := states[1]
+	require.Equal(t, "b", b.Name)
+	require.True(t, b.Enabled)
+	require.True(t, b.Configured)
+	require.False(t, *b.Remote)
+	require.True(t, *b.Runtime)
+
+	item, err := fm.SupportBundleCollector().Fn(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "feature-toggles.json", item.Filename)
+	var exported []ToggleStateExport
+	require.NoError(t, json.Unmarshal(item.FileBytes, &exported))
+	require.Equal(t, states, exported)
+}
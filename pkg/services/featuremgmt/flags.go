@@ -0,0 +1,17 @@
+package featuremgmt
+
+// Flags gives typed access to the state of the feature toggles. Its accessors are generated from the registry in
+// toggles_accessors_gen.go, so the toggles that are removed from the registry fail the build of their callers.
+type Flags struct {
+	features FeatureToggles
+}
+
+// NewFlags returns the typed accessors of the feature toggles
+func NewFlags(features FeatureToggles) Flags {
+	return Flags{features: features}
+}
+
+// Flags returns the typed accessors of the feature toggles
+func (fm *FeatureManager) Flags() Flags {
+	return NewFlags(fm)
+}
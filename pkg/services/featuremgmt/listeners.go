@@ -0,0 +1,69 @@
+package featuremgmt
+
+import "time"
+
+// ToggleChangeEvent is sent to the change listeners when toggles change, with the version of the state of the
+// toggles after the changes.
+type ToggleChangeEvent struct {
+	Version int64          `json:"version"`
+	Changes []ToggleChange `json:"changes"`
+}
+
+// FrontendToggleNotification is sent to the browsers when frontend only toggles change. It does not hold the states of
+// the toggles, which can depend on the user, so the browsers fetch them again with the frontend settings.
+type FrontendToggleNotification struct {
+	// Version is the version of the state of the toggles, see StateVersion.
+	Version int64 `json:"version"`
+}
+
+// AddChangeListener registers a function called in the background with every change of the toggles. The events may
+// be received out of order, their version orders them.
+func (fm *FeatureManager) AddChangeListener(listener func(ToggleChangeEvent)) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.listeners = append(fm.listeners, listener)
+}
+
+// StateVersion returns the version of the state of the toggles of this instance, so clients can tell if they missed
+// changes. It is the time of the last change in milliseconds, so that it only grows across restarts and between the
+// instances of a high availability setup, and 0 until the first change.
+func (fm *FeatureManager) StateVersion() int64 {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	return fm.version
+}
+
+// GetFrontendToggleNotification returns the notification of the current version of the state of the toggles.
+func (fm *FeatureManager) GetFrontendToggleNotification() FrontendToggleNotification {
+	return FrontendToggleNotification{Version: fm.StateVersion()}
+}
+
+// HasFrontendChanges checks if any of the changes is of a frontend only toggle.
+func (fm *FeatureManager) HasFrontendChanges(changes []ToggleChange) bool {
+	for _, change := range changes {
+		if flag, ok := fm.flags[change.Name]; ok && flag.FrontendOnly && !flag.Internal {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyListenersLocked increases the version of the state of the toggles, and sends the changes to the listeners.
+func (fm *FeatureManager) notifyListenersLocked(changes []ToggleChange) {
+	if len(changes) == 0 {
+		return
+	}
+	now := time.Now()
+	if fm.now != nil {
+		now = fm.now()
+	}
+	if version := now.UnixMilli(); version > fm.version {
+		fm.version = version
+	} else {
+		fm.version++
+	}
+	event := ToggleChangeEvent{Version: fm.version, Changes: changes}
+	for _, listener := range fm.listeners {
+		go listener(event)
+	}
+}
@@ -0,0 +1,50 @@
+package featuremgmt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeListeners(t *testing.T) {
+	fm := WithFeatureFlags([]*FeatureFlag{
+		{Name: "backend", RuntimeMutable: true},
+		{Name: "frontend", FrontendOnly: true, RuntimeMutable: true},
+		{Name: "hidden", FrontendOnly: true, Internal: true},
+	})
+	now := time.UnixMilli(1000)
+	fm.now = func() time.Time { return now }
+	events := make(chan ToggleChangeEvent, 1)
+	fm.AddChangeListener(func(e ToggleChangeEvent) {
+		events <- e
+	})
+	require.Equal(t, int64(0), fm.StateVersion())
+
+	t.Run("should notify the listeners with the time of the change as version", func(t *testing.T) {
+		require.NoError(t, fm.SetRuntimeState(context.Background(), "frontend", true))
+		e := <-events
+		require.Equal(t, int64(1000), e.Version)
+		require.Len(t, e.Changes, 1)
+		require.Equal(t, "frontend", e.Changes[0].Name)
+		require.True(t, fm.HasFrontendChanges(e.Changes))
+		require.Equal(t, int64(1000), fm.StateVersion())
+	})
+
+	t.Run("should increase the version of changes made at the same time", func(t *testing.T) {
+		require.NoError(t, fm.SetRuntimeState(context.Background(), "backend", true))
+		e := <-events
+		require.Equal(t, int64(1001), e.Version)
+		require.False(t, fm.HasFrontendChanges(e.Changes))
+	})
+
+	t.Run("should not notify the listeners without changes", func(t *testing.T) {
+		require.NoError(t, fm.SetRuntimeState(context.Background(), "backend", true))
+		require.Equal(t, int64(1001), fm.StateVersion())
+	})
+
+	t.Run("should notify the browsers of the version only", func(t *testing.T) {
+		require.Equal(t, FrontendToggleNotification{Version: 1001}, fm.GetFrontendToggleNotification())
+	})
+}
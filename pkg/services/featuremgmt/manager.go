@@ -4,9 +4,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/grafana/grafana/pkg/infra/appcontext"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
@@ -21,6 +26,49 @@ type FeatureManager struct {
 	config    string          // path to config file
 	vars      map[string]any
 	log       log.Logger
+	edition   Edition                      // edition the default states were picked for, see applyEditionDefaults
+	defaults  map[string]string            // default expressions of the toggles for the edition, see applyEditionDefaults
+	configs   map[string]map[string]string // parameters of the toggles, set on startup, see ParseToggleConfig
+	// configured holds the toggles with a state set in the configuration files on startup, see GetStaleToggles.
+	configured map[string]struct{}
+
+	// mu guards enabled, runtime, reloaded and remote, which change when toggles are set at runtime.
+	mu sync.RWMutex
+	// runtime holds the states changed at runtime, which take precedence over the configured ones.
+	runtime      map[string]bool
+	runtimeStore RuntimeToggleStore
+	// reloaded holds the states read from the configuration files by the ConfigWatcher, which replace the ones read
+	// on startup.
+	reloaded map[string]bool
+	// reloadedConfigured holds the toggles with a state set in the reloaded configuration files, which replace the
+	// configured ones, see GetStaleToggles.
+	reloadedConfigured map[string]struct{}
+	// remote holds the states fetched by the RemoteSource, which take precedence over the configured ones.
+	remote map[string]bool
+	// now returns the time the schedules of the toggles are evaluated at, it is time.Now if nil.
+	now func() time.Time
+	// webhook is notified of the changes of runtime and remote states, it is nil if no endpoint is configured.
+	webhook *changeWebhook
+	// targeting holds the users, teams and roles the toggles are enabled for, see IsEnabledForUser.
+	targeting map[string]setting.FeatureToggleTargeting
+	// experiments holds the variants of the toggles users are assigned to, see AssignVariant.
+	experiments map[string]setting.FeatureToggleExperiment
+	// snapshotStore holds the saved states of the toggles by name, see RestoreSnapshot.
+	snapshotStore ToggleSnapshotStore
+	// overridesSecret is the secret the per-request overrides are signed with, see ParseRequestOverrides.
+	overridesSecret string
+	// pending holds the changes of toggles that require a restart, see RequestChange.
+	pending map[string]PendingToggleChange
+	// evaluationTracing enables recording the toggles evaluated during each request, see WithEvaluationTracing.
+	evaluationTracing bool
+	// traced holds the evaluations of the requests in progress that record the toggles evaluated with IsEnabled, see
+	// TraceEvaluations. tracedCount is its size, read without the lock by IsEnabled.
+	traced      map[*ToggleEvaluations]struct{}
+	tracedMu    sync.Mutex
+	tracedCount atomic.Int32
+	// version increases with every change of enabled, see StateVersion, and the listeners are notified of the changes.
+	version   int64
+	listeners []func(ToggleChangeEvent)
 }
 
 // This will merge the flags with the current configuration
@@ -43,15 +91,34 @@ func (fm *FeatureManager) registerFlags(flags ...FeatureFlag) {
 		if add.DocsURL != "" {
 			flag.DocsURL = add.DocsURL
 		}
+		if add.AddedInVersion != "" {
+			flag.AddedInVersion = add.AddedInVersion
+		}
 		if add.Expression != "" {
 			flag.Expression = add.Expression
 		}
+		for edition, on := range add.EditionDefaults {
+			if flag.EditionDefaults == nil {
+				flag.EditionDefaults = make(map[Edition]bool, len(add.EditionDefaults))
+			}
+			flag.EditionDefaults[edition] = on
+		}
+		if add.StartAt != nil {
+			flag.StartAt = add.StartAt
+		}
+		if add.EndAt != nil {
+			flag.EndAt = add.EndAt
+		}
 
 		// The most recently defined state
 		if add.Stage != FeatureStageUnknown {
 			flag.Stage = add.Stage
 		}
 
+		if add.RuntimeMutable {
+			flag.RuntimeMutable = true
+		}
+
 		// Only gets more restrictive
 		if add.RequiresDevMode {
 			flag.RequiresDevMode = true
@@ -64,6 +131,10 @@ func (fm *FeatureManager) registerFlags(flags ...FeatureFlag) {
 		if add.RequiresRestart {
 			flag.RequiresRestart = true
 		}
+
+		if add.Internal {
+			flag.Internal = true
+		}
 	}
 
 	// This will evaluate all flags
@@ -85,6 +156,12 @@ func (fm *FeatureManager) meetsRequirements(ff *FeatureFlag) bool {
 
 // Update
 func (fm *FeatureManager) update() {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.updateLocked()
+}
+
+func (fm *FeatureManager) updateLocked() {
 	enabled := make(map[string]bool)
 	for _, flag := range fm.flags {
 		// if grafana cannot run the feature, omit metrics around it
@@ -93,15 +170,26 @@ func (fm *FeatureManager) update() {
 		}
 
 		// Update the registry
-		track := 0.0
 		// TODO: CEL - expression
-		if flag.Expression == "true" {
-			track = 1
+		on := flag.Expression == "true"
+		if state, ok := fm.reloaded[flag.Name]; ok {
+			on = state
+		}
+		if state, ok := fm.scheduledState(flag); ok {
+			on = state
+		}
+		if state, ok := fm.remote[flag.Name]; ok {
+			on = state
+		}
+		if state, ok := fm.runtime[flag.Name]; ok {
+			on = state
+		}
+		if on {
 			enabled[flag.Name] = true
 		}
 
 		// Register value with prometheus metric
-		featureToggleInfo.WithLabelValues(flag.Name).Set(track)
+		setToggleMetrics(flag.Name, on)
 	}
 	fm.enabled = enabled
 }
@@ -118,6 +206,11 @@ func (fm *FeatureManager) readFile() error {
 	}
 
 	fm.registerFlags(cfg.Flags...)
+	for _, flag := range cfg.Flags {
+		if flag.Expression != "" {
+			fm.markConfigured(flag.Name)
+		}
+	}
 	fm.vars = cfg.Vars
 
 	return nil
@@ -125,11 +218,21 @@ func (fm *FeatureManager) readFile() error {
 
 // IsEnabled checks if a feature is enabled
 func (fm *FeatureManager) IsEnabled(flag string) bool {
-	return fm.enabled[flag]
+	fm.mu.RLock()
+	on := fm.enabled[flag]
+	fm.mu.RUnlock()
+	fm.recordTracedEvaluation(flag, on)
+	return on
 }
 
-// GetEnabled returns a map containing only the features that are enabled
+// GetEnabled returns a map containing only the features that are enabled for the whole instance
 func (fm *FeatureManager) GetEnabled(ctx context.Context) map[string]bool {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	return fm.getEnabledLocked()
+}
+
+func (fm *FeatureManager) getEnabledLocked() map[string]bool {
 	enabled := make(map[string]bool, len(fm.enabled))
 	for key, val := range fm.enabled {
 		if val {
@@ -139,6 +242,54 @@ func (fm *FeatureManager) GetEnabled(ctx context.Context) map[string]bool {
 	return enabled
 }
 
+// getEnabledForRequest returns the features that are enabled for the request in the context: the ones enabled for the
+// whole instance, the ones enabled for its user, and the overrides of the request
+func (fm *FeatureManager) getEnabledForRequest(ctx context.Context) map[string]bool {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	enabled := fm.getEnabledLocked()
+	if len(fm.targeting) > 0 {
+		if usr, err := appcontext.User(ctx); err == nil {
+			for key := range fm.targeting {
+				if fm.isTargetedLocked(key, usr) {
+					enabled[key] = true
+				}
+			}
+		}
+	}
+	for key, on := range requestOverrides(ctx) {
+		if on {
+			enabled[key] = true
+		} else {
+			delete(enabled, key)
+		}
+	}
+	return enabled
+}
+
+// GetFrontendEnabled returns the enabled flags sent to the frontend, which excludes the internal flags
+func (fm *FeatureManager) GetFrontendEnabled(ctx context.Context) map[string]bool {
+	enabled := fm.getEnabledForRequest(ctx)
+	for key := range enabled {
+		if flag, ok := fm.flags[key]; ok && flag.Internal {
+			delete(enabled, key)
+		}
+	}
+	return enabled
+}
+
+// GetEnabledForPlugins returns the enabled flags sent to backend plugins on each request, which excludes the
+// frontend only and internal flags
+func (fm *FeatureManager) GetEnabledForPlugins(ctx context.Context) map[string]bool {
+	enabled := fm.getEnabledForRequest(ctx)
+	for key := range enabled {
+		if flag, ok := fm.flags[key]; ok && (flag.FrontendOnly || flag.Internal) {
+			delete(enabled, key)
+		}
+	}
+	return enabled
+}
+
 // GetFlags returns all flag definitions
 func (fm *FeatureManager) GetFlags() []FeatureFlag {
 	v := make([]FeatureFlag, 0, len(fm.flags))
@@ -4,7 +4,14 @@ import (
 	"context"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestFeatureManager(t *testing.T) {
@@ -24,6 +31,13 @@ func TestFeatureManager(t *testing.T) {
 		require.Equal(t, map[string]bool{"a": true}, ft.GetEnabled(context.Background()))
 	})
 
+	t.Run("check typed accessors", func(t *testing.T) {
+		ft := WithFeatures(FlagNestedFolders)
+		require.True(t, ft.Flags().NestedFolders())
+		require.False(t, ft.Flags().PanelTitleSearch())
+		require.False(t, NewFlags(WithFeatures()).NestedFolders())
+	})
+
 	t.Run("check license validation", func(t *testing.T) {
 		ft := FeatureManager{
 			flags: map[string]*FeatureFlag{},
@@ -74,4 +88,253 @@ func TestFeatureManager(t *testing.T) {
 		require.Equal(t, "second", flag.Description)
 		require.Equal(t, "http://something", flag.DocsURL)
 	})
+
+	t.Run("check internal flags", func(t *testing.T) {
+		ft := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		ft.registerFlags(FeatureFlag{
+			Name:       "a",
+			Expression: "true",
+		}, FeatureFlag{
+			Name:       "b",
+			Expression: "true",
+		}, FeatureFlag{
+			Name:     "b",
+			Internal: true,
+		})
+		require.True(t, ft.IsEnabled("b"))
+		require.Equal(t, map[string]bool{"a": true, "b": true}, ft.GetEnabled(context.Background()))
+		require.Equal(t, map[string]bool{"a": true}, ft.GetFrontendEnabled(context.Background()))
+	})
+
+	t.Run("check plugin flags", func(t *testing.T) {
+		ft := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		ft.registerFlags(FeatureFlag{
+			Name:       "a",
+			Expression: "true",
+		}, FeatureFlag{
+			Name:         "b",
+			Expression:   "true",
+			FrontendOnly: true,
+		}, FeatureFlag{
+			Name:       "c",
+			Expression: "true",
+			Internal:   true,
+		})
+		require.Equal(t, map[string]bool{"a": true}, ft.GetEnabledForPlugins(context.Background()))
+	})
+
+	t.Run("check runtime states", func(t *testing.T) {
+		ft := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		ft.registerFlags(FeatureFlag{
+			Name:           "a",
+			Expression:     "true",
+			RuntimeMutable: true,
+		}, FeatureFlag{
+			Name:           "b",
+			RuntimeMutable: true,
+		}, FeatureFlag{
+			Name:            "c",
+			RequiresRestart: true,
+			RuntimeMutable:  true,
+		}, FeatureFlag{
+			Name:            "d",
+			RequiresLicense: true,
+			RuntimeMutable:  true,
+		}, FeatureFlag{
+			Name:     "f",
+			Internal: true,
+		}, FeatureFlag{
+			Name: "g",
+		})
+
+		require.NoError(t, ft.SetRuntimeState(context.Background(), "a", false))
+		require.NoError(t, ft.SetRuntimeState(context.Background(), "b", true))
+		require.ErrorIs(t, ft.SetRuntimeState(context.Background(), "c", true), ErrFeatureToggleRequiresRestart)
+		require.ErrorIs(t, ft.SetRuntimeState(context.Background(), "d", true), ErrFeatureToggleUnavailable)
+		require.ErrorIs(t, ft.SetRuntimeState(context.Background(), "e", true), ErrFeatureToggleNotFound)
+		require.ErrorIs(t, ft.SetRuntimeState(context.Background(), "f", true), ErrFeatureToggleInternal)
+		// Toggles that do not opt in cannot be changed at runtime
+		require.ErrorIs(t, ft.SetRuntimeState(context.Background(), "g", true), ErrFeatureToggleRequiresRestart)
+		state, ok := ft.GetRuntimeState("g")
+		require.True(t, ok)
+		require.True(t, state.RequiresRestart)
+		_, err := ft.RequestChange(context.Background(), "f", true)
+		require.ErrorIs(t, err, ErrFeatureToggleInternal)
+		require.False(t, ft.IsEnabled("a"))
+		require.True(t, ft.IsEnabled("b"))
+		require.False(t, ft.IsEnabled("c"))
+
+		state, ok = ft.GetRuntimeState("a")
+		require.True(t, ok)
+		require.False(t, state.Enabled)
+		require.True(t, state.Changed)
+		state, ok = ft.GetRuntimeState("c")
+		require.True(t, ok)
+		require.False(t, state.Changed)
+
+		// Runtime states survive the evaluation of the flags
+		ft.update()
+		require.False(t, ft.IsEnabled("a"))
+		require.True(t, ft.IsEnabled("b"))
+
+		// Stored states are applied on startup, including the changes of the toggles that require a restart, except for
+		// the toggles that cannot be changed at runtime
+		store := NewMemoryToggleStore()
+		require.NoError(t, store.Set(context.Background(), "a", true))
+		require.NoError(t, store.Set(context.Background(), "c", true))
+		require.NoError(t, store.Set(context.Background(), "f", true))
+		ft2 := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		ft2.registerFlags(FeatureFlag{Name: "a", RuntimeMutable: true}, FeatureFlag{Name: "c", RequiresRestart: true}, FeatureFlag{Name: "f", Internal: true})
+		require.NoError(t, ft2.SetRuntimeStore(context.Background(), store))
+		require.True(t, ft2.IsEnabled("a"))
+		require.True(t, ft2.IsEnabled("c"))
+		require.False(t, ft2.IsEnabled("f"))
+
+		// Clearing a runtime state makes the toggle follow its configuration again
+		require.NoError(t, ft2.ClearRuntimeState(context.Background(), "a"))
+		require.False(t, ft2.IsEnabled("a"))
+		state, ok = ft2.GetRuntimeState("a")
+		require.True(t, ok)
+		require.False(t, state.Changed)
+		states, err := store.GetAll(context.Background())
+		require.NoError(t, err)
+		require.NotContains(t, states, "a")
+		require.ErrorIs(t, ft2.ClearRuntimeState(context.Background(), "c"), ErrFeatureToggleRequiresRestart)
+		require.ErrorIs(t, ft2.ClearRuntimeState(context.Background(), "e"), ErrFeatureToggleNotFound)
+	})
+
+	t.Run("check pending changes", func(t *testing.T) {
+		// The changes cannot be queued in memory, as they would be lost on the restart that applies them
+		inMemory := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		inMemory.registerFlags(FeatureFlag{Name: "c", RequiresRestart: true})
+		_, err := inMemory.RequestChange(context.Background(), "c", true)
+		require.ErrorIs(t, err, ErrFeatureToggleRequiresRestart)
+
+		store := persistentToggleStore{NewMemoryToggleStore()}
+		ft := FeatureManager{
+			flags:        map[string]*FeatureFlag{},
+			runtimeStore: store,
+		}
+		ft.registerFlags(FeatureFlag{Name: "c", RequiresRestart: true})
+
+		queued, err := ft.RequestChange(context.Background(), "c", true)
+		require.NoError(t, err)
+		require.True(t, queued)
+		require.False(t, ft.IsEnabled("c"))
+		require.Len(t, ft.GetPendingChanges(), 1)
+
+		// The change is applied when the instance restarts with the store.
+		restarted := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		restarted.registerFlags(FeatureFlag{Name: "c", RequiresRestart: true})
+		require.NoError(t, restarted.SetRuntimeStore(context.Background(), store))
+		require.True(t, restarted.IsEnabled("c"))
+		require.Empty(t, restarted.GetPendingChanges())
+
+		// The changes requested on other instances are pending until restart, and the synced states keep the state
+		// the instance was started with.
+		other := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+		}
+		other.registerFlags(FeatureFlag{Name: "c", RequiresRestart: true})
+		other.SyncRuntimeStates(context.Background(), map[string]bool{"c": true})
+		require.False(t, other.IsEnabled("c"))
+		require.Len(t, other.GetPendingChanges(), 1)
+		restarted.SyncRuntimeStates(context.Background(), map[string]bool{"c": false})
+		require.True(t, restarted.IsEnabled("c"))
+		require.Len(t, restarted.GetPendingChanges(), 1)
+
+		// Cancelling the change stores the current state again.
+		require.NoError(t, ft.CancelPendingChange(context.Background(), "c"))
+		require.ErrorIs(t, ft.CancelPendingChange(context.Background(), "c"), ErrNoPendingToggleChange)
+		states, err := store.GetAll(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"c": false}, states)
+	})
+
+	t.Run("check targeting", func(t *testing.T) {
+		ft := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+			log:   log.NewNopLogger(),
+		}
+		ft.registerFlags(FeatureFlag{
+			Name:           "a",
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+		}, FeatureFlag{
+			Name:         "b",
+			FrontendOnly: true,
+		}, FeatureFlag{
+			Name:            "c",
+			FrontendOnly:    true,
+			RequiresRestart: true,
+		}, FeatureFlag{
+			Name: "d",
+		})
+		ft.setTargeting(map[string]setting.FeatureToggleTargeting{
+			"a": {UserIDs: []int64{1}, TeamIDs: []int64{10}},
+			"b": {Roles: []string{"editor"}},
+			"c": {UserIDs: []int64{1}},
+			"d": {UserIDs: []int64{1}},
+		})
+
+		ctx := func(usr *user.SignedInUser) context.Context {
+			return appcontext.WithUser(context.Background(), usr)
+		}
+		require.False(t, ft.IsEnabled("a"))
+		require.False(t, ft.IsEnabledForUser(context.Background(), "a"))
+		require.True(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 1}), "a"))
+		require.True(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 2, Teams: []int64{10}}), "a"))
+		require.False(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 2, OrgRole: org.RoleEditor}), "a"))
+		require.True(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 2, OrgRole: org.RoleEditor}), "b"))
+		require.False(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 1}), "c"))
+		// Toggles that the backend checks for the whole instance cannot be targeted
+		require.False(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 1}), "d"))
+		require.Equal(t, map[string]bool{"a": true}, ft.GetFrontendEnabled(ctx(&user.SignedInUser{UserID: 1})))
+		require.Empty(t, ft.GetEnabled(ctx(&user.SignedInUser{UserID: 1})))
+		require.Empty(t, ft.GetFrontendEnabled(context.Background()))
+
+		// A runtime change applies to every user
+		require.NoError(t, ft.SetRuntimeState(context.Background(), "a", false))
+		require.False(t, ft.IsEnabledForUser(ctx(&user.SignedInUser{UserID: 1}), "a"))
+	})
+
+	t.Run("check metrics", func(t *testing.T) {
+		ft := FeatureManager{
+			flags: map[string]*FeatureFlag{},
+			log:   log.NewNopLogger(),
+		}
+		ft.registerFlags(FeatureFlag{
+			Name:       "metricsA",
+			Expression: "true",
+		}, FeatureFlag{
+			Name:           "metricsB",
+			RuntimeMutable: true,
+		})
+		require.Equal(t, 1.0, testutil.ToFloat64(featureToggleEnabled.WithLabelValues("metricsA")))
+		require.Equal(t, 0.0, testutil.ToFloat64(featureToggleEnabled.WithLabelValues("metricsB")))
+
+		require.NoError(t, ft.SetRuntimeState(context.Background(), "metricsB", true))
+		ft.setRemoteStates(map[string]bool{"metricsA": false})
+		require.Equal(t, 0.0, testutil.ToFloat64(featureToggleEnabled.WithLabelValues("metricsA")))
+		require.Equal(t, 1.0, testutil.ToFloat64(featureToggleEnabled.WithLabelValues("metricsB")))
+		require.Equal(t, 1.0, testutil.ToFloat64(featureToggleChanges.WithLabelValues("metricsB", ToggleChangeSourceRuntime)))
+		require.Equal(t, 1.0, testutil.ToFloat64(featureToggleChanges.WithLabelValues("metricsA", ToggleChangeSourceRemote)))
+	})
+}
+
+// persistentToggleStore stands for a runtime store that keeps the states across restarts.
+type persistentToggleStore struct {
+	RuntimeToggleStore
 }
@@ -3,6 +3,7 @@ package featuremgmt
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 )
 
 type FeatureToggles interface {
@@ -95,6 +96,15 @@ func (s *FeatureFlagStage) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Edition is the edition of Grafana the server runs, which can change the default states of the toggles
+type Edition string
+
+const (
+	EditionOSS        Edition = "oss"
+	EditionEnterprise Edition = "enterprise"
+	EditionCloud      Edition = "cloud"
+)
+
 type FeatureFlag struct {
 	Name        string           `json:"name" yaml:"name"` // Unique name
 	Description string           `json:"description"`
@@ -106,6 +116,8 @@ type FeatureFlag struct {
 
 	// CEL-GO expression.  Using the value "true" will mean this is on by default
 	Expression string `json:"expression,omitempty"`
+	// Default states by edition, which replace Expression in the editions they are declared for
+	EditionDefaults map[Edition]bool `json:"editionDefaults,omitempty"`
 
 	// Special behavior flags
 	RequiresDevMode bool `json:"requiresDevMode,omitempty"` // can not be enabled in production
@@ -113,10 +125,28 @@ type FeatureFlag struct {
 	RequiresLicense bool `json:"requiresLicense,omitempty"` // Must be enabled in the license
 	FrontendOnly    bool `json:"frontend,omitempty"`        // change is only seen in the frontend
 	HideFromDocs    bool `json:"hideFromDocs,omitempty"`    // don't add the values to docs
+	Internal        bool `json:"internal,omitempty"`        // operational toggle, only configurable in the ini files
+	RuntimeMutable  bool `json:"runtimeMutable,omitempty"`  // can be changed at runtime, the others apply changes on restart
+
+	// Version of Grafana the toggle was added in, to report it as stale when it stays in its stage for too long
+	AddedInVersion string `json:"addedInVersion,omitempty"`
+
+	// Lifecycle of the toggles in the FeatureStageDeprecated stage
+	RemovalVersion string `json:"removalVersion,omitempty"` // version of Grafana the toggle will be removed in
+	ReplacedBy     string `json:"replacedBy,omitempty"`     // name of the toggle to use instead
+
+	// Schedule of the toggle: when set, the toggle is only enabled from StartAt and until EndAt
+	StartAt *time.Time `json:"startAt,omitempty" yaml:"startAt"`
+	EndAt   *time.Time `json:"endAt,omitempty" yaml:"endAt"`
 
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+// IsDeprecated checks if the toggle will be removed in the future
+func (f FeatureFlag) IsDeprecated() bool {
+	return f.Stage == FeatureStageDeprecated
+}
+
 type UpdateFeatureTogglesCommand struct {
 	FeatureToggles []FeatureToggleDTO `json:"featureToggles"`
 }
@@ -126,4 +156,46 @@ type FeatureToggleDTO struct {
 	Description string `json:"description"`
 	Enabled     bool   `json:"enabled"`
 	ReadOnly    bool   `json:"readOnly,omitempty"`
+
+	// Owner is the squad that owns the toggle, from codeowners.go
+	Owner        string `json:"owner,omitempty"`
+	FrontendOnly bool   `json:"frontend,omitempty"`
+
+	Deprecated     bool   `json:"deprecated,omitempty"`
+	RemovalVersion string `json:"removalVersion,omitempty"`
+	ReplacedBy     string `json:"replacedBy,omitempty"`
+}
+
+type RuntimeFeatureToggleDTO struct {
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	Stage           FeatureFlagStage `json:"stage,omitempty"`
+	Enabled         bool             `json:"enabled"`
+	RequiresRestart bool             `json:"requiresRestart,omitempty"`
+	// Changed is set if the state was changed at runtime rather than configured.
+	Changed  bool `json:"changed,omitempty"`
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Pending is the state requested for the toggle, applied on the next restart.
+	Pending *bool `json:"pending,omitempty"`
+	// Config holds the parameters of the toggle, configured in [feature_toggles.<toggle>].
+	Config map[string]string `json:"config,omitempty"`
+
+	Owner        string `json:"owner,omitempty"`
+	FrontendOnly bool   `json:"frontend,omitempty"`
+
+	Deprecated     bool   `json:"deprecated,omitempty"`
+	RemovalVersion string `json:"removalVersion,omitempty"`
+	ReplacedBy     string `json:"replacedBy,omitempty"`
+}
+
+type SetRuntimeFeatureToggleCommand struct {
+	Enabled bool `json:"enabled"`
+}
+
+type CreateToggleSnapshotCommand struct {
+	Name string `json:"name" binding:"Required"`
+}
+
+type RestoreToggleSnapshotResponse struct {
+	Changes []ToggleChange `json:"changes"`
 }
@@ -0,0 +1,81 @@
+package featuremgmt
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Headers and query parameters of the per-request overrides, see ParseRequestOverrides.
+const (
+	RequestOverridesHeader              = "X-Grafana-Feature-Toggles"
+	RequestOverridesSignatureHeader     = "X-Grafana-Feature-Toggles-Signature"
+	RequestOverridesQueryParam          = "featureToggles"
+	RequestOverridesSignatureQueryParam = "featureTogglesSignature"
+)
+
+var (
+	ErrRequestOverridesDisabled         = errors.New("feature toggle overrides are only allowed in development mode with a secret")
+	ErrRequestOverridesInvalidSignature = errors.New("invalid signature of the feature toggle overrides")
+)
+
+type requestOverridesKey struct{}
+
+// RequestOverridesEnabled checks if the toggles can be overridden per request, which is only allowed in development
+// mode when a secret is configured.
+func (fm *FeatureManager) RequestOverridesEnabled() bool {
+	return fm.isDevMod && fm.overridesSecret != ""
+}
+
+// ParseRequestOverrides parses the overrides of a request, a comma separated list of toggle=true|false, signed with
+// the hex encoded HMAC-SHA256 of the list keyed with the request_overrides_secret setting. Toggles that require a
+// restart cannot be overridden, and toggles that do not meet their requirements cannot be enabled.
+func (fm *FeatureManager) ParseRequestOverrides(value, signature string) (map[string]bool, error) {
+	if !fm.RequestOverridesEnabled() {
+		return nil, ErrRequestOverridesDisabled
+	}
+	expected := signChangePayload(fm.overridesSecret, []byte(value))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, ErrRequestOverridesInvalidSignature
+	}
+
+	overrides := make(map[string]bool)
+	for _, override := range strings.Split(value, ",") {
+		override = strings.TrimSpace(override)
+		if override == "" {
+			continue
+		}
+		name, v, _ := strings.Cut(override, "=")
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid feature toggle override %q, expected toggle=true or toggle=false", override)
+		}
+		flag, ok := fm.flags[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrFeatureToggleNotFound, name)
+		}
+		if flag.RequiresRestart {
+			return nil, fmt.Errorf("%w: %s", ErrFeatureToggleRequiresRestart, name)
+		}
+		if enabled && !fm.meetsRequirements(flag) {
+			return nil, fmt.Errorf("%w: %s", ErrFeatureToggleUnavailable, name)
+		}
+		overrides[name] = enabled
+	}
+	return overrides, nil
+}
+
+// WithRequestOverrides returns a copy of the context whose toggles are evaluated with the overrides, by
+// IsEnabledForUser and GetEnabled. IsEnabled, which has no context, ignores them.
+func WithRequestOverrides(ctx context.Context, overrides map[string]bool) context.Context {
+	return context.WithValue(ctx, requestOverridesKey{}, overrides)
+}
+
+// requestOverrides returns the overrides of the request in the context, if any.
+func requestOverrides(ctx context.Context) map[string]bool {
+	overrides, _ := ctx.Value(requestOverridesKey{}).(map[string]bool)
+	return overrides
+}
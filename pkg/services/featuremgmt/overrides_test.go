@@ -0,0 +1,60 @@
+package featuremgmt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestOverrides(t *testing.T) {
+	fm := &FeatureManager{
+		isDevMod:        true,
+		flags:           map[string]*FeatureFlag{},
+		overridesSecret: "secret",
+	}
+	fm.registerFlags(FeatureFlag{
+		Name:       "a",
+		Expression: "true",
+	}, FeatureFlag{
+		Name: "b",
+	}, FeatureFlag{
+		Name:            "c",
+		RequiresRestart: true,
+	})
+	sign := func(value string) string {
+		return signChangePayload("secret", []byte(value))
+	}
+
+	t.Run("should evaluate the toggles with the overrides", func(t *testing.T) {
+		overrides, err := fm.ParseRequestOverrides("a=false, b=true", sign("a=false, b=true"))
+		require.NoError(t, err)
+		ctx := WithRequestOverrides(context.Background(), overrides)
+		require.Equal(t, map[string]bool{"b": true}, fm.GetFrontendEnabled(ctx))
+		require.False(t, fm.IsEnabledForUser(ctx, "a"))
+		require.True(t, fm.IsEnabledForUser(ctx, "b"))
+
+		// the instance is not affected
+		require.True(t, fm.IsEnabled("a"))
+		require.False(t, fm.IsEnabled("b"))
+		require.Equal(t, map[string]bool{"a": true}, fm.GetEnabled(ctx))
+	})
+
+	t.Run("should reject invalid overrides", func(t *testing.T) {
+		_, err := fm.ParseRequestOverrides("b=true", sign("b=false"))
+		require.ErrorIs(t, err, ErrRequestOverridesInvalidSignature)
+		_, err = fm.ParseRequestOverrides("b=maybe", sign("b=maybe"))
+		require.Error(t, err)
+		_, err = fm.ParseRequestOverrides("unknown=true", sign("unknown=true"))
+		require.ErrorIs(t, err, ErrFeatureToggleNotFound)
+		_, err = fm.ParseRequestOverrides("c=true", sign("c=true"))
+		require.ErrorIs(t, err, ErrFeatureToggleRequiresRestart)
+	})
+
+	t.Run("should only be allowed in development mode", func(t *testing.T) {
+		prod := &FeatureManager{flags: fm.flags, overridesSecret: "secret"}
+		require.False(t, prod.RequestOverridesEnabled())
+		_, err := prod.ParseRequestOverrides("b=true", sign("b=true"))
+		require.ErrorIs(t, err, ErrRequestOverridesDisabled)
+	})
+}
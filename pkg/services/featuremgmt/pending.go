@@ -0,0 +1,112 @@
+package featuremgmt
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+)
+
+var ErrNoPendingToggleChange = errors.New("no pending change of the feature toggle")
+
+// PendingToggleChange is a change of a toggle that cannot be changed at runtime, queued until Grafana is restarted with it. The
+// change is stored with the states changed at runtime, which are applied on startup, see SetRuntimeStore.
+type PendingToggleChange struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// RequestedBy is the login of the user who requested the change, it is empty if it was requested on another
+	// instance.
+	RequestedBy string    `json:"requestedBy,omitempty"`
+	Requested   time.Time `json:"requested"`
+}
+
+// RequestChange changes the toggle at runtime, or queues the change if the toggle cannot be changed at runtime, and
+// returns whether it was queued. Requesting the current state of a toggle that cannot be changed at runtime cancels its
+// pending change. Changes cannot be queued with the default in-memory runtime store, which loses them on restart.
+func (fm *FeatureManager) RequestChange(ctx context.Context, name string, enabled bool) (bool, error) {
+	flag, ok := fm.LookupFlag(name)
+	if !ok {
+		return false, ErrFeatureToggleNotFound
+	}
+	if flag.Internal {
+		return false, ErrFeatureToggleInternal
+	}
+	if changeableAtRuntime(&flag) {
+		return false, fm.SetRuntimeState(ctx, name, enabled)
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if _, ok := fm.runtimeStoreLocked().(*memoryToggleStore); ok {
+		return false, ErrFeatureToggleRequiresRestart
+	}
+	if enabled && !fm.meetsRequirements(&flag) {
+		return false, ErrFeatureToggleUnavailable
+	}
+	// Storing the current state cancels the change on the other instances as well.
+	if err := fm.runtimeStoreLocked().Set(ctx, name, enabled); err != nil {
+		return false, err
+	}
+	if fm.enabled[name] == enabled {
+		delete(fm.pending, name)
+		return false, nil
+	}
+
+	change := PendingToggleChange{
+		Name:      name,
+		Enabled:   enabled,
+		Requested: time.Now(),
+	}
+	if usr, err := appcontext.User(ctx); err == nil && usr != nil {
+		change.RequestedBy = usr.Login
+	}
+	if fm.pending == nil {
+		fm.pending = make(map[string]PendingToggleChange)
+	}
+	fm.pending[name] = change
+	return true, nil
+}
+
+// GetPendingChanges returns the changes queued until restart, sorted by toggle name.
+func (fm *FeatureManager) GetPendingChanges() []PendingToggleChange {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	changes := make([]PendingToggleChange, 0, len(fm.pending))
+	for _, change := range fm.pending {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// CancelPendingChange removes the change of the toggle queued until restart, storing its current state instead.
+func (fm *FeatureManager) CancelPendingChange(ctx context.Context, name string) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if _, ok := fm.pending[name]; !ok {
+		return ErrNoPendingToggleChange
+	}
+	if err := fm.runtimeStoreLocked().Set(ctx, name, fm.enabled[name]); err != nil {
+		return err
+	}
+	delete(fm.pending, name)
+	return nil
+}
+
+// syncPendingChangeLocked records the state stored for a toggle that requires a restart as a pending change, if it
+// differs from the state the instance was started with, for the changes requested on other instances.
+func (fm *FeatureManager) syncPendingChangeLocked(name string, enabled bool) {
+	if fm.enabled[name] == enabled {
+		delete(fm.pending, name)
+		return
+	}
+	if change, ok := fm.pending[name]; ok && change.Enabled == enabled {
+		return
+	}
+	if fm.pending == nil {
+		fm.pending = make(map[string]PendingToggleChange)
+	}
+	fm.pending[name] = PendingToggleChange{Name: name, Enabled: enabled, Requested: time.Now()}
+}
@@ -249,12 +249,13 @@ var (
 			Owner:       grafanaAuthnzSquad,
 		},
 		{
-			Name:         "emptyDashboardPage",
-			Description:  "Enable the redesigned user interface of a dashboard page that includes no panels",
-			Stage:        FeatureStageGeneralAvailability,
-			FrontendOnly: true,
-			Expression:   "true", // enabled by default
-			Owner:        grafanaDashboardsSquad,
+			Name:           "emptyDashboardPage",
+			Description:    "Enable the redesigned user interface of a dashboard page that includes no panels",
+			Stage:          FeatureStageGeneralAvailability,
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+			Expression:     "true", // enabled by default
+			Owner:          grafanaDashboardsSquad,
 		},
 		{
 			Name:        "disablePrometheusExemplarSampling",
@@ -269,11 +270,12 @@ var (
 			Owner:       grafanaAlertingSquad,
 		},
 		{
-			Name:         "editPanelCSVDragAndDrop",
-			Description:  "Enables drag and drop for CSV and Excel files",
-			FrontendOnly: true,
-			Stage:        FeatureStageExperimental,
-			Owner:        grafanaBiSquad,
+			Name:           "editPanelCSVDragAndDrop",
+			Description:    "Enables drag and drop for CSV and Excel files",
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+			Stage:          FeatureStageExperimental,
+			Owner:          grafanaBiSquad,
 		},
 		{
 			Name:            "alertingNoNormalState",
@@ -481,11 +483,12 @@ var (
 			Owner:        enterpriseDatasourcesSquad,
 		},
 		{
-			Name:         "extraThemes",
-			Description:  "Enables extra themes",
-			FrontendOnly: true,
-			Stage:        FeatureStageExperimental,
-			Owner:        grafanaFrontendPlatformSquad,
+			Name:           "extraThemes",
+			Description:    "Enables extra themes",
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+			Stage:          FeatureStageExperimental,
+			Owner:          grafanaFrontendPlatformSquad,
 		},
 		{
 			Name:         "lokiPredefinedOperations",
@@ -523,11 +526,12 @@ var (
 			Owner:        grafanaBiSquad,
 		},
 		{
-			Name:         "lokiFormatQuery",
-			Description:  "Enables the ability to format Loki queries",
-			FrontendOnly: true,
-			Stage:        FeatureStageExperimental,
-			Owner:        grafanaObservabilityLogsSquad,
+			Name:           "lokiFormatQuery",
+			Description:    "Enables the ability to format Loki queries",
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+			Stage:          FeatureStageExperimental,
+			Owner:          grafanaObservabilityLogsSquad,
 		},
 		{
 			Name:         "cloudWatchLogsMonacoEditor",
@@ -538,11 +542,12 @@ var (
 			Owner:        awsDatasourcesSquad,
 		},
 		{
-			Name:         "exploreScrollableLogsContainer",
-			Description:  "Improves the scrolling behavior of logs in Explore",
-			Stage:        FeatureStageExperimental,
-			FrontendOnly: true,
-			Owner:        grafanaObservabilityLogsSquad,
+			Name:           "exploreScrollableLogsContainer",
+			Description:    "Improves the scrolling behavior of logs in Explore",
+			Stage:          FeatureStageExperimental,
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+			Owner:          grafanaObservabilityLogsSquad,
 		},
 		{
 			Name:        "recordedQueriesMulti",
@@ -594,12 +599,13 @@ var (
 			Owner:        grafanaObservabilityMetricsSquad,
 		},
 		{
-			Name:         "toggleLabelsInLogsUI",
-			Description:  "Enable toggleable filters in log details view",
-			Stage:        FeatureStageGeneralAvailability,
-			FrontendOnly: true,
-			Expression:   "true", // enabled by default
-			Owner:        grafanaObservabilityLogsSquad,
+			Name:           "toggleLabelsInLogsUI",
+			Description:    "Enable toggleable filters in log details view",
+			Stage:          FeatureStageGeneralAvailability,
+			FrontendOnly:   true,
+			RuntimeMutable: true,
+			Expression:     "true", // enabled by default
+			Owner:          grafanaObservabilityLogsSquad,
 		},
 		{
 			Name:         "mlExpressions",
@@ -0,0 +1,164 @@
+package featuremgmt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ConfigWatcher watches the configuration files of Grafana and the features.yaml file, and applies the changes of the
+// toggles that do not require a restart, so container deployments can update the toggles of a mounted configuration.
+// The files are polled rather than watched for events, which are not reliable for mounted volumes.
+type ConfigWatcher struct {
+	features *FeatureManager
+	files    []string
+	enabled  bool
+	interval time.Duration
+	log      log.Logger
+
+	modTimes map[string]time.Time
+}
+
+func ProvideConfigWatcher(cfg *setting.Cfg, features *FeatureManager) *ConfigWatcher {
+	w := &ConfigWatcher{
+		features: features,
+		files:    cfg.ConfigFiles(),
+		enabled:  cfg.FeatureManagement.HotReload,
+		interval: cfg.FeatureManagement.HotReloadInterval,
+		log:      log.New("featuremgmt.reload"),
+	}
+	if w.interval <= 0 {
+		w.interval = 10 * time.Second
+	}
+	return w
+}
+
+func (w *ConfigWatcher) IsDisabled() bool {
+	return !w.enabled
+}
+
+func (w *ConfigWatcher) Run(ctx context.Context) error {
+	w.modTimes = w.readModTimes()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			modTimes := w.readModTimes()
+			if !w.changed(modTimes) {
+				continue
+			}
+			if err := w.reload(); err != nil {
+				// The files are read again on their next change.
+				w.log.Error("Failed to reload feature toggles", "error", err)
+			}
+			w.modTimes = modTimes
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// watchedFiles returns the configuration files and the features.yaml file, if any.
+func (w *ConfigWatcher) watchedFiles() []string {
+	if w.features.config == "" {
+		return w.files
+	}
+	return append(append([]string(nil), w.files...), w.features.config)
+}
+
+func (w *ConfigWatcher) readModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, file := range w.watchedFiles() {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func (w *ConfigWatcher) changed(modTimes map[string]time.Time) bool {
+	if len(modTimes) != len(w.modTimes) {
+		return true
+	}
+	for file, t := range modTimes {
+		if !t.Equal(w.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// reload reads the states of the toggles from the files, the [feature_toggles] section of the configuration files
+// then the features.yaml file, like on startup.
+func (w *ConfigWatcher) reload() error {
+	section, err := setting.ReloadFeatureTogglesSection(w.files)
+	if err != nil {
+		return err
+	}
+	flags, err := setting.ReadFeatureTogglesFromInitFile(section)
+	if err != nil {
+		return err
+	}
+	expressions := make(map[string]string, len(flags))
+	for key, val := range flags {
+		expressions[configuredFlagName(key)] = fmt.Sprintf("%t", val)
+	}
+	if w.features.config != "" {
+		cfg, err := readConfigFile(w.features.config)
+		if err != nil {
+			return err
+		}
+		for _, flag := range cfg.Flags {
+			if flag.Expression != "" {
+				expressions[flag.Name] = flag.Expression
+			}
+		}
+	}
+
+	w.features.setReloadedStates(expressions)
+	return nil
+}
+
+// setReloadedStates applies the expressions of the toggles read from the configuration files again. Toggles that
+// are not configured anymore get the default state of the edition back, and toggles that require a restart keep
+// their state.
+func (fm *FeatureManager) setReloadedStates(expressions map[string]string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.reloadedConfigured = make(map[string]struct{}, len(expressions))
+	for name := range expressions {
+		if _, ok := fm.flags[name]; !ok {
+			fm.log.Warn("Ignoring unknown feature toggle, it requires a restart to be added", "toggle", name)
+			continue
+		}
+		fm.reloadedConfigured[name] = struct{}{}
+	}
+
+	fm.reloaded = make(map[string]bool, len(fm.flags))
+	for name, flag := range fm.flags {
+		expression, ok := expressions[name]
+		if !ok {
+			expression = fm.defaults[name]
+		}
+		on := expression == "true"
+		if flag.RequiresRestart {
+			if on != (flag.Expression == "true") {
+				fm.log.Warn("Feature toggle requires a restart to be changed", "toggle", name, "enabled", on)
+			}
+			continue
+		}
+		fm.reloaded[name] = on
+	}
+
+	before := fm.enabledCopyLocked()
+	fm.updateLocked()
+	for _, change := range fm.publishChangesLocked(context.Background(), before, ToggleChangeSourceConfig) {
+		fm.log.Info("Feature toggle reloaded", "toggle", change.Name, "enabled", change.Enabled)
+	}
+}
@@ -0,0 +1,117 @@
+package featuremgmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	dir := t.TempDir()
+	defaults := filepath.Join(dir, "defaults.ini")
+	custom := filepath.Join(dir, "custom.ini")
+	features := filepath.Join(dir, "features.yaml")
+	write := func(t *testing.T, file, content string) {
+		require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	}
+	write(t, defaults, "[feature_toggles]\nenable =\n")
+	write(t, custom, "[feature_toggles]\nenable = b\nc = true\n")
+	write(t, features, "flags:\n  - name: d\n    expression: \"true\"\n")
+
+	fm := &FeatureManager{
+		flags:  map[string]*FeatureFlag{},
+		config: features,
+		log:    log.NewNopLogger(),
+	}
+	fm.registerFlags(FeatureFlag{
+		Name: "a",
+	}, FeatureFlag{
+		Name: "b",
+	}, FeatureFlag{
+		Name:            "c",
+		RequiresRestart: true,
+	}, FeatureFlag{
+		Name: "d",
+	})
+	w := &ConfigWatcher{
+		features: fm,
+		files:    []string{defaults, custom},
+		log:      log.NewNopLogger(),
+	}
+
+	t.Run("should apply the toggles that do not require a restart", func(t *testing.T) {
+		require.NoError(t, w.reload())
+		require.False(t, fm.IsEnabled("a"))
+		require.True(t, fm.IsEnabled("b"))
+		require.False(t, fm.IsEnabled("c"))
+		require.True(t, fm.IsEnabled("d"))
+	})
+
+	t.Run("should restore the state of toggles that are not configured anymore", func(t *testing.T) {
+		write(t, custom, "[feature_toggles]\na = true\n")
+		write(t, features, "flags: []\n")
+		require.NoError(t, w.reload())
+		require.True(t, fm.IsEnabled("a"))
+		require.False(t, fm.IsEnabled("b"))
+		require.False(t, fm.IsEnabled("d"))
+	})
+
+	t.Run("should apply environment overrides", func(t *testing.T) {
+		t.Setenv("GF_FEATURE_TOGGLES_A", "false")
+		require.NoError(t, w.reload())
+		require.False(t, fm.IsEnabled("a"))
+	})
+
+	t.Run("should keep the states if the files are invalid", func(t *testing.T) {
+		write(t, custom, "[feature_toggles]\nb = maybe\n")
+		require.Error(t, w.reload())
+		require.False(t, fm.IsEnabled("b"))
+	})
+
+	t.Run("should detect changes of the files", func(t *testing.T) {
+		w.modTimes = w.readModTimes()
+		require.False(t, w.changed(w.readModTimes()))
+		require.NoError(t, os.Remove(features))
+		require.True(t, w.changed(w.readModTimes()))
+	})
+}
+
+func TestConfigWatcherEditionDefaults(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "custom.ini")
+	require.NoError(t, os.WriteFile(custom, []byte("[feature_toggles]\nenterpriseOnly = false\n"), 0600))
+
+	fm := &FeatureManager{
+		flags: map[string]*FeatureFlag{},
+		log:   log.NewNopLogger(),
+	}
+	fm.registerFlags(FeatureFlag{
+		Name:            "enterpriseOnly",
+		EditionDefaults: map[Edition]bool{EditionEnterprise: true},
+	}, FeatureFlag{
+		Name:            "notInEnterprise",
+		Expression:      "true",
+		EditionDefaults: map[Edition]bool{EditionEnterprise: false},
+	})
+	fm.applyEditionDefaults(EditionEnterprise)
+	w := &ConfigWatcher{
+		features: fm,
+		files:    []string{custom},
+		log:      log.NewNopLogger(),
+	}
+
+	require.NoError(t, w.reload())
+	require.False(t, fm.IsEnabled("enterpriseOnly"))
+	require.False(t, fm.IsEnabled("notInEnterprise"))
+
+	t.Run("should restore the default of the edition of toggles that are not configured anymore", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(custom, []byte("[feature_toggles]\n"), 0600))
+		require.NoError(t, w.reload())
+		require.True(t, fm.IsEnabled("enterpriseOnly"))
+		require.False(t, fm.IsEnabled("notInEnterprise"))
+	})
+}
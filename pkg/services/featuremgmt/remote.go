@@ -0,0 +1,224 @@
+package featuremgmt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// RemoteSignatureHeader is the header of the response of the remote source that holds the base64 encoded ed25519
+// signature of the document.
+const RemoteSignatureHeader = "X-Grafana-Signature"
+
+// maxRemoteDocumentSize limits the size of the documents and signatures read from the remote source.
+const maxRemoteDocumentSize = 1 << 20
+
+// RemoteDocument is the document that the remote source serves. Its version and timestamp must increase with every
+// change, so that an older document, although signed, cannot be served again to roll the toggles back.
+type RemoteDocument struct {
+	Version   int64           `json:"version"`
+	Timestamp time.Time       `json:"timestamp"`
+	Flags     map[string]bool `json:"flags"`
+}
+
+// RemoteSource periodically fetches the states of the toggles from a remote HTTP endpoint or object storage, and
+// merges them into the FeatureManager, so that a central service can manage the toggles of a fleet of instances.
+type RemoteSource struct {
+	features     *FeatureManager
+	url          string
+	signatureURL string
+	publicKey    ed25519.PublicKey
+	interval     time.Duration
+	client       *http.Client
+	log          log.Logger
+
+	// etag is the ETag of the last document applied, sent in If-None-Match so unchanged documents are not transferred.
+	etag string
+	// version and timestamp are those of the last document applied, a document is only applied if both are newer.
+	version   int64
+	timestamp time.Time
+}
+
+func ProvideRemoteSource(cfg *setting.Cfg, features *FeatureManager) (*RemoteSource, error) {
+	s := &RemoteSource{
+		features:     features,
+		url:          cfg.FeatureManagement.RemoteSourceURL,
+		signatureURL: cfg.FeatureManagement.RemoteSourceSignatureURL,
+		interval:     cfg.FeatureManagement.RemoteSourcePollInterval,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		log:          log.New("featuremgmt.remote"),
+	}
+	if s.url != "" {
+		key := cfg.FeatureManagement.RemoteSourcePublicKey
+		if key == "" {
+			return nil, fmt.Errorf("remote_source_public_key in [feature_management] is required with remote_source_url")
+		}
+		b, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid remote_source_public_key in [feature_management], expected a base64 encoded ed25519 public key")
+		}
+		s.publicKey = b
+	}
+	if s.interval <= 0 {
+		s.interval = time.Minute
+	}
+	return s, nil
+}
+
+func (s *RemoteSource) IsDisabled() bool {
+	return s.url == ""
+}
+
+func (s *RemoteSource) Run(ctx context.Context) error {
+	s.poll(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.poll(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *RemoteSource) poll(ctx context.Context) {
+	if err := s.fetch(ctx); err != nil {
+		// The states of the last document applied are kept until the source is reachable again.
+		s.log.Error("Failed to fetch feature toggles from the remote source", "url", s.url, "error", err)
+	}
+}
+
+// fetch fetches the document, verifies its signature and applies it, unless it did not change since the last fetch. A
+// document that is not newer than the last one applied is rejected.
+func (s *RemoteSource) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteDocumentSize))
+	if err != nil {
+		return err
+	}
+
+	if err := s.verify(ctx, body, resp.Header.Get(RemoteSignatureHeader)); err != nil {
+		return err
+	}
+	var doc RemoteDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("failed to parse the document: %w", err)
+	}
+	if doc.Version <= 0 || doc.Timestamp.IsZero() {
+		return errors.New("the document has no version or timestamp")
+	}
+	// The sources without ETag serve the same document again.
+	if doc.Version == s.version && doc.Timestamp.Equal(s.timestamp) {
+		return nil
+	}
+	if doc.Version <= s.version || !doc.Timestamp.After(s.timestamp) {
+		return fmt.Errorf("the document (version %d, timestamp %s) is not newer than the one applied (version %d, timestamp %s)",
+			doc.Version, doc.Timestamp.Format(time.RFC3339), s.version, s.timestamp.Format(time.RFC3339))
+	}
+
+	s.features.setRemoteStates(doc.Flags)
+	s.etag = resp.Header.Get("ETag")
+	s.version, s.timestamp = doc.Version, doc.Timestamp
+	s.log.Debug("Applied feature toggles from the remote source", "count", len(doc.Flags), "version", doc.Version, "etag", s.etag)
+	return nil
+}
+
+// verify verifies the signature of the document with the configured public key. The signature is read from the header
+// of the response, or from the signature URL if the header is missing.
+func (s *RemoteSource) verify(ctx context.Context, body []byte, signature string) error {
+	if signature == "" && s.signatureURL != "" {
+		var err error
+		if signature, err = s.fetchSignature(ctx); err != nil {
+			return fmt.Errorf("failed to fetch the signature: %w", err)
+		}
+	}
+	if signature == "" {
+		return errors.New("the document is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ed25519.Verify(s.publicKey, body, sig) {
+		return errors.New("the signature of the document is invalid")
+	}
+	return nil
+}
+
+func (s *RemoteSource) fetchSignature(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.signatureURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteDocumentSize))
+	return string(b), err
+}
+
+// setRemoteStates replaces the states managed by the remote source. They take precedence over the configured states,
+// but not over the ones changed at runtime. Toggles that require a restart keep their configured state.
+func (fm *FeatureManager) setRemoteStates(states map[string]bool) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.remote = make(map[string]bool, len(states))
+	for name, enabled := range states {
+		flag, ok := fm.flags[name]
+		if !ok {
+			fm.log.Debug("Ignoring unknown feature toggle from the remote source", "toggle", name)
+			continue
+		}
+		if flag.RequiresRestart {
+			fm.log.Warn("Ignoring feature toggle from the remote source that requires a restart", "toggle", name)
+			continue
+		}
+		fm.remote[name] = enabled
+	}
+	before := fm.enabledCopyLocked()
+	fm.updateLocked()
+	fm.publishChangesLocked(context.Background(), before, ToggleChangeSourceRemote)
+}
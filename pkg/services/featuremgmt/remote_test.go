@@ -0,0 +1,170 @@
+package featuremgmt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRemoteSource(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	newFeatures := func() *FeatureManager {
+		fm := &FeatureManager{
+			flags: map[string]*FeatureFlag{},
+			log:   log.NewNopLogger(),
+		}
+		fm.registerFlags(FeatureFlag{
+			Name:           "a",
+			Expression:     "true",
+			RuntimeMutable: true,
+		}, FeatureFlag{
+			Name: "b",
+		}, FeatureFlag{
+			Name:            "c",
+			RequiresRestart: true,
+		})
+		return fm
+	}
+
+	sign := func(document string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(document)))
+	}
+	document := `{"version": 2, "timestamp": "2023-06-02T00:00:00Z", "flags": {"a": false, "b": true, "c": true, "unknown": true}}`
+	signature := sign(document)
+	older := `{"version": 1, "timestamp": "2023-06-01T00:00:00Z", "flags": {"b": false}}`
+	unversioned := `{"flags": {"b": true}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/older":
+			w.Header().Set(RemoteSignatureHeader, sign(older))
+			_, _ = w.Write([]byte(older))
+			return
+		case "/unversioned":
+			w.Header().Set(RemoteSignatureHeader, sign(unversioned))
+			_, _ = w.Write([]byte(unversioned))
+			return
+		case "/signed", "/no-etag":
+			w.Header().Set(RemoteSignatureHeader, signature)
+			if r.URL.Path == "/no-etag" {
+				_, _ = w.Write([]byte(document))
+				return
+			}
+		case "/detached.sig":
+			_, _ = w.Write([]byte(signature))
+			return
+		case "/invalid":
+			w.Header().Set(RemoteSignatureHeader, base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize)))
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(document))
+	}))
+	t.Cleanup(server.Close)
+
+	publicKeySetting := base64.StdEncoding.EncodeToString(publicKey)
+	newSource := func(t *testing.T, fm *FeatureManager, settings setting.FeatureMgmtSettings) *RemoteSource {
+		if settings.RemoteSourcePublicKey == "" {
+			settings.RemoteSourcePublicKey = publicKeySetting
+		}
+		cfg := setting.NewCfg()
+		cfg.FeatureManagement = settings
+		s, err := ProvideRemoteSource(cfg, fm)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("should be disabled without URL", func(t *testing.T) {
+		s, err := ProvideRemoteSource(setting.NewCfg(), newFeatures())
+		require.NoError(t, err)
+		require.True(t, s.IsDisabled())
+	})
+
+	t.Run("should require a valid public key", func(t *testing.T) {
+		for _, key := range []string{"", "invalid"} {
+			cfg := setting.NewCfg()
+			cfg.FeatureManagement.RemoteSourceURL = server.URL + "/signed"
+			cfg.FeatureManagement.RemoteSourcePublicKey = key
+			_, err := ProvideRemoteSource(cfg, newFeatures())
+			require.Error(t, err, key)
+		}
+	})
+
+	t.Run("should merge the states of known toggles that do not require a restart", func(t *testing.T) {
+		fm := newFeatures()
+		s := newSource(t, fm, setting.FeatureMgmtSettings{RemoteSourceURL: server.URL + "/signed"})
+		require.NoError(t, s.fetch(context.Background()))
+		require.False(t, fm.IsEnabled("a"))
+		require.True(t, fm.IsEnabled("b"))
+		require.False(t, fm.IsEnabled("c"))
+
+		// Toggles changed at runtime take precedence
+		require.NoError(t, fm.SetRuntimeState(context.Background(), "a", true))
+		require.True(t, fm.IsEnabled("a"))
+	})
+
+	t.Run("should not apply unchanged documents", func(t *testing.T) {
+		fm := newFeatures()
+		s := newSource(t, fm, setting.FeatureMgmtSettings{RemoteSourceURL: server.URL + "/signed"})
+		require.NoError(t, s.fetch(context.Background()))
+		require.Equal(t, `"v1"`, s.etag)
+
+		fm.setRemoteStates(nil)
+		require.True(t, fm.IsEnabled("a"))
+		require.NoError(t, s.fetch(context.Background()))
+		require.True(t, fm.IsEnabled("a"))
+	})
+
+	t.Run("should verify the signature", func(t *testing.T) {
+		settings := setting.FeatureMgmtSettings{RemoteSourceURL: server.URL + "/signed"}
+		fm := newFeatures()
+		require.NoError(t, newSource(t, fm, settings).fetch(context.Background()))
+		require.True(t, fm.IsEnabled("b"))
+
+		settings.RemoteSourceURL = server.URL + "/detached"
+		settings.RemoteSourceSignatureURL = server.URL + "/detached.sig"
+		fm = newFeatures()
+		require.NoError(t, newSource(t, fm, settings).fetch(context.Background()))
+		require.True(t, fm.IsEnabled("b"))
+
+		settings.RemoteSourceSignatureURL = ""
+		for _, path := range []string{"/unsigned", "/invalid"} {
+			settings.RemoteSourceURL = server.URL + path
+			fm = newFeatures()
+			require.Error(t, newSource(t, fm, settings).fetch(context.Background()), path)
+			require.False(t, fm.IsEnabled("b"), path)
+		}
+	})
+
+	t.Run("should reject documents that are not newer than the one applied", func(t *testing.T) {
+		fm := newFeatures()
+		s := newSource(t, fm, setting.FeatureMgmtSettings{RemoteSourceURL: server.URL + "/signed"})
+		require.NoError(t, s.fetch(context.Background()))
+		require.True(t, fm.IsEnabled("b"))
+
+		s.url = server.URL + "/older"
+		require.Error(t, s.fetch(context.Background()))
+		require.True(t, fm.IsEnabled("b"))
+
+		// The same document served again by a source without ETag is not an error.
+		s.url = server.URL + "/no-etag"
+		require.NoError(t, s.fetch(context.Background()))
+		require.True(t, fm.IsEnabled("b"))
+
+		s = newSource(t, newFeatures(), setting.FeatureMgmtSettings{RemoteSourceURL: server.URL + "/unversioned"})
+		require.Error(t, s.fetch(context.Background()))
+	})
+}
@@ -0,0 +1,287 @@
+package featuremgmt
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrFeatureToggleNotFound        = errors.New("feature toggle not found")
+	ErrFeatureToggleRequiresRestart = errors.New("feature toggle requires a restart to be changed")
+	ErrFeatureToggleUnavailable     = errors.New("feature toggle cannot be enabled on this instance")
+	ErrFeatureToggleInternal        = errors.New("feature toggle can only be configured in the configuration files")
+	// ErrFeatureToggleConflict is returned by the runtime store when the state of a toggle was changed on another
+	// instance since this instance last read it.
+	ErrFeatureToggleConflict = errors.New("feature toggle was changed on another instance")
+)
+
+// RuntimeToggleStore holds the states of the feature toggles that were changed at runtime, which take precedence
+// over the configured ones.
+type RuntimeToggleStore interface {
+	// GetAll returns the states changed at runtime, by toggle name.
+	GetAll(ctx context.Context) (map[string]bool, error)
+	// Set records the state of the toggle.
+	Set(ctx context.Context, name string, enabled bool) error
+	// SetAll records the states of the toggles at once, either all of them are recorded or none.
+	SetAll(ctx context.Context, states map[string]bool) error
+	// Delete removes the state of the toggle, which follows its configuration again.
+	Delete(ctx context.Context, name string) error
+}
+
+// memoryToggleStore is the RuntimeToggleStore used by default, its states are lost on restart, so the changes that
+// are pending until a restart cannot be requested with it.
+type memoryToggleStore struct {
+	mu     sync.Mutex
+	states map[string]bool
+}
+
+// NewMemoryToggleStore returns a RuntimeToggleStore that keeps the states in memory.
+func NewMemoryToggleStore() RuntimeToggleStore {
+	return &memoryToggleStore{states: make(map[string]bool)}
+}
+
+func (s *memoryToggleStore) GetAll(_ context.Context) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states := make(map[string]bool, len(s.states))
+	for k, v := range s.states {
+		states[k] = v
+	}
+	return states, nil
+}
+
+func (s *memoryToggleStore) Set(_ context.Context, name string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[name] = enabled
+	return nil
+}
+
+func (s *memoryToggleStore) SetAll(_ context.Context, states map[string]bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, enabled := range states {
+		s.states[name] = enabled
+	}
+	return nil
+}
+
+func (s *memoryToggleStore) Delete(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, name)
+	return nil
+}
+
+// RuntimeToggleState is the state of a feature toggle, and whether it was changed at runtime.
+type RuntimeToggleState struct {
+	FeatureFlag
+	// Changed is set if the state was changed at runtime rather than configured.
+	Changed bool
+	// Pending is the state the toggle is requested to have after a restart, if any.
+	Pending *bool
+	// Config holds the parameters of the toggle configured in [feature_toggles.<toggle>], if any.
+	Config map[string]string
+}
+
+// SetRuntimeStore replaces the store of the states changed at runtime, and applies the states it holds. It is called on
+// startup, before the services are started, so the stored changes of the toggles that require a restart are applied
+// as well.
+func (fm *FeatureManager) SetRuntimeStore(ctx context.Context, store RuntimeToggleStore) error {
+	states, err := store.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.runtimeStore = store
+	for name, enabled := range states {
+		flag, ok := fm.flags[name]
+		// The toggles that can no longer be changed at runtime keep their configured state.
+		if !ok || flag.Internal || (enabled && !fm.meetsRequirements(flag)) {
+			continue
+		}
+		fm.applyRuntimeStateLocked(name, enabled)
+		delete(fm.pending, name)
+	}
+	return nil
+}
+
+// runtimeStoreLocked returns the store of the states changed at runtime, keeping them in memory if none was set.
+func (fm *FeatureManager) runtimeStoreLocked() RuntimeToggleStore {
+	if fm.runtimeStore == nil {
+		fm.runtimeStore = NewMemoryToggleStore()
+	}
+	return fm.runtimeStore
+}
+
+// GetRuntimeStates returns the state of every feature toggle.
+func (fm *FeatureManager) GetRuntimeStates() []RuntimeToggleState {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	states := make([]RuntimeToggleState, 0, len(fm.flags))
+	for _, flag := range fm.flags {
+		states = append(states, fm.runtimeStateLocked(flag))
+	}
+	return states
+}
+
+// GetRuntimeState returns the state of the feature toggle, and false if it does not exist.
+func (fm *FeatureManager) GetRuntimeState(name string) (RuntimeToggleState, bool) {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	flag, ok := fm.flags[name]
+	if !ok {
+		return RuntimeToggleState{}, false
+	}
+	return fm.runtimeStateLocked(flag), true
+}
+
+func (fm *FeatureManager) runtimeStateLocked(flag *FeatureFlag) RuntimeToggleState {
+	state := RuntimeToggleState{FeatureFlag: *flag}
+	state.Enabled = fm.enabled[flag.Name]
+	_, state.Changed = fm.runtime[flag.Name]
+	if change, ok := fm.pending[flag.Name]; ok {
+		state.Pending = &change.Enabled
+	}
+	state.Config = fm.configs[flag.Name]
+	state.RequiresRestart = !changeableAtRuntime(flag)
+	return state
+}
+
+// changeableAtRuntime returns whether the state of the toggle can be changed without restarting. Toggles opt in with
+// RuntimeMutable in the registry, the changes of the others are pending until the next restart.
+func changeableAtRuntime(flag *FeatureFlag) bool {
+	return flag.RuntimeMutable && !flag.RequiresRestart
+}
+
+// SetRuntimeState enables or disables the feature toggle until the next restart, or for as long as the runtime store
+// keeps it. Only the toggles marked RuntimeMutable in the registry can be changed, and toggles that do not meet their
+// requirements cannot be enabled.
+func (fm *FeatureManager) SetRuntimeState(ctx context.Context, name string, enabled bool) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	flag, ok := fm.flags[name]
+	if !ok {
+		return ErrFeatureToggleNotFound
+	}
+	if flag.Internal {
+		return ErrFeatureToggleInternal
+	}
+	if !changeableAtRuntime(flag) {
+		return ErrFeatureToggleRequiresRestart
+	}
+	if enabled && !fm.meetsRequirements(flag) {
+		return ErrFeatureToggleUnavailable
+	}
+
+	// The state is only applied once the store recorded it, so the instance never runs with a state that the others
+	// do not get.
+	if err := fm.runtimeStoreLocked().Set(ctx, name, enabled); err != nil {
+		return err
+	}
+	before := fm.enabledCopyLocked()
+	fm.applyRuntimeStateLocked(name, enabled)
+	fm.publishChangesLocked(ctx, before, ToggleChangeSourceRuntime)
+	return nil
+}
+
+// ClearRuntimeState removes the state of the feature toggle changed at runtime, so that it follows its configuration
+// again. Only the toggles marked RuntimeMutable in the registry can be cleared.
+func (fm *FeatureManager) ClearRuntimeState(ctx context.Context, name string) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	flag, ok := fm.flags[name]
+	if !ok {
+		return ErrFeatureToggleNotFound
+	}
+	if flag.Internal {
+		return ErrFeatureToggleInternal
+	}
+	if !changeableAtRuntime(flag) {
+		return ErrFeatureToggleRequiresRestart
+	}
+	if _, ok := fm.runtime[name]; !ok {
+		return nil
+	}
+
+	if err := fm.runtimeStoreLocked().Delete(ctx, name); err != nil {
+		return err
+	}
+	before := fm.enabledCopyLocked()
+	delete(fm.runtime, name)
+	fm.updateLocked()
+	fm.publishChangesLocked(ctx, before, ToggleChangeSourceRuntime)
+	return nil
+}
+
+// SyncRuntimeStore reads the states shared by the instances from the runtime store and applies them, see
+// SyncRuntimeStates. They are read while holding the lock that the changes made on this instance hold while they are
+// stored, so that states older than these changes are never applied.
+func (fm *FeatureManager) SyncRuntimeStore(ctx context.Context) ([]ToggleChange, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	states, err := fm.runtimeStoreLocked().GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fm.syncRuntimeStatesLocked(ctx, states), nil
+}
+
+// SyncRuntimeStates replaces the states changed at runtime with the states shared by the instances, so changes made
+// on other instances are applied. The toggles that cannot be changed at runtime keep the state the instance was started
+// with, and their changes are pending until it restarts. The changes are counted in the metrics, but the change webhooks are
+// only notified by the instance the toggles were changed on.
+func (fm *FeatureManager) SyncRuntimeStates(ctx context.Context, states map[string]bool) []ToggleChange {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.syncRuntimeStatesLocked(ctx, states)
+}
+
+func (fm *FeatureManager) syncRuntimeStatesLocked(ctx context.Context, states map[string]bool) []ToggleChange {
+	before := fm.enabledCopyLocked()
+	runtime := make(map[string]bool, len(states))
+	for name, enabled := range fm.runtime {
+		if flag, ok := fm.flags[name]; ok && !changeableAtRuntime(flag) {
+			runtime[name] = enabled
+		}
+	}
+	for name, enabled := range states {
+		flag, ok := fm.flags[name]
+		if !ok || flag.Internal || (enabled && !fm.meetsRequirements(flag)) {
+			continue
+		}
+		if !changeableAtRuntime(flag) {
+			fm.syncPendingChangeLocked(name, enabled)
+			continue
+		}
+		runtime[name] = enabled
+	}
+	fm.runtime = runtime
+	fm.updateLocked()
+
+	changes := fm.changesLocked(ctx, before, ToggleChangeSourceSync)
+	for _, change := range changes {
+		featureToggleChanges.WithLabelValues(change.Name, ToggleChangeSourceSync).Inc()
+	}
+	fm.notifyListenersLocked(changes)
+	return changes
+}
+
+// applyRuntimeStateLocked records the state of the toggle changed at runtime and applies it.
+func (fm *FeatureManager) applyRuntimeStateLocked(name string, enabled bool) {
+	if fm.runtime == nil {
+		fm.runtime = make(map[string]bool)
+	}
+	fm.runtime[name] = enabled
+	if fm.enabled == nil {
+		fm.enabled = make(map[string]bool)
+	}
+	if enabled {
+		fm.enabled[name] = true
+	} else {
+		delete(fm.enabled, name)
+	}
+	setToggleMetrics(name, enabled)
+}
@@ -0,0 +1,112 @@
+package runtimestore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+)
+
+// featureToggleSnapshot is a row of the feature_toggle_snapshot table, States holds the states of the snapshot as
+// JSON.
+type featureToggleSnapshot struct {
+	Id        int64
+	Name      string
+	CreatedBy string
+	States    string
+	Created   time.Time
+}
+
+// sqlSnapshotStore is a featuremgmt.ToggleSnapshotStore backed by the Grafana database, so the snapshots survive
+// restarts and can be restored on any instance of a high availability setup.
+type sqlSnapshotStore struct {
+	db db.DB
+}
+
+func (s *sqlSnapshotStore) List(ctx context.Context) ([]featuremgmt.ToggleSnapshot, error) {
+	var rows []featureToggleSnapshot
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Asc("name").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]featuremgmt.ToggleSnapshot, 0, len(rows))
+	for _, row := range rows {
+		snapshot, err := row.toSnapshot()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (s *sqlSnapshotStore) Get(ctx context.Context, name string) (featuremgmt.ToggleSnapshot, error) {
+	row := featureToggleSnapshot{Name: name}
+	var has bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		has, err = sess.Get(&row)
+		return err
+	})
+	if err != nil {
+		return featuremgmt.ToggleSnapshot{}, err
+	}
+	if !has {
+		return featuremgmt.ToggleSnapshot{}, featuremgmt.ErrToggleSnapshotNotFound
+	}
+	return row.toSnapshot()
+}
+
+// Create saves the snapshot, the unique index on the name rejects the snapshots created at the same time on other
+// instances.
+func (s *sqlSnapshotStore) Create(ctx context.Context, snapshot featuremgmt.ToggleSnapshot) error {
+	states, err := json.Marshal(snapshot.States)
+	if err != nil {
+		return err
+	}
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Exist(&featureToggleSnapshot{Name: snapshot.Name})
+		if err != nil {
+			return err
+		}
+		if has {
+			return featuremgmt.ErrToggleSnapshotExists
+		}
+		_, err = sess.Insert(&featureToggleSnapshot{
+			Name:      snapshot.Name,
+			CreatedBy: snapshot.CreatedBy,
+			States:    string(states),
+			Created:   snapshot.Created,
+		})
+		return err
+	})
+}
+
+func (s *sqlSnapshotStore) Delete(ctx context.Context, name string) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM feature_toggle_snapshot WHERE name = ?", name)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return featuremgmt.ErrToggleSnapshotNotFound
+		}
+		return nil
+	})
+}
+
+func (row featureToggleSnapshot) toSnapshot() (featuremgmt.ToggleSnapshot, error) {
+	snapshot := featuremgmt.ToggleSnapshot{
+		Name:      row.Name,
+		Created:   row.Created,
+		CreatedBy: row.CreatedBy,
+	}
+	if err := json.Unmarshal([]byte(row.States), &snapshot.States); err != nil {
+		return featuremgmt.ToggleSnapshot{}, err
+	}
+	return snapshot, nil
+}
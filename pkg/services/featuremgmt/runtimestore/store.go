@@ -0,0 +1,156 @@
+package runtimestore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+)
+
+// ErrVersionConflict is returned when the state of a toggle was changed by another instance since this instance last
+// read it.
+var ErrVersionConflict = featuremgmt.ErrFeatureToggleConflict
+
+// featureToggleState is a row of the feature_toggle_state table. Version is incremented on every change, so the
+// instances can detect the changes made by the others.
+type featureToggleState struct {
+	Id      int64
+	Name    string
+	Enabled bool
+	Version int64
+	Updated time.Time
+}
+
+// sqlToggleStore is a featuremgmt.RuntimeToggleStore backed by the Grafana database, shared by the instances of a high
+// availability setup.
+type sqlToggleStore struct {
+	db db.DB
+
+	mu sync.Mutex
+	// versions are the versions of the states that the instance last read or stored. A state is only changed if it is
+	// still at this version, so that a change made on another instance and not synced yet is not overwritten.
+	versions map[string]int64
+}
+
+// GetAll returns the stored states, which become the versions that the instance changes.
+func (s *sqlToggleStore) GetAll(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.getVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.versions = versions(rows)
+	s.mu.Unlock()
+	states := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		states[row.Name] = row.Enabled
+	}
+	return states, nil
+}
+
+func (s *sqlToggleStore) Set(ctx context.Context, name string, enabled bool) error {
+	return s.SetAll(ctx, map[string]bool{name: enabled})
+}
+
+// SetAll records the states in a single transaction, so the other instances never sync part of them.
+func (s *sqlToggleStore) SetAll(ctx context.Context, states map[string]bool) error {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	// The rows are always locked in the same order, so concurrent changes do not deadlock.
+	sort.Strings(names)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := make(map[string]int64, len(names))
+	err := s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		for _, name := range names {
+			version, err := setState(sess, name, states[name], s.versions[name])
+			if err != nil {
+				return err
+			}
+			stored[name] = version
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if s.versions == nil {
+		s.versions = make(map[string]int64, len(stored))
+	}
+	for name, version := range stored {
+		s.versions[name] = version
+	}
+	return nil
+}
+
+// Delete removes the state of the toggle, unless it was changed on another instance since this instance read it.
+func (s *sqlToggleStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM feature_toggle_state WHERE name = ? AND version = ?", name, s.versions[name])
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return ErrVersionConflict
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	delete(s.versions, name)
+	return nil
+}
+
+// setState stores the state of the toggle if it is still at the expected version, 0 if the toggle has no state yet,
+// and returns its new version.
+func setState(sess *db.Session, name string, enabled bool, expected int64) (int64, error) {
+	row := featureToggleState{Name: name}
+	has, err := sess.Get(&row)
+	if err != nil {
+		return 0, err
+	}
+	if (has && row.Version != expected) || (!has && expected != 0) {
+		return 0, ErrVersionConflict
+	}
+
+	now := time.Now()
+	if !has {
+		_, err = sess.Insert(&featureToggleState{Name: name, Enabled: enabled, Version: 1, Updated: now})
+		return 1, err
+	}
+
+	res, err := sess.Exec("UPDATE feature_toggle_state SET enabled = ?, version = ?, updated = ? WHERE id = ? AND version = ?",
+		enabled, row.Version+1, now, row.Id, row.Version)
+	if err != nil {
+		return 0, err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return 0, ErrVersionConflict
+	}
+	return row.Version + 1, nil
+}
+
+// changed returns whether the versions differ from the ones this instance last read or stored.
+func (s *sqlToggleStore) changed(current map[string]int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return changed(s.versions, current)
+}
+
+// getVersions returns the state and version of every toggle changed at runtime.
+func (s *sqlToggleStore) getVersions(ctx context.Context) ([]featureToggleState, error) {
+	var rows []featureToggleState
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Find(&rows)
+	})
+	return rows, err
+}
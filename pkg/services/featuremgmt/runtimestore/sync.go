@@ -0,0 +1,105 @@
+package runtimestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Syncer stores the states of the toggles changed at runtime in the database, and polls it so the changes made on
+// other instances are applied within the sync interval.
+type Syncer struct {
+	features *featuremgmt.FeatureManager
+	store    *sqlToggleStore
+	enabled  bool
+	interval time.Duration
+	log      log.Logger
+}
+
+func ProvideSyncer(cfg *setting.Cfg, sqlStore db.DB, features *featuremgmt.FeatureManager) (*Syncer, error) {
+	s := &Syncer{
+		features: features,
+		store:    &sqlToggleStore{db: sqlStore},
+		enabled:  cfg.FeatureManagement.RuntimeStateStore == setting.FeatureToggleRuntimeStoreDatabase,
+		interval: cfg.FeatureManagement.RuntimeStateSyncInterval,
+		log:      log.New("featuremgmt.runtimestore"),
+	}
+	if s.interval <= 0 {
+		s.interval = 10 * time.Second
+	}
+	if !s.enabled {
+		return s, nil
+	}
+
+	// Apply the shared states before the server starts, the next ones are applied by Run. The snapshots are shared
+	// as well, so they can be restored on any instance.
+	features.SetSnapshotStore(&sqlSnapshotStore{db: sqlStore})
+	if err := features.SetRuntimeStore(context.Background(), s.store); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Syncer) IsDisabled() bool {
+	return !s.enabled
+}
+
+func (s *Syncer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sync(ctx); err != nil {
+				// The states are read again on the next tick.
+				s.log.Error("Failed to sync feature toggle states", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sync applies the states of the database if any of them changed since this instance last read or stored them.
+func (s *Syncer) sync(ctx context.Context) error {
+	rows, err := s.store.getVersions(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.store.changed(versions(rows)) {
+		return nil
+	}
+
+	changes, err := s.features.SyncRuntimeStore(ctx)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		s.log.Info("Feature toggle changed on another instance", "toggle", change.Name, "enabled", change.Enabled)
+	}
+	return nil
+}
+
+func versions(rows []featureToggleState) map[string]int64 {
+	v := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		v[row.Name] = row.Version
+	}
+	return v
+}
+
+func changed(before, after map[string]int64) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for name, version := range after {
+		if before[name] != version {
+			return true
+		}
+	}
+	return false
+}
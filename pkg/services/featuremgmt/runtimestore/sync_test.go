@@ -0,0 +1,123 @@
+package runtimestore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationSyncer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.FeatureManagement.RuntimeStateStore = setting.FeatureToggleRuntimeStoreDatabase
+
+	newInstance := func(t *testing.T) (*featuremgmt.FeatureManager, *Syncer) {
+		features := featuremgmt.WithFeatureFlags([]*featuremgmt.FeatureFlag{
+			{Name: "a", RuntimeMutable: true},
+			{Name: "b", Enabled: true, RuntimeMutable: true},
+		})
+		s, err := ProvideSyncer(cfg, sqlStore, features)
+		require.NoError(t, err)
+		require.False(t, s.IsDisabled())
+		return features, s
+	}
+	first, firstSyncer := newInstance(t)
+	second, secondSyncer := newInstance(t)
+
+	t.Run("should apply the changes made on another instance", func(t *testing.T) {
+		require.NoError(t, first.SetRuntimeState(context.Background(), "a", true))
+		require.NoError(t, first.SetRuntimeState(context.Background(), "b", false))
+		require.False(t, second.IsEnabled("a"))
+
+		require.NoError(t, secondSyncer.sync(context.Background()))
+		require.True(t, second.IsEnabled("a"))
+		require.False(t, second.IsEnabled("b"))
+
+		require.NoError(t, firstSyncer.sync(context.Background()))
+		require.True(t, first.IsEnabled("a"))
+	})
+
+	t.Run("should apply the stored states on startup", func(t *testing.T) {
+		third, _ := newInstance(t)
+		require.True(t, third.IsEnabled("a"))
+		require.False(t, third.IsEnabled("b"))
+	})
+
+	t.Run("should version the states", func(t *testing.T) {
+		require.NoError(t, second.SetRuntimeState(context.Background(), "a", false))
+		rows, err := secondSyncer.store.getVersions(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"a": 2, "b": 1}, versions(rows))
+
+		require.NoError(t, firstSyncer.sync(context.Background()))
+		require.False(t, first.IsEnabled("a"))
+	})
+	t.Run("should store several states at once", func(t *testing.T) {
+		require.NoError(t, firstSyncer.store.SetAll(context.Background(), map[string]bool{"a": true, "b": true}))
+		rows, err := firstSyncer.store.getVersions(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"a": 3, "b": 2}, versions(rows))
+
+		require.NoError(t, secondSyncer.sync(context.Background()))
+		require.True(t, second.IsEnabled("a"))
+		require.True(t, second.IsEnabled("b"))
+	})
+
+	t.Run("should not overwrite a change made on another instance that was not synced", func(t *testing.T) {
+		require.NoError(t, first.SetRuntimeState(context.Background(), "a", false))
+		require.ErrorIs(t, second.SetRuntimeState(context.Background(), "a", true), featuremgmt.ErrFeatureToggleConflict)
+		require.True(t, second.IsEnabled("a"))
+
+		require.NoError(t, secondSyncer.sync(context.Background()))
+		require.False(t, second.IsEnabled("a"))
+		require.NoError(t, second.SetRuntimeState(context.Background(), "a", true))
+		require.NoError(t, firstSyncer.sync(context.Background()))
+		require.True(t, first.IsEnabled("a"))
+	})
+
+	t.Run("should clear the states", func(t *testing.T) {
+		require.NoError(t, first.ClearRuntimeState(context.Background(), "a"))
+		require.False(t, first.IsEnabled("a"))
+		rows, err := firstSyncer.store.getVersions(context.Background())
+		require.NoError(t, err)
+		require.NotContains(t, versions(rows), "a")
+
+		require.NoError(t, secondSyncer.sync(context.Background()))
+		state, ok := second.GetRuntimeState("a")
+		require.True(t, ok)
+		require.False(t, state.Enabled)
+		require.False(t, state.Changed)
+	})
+	t.Run("should restore the snapshots saved on another instance", func(t *testing.T) {
+		require.NoError(t, first.SetRuntimeState(context.Background(), "a", true))
+		_, err := first.SaveSnapshot(context.Background(), "good")
+		require.NoError(t, err)
+		_, err = second.SaveSnapshot(context.Background(), "good")
+		require.ErrorIs(t, err, featuremgmt.ErrToggleSnapshotExists)
+		require.NoError(t, first.SetRuntimeState(context.Background(), "a", false))
+
+		require.NoError(t, secondSyncer.sync(context.Background()))
+		snapshots, err := second.GetSnapshots(context.Background())
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		require.Equal(t, map[string]bool{"a": true, "b": true}, snapshots[0].States)
+		_, err = second.RestoreSnapshot(context.Background(), "good")
+		require.NoError(t, err)
+		require.True(t, second.IsEnabled("a"))
+
+		third, _ := newInstance(t)
+		require.True(t, third.IsEnabled("a"))
+		require.NoError(t, third.DeleteSnapshot(context.Background(), "good"))
+		_, err = first.GetSnapshot(context.Background(), "good")
+		require.ErrorIs(t, err, featuremgmt.ErrToggleSnapshotNotFound)
+	})
+}
@@ -0,0 +1,90 @@
+package featuremgmt
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// scheduleInterval is how often the schedules of the toggles are evaluated.
+const scheduleInterval = 10 * time.Second
+
+// setSchedules registers the schedules of the toggles configured in [feature_toggles.schedule], which replace the
+// ones of the registry and features.yaml. Toggles that require a restart cannot be scheduled, as the server is
+// initialized with their value.
+func (fm *FeatureManager) setSchedules(schedules map[string]setting.FeatureToggleSchedule) {
+	for name, schedule := range schedules {
+		flag, ok := fm.flags[name]
+		if !ok {
+			fm.log.Warn("Ignoring the schedule of an unknown feature toggle", "toggle", name)
+			continue
+		}
+		flag.StartAt = schedule.StartAt
+		flag.EndAt = schedule.EndAt
+	}
+	for name, flag := range fm.flags {
+		if flag.RequiresRestart && (flag.StartAt != nil || flag.EndAt != nil) {
+			fm.log.Warn("Ignoring the schedule of a feature toggle that requires a restart", "toggle", name)
+			flag.StartAt = nil
+			flag.EndAt = nil
+		}
+	}
+	fm.update()
+}
+
+// scheduledState returns whether the toggle is enabled by its schedule, and false if it has none.
+func (fm *FeatureManager) scheduledState(flag *FeatureFlag) (bool, bool) {
+	if flag.StartAt == nil && flag.EndAt == nil {
+		return false, false
+	}
+	now := time.Now()
+	if fm.now != nil {
+		now = fm.now()
+	}
+	if flag.StartAt != nil && now.Before(*flag.StartAt) {
+		return false, true
+	}
+	if flag.EndAt != nil && !now.Before(*flag.EndAt) {
+		return false, true
+	}
+	return true, true
+}
+
+// hasSchedules checks if any toggle has a schedule.
+func (fm *FeatureManager) hasSchedules() bool {
+	for _, flag := range fm.flags {
+		if flag.StartAt != nil || flag.EndAt != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (fm *FeatureManager) IsDisabled() bool {
+	return !fm.hasSchedules()
+}
+
+// Run switches the scheduled toggles on and off when their schedule starts and ends.
+func (fm *FeatureManager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fm.evaluateSchedules()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (fm *FeatureManager) evaluateSchedules() {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	before := fm.enabledCopyLocked()
+	fm.updateLocked()
+	for _, change := range fm.publishChangesLocked(context.Background(), before, ToggleChangeSourceSchedule) {
+		fm.log.Info("Scheduled feature toggle switched", "toggle", change.Name, "enabled", change.Enabled)
+	}
+}
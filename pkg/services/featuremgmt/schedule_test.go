@@ -0,0 +1,67 @@
+package featuremgmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSchedules(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	fm := &FeatureManager{
+		flags: map[string]*FeatureFlag{},
+		log:   log.NewNopLogger(),
+		now:   func() time.Time { return now },
+	}
+	require.True(t, fm.IsDisabled())
+	fm.registerFlags(FeatureFlag{
+		Name: "launch",
+	}, FeatureFlag{
+		Name:       "emergency",
+		Expression: "true",
+	}, FeatureFlag{
+		Name:    "window",
+		StartAt: at(-time.Hour),
+		EndAt:   at(time.Hour),
+	}, FeatureFlag{
+		Name:            "restart",
+		RequiresRestart: true,
+	})
+	fm.setSchedules(map[string]setting.FeatureToggleSchedule{
+		"launch":    {StartAt: at(time.Minute)},
+		"emergency": {StartAt: at(2 * time.Minute)},
+		"restart":   {StartAt: at(-time.Minute)},
+	})
+	require.False(t, fm.IsDisabled())
+
+	require.False(t, fm.IsEnabled("launch"))
+	require.False(t, fm.IsEnabled("emergency"))
+	require.True(t, fm.IsEnabled("window"))
+	require.False(t, fm.IsEnabled("restart"))
+	require.Nil(t, fm.flags["restart"].StartAt)
+
+	now = now.Add(time.Minute)
+	fm.evaluateSchedules()
+	require.True(t, fm.IsEnabled("launch"))
+	require.False(t, fm.IsEnabled("emergency"))
+
+	now = now.Add(time.Hour)
+	fm.evaluateSchedules()
+	require.True(t, fm.IsEnabled("launch"))
+	require.True(t, fm.IsEnabled("emergency"))
+	require.False(t, fm.IsEnabled("window"))
+
+	// Toggles changed from the remote source take precedence
+	fm.setRemoteStates(map[string]bool{"window": true})
+	fm.evaluateSchedules()
+	require.True(t, fm.IsEnabled("window"))
+}
@@ -20,41 +20,79 @@ var (
 		Help:      "info metric that exposes what feature toggles are enabled or not",
 		Namespace: "grafana",
 	}, []string{"name"})
+
+	featureToggleEnabled = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "feature_toggle_enabled",
+		Help:      "1 if the feature toggle is enabled for the whole instance, 0 otherwise",
+		Namespace: "grafana",
+	}, []string{"name"})
+
+	featureToggleChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "feature_toggle_changes_total",
+		Help:      "number of changes of the state of feature toggles while running, by source of the change",
+		Namespace: "grafana",
+	}, []string{"name", "source"})
 )
 
+// setToggleMetrics updates the metrics of the state of the toggle
+func setToggleMetrics(name string, enabled bool) {
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	featureToggleInfo.WithLabelValues(name).Set(value)
+	featureToggleEnabled.WithLabelValues(name).Set(value)
+}
+
 func ProvideManagerService(cfg *setting.Cfg, licensing licensing.Licensing) (*FeatureManager, error) {
 	mgmt := &FeatureManager{
-		isDevMod:  setting.Env != setting.Prod,
-		licensing: licensing,
-		flags:     make(map[string]*FeatureFlag, 30),
-		enabled:   make(map[string]bool),
-		log:       log.New("featuremgmt"),
+		isDevMod:        setting.Env != setting.Prod,
+		licensing:       licensing,
+		flags:           make(map[string]*FeatureFlag, 30),
+		enabled:         make(map[string]bool),
+		log:             log.New("featuremgmt"),
+		webhook:         newChangeWebhook(cfg.FeatureManagement.ChangeWebhookURLs, cfg.FeatureManagement.ChangeWebhookSecret),
+		overridesSecret: cfg.FeatureManagement.RequestOverridesSecret,
+
+		evaluationTracing: cfg.FeatureManagement.EvaluationTracing,
 	}
 
 	// Register the standard flags
 	mgmt.registerFlags(standardFeatureFlags...)
 
+	// Pick the defaults of the edition, before the configured values replace them
+	mgmt.applyEditionDefaults(editionOf(cfg))
+
 	// Load the flags from `custom.ini` files
 	flags, err := setting.ReadFeatureTogglesFromInitFile(cfg.Raw.Section("feature_toggles"))
 	if err != nil {
 		return mgmt, err
 	}
+	var unknown []string
 	for key, val := range flags {
+		key = configuredFlagName(key)
 		flag, ok := mgmt.flags[key]
 		if !ok {
-			switch key {
-			// renamed the flag so it supports more panels
-			case "autoMigrateGraphPanels":
-				flag = mgmt.flags[FlagAutoMigrateOldPanels]
-			default:
-				flag = &FeatureFlag{
-					Name:  key,
-					Stage: FeatureStageUnknown,
-				}
-				mgmt.flags[key] = flag
+			unknown = append(unknown, key)
+			flag = &FeatureFlag{
+				Name:  key,
+				Stage: FeatureStageUnknown,
 			}
+			mgmt.flags[key] = flag
 		}
 		flag.Expression = fmt.Sprintf("%t", val) // true | false
+		mgmt.markConfigured(key)
+		if flag.IsDeprecated() {
+			mgmt.log.Warn("A deprecated feature toggle is configured, remove it from the configuration before it is removed from Grafana",
+				"toggle", flag.Name, "removalVersion", flag.RemovalVersion, "replacedBy", flag.ReplacedBy)
+		}
+	}
+	if len(unknown) > 0 {
+		err := mgmt.unknownFlagsError(unknown)
+		if cfg.FeatureManagement.StrictToggles {
+			return mgmt, err
+		}
+		mgmt.log.Error("Unknown feature toggles are configured, they have no effect", "error", err)
 	}
 
 	// Load config settings
@@ -71,11 +109,47 @@ func ProvideManagerService(cfg *setting.Cfg, licensing licensing.Licensing) (*Fe
 	// update the values
 	mgmt.update()
 
+	// Load the users, teams and roles the flags are enabled for
+	targeting, err := setting.ReadFeatureToggleTargetingFromInitFile(cfg.Raw.Section("feature_toggles.targeting"))
+	if err != nil {
+		return mgmt, err
+	}
+	mgmt.setTargeting(targeting)
+
+	// Load the times the flags are switched on and off
+	schedules, err := setting.ReadFeatureToggleScheduleFromInitFile(cfg.Raw.Section("feature_toggles.schedule"))
+	if err != nil {
+		return mgmt, err
+	}
+	mgmt.setSchedules(schedules)
+
+	// Load the variants users are assigned to
+	experiments, err := setting.ReadFeatureToggleExperimentsFromInitFile(cfg.Raw.Section("feature_toggles.experiments"))
+	if err != nil {
+		return mgmt, err
+	}
+	mgmt.setExperiments(experiments)
+
+	// Load the parameters of the flags from the [feature_toggles.<toggle>] sections
+	if err := mgmt.setToggleConfigs(setting.ReadFeatureToggleConfigsFromInitFile(cfg.Raw)); err != nil {
+		return mgmt, err
+	}
+
 	// Minimum approach to avoid circular dependency
 	cfg.IsFeatureToggleEnabled = mgmt.IsEnabled
 	return mgmt, nil
 }
 
+// configuredFlagName returns the name of the flag configured with the key of [feature_toggles]
+func configuredFlagName(key string) string {
+	switch key {
+	// renamed the flag so it supports more panels
+	case "autoMigrateGraphPanels":
+		return FlagAutoMigrateOldPanels
+	}
+	return key
+}
+
 // ProvideToggles allows read-only access to the feature state
 func ProvideToggles(mgmt *FeatureManager) FeatureToggles {
 	return mgmt
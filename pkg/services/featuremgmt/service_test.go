@@ -47,6 +47,34 @@ func TestFeatureService(t *testing.T) {
 	require.False(t, mgmt.IsEnabled("a.yes")) // licensed, but not enabled
 }
 
+func TestStrictToggles(t *testing.T) {
+	newCfg := func(t *testing.T, strict bool) *setting.Cfg {
+		cfg := setting.NewCfg()
+		cfg.FeatureManagement.StrictToggles = strict
+		_, err := cfg.Raw.Section("feature_toggles").NewKey("nestedfolders", "true")
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("should fail with suggestions in strict mode", func(t *testing.T) {
+		_, err := ProvideManagerService(newCfg(t, true), stubLicenseServier{})
+		require.ErrorContains(t, err, "nestedfolders (did you mean nestedFolders?)")
+	})
+
+	t.Run("should only log otherwise", func(t *testing.T) {
+		mgmt, err := ProvideManagerService(newCfg(t, false), stubLicenseServier{})
+		require.NoError(t, err)
+		require.False(t, mgmt.IsEnabled(FlagNestedFolders))
+	})
+
+	t.Run("should suggest close names", func(t *testing.T) {
+		mgmt, err := ProvideManagerService(setting.NewCfg(), stubLicenseServier{})
+		require.NoError(t, err)
+		require.Equal(t, FlagNestedFolders, mgmt.suggestFlagName("nestedFolder"))
+		require.Equal(t, "", mgmt.suggestFlagName("somethingCompletelyDifferent"))
+	})
+}
+
 var (
 	_ licensing.Licensing = (*stubLicenseServier)(nil)
 )
@@ -0,0 +1,198 @@
+package featuremgmt
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+)
+
+var (
+	ErrToggleSnapshotNotFound = errors.New("feature toggle snapshot not found")
+	ErrToggleSnapshotExists   = errors.New("feature toggle snapshot already exists")
+)
+
+// ToggleSnapshot is the state of the toggles that can be changed at runtime, saved under a name so it can be restored.
+type ToggleSnapshot struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	// CreatedBy is the login of the user who saved the snapshot.
+	CreatedBy string `json:"createdBy,omitempty"`
+	// States holds the state of every toggle that can be changed at runtime and is not internal, by name.
+	States map[string]bool `json:"states"`
+}
+
+// ToggleSnapshotStore holds the saved snapshots. It is the snapshot counterpart of the RuntimeToggleStore, so the
+// snapshots are shared by the instances when the runtime states are.
+type ToggleSnapshotStore interface {
+	// List returns the saved snapshots.
+	List(ctx context.Context) ([]ToggleSnapshot, error)
+	// Get returns the snapshot, or ErrToggleSnapshotNotFound.
+	Get(ctx context.Context, name string) (ToggleSnapshot, error)
+	// Create saves the snapshot, or returns ErrToggleSnapshotExists if there is one with the same name.
+	Create(ctx context.Context, snapshot ToggleSnapshot) error
+	// Delete deletes the snapshot, or returns ErrToggleSnapshotNotFound.
+	Delete(ctx context.Context, name string) error
+}
+
+// memorySnapshotStore is the ToggleSnapshotStore used by default, its snapshots are lost on restart.
+type memorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]ToggleSnapshot
+}
+
+// NewMemorySnapshotStore returns a ToggleSnapshotStore that keeps the snapshots in memory.
+func NewMemorySnapshotStore() ToggleSnapshotStore {
+	return &memorySnapshotStore{snapshots: make(map[string]ToggleSnapshot)}
+}
+
+func (s *memorySnapshotStore) List(_ context.Context) ([]ToggleSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshots := make([]ToggleSnapshot, 0, len(s.snapshots))
+	for _, snapshot := range s.snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (s *memorySnapshotStore) Get(_ context.Context, name string) (ToggleSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, ok := s.snapshots[name]
+	if !ok {
+		return ToggleSnapshot{}, ErrToggleSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func (s *memorySnapshotStore) Create(_ context.Context, snapshot ToggleSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.snapshots[snapshot.Name]; ok {
+		return ErrToggleSnapshotExists
+	}
+	s.snapshots[snapshot.Name] = snapshot
+	return nil
+}
+
+func (s *memorySnapshotStore) Delete(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.snapshots[name]; !ok {
+		return ErrToggleSnapshotNotFound
+	}
+	delete(s.snapshots, name)
+	return nil
+}
+
+// SetSnapshotStore replaces the store of the snapshots. It is called on startup, before the services are started.
+func (fm *FeatureManager) SetSnapshotStore(store ToggleSnapshotStore) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.snapshotStore = store
+}
+
+// snapshotStoreLocked returns the store of the snapshots, keeping them in memory if none was set.
+func (fm *FeatureManager) snapshotStoreLocked() ToggleSnapshotStore {
+	if fm.snapshotStore == nil {
+		fm.snapshotStore = NewMemorySnapshotStore()
+	}
+	return fm.snapshotStore
+}
+
+// getSnapshotStore returns the store of the snapshots, which is used without holding the lock.
+func (fm *FeatureManager) getSnapshotStore() ToggleSnapshotStore {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.snapshotStoreLocked()
+}
+
+// SaveSnapshot saves the current state of the toggles under the name in the snapshot store.
+func (fm *FeatureManager) SaveSnapshot(ctx context.Context, name string) (ToggleSnapshot, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	snapshot := ToggleSnapshot{
+		Name:    name,
+		Created: time.Now(),
+		States:  make(map[string]bool, len(fm.flags)),
+	}
+	if usr, err := appcontext.User(ctx); err == nil && usr != nil {
+		snapshot.CreatedBy = usr.Login
+	}
+	for key, flag := range fm.flags {
+		if !changeableAtRuntime(flag) || flag.Internal {
+			continue
+		}
+		snapshot.States[key] = fm.enabled[key]
+	}
+
+	if err := fm.snapshotStoreLocked().Create(ctx, snapshot); err != nil {
+		return ToggleSnapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// GetSnapshots returns the saved snapshots, sorted by name.
+func (fm *FeatureManager) GetSnapshots(ctx context.Context) ([]ToggleSnapshot, error) {
+	snapshots, err := fm.getSnapshotStore().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots, nil
+}
+
+// GetSnapshot returns the snapshot, or ErrToggleSnapshotNotFound.
+func (fm *FeatureManager) GetSnapshot(ctx context.Context, name string) (ToggleSnapshot, error) {
+	return fm.getSnapshotStore().Get(ctx, name)
+}
+
+// DeleteSnapshot deletes the snapshot.
+func (fm *FeatureManager) DeleteSnapshot(ctx context.Context, name string) error {
+	return fm.getSnapshotStore().Delete(ctx, name)
+}
+
+// RestoreSnapshot sets the toggles back to the states of the snapshot at once, as runtime changes, and returns the
+// changes. Toggles that were registered after the snapshot keep their state, and toggles that do not meet their
+// requirements anymore stay disabled. The states are only applied if the runtime store accepts all of them.
+func (fm *FeatureManager) RestoreSnapshot(ctx context.Context, name string) ([]ToggleChange, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	snapshot, err := fm.snapshotStoreLocked().Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the toggles that differ, or were already changed at runtime, are recorded as runtime states, so the
+	// others keep following their configuration.
+	states := make(map[string]bool)
+	for key, enabled := range snapshot.States {
+		flag, ok := fm.flags[key]
+		if !ok || !changeableAtRuntime(flag) || flag.Internal {
+			continue
+		}
+		if enabled && !fm.meetsRequirements(flag) {
+			fm.log.Warn("Feature toggle of the snapshot cannot be enabled on this instance", "snapshot", name, "toggle", key)
+			continue
+		}
+		if _, changed := fm.runtime[key]; changed || fm.enabled[key] != enabled {
+			states[key] = enabled
+		}
+	}
+
+	if fm.runtimeStore == nil {
+		fm.runtimeStore = NewMemoryToggleStore()
+	}
+	if err := fm.runtimeStore.SetAll(ctx, states); err != nil {
+		return nil, err
+	}
+	before := fm.enabledCopyLocked()
+	for key, enabled := range states {
+		fm.applyRuntimeStateLocked(key, enabled)
+	}
+	return fm.publishChangesLocked(ctx, before, ToggleChangeSourceSnapshot), nil
+}
@@ -0,0 +1,83 @@
+package featuremgmt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// failingToggleStore fails to record the nth state it is given. The states given to Set before it are recorded, but
+// none of the states given to SetAll with it are.
+type failingToggleStore struct {
+	RuntimeToggleStore
+	failOn int
+	count  int
+}
+
+var errStoreFailure = errors.New("store failure")
+
+func (s *failingToggleStore) Set(ctx context.Context, name string, enabled bool) error {
+	s.count++
+	if s.count == s.failOn {
+		return errStoreFailure
+	}
+	return s.RuntimeToggleStore.Set(ctx, name, enabled)
+}
+
+func (s *failingToggleStore) SetAll(ctx context.Context, states map[string]bool) error {
+	before := s.count
+	s.count += len(states)
+	if before < s.failOn && s.failOn <= s.count {
+		return errStoreFailure
+	}
+	return s.RuntimeToggleStore.SetAll(ctx, states)
+}
+
+func TestSnapshots(t *testing.T) {
+	newManager := func() *FeatureManager {
+		fm := &FeatureManager{
+			flags: map[string]*FeatureFlag{},
+			log:   log.NewNopLogger(),
+		}
+		fm.registerFlags(FeatureFlag{Name: "a", RuntimeMutable: true}, FeatureFlag{Name: "b", RuntimeMutable: true}, FeatureFlag{Name: "c", RuntimeMutable: true})
+		return fm
+	}
+
+	t.Run("should restore the states of the snapshot", func(t *testing.T) {
+		fm := newManager()
+		_, err := fm.SaveSnapshot(context.Background(), "good")
+		require.NoError(t, err)
+		for _, name := range []string{"a", "b", "c"} {
+			require.NoError(t, fm.SetRuntimeState(context.Background(), name, true))
+		}
+
+		changes, err := fm.RestoreSnapshot(context.Background(), "good")
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+		require.Empty(t, fm.GetEnabled(context.Background()))
+	})
+
+	t.Run("should not apply or store any state if the store fails to record one of them", func(t *testing.T) {
+		fm := newManager()
+		_, err := fm.SaveSnapshot(context.Background(), "good")
+		require.NoError(t, err)
+		for _, name := range []string{"a", "b", "c"} {
+			require.NoError(t, fm.SetRuntimeState(context.Background(), name, true))
+		}
+		stored, err := fm.runtimeStore.GetAll(context.Background())
+		require.NoError(t, err)
+
+		fm.runtimeStore = &failingToggleStore{RuntimeToggleStore: fm.runtimeStore, failOn: 2}
+		_, err = fm.RestoreSnapshot(context.Background(), "good")
+		require.ErrorIs(t, err, errStoreFailure)
+
+		require.Equal(t, map[string]bool{"a": true, "b": true, "c": true}, fm.GetEnabled(context.Background()))
+		after, err := fm.runtimeStore.GetAll(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, stored, after)
+	})
+}
@@ -0,0 +1,153 @@
+package featuremgmt
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// minorReleasesPerMajor is the number of minor releases a major release is counted as, to compare the versions of
+// different majors when counting the releases a toggle has been in its stage.
+const minorReleasesPerMajor = 5
+
+// Reasons a toggle is reported as stale.
+const (
+	StaleReasonPastRemovalVersion = "pastRemovalVersion"
+	StaleReasonExperimental       = "experimentalTooLong"
+	StaleReasonPreview            = "previewTooLong"
+)
+
+// Sources a toggle is configured in, see StaleToggle.
+const (
+	ToggleConfiguredInConfig     = "config"
+	ToggleConfiguredInRuntime    = "runtime"
+	ToggleConfiguredInRemote     = "remote"
+	ToggleConfiguredInTargeting  = "targeting"
+	ToggleConfiguredInSchedule   = "schedule"
+	ToggleConfiguredInExperiment = "experiment"
+)
+
+// StaleToggleWindows are the number of releases toggles can stay in the experimental and preview stages before they
+// are reported as stale. A window of 0 disables the check of its stage.
+type StaleToggleWindows struct {
+	Experimental int
+	Preview      int
+}
+
+// StaleToggle is a toggle past its stability window, which should be graduated or removed by its owner.
+type StaleToggle struct {
+	Name           string           `json:"name"`
+	Stage          FeatureFlagStage `json:"stage"`
+	Owner          string           `json:"owner,omitempty"`
+	Reason         string           `json:"reason"`
+	AddedInVersion string           `json:"addedInVersion,omitempty"`
+	RemovalVersion string           `json:"removalVersion,omitempty"`
+	// Releases is the number of releases the toggle has been in its stage for, when it was added in a known version.
+	Releases int  `json:"releases,omitempty"`
+	Enabled  bool `json:"enabled"`
+	// ConfiguredIn lists where the state of the toggle is explicitly set, so removing it is not a silent change.
+	ConfiguredIn []string `json:"configuredIn"`
+}
+
+// GetStaleToggles returns the toggles past their stability window in the current version of Grafana: deprecated
+// toggles past their removal version, and toggles in the experimental or preview stage for more releases than
+// allowed since the version they were added in.
+func (fm *FeatureManager) GetStaleToggles(currentVersion string, windows StaleToggleWindows) ([]StaleToggle, error) {
+	current, err := version.NewVersion(currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version of Grafana %s: %w", currentVersion, err)
+	}
+
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	stale := make([]StaleToggle, 0)
+	for _, flag := range fm.flags {
+		reason, releases := staleReason(flag, current, windows)
+		if reason == "" {
+			continue
+		}
+		stale = append(stale, StaleToggle{
+			Name:           flag.Name,
+			Stage:          flag.Stage,
+			Owner:          string(flag.Owner),
+			Reason:         reason,
+			AddedInVersion: flag.AddedInVersion,
+			RemovalVersion: flag.RemovalVersion,
+			Releases:       releases,
+			Enabled:        fm.enabled[flag.Name],
+			ConfiguredIn:   fm.configuredInLocked(flag),
+		})
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Name < stale[j].Name })
+	return stale, nil
+}
+
+// staleReason returns why the toggle is stale in the current version, and the releases it has been in its stage for.
+// Toggles with versions that cannot be parsed are never stale.
+func staleReason(flag *FeatureFlag, current *version.Version, windows StaleToggleWindows) (string, int) {
+	if flag.IsDeprecated() && flag.RemovalVersion != "" {
+		removal, err := version.NewVersion(flag.RemovalVersion)
+		if err == nil && current.Core().GreaterThanOrEqual(removal.Core()) {
+			return StaleReasonPastRemovalVersion, 0
+		}
+	}
+	if flag.AddedInVersion == "" {
+		return "", 0
+	}
+	added, err := version.NewVersion(flag.AddedInVersion)
+	if err != nil {
+		return "", 0
+	}
+	releases := releasesBetween(added, current)
+	switch {
+	case flag.Stage == FeatureStageExperimental && windows.Experimental > 0 && releases > windows.Experimental:
+		return StaleReasonExperimental, releases
+	case flag.Stage == FeatureStagePublicPreview && windows.Preview > 0 && releases > windows.Preview:
+		return StaleReasonPreview, releases
+	}
+	return "", releases
+}
+
+// releasesBetween counts the minor releases from one version to another.
+func releasesBetween(from, to *version.Version) int {
+	f, t := from.Segments(), to.Segments()
+	return (t[0]-f[0])*minorReleasesPerMajor + t[1] - f[1]
+}
+
+// configuredInLocked returns where the state of the toggle is explicitly set.
+func (fm *FeatureManager) configuredInLocked(flag *FeatureFlag) []string {
+	sources := make([]string, 0)
+	configured := fm.configured
+	// The reloaded configuration files replace the ones read on startup.
+	if fm.reloadedConfigured != nil {
+		configured = fm.reloadedConfigured
+	}
+	if _, ok := configured[flag.Name]; ok {
+		sources = append(sources, ToggleConfiguredInConfig)
+	}
+	if _, ok := fm.runtime[flag.Name]; ok {
+		sources = append(sources, ToggleConfiguredInRuntime)
+	}
+	if _, ok := fm.remote[flag.Name]; ok {
+		sources = append(sources, ToggleConfiguredInRemote)
+	}
+	if _, ok := fm.targeting[flag.Name]; ok {
+		sources = append(sources, ToggleConfiguredInTargeting)
+	}
+	if flag.StartAt != nil || flag.EndAt != nil {
+		sources = append(sources, ToggleConfiguredInSchedule)
+	}
+	if _, ok := fm.experiments[flag.Name]; ok {
+		sources = append(sources, ToggleConfiguredInExperiment)
+	}
+	return sources
+}
+
+// markConfigured records that the state of the toggle is set in the configuration files.
+func (fm *FeatureManager) markConfigured(name string) {
+	if fm.configured == nil {
+		fm.configured = make(map[string]struct{})
+	}
+	fm.configured[name] = struct{}{}
+}
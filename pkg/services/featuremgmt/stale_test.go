@@ -0,0 +1,98 @@
+package featuremgmt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestStaleToggles(t *testing.T) {
+	fm := &FeatureManager{
+		flags: map[string]*FeatureFlag{},
+		log:   log.NewNopLogger(),
+	}
+	fm.registerFlags(FeatureFlag{
+		Name:           "oldExperiment",
+		Stage:          FeatureStageExperimental,
+		AddedInVersion: "9.3.0",
+	}, FeatureFlag{
+		Name:           "newExperiment",
+		Stage:          FeatureStageExperimental,
+		AddedInVersion: "10.1.0",
+	}, FeatureFlag{
+		Name:           "oldPreview",
+		Stage:          FeatureStagePublicPreview,
+		AddedInVersion: "9.0.0",
+	}, FeatureFlag{
+		Name:           "removed",
+		Stage:          FeatureStageDeprecated,
+		RemovalVersion: "10.2.0",
+	}, FeatureFlag{
+		Name:  "unknownVersion",
+		Stage: FeatureStageExperimental,
+	}, FeatureFlag{
+		Name:           "stable",
+		Stage:          FeatureStageGeneralAvailability,
+		AddedInVersion: "8.0.0",
+	})
+	fm.markConfigured("oldExperiment")
+	fm.runtime = map[string]bool{"oldExperiment": true}
+	fm.update()
+
+	windows := StaleToggleWindows{Experimental: 3, Preview: 6}
+
+	t.Run("should report the toggles past their stability window", func(t *testing.T) {
+		stale, err := fm.GetStaleToggles("10.2.0-pre", windows)
+		require.NoError(t, err)
+		require.Equal(t, []StaleToggle{{
+			Name:           "oldExperiment",
+			Stage:          FeatureStageExperimental,
+			Reason:         StaleReasonExperimental,
+			AddedInVersion: "9.3.0",
+			Releases:       4,
+			Enabled:        true,
+			ConfiguredIn:   []string{ToggleConfiguredInConfig, ToggleConfiguredInRuntime},
+		}, {
+			Name:           "oldPreview",
+			Stage:          FeatureStagePublicPreview,
+			Reason:         StaleReasonPreview,
+			AddedInVersion: "9.0.0",
+			Releases:       7,
+			ConfiguredIn:   []string{},
+		}, {
+			Name:           "removed",
+			Stage:          FeatureStageDeprecated,
+			Reason:         StaleReasonPastRemovalVersion,
+			RemovalVersion: "10.2.0",
+			ConfiguredIn:   []string{},
+		}}, stale)
+	})
+
+	t.Run("should not report the stages with no window", func(t *testing.T) {
+		stale, err := fm.GetStaleToggles("10.1.0", StaleToggleWindows{})
+		require.NoError(t, err)
+		require.Empty(t, stale)
+	})
+
+	t.Run("should fail with an invalid version of Grafana", func(t *testing.T) {
+		_, err := fm.GetStaleToggles("dev", windows)
+		require.Error(t, err)
+	})
+
+	t.Run("should only report the toggles set in the reloaded configuration as configured", func(t *testing.T) {
+		fm.setReloadedStates(map[string]string{"oldPreview": "true"})
+		stale, err := fm.GetStaleToggles("10.2.0-pre", windows)
+		require.NoError(t, err)
+		configuredIn := make(map[string][]string, len(stale))
+		for _, toggle := range stale {
+			configuredIn[toggle.Name] = toggle.ConfiguredIn
+		}
+		require.Equal(t, map[string][]string{
+			"oldExperiment": {ToggleConfiguredInRuntime},
+			"oldPreview":    {ToggleConfiguredInConfig},
+			"removed":       {},
+		}, configuredIn)
+	})
+}
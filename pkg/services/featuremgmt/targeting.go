@@ -0,0 +1,93 @@
+package featuremgmt
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// setTargeting registers the users, teams and roles the feature toggles are enabled for. Only the toggles that are only
+// seen in the frontend can be enabled per user, as the frontend gets them for its user on every request while the
+// backend services check the others for the whole instance.
+func (fm *FeatureManager) setTargeting(targeting map[string]setting.FeatureToggleTargeting) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.targeting = make(map[string]setting.FeatureToggleTargeting, len(targeting))
+	for name, t := range targeting {
+		flag, ok := fm.flags[name]
+		if !ok {
+			fm.log.Warn("Ignoring the targeting of an unknown feature toggle", "toggle", name)
+			continue
+		}
+		if flag.RequiresRestart {
+			fm.log.Warn("Ignoring the targeting of a feature toggle that requires a restart", "toggle", name)
+			continue
+		}
+		if !flag.FrontendOnly {
+			fm.log.Warn("Ignoring the targeting of a feature toggle that is not only seen in the frontend", "toggle", name)
+			continue
+		}
+		fm.targeting[name] = t
+	}
+}
+
+// IsEnabledForUser checks if a feature is enabled, either for the whole instance or for the user of the request in
+// the context. The overrides of the request take precedence.
+func (fm *FeatureManager) IsEnabledForUser(ctx context.Context, flag string) bool {
+	on := fm.isEnabledForUser(ctx, flag)
+	recordEvaluation(ctx, flag, on)
+	return on
+}
+
+func (fm *FeatureManager) isEnabledForUser(ctx context.Context, flag string) bool {
+	if on, ok := requestOverrides(ctx)[flag]; ok {
+		return on
+	}
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	if fm.enabled[flag] {
+		return true
+	}
+	usr, err := appcontext.User(ctx)
+	if err != nil {
+		return false
+	}
+	return fm.isTargetedLocked(flag, usr)
+}
+
+// isTargetedLocked checks if the feature is enabled for the user by the targeting of the feature toggle.
+func (fm *FeatureManager) isTargetedLocked(name string, usr *user.SignedInUser) bool {
+	t, ok := fm.targeting[name]
+	if !ok || usr == nil {
+		return false
+	}
+	// A runtime change of the toggle applies to every user.
+	if _, ok := fm.runtime[name]; ok {
+		return false
+	}
+	if flag, ok := fm.flags[name]; !ok || !fm.meetsRequirements(flag) {
+		return false
+	}
+
+	for _, id := range t.UserIDs {
+		if id == usr.UserID {
+			return true
+		}
+	}
+	for _, id := range t.TeamIDs {
+		for _, teamID := range usr.Teams {
+			if id == teamID {
+				return true
+			}
+		}
+	}
+	for _, role := range t.Roles {
+		if usr.OrgRole != "" && strings.EqualFold(role, string(usr.OrgRole)) {
+			return true
+		}
+	}
+	return false
+}
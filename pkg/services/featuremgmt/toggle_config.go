@@ -0,0 +1,89 @@
+package featuremgmt
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// LokiQuerySplittingConfig is the configuration of lokiQuerySplitting in [feature_toggles.lokiQuerySplitting].
+type LokiQuerySplittingConfig struct {
+	// Interval is the duration of the subqueries large interval queries are split into.
+	Interval time.Duration `ini:"interval"`
+}
+
+// toggleConfigTypes returns the configurations the sections of the toggles are parsed into, by toggle name, with
+// their default values. The sections are validated on startup against them.
+var toggleConfigTypes = map[string]func() any{
+	"lokiQuerySplitting": func() any { return &LokiQuerySplittingConfig{Interval: 24 * time.Hour} },
+}
+
+// setToggleConfigs registers the parameters of the toggles configured in the [feature_toggles.<toggle>] sections.
+// Toggles with a typed configuration fail to start if their section cannot be parsed into it.
+func (fm *FeatureManager) setToggleConfigs(configs map[string]map[string]string) error {
+	fm.configs = make(map[string]map[string]string, len(configs))
+	for name, values := range configs {
+		if _, ok := fm.flags[name]; !ok {
+			fm.log.Warn("Ignoring the configuration of an unknown feature toggle", "toggle", name)
+			continue
+		}
+		fm.configs[name] = values
+		if newConfig, ok := toggleConfigTypes[name]; ok {
+			if err := fm.ParseToggleConfig(name, newConfig()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetToggleConfig returns the parameters of the toggle configured in [feature_toggles.<toggle>], and false if it has
+// none.
+func (fm *FeatureManager) GetToggleConfig(name string) (map[string]string, bool) {
+	values, ok := fm.configs[name]
+	return values, ok
+}
+
+// ParseToggleConfig parses the parameters of the toggle into v, a pointer to a struct with `ini` tags. The fields
+// of the parameters that are not configured keep their values.
+func (fm *FeatureManager) ParseToggleConfig(name string, v any) error {
+	values, ok := fm.configs[name]
+	if !ok {
+		return nil
+	}
+	section, err := ini.Empty().NewSection("feature_toggles." + name)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if _, err := section.NewKey(key, value); err != nil {
+			return err
+		}
+	}
+	if err := section.StrictMapTo(v); err != nil {
+		return fmt.Errorf("invalid configuration of %s in [feature_toggles.%s]: %w", name, name, err)
+	}
+	return nil
+}
+
+// LokiQuerySplittingConfig returns the configuration of lokiQuerySplitting, validated on startup.
+func (fm *FeatureManager) LokiQuerySplittingConfig() LokiQuerySplittingConfig {
+	cfg := toggleConfigTypes[FlagLokiQuerySplitting]().(*LokiQuerySplittingConfig)
+	if err := fm.ParseToggleConfig(FlagLokiQuerySplitting, cfg); err != nil {
+		fm.log.Error("Invalid feature toggle configuration", "toggle", FlagLokiQuerySplitting, "error", err)
+	}
+	return *cfg
+}
+
+// GetFrontendConfigs returns the parameters of the enabled toggles sent to the frontend, which excludes the internal
+// toggles.
+func (fm *FeatureManager) GetFrontendConfigs(enabled map[string]bool) map[string]map[string]string {
+	configs := make(map[string]map[string]string)
+	for name, values := range fm.configs {
+		if flag, ok := fm.flags[name]; ok && enabled[name] && !flag.Internal {
+			configs[name] = values
+		}
+	}
+	return configs
+}
@@ -0,0 +1,77 @@
+package featuremgmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestToggleConfigs(t *testing.T) {
+	newManager := func() *FeatureManager {
+		fm := &FeatureManager{
+			flags: map[string]*FeatureFlag{},
+			log:   log.NewNopLogger(),
+		}
+		fm.registerFlags(FeatureFlag{
+			Name:         FlagLokiQuerySplitting,
+			FrontendOnly: true,
+		}, FeatureFlag{
+			Name:     "operational",
+			Internal: true,
+		})
+		return fm
+	}
+
+	t.Run("should use the defaults without configuration", func(t *testing.T) {
+		fm := newManager()
+		require.NoError(t, fm.setToggleConfigs(nil))
+		require.Equal(t, LokiQuerySplittingConfig{Interval: 24 * time.Hour}, fm.LokiQuerySplittingConfig())
+		_, ok := fm.GetToggleConfig(FlagLokiQuerySplitting)
+		require.False(t, ok)
+	})
+
+	t.Run("should parse the configuration into the typed config", func(t *testing.T) {
+		fm := newManager()
+		require.NoError(t, fm.setToggleConfigs(map[string]map[string]string{
+			FlagLokiQuerySplitting: {"interval": "6h"},
+			"operational":          {"limit": "10"},
+			"unknown":              {"limit": "10"},
+		}))
+		require.Equal(t, LokiQuerySplittingConfig{Interval: 6 * time.Hour}, fm.LokiQuerySplittingConfig())
+
+		var operational struct {
+			Limit int `ini:"limit"`
+		}
+		require.NoError(t, fm.ParseToggleConfig("operational", &operational))
+		require.Equal(t, 10, operational.Limit)
+
+		_, ok := fm.GetToggleConfig("unknown")
+		require.False(t, ok)
+
+		state, ok := fm.GetRuntimeState(FlagLokiQuerySplitting)
+		require.True(t, ok)
+		require.Equal(t, map[string]string{"interval": "6h"}, state.Config)
+	})
+
+	t.Run("should only send the configuration of enabled frontend toggles", func(t *testing.T) {
+		fm := newManager()
+		require.NoError(t, fm.setToggleConfigs(map[string]map[string]string{
+			FlagLokiQuerySplitting: {"interval": "6h"},
+			"operational":          {"limit": "10"},
+		}))
+		require.Empty(t, fm.GetFrontendConfigs(map[string]bool{}))
+		require.Equal(t, map[string]map[string]string{
+			FlagLokiQuerySplitting: {"interval": "6h"},
+		}, fm.GetFrontendConfigs(map[string]bool{FlagLokiQuerySplitting: true, "operational": true}))
+	})
+
+	t.Run("should fail on an invalid typed config", func(t *testing.T) {
+		fm := newManager()
+		require.Error(t, fm.setToggleConfigs(map[string]map[string]string{
+			FlagLokiQuerySplitting: {"interval": "often"},
+		}))
+	})
+}
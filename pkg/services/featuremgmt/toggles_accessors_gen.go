@@ -0,0 +1,861 @@
+// NOTE: This file was auto generated.  DO NOT EDIT DIRECTLY!
+// To change feature flags, edit:
+//  pkg/services/featuremgmt/registry.go
+// Then run tests in:
+//  pkg/services/featuremgmt/toggles_gen_test.go
+
+package featuremgmt
+
+// TrimDefaults checks if the trimDefaults feature toggle is enabled
+// Use cue schema to remove values that will be applied automatically
+// Stage: preview, owner: @grafana/grafana-as-code
+func (f Flags) TrimDefaults() bool {
+	return f.features.IsEnabled(FlagTrimDefaults)
+}
+
+// DisableEnvelopeEncryption checks if the disableEnvelopeEncryption feature toggle is enabled
+// Disable envelope encryption (emergency only)
+// Stage: GA, owner: @grafana/grafana-as-code
+func (f Flags) DisableEnvelopeEncryption() bool {
+	return f.features.IsEnabled(FlagDisableEnvelopeEncryption)
+}
+
+// LiveServiceWebWorker checks if the live-service-web-worker feature toggle is enabled
+// This will use a webworker thread to processes events rather than the main thread
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) LiveServiceWebWorker() bool {
+	return f.features.IsEnabled(FlagLiveServiceWebWorker)
+}
+
+// QueryOverLive checks if the queryOverLive feature toggle is enabled
+// Use Grafana Live WebSocket to execute backend queries
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) QueryOverLive() bool {
+	return f.features.IsEnabled(FlagQueryOverLive)
+}
+
+// PanelTitleSearch checks if the panelTitleSearch feature toggle is enabled
+// Search for dashboards using panel title
+// Stage: preview, owner: @grafana/grafana-app-platform-squad
+func (f Flags) PanelTitleSearch() bool {
+	return f.features.IsEnabled(FlagPanelTitleSearch)
+}
+
+// PublicDashboards checks if the publicDashboards feature toggle is enabled
+// Enables public access to dashboards
+// Stage: preview, owner: @grafana/sharing-squad
+func (f Flags) PublicDashboards() bool {
+	return f.features.IsEnabled(FlagPublicDashboards)
+}
+
+// PublicDashboardsEmailSharing checks if the publicDashboardsEmailSharing feature toggle is enabled
+// Enables public dashboard sharing to be restricted to only allowed emails
+// Stage: preview, owner: @grafana/sharing-squad
+func (f Flags) PublicDashboardsEmailSharing() bool {
+	return f.features.IsEnabled(FlagPublicDashboardsEmailSharing)
+}
+
+// LokiExperimentalStreaming checks if the lokiExperimentalStreaming feature toggle is enabled
+// Support new streaming approach for loki (prototype, needs special loki build)
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LokiExperimentalStreaming() bool {
+	return f.features.IsEnabled(FlagLokiExperimentalStreaming)
+}
+
+// FeatureHighlights checks if the featureHighlights feature toggle is enabled
+// Highlight Grafana Enterprise features
+// Stage: GA, owner: @grafana/grafana-as-code
+func (f Flags) FeatureHighlights() bool {
+	return f.features.IsEnabled(FlagFeatureHighlights)
+}
+
+// MigrationLocking checks if the migrationLocking feature toggle is enabled
+// Lock database during migrations
+// Stage: preview, owner: @grafana/backend-platform
+func (f Flags) MigrationLocking() bool {
+	return f.features.IsEnabled(FlagMigrationLocking)
+}
+
+// Storage checks if the storage feature toggle is enabled
+// Configurable storage for dashboards, datasources, and resources
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) Storage() bool {
+	return f.features.IsEnabled(FlagStorage)
+}
+
+// Correlations checks if the correlations feature toggle is enabled
+// Correlations page
+// Stage: preview, owner: @grafana/explore-squad
+func (f Flags) Correlations() bool {
+	return f.features.IsEnabled(FlagCorrelations)
+}
+
+// DatasourceQueryMultiStatus checks if the datasourceQueryMultiStatus feature toggle is enabled
+// Introduce HTTP 207 Multi Status for api/ds/query
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) DatasourceQueryMultiStatus() bool {
+	return f.features.IsEnabled(FlagDatasourceQueryMultiStatus)
+}
+
+// TraceToMetrics checks if the traceToMetrics feature toggle is enabled
+// Enable trace to metrics links
+// Stage: experimental, owner: @grafana/observability-traces-and-profiling
+func (f Flags) TraceToMetrics() bool {
+	return f.features.IsEnabled(FlagTraceToMetrics)
+}
+
+// NewDBLibrary checks if the newDBLibrary feature toggle is enabled
+// Use jmoiron/sqlx rather than xorm for a few backend services
+// Stage: preview, owner: @grafana/backend-platform
+func (f Flags) NewDBLibrary() bool {
+	return f.features.IsEnabled(FlagNewDBLibrary)
+}
+
+// AutoMigrateOldPanels checks if the autoMigrateOldPanels feature toggle is enabled
+// Migrate old angular panels to supported versions (graph, table-old, worldmap, etc)
+// Stage: preview, owner: @grafana/dataviz-squad
+func (f Flags) AutoMigrateOldPanels() bool {
+	return f.features.IsEnabled(FlagAutoMigrateOldPanels)
+}
+
+// DisableAngular checks if the disableAngular feature toggle is enabled
+// Dynamic flag to disable angular at runtime. The preferred method is to set `angular_support_enabled` to `false` in the [security] settings, which allows you to change the state at runtime.
+// Stage: preview, owner: @grafana/dataviz-squad
+func (f Flags) DisableAngular() bool {
+	return f.features.IsEnabled(FlagDisableAngular)
+}
+
+// CanvasPanelNesting checks if the canvasPanelNesting feature toggle is enabled
+// Allow elements nesting
+// Stage: experimental, owner: @grafana/dataviz-squad
+func (f Flags) CanvasPanelNesting() bool {
+	return f.features.IsEnabled(FlagCanvasPanelNesting)
+}
+
+// Scenes checks if the scenes feature toggle is enabled
+// Experimental framework to build interactive dashboards
+// Stage: experimental, owner: @grafana/dashboards-squad
+func (f Flags) Scenes() bool {
+	return f.features.IsEnabled(FlagScenes)
+}
+
+// DisableSecretsCompatibility checks if the disableSecretsCompatibility feature toggle is enabled
+// Disable duplicated secret storage in legacy tables
+// Stage: experimental, owner: @grafana/hosted-grafana-team
+func (f Flags) DisableSecretsCompatibility() bool {
+	return f.features.IsEnabled(FlagDisableSecretsCompatibility)
+}
+
+// LogRequestsInstrumentedAsUnknown checks if the logRequestsInstrumentedAsUnknown feature toggle is enabled
+// Logs the path for requests that are instrumented as unknown
+// Stage: experimental, owner: @grafana/hosted-grafana-team
+func (f Flags) LogRequestsInstrumentedAsUnknown() bool {
+	return f.features.IsEnabled(FlagLogRequestsInstrumentedAsUnknown)
+}
+
+// DataConnectionsConsole checks if the dataConnectionsConsole feature toggle is enabled
+// Enables a new top-level page called Connections. This page is an experiment that provides a better experience when you install and configure data sources and other plugins.
+// Stage: GA, owner: @grafana/plugins-platform-backend
+func (f Flags) DataConnectionsConsole() bool {
+	return f.features.IsEnabled(FlagDataConnectionsConsole)
+}
+
+// Topnav checks if the topnav feature toggle is enabled
+// Enables topnav support in external plugins. The new Grafana navigation cannot be disabled.
+// Stage: deprecated, owner: @grafana/grafana-frontend-platform
+func (f Flags) Topnav() bool {
+	return f.features.IsEnabled(FlagTopnav)
+}
+
+// DockedMegaMenu checks if the dockedMegaMenu feature toggle is enabled
+// Enable support for a persistent (docked) navigation menu
+// Stage: preview, owner: @grafana/grafana-frontend-platform
+func (f Flags) DockedMegaMenu() bool {
+	return f.features.IsEnabled(FlagDockedMegaMenu)
+}
+
+// GrpcServer checks if the grpcServer feature toggle is enabled
+// Run the GRPC server
+// Stage: preview, owner: @grafana/grafana-app-platform-squad
+func (f Flags) GrpcServer() bool {
+	return f.features.IsEnabled(FlagGrpcServer)
+}
+
+// EntityStore checks if the entityStore feature toggle is enabled
+// SQL-based entity store (requires storage flag also)
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) EntityStore() bool {
+	return f.features.IsEnabled(FlagEntityStore)
+}
+
+// CloudWatchCrossAccountQuerying checks if the cloudWatchCrossAccountQuerying feature toggle is enabled
+// Enables cross-account querying in CloudWatch datasources
+// Stage: GA, owner: @grafana/aws-datasources
+func (f Flags) CloudWatchCrossAccountQuerying() bool {
+	return f.features.IsEnabled(FlagCloudWatchCrossAccountQuerying)
+}
+
+// RedshiftAsyncQueryDataSupport checks if the redshiftAsyncQueryDataSupport feature toggle is enabled
+// Enable async query data support for Redshift
+// Stage: GA, owner: @grafana/aws-datasources
+func (f Flags) RedshiftAsyncQueryDataSupport() bool {
+	return f.features.IsEnabled(FlagRedshiftAsyncQueryDataSupport)
+}
+
+// AthenaAsyncQueryDataSupport checks if the athenaAsyncQueryDataSupport feature toggle is enabled
+// Enable async query data support for Athena
+// Stage: GA, owner: @grafana/aws-datasources
+func (f Flags) AthenaAsyncQueryDataSupport() bool {
+	return f.features.IsEnabled(FlagAthenaAsyncQueryDataSupport)
+}
+
+// CloudwatchNewRegionsHandler checks if the cloudwatchNewRegionsHandler feature toggle is enabled
+// Refactor of /regions endpoint, no user-facing changes
+// Stage: GA, owner: @grafana/aws-datasources
+func (f Flags) CloudwatchNewRegionsHandler() bool {
+	return f.features.IsEnabled(FlagCloudwatchNewRegionsHandler)
+}
+
+// ShowDashboardValidationWarnings checks if the showDashboardValidationWarnings feature toggle is enabled
+// Show warnings when dashboards do not validate against the schema
+// Stage: experimental, owner: @grafana/dashboards-squad
+func (f Flags) ShowDashboardValidationWarnings() bool {
+	return f.features.IsEnabled(FlagShowDashboardValidationWarnings)
+}
+
+// MysqlAnsiQuotes checks if the mysqlAnsiQuotes feature toggle is enabled
+// Use double quotes to escape keyword in a MySQL query
+// Stage: experimental, owner: @grafana/backend-platform
+func (f Flags) MysqlAnsiQuotes() bool {
+	return f.features.IsEnabled(FlagMysqlAnsiQuotes)
+}
+
+// AccessControlOnCall checks if the accessControlOnCall feature toggle is enabled
+// Access control primitives for OnCall
+// Stage: preview, owner: @grafana/grafana-authnz-team
+func (f Flags) AccessControlOnCall() bool {
+	return f.features.IsEnabled(FlagAccessControlOnCall)
+}
+
+// NestedFolders checks if the nestedFolders feature toggle is enabled
+// Enable folder nesting
+// Stage: preview, owner: @grafana/backend-platform
+func (f Flags) NestedFolders() bool {
+	return f.features.IsEnabled(FlagNestedFolders)
+}
+
+// NestedFolderPicker checks if the nestedFolderPicker feature toggle is enabled
+// Enables the new folder picker to work with nested folders. Requires the nestedFolders feature flag
+// Stage: GA, owner: @grafana/grafana-frontend-platform
+func (f Flags) NestedFolderPicker() bool {
+	return f.features.IsEnabled(FlagNestedFolderPicker)
+}
+
+// AccessTokenExpirationCheck checks if the accessTokenExpirationCheck feature toggle is enabled
+// Enable OAuth access_token expiration check and token refresh using the refresh_token
+// Stage: GA, owner: @grafana/grafana-authnz-team
+func (f Flags) AccessTokenExpirationCheck() bool {
+	return f.features.IsEnabled(FlagAccessTokenExpirationCheck)
+}
+
+// EmptyDashboardPage checks if the emptyDashboardPage feature toggle is enabled
+// Enable the redesigned user interface of a dashboard page that includes no panels
+// Stage: GA, owner: @grafana/dashboards-squad
+func (f Flags) EmptyDashboardPage() bool {
+	return f.features.IsEnabled(FlagEmptyDashboardPage)
+}
+
+// DisablePrometheusExemplarSampling checks if the disablePrometheusExemplarSampling feature toggle is enabled
+// Disable Prometheus exemplar sampling
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) DisablePrometheusExemplarSampling() bool {
+	return f.features.IsEnabled(FlagDisablePrometheusExemplarSampling)
+}
+
+// AlertingBacktesting checks if the alertingBacktesting feature toggle is enabled
+// Rule backtesting API for alerting
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) AlertingBacktesting() bool {
+	return f.features.IsEnabled(FlagAlertingBacktesting)
+}
+
+// EditPanelCSVDragAndDrop checks if the editPanelCSVDragAndDrop feature toggle is enabled
+// Enables drag and drop for CSV and Excel files
+// Stage: experimental, owner: @grafana/grafana-bi-squad
+func (f Flags) EditPanelCSVDragAndDrop() bool {
+	return f.features.IsEnabled(FlagEditPanelCSVDragAndDrop)
+}
+
+// AlertingNoNormalState checks if the alertingNoNormalState feature toggle is enabled
+// Stop maintaining state of alerts that are not firing
+// Stage: preview, owner: @grafana/alerting-squad
+func (f Flags) AlertingNoNormalState() bool {
+	return f.features.IsEnabled(FlagAlertingNoNormalState)
+}
+
+// LogsContextDatasourceUi checks if the logsContextDatasourceUi feature toggle is enabled
+// Allow datasource to provide custom UI for context view
+// Stage: GA, owner: @grafana/observability-logs
+func (f Flags) LogsContextDatasourceUi() bool {
+	return f.features.IsEnabled(FlagLogsContextDatasourceUi)
+}
+
+// LokiQuerySplitting checks if the lokiQuerySplitting feature toggle is enabled
+// Split large interval queries into subqueries with smaller time intervals
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LokiQuerySplitting() bool {
+	return f.features.IsEnabled(FlagLokiQuerySplitting)
+}
+
+// LokiQuerySplittingConfig checks if the lokiQuerySplittingConfig feature toggle is enabled
+// Give users the option to configure split durations for Loki queries
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LokiQuerySplittingConfig() bool {
+	return f.features.IsEnabled(FlagLokiQuerySplittingConfig)
+}
+
+// IndividualCookiePreferences checks if the individualCookiePreferences feature toggle is enabled
+// Support overriding cookie preferences per user
+// Stage: experimental, owner: @grafana/backend-platform
+func (f Flags) IndividualCookiePreferences() bool {
+	return f.features.IsEnabled(FlagIndividualCookiePreferences)
+}
+
+// GcomOnlyExternalOrgRoleSync checks if the gcomOnlyExternalOrgRoleSync feature toggle is enabled
+// Prohibits a user from changing organization roles synced with Grafana Cloud auth provider
+// Stage: GA, owner: @grafana/grafana-authnz-team
+func (f Flags) GcomOnlyExternalOrgRoleSync() bool {
+	return f.features.IsEnabled(FlagGcomOnlyExternalOrgRoleSync)
+}
+
+// PrometheusMetricEncyclopedia checks if the prometheusMetricEncyclopedia feature toggle is enabled
+// Adds the metrics explorer component to the Prometheus query builder as an option in metric select
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) PrometheusMetricEncyclopedia() bool {
+	return f.features.IsEnabled(FlagPrometheusMetricEncyclopedia)
+}
+
+// TimeSeriesTable checks if the timeSeriesTable feature toggle is enabled
+// Enable time series table transformer & sparkline cell type
+// Stage: experimental, owner: @grafana/app-o11y
+func (f Flags) TimeSeriesTable() bool {
+	return f.features.IsEnabled(FlagTimeSeriesTable)
+}
+
+// PrometheusResourceBrowserCache checks if the prometheusResourceBrowserCache feature toggle is enabled
+// Displays browser caching options in Prometheus data source configuration
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) PrometheusResourceBrowserCache() bool {
+	return f.features.IsEnabled(FlagPrometheusResourceBrowserCache)
+}
+
+// InfluxdbBackendMigration checks if the influxdbBackendMigration feature toggle is enabled
+// Query InfluxDB InfluxQL without the proxy
+// Stage: preview, owner: @grafana/observability-metrics
+func (f Flags) InfluxdbBackendMigration() bool {
+	return f.features.IsEnabled(FlagInfluxdbBackendMigration)
+}
+
+// ClientTokenRotation checks if the clientTokenRotation feature toggle is enabled
+// Replaces the current in-request token rotation so that the client initiates the rotation
+// Stage: experimental, owner: @grafana/grafana-authnz-team
+func (f Flags) ClientTokenRotation() bool {
+	return f.features.IsEnabled(FlagClientTokenRotation)
+}
+
+// PrometheusDataplane checks if the prometheusDataplane feature toggle is enabled
+// Changes responses to from Prometheus to be compliant with the dataplane specification. In particular it sets the numeric Field.Name from 'Value' to the value of the `__name__` label when present.
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) PrometheusDataplane() bool {
+	return f.features.IsEnabled(FlagPrometheusDataplane)
+}
+
+// LokiMetricDataplane checks if the lokiMetricDataplane feature toggle is enabled
+// Changes metric responses from Loki to be compliant with the dataplane specification.
+// Stage: GA, owner: @grafana/observability-logs
+func (f Flags) LokiMetricDataplane() bool {
+	return f.features.IsEnabled(FlagLokiMetricDataplane)
+}
+
+// LokiLogsDataplane checks if the lokiLogsDataplane feature toggle is enabled
+// Changes logs responses from Loki to be compliant with the dataplane specification.
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LokiLogsDataplane() bool {
+	return f.features.IsEnabled(FlagLokiLogsDataplane)
+}
+
+// DataplaneFrontendFallback checks if the dataplaneFrontendFallback feature toggle is enabled
+// Support dataplane contract field name change for transformations and field name matchers where the name is different
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) DataplaneFrontendFallback() bool {
+	return f.features.IsEnabled(FlagDataplaneFrontendFallback)
+}
+
+// DisableSSEDataplane checks if the disableSSEDataplane feature toggle is enabled
+// Disables dataplane specific processing in server side expressions.
+// Stage: experimental, owner: @grafana/observability-metrics
+func (f Flags) DisableSSEDataplane() bool {
+	return f.features.IsEnabled(FlagDisableSSEDataplane)
+}
+
+// AlertStateHistoryLokiSecondary checks if the alertStateHistoryLokiSecondary feature toggle is enabled
+// Enable Grafana to write alert state history to an external Loki instance in addition to Grafana annotations.
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) AlertStateHistoryLokiSecondary() bool {
+	return f.features.IsEnabled(FlagAlertStateHistoryLokiSecondary)
+}
+
+// AlertingNotificationsPoliciesMatchingInstances checks if the alertingNotificationsPoliciesMatchingInstances feature toggle is enabled
+// Enables the preview of matching instances for notification policies
+// Stage: GA, owner: @grafana/alerting-squad
+func (f Flags) AlertingNotificationsPoliciesMatchingInstances() bool {
+	return f.features.IsEnabled(FlagAlertingNotificationsPoliciesMatchingInstances)
+}
+
+// AlertStateHistoryLokiPrimary checks if the alertStateHistoryLokiPrimary feature toggle is enabled
+// Enable a remote Loki instance as the primary source for state history reads.
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) AlertStateHistoryLokiPrimary() bool {
+	return f.features.IsEnabled(FlagAlertStateHistoryLokiPrimary)
+}
+
+// AlertStateHistoryLokiOnly checks if the alertStateHistoryLokiOnly feature toggle is enabled
+// Disable Grafana alerts from emitting annotations when a remote Loki instance is available.
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) AlertStateHistoryLokiOnly() bool {
+	return f.features.IsEnabled(FlagAlertStateHistoryLokiOnly)
+}
+
+// UnifiedRequestLog checks if the unifiedRequestLog feature toggle is enabled
+// Writes error logs to the request logger
+// Stage: experimental, owner: @grafana/backend-platform
+func (f Flags) UnifiedRequestLog() bool {
+	return f.features.IsEnabled(FlagUnifiedRequestLog)
+}
+
+// RenderAuthJWT checks if the renderAuthJWT feature toggle is enabled
+// Uses JWT-based auth for rendering instead of relying on remote cache
+// Stage: preview, owner: @grafana/grafana-as-code
+func (f Flags) RenderAuthJWT() bool {
+	return f.features.IsEnabled(FlagRenderAuthJWT)
+}
+
+// ExternalServiceAuth checks if the externalServiceAuth feature toggle is enabled
+// Starts an OAuth2 authentication provider for external services
+// Stage: experimental, owner: @grafana/grafana-authnz-team
+func (f Flags) ExternalServiceAuth() bool {
+	return f.features.IsEnabled(FlagExternalServiceAuth)
+}
+
+// RefactorVariablesTimeRange checks if the refactorVariablesTimeRange feature toggle is enabled
+// Refactor time range variables flow to reduce number of API calls made when query variables are chained
+// Stage: preview, owner: @grafana/dashboards-squad
+func (f Flags) RefactorVariablesTimeRange() bool {
+	return f.features.IsEnabled(FlagRefactorVariablesTimeRange)
+}
+
+// UseCachingService checks if the useCachingService feature toggle is enabled
+// When turned on, the new query and resource caching implementation using a wire service inject will be used in place of the previous middleware implementation
+// Stage: GA, owner: @grafana/grafana-operator-experience-squad
+func (f Flags) UseCachingService() bool {
+	return f.features.IsEnabled(FlagUseCachingService)
+}
+
+// EnableElasticsearchBackendQuerying checks if the enableElasticsearchBackendQuerying feature toggle is enabled
+// Enable the processing of queries and responses in the Elasticsearch data source through backend
+// Stage: GA, owner: @grafana/observability-logs
+func (f Flags) EnableElasticsearchBackendQuerying() bool {
+	return f.features.IsEnabled(FlagEnableElasticsearchBackendQuerying)
+}
+
+// AdvancedDataSourcePicker checks if the advancedDataSourcePicker feature toggle is enabled
+// Enable a new data source picker with contextual information, recently used order and advanced mode
+// Stage: GA, owner: @grafana/dashboards-squad
+func (f Flags) AdvancedDataSourcePicker() bool {
+	return f.features.IsEnabled(FlagAdvancedDataSourcePicker)
+}
+
+// FaroDatasourceSelector checks if the faroDatasourceSelector feature toggle is enabled
+// Enable the data source selector within the Frontend Apps section of the Frontend Observability
+// Stage: preview, owner: @grafana/app-o11y
+func (f Flags) FaroDatasourceSelector() bool {
+	return f.features.IsEnabled(FlagFaroDatasourceSelector)
+}
+
+// EnableDatagridEditing checks if the enableDatagridEditing feature toggle is enabled
+// Enables the edit functionality in the datagrid panel
+// Stage: preview, owner: @grafana/grafana-bi-squad
+func (f Flags) EnableDatagridEditing() bool {
+	return f.features.IsEnabled(FlagEnableDatagridEditing)
+}
+
+// DataSourcePageHeader checks if the dataSourcePageHeader feature toggle is enabled
+// Apply new pageHeader UI in data source edit page
+// Stage: preview, owner: @grafana/enterprise-datasources
+func (f Flags) DataSourcePageHeader() bool {
+	return f.features.IsEnabled(FlagDataSourcePageHeader)
+}
+
+// ExtraThemes checks if the extraThemes feature toggle is enabled
+// Enables extra themes
+// Stage: experimental, owner: @grafana/grafana-frontend-platform
+func (f Flags) ExtraThemes() bool {
+	return f.features.IsEnabled(FlagExtraThemes)
+}
+
+// LokiPredefinedOperations checks if the lokiPredefinedOperations feature toggle is enabled
+// Adds predefined query operations to Loki query editor
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LokiPredefinedOperations() bool {
+	return f.features.IsEnabled(FlagLokiPredefinedOperations)
+}
+
+// PluginsFrontendSandbox checks if the pluginsFrontendSandbox feature toggle is enabled
+// Enables the plugins frontend sandbox
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) PluginsFrontendSandbox() bool {
+	return f.features.IsEnabled(FlagPluginsFrontendSandbox)
+}
+
+// DashboardEmbed checks if the dashboardEmbed feature toggle is enabled
+// Allow embedding dashboard for external use in Code editors
+// Stage: experimental, owner: @grafana/grafana-as-code
+func (f Flags) DashboardEmbed() bool {
+	return f.features.IsEnabled(FlagDashboardEmbed)
+}
+
+// FrontendSandboxMonitorOnly checks if the frontendSandboxMonitorOnly feature toggle is enabled
+// Enables monitor only in the plugin frontend sandbox (if enabled)
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) FrontendSandboxMonitorOnly() bool {
+	return f.features.IsEnabled(FlagFrontendSandboxMonitorOnly)
+}
+
+// SqlDatasourceDatabaseSelection checks if the sqlDatasourceDatabaseSelection feature toggle is enabled
+// Enables previous SQL data source dataset dropdown behavior
+// Stage: preview, owner: @grafana/grafana-bi-squad
+func (f Flags) SqlDatasourceDatabaseSelection() bool {
+	return f.features.IsEnabled(FlagSqlDatasourceDatabaseSelection)
+}
+
+// LokiFormatQuery checks if the lokiFormatQuery feature toggle is enabled
+// Enables the ability to format Loki queries
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LokiFormatQuery() bool {
+	return f.features.IsEnabled(FlagLokiFormatQuery)
+}
+
+// CloudWatchLogsMonacoEditor checks if the cloudWatchLogsMonacoEditor feature toggle is enabled
+// Enables the Monaco editor for CloudWatch Logs queries
+// Stage: GA, owner: @grafana/aws-datasources
+func (f Flags) CloudWatchLogsMonacoEditor() bool {
+	return f.features.IsEnabled(FlagCloudWatchLogsMonacoEditor)
+}
+
+// ExploreScrollableLogsContainer checks if the exploreScrollableLogsContainer feature toggle is enabled
+// Improves the scrolling behavior of logs in Explore
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) ExploreScrollableLogsContainer() bool {
+	return f.features.IsEnabled(FlagExploreScrollableLogsContainer)
+}
+
+// RecordedQueriesMulti checks if the recordedQueriesMulti feature toggle is enabled
+// Enables writing multiple items from a single query within Recorded Queries
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) RecordedQueriesMulti() bool {
+	return f.features.IsEnabled(FlagRecordedQueriesMulti)
+}
+
+// PluginsDynamicAngularDetectionPatterns checks if the pluginsDynamicAngularDetectionPatterns feature toggle is enabled
+// Enables fetching Angular detection patterns for plugins from GCOM and fallback to hardcoded ones
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) PluginsDynamicAngularDetectionPatterns() bool {
+	return f.features.IsEnabled(FlagPluginsDynamicAngularDetectionPatterns)
+}
+
+// VizAndWidgetSplit checks if the vizAndWidgetSplit feature toggle is enabled
+// Split panels between visualizations and widgets
+// Stage: experimental, owner: @grafana/dashboards-squad
+func (f Flags) VizAndWidgetSplit() bool {
+	return f.features.IsEnabled(FlagVizAndWidgetSplit)
+}
+
+// PrometheusIncrementalQueryInstrumentation checks if the prometheusIncrementalQueryInstrumentation feature toggle is enabled
+// Adds RudderStack events to incremental queries
+// Stage: experimental, owner: @grafana/observability-metrics
+func (f Flags) PrometheusIncrementalQueryInstrumentation() bool {
+	return f.features.IsEnabled(FlagPrometheusIncrementalQueryInstrumentation)
+}
+
+// LogsExploreTableVisualisation checks if the logsExploreTableVisualisation feature toggle is enabled
+// A table visualisation for logs in Explore
+// Stage: experimental, owner: @grafana/observability-logs
+func (f Flags) LogsExploreTableVisualisation() bool {
+	return f.features.IsEnabled(FlagLogsExploreTableVisualisation)
+}
+
+// AwsDatasourcesTempCredentials checks if the awsDatasourcesTempCredentials feature toggle is enabled
+// Support temporary security credentials in AWS plugins for Grafana Cloud customers
+// Stage: experimental, owner: @grafana/aws-datasources
+func (f Flags) AwsDatasourcesTempCredentials() bool {
+	return f.features.IsEnabled(FlagAwsDatasourcesTempCredentials)
+}
+
+// TransformationsRedesign checks if the transformationsRedesign feature toggle is enabled
+// Enables the transformations redesign
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) TransformationsRedesign() bool {
+	return f.features.IsEnabled(FlagTransformationsRedesign)
+}
+
+// ToggleLabelsInLogsUI checks if the toggleLabelsInLogsUI feature toggle is enabled
+// Enable toggleable filters in log details view
+// Stage: GA, owner: @grafana/observability-logs
+func (f Flags) ToggleLabelsInLogsUI() bool {
+	return f.features.IsEnabled(FlagToggleLabelsInLogsUI)
+}
+
+// MlExpressions checks if the mlExpressions feature toggle is enabled
+// Enable support for Machine Learning in server-side expressions
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) MlExpressions() bool {
+	return f.features.IsEnabled(FlagMlExpressions)
+}
+
+// TraceQLStreaming checks if the traceQLStreaming feature toggle is enabled
+// Enables response streaming of TraceQL queries of the Tempo data source
+// Stage: experimental, owner: @grafana/observability-traces-and-profiling
+func (f Flags) TraceQLStreaming() bool {
+	return f.features.IsEnabled(FlagTraceQLStreaming)
+}
+
+// MetricsSummary checks if the metricsSummary feature toggle is enabled
+// Enables metrics summary queries in the Tempo data source
+// Stage: experimental, owner: @grafana/observability-traces-and-profiling
+func (f Flags) MetricsSummary() bool {
+	return f.features.IsEnabled(FlagMetricsSummary)
+}
+
+// GrafanaAPIServer checks if the grafanaAPIServer feature toggle is enabled
+// Enable Kubernetes API Server for Grafana resources
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) GrafanaAPIServer() bool {
+	return f.features.IsEnabled(FlagGrafanaAPIServer)
+}
+
+// GrafanaAPIServerWithExperimentalAPIs checks if the grafanaAPIServerWithExperimentalAPIs feature toggle is enabled
+// Register experimental APIs with the k8s API server
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) GrafanaAPIServerWithExperimentalAPIs() bool {
+	return f.features.IsEnabled(FlagGrafanaAPIServerWithExperimentalAPIs)
+}
+
+// FeatureToggleAdminPage checks if the featureToggleAdminPage feature toggle is enabled
+// Enable admin page for managing feature toggles from the Grafana front-end
+// Stage: experimental, owner: @grafana/grafana-operator-experience-squad
+func (f Flags) FeatureToggleAdminPage() bool {
+	return f.features.IsEnabled(FlagFeatureToggleAdminPage)
+}
+
+// AwsAsyncQueryCaching checks if the awsAsyncQueryCaching feature toggle is enabled
+// Enable caching for async queries for Redshift and Athena. Requires that the `useCachingService` feature toggle is enabled and the datasource has caching and async query support enabled
+// Stage: preview, owner: @grafana/aws-datasources
+func (f Flags) AwsAsyncQueryCaching() bool {
+	return f.features.IsEnabled(FlagAwsAsyncQueryCaching)
+}
+
+// SplitScopes checks if the splitScopes feature toggle is enabled
+// Support faster dashboard and folder search by splitting permission scopes into parts
+// Stage: preview, owner: @grafana/grafana-authnz-team
+func (f Flags) SplitScopes() bool {
+	return f.features.IsEnabled(FlagSplitScopes)
+}
+
+// AzureMonitorDataplane checks if the azureMonitorDataplane feature toggle is enabled
+// Adds dataplane compliant frame metadata in the Azure Monitor datasource
+// Stage: GA, owner: @grafana/partner-datasources
+func (f Flags) AzureMonitorDataplane() bool {
+	return f.features.IsEnabled(FlagAzureMonitorDataplane)
+}
+
+// PermissionsFilterRemoveSubquery checks if the permissionsFilterRemoveSubquery feature toggle is enabled
+// Alternative permission filter implementation that does not use subqueries for fetching the dashboard folder
+// Stage: experimental, owner: @grafana/backend-platform
+func (f Flags) PermissionsFilterRemoveSubquery() bool {
+	return f.features.IsEnabled(FlagPermissionsFilterRemoveSubquery)
+}
+
+// PrometheusConfigOverhaulAuth checks if the prometheusConfigOverhaulAuth feature toggle is enabled
+// Update the Prometheus configuration page with the new auth component
+// Stage: GA, owner: @grafana/observability-metrics
+func (f Flags) PrometheusConfigOverhaulAuth() bool {
+	return f.features.IsEnabled(FlagPrometheusConfigOverhaulAuth)
+}
+
+// ConfigurableSchedulerTick checks if the configurableSchedulerTick feature toggle is enabled
+// Enable changing the scheduler base interval via configuration option unified_alerting.scheduler_tick_interval
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) ConfigurableSchedulerTick() bool {
+	return f.features.IsEnabled(FlagConfigurableSchedulerTick)
+}
+
+// InfluxdbSqlSupport checks if the influxdbSqlSupport feature toggle is enabled
+// Enable InfluxDB SQL query language support with new querying UI
+// Stage: experimental, owner: @grafana/observability-metrics
+func (f Flags) InfluxdbSqlSupport() bool {
+	return f.features.IsEnabled(FlagInfluxdbSqlSupport)
+}
+
+// NoBasicRole checks if the noBasicRole feature toggle is enabled
+// Enables a new role that has no permissions by default
+// Stage: experimental, owner: @grafana/grafana-authnz-team
+func (f Flags) NoBasicRole() bool {
+	return f.features.IsEnabled(FlagNoBasicRole)
+}
+
+// AlertingNoDataErrorExecution checks if the alertingNoDataErrorExecution feature toggle is enabled
+// Changes how Alerting state manager handles execution of NoData/Error
+// Stage: privatePreview, owner: @grafana/alerting-squad
+func (f Flags) AlertingNoDataErrorExecution() bool {
+	return f.features.IsEnabled(FlagAlertingNoDataErrorExecution)
+}
+
+// AngularDeprecationUI checks if the angularDeprecationUI feature toggle is enabled
+// Display new Angular deprecation-related UI features
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) AngularDeprecationUI() bool {
+	return f.features.IsEnabled(FlagAngularDeprecationUI)
+}
+
+// Dashgpt checks if the dashgpt feature toggle is enabled
+// Enable AI powered features in dashboards
+// Stage: experimental, owner: @grafana/dashboards-squad
+func (f Flags) Dashgpt() bool {
+	return f.features.IsEnabled(FlagDashgpt)
+}
+
+// ReportingRetries checks if the reportingRetries feature toggle is enabled
+// Enables rendering retries for the reporting feature
+// Stage: preview, owner: @grafana/sharing-squad
+func (f Flags) ReportingRetries() bool {
+	return f.features.IsEnabled(FlagReportingRetries)
+}
+
+// NewBrowseDashboards checks if the newBrowseDashboards feature toggle is enabled
+// New browse/manage dashboards UI
+// Stage: GA, owner: @grafana/grafana-frontend-platform
+func (f Flags) NewBrowseDashboards() bool {
+	return f.features.IsEnabled(FlagNewBrowseDashboards)
+}
+
+// SseGroupByDatasource checks if the sseGroupByDatasource feature toggle is enabled
+// Send query to the same datasource in a single request when using server side expressions
+// Stage: experimental, owner: @grafana/observability-metrics
+func (f Flags) SseGroupByDatasource() bool {
+	return f.features.IsEnabled(FlagSseGroupByDatasource)
+}
+
+// RequestInstrumentationStatusSource checks if the requestInstrumentationStatusSource feature toggle is enabled
+// Include a status source label for request metrics and logs
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) RequestInstrumentationStatusSource() bool {
+	return f.features.IsEnabled(FlagRequestInstrumentationStatusSource)
+}
+
+// LokiRunQueriesInParallel checks if the lokiRunQueriesInParallel feature toggle is enabled
+// Enables running Loki queries in parallel
+// Stage: privatePreview, owner: @grafana/observability-logs
+func (f Flags) LokiRunQueriesInParallel() bool {
+	return f.features.IsEnabled(FlagLokiRunQueriesInParallel)
+}
+
+// WargamesTesting checks if the wargamesTesting feature toggle is enabled
+// Placeholder feature flag for internal testing
+// Stage: experimental, owner: @grafana/hosted-grafana-team
+func (f Flags) WargamesTesting() bool {
+	return f.features.IsEnabled(FlagWargamesTesting)
+}
+
+// AlertingInsights checks if the alertingInsights feature toggle is enabled
+// Show the new alerting insights landing page
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) AlertingInsights() bool {
+	return f.features.IsEnabled(FlagAlertingInsights)
+}
+
+// ExternalCorePlugins checks if the externalCorePlugins feature toggle is enabled
+// Allow core plugins to be loaded as external
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) ExternalCorePlugins() bool {
+	return f.features.IsEnabled(FlagExternalCorePlugins)
+}
+
+// PluginsAPIMetrics checks if the pluginsAPIMetrics feature toggle is enabled
+// Sends metrics of public grafana packages usage by plugins
+// Stage: experimental, owner: @grafana/plugins-platform-backend
+func (f Flags) PluginsAPIMetrics() bool {
+	return f.features.IsEnabled(FlagPluginsAPIMetrics)
+}
+
+// HttpSLOLevels checks if the httpSLOLevels feature toggle is enabled
+// Adds SLO level to http request metrics
+// Stage: experimental, owner: @grafana/hosted-grafana-team
+func (f Flags) HttpSLOLevels() bool {
+	return f.features.IsEnabled(FlagHttpSLOLevels)
+}
+
+// IdForwarding checks if the idForwarding feature toggle is enabled
+// Generate signed id token for identity that can be forwarded to plugins and external services
+// Stage: experimental, owner: @grafana/grafana-authnz-team
+func (f Flags) IdForwarding() bool {
+	return f.features.IsEnabled(FlagIdForwarding)
+}
+
+// CloudWatchWildCardDimensionValues checks if the cloudWatchWildCardDimensionValues feature toggle is enabled
+// Fetches dimension values from CloudWatch to correctly label wildcard dimensions
+// Stage: GA, owner: @grafana/aws-datasources
+func (f Flags) CloudWatchWildCardDimensionValues() bool {
+	return f.features.IsEnabled(FlagCloudWatchWildCardDimensionValues)
+}
+
+// ExternalServiceAccounts checks if the externalServiceAccounts feature toggle is enabled
+// Automatic service account and token setup for plugins
+// Stage: experimental, owner: @grafana/grafana-authnz-team
+func (f Flags) ExternalServiceAccounts() bool {
+	return f.features.IsEnabled(FlagExternalServiceAccounts)
+}
+
+// AlertingModifiedExport checks if the alertingModifiedExport feature toggle is enabled
+// Enables using UI for provisioned rules modification and export
+// Stage: experimental, owner: @grafana/alerting-squad
+func (f Flags) AlertingModifiedExport() bool {
+	return f.features.IsEnabled(FlagAlertingModifiedExport)
+}
+
+// PanelMonitoring checks if the panelMonitoring feature toggle is enabled
+// Enables panel monitoring through logs and measurements
+// Stage: experimental, owner: @grafana/dataviz-squad
+func (f Flags) PanelMonitoring() bool {
+	return f.features.IsEnabled(FlagPanelMonitoring)
+}
+
+// EnableNativeHTTPHistogram checks if the enableNativeHTTPHistogram feature toggle is enabled
+// Enables native HTTP Histograms
+// Stage: experimental, owner: @grafana/hosted-grafana-team
+func (f Flags) EnableNativeHTTPHistogram() bool {
+	return f.features.IsEnabled(FlagEnableNativeHTTPHistogram)
+}
+
+// TransformationsVariableSupport checks if the transformationsVariableSupport feature toggle is enabled
+// Allows using variables in transformations
+// Stage: experimental, owner: @grafana/grafana-bi-squad
+func (f Flags) TransformationsVariableSupport() bool {
+	return f.features.IsEnabled(FlagTransformationsVariableSupport)
+}
+
+// KubernetesPlaylists checks if the kubernetesPlaylists feature toggle is enabled
+// Use the kubernetes API in the frontend for playlists
+// Stage: experimental, owner: @grafana/grafana-app-platform-squad
+func (f Flags) KubernetesPlaylists() bool {
+	return f.features.IsEnabled(FlagKubernetesPlaylists)
+}
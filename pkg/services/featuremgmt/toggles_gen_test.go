@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
 	"github.com/olekukonko/tablewriter"
 	"github.com/stretchr/testify/require"
 
@@ -29,6 +30,10 @@ func TestFeatureToggleFiles(t *testing.T) {
 	}
 
 	t.Run("check registry constraints", func(t *testing.T) {
+		names := make(map[string]bool, len(standardFeatureFlags))
+		for _, flag := range standardFeatureFlags {
+			names[flag.Name] = true
+		}
 		for _, flag := range standardFeatureFlags {
 			if flag.Expression == "true" && !(flag.Stage == FeatureStageGeneralAvailability || flag.Stage == FeatureStageDeprecated) {
 				t.Errorf("only FeatureStageGeneralAvailability or FeatureStageDeprecated features can be enabled by default.  See: %s", flag.Name)
@@ -45,6 +50,25 @@ func TestFeatureToggleFiles(t *testing.T) {
 			if flag.Name != strings.TrimSpace(flag.Name) {
 				t.Errorf("flag Name should not start/end with spaces.  See: %s", flag.Name)
 			}
+			if (flag.RemovalVersion != "" || flag.ReplacedBy != "") && !flag.IsDeprecated() {
+				t.Errorf("only deprecated features can have a removal version or a replacement.  See: %s", flag.Name)
+			}
+			if flag.ReplacedBy != "" && !names[flag.ReplacedBy] {
+				t.Errorf("flag should be replaced by a registered flag.  See: %s", flag.Name)
+			}
+			if flag.Internal && flag.FrontendOnly {
+				t.Errorf("internal flags are not sent to the frontend.  See: %s", flag.Name)
+			}
+			if flag.AddedInVersion != "" {
+				if _, err := version.NewVersion(flag.AddedInVersion); err != nil {
+					t.Errorf("flag should be added in a valid version.  See: %s", flag.Name)
+				}
+			}
+			for edition := range flag.EditionDefaults {
+				if edition != EditionOSS && edition != EditionEnterprise && edition != EditionCloud {
+					t.Errorf("flag has a default state for an unknown edition %q.  See: %s", edition, flag.Name)
+				}
+			}
 		}
 	})
 
@@ -68,6 +92,10 @@ func TestFeatureToggleFiles(t *testing.T) {
 			"toggles_gen.go",
 			generateRegistry(t),
 		)
+		verifyAndGenerateFile(t,
+			"toggles_accessors_gen.go",
+			generateAccessors(),
+		)
 
 		// Docs files
 		verifyAndGenerateFile(t,
@@ -144,6 +172,9 @@ func generateTypeScript() string {
 export interface FeatureToggles {
 `
 	for _, flag := range standardFeatureFlags {
+		if flag.Internal {
+			continue
+		}
 		buf += "  " + getTypeScriptKey(flag.Name) + "?: boolean;\n"
 	}
 
@@ -204,6 +235,33 @@ const (`)
 	return buff.String()
 }
 
+// generateAccessors generates the typed accessors of Flags, documented with the description and owner of the flag
+func generateAccessors() string {
+	var buff strings.Builder
+	buff.WriteString(`// NOTE: This file was auto generated.  DO NOT EDIT DIRECTLY!
+// To change feature flags, edit:
+//  pkg/services/featuremgmt/registry.go
+// Then run tests in:
+//  pkg/services/featuremgmt/toggles_gen_test.go
+
+package featuremgmt
+`)
+
+	for _, flag := range standardFeatureFlags {
+		name := strcase.ToCamel(flag.Name)
+		buff.WriteString("\n// " + name + " checks if the " + flag.Name + " feature toggle is enabled\n")
+		if flag.Description != "" {
+			buff.WriteString("// " + flag.Description + "\n")
+		}
+		buff.WriteString("// Stage: " + flag.Stage.String() + ", owner: " + string(flag.Owner) + "\n")
+		buff.WriteString("func (f Flags) " + name + "() bool {\n")
+		buff.WriteString("\treturn f.features.IsEnabled(Flag" + name + ")\n")
+		buff.WriteString("}\n")
+	}
+
+	return buff.String()
+}
+
 func generateCSV() string {
 	var buf bytes.Buffer
 
@@ -309,7 +367,7 @@ func writeToggleDocsTable(include func(FeatureFlag) bool, showEnableByDefault bo
 	data := [][]string{}
 
 	for _, flag := range standardFeatureFlags {
-		if include(flag) && !flag.HideFromDocs {
+		if include(flag) && !flag.HideFromDocs && !flag.Internal {
 			row := []string{"`" + flag.Name + "`", flag.Description}
 			if showEnableByDefault {
 				on := ""
@@ -0,0 +1,71 @@
+package featuremgmt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestionDistance is the largest edit distance of a registered toggle suggested for an unknown one.
+const maxSuggestionDistance = 3
+
+// unknownFlagsError describes the unknown toggles of [feature_toggles], with the registered toggles they are likely
+// typos of.
+func (fm *FeatureManager) unknownFlagsError(unknown []string) error {
+	sort.Strings(unknown)
+	descriptions := make([]string, 0, len(unknown))
+	for _, name := range unknown {
+		if suggestion := fm.suggestFlagName(name); suggestion != "" {
+			descriptions = append(descriptions, fmt.Sprintf("%s (did you mean %s?)", name, suggestion))
+			continue
+		}
+		descriptions = append(descriptions, name)
+	}
+	return fmt.Errorf("unknown feature toggles in [feature_toggles]: %s", strings.Join(descriptions, ", "))
+}
+
+// suggestFlagName returns the registered toggle closest to the name, or an empty string if none is close enough.
+// Names that only differ in case are always suggested.
+func (fm *FeatureManager) suggestFlagName(name string) string {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for registered, flag := range fm.flags {
+		if flag.Stage == FeatureStageUnknown {
+			continue
+		}
+		if strings.EqualFold(registered, name) {
+			return registered
+		}
+		distance := editDistance(strings.ToLower(name), strings.ToLower(registered))
+		if distance < bestDistance || (distance == bestDistance && registered < best) {
+			best, bestDistance = registered, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,154 @@
+package featuremgmt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// Sources of a ToggleChange.
+const (
+	ToggleChangeSourceRuntime  = "runtime"
+	ToggleChangeSourceRemote   = "remote"
+	ToggleChangeSourceConfig   = "config"
+	ToggleChangeSourceSchedule = "schedule"
+	ToggleChangeSourceSnapshot = "snapshot"
+	ToggleChangeSourceSync     = "sync"
+)
+
+// ChangeWebhookSignatureHeader is the header of the change webhooks that holds the hex encoded HMAC-SHA256 of the
+// body, keyed with the change_webhook_secret setting and prefixed with "sha256=".
+const ChangeWebhookSignatureHeader = "X-Grafana-Signature"
+
+// ToggleChange is a change of the state of a toggle at runtime.
+type ToggleChange struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+	// User is the login of the user who changed the toggle, if it was changed through the API.
+	User      string    `json:"user,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ToggleChangePayload is the body of the change webhooks.
+type ToggleChangePayload struct {
+	Changes []ToggleChange `json:"changes"`
+}
+
+// changeWebhook notifies the configured endpoints of the changes of the toggles at runtime.
+type changeWebhook struct {
+	urls   []string
+	secret string
+	client *http.Client
+	log    log.Logger
+}
+
+func newChangeWebhook(urls []string, secret string) *changeWebhook {
+	if len(urls) == 0 {
+		return nil
+	}
+	return &changeWebhook{
+		urls:   urls,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+		log:    log.New("featuremgmt.webhook"),
+	}
+}
+
+// notify sends the changes to every endpoint in the background, so the toggles are not held by slow endpoints. It is
+// a no-op on a nil changeWebhook.
+func (w *changeWebhook) notify(changes []ToggleChange) {
+	if w == nil || len(changes) == 0 {
+		return
+	}
+	body, err := json.Marshal(ToggleChangePayload{Changes: changes})
+	if err != nil {
+		w.log.Error("Failed to marshal feature toggle changes", "error", err)
+		return
+	}
+	for _, url := range w.urls {
+		go func(url string) {
+			if err := w.send(context.Background(), url, body); err != nil {
+				w.log.Error("Failed to send feature toggle changes", "url", url, "error", err)
+			}
+		}(url)
+	}
+}
+
+func (w *changeWebhook) send(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set(ChangeWebhookSignatureHeader, "sha256="+signChangePayload(w.secret, body))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := resp.Body.Close(); err != nil {
+		w.log.Warn("Failed to close response body", "error", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// signChangePayload returns the hex encoded HMAC-SHA256 of the body.
+func signChangePayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// changesLocked returns the changes of the enabled toggles since before, which are the toggles enabled before.
+func (fm *FeatureManager) changesLocked(ctx context.Context, before map[string]bool, source string) []ToggleChange {
+	var login string
+	if usr, err := appcontext.User(ctx); err == nil {
+		login = usr.Login
+	}
+	now := time.Now().UTC()
+
+	var changes []ToggleChange
+	for name := range fm.flags {
+		if before[name] != fm.enabled[name] {
+			changes = append(changes, ToggleChange{Name: name, Enabled: fm.enabled[name], Source: source, User: login, Timestamp: now})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// publishChangesLocked counts the changes of the enabled toggles since before in the metrics, and notifies the webhook
+// of them.
+func (fm *FeatureManager) publishChangesLocked(ctx context.Context, before map[string]bool, source string) []ToggleChange {
+	changes := fm.changesLocked(ctx, before, source)
+	for _, change := range changes {
+		featureToggleChanges.WithLabelValues(change.Name, source).Inc()
+	}
+	fm.webhook.notify(changes)
+	fm.notifyListenersLocked(changes)
+	return changes
+}
+
+// enabledCopyLocked returns a copy of the enabled toggles, to compute the changes with changesLocked.
+func (fm *FeatureManager) enabledCopyLocked() map[string]bool {
+	enabled := make(map[string]bool, len(fm.enabled))
+	for name, on := range fm.enabled {
+		enabled[name] = on
+	}
+	return enabled
+}
@@ -0,0 +1,86 @@
+package featuremgmt
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestChangeWebhook(t *testing.T) {
+	received := make(chan ToggleChangePayload, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "sha256="+signChangePayload("secret", body), r.Header.Get(ChangeWebhookSignatureHeader))
+
+		var payload ToggleChangePayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	t.Cleanup(server.Close)
+
+	fm := &FeatureManager{
+		flags:   map[string]*FeatureFlag{},
+		log:     log.NewNopLogger(),
+		webhook: newChangeWebhook([]string{server.URL}, "secret"),
+	}
+	fm.registerFlags(FeatureFlag{
+		Name:       "a",
+		Expression: "true",
+	}, FeatureFlag{
+		Name:           "b",
+		RuntimeMutable: true,
+	})
+
+	receive := func(t *testing.T) ToggleChangePayload {
+		select {
+		case payload := <-received:
+			return payload
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not called")
+		}
+		return ToggleChangePayload{}
+	}
+
+	t.Run("should notify runtime changes with the user", func(t *testing.T) {
+		ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{Login: "admin"})
+		require.NoError(t, fm.SetRuntimeState(ctx, "b", true))
+
+		payload := receive(t)
+		require.Len(t, payload.Changes, 1)
+		require.Equal(t, "b", payload.Changes[0].Name)
+		require.True(t, payload.Changes[0].Enabled)
+		require.Equal(t, ToggleChangeSourceRuntime, payload.Changes[0].Source)
+		require.Equal(t, "admin", payload.Changes[0].User)
+	})
+
+	t.Run("should notify remote changes", func(t *testing.T) {
+		fm.setRemoteStates(map[string]bool{"a": false, "b": false})
+
+		// b was changed at runtime, so only a changes
+		payload := receive(t)
+		require.Len(t, payload.Changes, 1)
+		require.Equal(t, "a", payload.Changes[0].Name)
+		require.False(t, payload.Changes[0].Enabled)
+		require.Equal(t, ToggleChangeSourceRemote, payload.Changes[0].Source)
+	})
+
+	t.Run("should not notify if nothing changed", func(t *testing.T) {
+		fm.setRemoteStates(map[string]bool{"a": false})
+		select {
+		case payload := <-received:
+			t.Fatalf("unexpected webhook call: %v", payload)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/guardian"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/services/store/entity"
@@ -33,6 +35,7 @@ type Service struct {
 	dashboardFolderStore folder.FolderStore
 	features             featuremgmt.FeatureToggles
 	accessControl        accesscontrol.AccessControl
+	quotaService         quota.Service
 
 	// bus is currently used to publish event in case of title change
 	bus bus.Bus
@@ -49,6 +52,7 @@ func ProvideService(
 	folderStore folder.FolderStore,
 	db db.DB, // DB for the (new) nested folder store
 	features featuremgmt.FeatureToggles,
+	quotaService quota.Service,
 ) folder.Service {
 	store := ProvideStore(db, cfg, features)
 	srv := &Service{
@@ -59,6 +63,7 @@ func ProvideService(
 		store:                store,
 		features:             features,
 		accessControl:        ac,
+		quotaService:         quotaService,
 		bus:                  bus,
 		db:                   db,
 		registry:             make(map[string]folder.RegistryService),
@@ -606,6 +611,10 @@ func (s *Service) Move(ctx context.Context, cmd *folder.MoveFolderCommand) (*fol
 		}
 	}
 
+	if err := s.checkMovedFolderQuota(ctx, cmd); err != nil {
+		return nil, err
+	}
+
 	newParentUID := ""
 	if cmd.NewParentUID != "" {
 		newParentUID = cmd.NewParentUID
@@ -618,6 +627,39 @@ func (s *Service) Move(ctx context.Context, cmd *folder.MoveFolderCommand) (*fol
 	})
 }
 
+// movedFolderQuotaTargets are the quota services with a folder quota, by the kind of the resources they count in a
+// folder.
+var movedFolderQuotaTargets = map[string]quota.TargetSrv{
+	entity.StandardKindDashboard: dashboards.QuotaTargetSrv,
+	entity.StandardKindAlertRule: ngmodels.QuotaTargetSrv,
+}
+
+// checkMovedFolderQuota returns quota.ErrFolderQuotaReached if the new parent of the folder, or one of its parents,
+// cannot contain the dashboards and alert rules of the subtree of the folder.
+func (s *Service) checkMovedFolderQuota(ctx context.Context, cmd *folder.MoveFolderCommand) error {
+	if cmd.NewParentUID == "" || s.quotaService == nil {
+		return nil
+	}
+	counts, err := s.GetDescendantCounts(ctx, &folder.GetDescendantCountsQuery{UID: &cmd.UID, OrgID: cmd.OrgID, SignedInUser: cmd.SignedInUser})
+	if err != nil {
+		return err
+	}
+	for kind, targetSrv := range movedFolderQuotaTargets {
+		if counts[kind] == 0 {
+			continue
+		}
+		if err := s.quotaService.CheckFolderQuotaReached(ctx, targetSrv, &quota.ScopeParameters{
+			OrgID:          cmd.OrgID,
+			FolderUID:      cmd.NewParentUID,
+			Count:          counts[kind],
+			MovedFolderUID: cmd.UID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // nestedFolderDelete inspects the folder referenced by the cmd argument, deletes all the entries for
 // its descendant folders (folders which are nested within it either directly or indirectly) from
 // the folder store and returns the UIDs for all its descendants.
@@ -242,7 +242,7 @@ func (ss *sqlStore) GetParents(ctx context.Context, q folder.GetParentsQuery) ([
 func (ss *sqlStore) GetChildren(ctx context.Context, q folder.GetChildrenQuery) ([]*folder.Folder, error) {
 	var folders []*folder.Folder
 
-	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+	err := ss.db.WithReadReplicaDbSession(ctx, func(sess *db.Session) error {
 		sql := strings.Builder{}
 		args := make([]any, 0, 2)
 		if q.UID == "" {
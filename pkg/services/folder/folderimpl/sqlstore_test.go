@@ -26,8 +26,9 @@ func TestIntegrationCreate(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
@@ -146,8 +147,9 @@ func TestIntegrationDelete(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
@@ -193,8 +195,9 @@ func TestIntegrationUpdate(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
@@ -368,8 +371,9 @@ func TestIntegrationGet(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
@@ -475,8 +479,9 @@ func TestIntegrationGetParents(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
@@ -543,8 +548,9 @@ func TestIntegrationGetChildren(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
@@ -703,8 +709,9 @@ func TestIntegrationGetHeight(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
+	t.Parallel()
 
-	db := sqlstore.InitTestDB(t)
+	db := sqlstore.InitIsolatedTestDB(t)
 	folderStore := ProvideStore(db, db.Cfg, featuremgmt.WithFeatures(featuremgmt.FlagNestedFolders))
 
 	orgID := CreateOrg(t, db)
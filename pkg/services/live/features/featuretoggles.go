@@ -0,0 +1,85 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/live/model"
+)
+
+// FeatureTogglesChannel is the channel the browsers are notified on when frontend feature toggles change.
+const FeatureTogglesChannel = "grafana/featuretoggles/changes"
+
+// FeatureToggleHandler manages the `grafana/featuretoggles/changes` channel, so browsers apply the changes of the
+// frontend feature toggles without reloading. The channel only carries the version of the state of the toggles, the
+// browsers fetch the toggles enabled for their user when it is newer than theirs.
+type FeatureToggleHandler struct {
+	publisher model.ChannelPublisher
+	// notification returns the notification of the current version, sent on subscription so clients can reconcile.
+	notification func() any
+
+	mu sync.Mutex
+	// orgs are the organizations with subscribers, as the channels are scoped to organizations.
+	orgs map[int64]struct{}
+}
+
+func NewFeatureToggleHandler(publisher model.ChannelPublisher, notification func() any) *FeatureToggleHandler {
+	return &FeatureToggleHandler{
+		publisher:    publisher,
+		notification: notification,
+		orgs:         make(map[int64]struct{}),
+	}
+}
+
+// GetHandlerForPath called on init
+func (h *FeatureToggleHandler) GetHandlerForPath(_ string) (model.ChannelHandler, error) {
+	return h, nil
+}
+
+// OnSubscribe lets any user subscribe to the changes, and sends them the current version
+func (h *FeatureToggleHandler) OnSubscribe(_ context.Context, u identity.Requester, e model.SubscribeEvent) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	if e.Path != "changes" {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	h.mu.Lock()
+	h.orgs[u.GetOrgID()] = struct{}{}
+	h.mu.Unlock()
+
+	data, err := json.Marshal(h.notification())
+	if err != nil {
+		return model.SubscribeReply{}, 0, err
+	}
+	return model.SubscribeReply{Data: data}, backend.SubscribeStreamStatusOK, nil
+}
+
+// OnPublish does not let clients publish, the changes are only sent by the server
+func (h *FeatureToggleHandler) OnPublish(_ context.Context, _ identity.Requester, _ model.PublishEvent) (model.PublishReply, backend.PublishStreamStatus, error) {
+	return model.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
+}
+
+// PublishChange notifies the subscribers of every organization of the current version of the state of the toggles.
+func (h *FeatureToggleHandler) PublishChange() {
+	data, err := json.Marshal(h.notification())
+	if err != nil {
+		logger.Error("Failed to marshal feature toggle notification", "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	orgs := make([]int64, 0, len(h.orgs))
+	for orgID := range h.orgs {
+		orgs = append(orgs, orgID)
+	}
+	h.mu.Unlock()
+
+	for _, orgID := range orgs {
+		if err := h.publisher(orgID, FeatureTogglesChannel, data); err != nil {
+			logger.Error("Failed to publish feature toggle notification", "orgId", orgID, "error", err)
+		}
+	}
+}
@@ -197,6 +197,15 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	g.GrafanaScope.Dashboards = dash
 	g.GrafanaScope.Features["dashboard"] = dash
 	g.GrafanaScope.Features["broadcast"] = features.NewBroadcastRunner(g.storage)
+	if fm, ok := toggles.(*featuremgmt.FeatureManager); ok {
+		ft := features.NewFeatureToggleHandler(g.Publish, func() any { return fm.GetFrontendToggleNotification() })
+		g.GrafanaScope.Features["featuretoggles"] = ft
+		fm.AddChangeListener(func(e featuremgmt.ToggleChangeEvent) {
+			if fm.HasFrontendChanges(e.Changes) {
+				ft.PublishChange()
+			}
+		})
+	}
 
 	g.surveyCaller = survey.NewCaller(managedStreamRunner, node)
 	err = g.surveyCaller.SetupHandlers()
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/migrationcheck"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
@@ -49,6 +50,9 @@ type API struct {
 	RuleStore            RuleStore
 	AlertingStore        AlertingStore
 	AdminConfigStore     store.AdminConfigurationStore
+	MigrationStore       store.MigrationStore
+	MigrationChecker     *migrationcheck.Checker
+	MigrationEvents      MigrationEventPublisher
 	DataProxy            *datasourceproxy.DataSourceProxyService
 	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
 	StateManager         *state.Manager
@@ -139,6 +143,14 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		logger: logger,
 		hist:   api.Historian,
 	}), m)
+
+	api.RegisterMigrationApiEndpoints(NewMigrationApi(&MigrationSrv{
+		store:               api.MigrationStore,
+		checker:             api.MigrationChecker,
+		contactPointService: api.ContactPointService,
+		events:              api.MigrationEvents,
+		log:                 logger,
+	}), m)
 }
 
 func (api *API) Usage(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
@@ -169,5 +181,17 @@ func (api *API) Usage(ctx context.Context, scopeParams *quota.ScopeParameters) (
 		u.Set(tag, globalUsage)
 	}
 
+	if scopeParams != nil && scopeParams.OrgID != 0 && len(scopeParams.FolderUIDs) > 0 {
+		folderUsage, err := api.RuleStore.CountInFolders(ctx, scopeParams.OrgID, scopeParams.FolderUIDs)
+		if err != nil {
+			return u, err
+		}
+		tag, err := quota.NewTag(models.QuotaTargetSrv, models.QuotaTarget, quota.FolderScope)
+		if err != nil {
+			return u, err
+		}
+		u.Set(tag, folderUsage)
+	}
+
 	return u, nil
 }
@@ -0,0 +1,418 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/log"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/migrationcheck"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// MigrationEventPublisher publishes the lifecycle events of the migration from legacy alerting.
+type MigrationEventPublisher interface {
+	// PublishMigrationEvents publishes the events that the migration recorded, including its failures.
+	PublishMigrationEvents(ctx context.Context)
+}
+
+type MigrationSrv struct {
+	store               store.MigrationStore
+	checker             *migrationcheck.Checker
+	contactPointService ContactPointService
+	events              MigrationEventPublisher
+	log                 log.Logger
+}
+
+// RouteGetMigrationMappings returns the alert rules and contact points that were created for the legacy alerts and
+// notification channels of the organization by the migration from legacy alerting.
+func (srv MigrationSrv) RouteGetMigrationMappings(c *contextmodel.ReqContext) response.Response {
+	legacyType := c.Query("legacyType")
+	switch legacyType {
+	case "", ngmodels.MigrationMappingLegacyAlert, ngmodels.MigrationMappingLegacyChannel:
+	default:
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("unknown legacy type %q", legacyType), "")
+	}
+
+	mappings, err := srv.store.ListMigrationMappings(c.Req.Context(), &ngmodels.ListMigrationMappingsQuery{
+		OrgID:      c.SignedInUser.GetOrgID(),
+		LegacyType: legacyType,
+		LegacyID:   c.QueryInt64("legacyId"),
+		LegacyUID:  c.Query("legacyUid"),
+	})
+	if err != nil {
+		msg := "failed to fetch migration mappings from the database"
+		srv.log.Error(msg, "error", err)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+
+	result := make(apimodels.MigrationMappings, 0, len(mappings))
+	for _, m := range mappings {
+		result = append(result, apimodels.MigrationMapping{
+			LegacyType: m.LegacyType,
+			LegacyID:   m.LegacyID,
+			LegacyUID:  m.LegacyUID,
+			UID:        m.UID,
+			Name:       m.Name,
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteGetMigrationComparison evaluates the migrated legacy alerts of the organization and the alert rules migrated
+// from them over the same recent window, and returns the evaluations where their states differ.
+func (srv MigrationSrv) RouteGetMigrationComparison(c *contextmodel.ReqContext) response.Response {
+	window, err := parseDurationQuery(c, "window", time.Hour)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	step, err := parseDurationQuery(c, "step", 5*time.Minute)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	report, err := srv.checker.Compare(c.Req.Context(), c.SignedInUser, c.QueryInt64("legacyId"), time.Now(), window, step)
+	if err != nil {
+		if errors.Is(err, migrationcheck.ErrInvalidInputData) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		if errors.Is(err, migrationcheck.ErrLegacyAlertingAbsent) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		msg := "failed to compare legacy alerts with migrated alert rules"
+		srv.log.Error(msg, "error", err)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+
+	result := apimodels.MigrationComparison{
+		From:        report.From,
+		To:          report.To,
+		Compared:    report.Compared,
+		Evaluations: report.Evaluations,
+		Mismatches:  make([]apimodels.MigrationMismatch, 0, len(report.Mismatches)),
+		Errors:      report.Errors,
+	}
+	for _, m := range report.Mismatches {
+		result.Mismatches = append(result.Mismatches, apimodels.MigrationMismatch{
+			LegacyID: m.LegacyID,
+			RuleUID:  m.RuleUID,
+			Title:    m.Title,
+			Time:     m.Time,
+			Legacy:   string(m.Legacy),
+			Unified:  string(m.Unified),
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// parseDurationQuery parses the query parameter as a duration, or returns the default if it is absent.
+func parseDurationQuery(c *contextmodel.ReqContext, name string, def time.Duration) (time.Duration, error) {
+	value := c.Query(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := gtime.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return d, nil
+}
+
+// RouteGetMigrationOrgStatus returns whether the organization is migrated from legacy alerting, when and by which
+// Grafana version, the number of resources the migration created and the problems it recorded.
+func (srv MigrationSrv) RouteGetMigrationOrgStatus(c *contextmodel.ReqContext, orgID int64) response.Response {
+	status, err := srv.store.GetMigrationOrgStatus(c.Req.Context(), orgID)
+	if err != nil {
+		msg := "failed to fetch migration state from the database"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+
+	result := apimodels.MigrationOrgStatus{
+		OrgID:          orgID,
+		Migrated:       status.Migrated,
+		Shadow:         status.Shadow,
+		GrafanaVersion: status.GrafanaVersion,
+		Created:        status.Created,
+		Errors:         []string{},
+	}
+	if !status.Updated.IsZero() {
+		updated := status.Updated
+		result.Updated = &updated
+	}
+	if status.Errors != "" {
+		if err := json.Unmarshal([]byte(status.Errors), &result.Errors); err != nil {
+			srv.log.Warn("Failed to parse recorded migration errors", "error", err, "org", orgID)
+		}
+	}
+	if status.ContactPointTests != "" {
+		var tests []ngmodels.MigrationContactPointTest
+		if err := json.Unmarshal([]byte(status.ContactPointTests), &tests); err != nil {
+			srv.log.Warn("Failed to parse recorded contact point tests", "error", err, "org", orgID)
+		}
+		result.ContactPointTests = make([]apimodels.MigrationContactPointTest, 0, len(tests))
+		for _, t := range tests {
+			result.ContactPointTests = append(result.ContactPointTests, apimodels.MigrationContactPointTest(t))
+		}
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteGetMigratedResources returns the resources that the migration created for the organization and that were not
+// deleted since, grouped by type, so that it can be inspected what reverting the migration deletes.
+func (srv MigrationSrv) RouteGetMigratedResources(c *contextmodel.ReqContext, orgID int64) response.Response {
+	resources, err := srv.store.ListMigratedResources(c.Req.Context(), orgID)
+	if err != nil {
+		msg := "failed to fetch migrated resources from the database"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+
+	result := apimodels.MigratedResources{
+		OrgID:         orgID,
+		AlertRules:    []apimodels.MigratedResource{},
+		Folders:       []apimodels.MigratedResource{},
+		ContactPoints: []apimodels.MigratedResource{},
+		Silences:      []apimodels.MigratedResource{},
+	}
+	for _, r := range resources {
+		resource := apimodels.MigratedResource{
+			UID:       r.ResourceUID,
+			Name:      r.Name,
+			LegacyID:  r.LegacyID,
+			LegacyUID: r.LegacyUID,
+			RunID:     r.RunID,
+			Created:   r.Created,
+		}
+		switch r.ResourceType {
+		case ngmodels.MigrationAuditResourceAlertRule:
+			result.AlertRules = append(result.AlertRules, resource)
+		case ngmodels.MigrationAuditResourceFolder:
+			result.Folders = append(result.Folders, resource)
+		case ngmodels.MigrationAuditResourceReceiver:
+			result.ContactPoints = append(result.ContactPoints, resource)
+		case ngmodels.MigrationAuditResourceSilence:
+			result.Silences = append(result.Silences, resource)
+		}
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RoutePostMigrateOrg removes the unified alerting data of the organization and migrates its legacy alerts and
+// notification channels again.
+func (srv MigrationSrv) RoutePostMigrateOrg(c *contextmodel.ReqContext, orgID int64) response.Response {
+	if err := srv.store.MigrateOrg(c.Req.Context(), orgID); err != nil {
+		if errors.Is(err, store.ErrOrgMigrationInProgress) {
+			return ErrResp(http.StatusConflict, err, "")
+		}
+		msg := "failed to migrate organization"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		srv.events.PublishMigrationEvents(c.Req.Context())
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+	srv.events.PublishMigrationEvents(c.Req.Context())
+	srv.log.Info("Migrated organization to unified alerting", "org", orgID, "user", c.SignedInUser.GetLogin())
+	return response.JSON(http.StatusOK, util.DynMap{"message": "organization migrated"})
+}
+
+// RoutePostActivateOrgMigration resumes the alert rules of an organization that was migrated in shadow mode.
+func (srv MigrationSrv) RoutePostActivateOrgMigration(c *contextmodel.ReqContext, orgID int64) response.Response {
+	resumed, err := srv.store.ActivateOrgMigration(c.Req.Context(), orgID)
+	if err != nil {
+		if errors.Is(err, store.ErrMigrationNotInShadowMode) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		msg := "failed to activate migration of organization"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+	srv.log.Info("Activated migration of organization to unified alerting", "org", orgID, "rules", resumed, "user", c.SignedInUser.GetLogin())
+	return response.JSON(http.StatusOK, util.DynMap{"message": "organization migration activated", "resumed": resumed})
+}
+
+// RoutePostResumeMigratedRules resumes the alert rules of an organization that the migration created paused.
+func (srv MigrationSrv) RoutePostResumeMigratedRules(c *contextmodel.ReqContext, orgID int64) response.Response {
+	resumed, err := srv.store.ResumeMigratedRules(c.Req.Context(), orgID)
+	if err != nil {
+		msg := "failed to resume migrated alert rules of organization"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+	srv.log.Info("Resumed migrated alert rules of organization", "org", orgID, "rules", resumed, "user", c.SignedInUser.GetLogin())
+	return response.JSON(http.StatusOK, util.DynMap{"message": "migrated alert rules resumed", "resumed": resumed})
+}
+
+// RouteDeleteMigrateOrg removes the unified alerting data that the migration created for the organization. With the
+// partial query parameter it keeps the resources created or edited by users since the migration.
+func (srv MigrationSrv) RouteDeleteMigrateOrg(c *contextmodel.ReqContext, orgID int64) response.Response {
+	partial := c.QueryBool("partial")
+	revert := srv.store.RevertOrgMigration
+	if partial {
+		revert = srv.store.PartiallyRevertOrgMigration
+	}
+	if err := revert(c.Req.Context(), orgID); err != nil {
+		if errors.Is(err, store.ErrOrgMigrationInProgress) {
+			return ErrResp(http.StatusConflict, err, "")
+		}
+		msg := "failed to revert migration of organization"
+		srv.log.Error(msg, "error", err, "org", orgID, "partial", partial)
+		srv.events.PublishMigrationEvents(c.Req.Context())
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+	srv.events.PublishMigrationEvents(c.Req.Context())
+	srv.log.Info("Reverted migration of organization to unified alerting", "org", orgID, "partial", partial, "user", c.SignedInUser.GetLogin())
+	return response.JSON(http.StatusOK, util.DynMap{"message": "organization migration reverted"})
+}
+
+// RouteGetMigratedContactPointsExport exports the contact points that the migration created for the organization in
+// provisioning file format. Their secure settings are replaced by placeholders of environment variables, which file
+// provisioning expands, so that secrets are never exported in plain text. With the env format it returns the template
+// of the environment file that sets the placeholders instead.
+func (srv MigrationSrv) RouteGetMigratedContactPointsExport(c *contextmodel.ReqContext, orgID int64) response.Response {
+	envFile := c.Query("format") == "env"
+	if !envFile && extractExportRequest(c).Format == "hcl" {
+		return ErrResp(http.StatusBadRequest, errors.New("contact points cannot be exported in the hcl format"), "")
+	}
+
+	mappings, err := srv.store.ListMigrationMappings(c.Req.Context(), &ngmodels.ListMigrationMappingsQuery{
+		OrgID:      orgID,
+		LegacyType: ngmodels.MigrationMappingLegacyChannel,
+	})
+	if err != nil {
+		msg := "failed to fetch migration mappings from the database"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+	migrated := make(map[string]struct{}, len(mappings))
+	for _, m := range mappings {
+		migrated[m.UID] = struct{}{}
+	}
+
+	cps, err := srv.contactPointService.GetContactPoints(c.Req.Context(), provisioning.ContactPointQuery{OrgID: orgID}, c.SignedInUser)
+	if err != nil {
+		msg := "failed to fetch contact points"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+	exported := make([]apimodels.EmbeddedContactPoint, 0, len(migrated))
+	for _, cp := range cps {
+		if _, ok := migrated[cp.UID]; ok {
+			exported = append(exported, cp)
+		}
+	}
+	placeholders := replaceSecureSettings(exported)
+
+	if envFile {
+		r := response.Respond(http.StatusOK, secretsEnvFileTemplate(placeholders)).SetHeader("Content-Type", "text/plain")
+		if c.QueryBoolWithDefault("download", false) {
+			r.SetHeader("Content-Disposition", `attachment;filename="export.env"`)
+		}
+		return r
+	}
+
+	e, err := AlertingFileExportFromEmbeddedContactPoints(orgID, exported)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to create alerting file export")
+	}
+	return exportResponse(c, e)
+}
+
+// RouteGetMigrationPolicyPreview returns the notification policy tree that the migration would create for the
+// organization from its current legacy alerts and notification channels, so that it can be reviewed before the
+// organization is migrated, or compared with its current notification policies after.
+func (srv MigrationSrv) RouteGetMigrationPolicyPreview(c *contextmodel.ReqContext, orgID int64) response.Response {
+	config, err := srv.store.PreviewOrgNotificationPolicies(c.Req.Context(), orgID)
+	if err != nil {
+		msg := "failed to preview notification policies of organization"
+		srv.log.Error(msg, "error", err, "org", orgID)
+		return ErrResp(http.StatusInternalServerError, err, msg)
+	}
+
+	result := apimodels.MigrationPolicyPreview{OrgID: orgID, Receivers: make([]string, 0, len(config.AlertmanagerConfig.Receivers))}
+	for _, r := range config.AlertmanagerConfig.Receivers {
+		result.Receivers = append(result.Receivers, r.Name)
+	}
+	if config.AlertmanagerConfig.Route != nil {
+		// The routes of the migration have the same JSON representation as those of the API.
+		b, err := json.Marshal(config.AlertmanagerConfig.Route)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to encode notification policies")
+		}
+		result.Route = &apimodels.Route{}
+		if err := json.Unmarshal(b, result.Route); err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to encode notification policies")
+		}
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// secretPlaceholder is the environment variable that replaces a secure setting of an exported contact point.
+type secretPlaceholder struct {
+	Name         string
+	ContactPoint string
+	Type         string
+	Setting      string
+}
+
+var invalidEnvVarChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// replaceSecureSettings replaces the redacted secure settings of the contact points with placeholders of environment
+// variables named after the type of the integration, the setting and the contact point, such as
+// $SLACK_TOKEN_TEAM_A, and returns the placeholders in the order of the contact points.
+func replaceSecureSettings(cps []apimodels.EmbeddedContactPoint) []secretPlaceholder {
+	var placeholders []secretPlaceholder
+	taken := make(map[string]struct{})
+	for _, cp := range cps {
+		settings, err := cp.Settings.Map()
+		if err != nil {
+			continue
+		}
+		keys := make([]string, 0, len(settings))
+		for k, v := range settings {
+			if v == apimodels.RedactedValue {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			base := strings.Trim(invalidEnvVarChars.ReplaceAllString(strings.ToUpper(strings.Join([]string{cp.Type, k, cp.Name}, "_")), "_"), "_")
+			name := base
+			for i := 2; ; i++ {
+				if _, ok := taken[name]; !ok {
+					break
+				}
+				name = fmt.Sprintf("%s_%d", base, i)
+			}
+			taken[name] = struct{}{}
+			cp.Settings.Set(k, "$"+name)
+			placeholders = append(placeholders, secretPlaceholder{Name: name, ContactPoint: cp.Name, Type: cp.Type, Setting: k})
+		}
+	}
+	return placeholders
+}
+
+// secretsEnvFileTemplate returns an environment file that sets every placeholder to an empty value, to be filled in
+// with the secure settings of the contact points before provisioning them.
+func secretsEnvFileTemplate(placeholders []secretPlaceholder) string {
+	var b strings.Builder
+	b.WriteString("# Secure settings of the contact points migrated from legacy alerting.\n")
+	for _, p := range placeholders {
+		fmt.Fprintf(&b, "\n# %s of the %s integration of contact point %q\n%s=\n", p.Setting, p.Type, p.ContactPoint, p.Name)
+	}
+	return b.String()
+}
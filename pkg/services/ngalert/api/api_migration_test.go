@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+func TestReplaceSecureSettings(t *testing.T) {
+	cps := []definitions.EmbeddedContactPoint{
+		{
+			UID:  "uid1",
+			Name: "team-a",
+			Type: "slack",
+			Settings: simplejson.NewFromAny(map[string]any{
+				"recipient": "#alerts",
+				"token":     definitions.RedactedValue,
+				"url":       definitions.RedactedValue,
+			}),
+		},
+		{
+			UID:      "uid2",
+			Name:     "Team A",
+			Type:     "slack",
+			Settings: simplejson.NewFromAny(map[string]any{"token": definitions.RedactedValue}),
+		},
+		{
+			UID:      "uid3",
+			Name:     "ops",
+			Type:     "email",
+			Settings: simplejson.NewFromAny(map[string]any{"addresses": "ops@example.com"}),
+		},
+	}
+
+	placeholders := replaceSecureSettings(cps)
+
+	require.Equal(t, []secretPlaceholder{
+		{Name: "SLACK_TOKEN_TEAM_A", ContactPoint: "team-a", Type: "slack", Setting: "token"},
+		{Name: "SLACK_URL_TEAM_A", ContactPoint: "team-a", Type: "slack", Setting: "url"},
+		{Name: "SLACK_TOKEN_TEAM_A_2", ContactPoint: "Team A", Type: "slack", Setting: "token"},
+	}, placeholders)
+	require.Equal(t, "$SLACK_TOKEN_TEAM_A", cps[0].Settings.Get("token").MustString())
+	require.Equal(t, "$SLACK_URL_TEAM_A", cps[0].Settings.Get("url").MustString())
+	require.Equal(t, "#alerts", cps[0].Settings.Get("recipient").MustString())
+	require.Equal(t, "$SLACK_TOKEN_TEAM_A_2", cps[1].Settings.Get("token").MustString())
+	require.Equal(t, "ops@example.com", cps[2].Settings.Get("addresses").MustString())
+
+	env := secretsEnvFileTemplate(placeholders)
+	require.Contains(t, env, "\n# token of the slack integration of contact point \"team-a\"\nSLACK_TOKEN_TEAM_A=\n")
+	require.Contains(t, env, "\nSLACK_TOKEN_TEAM_A_2=\n")
+	require.NotContains(t, env, definitions.RedactedValue)
+}
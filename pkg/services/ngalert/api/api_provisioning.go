@@ -15,6 +15,7 @@ import (
 	alerting_models "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -336,6 +337,9 @@ func (srv *ProvisioningSrv) RoutePostAlertRule(c *contextmodel.ReqContext, ar de
 		if errors.Is(err, alerting_models.ErrQuotaReached) {
 			return ErrResp(http.StatusForbidden, err, "")
 		}
+		if errors.Is(err, quota.ErrFolderQuotaReached) {
+			return response.Err(err)
+		}
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
 
@@ -480,6 +484,9 @@ func (srv *ProvisioningSrv) RoutePutAlertRuleGroup(c *contextmodel.ReqContext, a
 		if errors.Is(err, store.ErrOptimisticLock) {
 			return ErrResp(http.StatusConflict, err, "")
 		}
+		if errors.Is(err, quota.ErrFolderQuotaReached) {
+			return response.Err(err)
+		}
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
 	return response.JSON(http.StatusOK, ag)
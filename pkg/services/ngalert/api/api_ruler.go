@@ -12,6 +12,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/apierrors"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
@@ -146,7 +147,7 @@ func (srv RulerSrv) RouteGetNamespaceRulesConfig(c *contextmodel.ReqContext, nam
 		return toNamespaceErrorResponse(err)
 	}
 
-	ruleGroups, _, err := srv.searchAuthorizedAlertRules(c.Req.Context(), c, []string{namespace.UID}, "", 0)
+	ruleGroups, _, err := srv.searchAuthorizedAlertRules(db.WithReadReplica(c.Req.Context()), c, []string{namespace.UID}, "", 0)
 	if err != nil {
 		return errorToResponse(err)
 	}
@@ -219,7 +220,7 @@ func (srv RulerSrv) RouteGetRulesConfig(c *contextmodel.ReqContext) response.Res
 		return ErrResp(http.StatusBadRequest, errors.New("panel_id must be set with dashboard_uid"), "")
 	}
 
-	configs, _, err := srv.searchAuthorizedAlertRules(c.Req.Context(), c, namespaceUIDs, dashboardUID, panelID)
+	configs, _, err := srv.searchAuthorizedAlertRules(db.WithReadReplica(c.Req.Context()), c, namespaceUIDs, dashboardUID, panelID)
 	if err != nil {
 		return errorToResponse(err)
 	}
@@ -323,6 +324,17 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 			}
 		}
 
+		// The rules moved to the folder by the updates above are already part of its usage.
+		if len(finalChanges.New) > 0 || finalChanges.MovedToNamespace() > 0 {
+			if err := srv.QuotaService.CheckFolderQuotaReached(tranCtx, ngmodels.QuotaTargetSrv, &quota.ScopeParameters{
+				OrgID:     c.SignedInUser.GetOrgID(),
+				FolderUID: groupKey.NamespaceUID,
+				Count:     int64(len(finalChanges.New)),
+			}); err != nil {
+				return err
+			}
+		}
+
 		if len(finalChanges.New) > 0 {
 			inserts := make([]ngmodels.AlertRule, 0, len(finalChanges.New))
 			for _, rule := range finalChanges.New {
@@ -364,6 +376,8 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 			return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
 		} else if errors.Is(err, ngmodels.ErrQuotaReached) {
 			return ErrResp(http.StatusForbidden, err, "")
+		} else if errors.Is(err, quota.ErrFolderQuotaReached) {
+			return response.Err(err)
 		} else if errors.Is(err, ErrAuthorization) {
 			return ErrResp(http.StatusUnauthorized, err, "")
 		} else if errors.Is(err, store.ErrOptimisticLock) {
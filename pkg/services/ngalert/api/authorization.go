@@ -185,9 +185,22 @@ func (api *API) authorize(method, path string) web.Handler {
 	case http.MethodDelete + "/api/v1/ngalert/admin_config",
 		http.MethodGet + "/api/v1/ngalert/admin_config",
 		http.MethodPost + "/api/v1/ngalert/admin_config",
-		http.MethodGet + "/api/v1/ngalert/alertmanagers":
+		http.MethodGet + "/api/v1/ngalert/alertmanagers",
+		http.MethodGet + "/api/v1/ngalert/migration/mappings",
+		http.MethodGet + "/api/v1/ngalert/migration/compare":
 		return middleware.ReqOrgAdmin
 
+	// Migration of any organization
+	case http.MethodGet + "/api/v1/upgrade/org/{OrgID}",
+		http.MethodGet + "/api/v1/upgrade/org/{OrgID}/resources",
+		http.MethodGet + "/api/v1/upgrade/org/{OrgID}/contact-points/export",
+		http.MethodGet + "/api/v1/upgrade/org/{OrgID}/notification-policies/preview",
+		http.MethodPost + "/api/v1/upgrade/org/{OrgID}",
+		http.MethodDelete + "/api/v1/upgrade/org/{OrgID}",
+		http.MethodPost + "/api/v1/upgrade/org/{OrgID}/activate",
+		http.MethodPost + "/api/v1/upgrade/org/{OrgID}/resume":
+		return middleware.ReqGrafanaAdmin
+
 	// Grafana-only Provisioning Read Paths
 	case http.MethodGet + "/api/v1/provisioning/policies/export",
 		http.MethodGet + "/api/v1/provisioning/contact-points/export":
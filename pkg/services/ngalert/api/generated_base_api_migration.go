@@ -0,0 +1,204 @@
+/*Package api contains base API implementation of unified alerting
+ *
+ *Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
+ *
+ *Do not manually edit these files, please find ngalert/api/swagger-codegen/ for commands on how to generate them.
+ */
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/middleware/requestmeta"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type MigrationApi interface {
+	RouteDeleteMigrateOrg(*contextmodel.ReqContext) response.Response
+	RouteGetMigratedContactPointsExport(*contextmodel.ReqContext) response.Response
+	RouteGetMigratedResources(*contextmodel.ReqContext) response.Response
+	RouteGetMigrationComparison(*contextmodel.ReqContext) response.Response
+	RouteGetMigrationMappings(*contextmodel.ReqContext) response.Response
+	RouteGetMigrationOrgStatus(*contextmodel.ReqContext) response.Response
+	RouteGetMigrationPolicyPreview(*contextmodel.ReqContext) response.Response
+	RoutePostActivateOrgMigration(*contextmodel.ReqContext) response.Response
+	RoutePostMigrateOrg(*contextmodel.ReqContext) response.Response
+	RoutePostResumeMigratedRules(*contextmodel.ReqContext) response.Response
+}
+
+func (f *MigrationApiHandler) RouteDeleteMigrateOrg(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRouteDeleteMigrateOrg(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RouteGetMigratedContactPointsExport(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRouteGetMigratedContactPointsExport(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RouteGetMigratedResources(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRouteGetMigratedResources(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RouteGetMigrationComparison(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetMigrationComparison(ctx)
+}
+func (f *MigrationApiHandler) RouteGetMigrationMappings(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetMigrationMappings(ctx)
+}
+func (f *MigrationApiHandler) RouteGetMigrationOrgStatus(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRouteGetMigrationOrgStatus(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RouteGetMigrationPolicyPreview(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRouteGetMigrationPolicyPreview(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RoutePostActivateOrgMigration(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRoutePostActivateOrgMigration(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RoutePostMigrateOrg(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRoutePostMigrateOrg(ctx, orgIDParam)
+}
+func (f *MigrationApiHandler) RoutePostResumeMigratedRules(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	orgIDParam := web.Params(ctx.Req)[":OrgID"]
+	return f.handleRoutePostResumeMigratedRules(ctx, orgIDParam)
+}
+
+func (api *API) RegisterMigrationApiEndpoints(srv MigrationApi, m *metrics.API) {
+	api.RouteRegister.Group("", func(group routing.RouteRegister) {
+		group.Delete(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodDelete, "/api/v1/upgrade/org/{OrgID}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/upgrade/org/{OrgID}",
+				api.Hooks.Wrap(srv.RouteDeleteMigrateOrg),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}/contact-points/export"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/upgrade/org/{OrgID}/contact-points/export"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/upgrade/org/{OrgID}/contact-points/export",
+				api.Hooks.Wrap(srv.RouteGetMigratedContactPointsExport),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}/resources"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/upgrade/org/{OrgID}/resources"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/upgrade/org/{OrgID}/resources",
+				api.Hooks.Wrap(srv.RouteGetMigratedResources),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/migration/compare"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/ngalert/migration/compare"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/migration/compare",
+				api.Hooks.Wrap(srv.RouteGetMigrationComparison),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/migration/mappings"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/ngalert/migration/mappings"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/migration/mappings",
+				api.Hooks.Wrap(srv.RouteGetMigrationMappings),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/upgrade/org/{OrgID}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/upgrade/org/{OrgID}",
+				api.Hooks.Wrap(srv.RouteGetMigrationOrgStatus),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}/notification-policies/preview"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/upgrade/org/{OrgID}/notification-policies/preview"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/upgrade/org/{OrgID}/notification-policies/preview",
+				api.Hooks.Wrap(srv.RouteGetMigrationPolicyPreview),
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}/activate"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/upgrade/org/{OrgID}/activate"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/upgrade/org/{OrgID}/activate",
+				api.Hooks.Wrap(srv.RoutePostActivateOrgMigration),
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/upgrade/org/{OrgID}"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/upgrade/org/{OrgID}",
+				api.Hooks.Wrap(srv.RoutePostMigrateOrg),
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/upgrade/org/{OrgID}/resume"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/upgrade/org/{OrgID}/resume"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/upgrade/org/{OrgID}/resume",
+				api.Hooks.Wrap(srv.RoutePostResumeMigratedRules),
+				m,
+			),
+		)
+	}, middleware.ReqSignedIn)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+type MigrationApiHandler struct {
+	svc *MigrationSrv
+}
+
+func NewMigrationApi(svc *MigrationSrv) *MigrationApiHandler {
+	return &MigrationApiHandler{
+		svc: svc,
+	}
+}
+
+func (f *MigrationApiHandler) handleRouteGetMigrationMappings(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetMigrationMappings(ctx)
+}
+
+func (f *MigrationApiHandler) handleRouteGetMigrationComparison(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetMigrationComparison(ctx)
+}
+
+func (f *MigrationApiHandler) handleRouteGetMigrationOrgStatus(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RouteGetMigrationOrgStatus(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRouteGetMigratedResources(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RouteGetMigratedResources(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRouteGetMigratedContactPointsExport(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RouteGetMigratedContactPointsExport(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRouteGetMigrationPolicyPreview(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RouteGetMigrationPolicyPreview(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRoutePostMigrateOrg(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RoutePostMigrateOrg(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRoutePostActivateOrgMigration(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RoutePostActivateOrgMigration(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRoutePostResumeMigratedRules(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RoutePostResumeMigratedRules(ctx, id)
+}
+
+func (f *MigrationApiHandler) handleRouteDeleteMigrateOrg(ctx *contextmodel.ReqContext, orgID string) response.Response {
+	id, err := strconv.ParseInt(orgID, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse organization ID")
+	}
+	return f.svc.RouteDeleteMigrateOrg(ctx, id)
+}
@@ -26,4 +26,5 @@ type RuleStore interface {
 	IncreaseVersionForAllRulesInNamespace(ctx context.Context, orgID int64, namespaceUID string) ([]ngmodels.AlertRuleKeyWithVersionAndPauseStatus, error)
 
 	Count(ctx context.Context, orgID int64) (int64, error)
+	CountInFolders(ctx context.Context, orgID int64, folderUIDs []string) (int64, error)
 }
@@ -74,6 +74,10 @@ const (
 	HandleGrafanaManagedAlerts                     = "handleGrafanaManagedAlerts"
 )
 
+// GrafanaManagedAlertsMatchers is the key of the matchers of the alerts that an Alertmanager data source receives in
+// its JSON data. The data source receives all the alerts without it.
+const GrafanaManagedAlertsMatchers = "grafanaManagedAlertsMatchers"
+
 // swagger:model
 type PostableNGalertConfig struct {
 	AlertmanagersChoice AlertmanagersChoice `json:"alertmanagersChoice"`
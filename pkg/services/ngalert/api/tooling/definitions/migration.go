@@ -0,0 +1,266 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /api/v1/ngalert/migration/mappings migration RouteGetMigrationMappings
+//
+// Get the alert rules and contact points that the migration from legacy alerting created for the legacy alerts and notification channels of the user's organization.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: MigrationMappings
+//       400: ValidationError
+
+// swagger:parameters RouteGetMigrationMappings
+type MigrationMappingsParams struct {
+	// Type of the legacy resource, either alert or channel.
+	// in: query
+	LegacyType string `json:"legacyType"`
+	// ID of the legacy alert or notification channel.
+	// in: query
+	LegacyID int64 `json:"legacyId"`
+	// UID of the legacy notification channel.
+	// in: query
+	LegacyUID string `json:"legacyUid"`
+}
+
+// swagger:model
+type MigrationMappings []MigrationMapping
+
+// swagger:model
+type MigrationMapping struct {
+	// Type of the legacy resource, either alert or channel.
+	LegacyType string `json:"legacyType"`
+	LegacyID   int64  `json:"legacyId"`
+	LegacyUID  string `json:"legacyUid,omitempty"`
+	// UID of the alert rule, or of the contact point integration.
+	UID string `json:"uid"`
+	// Title of the alert rule, or name of the contact point.
+	Name string `json:"name"`
+}
+
+// swagger:route GET /api/v1/ngalert/migration/compare migration RouteGetMigrationComparison
+//
+// Evaluate the migrated legacy alerts of the user's organization and the alert rules migrated from them over the same recent window, and report when their states differ.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: MigrationComparison
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:parameters RouteGetMigrationComparison
+type MigrationComparisonParams struct {
+	// ID of the legacy alert to compare, all migrated legacy alerts are compared if omitted.
+	// in: query
+	LegacyID int64 `json:"legacyId"`
+	// Duration of the window that ends now, for example 1h.
+	// in: query
+	// default: 1h
+	Window string `json:"window"`
+	// Duration between two evaluations in the window, for example 5m.
+	// in: query
+	// default: 5m
+	Step string `json:"step"`
+}
+
+// swagger:model
+type MigrationComparison struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Number of pairs of legacy alert and alert rule that were compared.
+	Compared int `json:"compared"`
+	// Number of evaluations of each of the legacy alerts and alert rules.
+	Evaluations int                 `json:"evaluations"`
+	Mismatches  []MigrationMismatch `json:"mismatches"`
+	// Pairs of legacy alert and alert rule that could not be compared.
+	Errors []string `json:"errors"`
+}
+
+// swagger:model
+type MigrationMismatch struct {
+	LegacyID int64     `json:"legacyId"`
+	RuleUID  string    `json:"ruleUid"`
+	Title    string    `json:"title"`
+	Time     time.Time `json:"time"`
+	// State of the legacy alert, one of Normal, Alerting, NoData or Error.
+	Legacy string `json:"legacy"`
+	// State of the alert rule before its no data and error handling, one of Normal, Alerting, NoData or Error.
+	Unified string `json:"unified"`
+}
+
+// swagger:route GET /api/v1/upgrade/org/{OrgID} migration RouteGetMigrationOrgStatus
+//
+// Get the state of the migration from legacy alerting of an organization.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: MigrationOrgStatus
+//       400: ValidationError
+
+// swagger:route POST /api/v1/upgrade/org/{OrgID} migration RoutePostMigrateOrg
+//
+// Migrate the legacy alerts and notification channels of an organization to unified alerting, replacing the data of a previous migration.
+//
+//     Responses:
+//       200: Ack
+//       400: ValidationError
+//       409: ValidationError
+
+// swagger:route DELETE /api/v1/upgrade/org/{OrgID} migration RouteDeleteMigrateOrg
+//
+// Remove the unified alerting data that the migration from legacy alerting created for an organization, or with partial only the resources created by the migration that were not edited since.
+//
+//     Responses:
+//       200: Ack
+//       400: ValidationError
+//       409: ValidationError
+
+// swagger:route POST /api/v1/upgrade/org/{OrgID}/activate migration RoutePostActivateOrgMigration
+//
+// Resume the alert rules of an organization that was migrated in shadow mode, except those of paused legacy alerts.
+//
+//     Responses:
+//       200: Ack
+//       400: ValidationError
+
+// swagger:route POST /api/v1/upgrade/org/{OrgID}/resume migration RoutePostResumeMigratedRules
+//
+// Resume the alert rules of an organization that the migration created paused although their legacy alert was not paused.
+//
+//     Responses:
+//       200: Ack
+//       400: ValidationError
+
+// swagger:route GET /api/v1/upgrade/org/{OrgID}/resources migration RouteGetMigratedResources
+//
+// Get the resources that the migration from legacy alerting created for an organization and that were not deleted since, which are deleted when the migration is reverted.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: MigratedResources
+//       400: ValidationError
+
+// swagger:route GET /api/v1/upgrade/org/{OrgID}/contact-points/export migration RouteGetMigratedContactPointsExport
+//
+// Export the contact points that the migration from legacy alerting created for an organization in provisioning file format, with their secure settings replaced by environment variable placeholders, or the template of the environment file that sets them.
+//
+//     Produces:
+//     - application/json
+//     - text/yaml
+//     - text/plain
+//
+//     Responses:
+//       200: AlertingFileExport
+//       400: ValidationError
+
+// swagger:route GET /api/v1/upgrade/org/{OrgID}/notification-policies/preview migration RouteGetMigrationPolicyPreview
+//
+// Get the notification policy tree and contact points that the migration from legacy alerting would create for an organization from its current legacy alerts and notification channels, without writing them.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: MigrationPolicyPreview
+//       400: ValidationError
+
+// swagger:parameters RouteGetMigratedContactPointsExport
+type MigratedContactPointsExportParams struct {
+	// Whether to initiate a download of the file or not.
+	// in: query
+	// required: false
+	// default: false
+	Download bool `json:"download"`
+	// Format of the file, either yaml or json for the provisioning file, or env for the template of the environment file that sets the placeholders of the secure settings.
+	// in: query
+	// required: false
+	// default: yaml
+	Format string `json:"format"`
+}
+
+// swagger:parameters RouteGetMigrationOrgStatus RoutePostMigrateOrg RouteDeleteMigrateOrg RoutePostActivateOrgMigration RoutePostResumeMigratedRules RouteGetMigratedResources RouteGetMigratedContactPointsExport RouteGetMigrationPolicyPreview
+type MigrationOrgStatusParams struct {
+	// in: path
+	OrgID int64
+}
+
+// swagger:parameters RouteDeleteMigrateOrg
+type RevertOrgMigrationParams struct {
+	// Keep the alert rules and contact points created or edited by users since the migration, and the folders that still contain them
+	// in: query
+	// required: false
+	Partial bool `json:"partial"`
+}
+
+// swagger:model
+type MigrationOrgStatus struct {
+	OrgID int64 `json:"orgId"`
+	// Whether the legacy alerts of the organization are migrated to unified alerting.
+	Migrated bool `json:"migrated"`
+	// Whether the organization was migrated in shadow mode, with all its alert rules paused, and not activated yet.
+	Shadow bool `json:"shadow"`
+	// When the organization was last migrated or reverted, omitted if it never was.
+	Updated *time.Time `json:"updated,omitempty"`
+	// Version of Grafana that last migrated or reverted the organization.
+	GrafanaVersion string `json:"grafanaVersion,omitempty"`
+	// Number of resources created by the migration and not deleted since, per resource type.
+	Created map[string]int64 `json:"created"`
+	// Problems recorded for the organization that did not fail the migration.
+	Errors []string `json:"errors"`
+	// Results of the test notifications sent through the migrated contact points, omitted if they were not tested.
+	ContactPointTests []MigrationContactPointTest `json:"contactPointTests,omitempty"`
+}
+
+// swagger:model
+type MigrationContactPointTest struct {
+	// Name of the contact point.
+	Receiver string `json:"receiver"`
+	// Name and UID of the integration of the contact point.
+	Integration string `json:"integration"`
+	UID         string `json:"uid"`
+	// Status of the test notification, either ok or failed.
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Tested time.Time `json:"tested"`
+}
+
+// swagger:model
+type MigrationPolicyPreview struct {
+	OrgID int64 `json:"orgId"`
+	// Root of the notification policy tree, omitted if the organization has no notification channel to migrate.
+	Route *Route `json:"route,omitempty"`
+	// Names of the contact points that the notification policies route to.
+	Receivers []string `json:"receivers"`
+}
+
+// swagger:model
+type MigratedResources struct {
+	OrgID      int64              `json:"orgId"`
+	AlertRules []MigratedResource `json:"alertRules"`
+	Folders    []MigratedResource `json:"folders"`
+	// Integrations of the contact points, named after their contact point.
+	ContactPoints []MigratedResource `json:"contactPoints"`
+	Silences      []MigratedResource `json:"silences"`
+}
+
+// swagger:model
+type MigratedResource struct {
+	UID string `json:"uid"`
+	// Title of the alert rule or folder, or name of the contact point. Omitted for silences and for resources that no longer exist.
+	Name string `json:"name,omitempty"`
+	// ID of the legacy alert, dashboard or notification channel the resource was created for.
+	LegacyID  int64  `json:"legacyId,omitempty"`
+	LegacyUID string `json:"legacyUid,omitempty"`
+	// ID of the run of the migration that created the resource.
+	RunID   string    `json:"runId,omitempty"`
+	Created time.Time `json:"created"`
+}
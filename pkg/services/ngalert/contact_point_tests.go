@@ -0,0 +1,114 @@
+package ngalert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// contactPointTestsTimeout bounds the test notifications sent through the migrated contact points of an organization.
+const contactPointTestsTimeout = 30 * time.Second
+
+// testMigratedContactPoints sends a test notification through the migrated contact points of the organizations for
+// which the migration from legacy alerting requested it with the test_contact_points setting, and records the results
+// in their migration state. The request of an organization is kept if its contact points cannot be tested, so that
+// they are tested on the next start.
+func (ng *AlertNG) testMigratedContactPoints(ctx context.Context) {
+	requests, err := ng.KVStore.GetAll(ctx, kvstore.AllOrganizations, ualert.TestContactPointsKVNamespace)
+	if err != nil {
+		ng.Log.Error("Failed to check for contact point test requests", "error", err)
+		return
+	}
+
+	for orgID, keys := range requests {
+		if _, ok := keys[ualert.TestContactPointsKVKey]; !ok {
+			continue
+		}
+		tests, err := ng.testOrgContactPoints(ctx, orgID)
+		if err != nil {
+			ng.Log.Error("Failed to test migrated contact points, will retry on next start", "org", orgID, "error", err)
+			continue
+		}
+		if err := ng.store.SetMigrationContactPointTests(ctx, orgID, tests); err != nil {
+			ng.Log.Error("Failed to record the tests of migrated contact points, will retry on next start", "org", orgID, "error", err)
+			continue
+		}
+		if err := ng.KVStore.Del(ctx, orgID, ualert.TestContactPointsKVNamespace, ualert.TestContactPointsKVKey); err != nil {
+			ng.Log.Error("Failed to remove contact point test request", "org", orgID, "error", err)
+		}
+		ng.Log.Info("Tested migrated contact points", "org", orgID, "integrations", len(tests))
+	}
+}
+
+// testOrgContactPoints sends a test notification through the contact points that the migration created for the
+// legacy notification channels of the organization.
+func (ng *AlertNG) testOrgContactPoints(ctx context.Context, orgID int64) ([]models.MigrationContactPointTest, error) {
+	mappings, err := ng.store.ListMigrationMappings(ctx, &models.ListMigrationMappingsQuery{
+		OrgID:      orgID,
+		LegacyType: models.MigrationMappingLegacyChannel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration mappings: %w", err)
+	}
+	migrated := make(map[string]struct{}, len(mappings))
+	for _, m := range mappings {
+		migrated[m.Name] = struct{}{}
+	}
+
+	cfg, err := ng.store.GetLatestAlertmanagerConfiguration(ctx, &models.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Alertmanager configuration: %w", err)
+	}
+	postableUserConfig, err := notifier.Load([]byte(cfg.AlertmanagerConfiguration))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	var receivers []*apimodels.PostableApiReceiver
+	for _, r := range postableUserConfig.AlertmanagerConfig.Receivers {
+		if _, ok := migrated[r.Name]; ok {
+			receivers = append(receivers, r)
+		}
+	}
+	if len(receivers) == 0 {
+		return []models.MigrationContactPointTest{}, nil
+	}
+
+	am, err := ng.MultiOrgAlertmanager.AlertmanagerFor(orgID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, contactPointTestsTimeout)
+	defer cancel()
+	result, err := am.TestReceivers(ctx, apimodels.TestReceiversConfigBodyParams{Receivers: receivers})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send test notifications: %w", err)
+	}
+	return migrationContactPointTests(result), nil
+}
+
+// migrationContactPointTests returns the result of the test of every integration of the tested contact points.
+func migrationContactPointTests(result *notifier.TestReceiversResult) []models.MigrationContactPointTest {
+	tests := make([]models.MigrationContactPointTest, 0, len(result.Receivers))
+	for _, r := range result.Receivers {
+		for _, c := range r.Configs {
+			test := models.MigrationContactPointTest{
+				Receiver:    r.Name,
+				Integration: c.Name,
+				UID:         c.UID,
+				Status:      c.Status,
+				Tested:      result.NotifedAt,
+			}
+			if c.Error != nil {
+				test.Error = c.Error.Error()
+			}
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
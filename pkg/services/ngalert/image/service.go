@@ -116,7 +116,9 @@ func NewScreenshotImageServiceFromCfg(cfg *setting.Cfg, db *store.DBstore, ds da
 // taken. If the alert rule does not have a Dashboard UID in its annotations,
 // or the dashboard does not exist, a models.ErrNoDashboard error is returned. If the
 // alert rule has a Dashboard UID and the dashboard exists, but does not have a
-// Panel ID in its annotations then a models.ErrNoPanel error is returned.
+// Panel ID in its annotations then a models.ErrNoPanel error is returned. If the
+// screenshots of the alert rule are disabled by its annotations then a
+// models.ErrScreenshotsDisabled error is returned.
 func (s *ScreenshotImageService) NewImage(ctx context.Context, r *models.AlertRule) (*models.Image, error) {
 	logger := s.logger.FromContext(ctx)
 
@@ -134,6 +136,11 @@ func (s *ScreenshotImageService) NewImage(ctx context.Context, r *models.AlertRu
 
 	logger = logger.New("dashboard", dashboardUID, "panel", panelID)
 
+	if r.Annotations[models.ScreenshotsDisabledAnnotation] == "true" {
+		logger.Debug("Cannot take screenshot for alert rule as screenshots are disabled for it")
+		return nil, models.ErrScreenshotsDisabled
+	}
+
 	opts := screenshot.ScreenshotOptions{
 		OrgID:        r.OrgID,
 		DashboardUID: dashboardUID,
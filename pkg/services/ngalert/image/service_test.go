@@ -146,4 +146,16 @@ func TestScreenshotImageService(t *testing.T) {
 		assert.EqualError(t, err, "context deadline exceeded")
 		assert.Nil(t, image)
 	})
+
+	t.Run("error is returned when screenshots are disabled for the alert rule", func(t *testing.T) {
+		// assert that no screenshot is taken, as the mocks expect no calls
+		image, err := s.NewImage(ctx, &models.AlertRule{
+			OrgID:        1,
+			UID:          "quux",
+			DashboardUID: util.Pointer("quux"),
+			PanelID:      util.Pointer(int64(1)),
+			Annotations:  map[string]string{models.ScreenshotsDisabledAnnotation: "true"}})
+		assert.ErrorIs(t, err, models.ErrScreenshotsDisabled)
+		assert.Nil(t, image)
+	})
 }
@@ -0,0 +1,126 @@
+package ngalert
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// storeMigratedSecrets stores the secure settings of the contact points migrated from legacy alerting in the secrets
+// kvstore, as requested by the migration with the store_secrets_in_secrets_kvstore setting, and replaces them with
+// references to the kvstore unless the migration keeps a copy in the configuration. The request of an organization is
+// kept if storing its secrets fails, so that it is retried on the next start.
+func (ng *AlertNG) storeMigratedSecrets(ctx context.Context) error {
+	requests, err := ng.KVStore.GetAll(ctx, kvstore.AllOrganizations, ualert.StoreSecretsKVNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to check for requests to store secrets in the secrets kvstore: %w", err)
+	}
+
+	var failed int
+	for orgID, values := range requests {
+		value, ok := values[ualert.StoreSecretsKVKey]
+		if !ok {
+			continue
+		}
+		var request ualert.StoreSecretsRequest
+		if err := json.Unmarshal([]byte(value), &request); err != nil {
+			ng.Log.Error("Failed to parse the request to store contact point secrets in the secrets kvstore", "org", orgID, "error", err)
+			failed++
+			continue
+		}
+
+		ng.Log.Info("Storing contact point secrets in the secrets kvstore as requested by the migration", "org", orgID, "integrations", len(request.UIDs))
+		if err := ng.storeConfigurationSecrets(ctx, orgID, request); err != nil {
+			ng.Log.Error("Failed to store contact point secrets in the secrets kvstore", "org", orgID, "error", err)
+			failed++
+			continue
+		}
+		if err := ng.KVStore.Del(ctx, orgID, ualert.StoreSecretsKVNamespace, ualert.StoreSecretsKVKey); err != nil {
+			return fmt.Errorf("failed to delete the request to store secrets in the secrets kvstore: %w", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to store contact point secrets of %d organizations in the secrets kvstore", failed)
+	}
+	return nil
+}
+
+// storeConfigurationSecrets stores the secure settings of the requested integrations of the latest configuration of the
+// organization in the secrets kvstore and, unless the request keeps a copy, saves the configuration with references
+// to them instead.
+func (ng *AlertNG) storeConfigurationSecrets(ctx context.Context, orgID int64, request ualert.StoreSecretsRequest) error {
+	cfg, err := ng.store.GetLatestAlertmanagerConfiguration(ctx, &models.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID})
+	if err != nil {
+		return fmt.Errorf("failed to get Alertmanager configuration: %w", err)
+	}
+	postableUserConfig, err := notifier.Load([]byte(cfg.AlertmanagerConfiguration))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	requested := make(map[string]struct{}, len(request.UIDs))
+	for _, uid := range request.UIDs {
+		requested[uid] = struct{}{}
+	}
+
+	var modified bool
+	for _, receiver := range postableUserConfig.AlertmanagerConfig.Receivers {
+		for _, gmr := range receiver.GrafanaManagedReceivers {
+			if _, ok := requested[gmr.UID]; !ok {
+				continue
+			}
+			secureSettings := make(map[string]string, len(gmr.SecureSettings))
+			for k, v := range gmr.SecureSettings {
+				decoded, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return fmt.Errorf("failed to decode secret %q of contact point %q: %w", k, gmr.Name, err)
+				}
+				if _, _, _, ok := ualert.ParseSecretsKVReference(decoded); ok {
+					// The configuration already references the secrets kvstore, the references are all saved at once.
+					continue
+				}
+				decrypted, err := ng.SecretsService.Decrypt(ctx, decoded)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt secret %q of contact point %q: %w", k, gmr.Name, err)
+				}
+				secureSettings[k] = string(decrypted)
+			}
+			if len(secureSettings) == 0 {
+				continue
+			}
+
+			if err := ng.integrationSecrets.SetIntegrationSecrets(ctx, orgID, gmr.UID, secureSettings); err != nil {
+				return err
+			}
+			if request.KeepCopy {
+				continue
+			}
+			for k := range secureSettings {
+				gmr.SecureSettings[k] = base64.StdEncoding.EncodeToString(ualert.SecretsKVReference(orgID, gmr.UID, k))
+			}
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+
+	b, err := json.Marshal(postableUserConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+
+	return ng.store.UpdateAlertmanagerConfiguration(ctx, &models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: string(b),
+		FetchedConfigurationHash:  cfg.ConfigurationHash,
+		ConfigurationVersion:      cfg.ConfigurationVersion,
+		Default:                   cfg.Default,
+		OrgID:                     cfg.OrgID,
+	})
+}
@@ -0,0 +1,132 @@
+package ngalert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+const (
+	// migrationEventTag is the tag of the annotations of the migration events.
+	migrationEventTag = "alerting-migration"
+	// migrationEventsLockName is the server lock held by the instance that publishes the migration events.
+	migrationEventsLockName = "alerting_migration_events"
+	// migrationEventsLockTimeout is how long the lock is considered held if its instance stops while publishing.
+	migrationEventsLockTimeout = 10 * time.Minute
+)
+
+// migrationEventPublisher publishes the lifecycle events of the migration from legacy alerting on the bus, and as
+// organization annotations if the migration_events_annotations setting is enabled.
+type migrationEventPublisher struct {
+	kvStore     kvstore.KVStore
+	bus         bus.Bus
+	annotations annotations.Repository
+	annotate    bool
+	serverLock  *serverlock.ServerLockService
+	log         log.Logger
+}
+
+// PublishMigrationEvents publishes the events that the migration wrote to the kv_store, including the failures of the
+// migrations that were rolled back. All instances of a high availability setup start at once after the migration, so
+// only the instance that acquires the server lock publishes the events, and the others leave them to it.
+func (p *migrationEventPublisher) PublishMigrationEvents(ctx context.Context) {
+	if p.serverLock == nil {
+		p.log.Error("Failed to publish migration events, server lock service is not available")
+		return
+	}
+	err := p.serverLock.LockExecuteAndRelease(ctx, migrationEventsLockName, migrationEventsLockTimeout, p.publishPendingEvents)
+	var exists *serverlock.ServerLockExistsError
+	if errors.As(err, &exists) {
+		p.log.Debug("Migration events are published by another instance")
+		return
+	}
+	if err != nil {
+		p.log.Error("Failed to lock migration events", "error", err)
+	}
+}
+
+// publishPendingEvents publishes the events still in the kv_store and removes them. The events of an organization are
+// kept if one of them cannot be published, so that they are published on the next call.
+func (p *migrationEventPublisher) publishPendingEvents(ctx context.Context) {
+	entries, err := p.kvStore.GetAll(ctx, kvstore.AllOrganizations, ualert.MigrationEventsKVNamespace)
+	if err != nil {
+		p.log.Error("Failed to check for migration events", "error", err)
+		return
+	}
+
+	for orgID, keys := range entries {
+		value, ok := keys[ualert.MigrationEventsKVKey]
+		if !ok {
+			continue
+		}
+		var migrationEvents []ualert.MigrationEvent
+		if err := json.Unmarshal([]byte(value), &migrationEvents); err != nil {
+			p.log.Error("Failed to parse migration events", "org", orgID, "error", err)
+			continue
+		}
+		if err := p.publish(ctx, migrationEvents); err != nil {
+			p.log.Error("Failed to publish migration events, will retry", "org", orgID, "error", err)
+			continue
+		}
+		if err := p.kvStore.Del(ctx, orgID, ualert.MigrationEventsKVNamespace, ualert.MigrationEventsKVKey); err != nil {
+			p.log.Error("Failed to remove migration events", "org", orgID, "error", err)
+		}
+	}
+}
+
+func (p *migrationEventPublisher) publish(ctx context.Context, migrationEvents []ualert.MigrationEvent) error {
+	for _, e := range migrationEvents {
+		msg, text := migrationBusEvent(e)
+		if msg == nil {
+			p.log.Warn("Unknown migration event, ignoring", "type", e.Type, "org", e.OrgID)
+			continue
+		}
+		if err := p.bus.Publish(ctx, msg); err != nil {
+			return fmt.Errorf("failed to publish %s event: %w", e.Type, err)
+		}
+		// The start and the failure of the migration of all organizations have no organization to annotate.
+		if !p.annotate || e.OrgID == 0 {
+			continue
+		}
+		item := &annotations.Item{
+			OrgID:    e.OrgID,
+			Epoch:    e.Timestamp.UnixMilli(),
+			EpochEnd: e.Timestamp.UnixMilli(),
+			Text:     text,
+			Tags:     []string{migrationEventTag, e.Type},
+		}
+		if err := p.annotations.Save(ctx, item); err != nil {
+			return fmt.Errorf("failed to annotate %s event: %w", e.Type, err)
+		}
+	}
+	return nil
+}
+
+// migrationBusEvent returns the bus event of the migration event and the text of its annotation, or nil if the type of
+// the event is unknown.
+func migrationBusEvent(e ualert.MigrationEvent) (bus.Msg, string) {
+	switch e.Type {
+	case ualert.MigrationEventStarted:
+		return &events.AlertingMigrationStarted{Timestamp: e.Timestamp, OrgID: e.OrgID}, "Migration from legacy alerting started"
+	case ualert.MigrationEventOrgMigrated:
+		return &events.AlertingOrgMigrated{Timestamp: e.Timestamp, OrgID: e.OrgID, AlertRules: e.AlertRules, ContactPoints: e.ContactPoints},
+			fmt.Sprintf("Legacy alerts migrated to unified alerting: %d alert rules, %d contact points", e.AlertRules, e.ContactPoints)
+	case ualert.MigrationEventOrgReverted:
+		return &events.AlertingOrgMigrationReverted{Timestamp: e.Timestamp, OrgID: e.OrgID}, "Migration from legacy alerting reverted"
+	case ualert.MigrationEventFailed:
+		return &events.AlertingMigrationFailed{Timestamp: e.Timestamp, OrgID: e.OrgID, Error: e.Error},
+			fmt.Sprintf("Migration from legacy alerting failed: %s", e.Error)
+	default:
+		return nil, ""
+	}
+}
@@ -0,0 +1,135 @@
+package ngalert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+const (
+	// migrationReportEmailTemplate is the email template of the report of the migration of an organization.
+	migrationReportEmailTemplate = "ng_migration_report"
+	// migrationReportsLockName is the server lock held by the instance that sends the migration reports.
+	migrationReportsLockName = "alerting_migration_reports"
+	// migrationReportsLockTimeout is how long the lock is considered held if its instance stops while sending.
+	migrationReportsLockTimeout = 10 * time.Minute
+)
+
+// sendMigrationReports sends the report of the migration of every organization that the migration from legacy
+// alerting wrote with the report_webhook_url or report_email_org_admins setting. All instances of a high availability
+// setup start at once after the migration, so only the instance that acquires the server lock sends the reports and
+// the others leave them to it. The report of an organization is kept if it cannot be sent, so that it is sent on the
+// next start.
+func (ng *AlertNG) sendMigrationReports(ctx context.Context) {
+	upgradeCfg := ng.Cfg.UnifiedAlerting.Upgrade
+	if upgradeCfg.ReportWebhookURL == "" && !upgradeCfg.ReportEmailOrgAdmins {
+		return
+	}
+
+	if ng.store.ServerLock == nil {
+		ng.Log.Error("Failed to send migration reports, server lock service is not available")
+		return
+	}
+	err := ng.store.ServerLock.LockExecuteAndRelease(ctx, migrationReportsLockName, migrationReportsLockTimeout, ng.sendPendingMigrationReports)
+	var exists *serverlock.ServerLockExistsError
+	if errors.As(err, &exists) {
+		ng.Log.Debug("Migration reports are sent by another instance")
+		return
+	}
+	if err != nil {
+		ng.Log.Error("Failed to lock migration reports", "error", err)
+	}
+}
+
+// sendPendingMigrationReports sends the reports still in the kv_store and removes those that were sent.
+func (ng *AlertNG) sendPendingMigrationReports(ctx context.Context) {
+	reports, err := ng.KVStore.GetAll(ctx, kvstore.AllOrganizations, ualert.MigrationReportKVNamespace)
+	if err != nil {
+		ng.Log.Error("Failed to check for migration reports", "error", err)
+		return
+	}
+
+	for orgID, keys := range reports {
+		value, ok := keys[ualert.MigrationReportKVKey]
+		if !ok {
+			continue
+		}
+		var report ualert.MigrationReport
+		if err := json.Unmarshal([]byte(value), &report); err != nil {
+			ng.Log.Error("Failed to parse migration report", "org", orgID, "error", err)
+			continue
+		}
+		if err := ng.sendMigrationReport(ctx, &report); err != nil {
+			ng.Log.Error("Failed to send migration report, will retry on next start", "org", orgID, "error", err)
+			continue
+		}
+		if err := ng.KVStore.Del(ctx, orgID, ualert.MigrationReportKVNamespace, ualert.MigrationReportKVKey); err != nil {
+			ng.Log.Error("Failed to remove migration report", "org", orgID, "error", err)
+		}
+		ng.Log.Info("Sent migration report", "org", orgID)
+	}
+}
+
+// sendMigrationReport posts the report to the report_webhook_url, if set, and emails it to the admins of the
+// organization if report_email_org_admins is enabled.
+func (ng *AlertNG) sendMigrationReport(ctx context.Context, report *ualert.MigrationReport) error {
+	upgradeCfg := ng.Cfg.UnifiedAlerting.Upgrade
+	if upgradeCfg.ReportWebhookURL != "" {
+		body, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		cmd := &notifications.SendWebhookSync{
+			Url:         upgradeCfg.ReportWebhookURL,
+			Body:        string(body),
+			HttpMethod:  "POST",
+			ContentType: "application/json",
+		}
+		if err := ng.NotificationService.SendWebhookSync(ctx, cmd); err != nil {
+			return fmt.Errorf("failed to post report: %w", err)
+		}
+	}
+
+	if upgradeCfg.ReportEmailOrgAdmins {
+		emails, err := ng.store.ListOrgAdminEmails(ctx, report.OrgID)
+		if err != nil {
+			return fmt.Errorf("failed to list the admins of the organization: %w", err)
+		}
+		if len(emails) == 0 {
+			ng.Log.Warn("Organization has no admin with an email address, migration report not emailed", "org", report.OrgID)
+			return nil
+		}
+		cmd := &notifications.SendEmailCommandSync{
+			SendEmailCommand: notifications.SendEmailCommand{
+				To:       emails,
+				Template: migrationReportEmailTemplate,
+				Subject:  "Your alerts were migrated to Grafana Alerting",
+				Data:     migrationReportEmailData(report),
+			},
+		}
+		if err := ng.NotificationService.SendEmailCommandHandlerSync(ctx, cmd); err != nil {
+			return fmt.Errorf("failed to email report: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrationReportEmailData returns the data of the email template of the report.
+func migrationReportEmailData(report *ualert.MigrationReport) map[string]any {
+	return map[string]any{
+		"OrgID":           report.OrgID,
+		"AlertRules":      report.AlertRules,
+		"ContactPoints":   report.ContactPoints,
+		"SkippedAlerts":   report.SkippedAlerts,
+		"RenamedRules":    report.RenamedRules,
+		"SkippedChannels": report.SkippedChannels,
+		"Warnings":        report.Warnings,
+	}
+}
@@ -0,0 +1,205 @@
+package migrationcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+var (
+	ErrInvalidInputData     = errors.New("invalid input data")
+	ErrLegacyAlertingAbsent = errors.New("legacy alerting is not available")
+
+	logger = log.New("ngalert.migrationcheck")
+)
+
+// maxEvaluations is the maximum number of evaluations of a pair of legacy alert and alert rule.
+const maxEvaluations = 100
+
+// State is the state of a legacy alert or of an alert rule at one evaluation, reduced to what both have in common.
+type State string
+
+const (
+	StateNormal   State = "Normal"
+	StateAlerting State = "Alerting"
+	StateNoData   State = "NoData"
+	StateError    State = "Error"
+)
+
+// LegacyEvaluator evaluates legacy alerts at a given time.
+type LegacyEvaluator interface {
+	EvalAlertAt(ctx context.Context, orgID, alertID int64, at time.Time) (*alerting.EvalContext, error)
+}
+
+// MappingStore lists the alert rules created by the migration for the legacy alerts.
+type MappingStore interface {
+	ListMigrationMappings(ctx context.Context, query *models.ListMigrationMappingsQuery) ([]*models.MigrationMapping, error)
+}
+
+// RuleStore gets the migrated alert rules.
+type RuleStore interface {
+	GetAlertRuleByUID(ctx context.Context, query *models.GetAlertRuleByUIDQuery) (*models.AlertRule, error)
+}
+
+// Mismatch is an evaluation where the legacy alert and the alert rule migrated from it are in different states.
+type Mismatch struct {
+	LegacyID int64
+	RuleUID  string
+	Title    string
+	Time     time.Time
+	Legacy   State
+	Unified  State
+}
+
+// Report is the result of the comparison of the legacy alerts of an organization with the migrated alert rules.
+type Report struct {
+	From        time.Time
+	To          time.Time
+	Compared    int
+	Evaluations int
+	Mismatches  []Mismatch
+	// Errors are the pairs that could not be compared.
+	Errors []string
+}
+
+// Checker evaluates the legacy alerts and the alert rules migrated from them over the same window of time, and
+// reports when their states differ, for example because a query could not be migrated with the same semantics.
+type Checker struct {
+	mappings    MappingStore
+	rules       RuleStore
+	evalFactory eval.EvaluatorFactory
+	legacy      LegacyEvaluator
+}
+
+func NewChecker(mappings MappingStore, rules RuleStore, evalFactory eval.EvaluatorFactory, legacy LegacyEvaluator) *Checker {
+	return &Checker{
+		mappings:    mappings,
+		rules:       rules,
+		evalFactory: evalFactory,
+		legacy:      legacy,
+	}
+}
+
+// Compare evaluates the migrated legacy alerts of the organization of the user, or only the one with the given ID if
+// legacyID is not 0, and the alert rules migrated from them at every step of the window [to-window, to].
+func (c *Checker) Compare(ctx context.Context, user *user.SignedInUser, legacyID int64, to time.Time, window, step time.Duration) (*Report, error) {
+	if c.legacy == nil {
+		return nil, ErrLegacyAlertingAbsent
+	}
+	if window <= 0 || step <= 0 || step > window {
+		return nil, fmt.Errorf("%w: window %s and step %s must be positive and the step must not exceed the window", ErrInvalidInputData, window, step)
+	}
+	evaluations := int(window / step)
+	if evaluations > maxEvaluations {
+		return nil, fmt.Errorf("%w: window %s with step %s exceeds %d evaluations", ErrInvalidInputData, window, step, maxEvaluations)
+	}
+
+	orgID := user.GetOrgID()
+	mappings, err := c.mappings.ListMigrationMappings(ctx, &models.ListMigrationMappingsQuery{
+		OrgID:      orgID,
+		LegacyType: models.MigrationMappingLegacyAlert,
+		LegacyID:   legacyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	from := to.Add(-window)
+	report := &Report{From: from, To: to, Mismatches: []Mismatch{}, Errors: []string{}}
+	for _, mapping := range mappings {
+		mismatches, err := c.comparePair(ctx, user, mapping, from, step, evaluations)
+		if err != nil {
+			logger.Warn("Failed to compare legacy alert with migrated alert rule", "org", orgID, "legacyId", mapping.LegacyID, "ruleUid", mapping.UID, "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("legacy alert %d and alert rule %s: %s", mapping.LegacyID, mapping.UID, err))
+			continue
+		}
+		report.Compared++
+		report.Evaluations += evaluations
+		report.Mismatches = append(report.Mismatches, mismatches...)
+	}
+	return report, nil
+}
+
+func (c *Checker) comparePair(ctx context.Context, user *user.SignedInUser, mapping *models.MigrationMapping, from time.Time, step time.Duration, evaluations int) ([]Mismatch, error) {
+	rule, err := c.rules.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{UID: mapping.UID, OrgID: mapping.OrgID})
+	if err != nil {
+		return nil, err
+	}
+	ruleCtx := models.WithRuleKey(ctx, rule.GetKey())
+	evaluator, err := c.evalFactory.Create(eval.NewContext(ruleCtx, user), rule.GetEvalCondition())
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []Mismatch
+	for i := 1; i <= evaluations; i++ {
+		now := from.Add(time.Duration(i) * step)
+
+		legacyCtx, err := c.legacy.EvalAlertAt(ctx, mapping.OrgID, mapping.LegacyID, now)
+		if err != nil {
+			return nil, err
+		}
+		results, err := evaluator.Evaluate(ruleCtx, now)
+		if err != nil {
+			return nil, err
+		}
+
+		legacy, unified := legacyState(legacyCtx), unifiedState(results)
+		if legacy != unified {
+			mismatches = append(mismatches, Mismatch{
+				LegacyID: mapping.LegacyID,
+				RuleUID:  rule.UID,
+				Title:    rule.Title,
+				Time:     now,
+				Legacy:   legacy,
+				Unified:  unified,
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// legacyState returns the state of an evaluation of a legacy alert before its no data and error handling is applied.
+func legacyState(c *alerting.EvalContext) State {
+	switch {
+	case c.Error != nil:
+		return StateError
+	case c.Firing:
+		return StateAlerting
+	case c.NoDataFound:
+		return StateNoData
+	default:
+		return StateNormal
+	}
+}
+
+// unifiedState returns the state of an evaluation of an alert rule before its no data and error handling is applied.
+// Like a legacy alert, the rule is in error if any of its series is in error, and alerting if any is alerting.
+func unifiedState(results eval.Results) State {
+	isAlerting, isNoData := false, false
+	for _, r := range results {
+		switch r.State {
+		case eval.Error:
+			return StateError
+		case eval.Alerting:
+			isAlerting = true
+		case eval.NoData:
+			isNoData = true
+		}
+	}
+	switch {
+	case isAlerting:
+		return StateAlerting
+	case isNoData:
+		return StateNoData
+	default:
+		return StateNormal
+	}
+}
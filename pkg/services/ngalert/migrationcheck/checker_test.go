@@ -0,0 +1,167 @@
+package migrationcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestCompare(t *testing.T) {
+	orgID := int64(1)
+	signedInUser := &user.SignedInUser{OrgID: orgID}
+	to := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	rule := models.AlertRuleGen(models.WithOrgID(orgID))()
+	mappings := &fakeMappingStore{mappings: []*models.MigrationMapping{
+		{OrgID: orgID, LegacyType: models.MigrationMappingLegacyAlert, LegacyID: 1, UID: rule.UID, Name: rule.Title},
+	}}
+	rules := &fakeRuleStore{rules: map[string]*models.AlertRule{rule.UID: rule}}
+
+	// Both firing at the same times except at 11:40, where the legacy alert fires and the alert rule does not.
+	firing := func(at time.Time) bool {
+		return at.Minute()%20 == 0
+	}
+	legacy := &fakeLegacyEvaluator{evalFn: func(at time.Time) *alerting.EvalContext {
+		return &alerting.EvalContext{Firing: firing(at)}
+	}}
+	evaluator := &fakeConditionEvaluator{evalFn: func(at time.Time) (eval.Results, error) {
+		if firing(at) && at.Minute() != 40 {
+			return eval.Results{{State: eval.Alerting}, {State: eval.Normal}}, nil
+		}
+		return eval.Results{{State: eval.Normal}}, nil
+	}}
+
+	t.Run("reports mismatches", func(t *testing.T) {
+		checker := NewChecker(mappings, rules, eval_mocks.NewEvaluatorFactory(evaluator), legacy)
+
+		report, err := checker.Compare(context.Background(), signedInUser, 0, to, time.Hour, 5*time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, to.Add(-time.Hour), report.From)
+		require.Equal(t, 1, report.Compared)
+		require.Equal(t, 12, report.Evaluations)
+		require.Empty(t, report.Errors)
+		require.Equal(t, []Mismatch{{
+			LegacyID: 1,
+			RuleUID:  rule.UID,
+			Title:    rule.Title,
+			Time:     time.Date(2023, 1, 1, 11, 40, 0, 0, time.UTC),
+			Legacy:   StateAlerting,
+			Unified:  StateNormal,
+		}}, report.Mismatches)
+	})
+
+	t.Run("records pairs that cannot be compared", func(t *testing.T) {
+		failing := &fakeConditionEvaluator{evalFn: func(at time.Time) (eval.Results, error) {
+			return nil, errors.New("query failed")
+		}}
+		checker := NewChecker(mappings, rules, eval_mocks.NewEvaluatorFactory(failing), legacy)
+
+		report, err := checker.Compare(context.Background(), signedInUser, 0, to, time.Hour, 5*time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, 0, report.Compared)
+		require.Empty(t, report.Mismatches)
+		require.Len(t, report.Errors, 1)
+	})
+
+	t.Run("fails if legacy alerting is absent", func(t *testing.T) {
+		checker := NewChecker(mappings, rules, eval_mocks.NewEvaluatorFactory(evaluator), nil)
+
+		_, err := checker.Compare(context.Background(), signedInUser, 0, to, time.Hour, 5*time.Minute)
+		require.ErrorIs(t, err, ErrLegacyAlertingAbsent)
+	})
+
+	t.Run("fails if the window is invalid", func(t *testing.T) {
+		checker := NewChecker(mappings, rules, eval_mocks.NewEvaluatorFactory(evaluator), legacy)
+
+		testCases := []struct {
+			name   string
+			window time.Duration
+			step   time.Duration
+		}{
+			{name: "zero window", window: 0, step: time.Minute},
+			{name: "zero step", window: time.Hour, step: 0},
+			{name: "step larger than window", window: time.Minute, step: time.Hour},
+			{name: "too many evaluations", window: 24 * time.Hour, step: time.Minute},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := checker.Compare(context.Background(), signedInUser, 0, to, tc.window, tc.step)
+				require.ErrorIs(t, err, ErrInvalidInputData)
+			})
+		}
+	})
+}
+
+func TestUnifiedState(t *testing.T) {
+	testCases := []struct {
+		name     string
+		results  eval.Results
+		expected State
+	}{
+		{name: "no results", results: nil, expected: StateNormal},
+		{name: "normal", results: eval.Results{{State: eval.Normal}}, expected: StateNormal},
+		{name: "alerting wins over no data", results: eval.Results{{State: eval.NoData}, {State: eval.Alerting}}, expected: StateAlerting},
+		{name: "no data", results: eval.Results{{State: eval.Normal}, {State: eval.NoData}}, expected: StateNoData},
+		{name: "error wins over alerting", results: eval.Results{{State: eval.Alerting}, {State: eval.Error}}, expected: StateError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, unifiedState(tc.results))
+		})
+	}
+}
+
+type fakeMappingStore struct {
+	mappings []*models.MigrationMapping
+}
+
+func (f *fakeMappingStore) ListMigrationMappings(_ context.Context, query *models.ListMigrationMappingsQuery) ([]*models.MigrationMapping, error) {
+	var result []*models.MigrationMapping
+	for _, m := range f.mappings {
+		if m.OrgID == query.OrgID && (query.LegacyID == 0 || m.LegacyID == query.LegacyID) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+type fakeRuleStore struct {
+	rules map[string]*models.AlertRule
+}
+
+func (f *fakeRuleStore) GetAlertRuleByUID(_ context.Context, query *models.GetAlertRuleByUIDQuery) (*models.AlertRule, error) {
+	rule, ok := f.rules[query.UID]
+	if !ok || rule.OrgID != query.OrgID {
+		return nil, models.ErrAlertRuleNotFound
+	}
+	return rule, nil
+}
+
+type fakeLegacyEvaluator struct {
+	evalFn func(at time.Time) *alerting.EvalContext
+}
+
+func (f *fakeLegacyEvaluator) EvalAlertAt(_ context.Context, _, _ int64, at time.Time) (*alerting.EvalContext, error) {
+	return f.evalFn(at), nil
+}
+
+type fakeConditionEvaluator struct {
+	evalFn func(at time.Time) (eval.Results, error)
+}
+
+func (f *fakeConditionEvaluator) EvaluateRaw(_ context.Context, _ time.Time) (*backend.QueryDataResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeConditionEvaluator) Evaluate(_ context.Context, now time.Time) (eval.Results, error) {
+	return f.evalFn(now)
+}
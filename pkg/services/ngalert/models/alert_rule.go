@@ -35,6 +35,10 @@ var (
 	// ErrNoPanel is returned when the alert rule does not have a PanelID in its
 	// annotations.
 	ErrNoPanel = errors.New("no panel")
+
+	// ErrScreenshotsDisabled is returned when the screenshots of the alert rule are
+	// disabled by its annotations.
+	ErrScreenshotsDisabled = errors.New("screenshots disabled")
 )
 
 // swagger:enum NoDataState
@@ -94,6 +98,11 @@ const (
 	DashboardUIDAnnotation = "__dashboardUid__"
 	PanelIDAnnotation      = "__panelId__"
 
+	// ScreenshotsDisabledAnnotation disables the screenshots of the dashboard panel of the alert rule when they are
+	// enabled in [unified_alerting.screenshots]. The migration from legacy alerting sets it on the alert rules whose
+	// notification channels did not upload images.
+	ScreenshotsDisabledAnnotation = "__screenshotsDisabled__"
+
 	// GrafanaReservedLabelPrefix contains the prefix for Grafana reserved labels. These differ from "__<label>__" labels
 	// in that they are not meant for internal-use only and will be passed-through to AMs and available to users in the same
 	// way as manually configured labels.
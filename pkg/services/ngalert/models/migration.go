@@ -0,0 +1,125 @@
+package models
+
+import "time"
+
+// Actions recorded in the migration audit log.
+const (
+	MigrationAuditActionCreate = "create"
+	MigrationAuditActionUpdate = "update"
+	MigrationAuditActionDelete = "delete"
+)
+
+// Resource types recorded in the migration audit log.
+const (
+	MigrationAuditResourceAlertRule = "alert_rule"
+	MigrationAuditResourceFolder    = "folder"
+	MigrationAuditResourceReceiver  = "receiver"
+	MigrationAuditResourceSilence   = "silence"
+)
+
+// MigrationAuditEntry records a resource that was created or deleted by the migration from legacy alerting,
+// together with the ID and UID of the legacy alert, dashboard or notification channel it originates from and the ID of
+// the run of the migration.
+type MigrationAuditEntry struct {
+	ID           int64     `xorm:"pk autoincr 'id'"`
+	OrgID        int64     `xorm:"org_id"`
+	Action       string    `xorm:"action"`
+	ResourceType string    `xorm:"resource_type"`
+	ResourceUID  string    `xorm:"resource_uid"`
+	LegacyID     int64     `xorm:"legacy_id"`
+	LegacyUID    string    `xorm:"legacy_uid"`
+	RunID        string    `xorm:"run_id"`
+	Created      time.Time `xorm:"created"`
+}
+
+func (e *MigrationAuditEntry) TableName() string {
+	return "alert_migration_audit"
+}
+
+// ListMigrationAuditEntriesQuery is the query for listing migration audit entries of an organization.
+// Zero values of the optional fields are not used as filters.
+type ListMigrationAuditEntriesQuery struct {
+	OrgID        int64
+	Action       string
+	ResourceType string
+	ResourceUID  string
+	LegacyID     int64
+	Limit        int
+}
+
+// Legacy resource types of the migration mappings.
+const (
+	MigrationMappingLegacyAlert   = "alert"
+	MigrationMappingLegacyChannel = "channel"
+)
+
+// MigrationMapping maps a legacy alert to the alert rule, or a legacy notification channel to the contact point,
+// that the migration from legacy alerting created for it. UID and Name are those of the alert rule, or those of the
+// contact point integration and the contact point respectively.
+type MigrationMapping struct {
+	ID         int64  `xorm:"pk autoincr 'id'"`
+	OrgID      int64  `xorm:"org_id"`
+	LegacyType string `xorm:"legacy_type"`
+	LegacyID   int64  `xorm:"legacy_id"`
+	LegacyUID  string `xorm:"legacy_uid"`
+	UID        string `xorm:"uid"`
+	Name       string `xorm:"name"`
+}
+
+func (m *MigrationMapping) TableName() string {
+	return "alert_migration_mapping"
+}
+
+// ListMigrationMappingsQuery is the query for listing the migration mappings of an organization.
+// Zero values of the optional fields are not used as filters.
+type ListMigrationMappingsQuery struct {
+	OrgID      int64
+	LegacyType string
+	LegacyID   int64
+	LegacyUID  string
+}
+
+// MigrationOrgState records whether the legacy alerts of an organization are migrated to unified alerting, when and
+// by which Grafana version. Errors is a JSON array of the problems that did not fail the migration. Shadow is set if
+// the organization was migrated in shadow mode and its migration is not activated yet. ContactPointTests is a JSON
+// array of the MigrationContactPointTest of the migrated contact points, if they were tested.
+type MigrationOrgState struct {
+	ID                int64     `xorm:"pk autoincr 'id'"`
+	OrgID             int64     `xorm:"org_id"`
+	Migrated          bool      `xorm:"migrated"`
+	GrafanaVersion    string    `xorm:"grafana_version"`
+	Errors            string    `xorm:"errors"`
+	Updated           time.Time `xorm:"updated"`
+	Shadow            bool      `xorm:"shadow"`
+	ContactPointTests string    `xorm:"contact_point_tests"`
+}
+
+// MigrationContactPointTest is the result of the test notification sent through an integration of a migrated contact
+// point after the migration. Error is empty if the notification was delivered.
+type MigrationContactPointTest struct {
+	Receiver    string    `json:"receiver"`
+	Integration string    `json:"integration"`
+	UID         string    `json:"uid"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	Tested      time.Time `json:"tested"`
+}
+
+func (s *MigrationOrgState) TableName() string {
+	return "alert_migration_org_state"
+}
+
+// MigrationOrgStatus is the state of the migration of an organization together with the number of resources
+// that the migration created and that were not deleted since, per resource type.
+type MigrationOrgStatus struct {
+	MigrationOrgState
+	Created map[string]int64
+}
+
+// MigratedResource is a resource that the migration from legacy alerting created and that was not deleted since.
+// Name is the title of the alert rule or folder, or the name of the contact point of the integration, and empty for
+// silences and for resources that no longer exist.
+type MigratedResource struct {
+	MigrationAuditEntry
+	Name string
+}
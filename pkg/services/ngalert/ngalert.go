@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/migrationcheck"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
@@ -41,6 +42,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -68,6 +70,8 @@ func ProvideService(
 	pluginsStore pluginstore.Store,
 	tracer tracing.Tracer,
 	ruleStore *store.DBstore,
+	legacyEvaluator migrationcheck.LegacyEvaluator,
+	secretsKVStore secretskvs.SecretsKVStore,
 ) (*AlertNG, error) {
 	ng := &AlertNG{
 		Cfg:                  cfg,
@@ -81,6 +85,7 @@ func ProvideService(
 		DataProxy:            dataProxy,
 		QuotaService:         quotaService,
 		SecretsService:       secretsService,
+		SecretsKVStore:       secretsKVStore,
 		Metrics:              m,
 		Log:                  log.New("ngalert"),
 		NotificationService:  notificationService,
@@ -94,6 +99,7 @@ func ProvideService(
 		pluginsStore:         pluginsStore,
 		tracer:               tracer,
 		store:                ruleStore,
+		legacyEvaluator:      legacyEvaluator,
 	}
 
 	if ng.IsDisabled() {
@@ -120,6 +126,7 @@ type AlertNG struct {
 	DataProxy           *datasourceproxy.DataSourceProxyService
 	QuotaService        quota.Service
 	SecretsService      secrets.Service
+	SecretsKVStore      secretskvs.SecretsKVStore
 	Metrics             *metrics.NGAlert
 	NotificationService notifications.Service
 	Log                 log.Logger
@@ -138,6 +145,13 @@ type AlertNG struct {
 	accesscontrolService accesscontrol.Service
 	annotationsRepo      annotations.Repository
 	store                *store.DBstore
+	// legacyEvaluator evaluates legacy alerts to compare them with the alert rules migrated from them.
+	legacyEvaluator migrationcheck.LegacyEvaluator
+	// integrationSecrets decrypts the secure settings of contact points, reading those that reference the secrets
+	// kvstore from it.
+	integrationSecrets *notifier.IntegrationSecretsService
+	// migrationEvents publishes the lifecycle events of the migration from legacy alerting.
+	migrationEvents *migrationEventPublisher
 
 	bus          bus.Bus
 	pluginsStore pluginstore.Store
@@ -153,9 +167,26 @@ func (ng *AlertNG) init() error {
 
 	ng.store.Logger = ng.Log
 
-	decryptFn := ng.SecretsService.GetDecryptedValue
+	ng.migrationEvents = &migrationEventPublisher{
+		kvStore:     ng.KVStore,
+		bus:         ng.bus,
+		annotations: ng.annotationsRepo,
+		annotate:    ng.Cfg.UnifiedAlerting.Upgrade.MigrationEventsAnnotations,
+		serverLock:  ng.store.ServerLock,
+		log:         log.New("ngalert.migration.events"),
+	}
+
+	if err := ng.rotateMigratedSecrets(initCtx); err != nil {
+		ng.Log.Error("Failed to rotate the secrets of migrated contact points, will retry on next start", "error", err)
+	}
+
+	ng.integrationSecrets = notifier.NewIntegrationSecretsService(ng.SecretsService, ng.SecretsKVStore, log.New("ngalert.integration.secrets"))
+	if err := ng.storeMigratedSecrets(initCtx); err != nil {
+		ng.Log.Error("Failed to store the secrets of migrated contact points in the secrets kvstore, will retry on next start", "error", err)
+	}
+
 	multiOrgMetrics := ng.Metrics.GetMultiOrgAlertmanagerMetrics()
-	ng.MultiOrgAlertmanager, err = notifier.NewMultiOrgAlertmanager(ng.Cfg, ng.store, ng.store, ng.KVStore, ng.store, decryptFn, multiOrgMetrics, ng.NotificationService, log.New("ngalert.multiorg.alertmanager"), ng.SecretsService)
+	ng.MultiOrgAlertmanager, err = notifier.NewMultiOrgAlertmanager(ng.Cfg, ng.store, ng.store, ng.KVStore, ng.store, ng.integrationSecrets.GetDecryptedValue, multiOrgMetrics, ng.NotificationService, log.New("ngalert.multiorg.alertmanager"), ng.integrationSecrets)
 	if err != nil {
 		return err
 	}
@@ -238,7 +269,7 @@ func (ng *AlertNG) init() error {
 
 	// Provisioning
 	policyService := provisioning.NewNotificationPolicyService(ng.store, ng.store, ng.store, ng.Cfg.UnifiedAlerting, ng.Log)
-	contactPointService := provisioning.NewContactPointService(ng.store, ng.SecretsService, ng.store, ng.store, ng.Log, ng.accesscontrol)
+	contactPointService := provisioning.NewContactPointService(ng.store, ng.integrationSecrets, ng.store, ng.store, ng.Log, ng.accesscontrol)
 	templateService := provisioning.NewTemplateService(ng.store, ng.store, ng.store, ng.Log)
 	muteTimingService := provisioning.NewMuteTimingService(ng.store, ng.store, ng.store, ng.Log)
 	alertRuleService := provisioning.NewAlertRuleService(ng.store, ng.store, ng.dashboardService, ng.QuotaService, ng.store,
@@ -256,6 +287,7 @@ func (ng *AlertNG) init() error {
 		RuleStore:            ng.store,
 		AlertingStore:        ng.store,
 		AdminConfigStore:     ng.store,
+		MigrationStore:       ng.store,
 		ProvenanceStore:      ng.store,
 		MultiOrgAlertmanager: ng.MultiOrgAlertmanager,
 		StateManager:         ng.stateManager,
@@ -272,6 +304,8 @@ func (ng *AlertNG) init() error {
 		Historian:            history,
 		Hooks:                api.NewHooks(ng.Log),
 		Tracer:               ng.tracer,
+		MigrationChecker:     migrationcheck.NewChecker(ng.store, ng.store, evalFactory, ng.legacyEvaluator),
+		MigrationEvents:      ng.migrationEvents,
 	}
 	ng.api.RegisterAPIEndpoints(ng.Metrics.GetAPIMetrics())
 
@@ -329,6 +363,18 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 	children.Go(func() error {
 		return ng.AlertsRouter.Run(subCtx)
 	})
+	children.Go(func() error {
+		ng.testMigratedContactPoints(subCtx)
+		return nil
+	})
+	children.Go(func() error {
+		ng.sendMigrationReports(subCtx)
+		return nil
+	})
+	children.Go(func() error {
+		ng.migrationEvents.PublishMigrationEvents(subCtx)
+		return nil
+	})
 
 	if ng.Cfg.UnifiedAlerting.ExecuteAlerts {
 		children.Go(func() error {
@@ -361,10 +407,12 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 
 	var alertOrgQuota int64
 	var alertGlobalQuota int64
+	alertFolderQuota := int64(-1)
 
 	if cfg.UnifiedAlerting.IsEnabled() {
 		alertOrgQuota = cfg.Quota.Org.AlertRule
 		alertGlobalQuota = cfg.Quota.Global.AlertRule
+		alertFolderQuota = cfg.Quota.Folder.AlertRule
 	}
 
 	globalQuotaTag, err := quota.NewTag(models.QuotaTargetSrv, models.QuotaTarget, quota.GlobalScope)
@@ -375,9 +423,14 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 	if err != nil {
 		return limits, err
 	}
+	folderQuotaTag, err := quota.NewTag(models.QuotaTargetSrv, models.QuotaTarget, quota.FolderScope)
+	if err != nil {
+		return limits, err
+	}
 
 	limits.Set(globalQuotaTag, alertGlobalQuota)
 	limits.Set(orgQuotaTag, alertOrgQuota)
+	limits.Set(folderQuotaTag, alertFolderQuota)
 	return limits, nil
 }
 
@@ -3,6 +3,7 @@ package ngalert
 import (
 	"bytes"
 	"context"
+	"errors"
 	"math/rand"
 	"testing"
 	"time"
@@ -15,10 +16,13 @@ import (
 	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -164,3 +168,62 @@ grafana_alerting_state_history_info{backend="noop"} 0
 		require.NoError(t, err)
 	})
 }
+
+func Test_migrationContactPointTests(t *testing.T) {
+	notifiedAt := time.Now()
+	result := &notifier.TestReceiversResult{
+		NotifedAt: notifiedAt,
+		Receivers: []notifier.TestReceiverResult{
+			{Name: "team-a", Configs: []notifier.TestReceiverConfigResult{
+				{Name: "team-a", UID: "uid-1", Status: "ok"},
+				{Name: "team-a", UID: "uid-2", Status: "failed", Error: errors.New("connection refused")},
+			}},
+			{Name: "team-b", Configs: []notifier.TestReceiverConfigResult{
+				{Name: "team-b", UID: "uid-3", Status: "ok"},
+			}},
+		},
+	}
+
+	require.Equal(t, []models.MigrationContactPointTest{
+		{Receiver: "team-a", Integration: "team-a", UID: "uid-1", Status: "ok", Tested: notifiedAt},
+		{Receiver: "team-a", Integration: "team-a", UID: "uid-2", Status: "failed", Error: "connection refused", Tested: notifiedAt},
+		{Receiver: "team-b", Integration: "team-b", UID: "uid-3", Status: "ok", Tested: notifiedAt},
+	}, migrationContactPointTests(result))
+}
+
+func Test_migrationEventPublisher(t *testing.T) {
+	b := bus.ProvideBus(tracing.InitializeTracerForTest())
+	var migrated []*events.AlertingOrgMigrated
+	b.AddEventListener(func(ctx context.Context, e *events.AlertingOrgMigrated) error {
+		migrated = append(migrated, e)
+		return nil
+	})
+	var failed []*events.AlertingMigrationFailed
+	b.AddEventListener(func(ctx context.Context, e *events.AlertingMigrationFailed) error {
+		failed = append(failed, e)
+		return nil
+	})
+	repo := annotationstest.NewFakeAnnotationsRepo()
+	p := &migrationEventPublisher{bus: b, annotations: repo, annotate: true, log: log.NewNopLogger()}
+
+	now := time.Now().UTC()
+	err := p.publish(context.Background(), []ualert.MigrationEvent{
+		{Type: ualert.MigrationEventStarted, Timestamp: now},
+		{Type: ualert.MigrationEventOrgMigrated, Timestamp: now, OrgID: 1, AlertRules: 3, ContactPoints: 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*events.AlertingOrgMigrated{{Timestamp: now, OrgID: 1, AlertRules: 3, ContactPoints: 2}}, migrated)
+	// The start of the migration of all organizations is not annotated.
+	require.Equal(t, 1, repo.Len())
+	require.Equal(t, int64(1), repo.Items()[1].OrgID)
+	require.Equal(t, []string{migrationEventTag, ualert.MigrationEventOrgMigrated}, repo.Items()[1].Tags)
+
+	err = p.publish(context.Background(), []ualert.MigrationEvent{
+		{Type: ualert.MigrationEventFailed, Timestamp: now, OrgID: 2, Error: "database is locked"},
+	})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.Equal(t, int64(2), failed[0].OrgID)
+	require.Equal(t, "database is locked", failed[0].Error)
+	require.Equal(t, 2, repo.Len())
+}
@@ -159,7 +159,7 @@ func (moa *MultiOrgAlertmanager) gettableUserConfigFromAMConfigString(ctx contex
 func (moa *MultiOrgAlertmanager) ApplyAlertmanagerConfiguration(ctx context.Context, org int64, config definitions.PostableUserConfig) error {
 	// Get the last known working configuration
 	query := models.GetLatestAlertmanagerConfigurationQuery{OrgID: org}
-	_, err := moa.configStore.GetLatestAlertmanagerConfiguration(ctx, &query)
+	previous, err := moa.configStore.GetLatestAlertmanagerConfiguration(ctx, &query)
 	if err != nil {
 		// If we don't have a configuration there's nothing for us to know and we should just continue saving the new one
 		if !errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
@@ -188,9 +188,27 @@ func (moa *MultiOrgAlertmanager) ApplyAlertmanagerConfiguration(ctx context.Cont
 		return AlertmanagerConfigRejectedError{err}
 	}
 
+	moa.deleteUnreferencedSecrets(ctx, org, previous, config.AlertmanagerConfig.Receivers)
 	return nil
 }
 
+// deleteUnreferencedSecrets deletes from the secrets kvstore the secure settings of the integrations that the previous
+// configuration referenced and the saved one no longer does, because they were removed or their secrets replaced.
+func (moa *MultiOrgAlertmanager) deleteUnreferencedSecrets(ctx context.Context, org int64, previous *models.AlertConfiguration, receivers []*definitions.PostableApiReceiver) {
+	s, ok := moa.secrets.(*IntegrationSecretsService)
+	if !ok || previous == nil {
+		return
+	}
+	previousConfig, err := Load([]byte(previous.AlertmanagerConfiguration))
+	if err != nil {
+		return
+	}
+	uids := unreferencedSecretsKVIntegrations(previousConfig.AlertmanagerConfig.Receivers, receivers)
+	if err := s.DeleteIntegrationSecrets(ctx, org, uids...); err != nil {
+		moa.logger.Warn("Failed to delete the secrets of removed integrations from the secrets kvstore", "org", org, "error", err)
+	}
+}
+
 // assignReceiverConfigsUIDs assigns missing UUIDs to receiver configs.
 func assignReceiverConfigsUIDs(c []*definitions.PostableApiReceiver) error {
 	seenUIDs := make(map[string]struct{})
@@ -0,0 +1,145 @@
+package notifier
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// IntegrationSecretsService is a secrets.Service that reads the secure settings of the integrations that reference the
+// secrets kvstore from it, and delegates everything else to the secrets service it decorates. The secure settings of
+// the integrations migrated from legacy alerting with the store_secrets_in_secrets_kvstore setting are such references.
+type IntegrationSecretsService struct {
+	secrets.Service
+	kv  secretskvs.SecretsKVStore
+	log log.Logger
+}
+
+func NewIntegrationSecretsService(s secrets.Service, kv secretskvs.SecretsKVStore, l log.Logger) *IntegrationSecretsService {
+	return &IntegrationSecretsService{Service: s, kv: kv, log: l}
+}
+
+// Decrypt returns the secure setting that the payload references in the secrets kvstore, or decrypts the payload.
+func (s *IntegrationSecretsService) Decrypt(ctx context.Context, payload []byte) ([]byte, error) {
+	orgID, uid, key, ok := ualert.ParseSecretsKVReference(payload)
+	if !ok {
+		return s.Service.Decrypt(ctx, payload)
+	}
+
+	secureSettings, err := s.GetIntegrationSecrets(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secureSettings[key]
+	if !ok {
+		return nil, fmt.Errorf("secure setting %q of integration %s is not in the secrets kvstore", key, uid)
+	}
+	return []byte(value), nil
+}
+
+func (s *IntegrationSecretsService) DecryptJsonData(ctx context.Context, sjd map[string][]byte) (map[string]string, error) {
+	decrypted := make(map[string]string, len(sjd))
+	for k, v := range sjd {
+		value, err := s.Decrypt(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		decrypted[k] = string(value)
+	}
+	return decrypted, nil
+}
+
+func (s *IntegrationSecretsService) GetDecryptedValue(ctx context.Context, sjd map[string][]byte, key, fallback string) string {
+	orgID, uid, _, ok := ualert.ParseSecretsKVReference(sjd[key])
+	if !ok {
+		return s.Service.GetDecryptedValue(ctx, sjd, key, fallback)
+	}
+
+	value, err := s.Decrypt(ctx, sjd[key])
+	if err != nil {
+		s.log.Error("Failed to get integration secret from the secrets kvstore", "org", orgID, "integration", uid, "key", key, "error", err)
+		return fallback
+	}
+	return string(value)
+}
+
+// GetIntegrationSecrets returns the secure settings of the integration stored in the secrets kvstore.
+func (s *IntegrationSecretsService) GetIntegrationSecrets(ctx context.Context, orgID int64, uid string) (map[string]string, error) {
+	value, found, err := s.kv.Get(ctx, orgID, uid, ualert.IntegrationSecretType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the secrets of integration %s: %w", uid, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("the secrets of integration %s are not in the secrets kvstore", uid)
+	}
+	secureSettings := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &secureSettings); err != nil {
+		return nil, fmt.Errorf("failed to parse the secrets of integration %s: %w", uid, err)
+	}
+	return secureSettings, nil
+}
+
+// SetIntegrationSecrets stores the secure settings of the integration in the secrets kvstore.
+func (s *IntegrationSecretsService) SetIntegrationSecrets(ctx context.Context, orgID int64, uid string, secureSettings map[string]string) error {
+	b, err := json.Marshal(secureSettings)
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Set(ctx, orgID, uid, ualert.IntegrationSecretType, string(b)); err != nil {
+		return fmt.Errorf("failed to store the secrets of integration %s: %w", uid, err)
+	}
+	return nil
+}
+
+// DeleteIntegrationSecrets deletes the secure settings of the integrations of the organization from the secrets
+// kvstore.
+func (s *IntegrationSecretsService) DeleteIntegrationSecrets(ctx context.Context, orgID int64, uids ...string) error {
+	for _, uid := range uids {
+		if err := s.kv.Del(ctx, orgID, uid, ualert.IntegrationSecretType); err != nil {
+			return fmt.Errorf("failed to delete the secrets of integration %s: %w", uid, err)
+		}
+	}
+	return nil
+}
+
+// secretsKVIntegrations returns the UIDs of the integrations whose secure settings the receivers reference in the
+// secrets kvstore.
+func secretsKVIntegrations(receivers []*definitions.PostableApiReceiver) map[string]struct{} {
+	uids := make(map[string]struct{})
+	for _, r := range receivers {
+		for _, gr := range r.GrafanaManagedReceivers {
+			for _, v := range gr.SecureSettings {
+				decoded, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					continue
+				}
+				if _, uid, _, ok := ualert.ParseSecretsKVReference(decoded); ok {
+					uids[uid] = struct{}{}
+				}
+			}
+		}
+	}
+	return uids
+}
+
+// unreferencedSecretsKVIntegrations returns the UIDs of the integrations whose secure settings the previous receivers
+// reference in the secrets kvstore and the current ones do not.
+func unreferencedSecretsKVIntegrations(previous, current []*definitions.PostableApiReceiver) []string {
+	referenced := secretsKVIntegrations(current)
+	var uids []string
+	for uid := range secretsKVIntegrations(previous) {
+		if _, ok := referenced[uid]; !ok {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
@@ -80,6 +80,7 @@ type MultiOrgAlertmanager struct {
 	kvStore     kvstore.KVStore
 
 	decryptFn alertingNotify.GetDecryptedValueFn
+	secrets   secrets.Service
 
 	metrics *metrics.MultiOrgAlertmanager
 	ns      notifications.Service
@@ -100,6 +101,7 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 		orgStore:      orgStore,
 		kvStore:       kvStore,
 		decryptFn:     decryptFn,
+		secrets:       s,
 		metrics:       m,
 		ns:            ns,
 		peer:          &NilPeer{},
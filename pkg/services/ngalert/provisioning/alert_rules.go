@@ -128,6 +128,9 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, rule model
 	}
 	rule.Updated = time.Now()
 	err = service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := service.checkFolderLimitsTransactionCtx(ctx, rule.OrgID, rule.NamespaceUID, 1); err != nil {
+			return err
+		}
 		ids, err := service.ruleStore.InsertAlertRules(ctx, []models.AlertRule{
 			rule,
 		})
@@ -310,6 +313,13 @@ func (service *AlertRuleService) ReplaceRuleGroup(ctx context.Context, orgID int
 			}
 		}
 
+		// The rules moved to the folder by the updates above are already part of its usage.
+		if len(delta.New) > 0 || delta.MovedToNamespace() > 0 {
+			if err := service.checkFolderLimitsTransactionCtx(ctx, orgID, group.FolderUID, int64(len(delta.New))); err != nil {
+				return err
+			}
+		}
+
 		if len(delta.New) > 0 {
 			uids, err := service.ruleStore.InsertAlertRules(ctx, withoutNilAlertRules(delta.New))
 			if err != nil {
@@ -356,6 +366,11 @@ func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, rule model
 		if err != nil {
 			return err
 		}
+		if rule.NamespaceUID != storedRule.NamespaceUID {
+			if err := service.checkFolderLimitsTransactionCtx(ctx, rule.OrgID, rule.NamespaceUID, 0); err != nil {
+				return err
+			}
+		}
 		return service.provenanceStore.SetProvenance(ctx, &rule, rule.OrgID, provenance)
 	})
 	if err != nil {
@@ -397,6 +412,17 @@ func (service *AlertRuleService) checkLimitsTransactionCtx(ctx context.Context,
 	return nil
 }
 
+// checkFolderLimitsTransactionCtx checks whether count alert rules can be added to the folder without breaching the
+// alert rule limits of the folder and its parents. The rules already saved in the folder by the current transaction
+// are part of its usage.
+func (service *AlertRuleService) checkFolderLimitsTransactionCtx(ctx context.Context, orgID int64, folderUID string, count int64) error {
+	return service.quotas.CheckFolderQuotaReached(ctx, models.QuotaTargetSrv, &quota.ScopeParameters{
+		OrgID:     orgID,
+		FolderUID: folderUID,
+		Count:     count,
+	})
+}
+
 // deleteRules deletes a set of target rules and associated data, while checking for database consistency.
 func (service *AlertRuleService) deleteRules(ctx context.Context, orgID int64, targets ...*models.AlertRule) error {
 	uids := make([]string, 0, len(targets))
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/expr"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -517,6 +519,62 @@ func TestAlertRuleService(t *testing.T) {
 
 		require.ErrorIs(t, err, models.ErrQuotaReached)
 	})
+
+	t.Run("folder quota met causes create to be rejected", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		checker := &MockQuotaChecker{}
+		checker.EXPECT().FolderLimitExceeded()
+		ruleService.quotas = checker
+
+		_, err := ruleService.CreateAlertRule(context.Background(), dummyRule("test#2", orgID), models.ProvenanceNone, 0)
+
+		require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+	})
+
+	t.Run("folder quota met causes group write to be rejected", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		checker := &MockQuotaChecker{}
+		checker.EXPECT().FolderLimitExceeded()
+		ruleService.quotas = checker
+
+		group := createDummyGroup("folder-quota-reached", 1)
+		err := ruleService.ReplaceRuleGroup(context.Background(), 1, group, 0, models.ProvenanceAPI)
+
+		require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+	})
+
+	t.Run("folder quota is checked for all the rules added to the group", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		checker := &MockQuotaChecker{}
+		checker.EXPECT().CheckQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		checker.EXPECT().CheckFolderQuotaReached(mock.Anything, mock.Anything, mock.MatchedBy(func(p *quota.ScopeParameters) bool {
+			return p.FolderUID == "my-namespace" && p.Count == 3
+		})).Return(quota.ErrFolderQuotaReached)
+		ruleService.quotas = checker
+
+		group := createDummyGroup("folder-quota-count", 1)
+		group.Rules = append(group.Rules, dummyRule("folder-quota-count-rule-2", 1), dummyRule("folder-quota-count-rule-3", 1))
+		err := ruleService.ReplaceRuleGroup(context.Background(), 1, group, 0, models.ProvenanceAPI)
+
+		require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+	})
+
+	t.Run("folder quota met causes rule move to be rejected", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		rule, err := ruleService.CreateAlertRule(context.Background(), dummyRule("folder-quota-move", orgID), models.ProvenanceNone, 0)
+		require.NoError(t, err)
+
+		checker := &MockQuotaChecker{}
+		checker.EXPECT().CheckFolderQuotaReached(mock.Anything, mock.Anything, mock.MatchedBy(func(p *quota.ScopeParameters) bool {
+			return p.FolderUID == "other-namespace" && p.Count == 0
+		})).Return(quota.ErrFolderQuotaReached)
+		ruleService.quotas = checker
+
+		rule.NamespaceUID = "other-namespace"
+		_, err = ruleService.UpdateAlertRule(context.Background(), rule, models.ProvenanceNone)
+
+		require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+	})
 }
 
 func TestCreateAlertRule(t *testing.T) {
@@ -306,7 +306,7 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 	if err != nil {
 		return err
 	}
-	return ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
+	err = ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
 		err = PersistConfig(ctx, ecp.amStore, &models.SaveAlertmanagerConfigurationCmd{
 			AlertmanagerConfiguration: string(data),
 			FetchedConfigurationHash:  revision.concurrencyToken,
@@ -324,6 +324,12 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 		contactPoint.Provenance = string(provenance)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	// The secure settings are all encrypted again, none references the secrets kvstore anymore.
+	ecp.deleteIntegrationSecrets(ctx, orgID, mergedReceiver.UID)
+	return nil
 }
 
 func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID int64, uid string) error {
@@ -359,7 +365,7 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 	if err != nil {
 		return err
 	}
-	return ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
+	err = ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
 		target := &apimodels.EmbeddedContactPoint{
 			UID: uid,
 		}
@@ -375,6 +381,29 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 			OrgID:                     orgID,
 		})
 	})
+	if err != nil {
+		return err
+	}
+	ecp.deleteIntegrationSecrets(ctx, orgID, uid)
+	return nil
+}
+
+// integrationSecretsDeleter is implemented by the secrets services that store the secure settings of contact points
+// outside of the configuration, in the secrets kvstore.
+type integrationSecretsDeleter interface {
+	DeleteIntegrationSecrets(ctx context.Context, orgID int64, uids ...string) error
+}
+
+// deleteIntegrationSecrets deletes the secure settings of the integration from the secrets kvstore, if the secrets
+// service stores them there. Failures are logged, since the configuration no longer references them.
+func (ecp *ContactPointService) deleteIntegrationSecrets(ctx context.Context, orgID int64, uid string) {
+	deleter, ok := ecp.encryptionService.(integrationSecretsDeleter)
+	if !ok {
+		return
+	}
+	if err := deleter.DeleteIntegrationSecrets(ctx, orgID, uid); err != nil {
+		ecp.log.Warn("Failed to delete the secrets of the contact point from the secrets kvstore", "org", orgID, "integrationUid", uid, "error", err)
+	}
 }
 
 func isContactPointInUse(name string, routes []*apimodels.Route) bool {
@@ -23,6 +23,45 @@ func (_m *MockQuotaChecker) EXPECT() *MockQuotaChecker_Expecter {
 	return &MockQuotaChecker_Expecter{mock: &_m.Mock}
 }
 
+// CheckFolderQuotaReached provides a mock function with given fields: ctx, target, scopeParams
+func (_m *MockQuotaChecker) CheckFolderQuotaReached(ctx context.Context, target quota.TargetSrv, scopeParams *quota.ScopeParameters) error {
+	ret := _m.Called(ctx, target, scopeParams)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, quota.TargetSrv, *quota.ScopeParameters) error); ok {
+		r0 = rf(ctx, target, scopeParams)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockQuotaChecker_CheckFolderQuotaReached_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckFolderQuotaReached'
+type MockQuotaChecker_CheckFolderQuotaReached_Call struct {
+	*mock.Call
+}
+
+// CheckFolderQuotaReached is a helper method to define mock.On call
+//   - ctx context.Context
+//   - target quota.TargetSrv
+//   - scopeParams *quota.ScopeParameters
+func (_e *MockQuotaChecker_Expecter) CheckFolderQuotaReached(ctx any, target any, scopeParams any) *MockQuotaChecker_CheckFolderQuotaReached_Call {
+	return &MockQuotaChecker_CheckFolderQuotaReached_Call{Call: _e.mock.On("CheckFolderQuotaReached", ctx, target, scopeParams)}
+}
+
+func (_c *MockQuotaChecker_CheckFolderQuotaReached_Call) Run(run func(ctx context.Context, target quota.TargetSrv, scopeParams *quota.ScopeParameters)) *MockQuotaChecker_CheckFolderQuotaReached_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(quota.TargetSrv), args[2].(*quota.ScopeParameters))
+	})
+	return _c
+}
+
+func (_c *MockQuotaChecker_CheckFolderQuotaReached_Call) Return(_a0 error) *MockQuotaChecker_CheckFolderQuotaReached_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 // CheckQuotaReached provides a mock function with given fields: ctx, target, scopeParams
 func (_m *MockQuotaChecker) CheckQuotaReached(ctx context.Context, target quota.TargetSrv, scopeParams *quota.ScopeParameters) (bool, error) {
 	ret := _m.Called(ctx, target, scopeParams)
@@ -9,6 +9,7 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/quota"
 )
 
 const defaultAlertmanagerConfigJSON = `
@@ -178,10 +179,18 @@ func (m *MockProvisioningStore_Expecter) SaveSucceeds() *MockProvisioningStore_E
 
 func (m *MockQuotaChecker_Expecter) LimitOK() *MockQuotaChecker_Expecter {
 	m.CheckQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	m.CheckFolderQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	return m
 }
 
 func (m *MockQuotaChecker_Expecter) LimitExceeded() *MockQuotaChecker_Expecter {
 	m.CheckQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	m.CheckFolderQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return m
+}
+
+func (m *MockQuotaChecker_Expecter) FolderLimitExceeded() *MockQuotaChecker_Expecter {
+	m.CheckQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	m.CheckFolderQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(quota.ErrFolderQuotaReached)
 	return m
 }
@@ -0,0 +1,101 @@
+package ngalert
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// rotateMigratedSecrets rotates the data keys and re-encrypts the secure settings of all contact points with a new
+// data key, if the migration from legacy alerting requested it with the rotate_secrets_data_key setting. Every
+// re-encrypted value is decrypted again and compared to the original before the configuration is saved. The request is
+// kept if any configuration fails, so that the rotation is retried on the next start.
+func (ng *AlertNG) rotateMigratedSecrets(ctx context.Context) error {
+	_, requested, err := ng.KVStore.Get(ctx, 0, ualert.RotateSecretsKVNamespace, ualert.RotateSecretsKVKey)
+	if err != nil {
+		return fmt.Errorf("failed to check for secrets rotation request: %w", err)
+	}
+	if !requested {
+		return nil
+	}
+
+	ng.Log.Info("Rotating data keys and re-encrypting contact point secrets as requested by the migration")
+	if err := ng.SecretsService.RotateDataKeys(ctx); err != nil {
+		return fmt.Errorf("failed to rotate data keys: %w", err)
+	}
+
+	configs, err := ng.store.GetAllLatestAlertmanagerConfiguration(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get Alertmanager configurations: %w", err)
+	}
+
+	var failed int
+	for _, cfg := range configs {
+		if err := ng.reEncryptConfiguration(ctx, cfg); err != nil {
+			ng.Log.Error("Failed to re-encrypt contact point secrets", "org", cfg.OrgID, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to re-encrypt contact point secrets of %d organizations", failed)
+	}
+
+	return ng.KVStore.Del(ctx, 0, ualert.RotateSecretsKVNamespace, ualert.RotateSecretsKVKey)
+}
+
+// reEncryptConfiguration re-encrypts the secure settings of the contact points of the configuration with the current
+// data key and saves it.
+func (ng *AlertNG) reEncryptConfiguration(ctx context.Context, cfg *models.AlertConfiguration) error {
+	postableUserConfig, err := notifier.Load([]byte(cfg.AlertmanagerConfiguration))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	for _, receiver := range postableUserConfig.AlertmanagerConfig.Receivers {
+		for _, gmr := range receiver.GrafanaManagedReceivers {
+			for k, v := range gmr.SecureSettings {
+				decoded, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return fmt.Errorf("failed to decode secret %q of contact point %q: %w", k, gmr.Name, err)
+				}
+				if _, _, _, ok := ualert.ParseSecretsKVReference(decoded); ok {
+					// The secret is stored in the secrets kvstore, the configuration only references it.
+					continue
+				}
+				decrypted, err := ng.SecretsService.Decrypt(ctx, decoded)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt secret %q of contact point %q: %w", k, gmr.Name, err)
+				}
+				reencrypted, err := ng.SecretsService.Encrypt(ctx, decrypted, secrets.WithoutScope())
+				if err != nil {
+					return fmt.Errorf("failed to re-encrypt secret %q of contact point %q: %w", k, gmr.Name, err)
+				}
+				verified, err := ng.SecretsService.Decrypt(ctx, reencrypted)
+				if err != nil || !bytes.Equal(verified, decrypted) {
+					return fmt.Errorf("failed to verify re-encrypted secret %q of contact point %q: %v", k, gmr.Name, err)
+				}
+				gmr.SecureSettings[k] = base64.StdEncoding.EncodeToString(reencrypted)
+			}
+		}
+	}
+
+	b, err := json.Marshal(postableUserConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+
+	return ng.store.UpdateAlertmanagerConfiguration(ctx, &models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: string(b),
+		FetchedConfigurationHash:  cfg.ConfigurationHash,
+		ConfigurationVersion:      cfg.ConfigurationVersion,
+		Default:                   cfg.Default,
+		OrgID:                     cfg.OrgID,
+	})
+}
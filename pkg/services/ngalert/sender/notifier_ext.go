@@ -18,13 +18,14 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/prometheus/config"
 	"go.uber.org/atomic"
 )
 
 // ApplyConfig updates the status state as the new config requires.
-// Extension: add new parameter headers.
-func (n *Manager) ApplyConfig(conf *config.Config, headers map[string]map[string]string) error {
+// Extension: add new parameters headers and matchers.
+func (n *Manager) ApplyConfig(conf *config.Config, headers map[string]map[string]string, matchers map[string]amlabels.Matchers) error {
 	n.mtx.Lock()
 	defer n.mtx.Unlock()
 
@@ -42,6 +43,10 @@ func (n *Manager) ApplyConfig(conf *config.Config, headers map[string]map[string
 		if headers, ok := headers[k]; ok {
 			ams.headers = headers
 		}
+		// Extension: set the matchers of the alerts to the alertmanager set.
+		if matchers, ok := matchers[k]; ok {
+			ams.matchers = matchers
+		}
 		amSets[k] = ams
 	}
 
@@ -59,6 +64,9 @@ type alertmanagerSet struct {
 	// Extension: headers that should be used for the http requests to the alertmanagers.
 	headers map[string]string
 
+	// Extension: matchers of the alerts sent to the alertmanagers, all alerts are sent without them.
+	matchers amlabels.Matchers
+
 	metrics *alertMetrics
 
 	mtx        sync.RWMutex
@@ -97,9 +105,29 @@ func (n *Manager) sendAll(alerts ...*Alert) bool {
 
 		ams.mtx.RLock()
 
+		// Extension: only send the alerts that match the matchers of the set, the payload is not shared with the
+		// other sets then.
+		alerts := alerts
+		if len(ams.matchers) > 0 {
+			alerts = filterAlerts(alerts, ams.matchers)
+			if len(alerts) == 0 {
+				ams.mtx.RUnlock()
+				continue
+			}
+			payload, err = marshalAlerts(ams.cfg.APIVersion, alerts)
+			if err != nil {
+				level.Error(n.logger).Log("msg", "Encoding alerts for Alertmanager failed", "err", err)
+				ams.mtx.RUnlock()
+				return false
+			}
+		}
+
 		switch ams.cfg.APIVersion {
 		case config.AlertmanagerAPIVersionV1:
 			{
+				if payload != nil {
+					break
+				}
 				if v1Payload == nil {
 					v1Payload, err = json.Marshal(alerts)
 					if err != nil {
@@ -113,6 +141,9 @@ func (n *Manager) sendAll(alerts ...*Alert) bool {
 			}
 		case config.AlertmanagerAPIVersionV2:
 			{
+				if payload != nil {
+					break
+				}
 				if v2Payload == nil {
 					openAPIAlerts := alertsToOpenAPIAlerts(alerts)
 
@@ -166,6 +197,32 @@ func (n *Manager) sendAll(alerts ...*Alert) bool {
 	return numSuccess.Load() > 0
 }
 
+// Extension: filterAlerts returns the alerts whose labels match all the matchers.
+func filterAlerts(alerts []*Alert, matchers amlabels.Matchers) []*Alert {
+	filtered := make([]*Alert, 0, len(alerts))
+	for _, a := range alerts {
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(a.Labels.Get(m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// Extension: marshalAlerts encodes the alerts for the Alertmanager API version.
+func marshalAlerts(version config.AlertmanagerAPIVersion, alerts []*Alert) ([]byte, error) {
+	if version == config.AlertmanagerAPIVersionV1 {
+		return json.Marshal(alerts)
+	}
+	return json.Marshal(alertsToOpenAPIAlerts(alerts))
+}
+
 // Extension: added headers parameter.
 func (n *Manager) sendOne(ctx context.Context, c *http.Client, url string, b []byte, headers map[string]string) error {
 	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
 
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -236,6 +237,17 @@ func (d *AlertsRouter) alertmanagersFromDatasources(orgID int64) ([]externalAMcf
 				"error", err)
 			continue
 		}
+		var matchers amlabels.Matchers
+		if m := ds.JsonData.Get(definitions.GrafanaManagedAlertsMatchers).MustString(); m != "" {
+			matchers, err = amlabels.ParseMatchers(m)
+			if err != nil {
+				d.logger.Error("Failed to parse the matchers of the alerts for external alertmanager",
+					"org", ds.OrgID,
+					"uid", ds.UID,
+					"error", err)
+				continue
+			}
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 		headers, err := d.datasourceService.CustomHeaders(ctx, ds)
 		cancel()
@@ -247,8 +259,9 @@ func (d *AlertsRouter) alertmanagersFromDatasources(orgID int64) ([]externalAMcf
 			continue
 		}
 		alertmanagers = append(alertmanagers, externalAMcfg{
-			amURL:   amURL,
-			headers: headers,
+			amURL:    amURL,
+			headers:  headers,
+			matchers: matchers,
 		})
 	}
 	return alertmanagers, nil
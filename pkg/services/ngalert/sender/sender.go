@@ -13,6 +13,7 @@ import (
 	"unicode"
 
 	"github.com/prometheus/alertmanager/api/v2/models"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/client_golang/prometheus"
 	common_config "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
@@ -43,10 +44,12 @@ type ExternalAlertmanager struct {
 type externalAMcfg struct {
 	amURL   string
 	headers map[string]string
+	// matchers restrict the alerts sent to the Alertmanager, it receives all alerts without them.
+	matchers amlabels.Matchers
 }
 
 func (cfg *externalAMcfg) SHA256() string {
-	return asSHA256([]string{cfg.headerString(), cfg.amURL})
+	return asSHA256([]string{cfg.headerString(), cfg.amURL, cfg.matchers.String()})
 }
 
 // headersString transforms all the headers in a sorted way as a
@@ -90,7 +93,7 @@ func NewExternalAlertmanagerSender() *ExternalAlertmanager {
 
 // ApplyConfig syncs a configuration with the sender.
 func (s *ExternalAlertmanager) ApplyConfig(orgId, id int64, alertmanagers []externalAMcfg) error {
-	notifierCfg, headers, err := buildNotifierConfig(alertmanagers)
+	notifierCfg, headers, matchers, err := buildNotifierConfig(alertmanagers)
 	if err != nil {
 		return err
 	}
@@ -98,7 +101,7 @@ func (s *ExternalAlertmanager) ApplyConfig(orgId, id int64, alertmanagers []exte
 	s.logger = s.logger.New("org", orgId, "cfg", id)
 
 	s.logger.Info("Synchronizing config with external Alertmanager group")
-	if err := s.manager.ApplyConfig(notifierCfg, headers); err != nil {
+	if err := s.manager.ApplyConfig(notifierCfg, headers, matchers); err != nil {
 		return err
 	}
 
@@ -160,13 +163,14 @@ func (s *ExternalAlertmanager) DroppedAlertmanagers() []*url.URL {
 	return s.manager.DroppedAlertmanagers()
 }
 
-func buildNotifierConfig(alertmanagers []externalAMcfg) (*config.Config, map[string]map[string]string, error) {
+func buildNotifierConfig(alertmanagers []externalAMcfg) (*config.Config, map[string]map[string]string, map[string]amlabels.Matchers, error) {
 	amConfigs := make([]*config.AlertmanagerConfig, 0, len(alertmanagers))
 	headers := map[string]map[string]string{}
+	matchers := map[string]amlabels.Matchers{}
 	for i, am := range alertmanagers {
 		u, err := url.Parse(am.amURL)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		sdConfig := discovery.Configs{
@@ -190,6 +194,9 @@ func buildNotifierConfig(alertmanagers []externalAMcfg) (*config.Config, map[str
 			// so we can use it later on when working with the alertmanager config map.
 			headers[fmt.Sprintf("config-%d", i)] = am.headers
 		}
+		if len(am.matchers) > 0 {
+			matchers[fmt.Sprintf("config-%d", i)] = am.matchers
+		}
 
 		// Check the URL for basic authentication information first
 		if u.User != nil {
@@ -210,7 +217,7 @@ func buildNotifierConfig(alertmanagers []externalAMcfg) (*config.Config, map[str
 		},
 	}
 
-	return notifierConfig, headers, nil
+	return notifierConfig, headers, matchers, nil
 }
 
 func (s *ExternalAlertmanager) alertToNotifierAlert(alert models.PostableAlert) *Alert {
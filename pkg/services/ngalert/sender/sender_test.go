@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/alertmanager/api/v2/models"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 )
@@ -101,3 +102,24 @@ func TestSanitizeLabelSet(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildNotifierConfigMatchers(t *testing.T) {
+	matchers, err := amlabels.ParseMatchers(`__legacy_alertmanager_1__="true"`)
+	require.NoError(t, err)
+
+	_, _, byConfig, err := buildNotifierConfig([]externalAMcfg{
+		{amURL: "http://localhost:9093"},
+		{amURL: "http://localhost:9094", matchers: matchers},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]amlabels.Matchers{"config-1": matchers}, byConfig)
+
+	alerts := []*Alert{
+		{Labels: labels.FromStrings("alertname", "a", "__legacy_alertmanager_1__", "true")},
+		{Labels: labels.FromStrings("alertname", "b")},
+		{Labels: labels.FromStrings("alertname", "c", "__legacy_alertmanager_2__", "true")},
+	}
+	filtered := filterAlerts(alerts, byConfig["config-1"])
+	require.Len(t, filtered, 1)
+	require.Equal(t, "a", filtered[0].Labels.Get("alertname"))
+}
@@ -460,7 +460,8 @@ func takeImage(ctx context.Context, s ImageCapturer, r *models.AlertRule) (*mode
 	if err != nil {
 		if errors.Is(err, screenshot.ErrScreenshotsUnavailable) ||
 			errors.Is(err, models.ErrNoDashboard) ||
-			errors.Is(err, models.ErrNoPanel) {
+			errors.Is(err, models.ErrNoPanel) ||
+			errors.Is(err, models.ErrScreenshotsDisabled) {
 			return nil, nil
 		}
 		return nil, err
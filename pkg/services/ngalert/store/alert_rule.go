@@ -327,9 +327,21 @@ func (st DBstore) CountInFolder(ctx context.Context, orgID int64, folderUID stri
 	return count, err
 }
 
+// CountInFolders returns the number of alert rules in the folders of the organisation.
+func (st DBstore) CountInFolders(ctx context.Context, orgID int64, folderUIDs []string) (int64, error) {
+	var count int64
+	var err error
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table("alert_rule").Where("org_id = ?", orgID).In("namespace_uid", folderUIDs)
+		count, err = q.Count()
+		return err
+	})
+	return count, err
+}
+
 // ListAlertRules is a handler for retrieving alert rules of specific organisation.
 func (st DBstore) ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (result ngmodels.RulesGroup, err error) {
-	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+	err = st.SQLStore.WithReadReplicaDbSession(ctx, func(sess *db.Session) error {
 		q := sess.Table("alert_rule")
 
 		if query.OrgID >= 0 {
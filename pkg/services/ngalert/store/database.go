@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
@@ -41,11 +42,13 @@ type DBstore struct {
 	FolderService    folder.Service
 	AccessControl    accesscontrol.AccessControl
 	DashboardService dashboards.DashboardService
+	// ServerLock serializes the migrations of organizations started by all instances.
+	ServerLock *serverlock.ServerLockService
 }
 
 func ProvideDBStore(
 	cfg *setting.Cfg, featureToggles featuremgmt.FeatureToggles, sqlstore db.DB, folderService folder.Service,
-	access accesscontrol.AccessControl, dashboards dashboards.DashboardService) (*DBstore, error) {
+	access accesscontrol.AccessControl, dashboards dashboards.DashboardService, serverLock *serverlock.ServerLockService) (*DBstore, error) {
 	store := DBstore{
 		Cfg:              cfg.UnifiedAlerting,
 		FeatureToggles:   featureToggles,
@@ -54,6 +57,7 @@ func ProvideDBStore(
 		FolderService:    folderService,
 		AccessControl:    access,
 		DashboardService: dashboards,
+		ServerLock:       serverLock,
 	}
 	if err := folderService.RegisterService(store); err != nil {
 		return nil, err
@@ -31,6 +31,17 @@ func (c *GroupDelta) IsEmpty() bool {
 	return len(c.Update)+len(c.New)+len(c.Delete) == 0
 }
 
+// MovedToNamespace returns the number of updated rules that are moved to the namespace of the group from another namespace.
+func (c *GroupDelta) MovedToNamespace() int {
+	count := 0
+	for _, update := range c.Update {
+		if update.Existing.NamespaceUID != c.GroupKey.NamespaceUID && update.New.NamespaceUID == c.GroupKey.NamespaceUID {
+			count++
+		}
+	}
+	return count
+}
+
 type RuleReader interface {
 	ListAlertRules(ctx context.Context, query *models.ListAlertRulesQuery) (models.RulesGroup, error)
 	GetAlertRulesGroupByRuleUID(ctx context.Context, query *models.GetAlertRulesGroupByRuleUIDQuery) ([]*models.AlertRule, error)
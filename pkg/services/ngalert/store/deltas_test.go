@@ -97,6 +97,7 @@ func TestCalculateChanges(t *testing.T) {
 		}
 		require.Empty(t, changes.Delete)
 		require.Empty(t, changes.New)
+		require.Zero(t, changes.MovedToNamespace())
 
 		require.Contains(t, changes.AffectedGroups, groupKey)
 		require.Equal(t, models.RulesGroup(inDatabase), changes.AffectedGroups[groupKey])
@@ -220,6 +221,7 @@ func TestCalculateChanges(t *testing.T) {
 		require.Empty(t, changes.Delete)
 		require.Empty(t, changes.New)
 		require.Len(t, changes.Update, len(submitted))
+		require.Equal(t, len(submitted), changes.MovedToNamespace())
 		for _, update := range changes.Update {
 			require.NotNil(t, update.Existing)
 			require.Equal(t, update.Existing.UID, update.New.UID)
@@ -0,0 +1,342 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// MigrationStore is the database interface to the records kept by the migration from legacy alerting.
+type MigrationStore interface {
+	// ListMigrationAuditEntries returns the audit entries of the organization that match the query, oldest first.
+	ListMigrationAuditEntries(ctx context.Context, query *models.ListMigrationAuditEntriesQuery) ([]*models.MigrationAuditEntry, error)
+	// ListMigrationMappings returns the mappings of legacy alerts and notification channels of the organization that match the query.
+	ListMigrationMappings(ctx context.Context, query *models.ListMigrationMappingsQuery) ([]*models.MigrationMapping, error)
+	// GetMigrationOrgStatus returns the migration state of the organization. An organization without state is not migrated.
+	GetMigrationOrgStatus(ctx context.Context, orgID int64) (*models.MigrationOrgStatus, error)
+	// ListMigratedResources returns the resources that the migration created for the organization and that were not
+	// deleted since, oldest first. They are the resources that RevertOrgMigration deletes.
+	ListMigratedResources(ctx context.Context, orgID int64) ([]*models.MigratedResource, error)
+	// MigrateOrg removes the unified alerting data of the organization and migrates its legacy alerts again.
+	MigrateOrg(ctx context.Context, orgID int64) error
+	// RevertOrgMigration removes the unified alerting data of the organization.
+	RevertOrgMigration(ctx context.Context, orgID int64) error
+	// PartiallyRevertOrgMigration removes the unified alerting resources that the migration created for the
+	// organization, keeping those created or edited by users since.
+	PartiallyRevertOrgMigration(ctx context.Context, orgID int64) error
+	// ActivateOrgMigration resumes the alert rules of an organization migrated in shadow mode whose legacy alert was
+	// not paused, and returns the number of resumed rules. It clears the shadow mode of the organization in the same
+	// transaction, which stops the legacy engine from evaluating its alerts.
+	ActivateOrgMigration(ctx context.Context, orgID int64) (int, error)
+	// ResumeMigratedRules resumes the alert rules of the organization that the migration created paused although their
+	// legacy alert was not paused, identified by the ualert.PausedByMigrationLabel label, and returns their number.
+	ResumeMigratedRules(ctx context.Context, orgID int64) (int, error)
+	// SetMigrationContactPointTests records the results of the test notifications sent through the migrated contact
+	// points of the organization in its migration state.
+	SetMigrationContactPointTests(ctx context.Context, orgID int64, tests []models.MigrationContactPointTest) error
+	// ListOrgAdminEmails returns the email addresses of the enabled admins of the organization, to which the report of
+	// its migration is sent.
+	ListOrgAdminEmails(ctx context.Context, orgID int64) ([]string, error)
+	// PreviewOrgNotificationPolicies returns the Alertmanager configuration that the migration would write for the
+	// organization from its current legacy alerts and notification channels, without writing anything.
+	PreviewOrgNotificationPolicies(ctx context.Context, orgID int64) (*ualert.PostableUserConfig, error)
+}
+
+// ErrMigrationNotInShadowMode is returned when activating the migration of an organization that is not migrated in shadow mode.
+var ErrMigrationNotInShadowMode = errors.New("organization is not migrated in shadow mode")
+
+// liveMigrationAuditCreates is the condition on the alert_migration_audit table aliased a that selects the resources
+// created by the migration of an organization and not deleted since. Its arguments are the organization ID and the
+// create and delete actions.
+const liveMigrationAuditCreates = `a.org_id = ? AND a.action = ? AND NOT EXISTS (
+	SELECT 1 FROM alert_migration_audit d WHERE d.action = ? AND d.org_id = a.org_id
+		AND d.resource_type = a.resource_type AND d.resource_uid = a.resource_uid AND d.id > a.id
+)`
+
+// ErrOrgMigrationInProgress is returned when an organization is migrated or reverted while the migration of an
+// organization started by any instance is running.
+var ErrOrgMigrationInProgress = errors.New("a migration of an organization is already in progress")
+
+const (
+	// orgMigrationLockName is the server lock held while an organization is migrated or reverted, so that the
+	// migrations of organizations started by all instances are serialized. The migrations at startup are excluded by
+	// the database lock of the migrator if migration locking is enabled.
+	orgMigrationLockName = "alerting_org_migration"
+	// orgMigrationLockTimeout is how long the server lock is held at most, if the instance holding it stopped.
+	orgMigrationLockTimeout = time.Hour
+)
+
+func (st DBstore) ListMigrationAuditEntries(ctx context.Context, query *models.ListMigrationAuditEntriesQuery) ([]*models.MigrationAuditEntry, error) {
+	var result []*models.MigrationAuditEntry
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table("alert_migration_audit").Where("org_id = ?", query.OrgID)
+		if query.Action != "" {
+			q = q.Where("action = ?", query.Action)
+		}
+		if query.ResourceType != "" {
+			q = q.Where("resource_type = ?", query.ResourceType)
+		}
+		if query.ResourceUID != "" {
+			q = q.Where("resource_uid = ?", query.ResourceUID)
+		}
+		if query.LegacyID != 0 {
+			q = q.Where("legacy_id = ?", query.LegacyID)
+		}
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		return q.Asc("id").Find(&result)
+	})
+	return result, err
+}
+
+func (st DBstore) ListMigrationMappings(ctx context.Context, query *models.ListMigrationMappingsQuery) ([]*models.MigrationMapping, error) {
+	var result []*models.MigrationMapping
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table("alert_migration_mapping").Where("org_id = ?", query.OrgID)
+		if query.LegacyType != "" {
+			q = q.Where("legacy_type = ?", query.LegacyType)
+		}
+		if query.LegacyID != 0 {
+			q = q.Where("legacy_id = ?", query.LegacyID)
+		}
+		if query.LegacyUID != "" {
+			q = q.Where("legacy_uid = ?", query.LegacyUID)
+		}
+		return q.Asc("legacy_type", "legacy_id").Find(&result)
+	})
+	return result, err
+}
+
+func (st DBstore) GetMigrationOrgStatus(ctx context.Context, orgID int64) (*models.MigrationOrgStatus, error) {
+	result := &models.MigrationOrgStatus{
+		MigrationOrgState: models.MigrationOrgState{OrgID: orgID},
+		Created:           make(map[string]int64),
+	}
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Where("org_id = ?", orgID).Get(&result.MigrationOrgState); err != nil {
+			return err
+		}
+
+		var counts []struct {
+			ResourceType string `xorm:"resource_type"`
+			Count        int64  `xorm:"count"`
+		}
+		err := sess.SQL(`SELECT a.resource_type, COUNT(*) AS count FROM alert_migration_audit a
+			WHERE `+liveMigrationAuditCreates+` GROUP BY a.resource_type`, orgID, models.MigrationAuditActionCreate, models.MigrationAuditActionDelete).Find(&counts)
+		if err != nil {
+			return err
+		}
+		for _, c := range counts {
+			result.Created[c.ResourceType] = c.Count
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (st DBstore) ListMigratedResources(ctx context.Context, orgID int64) ([]*models.MigratedResource, error) {
+	var result []*models.MigratedResource
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var entries []*models.MigrationAuditEntry
+		err := sess.SQL(`SELECT a.* FROM alert_migration_audit a WHERE `+liveMigrationAuditCreates+` ORDER BY a.id`,
+			orgID, models.MigrationAuditActionCreate, models.MigrationAuditActionDelete).Find(&entries)
+		if err != nil {
+			return err
+		}
+
+		names, err := migratedResourceNames(sess, orgID)
+		if err != nil {
+			return err
+		}
+		result = make([]*models.MigratedResource, 0, len(entries))
+		for _, e := range entries {
+			result = append(result, &models.MigratedResource{MigrationAuditEntry: *e, Name: names[e.ResourceType][e.ResourceUID]})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// migratedResourceNames returns the titles of the alert rules and folders, and the names of the contact points of
+// the integrations created by the migration, of the organization by resource type and UID.
+func migratedResourceNames(sess *db.Session, orgID int64) (map[string]map[string]string, error) {
+	queries := []struct {
+		resourceType string
+		sql          string
+		args         []any
+	}{
+		{models.MigrationAuditResourceAlertRule, "SELECT uid, title AS name FROM alert_rule WHERE org_id = ?", []any{orgID}},
+		{models.MigrationAuditResourceFolder, "SELECT uid, title AS name FROM dashboard WHERE org_id = ? AND is_folder = ?", []any{orgID, true}},
+		{models.MigrationAuditResourceReceiver, "SELECT uid, name FROM alert_migration_mapping WHERE org_id = ? AND legacy_type = ?", []any{orgID, models.MigrationMappingLegacyChannel}},
+	}
+
+	result := make(map[string]map[string]string, len(queries))
+	for _, q := range queries {
+		var rows []struct {
+			UID  string `xorm:"uid"`
+			Name string `xorm:"name"`
+		}
+		if err := sess.SQL(q.sql, q.args...).Find(&rows); err != nil {
+			return nil, fmt.Errorf("failed to read names of %s resources: %w", q.resourceType, err)
+		}
+		names := make(map[string]string, len(rows))
+		for _, r := range rows {
+			names[r.UID] = r.Name
+		}
+		result[q.resourceType] = names
+	}
+	return result, nil
+}
+
+func (st DBstore) MigrateOrg(ctx context.Context, orgID int64) error {
+	return st.runOrgMigration(ctx, orgID, false)
+}
+
+func (st DBstore) RevertOrgMigration(ctx context.Context, orgID int64) error {
+	return st.runOrgMigration(ctx, orgID, true)
+}
+
+func (st DBstore) PartiallyRevertOrgMigration(ctx context.Context, orgID int64) error {
+	return st.runOrgMigrations(ctx, func(mg *migrator.Migrator) {
+		ualert.AddOrgPartialRevert(mg, orgID)
+	})
+}
+
+func (st DBstore) ActivateOrgMigration(ctx context.Context, orgID int64) (int, error) {
+	var resumed int
+	err := st.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+		err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+			state := models.MigrationOrgState{}
+			found, err := sess.Where("org_id = ?", orgID).Get(&state)
+			if err != nil {
+				return err
+			}
+			if !found || !state.Migrated || !state.Shadow {
+				return ErrMigrationNotInShadowMode
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		resumed, err = st.ResumeMigratedRules(ctx, orgID)
+		if err != nil {
+			return err
+		}
+
+		return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Table("alert_migration_org_state").Where("org_id = ?", orgID).Cols("shadow").Update(&models.MigrationOrgState{Shadow: false})
+			return err
+		})
+	})
+	return resumed, err
+}
+
+func (st DBstore) ResumeMigratedRules(ctx context.Context, orgID int64) (int, error) {
+	rules, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		return 0, err
+	}
+
+	updates := make([]models.UpdateRule, 0)
+	for _, rule := range rules {
+		if _, ok := rule.Labels[ualert.PausedByMigrationLabel]; !ok {
+			continue
+		}
+		r := models.CopyRule(rule)
+		r.IsPaused = false
+		delete(r.Labels, ualert.PausedByMigrationLabel)
+		updates = append(updates, models.UpdateRule{Existing: rule, New: *r})
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	if err := st.UpdateAlertRules(ctx, updates); err != nil {
+		return 0, err
+	}
+	return len(updates), nil
+}
+
+func (st DBstore) SetMigrationContactPointTests(ctx context.Context, orgID int64, tests []models.MigrationContactPointTest) error {
+	b, err := json.Marshal(tests)
+	if err != nil {
+		return err
+	}
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Table("alert_migration_org_state").Where("org_id = ?", orgID).Cols("contact_point_tests").Update(&models.MigrationOrgState{ContactPointTests: string(b)})
+		return err
+	})
+}
+
+func (st DBstore) ListOrgAdminEmails(ctx context.Context, orgID int64) ([]string, error) {
+	var emails []string
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		userTable := st.SQLStore.GetDialect().Quote("user")
+		return sess.SQL(`SELECT u.email FROM org_user ou INNER JOIN `+userTable+` u ON u.id = ou.user_id
+			WHERE ou.org_id = ? AND ou.role = ? AND u.is_disabled = ? AND u.is_service_account = ? AND u.email <> ''
+			ORDER BY u.email`, orgID, org.RoleAdmin, false, false).Find(&emails)
+	})
+	return emails, err
+}
+
+func (st DBstore) PreviewOrgNotificationPolicies(ctx context.Context, orgID int64) (*ualert.PostableUserConfig, error) {
+	ss, ok := st.SQLStore.(*sqlstore.SQLStore)
+	if !ok {
+		return nil, errors.New("preview of the migration of an organization requires the SQL store")
+	}
+
+	var config *ualert.PostableUserConfig
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		config, err = ualert.PreviewOrgAlertmanagerConfig(sess.Session, st.SQLStore.GetDialect(), ss.Cfg, orgID)
+		return err
+	})
+	return config, err
+}
+
+func (st DBstore) runOrgMigration(ctx context.Context, orgID int64, revertOnly bool) error {
+	return st.runOrgMigrations(ctx, func(mg *migrator.Migrator) {
+		ualert.AddOrgMigration(mg, orgID, revertOnly)
+	})
+}
+
+// runOrgMigrations runs the migrations of a single organization added by add with a migrator of their own, holding the
+// server lock of the migrations of organizations. It returns ErrOrgMigrationInProgress if the lock is held.
+func (st DBstore) runOrgMigrations(ctx context.Context, add func(mg *migrator.Migrator)) error {
+	// Migrations depend on upstream xorm implementations
+	ss, ok := st.SQLStore.(*sqlstore.SQLStore)
+	if !ok {
+		return errors.New("migration of an organization requires the SQL store")
+	}
+	if st.ServerLock == nil {
+		return errors.New("migration of an organization requires the server lock service")
+	}
+
+	var err error
+	lockErr := st.ServerLock.LockExecuteAndRelease(ctx, orgMigrationLockName, orgMigrationLockTimeout, func(context.Context) {
+		mg := migrator.NewMigrator(ss.GetEngine(), ss.Cfg)
+		add(mg)
+		err = mg.Start(st.FeatureToggles.IsEnabled(featuremgmt.FlagMigrationLocking), ss.GetMigrationLockAttemptTimeout())
+	})
+	var exists *serverlock.ServerLockExistsError
+	if errors.As(lockErr, &exists) {
+		return ErrOrgMigrationInProgress
+	}
+	if lockErr != nil {
+		return fmt.Errorf("failed to lock migrations of organizations: %w", lockErr)
+	}
+	return err
+}
@@ -0,0 +1,275 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+func TestIntegrationListMigrationAuditEntries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	entries := []*models.MigrationAuditEntry{
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-1", LegacyID: 10, Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceReceiver, ResourceUID: "slack", LegacyID: 3, LegacyUID: "chan-3", Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionDelete, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-1", LegacyID: 10, Created: time.Now()},
+		{OrgID: 2, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-2", LegacyID: 11, Created: time.Now()},
+	}
+	require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, e := range entries {
+			if _, err := sess.Insert(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	t.Run("should list all entries of the organization", func(t *testing.T) {
+		result, err := dbstore.ListMigrationAuditEntries(ctx, &models.ListMigrationAuditEntriesQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+	})
+
+	t.Run("should filter by resource", func(t *testing.T) {
+		result, err := dbstore.ListMigrationAuditEntries(ctx, &models.ListMigrationAuditEntriesQuery{
+			OrgID:        1,
+			ResourceType: models.MigrationAuditResourceAlertRule,
+			ResourceUID:  "rule-1",
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Equal(t, models.MigrationAuditActionCreate, result[0].Action)
+		require.Equal(t, models.MigrationAuditActionDelete, result[1].Action)
+	})
+
+	t.Run("should filter by legacy ID", func(t *testing.T) {
+		result, err := dbstore.ListMigrationAuditEntries(ctx, &models.ListMigrationAuditEntriesQuery{OrgID: 1, LegacyID: 3})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, "chan-3", result[0].LegacyUID)
+	})
+}
+
+func TestIntegrationListMigrationMappings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	mappings := []*models.MigrationMapping{
+		{OrgID: 1, LegacyType: models.MigrationMappingLegacyAlert, LegacyID: 10, UID: "rule-1", Name: "alert 1"},
+		{OrgID: 1, LegacyType: models.MigrationMappingLegacyChannel, LegacyID: 3, LegacyUID: "chan-3", UID: "chan-3", Name: "slack"},
+		{OrgID: 2, LegacyType: models.MigrationMappingLegacyAlert, LegacyID: 11, UID: "rule-2", Name: "alert 2"},
+	}
+	require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, m := range mappings {
+			if _, err := sess.Insert(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	t.Run("should list all mappings of the organization", func(t *testing.T) {
+		result, err := dbstore.ListMigrationMappings(ctx, &models.ListMigrationMappingsQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+	})
+
+	t.Run("should filter by legacy alert ID", func(t *testing.T) {
+		result, err := dbstore.ListMigrationMappings(ctx, &models.ListMigrationMappingsQuery{
+			OrgID:      1,
+			LegacyType: models.MigrationMappingLegacyAlert,
+			LegacyID:   10,
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, "rule-1", result[0].UID)
+	})
+
+	t.Run("should filter by legacy channel UID", func(t *testing.T) {
+		result, err := dbstore.ListMigrationMappings(ctx, &models.ListMigrationMappingsQuery{OrgID: 1, LegacyUID: "chan-3"})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, "slack", result[0].Name)
+	})
+}
+
+func TestIntegrationActivateOrgMigration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(&models.MigrationOrgState{OrgID: 1, Migrated: true, GrafanaVersion: "10.1.0", Updated: time.Now()})
+		return err
+	}))
+
+	t.Run("should fail for an organization not migrated in shadow mode", func(t *testing.T) {
+		_, err := dbstore.ActivateOrgMigration(ctx, 1)
+		require.ErrorIs(t, err, store.ErrMigrationNotInShadowMode)
+	})
+
+	t.Run("should fail for an organization without state", func(t *testing.T) {
+		_, err := dbstore.ActivateOrgMigration(ctx, 2)
+		require.ErrorIs(t, err, store.ErrMigrationNotInShadowMode)
+	})
+
+	t.Run("should clear the shadow state", func(t *testing.T) {
+		require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(&models.MigrationOrgState{OrgID: 3, Migrated: true, GrafanaVersion: "10.1.0", Updated: time.Now(), Shadow: true})
+			return err
+		}))
+
+		resumed, err := dbstore.ActivateOrgMigration(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, 0, resumed)
+
+		status, err := dbstore.GetMigrationOrgStatus(ctx, 3)
+		require.NoError(t, err)
+		require.False(t, status.Shadow)
+	})
+}
+
+func TestIntegrationResumeMigratedRules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	paused := func(r *models.AlertRule) { r.IsPaused = true }
+	gen := func(orgID int64, mutators ...models.AlertRuleMutator) *models.AlertRule {
+		mutators = append([]models.AlertRuleMutator{models.WithOrgID(orgID), models.WithUniqueID(), models.WithInterval(baseIntervalSeconds * time.Second), paused}, mutators...)
+		return models.AlertRuleGen(mutators...)()
+	}
+	pausedByMigration := gen(1, models.WithLabel(ualert.PausedByMigrationLabel, "true"))
+	pausedByUser := gen(1)
+	otherOrg := gen(2, models.WithLabel(ualert.PausedByMigrationLabel, "true"))
+	require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, r := range []*models.AlertRule{pausedByMigration, pausedByUser, otherOrg} {
+			if _, err := sess.Table(models.AlertRule{}).InsertOne(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	resumed, err := dbstore.ResumeMigratedRules(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, resumed)
+
+	get := func(uid string, orgID int64) *models.AlertRule {
+		rule, err := dbstore.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{UID: uid, OrgID: orgID})
+		require.NoError(t, err)
+		return rule
+	}
+	rule := get(pausedByMigration.UID, 1)
+	require.False(t, rule.IsPaused)
+	require.NotContains(t, rule.Labels, ualert.PausedByMigrationLabel)
+	require.True(t, get(pausedByUser.UID, 1).IsPaused)
+	require.True(t, get(otherOrg.UID, 2).IsPaused)
+}
+
+func TestIntegrationGetMigrationOrgStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	state := &models.MigrationOrgState{OrgID: 1, Migrated: true, GrafanaVersion: "10.1.0", Errors: `["problem"]`, Updated: time.Now()}
+	entries := []*models.MigrationAuditEntry{
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-1", Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-2", Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceFolder, ResourceUID: "folder-1", Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionDelete, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-2", Created: time.Now()},
+	}
+	require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(state); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, err := sess.Insert(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	t.Run("should return the state and the live created resources", func(t *testing.T) {
+		result, err := dbstore.GetMigrationOrgStatus(ctx, 1)
+		require.NoError(t, err)
+		require.True(t, result.Migrated)
+		require.Equal(t, "10.1.0", result.GrafanaVersion)
+		require.Equal(t, map[string]int64{
+			models.MigrationAuditResourceAlertRule: 1,
+			models.MigrationAuditResourceFolder:    1,
+		}, result.Created)
+	})
+
+	t.Run("should return not migrated for an organization without state", func(t *testing.T) {
+		result, err := dbstore.GetMigrationOrgStatus(ctx, 2)
+		require.NoError(t, err)
+		require.False(t, result.Migrated)
+		require.Empty(t, result.Created)
+	})
+}
+
+func TestIntegrationListMigratedResources(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	entries := []*models.MigrationAuditEntry{
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceFolder, ResourceUID: "folder-1", LegacyID: 5, Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceReceiver, ResourceUID: "integration-1", LegacyID: 3, LegacyUID: "chan-3", Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-1", LegacyID: 10, Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-2", LegacyID: 11, Created: time.Now()},
+		{OrgID: 1, Action: models.MigrationAuditActionDelete, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-2", LegacyID: 11, Created: time.Now()},
+		{OrgID: 2, Action: models.MigrationAuditActionCreate, ResourceType: models.MigrationAuditResourceAlertRule, ResourceUID: "rule-3", LegacyID: 12, Created: time.Now()},
+	}
+	mapping := &models.MigrationMapping{OrgID: 1, LegacyType: models.MigrationMappingLegacyChannel, LegacyID: 3, LegacyUID: "chan-3", UID: "integration-1", Name: "slack"}
+	require.NoError(t, dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, e := range entries {
+			if _, err := sess.Insert(e); err != nil {
+				return err
+			}
+		}
+		_, err := sess.Insert(mapping)
+		return err
+	}))
+
+	result, err := dbstore.ListMigratedResources(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	require.Equal(t, "folder-1", result[0].ResourceUID)
+	require.Empty(t, result[0].Name)
+	require.Equal(t, "integration-1", result[1].ResourceUID)
+	require.Equal(t, "slack", result[1].Name)
+	require.Equal(t, "rule-1", result[2].ResourceUID)
+	require.Equal(t, int64(10), result[2].LegacyID)
+}
@@ -349,6 +349,20 @@ func (f *RuleStore) Count(ctx context.Context, orgID int64) (int64, error) {
 	return 0, nil
 }
 
+func (f *RuleStore) CountInFolders(ctx context.Context, orgID int64, folderUIDs []string) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var count int64
+	for _, r := range f.Rules[orgID] {
+		for _, uid := range folderUIDs {
+			if r.NamespaceUID == uid {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
 func (f *RuleStore) CountInFolder(ctx context.Context, orgID int64, folderUID string, u identity.Requester) (int64, error) {
 	return 0, nil
 }
@@ -42,6 +42,7 @@ func ProvideService(db db.DB, cfg *setting.Cfg, quotaService quota.Service) (org
 		TargetSrv:     quota.TargetSrv(org.QuotaTargetSrv),
 		DefaultLimits: defaultLimits,
 		Reporter:      s.Usage,
+		OrgsReporter:  s.UsageInOrgs,
 	}); err != nil {
 		return s, nil
 	}
@@ -52,6 +53,11 @@ func (s *Service) Usage(ctx context.Context, scopeParams *quota.ScopeParameters)
 	return s.store.Count(ctx, scopeParams)
 }
 
+// UsageInOrgs reports the number of users of every organization.
+func (s *Service) UsageInOrgs(ctx context.Context) (map[int64]*quota.Map, error) {
+	return s.store.CountUsersInOrgs(ctx)
+}
+
 func (s *Service) GetIDForNewUser(ctx context.Context, cmd org.GetOrgIDForNewUserCommand) (int64, error) {
 	var orga org.Org
 	if cmd.SkipOrgSetup {
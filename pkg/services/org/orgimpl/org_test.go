@@ -154,3 +154,7 @@ func (f *FakeOrgStore) RemoveOrgUser(ctx context.Context, cmd *org.RemoveOrgUser
 func (f *FakeOrgStore) Count(ctx context.Context, _ *quota.ScopeParameters) (*quota.Map, error) {
 	return nil, nil
 }
+
+func (f *FakeOrgStore) CountUsersInOrgs(ctx context.Context) (map[int64]*quota.Map, error) {
+	return nil, nil
+}
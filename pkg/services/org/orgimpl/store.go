@@ -46,6 +46,7 @@ type store interface {
 	RemoveOrgUser(context.Context, *org.RemoveOrgUserCommand) error
 
 	Count(context.Context, *quota.ScopeParameters) (*quota.Map, error)
+	CountUsersInOrgs(context.Context) (map[int64]*quota.Map, error)
 }
 
 type sqlStore struct {
@@ -239,6 +240,7 @@ func (ss *sqlStore) Delete(ctx context.Context, cmd *org.DeleteOrgCommand) error
 			"DELETE FROM alert WHERE org_id = ?",
 			"DELETE FROM annotation WHERE org_id = ?",
 			"DELETE FROM kv_store WHERE org_id = ?",
+			"DELETE FROM quota_folder WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -474,6 +476,37 @@ func (ss *sqlStore) Count(ctx context.Context, scopeParams *quota.ScopeParameter
 	return u, nil
 }
 
+// CountUsersInOrgs reports the number of users, service accounts excluded, of every organization.
+func (ss *sqlStore) CountUsersInOrgs(ctx context.Context) (map[int64]*quota.Map, error) {
+	type result struct {
+		OrgID int64 `xorm:"org_id"`
+		Count int64
+	}
+
+	var results []result
+	if err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := fmt.Sprintf("SELECT org_id, COUNT(*) AS count FROM org_user WHERE user_id IN (SELECT id AS user_id FROM %s WHERE is_service_account=%s) GROUP BY org_id",
+			ss.db.GetDialect().Quote("user"),
+			ss.db.GetDialect().BooleanStr(false),
+		)
+		return sess.SQL(rawSQL).Find(&results)
+	}); err != nil {
+		return nil, err
+	}
+
+	tag, err := quota.NewTag(quota.TargetSrv(org.QuotaTargetSrv), quota.Target(org.OrgUserQuotaTarget), quota.OrgScope)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[int64]*quota.Map, len(results))
+	for _, r := range results {
+		u := &quota.Map{}
+		u.Set(tag, r.Count)
+		usage[r.OrgID] = u
+	}
+	return usage, nil
+}
+
 func setUsingOrgInTransaction(sess *db.Session, userID int64, orgID int64) error {
 	user := user.User{
 		ID:    userID,
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/auth/identity"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/quota/quotaimpl"
 	"github.com/grafana/grafana/pkg/services/searchusers/sortopts"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -111,9 +112,29 @@ func TestIntegrationOrgDataAccess(t *testing.T) {
 		ac2 := &org.Org{ID: 22, Name: "ac2", Version: 1, Created: time.Now(), Updated: time.Now()}
 		_, err := orgStore.Insert(context.Background(), ac2)
 		require.NoError(t, err)
+		err = ss.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Table("quota_folder").Insert(&quota.FolderQuota{
+				OrgId:     ac2.ID,
+				FolderUid: "folder",
+				Target:    "dashboard",
+				Limit:     1,
+				Created:   time.Now(),
+				Updated:   time.Now(),
+			})
+			return err
+		})
+		require.NoError(t, err)
 		err = orgStore.Delete(context.Background(), &org.DeleteOrgCommand{ID: ac2.ID})
 		require.NoError(t, err)
 
+		err = ss.WithDbSession(context.Background(), func(sess *db.Session) error {
+			count, err := sess.Table("quota_folder").Where("org_id = ?", ac2.ID).Count()
+			require.NoError(t, err)
+			require.Zero(t, count)
+			return nil
+		})
+		require.NoError(t, err)
+
 		// TODO: this part of the test will be added when we move RemoveOrgUser to org store
 		// "Removing user from org should delete user completely if in no other org"
 		// // remove ac2 user from ac1 org
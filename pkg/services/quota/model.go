@@ -17,10 +17,22 @@ var ErrUsageFoundForTarget = errutil.NotFound("quota.missing-target-usage")
 var ErrTargetSrvConflict = errutil.BadRequest("quota.target-srv-conflict")
 var ErrDisabled = errutil.Forbidden("quota.disabled", errutil.WithPublicMessage("Quotas not enabled"))
 var ErrInvalidTagFormat = errutil.Internal("quota.invalid-invalid-tag-format")
+var ErrFolderQuotaReached = errutil.Forbidden("quota.folder-reached", errutil.WithPublicMessage("Folder quota reached"))
 
 type ScopeParameters struct {
 	OrgID  int64
 	UserID int64
+	// FolderUID is the folder the resource is saved in, for checking the folder scope.
+	FolderUID string
+	// FolderUIDs are the UIDs of a folder and its descendants, set by the quota service when it asks a reporter
+	// for the usage of the folder scope.
+	FolderUIDs []string
+	// Count is the number of resources about to be added to the folder, for checking the folder scope. The resources
+	// already saved in the folder, e.g. in the transaction checking the quota, are part of its usage.
+	Count int64
+	// MovedFolderUID is the folder whose subtree, holding the Count resources, is moved to FolderUID. The folders that
+	// already contain it are not checked, since the resources are part of their usage.
+	MovedFolderUID string
 }
 
 type Scope string
@@ -29,11 +41,13 @@ const (
 	GlobalScope Scope = "global"
 	OrgScope    Scope = "org"
 	UserScope   Scope = "user"
+	// FolderScope limits the number of resources in a folder and its subtree.
+	FolderScope Scope = "folder"
 )
 
 func (s Scope) Validate() error {
 	switch s {
-	case GlobalScope, OrgScope, UserScope:
+	case GlobalScope, OrgScope, UserScope, FolderScope:
 		return nil
 	default:
 		return ErrInvalidScope.Errorf("bad scope: %s", s)
@@ -190,29 +204,57 @@ type Quota struct {
 	Updated time.Time
 }
 
+// FolderQuota is a limit of the number of resources in a folder and its subtree.
+type FolderQuota struct {
+	Id        int64
+	OrgId     int64
+	FolderUid string
+	Target    string
+	Limit     int64
+	Created   time.Time
+	Updated   time.Time
+}
+
 type QuotaDTO struct {
-	OrgId   int64  `json:"org_id,omitempty"`
-	UserId  int64  `json:"user_id,omitempty"`
-	Target  string `json:"target"`
-	Limit   int64  `json:"limit"`
-	Used    int64  `json:"used"`
-	Service string `json:"-"`
-	Scope   string `json:"-"`
+	OrgId     int64  `json:"org_id,omitempty"`
+	UserId    int64  `json:"user_id,omitempty"`
+	FolderUID string `json:"folder_uid,omitempty"`
+	Target    string `json:"target"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+	Service   string `json:"-"`
+	Scope     string `json:"-"`
 }
 
 func (dto QuotaDTO) Tag() (Tag, error) {
 	return NewTag(TargetSrv(dto.Service), Target(dto.Target), Scope(dto.Scope))
 }
 
+// UsageReport is the usage and limit of the quota targets of the instance, and of each organization.
+type UsageReport struct {
+	Global []QuotaDTO       `json:"global"`
+	Orgs   []OrgUsageReport `json:"orgs"`
+}
+
+// OrgUsageReport is the usage and limit of the quota targets of an organization.
+type OrgUsageReport struct {
+	OrgID  int64      `json:"org_id"`
+	Quotas []QuotaDTO `json:"quotas"`
+}
+
 type UpdateQuotaCmd struct {
-	Target string `json:"target"`
-	Limit  int64  `json:"limit"`
-	OrgID  int64  `json:"-"`
-	UserID int64  `json:"-"`
+	Target    string `json:"target"`
+	Limit     int64  `json:"limit"`
+	OrgID     int64  `json:"-"`
+	UserID    int64  `json:"-"`
+	FolderUID string `json:"-"`
 }
 
 type NewUsageReporter struct {
 	TargetSrv     TargetSrv
 	DefaultLimits *Map
 	Reporter      UsageReporterFunc
+	// OrgsReporter optionally reports the usage of all organizations with a single query for the usage report,
+	// which otherwise calls Reporter for each organization.
+	OrgsReporter OrgsUsageReporterFunc
 }
@@ -14,11 +14,21 @@ type Service interface {
 	// Update overrides the quota for a specific scope (global, organization, user).
 	// If the cmd.OrgID is set, then the organization quota are updated.
 	// If the cmd.UseID is set, then the user quota are updated.
+	// If the cmd.FolderUID is set, then the folder quota of the organization are updated.
 	Update(ctx context.Context, cmd *UpdateQuotaCmd) error
 	// QuotaReached is called by the quota middleware for applying quota enforcement to API handlers
 	QuotaReached(c *contextmodel.ReqContext, targetSrv TargetSrv) (bool, error)
 	// CheckQuotaReached checks if the quota limitations have been reached for a specific service
 	CheckQuotaReached(ctx context.Context, targetSrv TargetSrv, scopeParams *ScopeParameters) (bool, error)
+	// CheckFolderQuotaReached checks the folder quota of a service for the folder in scopeParams.FolderUID and its
+	// ancestors, which include the folder in their subtree. It returns ErrFolderQuotaReached if adding
+	// scopeParams.Count resources would exceed one of them.
+	CheckFolderQuotaReached(ctx context.Context, targetSrv TargetSrv, scopeParams *ScopeParameters) error
+	// GetFolderQuotas returns the quota of the folder and the usage of its subtree.
+	GetFolderQuotas(ctx context.Context, orgID int64, folderUID string) ([]QuotaDTO, error)
+	// GetUsageReport returns the usage and limit of every target in the global scope, and in the scope of every
+	// organization.
+	GetUsageReport(ctx context.Context) (*UsageReport, error)
 	// DeleteQuotaForUser deletes custom quota limitations for the user
 	DeleteQuotaForUser(ctx context.Context, userID int64) error
 	// DeleteByOrg(ctx context.Context, orgID int64) error
@@ -28,3 +38,6 @@ type Service interface {
 }
 
 type UsageReporterFunc func(ctx context.Context, scopeParams *ScopeParameters) (*Map, error)
+
+// OrgsUsageReporterFunc returns the usage in the scope of every organization at once, keyed by organization ID.
+type OrgsUsageReporterFunc func(ctx context.Context) (map[int64]*Map, error)
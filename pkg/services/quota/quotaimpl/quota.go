@@ -2,6 +2,7 @@ package quotaimpl
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
@@ -32,6 +33,18 @@ func (s *serviceDisabled) CheckQuotaReached(ctx context.Context, targetSrv quota
 	return false, nil
 }
 
+func (s *serviceDisabled) CheckFolderQuotaReached(ctx context.Context, targetSrv quota.TargetSrv, scopeParams *quota.ScopeParameters) error {
+	return nil
+}
+
+func (s *serviceDisabled) GetFolderQuotas(ctx context.Context, orgID int64, folderUID string) ([]quota.QuotaDTO, error) {
+	return nil, quota.ErrDisabled
+}
+
+func (s *serviceDisabled) GetUsageReport(ctx context.Context) (*quota.UsageReport, error) {
+	return nil, quota.ErrDisabled
+}
+
 func (s *serviceDisabled) DeleteQuotaForUser(ctx context.Context, userID int64) error {
 	return nil
 }
@@ -45,8 +58,9 @@ type service struct {
 	Cfg    *setting.Cfg
 	Logger log.Logger
 
-	mutex     sync.RWMutex
-	reporters map[quota.TargetSrv]quota.UsageReporterFunc
+	mutex         sync.RWMutex
+	reporters     map[quota.TargetSrv]quota.UsageReporterFunc
+	orgsReporters map[quota.TargetSrv]quota.OrgsUsageReporterFunc
 
 	defaultLimits *quota.Map
 
@@ -60,6 +74,7 @@ func ProvideService(db db.DB, cfg *setting.Cfg) quota.Service {
 		Cfg:           cfg,
 		Logger:        logger,
 		reporters:     make(map[quota.TargetSrv]quota.UsageReporterFunc),
+		orgsReporters: make(map[quota.TargetSrv]quota.OrgsUsageReporterFunc),
 		defaultLimits: &quota.Map{},
 		targetToSrv:   quota.NewTargetToSrv(),
 	}
@@ -94,8 +109,9 @@ func (s *service) GetQuotasByScope(ctx context.Context, scope quota.Scope, id in
 	if err := scope.Validate(); err != nil {
 		return nil, err
 	}
-
-	q := make([]quota.QuotaDTO, 0)
+	if scope == quota.FolderScope {
+		return nil, quota.ErrInvalidScope.Errorf("folder quota are returned by GetFolderQuotas")
+	}
 
 	scopeParams := quota.ScopeParameters{}
 	if scope == quota.OrgScope {
@@ -118,6 +134,12 @@ func (s *service) GetQuotasByScope(ctx context.Context, scope quota.Scope, id in
 		return nil, err
 	}
 
+	return s.quotaDTOs(scope, &scopeParams, customLimits, u)
+}
+
+// quotaDTOs returns the limit and usage of every target of the scope, the custom limits overriding the default ones.
+func (s *service) quotaDTOs(scope quota.Scope, scopeParams *quota.ScopeParameters, customLimits *quota.Map, u *quota.Map) ([]quota.QuotaDTO, error) {
+	q := make([]quota.QuotaDTO, 0)
 	for item := range s.defaultLimits.Iter() {
 		limit := item.Value
 
@@ -175,6 +197,20 @@ func (s *service) Update(ctx context.Context, cmd *quota.UpdateQuotaCmd) error {
 		return quota.ErrInvalidTarget.Errorf("unknown quota target: %s", cmd.Target)
 	}
 
+	if cmd.FolderUID != "" {
+		srv, ok := s.targetToSrv.Get(quota.Target(cmd.Target))
+		if !ok {
+			return quota.ErrInvalidTarget.Errorf("unknown quota target: %s", cmd.Target)
+		}
+		tag, err := quota.NewTag(srv, quota.Target(cmd.Target), quota.FolderScope)
+		if err != nil {
+			return err
+		}
+		if _, ok := s.defaultLimits.Get(tag); !ok {
+			return quota.ErrInvalidTarget.Errorf("quota target %s cannot be limited per folder", cmd.Target)
+		}
+	}
+
 	c, err := s.getContext(ctx)
 	if err != nil {
 		return err
@@ -199,17 +235,22 @@ func (s *service) CheckQuotaReached(ctx context.Context, targetSrv quota.TargetS
 	}
 
 	for t, limit := range targetSrvLimits {
+		scope, err := t.GetScope()
+		if err != nil {
+			return false, quota.ErrFailedToGetScope.Errorf("failed to get the scope for target: %s", t)
+		}
+
+		// folder quota are checked by CheckFolderQuotaReached
+		if scope == quota.FolderScope {
+			continue
+		}
+
 		switch {
 		case limit < 0:
 			continue
 		case limit == 0:
 			return true, nil
 		default:
-			scope, err := t.GetScope()
-			if err != nil {
-				return false, quota.ErrFailedToGetScope.Errorf("failed to get the scope for target: %s", t)
-			}
-
 			// do not check user quota if the user information is not available (eg no user is signed in)
 			if scope == quota.UserScope && (scopeParams == nil || scopeParams.UserID == 0) {
 				continue
@@ -232,6 +273,256 @@ func (s *service) CheckQuotaReached(ctx context.Context, targetSrv quota.TargetS
 	return false, nil
 }
 
+// CheckFolderQuotaReached checks whether the folder quota of the folder the resources are saved in, and of its ancestors
+// since a folder quota applies to the whole subtree of the folder, can hold scopeParams.Count more resources.
+func (s *service) CheckFolderQuotaReached(ctx context.Context, targetSrv quota.TargetSrv, scopeParams *quota.ScopeParameters) error {
+	if scopeParams == nil || scopeParams.OrgID == 0 || scopeParams.FolderUID == "" {
+		return nil
+	}
+
+	if !s.hasFolderScope(targetSrv) {
+		return nil
+	}
+
+	usageReporterFunc, ok := s.getReporter(targetSrv)
+	if !ok {
+		return quota.ErrInvalidTargetSrv
+	}
+
+	c, err := s.getContext(ctx)
+	if err != nil {
+		return err
+	}
+	ancestors, err := s.store.GetFolderAncestors(c, scopeParams.OrgID, scopeParams.FolderUID)
+	if err != nil {
+		return err
+	}
+	containing := make(map[string]struct{})
+	if scopeParams.MovedFolderUID != "" {
+		current, err := s.store.GetFolderAncestors(c, scopeParams.OrgID, scopeParams.MovedFolderUID)
+		if err != nil {
+			return err
+		}
+		for _, uid := range current {
+			containing[uid] = struct{}{}
+		}
+	}
+
+	for _, folderUID := range ancestors {
+		if _, ok := containing[folderUID]; ok {
+			continue
+		}
+		folderParams := &quota.ScopeParameters{OrgID: scopeParams.OrgID, FolderUID: folderUID}
+		limits, err := s.getOverridenLimits(ctx, targetSrv, folderParams)
+		if err != nil {
+			return err
+		}
+
+		var folderUsage *quota.Map
+		for t, limit := range limits {
+			scope, err := t.GetScope()
+			if err != nil {
+				return quota.ErrFailedToGetScope.Errorf("failed to get the scope for target: %s", t)
+			}
+			if scope != quota.FolderScope || limit < 0 {
+				continue
+			}
+			if limit == 0 && scopeParams.Count > 0 {
+				return quota.ErrFolderQuotaReached.Errorf("quota reached for target %s in folder %s", t, folderUID)
+			}
+
+			if folderUsage == nil {
+				folderParams.FolderUIDs, err = s.store.GetFolderSubtree(c, scopeParams.OrgID, folderUID)
+				if err != nil {
+					return err
+				}
+				folderUsage, err = usageReporterFunc(ctx, folderParams)
+				if err != nil {
+					return err
+				}
+			}
+
+			u, ok := folderUsage.Get(t)
+			if !ok {
+				return quota.ErrUsageFoundForTarget.Errorf("no usage for target:%s", t)
+			}
+			if u+scopeParams.Count > limit {
+				return quota.ErrFolderQuotaReached.Errorf("quota reached for target %s in folder %s", t, folderUID)
+			}
+		}
+	}
+	return nil
+}
+
+// GetFolderQuotas returns the folder quota of the folder, with the usage of its subtree.
+func (s *service) GetFolderQuotas(ctx context.Context, orgID int64, folderUID string) ([]quota.QuotaDTO, error) {
+	c, err := s.getContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scopeParams := &quota.ScopeParameters{OrgID: orgID, FolderUID: folderUID}
+	customLimits, err := s.store.Get(c, scopeParams)
+	if err != nil {
+		return nil, err
+	}
+	scopeParams.FolderUIDs, err = s.store.GetFolderSubtree(c, orgID, folderUID)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.getUsage(ctx, scopeParams)
+	if err != nil {
+		return nil, err
+	}
+
+	q := make([]quota.QuotaDTO, 0)
+	for item := range s.defaultLimits.Iter() {
+		scp, err := item.Tag.GetScope()
+		if err != nil {
+			return nil, err
+		}
+		if scp != quota.FolderScope {
+			continue
+		}
+
+		limit := item.Value
+		if targetCustomLimit, ok := customLimits.Get(item.Tag); ok {
+			limit = targetCustomLimit
+		}
+
+		target, err := item.Tag.GetTarget()
+		if err != nil {
+			return nil, err
+		}
+		srv, err := item.Tag.GetSrv()
+		if err != nil {
+			return nil, err
+		}
+
+		used, _ := u.Get(item.Tag)
+		q = append(q, quota.QuotaDTO{
+			Target:    string(target),
+			Limit:     limit,
+			OrgId:     orgID,
+			FolderUID: folderUID,
+			Used:      used,
+			Service:   string(srv),
+			Scope:     string(quota.FolderScope),
+		})
+	}
+	return q, nil
+}
+
+// GetUsageReport returns the usage and limit of every target in the global scope, and in the scope of every
+// organization. The quota of each scope are sorted by target. The global usage is computed once, and the usage of the
+// organizations with a query per target service for the services that report all organizations at once.
+func (s *service) GetUsageReport(ctx context.Context) (*quota.UsageReport, error) {
+	c, err := s.getContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	globalParams := &quota.ScopeParameters{}
+	globalUsage, err := s.getUsage(ctx, globalParams)
+	if err != nil {
+		return nil, err
+	}
+	global, err := s.quotaDTOs(quota.GlobalScope, globalParams, &quota.Map{}, globalUsage)
+	if err != nil {
+		return nil, err
+	}
+	sortQuotas(global)
+
+	orgIDs, err := s.store.GetOrgIDs(c)
+	if err != nil {
+		return nil, err
+	}
+	orgsLimits, err := s.store.GetOrgsLimits(c)
+	if err != nil {
+		return nil, err
+	}
+	orgsUsage, err := s.getOrgsUsage(ctx, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &quota.UsageReport{
+		Global: global,
+		Orgs:   make([]quota.OrgUsageReport, 0, len(orgIDs)),
+	}
+	for _, orgID := range orgIDs {
+		limits, ok := orgsLimits[orgID]
+		if !ok {
+			limits = &quota.Map{}
+		}
+		q, err := s.quotaDTOs(quota.OrgScope, &quota.ScopeParameters{OrgID: orgID}, limits, orgsUsage[orgID])
+		if err != nil {
+			return nil, err
+		}
+		sortQuotas(q)
+		report.Orgs = append(report.Orgs, quota.OrgUsageReport{OrgID: orgID, Quotas: q})
+	}
+	return report, nil
+}
+
+// getOrgsUsage returns the usage of each of the organizations. The services without an OrgsUsageReporterFunc report
+// the usage of one organization at a time.
+func (s *service) getOrgsUsage(ctx context.Context, orgIDs []int64) (map[int64]*quota.Map, error) {
+	usage := make(map[int64]*quota.Map, len(orgIDs))
+	for _, orgID := range orgIDs {
+		usage[orgID] = &quota.Map{}
+	}
+
+	s.mutex.RLock()
+	orgsReporters := make(map[quota.TargetSrv]quota.OrgsUsageReporterFunc, len(s.orgsReporters))
+	for srv, r := range s.orgsReporters {
+		orgsReporters[srv] = r
+	}
+	s.mutex.RUnlock()
+
+	g, ctx := errgroup.WithContext(ctx)
+	for r := range s.getReporters() {
+		r := r
+		orgsReporter, batched := orgsReporters[r.target]
+		g.Go(func() error {
+			if batched {
+				u, err := orgsReporter(ctx)
+				if err != nil {
+					return err
+				}
+				for orgID, orgUsage := range u {
+					if m, ok := usage[orgID]; ok {
+						m.Merge(orgUsage)
+					}
+				}
+				return nil
+			}
+			for _, orgID := range orgIDs {
+				u, err := r.reporterFunc(ctx, &quota.ScopeParameters{OrgID: orgID})
+				if err != nil {
+					return err
+				}
+				usage[orgID].Merge(u)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func sortQuotas(q []quota.QuotaDTO) {
+	sort.Slice(q, func(i, j int) bool {
+		if q[i].Target != q[j].Target {
+			return q[i].Target < q[j].Target
+		}
+		return q[i].Service < q[j].Service
+	})
+}
+
 func (s *service) DeleteQuotaForUser(ctx context.Context, userID int64) error {
 	c, err := s.getContext(ctx)
 	if err != nil {
@@ -250,6 +541,9 @@ func (s *service) RegisterQuotaReporter(e *quota.NewUsageReporter) error {
 	}
 
 	s.reporters[e.TargetSrv] = e.Reporter
+	if e.OrgsReporter != nil {
+		s.orgsReporters[e.TargetSrv] = e.OrgsReporter
+	}
 
 	for item := range e.DefaultLimits.Iter() {
 		target, err := item.Tag.GetTarget()
@@ -267,6 +561,21 @@ func (s *service) RegisterQuotaReporter(e *quota.NewUsageReporter) error {
 	return nil
 }
 
+// hasFolderScope returns whether the service has targets limited per folder.
+func (s *service) hasFolderScope(targetSrv quota.TargetSrv) bool {
+	found := false
+	for item := range s.defaultLimits.Iter() {
+		srv, err := item.Tag.GetSrv()
+		if err != nil || srv != targetSrv {
+			continue
+		}
+		if scope, err := item.Tag.GetScope(); err == nil && scope == quota.FolderScope {
+			found = true
+		}
+	}
+	return found
+}
+
 func (s *service) getReporter(target quota.TargetSrv) (quota.UsageReporterFunc, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -10,6 +10,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
@@ -76,6 +77,10 @@ func TestIntegrationQuotaCommandsAndQueries(t *testing.T) {
 		User: setting.UserQuota{
 			Org: 7,
 		},
+		Folder: setting.FolderQuota{
+			Dashboard: 2,
+			AlertRule: 3,
+		},
 		Global: setting.GlobalQuota{
 			Org:        8,
 			User:       9,
@@ -234,7 +239,7 @@ func TestIntegrationQuotaCommandsAndQueries(t *testing.T) {
 		t.Run("Should be able to quota list for org", func(t *testing.T) {
 			result, err := quotaService.GetQuotasByScope(context.Background(), quota.OrgScope, o.ID)
 			require.NoError(t, err)
-			require.Len(t, result, 5)
+			require.Len(t, result, 6)
 
 			require.NoError(t, err)
 			for _, res := range result {
@@ -429,6 +434,165 @@ func TestIntegrationQuotaCommandsAndQueries(t *testing.T) {
 		require.Equal(t, customUserOrgLimit, query.Limit)
 	})
 
+	t.Run("Given saved folder quota for dashboards", func(t *testing.T) {
+		err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			parent := dashboards.NewDashboardFolder("parent")
+			parent.OrgID = o.ID
+			parent.SetUID("parent")
+			child := dashboards.NewDashboardFolder("child")
+			child.OrgID = o.ID
+			child.SetUID("child")
+			other := dashboards.NewDashboardFolder("other")
+			other.OrgID = o.ID
+			other.SetUID("other")
+			if _, err := sess.Insert(parent, child, other); err != nil {
+				return err
+			}
+			if _, err := sess.Exec("INSERT INTO folder (uid, org_id, title, parent_uid, created, updated) VALUES (?, ?, ?, ?, ?, ?)",
+				"child", o.ID, "child", "parent", time.Now(), time.Now()); err != nil {
+				return err
+			}
+			dash := dashboards.NewDashboard("in child")
+			dash.OrgID = o.ID
+			dash.FolderID = child.ID
+			dash.SetUID("in-child")
+			_, err := sess.Insert(dash)
+			return err
+		})
+		require.NoError(t, err)
+
+		err = quotaService.Update(context.Background(), &quota.UpdateQuotaCmd{
+			OrgID:     o.ID,
+			FolderUID: "parent",
+			Target:    string(dashboards.QuotaTarget),
+			Limit:     1,
+		})
+		require.NoError(t, err)
+
+		t.Run("Should be able to get saved limit and usage of the subtree", func(t *testing.T) {
+			result, err := quotaService.GetFolderQuotas(context.Background(), o.ID, "parent")
+			require.NoError(t, err)
+			found := false
+			for _, q := range result {
+				if q.Target != string(dashboards.QuotaTarget) {
+					continue
+				}
+				found = true
+				require.Equal(t, "parent", q.FolderUID)
+				require.Equal(t, int64(1), q.Limit)
+				require.Equal(t, int64(1), q.Used)
+			}
+			require.True(t, found)
+		})
+
+		t.Run("Should reach the quota of the parent folder when saving in a subfolder", func(t *testing.T) {
+			err := quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "child", Count: 1})
+			require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+		})
+
+		t.Run("Should not reach the quota of the parent folder with the resources already saved in it", func(t *testing.T) {
+			err := quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "child"})
+			require.NoError(t, err)
+		})
+
+		t.Run("Should reach the quota of the parent folder when saving several resources at once", func(t *testing.T) {
+			err := quotaService.Update(context.Background(), &quota.UpdateQuotaCmd{
+				OrgID:     o.ID,
+				FolderUID: "parent",
+				Target:    string(dashboards.QuotaTarget),
+				Limit:     3,
+			})
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				err := quotaService.Update(context.Background(), &quota.UpdateQuotaCmd{
+					OrgID:     o.ID,
+					FolderUID: "parent",
+					Target:    string(dashboards.QuotaTarget),
+					Limit:     1,
+				})
+				require.NoError(t, err)
+			})
+
+			err = quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "child", Count: 2})
+			require.NoError(t, err)
+			err = quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "child", Count: 3})
+			require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+		})
+
+		t.Run("Should only reach the quota of the folders that do not contain the moved folder yet", func(t *testing.T) {
+			err := quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "parent", Count: 1, MovedFolderUID: "child"})
+			require.NoError(t, err)
+			err = quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "child", Count: 1, MovedFolderUID: "other"})
+			require.ErrorIs(t, err, quota.ErrFolderQuotaReached)
+		})
+
+		t.Run("Should ignore the folder quota of unknown targets", func(t *testing.T) {
+			err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+				_, err := sess.Table("quota_folder").Insert(&quota.FolderQuota{OrgId: o.ID, FolderUid: "other", Target: "unknown", Limit: 0, Created: time.Now(), Updated: time.Now()})
+				return err
+			})
+			require.NoError(t, err)
+			err = quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "other", Count: 1})
+			require.NoError(t, err)
+		})
+
+		t.Run("Should not reach the default quota of another folder", func(t *testing.T) {
+			err := quotaService.CheckFolderQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID, FolderUID: "other", Count: 1})
+			require.NoError(t, err)
+		})
+
+		t.Run("Should not check the folder quota without a folder", func(t *testing.T) {
+			reached, err := quotaService.CheckQuotaReached(context.Background(), dashboards.QuotaTargetSrv, &quota.ScopeParameters{OrgID: o.ID})
+			require.NoError(t, err)
+			require.False(t, reached)
+		})
+
+		t.Run("Should not be able to limit a target without folder scope", func(t *testing.T) {
+			err := quotaService.Update(context.Background(), &quota.UpdateQuotaCmd{
+				OrgID:     o.ID,
+				FolderUID: "parent",
+				Target:    string(datasources.QuotaTarget),
+				Limit:     1,
+			})
+			require.ErrorIs(t, err, quota.ErrInvalidTarget)
+		})
+	})
+
+	t.Run("Should be able to get the usage report", func(t *testing.T) {
+		report, err := quotaService.GetUsageReport(context.Background())
+		require.NoError(t, err)
+
+		tag, err := quota.NewTag(dashboards.FolderQuotaTargetSrv, dashboards.FolderQuotaTarget, quota.GlobalScope)
+		require.NoError(t, err)
+		found := false
+		for _, q := range report.Global {
+			if q.Target == string(dashboards.FolderQuotaTarget) {
+				found = true
+				require.Equal(t, int64(-1), q.Limit)
+				require.Equal(t, existingGlobalUsage[tag]+3, q.Used)
+			}
+		}
+		require.True(t, found)
+
+		var orgReport *quota.OrgUsageReport
+		for i := range report.Orgs {
+			if report.Orgs[i].OrgID == o.ID {
+				orgReport = &report.Orgs[i]
+			}
+		}
+		require.NotNil(t, orgReport)
+		require.Len(t, orgReport.Quotas, 6)
+		for _, q := range orgReport.Quotas {
+			switch q.Target {
+			case string(dashboards.FolderQuotaTarget):
+				require.Equal(t, int64(-1), q.Limit)
+				require.Equal(t, int64(3), q.Used)
+			case org.OrgUserQuotaTarget:
+				require.Equal(t, int64(1), q.Used)
+			}
+		}
+	})
+
 	// TODO data_source, file
 }
 
@@ -479,12 +643,12 @@ func setupEnv(t *testing.T, sqlStore *sqlstore.SQLStore, b bus.Bus, quotaService
 	require.NoError(t, err)
 	m := metrics.NewNGAlert(prometheus.NewRegistry())
 	ruleStore, err := ngstore.ProvideDBStore(sqlStore.Cfg, featuremgmt.WithFeatures(), sqlStore, &foldertest.FakeService{},
-		&acmock.Mock{}, &dashboards.FakeDashboardService{})
+		&acmock.Mock{}, &dashboards.FakeDashboardService{}, nil)
 	require.NoError(t, err)
 	_, err = ngalert.ProvideService(
-		sqlStore.Cfg, featuremgmt.WithFeatures(), nil, nil, routing.NewRouteRegister(), sqlStore, nil, nil, nil, quotaService,
+		sqlStore.Cfg, featuremgmt.WithFeatures(), nil, nil, routing.NewRouteRegister(), sqlStore, kvstore.ProvideService(sqlStore), nil, nil, quotaService,
 		secretsService, nil, m, &foldertest.FakeService{}, &acmock.Mock{}, &dashboards.FakeDashboardService{}, nil, b, &acmock.Mock{},
-		annotationstest.NewFakeAnnotationsRepo(), &pluginstore.FakePluginStore{}, tracer, ruleStore,
+		annotationstest.NewFakeAnnotationsRepo(), &pluginstore.FakePluginStore{}, tracer, ruleStore, nil, secretsStore,
 	)
 	require.NoError(t, err)
 	_, err = storesrv.ProvideService(sqlStore, featuremgmt.WithFeatures(), sqlStore.Cfg, quotaService, storesrv.ProvideSystemUsersService())
@@ -13,6 +13,10 @@ type store interface {
 	Get(ctx quota.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error)
 	Update(ctx quota.Context, cmd *quota.UpdateQuotaCmd) error
 	DeleteByUser(quota.Context, int64) error
+	GetOrgIDs(ctx quota.Context) ([]int64, error)
+	GetOrgsLimits(ctx quota.Context) (map[int64]*quota.Map, error)
+	GetFolderAncestors(ctx quota.Context, orgID int64, folderUID string) ([]string, error)
+	GetFolderSubtree(ctx quota.Context, orgID int64, folderUID string) ([]string, error)
 }
 
 type sqlStore struct {
@@ -50,10 +54,21 @@ func (ss *sqlStore) Get(ctx quota.Context, scopeParams *quota.ScopeParameters) (
 		limits.Merge(userLimits)
 	}
 
+	if scopeParams.OrgID != 0 && scopeParams.FolderUID != "" {
+		folderLimits, err := ss.getFolderScopeQuota(ctx, scopeParams.OrgID, scopeParams.FolderUID)
+		if err != nil {
+			return nil, err
+		}
+		limits.Merge(folderLimits)
+	}
+
 	return &limits, nil
 }
 
 func (ss *sqlStore) Update(ctx quota.Context, cmd *quota.UpdateQuotaCmd) error {
+	if cmd.FolderUID != "" {
+		return ss.updateFolderQuota(ctx, cmd)
+	}
 	return ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
 		// Check if quota is already defined in the DB
 		quota := quota.Quota{
@@ -132,3 +147,147 @@ func (ss *sqlStore) getOrgScopeQuota(ctx quota.Context, OrgID int64) (*quota.Map
 	})
 	return &r, err
 }
+
+func (ss *sqlStore) getFolderScopeQuota(ctx quota.Context, orgID int64, folderUID string) (*quota.Map, error) {
+	r := quota.Map{}
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		quotas := make([]*quota.FolderQuota, 0)
+		if err := sess.Table("quota_folder").Where("org_id=? AND folder_uid=?", orgID, folderUID).Find(&quotas); err != nil {
+			return err
+		}
+
+		for _, q := range quotas {
+			srv, ok := ctx.TargetToSrv.Get(quota.Target(q.Target))
+			if !ok {
+				// The folder quota of a target that no service registers, for example of a disabled service, is ignored.
+				ss.logger.Info("failed to get service for target", "target", q.Target)
+				continue
+			}
+			tag, err := quota.NewTag(srv, quota.Target(q.Target), quota.FolderScope)
+			if err != nil {
+				return err
+			}
+			r.Set(tag, q.Limit)
+		}
+		return nil
+	})
+	return &r, err
+}
+
+func (ss *sqlStore) updateFolderQuota(ctx quota.Context, cmd *quota.UpdateQuotaCmd) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := quota.FolderQuota{
+			OrgId:     cmd.OrgID,
+			FolderUid: cmd.FolderUID,
+			Target:    cmd.Target,
+		}
+		has, err := sess.Table("quota_folder").Get(&q)
+		if err != nil {
+			return err
+		}
+		q.Updated = time.Now()
+		q.Limit = cmd.Limit
+		if !has {
+			q.Created = time.Now()
+			_, err = sess.Table("quota_folder").Insert(&q)
+			return err
+		}
+		_, err = sess.Table("quota_folder").ID(q.Id).Update(&q)
+		return err
+	})
+}
+
+// GetOrgIDs returns the IDs of all the organizations, in ascending order.
+func (ss *sqlStore) GetOrgIDs(ctx quota.Context) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("org").Cols("id").OrderBy("id").Find(&ids)
+	})
+	return ids, err
+}
+
+// GetOrgsLimits returns the custom limits of every organization that has some, keyed by organization ID.
+func (ss *sqlStore) GetOrgsLimits(ctx quota.Context) (map[int64]*quota.Map, error) {
+	limits := make(map[int64]*quota.Map)
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		quotas := make([]*quota.Quota, 0)
+		if err := sess.Table("quota").Where("user_id=0 AND org_id<>0").Find(&quotas); err != nil {
+			return err
+		}
+
+		for _, q := range quotas {
+			srv, ok := ctx.TargetToSrv.Get(quota.Target(q.Target))
+			if !ok {
+				ss.logger.Info("failed to get service for target", "target", q.Target)
+			}
+			tag, err := quota.NewTag(srv, quota.Target(q.Target), quota.OrgScope)
+			if err != nil {
+				return err
+			}
+			if _, ok := limits[q.OrgId]; !ok {
+				limits[q.OrgId] = &quota.Map{}
+			}
+			limits[q.OrgId].Set(tag, q.Limit)
+		}
+		return nil
+	})
+	return limits, err
+}
+
+type folderNode struct {
+	UID       string `xorm:"uid"`
+	ParentUID string `xorm:"parent_uid"`
+}
+
+// GetFolderAncestors returns the UID of the folder followed by the UIDs of its parents, up to the root. Folders
+// that are not in the folder table, which is only populated with nested folders, have no parents.
+func (ss *sqlStore) GetFolderAncestors(ctx quota.Context, orgID int64, folderUID string) ([]string, error) {
+	uids := []string{folderUID}
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		seen := map[string]struct{}{folderUID: {}}
+		uid := folderUID
+		for {
+			var node folderNode
+			has, err := sess.SQL("SELECT uid, parent_uid FROM folder WHERE org_id=? AND uid=?", orgID, uid).Get(&node)
+			if err != nil {
+				return err
+			}
+			if !has || node.ParentUID == "" {
+				return nil
+			}
+			if _, ok := seen[node.ParentUID]; ok {
+				return nil
+			}
+			seen[node.ParentUID] = struct{}{}
+			uids = append(uids, node.ParentUID)
+			uid = node.ParentUID
+		}
+	})
+	return uids, err
+}
+
+// GetFolderSubtree returns the UID of the folder followed by the UIDs of its descendants.
+func (ss *sqlStore) GetFolderSubtree(ctx quota.Context, orgID int64, folderUID string) ([]string, error) {
+	uids := []string{folderUID}
+	err := ss.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		seen := map[string]struct{}{folderUID: {}}
+		parents := []string{folderUID}
+		for len(parents) > 0 {
+			children := make([]string, 0)
+			if err := sess.Table("folder").Cols("uid").Where("org_id=?", orgID).In("parent_uid", parents).Find(&children); err != nil {
+				return err
+			}
+			parents = parents[:0]
+			for _, uid := range children {
+				if _, ok := seen[uid]; ok {
+					continue
+				}
+				seen[uid] = struct{}{}
+				uids = append(uids, uid)
+				parents = append(parents, uid)
+			}
+		}
+		return nil
+	})
+	return uids, err
+}
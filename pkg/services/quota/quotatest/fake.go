@@ -32,6 +32,18 @@ func (f *FakeQuotaService) CheckQuotaReached(c context.Context, target quota.Tar
 	return f.reached, f.err
 }
 
+func (f *FakeQuotaService) CheckFolderQuotaReached(c context.Context, target quota.TargetSrv, params *quota.ScopeParameters) error {
+	return f.err
+}
+
+func (f *FakeQuotaService) GetFolderQuotas(c context.Context, orgID int64, folderUID string) ([]quota.QuotaDTO, error) {
+	return []quota.QuotaDTO{}, nil
+}
+
+func (f *FakeQuotaService) GetUsageReport(c context.Context) (*quota.UsageReport, error) {
+	return &quota.UsageReport{Global: []quota.QuotaDTO{}, Orgs: []quota.OrgUsageReport{}}, f.err
+}
+
 func (f *FakeQuotaService) DeleteQuotaForUser(c context.Context, userID int64) error {
 	return f.err
 }
@@ -55,3 +67,19 @@ func (f *FakeQuotaStore) Get(ctx quota.Context, scopeParams *quota.ScopeParamete
 func (f *FakeQuotaStore) Update(ctx quota.Context, cmd *quota.UpdateQuotaCmd) error {
 	return f.ExpectedError
 }
+
+func (f *FakeQuotaStore) GetOrgIDs(ctx quota.Context) ([]int64, error) {
+	return []int64{}, f.ExpectedError
+}
+
+func (f *FakeQuotaStore) GetOrgsLimits(ctx quota.Context) (map[int64]*quota.Map, error) {
+	return map[int64]*quota.Map{}, f.ExpectedError
+}
+
+func (f *FakeQuotaStore) GetFolderAncestors(ctx quota.Context, orgID int64, folderUID string) ([]string, error) {
+	return []string{folderUID}, f.ExpectedError
+}
+
+func (f *FakeQuotaStore) GetFolderSubtree(ctx quota.Context, orgID int64, folderUID string) ([]string, error) {
+	return []string{folderUID}, f.ExpectedError
+}
@@ -372,6 +372,27 @@ func (s *SecretsService) Decrypt(ctx context.Context, payload []byte) ([]byte, e
 	return decrypted, err
 }
 
+// DataKeyID returns the id of the data key the payload is encrypted with,
+// or an empty string if it is not encrypted with envelope encryption.
+func DataKeyID(payload []byte) (string, error) {
+	if len(payload) == 0 || payload[0] != keyIdDelimiter {
+		return "", nil
+	}
+
+	payload = payload[1:]
+	endOfKey := bytes.Index(payload, []byte{keyIdDelimiter})
+	if endOfKey == -1 {
+		return "", fmt.Errorf("could not find valid key id in encrypted payload")
+	}
+
+	keyId, err := b64.DecodeString(string(payload[:endOfKey]))
+	if err != nil {
+		return "", err
+	}
+
+	return string(keyId), nil
+}
+
 func (s *SecretsService) EncryptJsonData(ctx context.Context, kv map[string]string, opt secrets.EncryptionOptions) (map[string][]byte, error) {
 	encrypted := make(map[string][]byte)
 	for key, value := range kv {
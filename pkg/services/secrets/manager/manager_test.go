@@ -425,6 +425,39 @@ func TestSecretsService_Decrypt(t *testing.T) {
 	})
 }
 
+func TestDataKeyID(t *testing.T) {
+	ctx := context.Background()
+	testDB := db.InitTestDB(t)
+	store := database.ProvideSecretsStore(testDB)
+	svc := SetupTestService(t, store)
+
+	t.Run("ee encrypted payload should return the data key id", func(t *testing.T) {
+		ciphertext, err := svc.Encrypt(ctx, []byte("grafana"), secrets.WithoutScope())
+		require.NoError(t, err)
+
+		keys, err := store.GetAllDataKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+
+		id, err := DataKeyID(ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, keys[0].Id, id)
+	})
+
+	t.Run("legacy payload should return no data key id", func(t *testing.T) {
+		encrypted := []byte{122, 56, 53, 113, 101, 117, 73, 89, 20, 254, 36, 112, 112, 16, 128, 232, 227, 52, 166, 108, 192, 5, 28, 125, 126, 42, 197, 190, 251, 36, 94}
+
+		id, err := DataKeyID(encrypted)
+		require.NoError(t, err)
+		assert.Empty(t, id)
+	})
+
+	t.Run("malformed ee payload should fail", func(t *testing.T) {
+		_, err := DataKeyID([]byte("#no-delimiter"))
+		require.Error(t, err)
+	})
+}
+
 func TestIntegration_SecretsService(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
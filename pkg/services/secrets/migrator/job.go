@@ -0,0 +1,451 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// reEncryptionJob tracks the progress of the secrets re-encrypted in the background, and lets
+// the rotators wait while it is paused. The rotators get it from the context, see wait and secretProcessed.
+// Its status is stored at every state change and heartbeat, see reEncryptionJobStore.
+type reEncryptionJob struct {
+	mu     sync.Mutex
+	status secrets.ReEncryptionJobStatus
+	step   int
+	// resume is closed when the paused job is resumed, it is nil if the job is not paused.
+	resume chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	store *reEncryptionJobStore
+	// storeMu orders the stored statuses, so an older one never replaces a newer one.
+	storeMu sync.Mutex
+}
+
+type reEncryptionJobKey struct{}
+
+func newReEncryptionJob(rotators []SecretsRotator, cancel context.CancelFunc, store *reEncryptionJobStore) *reEncryptionJob {
+	steps := make([]secrets.ReEncryptionStepStatus, 0, len(rotators))
+	for _, r := range rotators {
+		steps = append(steps, secrets.ReEncryptionStepStatus{Name: rotatorName(r), State: secrets.ReEncryptionJobIdle})
+	}
+
+	now := time.Now()
+	return &reEncryptionJob{
+		status: secrets.ReEncryptionJobStatus{
+			State:     secrets.ReEncryptionJobRunning,
+			StartedAt: &now,
+			Steps:     steps,
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+		store:  store,
+	}
+}
+
+func withReEncryptionJob(ctx context.Context, job *reEncryptionJob) context.Context {
+	return context.WithValue(ctx, reEncryptionJobKey{}, job)
+}
+
+// reEncryptionJobFromContext returns the job the secrets are re-encrypted by, or nil if they are
+// re-encrypted synchronously, e.g. by ReEncryptSecrets.
+func reEncryptionJobFromContext(ctx context.Context) *reEncryptionJob {
+	job, _ := ctx.Value(reEncryptionJobKey{}).(*reEncryptionJob)
+	return job
+}
+
+func (j *reEncryptionJob) run(ctx context.Context, rotators []SecretsRotator, secretsSrv *manager.SecretsService, sqlStore db.DB) {
+	defer close(j.done)
+	defer j.cancel()
+
+	ctx = withReEncryptionJob(ctx, j)
+
+	stopHeartbeat := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go j.heartbeat(stopHeartbeat, heartbeatDone)
+
+	var anyFailure bool
+	for i, r := range rotators {
+		if ctx.Err() != nil {
+			break
+		}
+
+		j.startStep(i)
+		j.persist()
+		success := r.ReEncrypt(ctx, secretsSrv, sqlStore)
+		if !success {
+			anyFailure = true
+		}
+		j.finishStep(ctx, success)
+	}
+
+	close(stopHeartbeat)
+	<-heartbeatDone
+	j.finish(ctx, anyFailure)
+	j.persist()
+}
+
+func (j *reEncryptionJob) finish(ctx context.Context, anyFailure bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// the job may have been paused after the last secret, nothing waits for it to be resumed anymore
+	if j.resume != nil {
+		close(j.resume)
+		j.resume = nil
+	}
+
+	now := time.Now()
+	j.status.FinishedAt = &now
+	switch {
+	case ctx.Err() != nil:
+		j.status.State = secrets.ReEncryptionJobCanceled
+		logger.Warn("Secrets re-encryption job has been canceled", "processed", j.status.Processed, "failed", j.status.Failed)
+	case anyFailure:
+		j.status.State = secrets.ReEncryptionJobFailed
+		logger.Warn("Secrets re-encryption job has finished with errors", "processed", j.status.Processed, "failed", j.status.Failed)
+	default:
+		j.status.State = secrets.ReEncryptionJobCompleted
+		logger.Info("Secrets re-encryption job has finished successfully", "processed", j.status.Processed)
+	}
+}
+
+func (j *reEncryptionJob) startStep(i int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.step = i
+	j.status.Steps[i].State = secrets.ReEncryptionJobRunning
+}
+
+func (j *reEncryptionJob) finishStep(ctx context.Context, success bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	step := &j.status.Steps[j.step]
+	switch {
+	case ctx.Err() != nil:
+		step.State = secrets.ReEncryptionJobCanceled
+	case !success:
+		step.State = secrets.ReEncryptionJobFailed
+	default:
+		step.State = secrets.ReEncryptionJobCompleted
+	}
+}
+
+// heartbeat stores the progress of the job periodically, and applies the state requested by the other instances.
+func (j *reEncryptionJob) heartbeat(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(reEncryptionJobHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		state, ok, err := j.store.takeRequestedState(context.Background())
+		if err != nil {
+			logger.Warn("Could not read the state requested for the secrets re-encryption job", "error", err)
+		}
+		if ok {
+			switch state {
+			case secrets.ReEncryptionJobPaused:
+				err = j.pause()
+			case secrets.ReEncryptionJobRunning:
+				err = j.unpause()
+			}
+			if err != nil {
+				logger.Warn("Could not apply the state requested for the secrets re-encryption job", "state", state, "error", err)
+			}
+		}
+		j.persist()
+	}
+}
+
+// persist stores the status of the job, so that the other instances report it and it is kept across restarts.
+func (j *reEncryptionJob) persist() {
+	j.storeMu.Lock()
+	defer j.storeMu.Unlock()
+
+	if err := j.store.save(context.Background(), j.getStatus()); err != nil {
+		logger.Warn("Could not store the status of the secrets re-encryption job", "error", err)
+	}
+}
+
+// stepTotal records the number of secrets to re-encrypt in the current step.
+func (j *reEncryptionJob) stepTotal(total int64) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.Steps[j.step].Total = total
+	j.status.Total += total
+}
+
+// wait blocks while the job is paused. It returns an error if the job is canceled,
+// in which case the rotator must stop re-encrypting secrets.
+func (j *reEncryptionJob) wait(ctx context.Context) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	resume := j.resume
+	j.mu.Unlock()
+
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-ctx.Done():
+		}
+	}
+
+	return ctx.Err()
+}
+
+// secretProcessed records that a secret of the current step has been re-encrypted, successfully or not.
+func (j *reEncryptionJob) secretProcessed(success bool) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	step := &j.status.Steps[j.step]
+	step.Processed++
+	j.status.Processed++
+	if !success {
+		step.Failed++
+		j.status.Failed++
+	}
+}
+
+func (j *reEncryptionJob) pause() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.State != secrets.ReEncryptionJobRunning {
+		return secrets.ErrReEncryptionJobNotRunning
+	}
+
+	j.status.State = secrets.ReEncryptionJobPaused
+	j.resume = make(chan struct{})
+	return nil
+}
+
+func (j *reEncryptionJob) unpause() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.State != secrets.ReEncryptionJobPaused {
+		return secrets.ErrReEncryptionJobNotPaused
+	}
+
+	j.status.State = secrets.ReEncryptionJobRunning
+	close(j.resume)
+	j.resume = nil
+	return nil
+}
+
+func (j *reEncryptionJob) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.status.FinishedAt != nil
+}
+
+func (j *reEncryptionJob) getStatus() secrets.ReEncryptionJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := j.status
+	status.Steps = make([]secrets.ReEncryptionStepStatus, len(j.status.Steps))
+	copy(status.Steps, j.status.Steps)
+	return status
+}
+
+func rotatorName(r SecretsRotator) string {
+	switch s := r.(type) {
+	case simpleSecret:
+		return fmt.Sprintf("%s.%s", s.tableName, s.columnName)
+	case b64Secret:
+		return fmt.Sprintf("%s.%s", s.tableName, s.columnName)
+	case jsonSecret:
+		return fmt.Sprintf("%s.secure_json_data", s.tableName)
+	case alertingSecret:
+		return "alert_configuration.alertmanager_configuration"
+	default:
+		return fmt.Sprintf("%T", r)
+	}
+}
+
+// StartReEncryptionJob starts re-encrypting the secrets in the background, the job is
+// canceled when Grafana shuts down. Its progress is returned by ReEncryptionJobStatus.
+func (m *SecretsMigrator) StartReEncryptionJob(ctx context.Context) error {
+	if err := m.initProvidersIfNeeded(); err != nil {
+		return err
+	}
+
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+
+	return m.startReEncryptionJob(ctx)
+}
+
+// startReEncryptionJob starts the job unless secrets are already being re-encrypted, the caller must hold jobMu.
+func (m *SecretsMigrator) startReEncryptionJob(ctx context.Context) error {
+	busy, err := m.reEncryptionInProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if busy {
+		return secrets.ErrReEncryptionJobInProgress
+	}
+
+	// A state requested for a previous job must not apply to this one.
+	if _, _, err := m.jobStore.takeRequestedState(ctx); err != nil {
+		return err
+	}
+
+	rotators := make([]SecretsRotator, len(m.rotators))
+	copy(rotators, m.rotators)
+
+	// The job outlives the request starting it, so it is only bound to the lifetime of the server, see Run.
+	jobCtx, cancel := context.WithCancel(context.Background())
+	m.job = newReEncryptionJob(rotators, cancel, m.jobStore)
+	m.job.persist()
+	go m.job.run(jobCtx, rotators, m.secretsSrv, m.sqlStore)
+
+	logger.FromContext(ctx).Info("Secrets re-encryption job started", "steps", len(rotators))
+	return nil
+}
+
+// reEncryptionInProgress returns whether a job is running or paused, on this instance or according to the stored status
+// on another one, or ReEncryptSecrets is running. The caller must hold jobMu.
+func (m *SecretsMigrator) reEncryptionInProgress(ctx context.Context) (bool, error) {
+	if m.syncReEncryption || (m.job != nil && !m.job.finished()) {
+		return true, nil
+	}
+
+	stored, err := m.storedJob(ctx)
+	if err != nil {
+		return false, err
+	}
+	return stored != nil && inProgress(stored.Status.State), nil
+}
+
+// storedJob returns the stored status of the last job, or nil if there is none or it is the job of this instance,
+// whose own status is more recent. The caller must hold jobMu.
+func (m *SecretsMigrator) storedJob(ctx context.Context) (*storedReEncryptionJob, error) {
+	stored, err := m.jobStore.get(ctx)
+	if err != nil || stored == nil || m.job == nil {
+		return stored, err
+	}
+
+	startedAt := m.job.getStatus().StartedAt
+	if stored.Status.StartedAt != nil && startedAt != nil && stored.Status.StartedAt.Equal(*startedAt) {
+		return nil, nil
+	}
+	return stored, nil
+}
+
+// PauseReEncryptionJob pauses the job running on this instance, or asks the instance running it to pause it at its next
+// heartbeat.
+func (m *SecretsMigrator) PauseReEncryptionJob() error {
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+
+	if m.job != nil && !m.job.finished() {
+		if err := m.job.pause(); err != nil {
+			return err
+		}
+		m.job.persist()
+		return nil
+	}
+
+	return m.requestJobState(secrets.ReEncryptionJobRunning, secrets.ReEncryptionJobPaused, secrets.ErrReEncryptionJobNotRunning)
+}
+
+// ResumeReEncryptionJob resumes the job paused on this instance, or asks the instance running it to resume it at its
+// next heartbeat.
+func (m *SecretsMigrator) ResumeReEncryptionJob() error {
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+
+	if m.job != nil && !m.job.finished() {
+		if err := m.job.unpause(); err != nil {
+			return err
+		}
+		m.job.persist()
+		return nil
+	}
+
+	return m.requestJobState(secrets.ReEncryptionJobPaused, secrets.ReEncryptionJobRunning, secrets.ErrReEncryptionJobNotPaused)
+}
+
+// requestJobState asks the instance running the job to change its state from one state to another, it returns
+// errWrongState if the stored job is not in the from state.
+func (m *SecretsMigrator) requestJobState(from, to secrets.ReEncryptionJobState, errWrongState error) error {
+	ctx := context.Background()
+	stored, err := m.storedJob(ctx)
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.Status.State != from {
+		return errWrongState
+	}
+	return m.jobStore.requestState(ctx, to)
+}
+
+// ReEncryptionJobStatus returns the status of the job running on this instance, or else the stored status of the last
+// job, which may have run on another instance or before a restart.
+func (m *SecretsMigrator) ReEncryptionJobStatus() secrets.ReEncryptionJobStatus {
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+
+	if m.job != nil && !m.job.finished() {
+		return m.job.getStatus()
+	}
+
+	stored, err := m.storedJob(context.Background())
+	if err != nil {
+		logger.Warn("Could not read the stored status of the secrets re-encryption job", "error", err)
+	}
+	switch {
+	case stored != nil:
+		return stored.Status
+	case m.job != nil:
+		return m.job.getStatus()
+	default:
+		return secrets.ReEncryptionJobStatus{State: secrets.ReEncryptionJobIdle, Steps: []secrets.ReEncryptionStepStatus{}}
+	}
+}
+
+// Run cancels the secrets re-encryption job, if any, when Grafana shuts down.
+func (m *SecretsMigrator) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	m.jobMu.Lock()
+	job := m.job
+	m.jobMu.Unlock()
+
+	if job != nil {
+		job.cancel()
+		<-job.done
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,106 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/services/secrets"
+)
+
+const (
+	reEncryptionJobNamespace = "secrets.reencryption"
+	reEncryptionJobStatusKey = "job"
+	// reEncryptionJobControlKey holds the state, paused or running, requested for the job by another instance than
+	// the one running it. The instance running the job applies it at its next heartbeat.
+	reEncryptionJobControlKey = "control"
+)
+
+var (
+	// reEncryptionJobHeartbeat is how often the instance running the job stores its progress and applies the state
+	// requested by the other instances.
+	reEncryptionJobHeartbeat = 10 * time.Second
+	// reEncryptionJobStaleAfter is how long after its last heartbeat a running or paused job is considered left by an
+	// instance that stopped.
+	reEncryptionJobStaleAfter = time.Minute
+)
+
+// storedReEncryptionJob is the status of the last job, stored so that every instance reports it and it is kept across
+// restarts.
+type storedReEncryptionJob struct {
+	Status    secrets.ReEncryptionJobStatus `json:"status"`
+	Heartbeat time.Time                     `json:"heartbeat"`
+}
+
+// reEncryptionJobStore stores the status of the re-encryption job in the kvstore. A nil store stores nothing.
+type reEncryptionJobStore struct {
+	kv *kvstore.NamespacedKVStore
+}
+
+func newReEncryptionJobStore(kv kvstore.KVStore) *reEncryptionJobStore {
+	return &reEncryptionJobStore{kv: kvstore.WithNamespace(kv, 0, reEncryptionJobNamespace)}
+}
+
+// get returns the stored status of the last job, or nil if no job was stored. A running or paused job whose
+// heartbeat is stale is reported canceled, since the instance running it stopped.
+func (s *reEncryptionJobStore) get(ctx context.Context) (*storedReEncryptionJob, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	value, ok, err := s.kv.Get(ctx, reEncryptionJobStatusKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var job storedReEncryptionJob
+	if err := json.Unmarshal([]byte(value), &job); err != nil {
+		return nil, err
+	}
+	if inProgress(job.Status.State) && time.Since(job.Heartbeat) > reEncryptionJobStaleAfter {
+		job.Status.State = secrets.ReEncryptionJobCanceled
+		job.Status.FinishedAt = &job.Heartbeat
+	}
+	return &job, nil
+}
+
+func (s *reEncryptionJobStore) save(ctx context.Context, status secrets.ReEncryptionJobStatus) error {
+	if s == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(storedReEncryptionJob{Status: status, Heartbeat: time.Now()})
+	if err != nil {
+		return err
+	}
+	return s.kv.Set(ctx, reEncryptionJobStatusKey, string(value))
+}
+
+// requestState asks the instance running the job to pause or resume it.
+func (s *reEncryptionJobStore) requestState(ctx context.Context, state secrets.ReEncryptionJobState) error {
+	if s == nil {
+		return nil
+	}
+	return s.kv.Set(ctx, reEncryptionJobControlKey, string(state))
+}
+
+// takeRequestedState returns the state requested by another instance, if any, and clears the request.
+func (s *reEncryptionJobStore) takeRequestedState(ctx context.Context) (secrets.ReEncryptionJobState, bool, error) {
+	if s == nil {
+		return "", false, nil
+	}
+
+	value, ok, err := s.kv.Get(ctx, reEncryptionJobControlKey)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if err := s.kv.Del(ctx, reEncryptionJobControlKey); err != nil {
+		return "", false, err
+	}
+	return secrets.ReEncryptionJobState(value), true, nil
+}
+
+func inProgress(state secrets.ReEncryptionJobState) bool {
+	return state == secrets.ReEncryptionJobRunning || state == secrets.ReEncryptionJobPaused
+}
@@ -0,0 +1,278 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeRotator struct {
+	secrets int
+	failed  int
+	proceed chan struct{}
+}
+
+func (r fakeRotator) ReEncrypt(ctx context.Context, _ *manager.SecretsService, _ db.DB) bool {
+	<-r.proceed
+
+	job := reEncryptionJobFromContext(ctx)
+	job.stepTotal(int64(r.secrets))
+	for i := 0; i < r.secrets; i++ {
+		if err := job.wait(ctx); err != nil {
+			return false
+		}
+		job.secretProcessed(i >= r.failed)
+	}
+
+	return r.failed == 0
+}
+
+func (r fakeRotator) Rollback(context.Context, *manager.SecretsService, encryption.Internal, db.DB, string) bool {
+	return false
+}
+
+func setupJobTest(rotators ...SecretsRotator) *SecretsMigrator {
+	return &SecretsMigrator{
+		features: featuremgmt.WithFeatures(),
+		rotators: rotators,
+	}
+}
+
+// lockedKVStore makes the fake kvstore safe to share between the instances of a test and their jobs.
+type lockedKVStore struct {
+	mu sync.Mutex
+	kvstore.KVStore
+}
+
+func (s *lockedKVStore) Get(ctx context.Context, orgID int64, namespace string, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.KVStore.Get(ctx, orgID, namespace, key)
+}
+
+func (s *lockedKVStore) Set(ctx context.Context, orgID int64, namespace string, key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.KVStore.Set(ctx, orgID, namespace, key, value)
+}
+
+func (s *lockedKVStore) Del(ctx context.Context, orgID int64, namespace string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.KVStore.Del(ctx, orgID, namespace, key)
+}
+
+func TestReEncryptionJob(t *testing.T) {
+	t.Run("reports the progress of every step", func(t *testing.T) {
+		proceed := make(chan struct{})
+		close(proceed)
+		m := setupJobTest(
+			fakeRotator{secrets: 3, proceed: proceed},
+			fakeRotator{secrets: 2, failed: 1, proceed: proceed},
+		)
+
+		require.Equal(t, secrets.ReEncryptionJobIdle, m.ReEncryptionJobStatus().State)
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+
+		require.Eventually(t, func() bool {
+			return m.ReEncryptionJobStatus().FinishedAt != nil
+		}, time.Second, 10*time.Millisecond)
+
+		status := m.ReEncryptionJobStatus()
+		require.Equal(t, secrets.ReEncryptionJobFailed, status.State)
+		require.EqualValues(t, 5, status.Processed)
+		require.EqualValues(t, 1, status.Failed)
+		require.EqualValues(t, 5, status.Total)
+		require.Len(t, status.Steps, 2)
+		require.Equal(t, secrets.ReEncryptionJobCompleted, status.Steps[0].State)
+		require.EqualValues(t, 3, status.Steps[0].Processed)
+		require.EqualValues(t, 3, status.Steps[0].Total)
+		require.Equal(t, secrets.ReEncryptionJobFailed, status.Steps[1].State)
+		require.EqualValues(t, 1, status.Steps[1].Failed)
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()), "a finished job can be started again")
+	})
+
+	t.Run("can be paused and resumed", func(t *testing.T) {
+		proceed := make(chan struct{})
+		m := setupJobTest(fakeRotator{secrets: 2, proceed: proceed})
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+		require.ErrorIs(t, m.ResumeReEncryptionJob(), secrets.ErrReEncryptionJobNotPaused)
+		require.NoError(t, m.PauseReEncryptionJob())
+		require.ErrorIs(t, m.PauseReEncryptionJob(), secrets.ErrReEncryptionJobNotRunning)
+		require.ErrorIs(t, m.StartReEncryptionJob(context.Background()), secrets.ErrReEncryptionJobInProgress)
+		close(proceed)
+
+		require.Never(t, func() bool {
+			return m.ReEncryptionJobStatus().Processed > 0
+		}, 100*time.Millisecond, 10*time.Millisecond)
+		require.Equal(t, secrets.ReEncryptionJobPaused, m.ReEncryptionJobStatus().State)
+
+		require.NoError(t, m.ResumeReEncryptionJob())
+		require.Eventually(t, func() bool {
+			return m.ReEncryptionJobStatus().State == secrets.ReEncryptionJobCompleted
+		}, time.Second, 10*time.Millisecond)
+		require.EqualValues(t, 2, m.ReEncryptionJobStatus().Processed)
+	})
+
+	t.Run("is no longer paused once finished after the last secret", func(t *testing.T) {
+		proceed := make(chan struct{})
+		m := setupJobTest(fakeRotator{proceed: proceed})
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+		require.NoError(t, m.PauseReEncryptionJob())
+		close(proceed)
+
+		require.Eventually(t, func() bool {
+			return m.ReEncryptionJobStatus().FinishedAt != nil
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, secrets.ReEncryptionJobCompleted, m.ReEncryptionJobStatus().State)
+		require.ErrorIs(t, m.ResumeReEncryptionJob(), secrets.ErrReEncryptionJobNotPaused)
+
+		m.job.mu.Lock()
+		resume := m.job.resume
+		m.job.mu.Unlock()
+		require.Nil(t, resume)
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+	})
+
+	t.Run("rejects the synchronous re-encryption while in progress", func(t *testing.T) {
+		proceed := make(chan struct{})
+		m := setupJobTest(fakeRotator{secrets: 1, proceed: proceed})
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+		_, err := m.ReEncryptSecrets(context.Background())
+		require.ErrorIs(t, err, secrets.ErrReEncryptionJobInProgress)
+
+		require.NoError(t, m.PauseReEncryptionJob())
+		_, err = m.ReEncryptSecrets(context.Background())
+		require.ErrorIs(t, err, secrets.ErrReEncryptionJobInProgress)
+
+		require.NoError(t, m.ResumeReEncryptionJob())
+		close(proceed)
+		require.Eventually(t, func() bool {
+			return m.ReEncryptionJobStatus().FinishedAt != nil
+		}, time.Second, 10*time.Millisecond)
+
+		success, err := m.ReEncryptSecrets(context.Background())
+		require.NoError(t, err)
+		require.True(t, success)
+	})
+
+	t.Run("is not started while secrets are re-encrypted synchronously", func(t *testing.T) {
+		proceed := make(chan struct{})
+		m := setupJobTest(fakeRotator{secrets: 1, proceed: proceed})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = m.ReEncryptSecrets(context.Background())
+		}()
+
+		require.Eventually(t, func() bool {
+			m.jobMu.Lock()
+			defer m.jobMu.Unlock()
+			return m.syncReEncryption
+		}, time.Second, 10*time.Millisecond)
+		require.ErrorIs(t, m.StartReEncryptionJob(context.Background()), secrets.ErrReEncryptionJobInProgress)
+		close(proceed)
+		<-done
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+	})
+
+	t.Run("rejects the rotation of the data keys with re-encryption before rotating them while in progress", func(t *testing.T) {
+		proceed := make(chan struct{})
+		// the migrator has no secrets service, rotating the data keys would panic
+		m := setupJobTest(fakeRotator{secrets: 1, proceed: proceed})
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+		require.ErrorIs(t, m.RotateDataKeys(context.Background(), true), secrets.ErrReEncryptionJobInProgress)
+		close(proceed)
+	})
+
+	t.Run("is reported, paused and resumed by the other instances", func(t *testing.T) {
+		heartbeat := reEncryptionJobHeartbeat
+		reEncryptionJobHeartbeat = 10 * time.Millisecond
+		t.Cleanup(func() { reEncryptionJobHeartbeat = heartbeat })
+
+		kv := &lockedKVStore{KVStore: kvstore.NewFakeKVStore()}
+		proceed := make(chan struct{})
+		m1 := setupJobTest(fakeRotator{secrets: 2, proceed: proceed})
+		m1.jobStore = newReEncryptionJobStore(kv)
+		m2 := setupJobTest(fakeRotator{secrets: 2, proceed: proceed})
+		m2.jobStore = newReEncryptionJobStore(kv)
+
+		require.NoError(t, m1.StartReEncryptionJob(context.Background()))
+		require.Equal(t, secrets.ReEncryptionJobRunning, m2.ReEncryptionJobStatus().State)
+		require.ErrorIs(t, m2.StartReEncryptionJob(context.Background()), secrets.ErrReEncryptionJobInProgress)
+		_, err := m2.ReEncryptSecrets(context.Background())
+		require.ErrorIs(t, err, secrets.ErrReEncryptionJobInProgress)
+		require.ErrorIs(t, m2.ResumeReEncryptionJob(), secrets.ErrReEncryptionJobNotPaused)
+
+		require.NoError(t, m2.PauseReEncryptionJob())
+		require.Eventually(t, func() bool {
+			return m2.ReEncryptionJobStatus().State == secrets.ReEncryptionJobPaused
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, secrets.ReEncryptionJobPaused, m1.ReEncryptionJobStatus().State)
+		close(proceed)
+
+		require.NoError(t, m2.ResumeReEncryptionJob())
+		require.Eventually(t, func() bool {
+			return m2.ReEncryptionJobStatus().State == secrets.ReEncryptionJobCompleted
+		}, time.Second, 10*time.Millisecond)
+		require.EqualValues(t, 2, m2.ReEncryptionJobStatus().Processed)
+		require.NoError(t, m2.StartReEncryptionJob(context.Background()))
+	})
+
+	t.Run("is reported canceled once the instance running it stopped", func(t *testing.T) {
+		kv := &lockedKVStore{KVStore: kvstore.NewFakeKVStore()}
+		m := setupJobTest(fakeRotator{proceed: make(chan struct{})})
+		m.jobStore = newReEncryptionJobStore(kv)
+
+		startedAt := time.Now().Add(-time.Hour)
+		stored, err := json.Marshal(storedReEncryptionJob{
+			Status:    secrets.ReEncryptionJobStatus{State: secrets.ReEncryptionJobRunning, StartedAt: &startedAt},
+			Heartbeat: startedAt,
+		})
+		require.NoError(t, err)
+		require.NoError(t, kv.Set(context.Background(), 0, reEncryptionJobNamespace, reEncryptionJobStatusKey, string(stored)))
+
+		require.Equal(t, secrets.ReEncryptionJobCanceled, m.ReEncryptionJobStatus().State)
+		require.ErrorIs(t, m.PauseReEncryptionJob(), secrets.ErrReEncryptionJobNotRunning)
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+	})
+
+	t.Run("is canceled when the server shuts down", func(t *testing.T) {
+		proceed := make(chan struct{})
+		m := setupJobTest(fakeRotator{secrets: 2, proceed: proceed}, fakeRotator{secrets: 1, proceed: proceed})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error)
+		go func() {
+			runErr <- m.Run(ctx)
+		}()
+
+		require.NoError(t, m.StartReEncryptionJob(context.Background()))
+		require.NoError(t, m.PauseReEncryptionJob())
+		close(proceed)
+		cancel()
+		require.ErrorIs(t, <-runErr, context.Canceled)
+
+		status := m.ReEncryptionJobStatus()
+		require.Equal(t, secrets.ReEncryptionJobCanceled, status.State)
+		require.Equal(t, secrets.ReEncryptionJobIdle, status.Steps[1].State)
+	})
+}
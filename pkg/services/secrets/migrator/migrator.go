@@ -3,12 +3,15 @@ package migrator
 import (
 	"context"
 	"encoding/base64"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -21,18 +24,27 @@ type SecretsRotator interface {
 type SecretsMigrator struct {
 	encryptionSrv encryption.Internal
 	secretsSrv    *manager.SecretsService
+	secretsStore  secrets.Store
 	sqlStore      db.DB
 	settings      setting.Provider
 	features      featuremgmt.FeatureToggles
 	rotators      []SecretsRotator
+	jobStore      *reEncryptionJobStore
+
+	jobMu sync.Mutex
+	job   *reEncryptionJob
+	// syncReEncryption is set while ReEncryptSecrets runs, it is guarded by jobMu like the job.
+	syncReEncryption bool
 }
 
 func ProvideSecretsMigrator(
 	encryptionSrv encryption.Internal,
 	service *manager.SecretsService,
+	secretsStore secrets.Store,
 	sqlStore db.DB,
 	settings setting.Provider,
 	features featuremgmt.FeatureToggles,
+	kvStore kvstore.KVStore,
 ) *SecretsMigrator {
 	rotators := []SecretsRotator{
 		simpleSecret{tableName: "dashboard_snapshot", columnName: "dashboard_encrypted"},
@@ -50,10 +62,12 @@ func ProvideSecretsMigrator(
 	return &SecretsMigrator{
 		encryptionSrv: encryptionSrv,
 		secretsSrv:    service,
+		secretsStore:  secretsStore,
 		sqlStore:      sqlStore,
 		settings:      settings,
 		features:      features,
 		rotators:      rotators,
+		jobStore:      newReEncryptionJobStore(kvStore),
 	}
 }
 
@@ -67,6 +81,24 @@ func (m *SecretsMigrator) ReEncryptSecrets(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
+	m.jobMu.Lock()
+	busy, err := m.reEncryptionInProgress(ctx)
+	if err != nil || busy {
+		m.jobMu.Unlock()
+		if err != nil {
+			return false, err
+		}
+		return false, secrets.ErrReEncryptionJobInProgress
+	}
+	m.syncReEncryption = true
+	m.jobMu.Unlock()
+
+	defer func() {
+		m.jobMu.Lock()
+		m.syncReEncryption = false
+		m.jobMu.Unlock()
+	}()
+
 	var anyFailure bool
 
 	for _, r := range m.rotators {
@@ -10,9 +10,14 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/secrets/manager"
-	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
 )
 
+// The rotators list the rows to re-encrypt once, then re-read each row in the transaction re-encrypting it and only
+// write it back if it still holds the value that was re-encrypted, i.e. a compare-and-swap on the old value. A row
+// changed in between, e.g. by a user saving a data source, was written with the current data keys and is left as is,
+// instead of being overwritten with a re-encrypted copy of its previous value.
+
 func (s simpleSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.SecretsService, sqlStore db.DB) bool {
 	var rows []struct {
 		Id     int
@@ -28,36 +33,54 @@ func (s simpleSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.Secrets
 
 	var anyFailure bool
 
+	job := reEncryptionJobFromContext(ctx)
+	job.stepTotal(countNonEmpty(len(rows), func(i int) bool { return len(rows[i].Secret) > 0 }))
+
+	selectSQL := fmt.Sprintf("SELECT %s FROM %s WHERE id = ?", s.columnName, s.tableName)
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s = ?, updated = ? WHERE id = ? AND %s = ?", s.tableName, s.columnName, s.columnName)
 	for _, row := range rows {
 		if len(row.Secret) == 0 {
 			continue
 		}
 
+		if err := job.wait(ctx); err != nil {
+			anyFailure = true
+			break
+		}
+
 		err := sqlStore.InTransaction(ctx, func(ctx context.Context) error {
-			decrypted, err := secretsSrv.Decrypt(ctx, row.Secret)
-			if err != nil {
-				logger.Warn("Could not decrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			encrypted, err := secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
-			if err != nil {
-				logger.Warn("Could not encrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			updateSQL := fmt.Sprintf("UPDATE %s SET %s = ?, updated = ? WHERE id = ?", s.tableName, s.columnName)
-			if err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
-				_, err := sess.Exec(updateSQL, encrypted, nowInUTC(), row.Id)
-				return err
-			}); err != nil {
-				logger.Warn("Could not update secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			return nil
+			return sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+				var secret []byte
+				if _, err := sess.SQL(selectSQL, row.Id).Get(&secret); err != nil {
+					logger.Warn("Could not read secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+				if len(secret) == 0 {
+					return nil
+				}
+
+				decrypted, err := secretsSrv.Decrypt(ctx, secret)
+				if err != nil {
+					logger.Warn("Could not decrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				encrypted, err := secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
+				if err != nil {
+					logger.Warn("Could not encrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				if err := compareAndSwap(sess, s.tableName, row.Id, updateSQL, encrypted, nowInUTC(), row.Id, secret); err != nil {
+					logger.Warn("Could not update secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				return nil
+			})
 		})
 
+		job.secretProcessed(err == nil)
 		if err != nil {
 			anyFailure = true
 		}
@@ -87,48 +110,69 @@ func (s b64Secret) ReEncrypt(ctx context.Context, secretsSrv *manager.SecretsSer
 
 	var anyFailure bool
 
+	job := reEncryptionJobFromContext(ctx)
+	job.stepTotal(countNonEmpty(len(rows), func(i int) bool { return len(rows[i].Secret) > 0 }))
+
+	selectSQL := fmt.Sprintf("SELECT %s FROM %s WHERE id = ?", s.columnName, s.tableName)
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ? AND %s = ?", s.tableName, s.columnName, s.columnName)
+	if s.hasUpdatedColumn {
+		updateSQL = fmt.Sprintf("UPDATE %s SET %s = ?, updated = ? WHERE id = ? AND %s = ?", s.tableName, s.columnName, s.columnName)
+	}
 	for _, row := range rows {
 		if len(row.Secret) == 0 {
 			continue
 		}
 
+		if err := job.wait(ctx); err != nil {
+			anyFailure = true
+			break
+		}
+
 		err := sqlStore.InTransaction(ctx, func(ctx context.Context) error {
-			decoded, err := s.encoding.DecodeString(row.Secret)
-			if err != nil {
-				logger.Warn("Could not decode base64-encoded secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			decrypted, err := secretsSrv.Decrypt(ctx, decoded)
-			if err != nil {
-				logger.Warn("Could not decrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			encrypted, err := secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
-			if err != nil {
-				logger.Warn("Could not encrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			if err = sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) (err error) {
+			return sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+				var secret string
+				if _, err := sess.SQL(selectSQL, row.Id).Get(&secret); err != nil {
+					logger.Warn("Could not read secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+				if len(secret) == 0 {
+					return nil
+				}
+
+				decoded, err := s.encoding.DecodeString(secret)
+				if err != nil {
+					logger.Warn("Could not decode base64-encoded secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				decrypted, err := secretsSrv.Decrypt(ctx, decoded)
+				if err != nil {
+					logger.Warn("Could not decrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				encrypted, err := secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
+				if err != nil {
+					logger.Warn("Could not encrypt secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
 				encoded := s.encoding.EncodeToString(encrypted)
 				if s.hasUpdatedColumn {
-					updateSQL := fmt.Sprintf("UPDATE %s SET %s = ?, updated = ? WHERE id = ?", s.tableName, s.columnName)
-					_, err = sess.Exec(updateSQL, encoded, nowInUTC(), row.Id)
+					err = compareAndSwap(sess, s.tableName, row.Id, updateSQL, encoded, nowInUTC(), row.Id, secret)
 				} else {
-					updateSQL := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", s.tableName, s.columnName)
-					_, err = sess.Exec(updateSQL, encoded, row.Id)
+					err = compareAndSwap(sess, s.tableName, row.Id, updateSQL, encoded, row.Id, secret)
+				}
+				if err != nil {
+					logger.Warn("Could not update secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
+					return err
 				}
-				return
-			}); err != nil {
-				logger.Warn("Could not update secret while re-encrypting it", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
 
-			return nil
+				return nil
+			})
 		})
 
+		job.secretProcessed(err == nil)
 		if err != nil {
 			anyFailure = true
 		}
@@ -158,40 +202,67 @@ func (s jsonSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.SecretsSe
 
 	var anyFailure bool
 
+	job := reEncryptionJobFromContext(ctx)
+	job.stepTotal(countNonEmpty(len(rows), func(i int) bool { return len(rows[i].SecureJsonData) > 0 }))
+
+	selectSQL := fmt.Sprintf("SELECT secure_json_data FROM %s WHERE id = ?", s.tableName)
+	updateSQL := fmt.Sprintf("UPDATE %s SET secure_json_data = ?, updated = ? WHERE id = ? AND secure_json_data = ?", s.tableName)
 	for _, row := range rows {
 		if len(row.SecureJsonData) == 0 {
 			continue
 		}
 
+		if err := job.wait(ctx); err != nil {
+			anyFailure = true
+			break
+		}
+
 		err := sqlStore.InTransaction(ctx, func(ctx context.Context) error {
-			decrypted, err := secretsSrv.DecryptJsonData(ctx, row.SecureJsonData)
-			if err != nil {
-				logger.Warn("Could not decrypt secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			toUpdate := struct {
-				SecureJsonData map[string][]byte
-				Updated        string
-			}{Updated: nowInUTC()}
-
-			toUpdate.SecureJsonData, err = secretsSrv.EncryptJsonData(ctx, decrypted, secrets.WithoutScope())
-			if err != nil {
-				logger.Warn("Could not re-encrypt secrets", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			if err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
-				_, err := sess.Table(s.tableName).Where("id = ?", row.Id).Update(toUpdate)
-				return err
-			}); err != nil {
-				logger.Warn("Could not update secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
-				return err
-			}
-
-			return nil
+			return sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+				var raw string
+				if _, err := sess.SQL(selectSQL, row.Id).Get(&raw); err != nil {
+					logger.Warn("Could not read secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+				var secureJsonData map[string][]byte
+				if raw != "" {
+					if err := json.Unmarshal([]byte(raw), &secureJsonData); err != nil {
+						logger.Warn("Could not parse secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
+						return err
+					}
+				}
+				if len(secureJsonData) == 0 {
+					return nil
+				}
+
+				decrypted, err := secretsSrv.DecryptJsonData(ctx, secureJsonData)
+				if err != nil {
+					logger.Warn("Could not decrypt secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				reencrypted, err := secretsSrv.EncryptJsonData(ctx, decrypted, secrets.WithoutScope())
+				if err != nil {
+					logger.Warn("Could not re-encrypt secrets", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				marshalled, err := json.Marshal(reencrypted)
+				if err != nil {
+					logger.Warn("Could not marshal secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				if err := compareAndSwap(sess, s.tableName, row.Id, updateSQL, string(marshalled), nowInUTC(), row.Id, raw); err != nil {
+					logger.Warn("Could not update secrets while re-encrypting them", "table", s.tableName, "id", row.Id, "error", err)
+					return err
+				}
+
+				return nil
+			})
 		})
 
+		job.secretProcessed(err == nil)
 		if err != nil {
 			anyFailure = true
 		}
@@ -207,14 +278,11 @@ func (s jsonSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.SecretsSe
 }
 
 func (s alertingSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.SecretsService, sqlStore db.DB) bool {
-	var results []struct {
-		Id                        int
-		AlertmanagerConfiguration string
-	}
+	var ids []int
 
-	selectSQL := "SELECT id, alertmanager_configuration FROM alert_configuration"
+	selectSQL := "SELECT id FROM alert_configuration"
 	if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
-		return sess.SQL(selectSQL).Find(&results)
+		return sess.SQL(selectSQL).Find(&ids)
 	}); err != nil {
 		logger.Warn("Could not find any alert_configuration secret to re-encrypt")
 		return false
@@ -222,60 +290,83 @@ func (s alertingSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.Secre
 
 	var anyFailure bool
 
-	for _, result := range results {
-		result := result
+	job := reEncryptionJobFromContext(ctx)
+	job.stepTotal(int64(len(ids)))
+
+	for _, id := range ids {
+		id := id
+
+		if err := job.wait(ctx); err != nil {
+			anyFailure = true
+			break
+		}
 
 		err := sqlStore.InTransaction(ctx, func(ctx context.Context) error {
-			postableUserConfig, err := notifier.Load([]byte(result.AlertmanagerConfiguration))
-			if err != nil {
-				logger.Warn("Could not load alert_configuration while re-encrypting it", "id", result.Id, "error", err)
-				return err
-			}
-
-			for _, receiver := range postableUserConfig.AlertmanagerConfig.Receivers {
-				for _, gmr := range receiver.GrafanaManagedReceivers {
-					for k, v := range gmr.SecureSettings {
-						decoded, err := base64.StdEncoding.DecodeString(v)
-						if err != nil {
-							logger.Warn("Could not decode base64-encoded alert_configuration secret", "id", result.Id, "key", k, "error", err)
-							return err
-						}
+			return sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+				var config string
+				found, err := sess.SQL("SELECT alertmanager_configuration FROM alert_configuration WHERE id = ?", id).Get(&config)
+				if err != nil {
+					logger.Warn("Could not read alert_configuration while re-encrypting it", "id", id, "error", err)
+					return err
+				}
+				if !found {
+					return nil
+				}
 
-						decrypted, err := secretsSrv.Decrypt(ctx, decoded)
-						if err != nil {
-							logger.Warn("Could not decrypt alert_configuration secret", "id", result.Id, "key", k, "error", err)
-							return err
-						}
+				postableUserConfig, err := notifier.Load([]byte(config))
+				if err != nil {
+					logger.Warn("Could not load alert_configuration while re-encrypting it", "id", id, "error", err)
+					return err
+				}
 
-						reencrypted, err := secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
-						if err != nil {
-							logger.Warn("Could not re-encrypt alert_configuration secret", "id", result.Id, "key", k, "error", err)
-							return err
+				for _, receiver := range postableUserConfig.AlertmanagerConfig.Receivers {
+					for _, gmr := range receiver.GrafanaManagedReceivers {
+						for k, v := range gmr.SecureSettings {
+							decoded, err := base64.StdEncoding.DecodeString(v)
+							if err != nil {
+								logger.Warn("Could not decode base64-encoded alert_configuration secret", "id", id, "key", k, "error", err)
+								return err
+							}
+
+							if _, _, _, ok := ualert.ParseSecretsKVReference(decoded); ok {
+								// The secret is stored in the secrets kvstore, which is re-encrypted with the secrets table.
+								continue
+							}
+
+							decrypted, err := secretsSrv.Decrypt(ctx, decoded)
+							if err != nil {
+								logger.Warn("Could not decrypt alert_configuration secret", "id", id, "key", k, "error", err)
+								return err
+							}
+
+							reencrypted, err := secretsSrv.Encrypt(ctx, decrypted, secrets.WithoutScope())
+							if err != nil {
+								logger.Warn("Could not re-encrypt alert_configuration secret", "id", id, "key", k, "error", err)
+								return err
+							}
+
+							gmr.SecureSettings[k] = base64.StdEncoding.EncodeToString(reencrypted)
 						}
-
-						gmr.SecureSettings[k] = base64.StdEncoding.EncodeToString(reencrypted)
 					}
 				}
-			}
-
-			marshalled, err := json.Marshal(postableUserConfig)
-			if err != nil {
-				logger.Warn("Could not marshal alert_configuration while re-encrypting it", "id", result.Id, "error", err)
-				return err
-			}
-
-			result.AlertmanagerConfiguration = string(marshalled)
-			if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
-				_, err := sess.Table("alert_configuration").Where("id = ?", result.Id).Update(&result)
-				return err
-			}); err != nil {
-				logger.Warn("Could not update alert_configuration secret while re-encrypting it", "id", result.Id, "error", err)
-				return err
-			}
-
-			return nil
+
+				marshalled, err := json.Marshal(postableUserConfig)
+				if err != nil {
+					logger.Warn("Could not marshal alert_configuration while re-encrypting it", "id", id, "error", err)
+					return err
+				}
+
+				updateSQL := "UPDATE alert_configuration SET alertmanager_configuration = ? WHERE id = ? AND alertmanager_configuration = ?"
+				if err := compareAndSwap(sess, "alert_configuration", id, updateSQL, string(marshalled), id, config); err != nil {
+					logger.Warn("Could not update alert_configuration secret while re-encrypting it", "id", id, "error", err)
+					return err
+				}
+
+				return nil
+			})
 		})
 
+		job.secretProcessed(err == nil)
 		if err != nil {
 			anyFailure = true
 		}
@@ -289,3 +380,27 @@ func (s alertingSecret) ReEncrypt(ctx context.Context, secretsSrv *manager.Secre
 
 	return !anyFailure
 }
+
+// compareAndSwap runs the update of the re-encrypted secret of the row, whose last argument is the value it was
+// re-encrypted from. The row is left as is if its value changed in the meantime.
+func compareAndSwap(sess *db.Session, table string, id int, updateSQL string, args ...any) error {
+	res, err := sess.Exec(append([]any{updateSQL}, args...)...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		logger.Debug("Secret changed while re-encrypting it, keeping the new value", "table", table, "id", id)
+	}
+	return nil
+}
+
+// countNonEmpty returns the number of the n rows that hold secrets to re-encrypt.
+func countNonEmpty(n int, nonEmpty func(i int) bool) int64 {
+	var count int64
+	for i := 0; i < n; i++ {
+		if nonEmpty(i) {
+			count++
+		}
+	}
+	return count
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
 )
 
 func (s simpleSecret) Rollback(
@@ -249,6 +250,11 @@ func (s alertingSecret) Rollback(
 							return err
 						}
 
+						if _, _, _, ok := ualert.ParseSecretsKVReference(decoded); ok {
+							// The secret is stored in the secrets kvstore, which is rolled back with the secrets table.
+							continue
+						}
+
 						decrypted, err := secretsSrv.Decrypt(ctx, decoded)
 						if err != nil {
 							logger.Warn("Could not decrypt secret (alert_configuration with id: %d, key)", k, result.Id, err)
@@ -0,0 +1,234 @@
+package migrator
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+// SecretsScanner can be implemented by a SecretsRotator to report which data keys its secrets are encrypted with.
+type SecretsScanner interface {
+	// DataKeyIDs returns the number of secrets encrypted with each data key.
+	DataKeyIDs(context.Context, db.DB) (map[string]int64, error)
+}
+
+func (m *SecretsMigrator) RotateDataKeys(ctx context.Context, reEncrypt bool) error {
+	if !reEncrypt {
+		return m.secretsSrv.RotateDataKeys(ctx)
+	}
+
+	if err := m.initProvidersIfNeeded(); err != nil {
+		return err
+	}
+
+	// jobMu is held until the job is started, so that no other re-encryption can start after the
+	// check and make the job fail once the data keys are rotated.
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+
+	busy, err := m.reEncryptionInProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if busy {
+		return secrets.ErrReEncryptionJobInProgress
+	}
+
+	if err := m.secretsSrv.RotateDataKeys(ctx); err != nil {
+		return err
+	}
+
+	return m.startReEncryptionJob(ctx)
+}
+
+func (m *SecretsMigrator) GetRetiredDataKeysUsage(ctx context.Context) ([]secrets.RetiredDataKeyUsage, error) {
+	dataKeys, err := m.secretsStore.GetAllDataKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	retired := make(map[string]*secrets.RetiredDataKeyUsage)
+	for _, dk := range dataKeys {
+		if dk.Active {
+			continue
+		}
+
+		retired[dk.Id] = &secrets.RetiredDataKeyUsage{
+			Id:        dk.Id,
+			Label:     dk.Label,
+			Scope:     dk.Scope,
+			Provider:  dk.Provider,
+			Created:   dk.Created,
+			Consumers: make(map[string]int64),
+		}
+	}
+
+	// The kinds of secrets whose data keys cannot be found are reported, since the retired data keys
+	// may still be used by them.
+	var unscanned []string
+	if len(retired) > 0 {
+		for _, r := range m.rotators {
+			scanner, ok := r.(SecretsScanner)
+			if !ok {
+				unscanned = append(unscanned, rotatorName(r))
+				continue
+			}
+
+			ids, err := scanner.DataKeyIDs(ctx, m.sqlStore)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find the data keys of %s: %w", rotatorName(r), err)
+			}
+
+			for id, count := range ids {
+				if usage, ok := retired[id]; ok {
+					usage.Consumers[rotatorName(r)] += count
+				}
+			}
+		}
+	}
+
+	result := make([]secrets.RetiredDataKeyUsage, 0, len(retired))
+	for _, usage := range retired {
+		usage.Complete = len(unscanned) == 0
+		usage.Unscanned = unscanned
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Created.Equal(result[j].Created) {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].Created.Before(result[j].Created)
+	})
+
+	return result, nil
+}
+
+func (s simpleSecret) DataKeyIDs(ctx context.Context, sqlStore db.DB) (map[string]int64, error) {
+	var rows []struct {
+		Id     int
+		Secret []byte
+	}
+
+	if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table(s.tableName).Select(fmt.Sprintf("id, %s as secret", s.columnName)).Find(&rows)
+	}); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int64)
+	for _, row := range rows {
+		countDataKeyID(ids, row.Secret, s.tableName, row.Id)
+	}
+
+	return ids, nil
+}
+
+func (s b64Secret) DataKeyIDs(ctx context.Context, sqlStore db.DB) (map[string]int64, error) {
+	var rows []struct {
+		Id     int
+		Secret string
+	}
+
+	if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table(s.tableName).Select(fmt.Sprintf("id, %s as secret", s.columnName)).Find(&rows)
+	}); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int64)
+	for _, row := range rows {
+		if len(row.Secret) == 0 {
+			continue
+		}
+
+		decoded, err := s.encoding.DecodeString(row.Secret)
+		if err != nil {
+			logger.Warn("Could not decode base64-encoded secret while looking for its data key", "table", s.tableName, "id", row.Id, "error", err)
+			continue
+		}
+
+		countDataKeyID(ids, decoded, s.tableName, row.Id)
+	}
+
+	return ids, nil
+}
+
+func (s jsonSecret) DataKeyIDs(ctx context.Context, sqlStore db.DB) (map[string]int64, error) {
+	var rows []struct {
+		Id             int
+		SecureJsonData map[string][]byte
+	}
+
+	if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table(s.tableName).Cols("id", "secure_json_data").Find(&rows)
+	}); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int64)
+	for _, row := range rows {
+		for _, secret := range row.SecureJsonData {
+			countDataKeyID(ids, secret, s.tableName, row.Id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s alertingSecret) DataKeyIDs(ctx context.Context, sqlStore db.DB) (map[string]int64, error) {
+	var results []struct {
+		Id                        int
+		AlertmanagerConfiguration string
+	}
+
+	selectSQL := "SELECT id, alertmanager_configuration FROM alert_configuration"
+	if err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.SQL(selectSQL).Find(&results)
+	}); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int64)
+	for _, result := range results {
+		postableUserConfig, err := notifier.Load([]byte(result.AlertmanagerConfiguration))
+		if err != nil {
+			logger.Warn("Could not load alert_configuration while looking for its data keys", "id", result.Id, "error", err)
+			continue
+		}
+
+		for _, receiver := range postableUserConfig.AlertmanagerConfig.Receivers {
+			for _, gmr := range receiver.GrafanaManagedReceivers {
+				for k, v := range gmr.SecureSettings {
+					decoded, err := base64.StdEncoding.DecodeString(v)
+					if err != nil {
+						logger.Warn("Could not decode base64-encoded alert_configuration secret", "id", result.Id, "key", k, "error", err)
+						continue
+					}
+
+					countDataKeyID(ids, decoded, "alert_configuration", result.Id)
+				}
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// countDataKeyID counts the secret against the data key it is encrypted with, if it is encrypted with envelope encryption.
+func countDataKeyID(ids map[string]int64, secret []byte, table string, id int) {
+	dataKeyID, err := manager.DataKeyID(secret)
+	if err != nil {
+		logger.Warn("Could not find the data key of secret", "table", table, "id", id, "error", err)
+		return
+	}
+
+	if dataKeyID != "" {
+		ids[dataKeyID]++
+	}
+}
@@ -0,0 +1,53 @@
+package migrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+)
+
+type fakeScanner struct {
+	fakeRotator
+	ids map[string]int64
+}
+
+func (s fakeScanner) DataKeyIDs(context.Context, db.DB) (map[string]int64, error) {
+	return s.ids, nil
+}
+
+func TestGetRetiredDataKeysUsage(t *testing.T) {
+	store := fakes.NewFakeSecretsStore()
+	require.NoError(t, store.CreateDataKey(context.Background(), &secrets.DataKey{Id: "retired", Created: time.Now()}))
+	require.NoError(t, store.CreateDataKey(context.Background(), &secrets.DataKey{Id: "active", Active: true, Created: time.Now()}))
+
+	t.Run("is complete when every kind of secret is checked", func(t *testing.T) {
+		m := setupJobTest(fakeScanner{ids: map[string]int64{"retired": 2, "active": 1}})
+		m.secretsStore = store
+
+		usage, err := m.GetRetiredDataKeysUsage(context.Background())
+		require.NoError(t, err)
+		require.Len(t, usage, 1)
+		require.Equal(t, "retired", usage[0].Id)
+		require.True(t, usage[0].Complete)
+		require.Empty(t, usage[0].Unscanned)
+		require.Len(t, usage[0].Consumers, 1)
+	})
+
+	t.Run("reports the kinds of secrets that cannot be checked", func(t *testing.T) {
+		m := setupJobTest(fakeScanner{}, fakeRotator{})
+		m.secretsStore = store
+
+		usage, err := m.GetRetiredDataKeysUsage(context.Background())
+		require.NoError(t, err)
+		require.Len(t, usage, 1)
+		require.Empty(t, usage[0].Consumers)
+		require.False(t, usage[0].Complete)
+		require.Equal(t, []string{rotatorName(fakeRotator{})}, usage[0].Unscanned)
+	})
+}
@@ -72,11 +72,29 @@ type Migrator interface {
 	// ReEncryptSecrets decrypts and re-encrypts the secrets with most recent
 	// available data key. If a secret-specific decryption / re-encryption fails,
 	// it does not stop, but returns false as the first return (success or not)
-	// at the end of the process.
+	// at the end of the process. It returns ErrReEncryptionJobInProgress if
+	// a re-encryption job is running or paused.
 	ReEncryptSecrets(ctx context.Context) (bool, error)
 	// RollBackSecrets decrypts and re-encrypts the secrets using the legacy
 	// encryption. If a secret-specific decryption / re-encryption fails, it
 	// does not stop, but returns false as the first return (success or not)
 	// at the end of the process.
 	RollBackSecrets(ctx context.Context) (bool, error)
+
+	// StartReEncryptionJob starts re-encrypting the secrets like ReEncryptSecrets, but in the
+	// background. It returns ErrReEncryptionJobInProgress if a job is running or paused, or if
+	// ReEncryptSecrets is running.
+	StartReEncryptionJob(ctx context.Context) error
+	// PauseReEncryptionJob pauses the running job after the secret being re-encrypted.
+	PauseReEncryptionJob() error
+	// ResumeReEncryptionJob resumes the paused job.
+	ResumeReEncryptionJob() error
+	// ReEncryptionJobStatus returns the progress of the last started job.
+	ReEncryptionJobStatus() ReEncryptionJobStatus
+
+	// RotateDataKeys disables the active data keys, so new ones are created to encrypt secrets,
+	// and starts the re-encryption job if reEncrypt is set to re-encrypt the existing secrets with them.
+	RotateDataKeys(ctx context.Context, reEncrypt bool) error
+	// GetRetiredDataKeysUsage returns the data keys disabled by a rotation, and the secrets that still reference them.
+	GetRetiredDataKeysUsage(ctx context.Context) ([]RetiredDataKeyUsage, error)
 }
@@ -7,6 +7,12 @@ import (
 
 var ErrDataKeyNotFound = errors.New("data key not found")
 
+var (
+	ErrReEncryptionJobInProgress = errors.New("secrets re-encryption job already in progress")
+	ErrReEncryptionJobNotRunning = errors.New("secrets re-encryption job is not running")
+	ErrReEncryptionJobNotPaused  = errors.New("secrets re-encryption job is not paused")
+)
+
 type DataKey struct {
 	Active        bool
 	Id            string `xorm:"name"` // renaming the col in the db itself would break backward compatibility with 8.5.x
@@ -35,3 +41,54 @@ func WithScope(scope string) EncryptionOptions {
 		return scope
 	}
 }
+
+type ReEncryptionJobState string
+
+const (
+	ReEncryptionJobIdle      ReEncryptionJobState = "idle"
+	ReEncryptionJobRunning   ReEncryptionJobState = "running"
+	ReEncryptionJobPaused    ReEncryptionJobState = "paused"
+	ReEncryptionJobCompleted ReEncryptionJobState = "completed"
+	ReEncryptionJobFailed    ReEncryptionJobState = "failed"
+	ReEncryptionJobCanceled  ReEncryptionJobState = "canceled"
+)
+
+// ReEncryptionJobStatus is the progress of the background job re-encrypting the secrets.
+type ReEncryptionJobStatus struct {
+	State      ReEncryptionJobState `json:"state"`
+	StartedAt  *time.Time           `json:"startedAt,omitempty"`
+	FinishedAt *time.Time           `json:"finishedAt,omitempty"`
+	// Processed and Failed are the number of secrets re-encrypted, successfully or not, across all steps.
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	// Total is the number of secrets to re-encrypt in the steps started so far.
+	Total int64 `json:"total"`
+	// Steps are the kinds of secrets re-encrypted by the job, in the order they are processed.
+	Steps []ReEncryptionStepStatus `json:"steps"`
+}
+
+// ReEncryptionStepStatus is the progress of the re-encryption of one kind of secret, e.g. a table column.
+type ReEncryptionStepStatus struct {
+	Name      string               `json:"name"`
+	State     ReEncryptionJobState `json:"state"`
+	Processed int64                `json:"processed"`
+	Failed    int64                `json:"failed"`
+	// Total is the number of secrets to re-encrypt in the step, it is known once the step has started.
+	Total int64 `json:"total"`
+}
+
+// RetiredDataKeyUsage reports a data key disabled by a rotation, and the secrets still encrypted with it.
+type RetiredDataKeyUsage struct {
+	Id       string     `json:"id"`
+	Label    string     `json:"label"`
+	Scope    string     `json:"scope"`
+	Provider ProviderID `json:"provider"`
+	Created  time.Time  `json:"created"`
+	// Consumers are the number of secrets encrypted with the data key, by kind of secret,
+	// e.g. "data_source.secure_json_data". The data key is no longer used if it is empty and Complete is set.
+	Consumers map[string]int64 `json:"consumers"`
+	// Complete is whether every kind of secret was checked for the data key. Otherwise the kinds of
+	// secrets that could not be checked are listed in Unscanned, and may still be encrypted with it.
+	Complete  bool     `json:"complete"`
+	Unscanned []string `json:"unscanned,omitempty"`
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addFeatureToggleStateMigrations(mg *Migrator) {
+	featureToggleStateV1 := Table{
+		Name: "feature_toggle_state",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create feature_toggle_state table v1", NewAddTableMigration(featureToggleStateV1))
+
+	mg.AddMigration("add unique index feature_toggle_state.name", NewAddIndexMigration(featureToggleStateV1, featureToggleStateV1.Indices[0]))
+
+	featureToggleSnapshotV1 := Table{
+		Name: "feature_toggle_snapshot",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created_by", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "states", Type: DB_Text, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create feature_toggle_snapshot table v1", NewAddTableMigration(featureToggleSnapshotV1))
+
+	mg.AddMigration("add unique index feature_toggle_snapshot.name", NewAddIndexMigration(featureToggleSnapshotV1, featureToggleSnapshotV1.Indices[0]))
+}
@@ -101,6 +101,7 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 
 	anonservice.AddMigration(mg)
 	signingkeys.AddMigration(mg)
+	addFeatureToggleStateMigrations(mg)
 }
 
 func addStarMigrations(mg *Migrator) {
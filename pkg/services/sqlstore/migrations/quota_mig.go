@@ -28,4 +28,22 @@ func addQuotaMigration(mg *Migrator) {
 	mg.AddMigration("Update quota table charset", NewTableCharsetMigration("quota", []*Column{
 		{Name: "target", Type: DB_NVarchar, Length: 190, Nullable: false},
 	}))
+
+	var quotaFolderV1 = Table{
+		Name: "quota_folder",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "folder_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "target", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "limit", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "folder_uid", "target"}, Type: UniqueIndex},
+		},
+	}
+	mg.AddMigration("create quota_folder table v1", NewAddTableMigration(quotaFolderV1))
+	addTableIndicesMigrations(mg, "v1", quotaFolderV1)
 }
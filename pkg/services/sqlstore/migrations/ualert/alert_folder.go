@@ -0,0 +1,195 @@
+package ualert
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// alertFolders gets the folders that the alerts are migrated to. The migration gets or creates them, its preview only
+// needs their titles, see alertFolder.
+type alertFolders interface {
+	// targetFolder returns the folder configured for the alerts of the organisation.
+	targetFolder(orgID int64, uid string) (*dashboard, error)
+	// permissionsFolder returns the folder with the given title for the alerts of a dashboard with custom permissions.
+	permissionsFolder(l log.Logger, dash *dashboard, title string) (*dashboard, error)
+	// orphanFolder returns the folder with the given title for the alerts of the dashboards whose folder does not exist.
+	orphanFolder(orgID int64, title string) (*dashboard, error)
+	// generalFolder returns the General folder of the organisation.
+	generalFolder(orgID int64) (*dashboard, error)
+}
+
+// alertFolder returns the folder that the alert of the dashboard is migrated to, or nil if the alert is skipped.
+func (m *migration) alertFolder(l log.Logger, folders alertFolders, dash *dashboard, da dashAlert) (*dashboard, error) {
+	switch {
+	case m.upgradeCfg.TargetFolderUIDs[dash.OrgId] != "":
+		f, err := folders.targetFolder(dash.OrgId, m.upgradeCfg.TargetFolderUIDs[dash.OrgId])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get target folder under organisation %d: %w", dash.OrgId, err)
+		}
+		return f, nil
+	case dash.HasACL:
+		folderName, err := m.folderName(dash)
+		if err != nil {
+			return nil, err
+		}
+		return folders.permissionsFolder(l, dash, folderName)
+	case dash.FolderId > 0:
+		// get folder if exists
+		helper := folderHelper{sess: m.sess, mg: m.mg}
+		f, err := helper.getFolder(*dash, da)
+		if err == nil {
+			return &f, nil
+		}
+		// If folder does not exist then the dashboard is an orphan, its alerts are handled as configured.
+		orphanErr := fmt.Errorf("folder %d of dashboard %s of alert %q (ID %d) not found", dash.FolderId, da.DashboardUID, da.Name, da.Id)
+		switch m.upgradeCfg.OrphanedAlerts {
+		case setting.OrphanedAlertsFail:
+			return nil, fmt.Errorf("%s: %w", orphanErr, err)
+		case setting.OrphanedAlertsSkip:
+			l.Warn("Failed to find folder for dashboard. Skip rule", "rule_name", da.Name, "dashboard_uid", da.DashboardUID, "missing_folder_id", dash.FolderId)
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("%s, alert not migrated", orphanErr))
+			return nil, nil
+		case setting.OrphanedAlertsFolder:
+			title := m.upgradeCfg.OrphanedAlertsFolderTitle
+			l.Warn("Failed to find folder for dashboard. Migrate rule to the folder for orphaned alerts", "rule_name", da.Name, "dashboard_uid", da.DashboardUID, "missing_folder_id", dash.FolderId, "folder", title)
+			f, err := folders.orphanFolder(dash.OrgId, title)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get or create folder %q for orphaned alerts under organisation %d: %w", title, dash.OrgId, err)
+			}
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("%s, alert migrated to folder %q", orphanErr, title))
+			return f, nil
+		default:
+			l.Warn("Failed to find folder for dashboard. Migrate rule to the default folder", "rule_name", da.Name, "dashboard_uid", da.DashboardUID, "missing_folder_id", dash.FolderId)
+			f, err := folders.generalFolder(dash.OrgId)
+			if err != nil {
+				return nil, err
+			}
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("%s, alert migrated to folder %q", orphanErr, f.Title))
+			return f, nil
+		}
+	default:
+		return folders.generalFolder(dash.OrgId)
+	}
+}
+
+// migrationFolders gets or creates the folders of the migrated alerts, and caches them for the other alerts.
+type migrationFolders struct {
+	m      *migration
+	helper folderHelper
+	// cache for folders created for dashboards that have custom permissions
+	permissions map[string]*dashboard
+	// cache for the general folders
+	general map[int64]*dashboard
+	// cache for the target folders of organisations that have one configured
+	target map[int64]*dashboard
+	// cache for the folders of orphaned alerts, per organization
+	orphan map[int64]*dashboard
+}
+
+func newMigrationFolders(m *migration, helper folderHelper) *migrationFolders {
+	return &migrationFolders{
+		m:           m,
+		helper:      helper,
+		permissions: make(map[string]*dashboard),
+		general:     make(map[int64]*dashboard),
+		target:      make(map[int64]*dashboard),
+		orphan:      make(map[int64]*dashboard),
+	}
+}
+
+// forgetCreated returns a function that removes the folders cached since it was called, see forgetCachedFolders.
+func (f *migrationFolders) forgetCreated() func() {
+	restore := []func(){
+		forgetCachedFolders(f.permissions),
+		forgetCachedFolders(f.general),
+		forgetCachedFolders(f.target),
+		forgetCachedFolders(f.orphan),
+	}
+	return func() {
+		for _, r := range restore {
+			r()
+		}
+	}
+}
+
+func (f *migrationFolders) targetFolder(orgID int64, uid string) (*dashboard, error) {
+	folder, ok := f.target[orgID]
+	if !ok {
+		var err error
+		folder, err = f.helper.getFolderByUID(orgID, uid)
+		if err != nil {
+			return nil, err
+		}
+		f.target[orgID] = folder
+	}
+	return folder, nil
+}
+
+func (f *migrationFolders) permissionsFolder(l log.Logger, dash *dashboard, title string) (*dashboard, error) {
+	folder, ok := f.permissions[title]
+	if uid, migrated := f.m.migrated.get(auditResourceFolder, dash.OrgId, dash.Id); !ok && migrated {
+		folder = &dashboard{}
+		if _, err := f.m.sess.Where("org_id=? AND uid=?", dash.OrgId, uid).Get(folder); err != nil {
+			return nil, fmt.Errorf("failed to get previously migrated folder %s: %w", uid, err)
+		}
+		l.Info("Reuse the folder created by a previous migration for alerts that belong to dashboard", "folder", folder.Title)
+		f.permissions[title] = folder
+		ok = true
+	}
+	if ok {
+		return folder, nil
+	}
+
+	l.Info("Create a new folder for alerts that belongs to dashboard because it has custom permissions", "folder", title)
+	created, permissions, report, err := f.helper.createDashboardFolder(dash, title)
+	if err == nil {
+		l.Info("Copied dashboard permissions to folder", "folder", created.Title, "folderUID", created.Uid, "acl", len(permissions), "users", report.users, "serviceAccounts", report.serviceAccounts, "teams", report.teams, "basicRoles", report.basicRoles)
+		f.m.audit.recordCreate(created.OrgId, auditResourceFolder, created.Uid, dash.Id, dash.Uid)
+		folder = created
+	} else {
+		// The General folder would expose the alerts to every user, unless the fallback is enabled they fail.
+		if !f.m.upgradeCfg.GeneralFolderFallback {
+			return nil, fmt.Errorf("failed to create folder %q for the alerts of dashboard %s with custom permissions: %w", title, dash.Uid, err)
+		}
+		l.Warn("Alert migration warning: failed to create folder for dashboard with custom permissions, migrating its alerts to the General folder", "folder", title, "error", err)
+		f.m.orgStates.recordError(dash.OrgId, fmt.Errorf("folder %q for the alerts of dashboard %s not created, they are migrated to the %s folder without the permissions of the dashboard: %w", title, dash.Uid, GENERAL_FOLDER, err))
+		folder, err = f.generalFolder(dash.OrgId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.permissions[title] = folder
+	return folder, nil
+}
+
+func (f *migrationFolders) orphanFolder(orgID int64, title string) (*dashboard, error) {
+	folder, ok := f.orphan[orgID]
+	if !ok {
+		var err error
+		folder, err = f.helper.getOrCreateFolder(orgID, title)
+		if err != nil {
+			return nil, err
+		}
+		f.orphan[orgID] = folder
+	}
+	return folder, nil
+}
+
+func (f *migrationFolders) generalFolder(orgID int64) (*dashboard, error) {
+	folder, ok := f.general[orgID]
+	if !ok {
+		// get or create general folder
+		var err error
+		folder, err = f.helper.getOrCreateGeneralFolder(orgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get or create general folder under organisation %d: %w", orgID, err)
+		}
+		f.general[orgID] = folder
+	}
+	// No need to assign default permissions to general folder
+	// because they are included to the query result if it's a folder with no permissions
+	// https://github.com/grafana/grafana/blob/076e2ce06a6ecf15804423fcc8dca1b620a321e5/pkg/services/sqlstore/dashboard_acl.go#L109
+	return folder, nil
+}
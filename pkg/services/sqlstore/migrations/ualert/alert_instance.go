@@ -0,0 +1,111 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	alertingModels "github.com/grafana/alerting/models"
+	"github.com/prometheus/common/model"
+
+	legacymodels "github.com/grafana/grafana/pkg/services/alerting/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// alertInstanceResendDelay is the minimum interval at which the state of firing alerts is sent to the Alertmanager,
+// copied from the state package to compute the end of the seeded states.
+const alertInstanceResendDelay = 30 * time.Second
+
+// seedAlertInstances writes the state of the legacy alerts that are alerting, pending or ok to the alert_instance table
+// for the alert rules they were migrated to, so that the alert rules do not start pending again after the migration.
+// Only the alert rules with a classic condition are seeded, because their only alert instance has the labels of the
+// rule, and paused alert rules are not seeded.
+func (m *migration) seedAlertInstances(rules map[*alertRule][]uidOrID) error {
+	now := time.Now()
+	seeded := 0
+	for rule := range rules {
+		state, ok := seededInstanceState(rule.legacyState)
+		if !ok || rule.IsPaused || !hasClassicCondition(rule) {
+			continue
+		}
+
+		labels := ngmodels.InstanceLabels(alertInstanceLabels(rule, !m.folderLabelDisabled))
+		key, hash, err := labels.StringAndHash()
+		if err != nil {
+			return err
+		}
+
+		since := rule.legacyStateSince
+		if since.IsZero() || since.After(now) {
+			since = now
+		}
+		end := now
+		if state != ngmodels.InstanceStateNormal {
+			resend := alertInstanceResendDelay
+			if interval := time.Duration(rule.IntervalSeconds) * time.Second; interval > resend {
+				resend = interval
+			}
+			end = now.Add(3 * resend)
+		}
+
+		if _, err := m.sess.Exec("DELETE FROM alert_instance WHERE rule_org_id = ? AND rule_uid = ?", rule.OrgID, rule.UID); err != nil {
+			return fmt.Errorf("failed to clear the state of alert rule %s: %w", rule.UID, err)
+		}
+		_, err = m.sess.Exec("INSERT INTO alert_instance (rule_org_id, rule_uid, labels, labels_hash, current_state, current_reason, current_state_since, current_state_end, last_eval_time) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			rule.OrgID, rule.UID, key, hash, string(state), "", since.Unix(), end.Unix(), now.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to seed the state of alert rule %s: %w", rule.UID, err)
+		}
+		seeded++
+	}
+	m.mg.Logger.Info("Seeded the state of migrated alert rules from the legacy alerts", "rules", seeded)
+	return nil
+}
+
+// seededInstanceState returns the state of an alert instance for the state of a legacy alert, and false if the state
+// is not seeded. The state of legacy alerts without data depends on the no data state of the rule and is not seeded.
+func seededInstanceState(legacyState string) (ngmodels.InstanceStateType, bool) {
+	switch legacymodels.AlertStateType(legacyState) {
+	case legacymodels.AlertStateAlerting:
+		return ngmodels.InstanceStateFiring, true
+	case legacymodels.AlertStatePending:
+		return ngmodels.InstanceStatePending, true
+	case legacymodels.AlertStateOK:
+		return ngmodels.InstanceStateNormal, true
+	default:
+		return "", false
+	}
+}
+
+// hasClassicCondition returns true if the condition of the alert rule is a classic condition expression.
+func hasClassicCondition(rule *alertRule) bool {
+	for _, q := range rule.Data {
+		if q.RefID != rule.Condition || q.DatasourceUID != expressionDatasourceUID {
+			continue
+		}
+		var model struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(q.Model, &model); err != nil {
+			return false
+		}
+		return model.Type == "classic_conditions"
+	}
+	return false
+}
+
+// alertInstanceLabels returns the labels of the alert instance of an alert rule with a classic condition: the labels of
+// the rule and the labels that the scheduler adds, which take precedence.
+func alertInstanceLabels(rule *alertRule, includeFolder bool) map[string]string {
+	labels := make(map[string]string, len(rule.Labels)+4)
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+	labels[alertingModels.NamespaceUIDLabel] = rule.NamespaceUID
+	labels[model.AlertNameLabel] = rule.Title
+	labels[alertingModels.RuleUIDLabel] = rule.UID
+	if includeFolder {
+		labels[ngmodels.FolderTitleLabel] = rule.folderTitle
+	}
+	return labels
+}
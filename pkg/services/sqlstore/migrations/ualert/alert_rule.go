@@ -3,12 +3,15 @@ package ualert
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	legacymodels "github.com/grafana/grafana/pkg/services/alerting/models"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/graphite"
 )
 
@@ -17,6 +20,15 @@ const (
 	// It stores a string array of all contact point names an alert rule should send to.
 	// It was created as a means to simplify post-migration notification policies.
 	ContactLabel = "__contacts__"
+
+	// PausedByMigrationLabel is a private label set on the alert rules that the migration created paused although their
+	// legacy alert was not paused. The rules are resumed by removing the label, see the pause_migrated_rules setting.
+	PausedByMigrationLabel = "__paused_by_migration__"
+
+	// MissingDatasourceAnnotation is a private annotation set on the alert rules that the migration created paused
+	// because queries of their legacy alert reference a data source that does not exist. It lists the RefIDs of the
+	// queries, see the missing_datasource setting.
+	MissingDatasourceAnnotation = "__missing_datasource__"
 )
 
 type alertRule struct {
@@ -38,6 +50,16 @@ type alertRule struct {
 	Annotations     map[string]string
 	Labels          map[string]string
 	IsPaused        bool
+
+	// folderTitle is the title of the folder of the rule, used to build nested notification policies.
+	folderTitle string `xorm:"-"`
+	// legacyState and legacyStateSince are the state of the legacy alert and the time it changed, used to seed the
+	// state of the rule.
+	legacyState      string    `xorm:"-"`
+	legacyStateSince time.Time `xorm:"-"`
+	// keepStateAlerts are the names of the DatasourceError and DatasourceNoData alerts of the rule that are routed by
+	// the keep_state_routing setting.
+	keepStateAlerts []string `xorm:"-"`
 }
 
 type alertRuleVersion struct {
@@ -90,14 +112,30 @@ func (a *alertRule) makeVersion() *alertRuleVersion {
 	}
 }
 
-func addMigrationInfo(da *dashAlert) (map[string]string, map[string]string) {
-	tagsMap := simplejson.NewFromAny(da.ParsedSettings.AlertRuleTags).MustMap()
+// addMigrationInfo returns the labels migrated from the alert rule tags of the legacy alert and the annotations that
+// link the alert rule to it. arrayTags is the array_alert_rule_tags setting for tags stored as a JSON array.
+func addMigrationInfo(da *dashAlert, arrayTags string) (map[string]string, map[string]string) {
+	tags := simplejson.NewFromAny(da.ParsedSettings.AlertRuleTags)
+	tagsMap := tags.MustMap()
 	lbls := make(map[string]string, len(tagsMap))
 
 	for k, v := range tagsMap {
 		lbls[k] = simplejson.NewFromAny(v).MustString()
 	}
 
+	switch arrayTags {
+	case setting.ArrayAlertRuleTagsIndex:
+		for i, v := range tags.MustArray() {
+			lbls[fmt.Sprintf("tag_%d", i)] = tagString(v)
+		}
+	case setting.ArrayAlertRuleTagsKey:
+		for _, v := range tags.MustArray() {
+			if s := tagString(v); s != "" {
+				lbls[s] = "true"
+			}
+		}
+	}
+
 	annotations := make(map[string]string, 3)
 	annotations[ngmodels.DashboardUIDAnnotation] = da.DashboardUID
 	annotations[ngmodels.PanelIDAnnotation] = fmt.Sprintf("%v", da.PanelId)
@@ -106,27 +144,101 @@ func addMigrationInfo(da *dashAlert) (map[string]string, map[string]string) {
 	return lbls, annotations
 }
 
+// tagString returns the string form of an element of alert rule tags stored as a JSON array.
+func tagString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
 func (m *migration) makeAlertRule(l log.Logger, cond condition, da dashAlert, folderUID string) (*alertRule, error) {
-	lbls, annotations := addMigrationInfo(&da)
+	lbls, annotations := addMigrationInfo(&da, m.upgradeCfg.ArrayAlertRuleTags)
+	m.stamp.annotate(annotations)
 
-	message := MigrateTmpl(l.New("field", "message"), da.Message)
+	mapping := m.templateMappings.forOrg(da.OrgId)
+	message := migrateTmpl(l.New("field", "message"), da.Message, mapping)
 	annotations["message"] = message
+	if mapping != nil {
+		if unmapped := mapping.unmappedVariables(tokenizeTmpl(l, da.Message)); len(unmapped) > 0 {
+			l.Warn("Alert message uses variables that are not in the template mapping", "variables", unmapped)
+		}
+	}
+
+	if m.upgradeCfg.SplitPrometheusBothQueries {
+		var err error
+		cond, err = splitPrometheusBothTypeQueries(l, cond)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split Prometheus 'Both' type queries: %w", err)
+		}
+	}
+
+	// Reduce and threshold expressions create an alert instance per series instead of a single one for all series, so
+	// the classic condition is only converted once multi-dimensional alert rules are enabled.
+	switch {
+	case m.upgradeCfg.MultiDimensionalRules && m.upgradeCfg.ConvertClassicConditions:
+		var err error
+		cond, err = convertClassicCondition(l, cond)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert classic condition: %w", err)
+		}
+	case m.upgradeCfg.MultiDimensionalRules:
+		converted, reason, err := convertMultiDimensional(cond)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert classic condition: %w", err)
+		}
+		if reason != "" {
+			l.Warn("Alert cannot be converted to a multi-dimensional alert rule, migrating it with a classic condition", "reason", reason)
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("alert %q (ID %d) not converted to a multi-dimensional alert rule: %s", da.Name, da.Id, reason))
+		}
+		cond = converted
+	}
 
-	data, err := migrateAlertRuleQueries(l, cond.Data)
+	data, err := migrateAlertRuleQueries(l, cond.Data, da.OrgId, m.dsUIDMappings)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate alert rule queries: %w", err)
 	}
 
-	uid, err := m.seenUIDs.generateUid()
-	if err != nil {
-		return nil, fmt.Errorf("failed to migrate alert rule: %w", err)
+	pausedForMissingDatasource := false
+	if missing := missingDatasourceQueries(data); len(missing) > 0 {
+		refIDs := strings.Join(missing, ", ")
+		switch m.upgradeCfg.MissingDatasource {
+		case setting.MissingDatasourceFallback:
+			data, err = withDatasourceUID(data, m.upgradeCfg.MissingDatasourceFallbackUID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set fallback datasource: %w", err)
+			}
+			l.Warn("Alert queries reference a data source that does not exist, migrating them to the fallback data source", "queries", refIDs, "datasourceUID", m.upgradeCfg.MissingDatasourceFallbackUID)
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("queries %s of alert %q (ID %d) reference a data source that does not exist, migrated to data source %s", refIDs, da.Name, da.Id, m.upgradeCfg.MissingDatasourceFallbackUID))
+		case setting.MissingDatasourcePause:
+			pausedForMissingDatasource = true
+			annotations[MissingDatasourceAnnotation] = refIDs
+			l.Warn("Alert queries reference a data source that does not exist, migrating the alert paused", "queries", refIDs)
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("queries %s of alert %q (ID %d) reference a data source that does not exist, alert rule migrated paused", refIDs, da.Name, da.Id))
+		}
+	}
+
+	uid, ok := m.migrated.get(mappingLegacyAlert, da.OrgId, da.Id)
+	if ok {
+		// Keep the UID of the rule created by a previous migration so that it is updated in place.
+		m.seenUIDs.add(uid)
+	} else {
+		uid, err = m.newUid(auditResourceAlertRule, da.OrgId, da.DashboardId, da.PanelId, da.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate alert rule: %w", err)
+		}
 	}
 
 	name := normalizeRuleName(da.Name, uid)
 
 	isPaused := false
-	if da.State == "paused" {
+	pausedByMigration := false
+	if da.State == "paused" || pausedForMissingDatasource {
+		// Alert rules without data source are not resumed with the rules paused by the migration.
 		isPaused = true
+	} else if m.upgradeCfg.ShadowMode || m.upgradeCfg.PauseMigratedRules {
+		isPaused = true
+		pausedByMigration = true
 	}
 
 	ar := &alertRule{
@@ -135,7 +247,7 @@ func (m *migration) makeAlertRule(l log.Logger, cond condition, da dashAlert, fo
 		UID:             uid,
 		Condition:       cond.Condition,
 		Data:            data,
-		IntervalSeconds: ruleAdjustInterval(da.Frequency),
+		IntervalSeconds: ruleAdjustInterval(da.Frequency, m.baseIntervalSeconds()),
 		Version:         1,
 		NamespaceUID:    folderUID, // Folder already created, comes from env var.
 		RuleGroup:       name,
@@ -153,19 +265,29 @@ func (m *migration) makeAlertRule(l log.Logger, cond condition, da dashAlert, fo
 	n, v := getLabelForSilenceMatching(ar.UID)
 	ar.Labels[n] = v
 
-	if err := m.addErrorSilence(da, ar); err != nil {
-		m.mg.Logger.Error("Alert migration error: failed to create silence for Error", "rule_name", ar.Title, "err", err)
+	if pausedByMigration {
+		ar.Labels[PausedByMigrationLabel] = "true"
 	}
 
-	if err := m.addNoDataSilence(da, ar); err != nil {
-		m.mg.Logger.Error("Alert migration error: failed to create silence for NoData", "rule_name", ar.Title, "err", err)
+	if m.upgradeCfg.KeepStateRouting {
+		ar.keepStateAlerts = keepStateAlertNames(da)
+	} else if !m.upgradeCfg.DisableKeepStateSilences {
+		if err := m.addErrorSilence(da, ar); err != nil {
+			m.mg.Logger.Error("Alert migration error: failed to create silence for Error", "rule_name", ar.Title, "err", err)
+		}
+
+		if err := m.addNoDataSilence(da, ar); err != nil {
+			m.mg.Logger.Error("Alert migration error: failed to create silence for NoData", "rule_name", ar.Title, "err", err)
+		}
 	}
 
 	return ar, nil
 }
 
 // migrateAlertRuleQueries attempts to fix alert rule queries so they can work in unified alerting. Queries of some data sources are not compatible with unified alerting.
-func migrateAlertRuleQueries(l log.Logger, data []alertQuery) ([]alertQuery, error) {
+// The data source references of the query models are remapped with the datasource UID mapping file of the organization.
+func migrateAlertRuleQueries(l log.Logger, data []alertQuery, orgID int64, dsMappings *datasourceUIDMappingFile) ([]alertQuery, error) {
+	graphiteTargets := collectGraphiteTargets(data)
 	result := make([]alertQuery, 0, len(data))
 	for _, d := range data {
 		// queries that are expression are not relevant, skip them.
@@ -180,8 +302,11 @@ func migrateAlertRuleQueries(l log.Logger, data []alertQuery) ([]alertQuery, err
 		}
 		// remove hidden tag from the query (if exists)
 		delete(fixedData, "hide")
-		fixedData = fixGraphiteReferencedSubQueries(fixedData)
+		fixedData = fixGraphiteReferencedSubQueries(fixedData, d.RefID, graphiteTargets)
 		fixedData = fixPrometheusBothTypeQuery(l, fixedData)
+		if err := dsMappings.remapModelDatasource(orgID, fixedData); err != nil {
+			return nil, err
+		}
 		updatedModel, err := json.Marshal(fixedData)
 		if err != nil {
 			return nil, err
@@ -192,13 +317,108 @@ func migrateAlertRuleQueries(l log.Logger, data []alertQuery) ([]alertQuery, err
 	return result, nil
 }
 
+// missingDatasourceQueries returns the RefIDs of the queries without data source, because the data source of the
+// legacy alert query does not exist.
+func missingDatasourceQueries(data []alertQuery) []string {
+	var refIDs []string
+	for _, d := range data {
+		if d.DatasourceUID == "" {
+			refIDs = append(refIDs, d.RefID)
+		}
+	}
+	return refIDs
+}
+
+// withDatasourceUID sets the data source of the queries without data source, in the query and in the data source
+// reference of its model if it has one.
+func withDatasourceUID(data []alertQuery, uid string) ([]alertQuery, error) {
+	result := make([]alertQuery, 0, len(data))
+	for _, d := range data {
+		if d.DatasourceUID != "" {
+			result = append(result, d)
+			continue
+		}
+		d.DatasourceUID = uid
+		var model map[string]json.RawMessage
+		if err := json.Unmarshal(d.Model, &model); err != nil {
+			return nil, err
+		}
+		if _, ok := model["datasource"]; ok {
+			ref, err := json.Marshal(map[string]string{"uid": uid})
+			if err != nil {
+				return nil, err
+			}
+			model["datasource"] = ref
+			if d.Model, err = json.Marshal(model); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// graphiteReferenceRegex matches the references to other queries in a Graphite target, e.g. #A.
+var graphiteReferenceRegex = regexp.MustCompile(`#([A-Z])`)
+
+// collectGraphiteTargets returns the 'target' field of the queries that have one by their RefID.
+func collectGraphiteTargets(data []alertQuery) map[string]string {
+	targets := make(map[string]string, len(data))
+	for _, d := range data {
+		if d.DatasourceUID == expressionDatasourceUID {
+			continue
+		}
+		var model map[string]json.RawMessage
+		if err := json.Unmarshal(d.Model, &model); err != nil {
+			continue
+		}
+		var target string
+		if raw, ok := model[graphite.TargetModelField]; ok && json.Unmarshal(raw, &target) == nil {
+			targets[d.RefID] = target
+		}
+	}
+	return targets
+}
+
+// expandGraphiteTarget recursively replaces the references to other queries in the target with their targets,
+// the same way the Graphite query editor builds 'targetFull'. References to unknown or cyclic queries are kept as is.
+func expandGraphiteTarget(target string, targets map[string]string, visiting map[string]struct{}) string {
+	return graphiteReferenceRegex.ReplaceAllStringFunc(target, func(ref string) string {
+		refID := ref[1:]
+		referenced, ok := targets[refID]
+		if !ok {
+			return ref
+		}
+		if _, cyclic := visiting[refID]; cyclic {
+			return ref
+		}
+		visiting[refID] = struct{}{}
+		defer delete(visiting, refID)
+		return expandGraphiteTarget(referenced, targets, visiting)
+	})
+}
+
 // fixGraphiteReferencedSubQueries attempts to fix graphite referenced sub queries, given unified alerting does not support this.
 // targetFull of Graphite data source contains the expanded version of field 'target', so let's copy that.
-func fixGraphiteReferencedSubQueries(queryData map[string]json.RawMessage) map[string]json.RawMessage {
+// If there is no targetFull, the references in 'target' are expanded using the targets of the other queries.
+func fixGraphiteReferencedSubQueries(queryData map[string]json.RawMessage, refID string, targets map[string]string) map[string]json.RawMessage {
 	fullQuery, ok := queryData[graphite.TargetFullModelField]
 	if ok {
 		delete(queryData, graphite.TargetFullModelField)
 		queryData[graphite.TargetModelField] = fullQuery
+		return queryData
+	}
+
+	var target string
+	if raw, ok := queryData[graphite.TargetModelField]; !ok || json.Unmarshal(raw, &target) != nil {
+		return queryData
+	}
+	if !graphiteReferenceRegex.MatchString(target) {
+		return queryData
+	}
+	expanded, err := json.Marshal(expandGraphiteTarget(target, targets, map[string]struct{}{refID: {}}))
+	if err == nil {
+		queryData[graphite.TargetModelField] = expanded
 	}
 
 	return queryData
@@ -323,15 +543,48 @@ func (d *duration) UnmarshalJSON(b []byte) error {
 	}
 }
 
-func ruleAdjustInterval(freq int64) int64 {
-	// 10 corresponds to the SchedulerCfg, but TODO not worrying about fetching for now.
-	var baseFreq int64 = 10
+// ruleAdjustInterval rounds the frequency of a legacy alert down to a multiple of the base interval of the scheduler,
+// with the base interval as the minimum.
+func ruleAdjustInterval(freq int64, baseFreq int64) int64 {
+	if baseFreq <= 0 {
+		baseFreq = int64(setting.SchedulerBaseInterval.Seconds())
+	}
 	if freq <= baseFreq {
-		return 10
+		return baseFreq
 	}
 	return freq - (freq % baseFreq)
 }
 
+// dashboardEvaluationInterval returns the evaluation interval in seconds derived from the refresh interval of the
+// dashboard, bounded by the min_evaluation_interval and max_evaluation_interval settings and rounded to the base
+// interval of the scheduler, and false if the dashboard has no refresh interval.
+func (m *migration) dashboardEvaluationInterval(dash *dashboard) (int64, bool) {
+	refresh, ok := dash.refreshInterval()
+	if !ok {
+		return 0, false
+	}
+	if minInterval := m.upgradeCfg.MinEvaluationInterval; minInterval > 0 && refresh < minInterval {
+		refresh = minInterval
+	}
+	if maxInterval := m.upgradeCfg.MaxEvaluationInterval; maxInterval > 0 && refresh > maxInterval {
+		refresh = maxInterval
+	}
+	return ruleAdjustInterval(int64(refresh.Seconds()), m.baseIntervalSeconds()), true
+}
+
+// adjustPendingPeriod applies the minimum pending period and the rounding configured for the migration to the pending
+// period of a rule with the given evaluation interval.
+func adjustPendingPeriod(cfg setting.UnifiedAlertingUpgradeSettings, pending time.Duration, intervalSeconds int64) time.Duration {
+	if pending < cfg.MinPendingPeriod {
+		pending = cfg.MinPendingPeriod
+	}
+	interval := time.Duration(intervalSeconds) * time.Second
+	if cfg.RoundPendingPeriod && interval > 0 && pending%interval != 0 {
+		pending += interval - pending%interval
+	}
+	return pending
+}
+
 func transNoData(l log.Logger, s string) string {
 	switch legacymodels.NoDataOption(s) {
 	case legacymodels.NoDataSetOK:
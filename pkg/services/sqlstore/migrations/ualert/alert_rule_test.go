@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -11,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log/logtest"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestMigrateAlertRuleQueries(t *testing.T) {
@@ -73,7 +75,7 @@ func TestMigrateAlertRuleQueries(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			model, err := tt.input.Encode()
 			require.NoError(t, err)
-			queries, err := migrateAlertRuleQueries(&logtest.Fake{}, []alertQuery{{Model: model}})
+			queries, err := migrateAlertRuleQueries(&logtest.Fake{}, []alertQuery{{Model: model}}, 0, nil)
 			if tt.err != nil {
 				require.Error(t, err)
 				require.EqualError(t, err, tt.err.Error())
@@ -86,12 +88,48 @@ func TestMigrateAlertRuleQueries(t *testing.T) {
 			require.JSONEq(t, tt.expected, string(r))
 		})
 	}
+
+	t.Run("when graphite queries reference each other without targetFull, they are expanded recursively", func(t *testing.T) {
+		queries, err := migrateAlertRuleQueries(&logtest.Fake{}, []alertQuery{
+			{RefID: "A", Model: []byte(`{"target":"servers.*.cpu"}`)},
+			{RefID: "B", Model: []byte(`{"target":"sumSeries(#A)"}`)},
+			{RefID: "C", Model: []byte(`{"target":"asPercent(#B, #A)"}`)},
+			{RefID: "D", Model: []byte(`{"target":"scale(#D, #Z)"}`)},
+		}, 0, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"target":"servers.*.cpu"}`, string(queries[0].Model))
+		require.JSONEq(t, `{"target":"sumSeries(servers.*.cpu)"}`, string(queries[1].Model))
+		require.JSONEq(t, `{"target":"asPercent(sumSeries(servers.*.cpu), servers.*.cpu)"}`, string(queries[2].Model))
+		require.JSONEq(t, `{"target":"scale(#D, #Z)"}`, string(queries[3].Model))
+	})
+
+	t.Run("when the datasource UID is mapped, the datasource reference of the model is remapped", func(t *testing.T) {
+		mappings := &datasourceUIDMappingFile{
+			UIDs: map[string]string{"old": "new"},
+			Orgs: map[int64]map[string]string{2: {"old": "new-2"}},
+		}
+		data := []alertQuery{
+			{RefID: "A", Model: []byte(`{"datasource":{"type":"prometheus","uid":"old"}}`)},
+			{RefID: "B", Model: []byte(`{"datasource":{"type":"prometheus","uid":"other"}}`)},
+			{RefID: "C", Model: []byte(`{"datasource":"old"}`)},
+		}
+		queries, err := migrateAlertRuleQueries(&logtest.Fake{}, data, 1, mappings)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"datasource":{"type":"prometheus","uid":"new"}}`, string(queries[0].Model))
+		require.JSONEq(t, `{"datasource":{"type":"prometheus","uid":"other"}}`, string(queries[1].Model))
+		require.JSONEq(t, `{"datasource":"old"}`, string(queries[2].Model))
+
+		queries, err = migrateAlertRuleQueries(&logtest.Fake{}, data, 2, mappings)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"datasource":{"type":"prometheus","uid":"new-2"}}`, string(queries[0].Model))
+	})
 }
 
 func TestAddMigrationInfo(t *testing.T) {
 	tt := []struct {
 		name                string
 		tagsJSON            string
+		arrayTags           string
 		expectedLabels      map[string]string
 		expectedAnnotations map[string]string
 	}{
@@ -101,6 +139,20 @@ func TestAddMigrationInfo(t *testing.T) {
 			expectedLabels:      map[string]string{},
 			expectedAnnotations: map[string]string{"__alertId__": "0", "__dashboardUid__": "", "__panelId__": "0"},
 		},
+		{
+			name:                "when alert rule tags are a JSON array and migrated by index",
+			tagsJSON:            `{ "alertRuleTags": ["one", "two", 3] }`,
+			arrayTags:           setting.ArrayAlertRuleTagsIndex,
+			expectedLabels:      map[string]string{"tag_0": "one", "tag_1": "two", "tag_2": "3"},
+			expectedAnnotations: map[string]string{"__alertId__": "0", "__dashboardUid__": "", "__panelId__": "0"},
+		},
+		{
+			name:                "when alert rule tags are a JSON array and migrated as keys",
+			tagsJSON:            `{ "alertRuleTags": ["one", "two", ""] }`,
+			arrayTags:           setting.ArrayAlertRuleTagsKey,
+			expectedLabels:      map[string]string{"one": "true", "two": "true"},
+			expectedAnnotations: map[string]string{"__alertId__": "0", "__dashboardUid__": "", "__panelId__": "0"},
+		},
 		{
 			name:                "when alert rule tags are a JSON object",
 			tagsJSON:            `{ "alertRuleTags": { "key": "value", "key2": "value2" } }`,
@@ -114,7 +166,7 @@ func TestAddMigrationInfo(t *testing.T) {
 			var settings dashAlertSettings
 			require.NoError(t, json.Unmarshal([]byte(tc.tagsJSON), &settings))
 
-			labels, annotations := addMigrationInfo(&dashAlert{ParsedSettings: &settings})
+			labels, annotations := addMigrationInfo(&dashAlert{ParsedSettings: &settings}, tc.arrayTags)
 			require.Equal(t, tc.expectedLabels, labels)
 			require.Equal(t, tc.expectedAnnotations, annotations)
 		})
@@ -153,6 +205,26 @@ func TestMakeAlertRule(t *testing.T) {
 		})
 	})
 
+	t.Run("classic condition is kept unless multi-dimensional alert rules are enabled", func(t *testing.T) {
+		cnd := condition{Condition: "B", Data: []alertQuery{
+			{RefID: "A", DatasourceUID: "prom", Model: []byte(`{"refId":"A","expr":"up"}`)},
+			{RefID: "B", DatasourceUID: expressionDatasourceUID, Model: []byte(`{"type":"classic_conditions","refId":"B","conditions":[{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}}]}`)},
+		}}
+
+		m := newTestMigration(t)
+		m.upgradeCfg.ConvertClassicConditions = true
+		ar, err := m.makeAlertRule(&logtest.Fake{}, cnd, createTestDashAlert(), "folder")
+		require.NoError(t, err)
+		require.Equal(t, "B", ar.Condition)
+		require.Len(t, ar.Data, 2)
+
+		m.upgradeCfg.MultiDimensionalRules = true
+		ar, err = m.makeAlertRule(&logtest.Fake{}, cnd, createTestDashAlert(), "folder")
+		require.NoError(t, err)
+		require.Equal(t, "C", ar.Condition)
+		require.Len(t, ar.Data, 3)
+	})
+
 	t.Run("alert is not paused", func(t *testing.T) {
 		m := newTestMigration(t)
 		da := createTestDashAlert()
@@ -163,6 +235,23 @@ func TestMakeAlertRule(t *testing.T) {
 		require.False(t, ar.IsPaused)
 	})
 
+	t.Run("interval is rounded to the base interval of the scheduler", func(t *testing.T) {
+		m := newTestMigration(t)
+		m.baseInterval = 30 * time.Second
+		da := createTestDashAlert()
+		cnd := createTestDashAlertCondition()
+
+		da.Frequency = 100
+		ar, err := m.makeAlertRule(&logtest.Fake{}, cnd, da, "folder")
+		require.NoError(t, err)
+		require.Equal(t, int64(90), ar.IntervalSeconds)
+
+		da.Frequency = 10
+		ar, err = m.makeAlertRule(&logtest.Fake{}, cnd, da, "folder")
+		require.NoError(t, err)
+		require.Equal(t, int64(30), ar.IntervalSeconds)
+	})
+
 	t.Run("paused dash alert is paused", func(t *testing.T) {
 		m := newTestMigration(t)
 		da := createTestDashAlert()
@@ -209,6 +298,83 @@ func TestMakeAlertRule(t *testing.T) {
 				"Instance {{$mergedLabels.instance}} is down"
 		require.Equal(t, expected, ar.Annotations["message"])
 	})
+
+	t.Run("creates silences for keep_state error and nodata", func(t *testing.T) {
+		m := newTestMigration(t)
+		da := createTestDashAlert()
+		da.ParsedSettings.ExecutionErrorState = "keep_state"
+		da.ParsedSettings.NoDataState = "keep_state"
+		cnd := createTestDashAlertCondition()
+
+		_, err := m.makeAlertRule(&logtest.Fake{}, cnd, da, "folder")
+		require.NoError(t, err)
+		require.Len(t, m.silences[da.OrgId], 2)
+	})
+
+	t.Run("does not create silences when keep state silences are disabled", func(t *testing.T) {
+		m := newTestMigration(t)
+		m.upgradeCfg.DisableKeepStateSilences = true
+		da := createTestDashAlert()
+		da.ParsedSettings.ExecutionErrorState = "keep_state"
+		da.ParsedSettings.NoDataState = "keep_state"
+		cnd := createTestDashAlertCondition()
+
+		_, err := m.makeAlertRule(&logtest.Fake{}, cnd, da, "folder")
+		require.NoError(t, err)
+		require.Empty(t, m.silences[da.OrgId])
+	})
+
+	t.Run("routes keep_state error and nodata alerts instead of silencing them", func(t *testing.T) {
+		m := newTestMigration(t)
+		m.upgradeCfg.KeepStateRouting = true
+		da := createTestDashAlert()
+		da.ParsedSettings.NoDataState = "keep_state"
+		cnd := createTestDashAlertCondition()
+
+		ar, err := m.makeAlertRule(&logtest.Fake{}, cnd, da, "folder")
+		require.NoError(t, err)
+		require.Empty(t, m.silences[da.OrgId])
+		require.Equal(t, []string{NoDataAlertName}, ar.keepStateAlerts)
+	})
+
+	t.Run("queries of a missing datasource", func(t *testing.T) {
+		cnd := createTestDashAlertCondition()
+		cnd.Data = []alertQuery{
+			{RefID: "A", Model: []byte(`{"datasource":{"type":"prometheus","uid":"deleted"},"expr":"up"}`)},
+			{RefID: "B", DatasourceUID: "existing", Model: []byte(`{"expr":"up"}`)},
+		}
+
+		t.Run("are kept without datasource by default", func(t *testing.T) {
+			m := newTestMigration(t)
+			ar, err := m.makeAlertRule(&logtest.Fake{}, cnd, createTestDashAlert(), "folder")
+			require.NoError(t, err)
+			require.Empty(t, ar.Data[0].DatasourceUID)
+			require.False(t, ar.IsPaused)
+		})
+
+		t.Run("are migrated to the fallback datasource", func(t *testing.T) {
+			m := newTestMigration(t)
+			m.upgradeCfg.MissingDatasource = setting.MissingDatasourceFallback
+			m.upgradeCfg.MissingDatasourceFallbackUID = "fallback"
+			ar, err := m.makeAlertRule(&logtest.Fake{}, cnd, createTestDashAlert(), "folder")
+			require.NoError(t, err)
+			require.Equal(t, "fallback", ar.Data[0].DatasourceUID)
+			require.JSONEq(t, `{"datasource":{"uid":"fallback"},"expr":"up"}`, string(ar.Data[0].Model))
+			require.Equal(t, "existing", ar.Data[1].DatasourceUID)
+			require.False(t, ar.IsPaused)
+		})
+
+		t.Run("pause the alert rule", func(t *testing.T) {
+			m := newTestMigration(t)
+			m.upgradeCfg.MissingDatasource = setting.MissingDatasourcePause
+			m.upgradeCfg.PauseMigratedRules = true
+			ar, err := m.makeAlertRule(&logtest.Fake{}, cnd, createTestDashAlert(), "folder")
+			require.NoError(t, err)
+			require.True(t, ar.IsPaused)
+			require.Equal(t, "A", ar.Annotations[MissingDatasourceAnnotation])
+			require.NotContains(t, ar.Labels, PausedByMigrationLabel)
+		})
+	})
 }
 
 func createTestDashAlert() dashAlert {
@@ -224,3 +390,287 @@ func createTestDashAlertCondition() condition {
 		Condition: "A",
 	}
 }
+
+func TestAdjustPendingPeriod(t *testing.T) {
+	tc := []struct {
+		name     string
+		cfg      setting.UnifiedAlertingUpgradeSettings
+		pending  time.Duration
+		interval int64
+		expected time.Duration
+	}{
+		{
+			name:     "no adjustment by default",
+			pending:  45 * time.Second,
+			interval: 60,
+			expected: 45 * time.Second,
+		},
+		{
+			name:     "minimum pending period replaces shorter one",
+			cfg:      setting.UnifiedAlertingUpgradeSettings{MinPendingPeriod: 5 * time.Minute},
+			pending:  0,
+			interval: 60,
+			expected: 5 * time.Minute,
+		},
+		{
+			name:     "longer pending period is kept",
+			cfg:      setting.UnifiedAlertingUpgradeSettings{MinPendingPeriod: time.Minute},
+			pending:  10 * time.Minute,
+			interval: 60,
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "pending period is rounded up to the interval",
+			cfg:      setting.UnifiedAlertingUpgradeSettings{MinPendingPeriod: 90 * time.Second, RoundPendingPeriod: true},
+			pending:  0,
+			interval: 60,
+			expected: 2 * time.Minute,
+		},
+	}
+
+	for _, test := range tc {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, adjustPendingPeriod(test.cfg, test.pending, test.interval))
+		})
+	}
+}
+
+func TestDashboardEvaluationInterval(t *testing.T) {
+	tc := []struct {
+		name     string
+		cfg      setting.UnifiedAlertingUpgradeSettings
+		refresh  any
+		expected int64
+		ok       bool
+	}{
+		{
+			name:     "refresh interval is used",
+			refresh:  "1m",
+			expected: 60,
+			ok:       true,
+		},
+		{
+			name:     "refresh interval is rounded to the base interval",
+			refresh:  "45s",
+			expected: 40,
+			ok:       true,
+		},
+		{
+			name:     "refresh interval is raised to the minimum",
+			cfg:      setting.UnifiedAlertingUpgradeSettings{MinEvaluationInterval: time.Minute},
+			refresh:  "5s",
+			expected: 60,
+			ok:       true,
+		},
+		{
+			name:     "refresh interval is lowered to the maximum",
+			cfg:      setting.UnifiedAlertingUpgradeSettings{MaxEvaluationInterval: 10 * time.Minute},
+			refresh:  "1h",
+			expected: 600,
+			ok:       true,
+		},
+		{
+			name:    "dashboard without refresh interval",
+			refresh: "",
+		},
+		{
+			name:    "dashboard with disabled refresh",
+			refresh: false,
+		},
+		{
+			name:    "invalid refresh interval",
+			refresh: "often",
+		},
+	}
+
+	for _, test := range tc {
+		t.Run(test.name, func(t *testing.T) {
+			m := newTestMigration(t)
+			m.upgradeCfg = test.cfg
+			dash := &dashboard{Data: simplejson.NewFromAny(map[string]any{"refresh": test.refresh})}
+
+			interval, ok := m.dashboardEvaluationInterval(dash)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.expected, interval)
+		})
+	}
+}
+
+func TestSplitPrometheusBothTypeQueries(t *testing.T) {
+	promQuery := func(refID string) alertQuery {
+		return alertQuery{
+			RefID:         refID,
+			DatasourceUID: "prom",
+			Model:         []byte(`{"refId":"` + refID + `","datasource":{"type":"prometheus"},"instant":true,"range":true}`),
+		}
+	}
+	classicConditions := func(refID string, conditions string) alertQuery {
+		return alertQuery{
+			RefID:         refID,
+			DatasourceUID: expressionDatasourceUID,
+			Model:         []byte(`{"type":"classic_conditions","refId":"` + refID + `","conditions":` + conditions + `}`),
+		}
+	}
+
+	t.Run("splits the query and ORs the conditions", func(t *testing.T) {
+		cond := condition{Condition: "B", Data: []alertQuery{
+			promQuery("A"),
+			classicConditions("B", `[{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}}]`),
+		}}
+
+		result, err := splitPrometheusBothTypeQueries(&logtest.Fake{}, cond)
+		require.NoError(t, err)
+		require.Len(t, result.Data, 3)
+		require.JSONEq(t, `{"refId":"A","datasource":{"type":"prometheus"},"instant":false,"range":true}`, string(result.Data[0].Model))
+		require.Equal(t, "C", result.Data[2].RefID)
+		require.JSONEq(t, `{"refId":"C","datasource":{"type":"prometheus"},"instant":true,"range":false}`, string(result.Data[2].Model))
+		require.JSONEq(t, `{"type":"classic_conditions","refId":"B","conditions":[
+			{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}},
+			{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"or"},"query":{"params":["C"]},"reducer":{"type":"avg"}}
+		]}`, string(result.Data[1].Model))
+	})
+
+	t.Run("does not split the query if a condition is AND'ed with preceding conditions", func(t *testing.T) {
+		cond := condition{Condition: "C", Data: []alertQuery{
+			promQuery("A"),
+			promQuery("B"),
+			classicConditions("C", `[
+				{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}},
+				{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["B"]},"reducer":{"type":"avg"}}
+			]`),
+		}}
+
+		result, err := splitPrometheusBothTypeQueries(&logtest.Fake{}, cond)
+		require.NoError(t, err)
+		require.Len(t, result.Data, 4)
+		require.JSONEq(t, `{"refId":"B","datasource":{"type":"prometheus"},"instant":true,"range":true}`, string(result.Data[1].Model))
+		require.Equal(t, "D", result.Data[3].RefID)
+	})
+}
+
+func TestConvertClassicCondition(t *testing.T) {
+	query := alertQuery{RefID: "A", DatasourceUID: "prom", Model: []byte(`{"refId":"A","expr":"up"}`)}
+	classicConditions := func(conditions string) alertQuery {
+		return alertQuery{
+			RefID:         "B",
+			DatasourceUID: expressionDatasourceUID,
+			Model:         []byte(`{"type":"classic_conditions","refId":"B","conditions":` + conditions + `}`),
+		}
+	}
+
+	t.Run("converts a single condition into reduce and threshold expressions", func(t *testing.T) {
+		cond := condition{Condition: "B", Data: []alertQuery{
+			query,
+			classicConditions(`[{"evaluator":{"type":"within_range","params":[1,5]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}}]`),
+		}}
+
+		result, err := convertClassicCondition(&logtest.Fake{}, cond)
+		require.NoError(t, err)
+		require.Equal(t, "C", result.Condition)
+		require.Len(t, result.Data, 3)
+		require.Equal(t, query, result.Data[0])
+		require.JSONEq(t, `{"type":"reduce","refId":"B","expression":"A","reducer":"mean","settings":{"mode":"dropNN"}}`, string(result.Data[1].Model))
+		require.Equal(t, "C", result.Data[2].RefID)
+		require.Equal(t, expressionDatasourceUID, result.Data[2].DatasourceUID)
+		require.JSONEq(t, `{"type":"threshold","refId":"C","expression":"B","conditions":[{"evaluator":{"type":"within_range","params":[1,5]}}]}`, string(result.Data[2].Model))
+	})
+
+	tc := []struct {
+		name       string
+		conditions string
+	}{
+		{
+			name: "several conditions",
+			conditions: `[
+				{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}},
+				{"evaluator":{"type":"lt","params":[5]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}}
+			]`,
+		},
+		{
+			name:       "reducer without reduce expression equivalent",
+			conditions: `[{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"percent_diff"}}]`,
+		},
+		{
+			name:       "evaluator without threshold expression equivalent",
+			conditions: `[{"evaluator":{"type":"no_value","params":[]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}}]`,
+		},
+	}
+	for _, tt := range tc {
+		t.Run("does not convert a classic condition with "+tt.name, func(t *testing.T) {
+			cond := condition{Condition: "B", Data: []alertQuery{query, classicConditions(tt.conditions)}}
+
+			result, err := convertClassicCondition(&logtest.Fake{}, cond)
+			require.NoError(t, err)
+			require.Equal(t, cond, result)
+		})
+	}
+}
+
+func TestConvertMultiDimensional(t *testing.T) {
+	query := func(refID string) alertQuery {
+		return alertQuery{RefID: refID, DatasourceUID: "prom", Model: []byte(`{"refId":"` + refID + `","expr":"up"}`)}
+	}
+	classicConditions := func(refID, conditions string) alertQuery {
+		return alertQuery{
+			RefID:         refID,
+			DatasourceUID: expressionDatasourceUID,
+			Model:         []byte(`{"type":"classic_conditions","refId":"` + refID + `","conditions":` + conditions + `}`),
+		}
+	}
+
+	t.Run("combines the conditions on the same query with a math expression", func(t *testing.T) {
+		cond := condition{Condition: "B", Data: []alertQuery{
+			query("A"),
+			classicConditions("B", `[
+				{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}},
+				{"evaluator":{"type":"lt","params":[5]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}},
+				{"evaluator":{"type":"gt","params":[10]},"operator":{"type":"or"},"query":{"params":["A"]},"reducer":{"type":"max"}}
+			]`),
+		}}
+
+		result, reason, err := convertMultiDimensional(cond)
+		require.NoError(t, err)
+		require.Empty(t, reason)
+		require.Equal(t, "H", result.Condition)
+
+		models := make(map[string]string, len(result.Data))
+		for _, q := range result.Data {
+			models[q.RefID] = string(q.Model)
+		}
+		require.Len(t, models, 7)
+		require.JSONEq(t, `{"type":"reduce","refId":"C","expression":"A","reducer":"mean","settings":{"mode":"dropNN"}}`, models["C"])
+		require.JSONEq(t, `{"type":"threshold","refId":"D","expression":"C","conditions":[{"evaluator":{"type":"gt","params":[1]}}]}`, models["D"])
+		require.JSONEq(t, `{"type":"threshold","refId":"E","expression":"C","conditions":[{"evaluator":{"type":"lt","params":[5]}}]}`, models["E"])
+		require.JSONEq(t, `{"type":"reduce","refId":"F","expression":"A","reducer":"max","settings":{"mode":"dropNN"}}`, models["F"])
+		require.JSONEq(t, `{"type":"threshold","refId":"G","expression":"F","conditions":[{"evaluator":{"type":"gt","params":[10]}}]}`, models["G"])
+		require.JSONEq(t, `{"type":"math","refId":"H","expression":"($D && $E) || $G"}`, models["H"])
+	})
+
+	t.Run("reports conditions on different queries as ambiguous", func(t *testing.T) {
+		cond := condition{Condition: "C", Data: []alertQuery{
+			query("A"),
+			query("B"),
+			classicConditions("C", `[
+				{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"avg"}},
+				{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["B"]},"reducer":{"type":"avg"}}
+			]`),
+		}}
+
+		result, reason, err := convertMultiDimensional(cond)
+		require.NoError(t, err)
+		require.Equal(t, "the conditions use different queries whose series cannot be matched", reason)
+		require.Equal(t, cond, result)
+	})
+
+	t.Run("reports reducers without reduce expression equivalent as ambiguous", func(t *testing.T) {
+		cond := condition{Condition: "B", Data: []alertQuery{
+			query("A"),
+			classicConditions("B", `[{"evaluator":{"type":"gt","params":[1]},"operator":{"type":"and"},"query":{"params":["A"]},"reducer":{"type":"diff"}}]`),
+		}}
+
+		result, reason, err := convertMultiDimensional(cond)
+		require.NoError(t, err)
+		require.Equal(t, `reducer "diff" has no reduce expression equivalent`, reason)
+		require.Equal(t, cond, result)
+	})
+}
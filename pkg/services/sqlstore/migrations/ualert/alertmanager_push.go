@@ -0,0 +1,312 @@
+package ualert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// alertmanagerPushTimeout bounds the request that pushes the Alertmanager configuration of an organization to the
+// external Alertmanager.
+const alertmanagerPushTimeout = 30 * time.Second
+
+// victorOpsDefaultAPIURL is the base URL of the VictorOps REST endpoint in the upstream Alertmanager. The URL of a
+// Grafana VictorOps integration is this URL followed by the API key and the routing key.
+const victorOpsDefaultAPIURL = "https://alert.victorops.com/integrations/generic/20131114/alert/"
+
+// schedulePushAlertmanagerConfig pushes the Alertmanager configuration of the organization to the external
+// Alertmanager once the transaction of the migration is committed, so that a rolled back migration is never pushed
+// and a slow or unavailable Alertmanager does not hold the transaction open. A failed push is recorded in the
+// migration state of the organization; it does not fail the migration, which is already committed.
+func (m *migration) schedulePushAlertmanagerConfig(orgID int64, rawAmConfig []byte) {
+	m.mg.OnTransactionEnd(func(committed bool) {
+		if !committed {
+			return
+		}
+		problems, err := m.pushAlertmanagerConfig(orgID, rawAmConfig)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("alertmanager configuration not pushed to the external Alertmanager: %w", err))
+		}
+		for _, problem := range problems {
+			m.mg.Logger.Warn("Alert migration warning: failed to push the Alertmanager configuration to the external Alertmanager", "orgID", orgID, "error", problem)
+		}
+		if err := appendOrgStateErrors(m.mg.DBEngine, orgID, problems); err != nil {
+			m.mg.Logger.Error("Alert migration error: failed to record the problems of the push to the external Alertmanager", "orgID", orgID, "error", err)
+		}
+	})
+}
+
+// pushAlertmanagerConfig pushes the Alertmanager configuration of the organization, as written to the database, to the
+// configuration API of the Mimir or Cortex Alertmanager of the upgrade settings. The Grafana-managed receivers are
+// translated to the receivers of the upstream Alertmanager with their secure settings decrypted, since the external
+// Alertmanager supports neither. The integrations that cannot be translated are left out and returned as problems.
+// The API replaces the configuration of the tenant, so pushing the same configuration again is idempotent.
+func (m *migration) pushAlertmanagerConfig(orgID int64, rawAmConfig []byte) ([]error, error) {
+	cfg := m.upgradeCfg
+	pushURL, err := url.Parse(cfg.PushAlertmanagerConfigURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the URL of the external Alertmanager: %w", err)
+	}
+
+	body, problems, err := m.upstreamAlertmanagerConfig(orgID, rawAmConfig)
+	if err != nil {
+		return problems, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, pushURL.JoinPath("/api/v1/alerts").String(), bytes.NewReader(body))
+	if err != nil {
+		return problems, fmt.Errorf("failed to create Alertmanager request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	req.Header.Set("X-Scope-OrgID", alertmanagerTenantID(cfg.PushAlertmanagerConfigTenantIDs, orgID))
+	if cfg.PushAlertmanagerConfigBasicAuthUsername != "" || cfg.PushAlertmanagerConfigBasicAuthPassword != "" {
+		req.SetBasicAuth(cfg.PushAlertmanagerConfigBasicAuthUsername, cfg.PushAlertmanagerConfigBasicAuthPassword)
+	}
+
+	client := &http.Client{Timeout: alertmanagerPushTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return problems, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return problems, fmt.Errorf("received a non-200 response from the Alertmanager, status: %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return problems, nil
+}
+
+// upstreamAlertmanagerConfig converts the JSON Alertmanager configuration of Grafana to the YAML body of the Mimir
+// configuration API: the upstream Alertmanager configuration as a string next to the templates. The receivers and the
+// matchers of the routes are translated, the other settings are kept as they are.
+func (m *migration) upstreamAlertmanagerConfig(orgID int64, rawAmConfig []byte) ([]byte, []error, error) {
+	var config struct {
+		TemplateFiles      map[string]string `json:"template_files"`
+		AlertmanagerConfig map[string]any    `json:"alertmanager_config"`
+	}
+	if err := json.Unmarshal(rawAmConfig, &config); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the Alertmanager configuration: %w", err)
+	}
+	var receivers struct {
+		AlertmanagerConfig struct {
+			Receivers []*PostableApiReceiver `json:"receivers"`
+		} `json:"alertmanager_config"`
+	}
+	if err := json.Unmarshal(rawAmConfig, &receivers); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the receivers of the Alertmanager configuration: %w", err)
+	}
+
+	amConfig := config.AlertmanagerConfig
+	if amConfig == nil {
+		amConfig = make(map[string]any)
+	}
+	var problems []error
+	upstreamReceivers := make([]map[string]any, 0, len(receivers.AlertmanagerConfig.Receivers))
+	for _, r := range receivers.AlertmanagerConfig.Receivers {
+		upstream := map[string]any{"name": r.Name}
+		for _, gr := range r.GrafanaManagedReceivers {
+			key, integration, err := m.upstreamIntegration(orgID, gr)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("integration %q of contact point %q not pushed to the external Alertmanager: %w", gr.Name, r.Name, err))
+				continue
+			}
+			configs, _ := upstream[key].([]map[string]any)
+			upstream[key] = append(configs, integration)
+		}
+		upstreamReceivers = append(upstreamReceivers, upstream)
+	}
+	amConfig["receivers"] = upstreamReceivers
+	if route, ok := amConfig["route"].(map[string]any); ok {
+		upstreamRoute(route)
+	}
+	// The templates of the upstream Alertmanager are the names of the template files pushed alongside it.
+	templates := make([]string, 0, len(config.TemplateFiles))
+	for name := range config.TemplateFiles {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+	amConfig["templates"] = templates
+
+	amConfigYAML, err := yaml.Marshal(amConfig)
+	if err != nil {
+		return nil, problems, err
+	}
+	body, err := yaml.Marshal(struct {
+		TemplateFiles      map[string]string `yaml:"template_files"`
+		AlertmanagerConfig string            `yaml:"alertmanager_config"`
+	}{
+		TemplateFiles:      config.TemplateFiles,
+		AlertmanagerConfig: string(amConfigYAML),
+	})
+	return body, problems, err
+}
+
+// upstreamRoute replaces the object matchers of the route and its nested routes, which the upstream Alertmanager does
+// not support, with the equivalent matchers.
+func upstreamRoute(route map[string]any) {
+	if objectMatchers, ok := route["object_matchers"].([]any); ok {
+		matchers, _ := route["matchers"].([]any)
+		for _, om := range objectMatchers {
+			parts, ok := om.([]any)
+			if !ok || len(parts) != 3 {
+				continue
+			}
+			matchers = append(matchers, fmt.Sprintf("%v%v%s", parts[0], parts[1], strconv.Quote(fmt.Sprint(parts[2]))))
+		}
+		delete(route, "object_matchers")
+		route["matchers"] = matchers
+	}
+	routes, _ := route["routes"].([]any)
+	for _, r := range routes {
+		if nested, ok := r.(map[string]any); ok {
+			upstreamRoute(nested)
+		}
+	}
+}
+
+// upstreamIntegration translates the Grafana-managed integration to the integration of the upstream Alertmanager,
+// returning the key of its list in the receiver, such as webhook_configs, and its configuration. Only the integrations
+// whose upstream equivalent needs no settings of the external Alertmanager itself, such as its SMTP server, are
+// translated.
+func (m *migration) upstreamIntegration(orgID int64, gr *PostableGrafanaReceiver) (string, map[string]any, error) {
+	secrets, err := m.decryptPushedSecureSettings(gr)
+	if err != nil {
+		return "", nil, err
+	}
+	get := func(key string) string {
+		if v, ok := secrets[key]; ok {
+			return v
+		}
+		if gr.Settings == nil {
+			return ""
+		}
+		if v, err := gr.Settings.Get(key).String(); err == nil {
+			return v
+		}
+		if v, err := gr.Settings.Get(key).Int64(); err == nil {
+			return strconv.FormatInt(v, 10)
+		}
+		return ""
+	}
+	config := map[string]any{"send_resolved": !gr.DisableResolveMessage}
+	set := func(key, value string) {
+		if value != "" {
+			config[key] = value
+		}
+	}
+
+	switch gr.Type {
+	case "slack":
+		if token := get("token"); token != "" {
+			config["api_url"] = "https://slack.com/api/chat.postMessage"
+			config["http_config"] = map[string]any{"authorization": map[string]any{"credentials": token}}
+		} else {
+			set("api_url", get("url"))
+		}
+		set("channel", get("recipient"))
+		set("username", get("username"))
+		set("icon_emoji", get("icon_emoji"))
+		set("icon_url", get("icon_url"))
+		set("title", get("title"))
+		set("text", get("text"))
+		return "slack_configs", config, nil
+	case "pagerduty":
+		set("routing_key", get("integrationKey"))
+		set("severity", get("severity"))
+		set("class", get("class"))
+		set("component", get("component"))
+		set("group", get("group"))
+		return "pagerduty_configs", config, nil
+	case "webhook":
+		set("url", get("url"))
+		if maxAlerts, err := strconv.Atoi(get("maxAlerts")); err == nil && maxAlerts > 0 {
+			config["max_alerts"] = maxAlerts
+		}
+		if username := get("username"); username != "" {
+			config["http_config"] = map[string]any{"basic_auth": map[string]any{"username": username, "password": get("password")}}
+		} else if credentials := get("authorization_credentials"); credentials != "" {
+			authorization := map[string]any{"credentials": credentials}
+			if scheme := get("authorization_scheme"); scheme != "" {
+				authorization["type"] = scheme
+			}
+			config["http_config"] = map[string]any{"authorization": authorization}
+		}
+		return "webhook_configs", config, nil
+	case "opsgenie":
+		set("api_key", get("apiKey"))
+		// The upstream API URL is the base URL of the Opsgenie API, Grafana's the URL of its alerts endpoint.
+		set("api_url", strings.TrimSuffix(strings.TrimSuffix(get("apiUrl"), "/"), "v2/alerts"))
+		return "opsgenie_configs", config, nil
+	case "victorops":
+		rest, ok := strings.CutPrefix(get("url"), victorOpsDefaultAPIURL)
+		apiKey, routingKey, found := strings.Cut(strings.Trim(rest, "/"), "/")
+		if !ok || !found || apiKey == "" || routingKey == "" {
+			return "", nil, fmt.Errorf("the URL is not a VictorOps REST endpoint with an API key and a routing key")
+		}
+		config["api_key"] = apiKey
+		config["routing_key"] = routingKey
+		return "victorops_configs", config, nil
+	case "pushover":
+		set("user_key", get("userKey"))
+		set("token", get("apiToken"))
+		set("priority", get("priority"))
+		set("sound", get("sound"))
+		return "pushover_configs", config, nil
+	case "telegram":
+		chatID, err := strconv.ParseInt(get("chatid"), 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("the chat ID is not numeric")
+		}
+		set("bot_token", get("bottoken"))
+		config["chat_id"] = chatID
+		return "telegram_configs", config, nil
+	case "discord":
+		set("webhook_url", get("url"))
+		return "discord_configs", config, nil
+	default:
+		return "", nil, fmt.Errorf("the integration type %q has no equivalent in the external Alertmanager that can be configured without its own settings", gr.Type)
+	}
+}
+
+// decryptPushedSecureSettings returns the secure settings of the integration decrypted. The migration writes them
+// encrypted in the configuration, and unified alerting moves them to the secrets kvstore only once it starts.
+func (m *migration) decryptPushedSecureSettings(gr *PostableGrafanaReceiver) (map[string]string, error) {
+	secrets := make(map[string]string, len(gr.SecureSettings))
+	for k, v := range gr.SecureSettings {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode secure setting %q: %w", k, err)
+		}
+		if _, _, _, ok := ParseSecretsKVReference(decoded); ok {
+			return nil, fmt.Errorf("the secure setting %q is stored in the secrets kvstore", k)
+		}
+		decrypted, err := util.Decrypt(decoded, setting.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secure setting %q: %w", k, err)
+		}
+		secrets[k] = string(decrypted)
+	}
+	return secrets, nil
+}
+
+// alertmanagerTenantID returns the tenant of the external Alertmanager that the configuration of the organization is
+// pushed to.
+func alertmanagerTenantID(tenantIDs map[int64]string, orgID int64) string {
+	if tenantID, ok := tenantIDs[orgID]; ok {
+		return tenantID
+	}
+	return strconv.FormatInt(orgID, 10)
+}
@@ -0,0 +1,151 @@
+package ualert
+
+import (
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// Actions recorded in the alert_migration_audit table.
+const (
+	auditActionCreate = "create"
+	auditActionUpdate = "update"
+	auditActionDelete = "delete"
+)
+
+// Resource types recorded in the alert_migration_audit table.
+const (
+	auditResourceAlertRule = "alert_rule"
+	auditResourceFolder    = "folder"
+	auditResourceReceiver  = "receiver"
+	auditResourceSilence   = "silence"
+)
+
+// alertMigrationAudit is a row of the alert_migration_audit table. It links a resource created or deleted by the
+// migration to the legacy alert or notification channel it originates from.
+type alertMigrationAudit struct {
+	ID           int64  `xorm:"pk autoincr 'id'"`
+	OrgID        int64  `xorm:"org_id"`
+	Action       string `xorm:"action"`
+	ResourceType string `xorm:"resource_type"`
+	ResourceUID  string `xorm:"resource_uid"`
+	LegacyID     int64  `xorm:"legacy_id"`
+	LegacyUID    string `xorm:"legacy_uid"`
+	RunID        string `xorm:"run_id"`
+	Created      time.Time
+}
+
+// migrationAudit collects audit entries during the migration so they are written in the same transaction.
+type migrationAudit struct {
+	entries []*alertMigrationAudit
+	// runID is the ID of the run of the migration recorded with every entry.
+	runID string
+}
+
+// size returns the number of entries collected so far. It is 0 for a nil migrationAudit.
+func (a *migrationAudit) size() int {
+	if a == nil {
+		return 0
+	}
+	return len(a.entries)
+}
+
+// truncate drops the entries collected after the audit had the given size, the resources of which were rolled back.
+func (a *migrationAudit) truncate(size int) {
+	if a == nil || size >= len(a.entries) {
+		return
+	}
+	a.entries = a.entries[:size]
+}
+
+// recordCreate records a resource created by the migration. It is a no-op on a nil migrationAudit.
+func (a *migrationAudit) recordCreate(orgID int64, resourceType, resourceUID string, legacyID int64, legacyUID string) {
+	a.record(auditActionCreate, orgID, resourceType, resourceUID, legacyID, legacyUID)
+}
+
+// recordUpdate records a resource of a previous migration that was updated in place. It is a no-op on a nil migrationAudit.
+func (a *migrationAudit) recordUpdate(orgID int64, resourceType, resourceUID string, legacyID int64, legacyUID string) {
+	a.record(auditActionUpdate, orgID, resourceType, resourceUID, legacyID, legacyUID)
+}
+
+// recordDelete records a resource of a previous migration that was deleted. It is a no-op on a nil migrationAudit.
+func (a *migrationAudit) recordDelete(orgID int64, resourceType, resourceUID string, legacyID int64, legacyUID string) {
+	a.record(auditActionDelete, orgID, resourceType, resourceUID, legacyID, legacyUID)
+}
+
+func (a *migrationAudit) record(action string, orgID int64, resourceType, resourceUID string, legacyID int64, legacyUID string) {
+	if a == nil {
+		return
+	}
+	a.entries = append(a.entries, &alertMigrationAudit{
+		OrgID:        orgID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceUID:  resourceUID,
+		LegacyID:     legacyID,
+		LegacyUID:    legacyUID,
+		RunID:        a.runID,
+		Created:      time.Now().UTC(),
+	})
+}
+
+// write inserts all collected audit entries.
+func (a *migrationAudit) write(sess *xorm.Session) error {
+	for _, e := range a.entries {
+		if _, err := sess.Insert(e); err != nil {
+			return fmt.Errorf("failed to write migration audit entry for %s %s: %w", e.ResourceType, e.ResourceUID, err)
+		}
+	}
+	a.entries = nil
+	return nil
+}
+
+// queryLiveAuditEntries returns the create entries of resources that were not deleted since.
+func queryLiveAuditEntries(sess *xorm.Session) ([]*alertMigrationAudit, error) {
+	var created []*alertMigrationAudit
+	err := sess.SQL(`SELECT * FROM alert_migration_audit a WHERE a.action = ? AND NOT EXISTS (
+		SELECT 1 FROM alert_migration_audit d WHERE d.action = ? AND d.org_id = a.org_id
+			AND d.resource_type = a.resource_type AND d.resource_uid = a.resource_uid AND d.id > a.id
+	)`, auditActionCreate, auditActionDelete).Find(&created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration audit entries: %w", err)
+	}
+	return created, nil
+}
+
+// recordDeleteAll records a delete entry for every resource that the migration created in the organization, or in all
+// organizations if orgID is 0, and that was not deleted since. Resources of the kept types are not deleted and therefore not recorded.
+func recordDeleteAll(sess *xorm.Session, orgID int64, keep ...string) error {
+	exists, err := sess.IsTableExist("alert_migration_audit")
+	if err != nil || !exists {
+		return err
+	}
+
+	created, err := queryLiveAuditEntries(sess)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]struct{}, len(keep))
+	for _, k := range keep {
+		kept[k] = struct{}{}
+	}
+
+	now := time.Now().UTC()
+	for _, e := range created {
+		if orgID != 0 && e.OrgID != orgID {
+			continue
+		}
+		if _, ok := kept[e.ResourceType]; ok {
+			continue
+		}
+		e.ID = 0
+		e.Action = auditActionDelete
+		e.Created = now
+		if _, err := sess.Insert(e); err != nil {
+			return fmt.Errorf("failed to write migration audit entry for %s %s: %w", e.ResourceType, e.ResourceUID, err)
+		}
+	}
+	return nil
+}
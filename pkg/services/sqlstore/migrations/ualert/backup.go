@@ -0,0 +1,505 @@
+package ualert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ErrBackupNotFound is returned by RestoreOrgBackup if the organization has no such backup.
+var ErrBackupNotFound = errors.New("unified alerting backup not found")
+
+// alertMigrationBackup is a row of the alert_migration_backup table. It describes a backup of the unified alerting data
+// of an organization as it was before it was removed to roll back or re-migrate the organization. The data holds the
+// number of resources of the backup, the resources are rows of the alert_migration_backup_resource table.
+type alertMigrationBackup struct {
+	ID      int64     `xorm:"pk autoincr 'id'"`
+	OrgID   int64     `xorm:"org_id"`
+	Data    string    `xorm:"data"`
+	Created time.Time `xorm:"created"`
+}
+
+func (b alertMigrationBackup) TableName() string { return "alert_migration_backup" }
+
+// alertMigrationBackupResource is a row of the alert_migration_backup_resource table. It holds a single resource of a
+// backup, so that the size of a row does not grow with the number of alert rules of the organization.
+type alertMigrationBackupResource struct {
+	ID           int64  `xorm:"pk autoincr 'id'"`
+	BackupID     int64  `xorm:"backup_id"`
+	ResourceType string `xorm:"resource_type"`
+	Data         string `xorm:"data"`
+}
+
+func (r alertMigrationBackupResource) TableName() string { return "alert_migration_backup_resource" }
+
+// Resource types of the alert_migration_backup_resource table.
+const (
+	backupResourceAlertRule                 = "alert_rule"
+	backupResourceFolder                    = "folder"
+	backupResourceAlertmanagerConfiguration = "alertmanager_configuration"
+	backupResourceAdminConfiguration        = "admin_configuration"
+	backupResourceDatasource                = "datasource"
+	backupResourceAlertInstance             = "alert_instance"
+	backupResourceKVStore                   = "kvstore"
+)
+
+// orgBackup is the unified alerting data of an organization stored in a backup.
+//
+// The backup holds every resource removed with the organization except the previous versions of the alert rules, of
+// which only the current one is restored, the managed permissions of the migrated folders, the contact point secrets
+// stored in the secrets kvstore, the state history backfilled by the migration, and the records of the migration of
+// the organization: its state, audit entries and legacy ID mappings. The restored resources are therefore not known to
+// the next migration of the organization, which creates them again.
+type orgBackup struct {
+	Rules                      []*backupAlertRule
+	Folders                    []*backupFolder
+	AlertmanagerConfigurations []*AlertConfiguration
+	AdminConfigurations        []*ngmodels.AdminConfiguration
+	Datasources                []*backupDatasource
+	AlertInstances             []*backupAlertInstance
+	// KVStore holds the silences and notification log of the Alertmanager of the organization.
+	KVStore []*backupKVStoreEntry
+}
+
+// backupSummary is the data of a row of the alert_migration_backup table.
+type backupSummary struct {
+	Rules       int `json:"rules"`
+	Folders     int `json:"folders"`
+	Datasources int `json:"datasources"`
+}
+
+// backupAlertRule is an alert rule with the columns that the migration does not set.
+type backupAlertRule struct {
+	Rule         alertRule `xorm:"extends" json:"rule"`
+	DashboardUID *string   `xorm:"dashboard_uid" json:"dashboardUid"`
+	PanelID      *int64    `xorm:"panel_id" json:"panelId"`
+}
+
+func (r backupAlertRule) TableName() string { return "alert_rule" }
+
+// backupFolder is a folder created by the migration together with its permissions.
+type backupFolder struct {
+	Folder dashboard      `json:"folder"`
+	ACL    []dashboardACL `json:"acl"`
+}
+
+// backupDatasource is an Alertmanager data source created by the migration together with the legacy notification
+// channel it was migrated from.
+type backupDatasource struct {
+	Datasource datasources.DataSource `json:"datasource"`
+	LegacyID   int64                  `json:"legacyId"`
+	LegacyUID  string                 `json:"legacyUid"`
+}
+
+// backupAlertInstance is a row of the alert_instance table.
+type backupAlertInstance struct {
+	RuleOrgID         int64   `xorm:"rule_org_id" json:"ruleOrgId"`
+	RuleUID           string  `xorm:"rule_uid" json:"ruleUid"`
+	Labels            string  `xorm:"labels" json:"labels"`
+	LabelsHash        string  `xorm:"labels_hash" json:"labelsHash"`
+	CurrentState      string  `xorm:"current_state" json:"currentState"`
+	CurrentReason     *string `xorm:"current_reason" json:"currentReason"`
+	CurrentStateSince int64   `xorm:"current_state_since" json:"currentStateSince"`
+	CurrentStateEnd   int64   `xorm:"current_state_end" json:"currentStateEnd"`
+	LastEvalTime      int64   `xorm:"last_eval_time" json:"lastEvalTime"`
+}
+
+func (i backupAlertInstance) TableName() string { return "alert_instance" }
+
+// backupKVStoreEntry is a row of the kv_store table.
+type backupKVStoreEntry struct {
+	OrgID     int64     `xorm:"org_id" json:"orgId"`
+	Namespace string    `xorm:"namespace" json:"namespace"`
+	Key       string    `xorm:"'key'" json:"key"`
+	Value     string    `xorm:"value" json:"value"`
+	Created   time.Time `xorm:"created" json:"created"`
+	Updated   time.Time `xorm:"updated" json:"updated"`
+}
+
+func (e backupKVStoreEntry) TableName() string { return "kv_store" }
+
+// OrgBackup describes a backup of the unified alerting data of an organization.
+type OrgBackup struct {
+	ID          int64
+	OrgID       int64
+	Created     time.Time
+	Rules       int
+	Folders     int
+	Datasources int
+}
+
+// backupOrgs stores the unified alerting data of the organization, or of every organization if orgID is 0, in the
+// alert_migration_backup tables, see orgBackup. Organizations without unified alerting data are skipped. Only the
+// latest maxBackups backups of an organization are kept, all of them if maxBackups is 0.
+func backupOrgs(sess *xorm.Session, orgID int64, maxBackups int) error {
+	cond, args := orgCondition("id", orgID)
+	var orgIDs []int64
+	if err := sess.SQL("SELECT id FROM org WHERE "+cond, args...).Find(&orgIDs); err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, orgID := range orgIDs {
+		backup, err := readOrgBackup(sess, orgID)
+		if err != nil {
+			return fmt.Errorf("failed to read unified alerting data of organisation %d: %w", orgID, err)
+		}
+		if len(backup.Rules) == 0 && len(backup.Folders) == 0 && len(backup.AlertmanagerConfigurations) == 0 && len(backup.Datasources) == 0 {
+			continue
+		}
+		if err := writeOrgBackup(sess, orgID, backup, now); err != nil {
+			return fmt.Errorf("failed to back up unified alerting data of organisation %d: %w", orgID, err)
+		}
+		if err := pruneOrgBackups(sess, orgID, maxBackups); err != nil {
+			return fmt.Errorf("failed to delete old unified alerting backups of organisation %d: %w", orgID, err)
+		}
+	}
+	return nil
+}
+
+// readOrgBackup reads the unified alerting data of the organization that is removed before a re-migration.
+func readOrgBackup(sess *xorm.Session, orgID int64) (*orgBackup, error) {
+	backup := &orgBackup{}
+	if err := sess.Table("alert_rule").Where("org_id = ?", orgID).Find(&backup.Rules); err != nil {
+		return nil, err
+	}
+
+	var folders []dashboard
+	if err := sess.Table("dashboard").Where("org_id = ? AND is_folder = ? AND created_by = ?", orgID, true, FOLDER_CREATED_BY).Find(&folders); err != nil {
+		return nil, err
+	}
+	for _, f := range folders {
+		var acl []dashboardACL
+		if err := sess.Where("dashboard_id = ?", f.Id).Find(&acl); err != nil {
+			return nil, err
+		}
+		backup.Folders = append(backup.Folders, &backupFolder{Folder: f, ACL: acl})
+	}
+
+	if err := sess.Table("alert_configuration").Where("org_id = ?", orgID).Find(&backup.AlertmanagerConfigurations); err != nil {
+		return nil, err
+	}
+	if err := sess.Table("ngalert_configuration").Where("org_id = ?", orgID).Find(&backup.AdminConfigurations); err != nil {
+		return nil, err
+	}
+	if err := sess.Where("rule_org_id = ?", orgID).Find(&backup.AlertInstances); err != nil {
+		return nil, err
+	}
+	if exists, err := sess.IsTableExist("kv_store"); err != nil {
+		return nil, err
+	} else if exists {
+		if err := sess.Where("org_id = ? AND namespace = ?", orgID, KV_NAMESPACE).Find(&backup.KVStore); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := liveDatasourceAuditEntries(sess, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		ds := datasources.DataSource{}
+		exists, err := sess.Table("data_source").Where("org_id = ? AND uid = ?", orgID, e.ResourceUID).Get(&ds)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			backup.Datasources = append(backup.Datasources, &backupDatasource{Datasource: ds, LegacyID: e.LegacyID, LegacyUID: e.LegacyUID})
+		}
+	}
+	return backup, nil
+}
+
+// writeOrgBackup writes the backup of the organization, a row per resource.
+func writeOrgBackup(sess *xorm.Session, orgID int64, backup *orgBackup, created time.Time) error {
+	summary, err := json.Marshal(backupSummary{Rules: len(backup.Rules), Folders: len(backup.Folders), Datasources: len(backup.Datasources)})
+	if err != nil {
+		return err
+	}
+	row := &alertMigrationBackup{OrgID: orgID, Data: string(summary), Created: created}
+	if _, err := sess.Insert(row); err != nil {
+		return err
+	}
+
+	write := func(resourceType string, resource any) error {
+		data, err := json.Marshal(resource)
+		if err != nil {
+			return err
+		}
+		_, err = sess.Insert(&alertMigrationBackupResource{BackupID: row.ID, ResourceType: resourceType, Data: string(data)})
+		return err
+	}
+	for _, r := range backup.Rules {
+		if err := write(backupResourceAlertRule, r); err != nil {
+			return err
+		}
+	}
+	for _, f := range backup.Folders {
+		if err := write(backupResourceFolder, f); err != nil {
+			return err
+		}
+	}
+	for _, c := range backup.AlertmanagerConfigurations {
+		if err := write(backupResourceAlertmanagerConfiguration, c); err != nil {
+			return err
+		}
+	}
+	for _, c := range backup.AdminConfigurations {
+		if err := write(backupResourceAdminConfiguration, c); err != nil {
+			return err
+		}
+	}
+	for _, d := range backup.Datasources {
+		if err := write(backupResourceDatasource, d); err != nil {
+			return err
+		}
+	}
+	for _, i := range backup.AlertInstances {
+		if err := write(backupResourceAlertInstance, i); err != nil {
+			return err
+		}
+	}
+	for _, e := range backup.KVStore {
+		if err := write(backupResourceKVStore, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadOrgBackup reads the resources of the backup.
+func loadOrgBackup(sess *xorm.Session, backupID int64) (*orgBackup, error) {
+	var rows []alertMigrationBackupResource
+	if err := sess.Where("backup_id = ?", backupID).Asc("id").Find(&rows); err != nil {
+		return nil, err
+	}
+
+	backup := &orgBackup{}
+	for _, row := range rows {
+		var target any
+		switch row.ResourceType {
+		case backupResourceAlertRule:
+			r := &backupAlertRule{}
+			backup.Rules, target = append(backup.Rules, r), r
+		case backupResourceFolder:
+			f := &backupFolder{}
+			backup.Folders, target = append(backup.Folders, f), f
+		case backupResourceAlertmanagerConfiguration:
+			c := &AlertConfiguration{}
+			backup.AlertmanagerConfigurations, target = append(backup.AlertmanagerConfigurations, c), c
+		case backupResourceAdminConfiguration:
+			c := &ngmodels.AdminConfiguration{}
+			backup.AdminConfigurations, target = append(backup.AdminConfigurations, c), c
+		case backupResourceDatasource:
+			d := &backupDatasource{}
+			backup.Datasources, target = append(backup.Datasources, d), d
+		case backupResourceAlertInstance:
+			i := &backupAlertInstance{}
+			backup.AlertInstances, target = append(backup.AlertInstances, i), i
+		case backupResourceKVStore:
+			e := &backupKVStoreEntry{}
+			backup.KVStore, target = append(backup.KVStore, e), e
+		default:
+			return nil, fmt.Errorf("unknown resource type %q", row.ResourceType)
+		}
+		if err := json.Unmarshal([]byte(row.Data), target); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", row.ResourceType, err)
+		}
+	}
+	return backup, nil
+}
+
+// pruneOrgBackups deletes the backups of the organization but the latest maxBackups ones, unless maxBackups is 0.
+func pruneOrgBackups(sess *xorm.Session, orgID int64, maxBackups int) error {
+	if maxBackups <= 0 {
+		return nil
+	}
+	var ids []int64
+	if err := sess.Table("alert_migration_backup").Where("org_id = ?", orgID).Desc("id").Cols("id").Find(&ids); err != nil {
+		return err
+	}
+	if len(ids) <= maxBackups {
+		return nil
+	}
+	for _, id := range ids[maxBackups:] {
+		if _, err := sess.Exec("DELETE FROM alert_migration_backup_resource WHERE backup_id = ?", id); err != nil {
+			return err
+		}
+		if _, err := sess.Exec("DELETE FROM alert_migration_backup WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// liveDatasourceAuditEntries returns the audit entries of the Alertmanager data sources that the migration created in
+// the organization and that were not deleted since.
+func liveDatasourceAuditEntries(sess *xorm.Session, orgID int64) ([]*alertMigrationAudit, error) {
+	exists, err := sess.IsTableExist("alert_migration_audit")
+	if err != nil || !exists {
+		return nil, err
+	}
+	created, err := queryLiveAuditEntries(sess)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*alertMigrationAudit
+	for _, e := range created {
+		if e.ResourceType == auditResourceDatasource && e.OrgID == orgID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// restoreDatasources replaces the Alertmanager data sources created by the migration in the organization with those of
+// the backup, and records the replacement in the audit table so that the next removal deletes the restored ones.
+func restoreDatasources(sess *xorm.Session, orgID int64, backup []*backupDatasource) error {
+	entries, err := liveDatasourceAuditEntries(sess, orgID)
+	if err != nil {
+		return err
+	}
+	if err := deleteMigratedDatasources(sess, orgID); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, e := range entries {
+		if _, err := sess.Insert(&alertMigrationAudit{OrgID: orgID, Action: auditActionDelete, ResourceType: auditResourceDatasource, ResourceUID: e.ResourceUID, LegacyID: e.LegacyID, LegacyUID: e.LegacyUID, Created: now}); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range backup {
+		ds := d.Datasource
+		ds.ID = 0
+		if _, err := sess.Table("data_source").Insert(&ds); err != nil {
+			return fmt.Errorf("failed to restore data source %s: %w", ds.UID, err)
+		}
+		if _, err := sess.Insert(&alertMigrationAudit{OrgID: orgID, Action: auditActionCreate, ResourceType: auditResourceDatasource, ResourceUID: ds.UID, LegacyID: d.LegacyID, LegacyUID: d.LegacyUID, Created: now}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListOrgBackups returns the backups of the unified alerting data of the organization, the latest first.
+func ListOrgBackups(sess *xorm.Session, orgID int64) ([]OrgBackup, error) {
+	var rows []alertMigrationBackup
+	if err := sess.Where("org_id = ?", orgID).Desc("id").Find(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list unified alerting backups: %w", err)
+	}
+	result := make([]OrgBackup, 0, len(rows))
+	for _, row := range rows {
+		var summary backupSummary
+		if err := json.Unmarshal([]byte(row.Data), &summary); err != nil {
+			return nil, fmt.Errorf("failed to read unified alerting backup %d: %w", row.ID, err)
+		}
+		result = append(result, OrgBackup{ID: row.ID, OrgID: row.OrgID, Created: row.Created, Rules: summary.Rules, Folders: summary.Folders, Datasources: summary.Datasources})
+	}
+	return result, nil
+}
+
+// RestoreOrgBackup replaces the unified alerting data of the organization with that of the backup, or of the latest
+// backup of the organization if backupID is 0, see orgBackup for what is restored. The replaced data is backed up
+// first, so that the restore can be undone the same way, and only the latest maxBackups backups are kept, all of them
+// if maxBackups is 0. It must run in a transaction.
+func RestoreOrgBackup(sess *xorm.Session, orgID, backupID int64, maxBackups int) (*OrgBackup, error) {
+	row := alertMigrationBackup{}
+	q := sess.Where("org_id = ?", orgID)
+	if backupID != 0 {
+		q = q.And("id = ?", backupID)
+	}
+	exists, err := q.Desc("id").Get(&row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unified alerting backup: %w", err)
+	}
+	if !exists {
+		return nil, ErrBackupNotFound
+	}
+	backup, err := loadOrgBackup(sess, row.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unified alerting backup %d: %w", row.ID, err)
+	}
+
+	// The backup being restored is kept even if the backup of the replaced data exceeds maxBackups.
+	if maxBackups > 0 {
+		maxBackups++
+	}
+	if err := backupOrgs(sess, orgID, maxBackups); err != nil {
+		return nil, err
+	}
+	if err := deleteRulesAndFolders(sess, orgID); err != nil {
+		return nil, fmt.Errorf("failed to delete alert rules and folders: %w", err)
+	}
+	if _, err := sess.Exec("delete from alert_configuration where org_id = ?", orgID); err != nil {
+		return nil, fmt.Errorf("failed to delete Alertmanager configuration: %w", err)
+	}
+	if _, err := sess.Exec("delete from ngalert_configuration where org_id = ?", orgID); err != nil {
+		return nil, fmt.Errorf("failed to delete admin configuration: %w", err)
+	}
+	if _, err := sess.Exec("delete from alert_instance where rule_org_id = ?", orgID); err != nil {
+		return nil, fmt.Errorf("failed to delete alert instances: %w", err)
+	}
+	if len(backup.KVStore) > 0 {
+		if _, err := sess.Exec("delete from kv_store where org_id = ? and namespace = ?", orgID, KV_NAMESPACE); err != nil {
+			return nil, fmt.Errorf("failed to delete Alertmanager state: %w", err)
+		}
+	}
+	if err := restoreDatasources(sess, orgID, backup.Datasources); err != nil {
+		return nil, fmt.Errorf("failed to restore Alertmanager data sources: %w", err)
+	}
+
+	for _, f := range backup.Folders {
+		folder := f.Folder
+		folder.Id = 0
+		if _, err := sess.Insert(&folder); err != nil {
+			return nil, fmt.Errorf("failed to restore folder %s: %w", folder.Uid, err)
+		}
+		for _, acl := range f.ACL {
+			acl.Id = 0
+			acl.DashboardID = folder.Id
+			if _, err := sess.Insert(&acl); err != nil {
+				return nil, fmt.Errorf("failed to restore permissions of folder %s: %w", folder.Uid, err)
+			}
+		}
+	}
+	for _, rule := range backup.Rules {
+		rule.Rule.ID = 0
+		if _, err := sess.Insert(rule); err != nil {
+			return nil, fmt.Errorf("failed to restore alert rule %s: %w", rule.Rule.UID, err)
+		}
+		if _, err := sess.Insert(rule.Rule.makeVersion()); err != nil {
+			return nil, fmt.Errorf("failed to restore version of alert rule %s: %w", rule.Rule.UID, err)
+		}
+	}
+	for _, cfg := range backup.AlertmanagerConfigurations {
+		cfg.ID = 0
+		if _, err := sess.Insert(cfg); err != nil {
+			return nil, fmt.Errorf("failed to restore Alertmanager configuration: %w", err)
+		}
+	}
+	for _, cfg := range backup.AdminConfigurations {
+		cfg.ID = 0
+		if _, err := sess.Table("ngalert_configuration").Insert(cfg); err != nil {
+			return nil, fmt.Errorf("failed to restore admin configuration: %w", err)
+		}
+	}
+	for _, instance := range backup.AlertInstances {
+		if _, err := sess.Insert(instance); err != nil {
+			return nil, fmt.Errorf("failed to restore alert instance of alert rule %s: %w", instance.RuleUID, err)
+		}
+	}
+	for _, e := range backup.KVStore {
+		if _, err := sess.Insert(e); err != nil {
+			return nil, fmt.Errorf("failed to restore Alertmanager state %s: %w", e.Key, err)
+		}
+	}
+
+	return &OrgBackup{ID: row.ID, OrgID: row.OrgID, Created: row.Created, Rules: len(backup.Rules), Folders: len(backup.Folders), Datasources: len(backup.Datasources)}, nil
+}
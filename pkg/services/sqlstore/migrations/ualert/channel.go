@@ -15,6 +15,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 const (
@@ -51,82 +52,191 @@ type channelReceiver struct {
 	receiver *PostableApiReceiver
 }
 
-// setupAlertmanagerConfigs creates Alertmanager configs with migrated receivers and routes.
-func (m *migration) setupAlertmanagerConfigs(rulesPerOrg map[int64]map[*alertRule][]uidOrID) (amConfigsPerOrg, error) {
-	// allChannels: channelUID -> channelConfig
-	allChannelsPerOrg, defaultChannelsPerOrg, err := m.getNotificationChannelMap()
+// setupAlertmanagerConfig creates the Alertmanager config of the organization with receivers and routes migrated from
+// its notification channels, and sets the contact label of its alert rules.
+func (m *migration) setupAlertmanagerConfig(orgID int64, channels, defaultChannels []*notificationChannel, rules map[*alertRule][]uidOrID) (*PostableUserConfig, error) {
+	amConfig := &PostableUserConfig{
+		AlertmanagerConfig: PostableApiAlertingConfig{
+			Receivers: make([]*PostableApiReceiver, 0),
+		},
+	}
+
+	// Create all newly migrated receivers from legacy notification channels.
+	receiversMap, receivers, err := m.createReceivers(channels)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load notification channels: %w", err)
+		return nil, fmt.Errorf("failed to create receiver in orgId %d: %w", orgID, err)
 	}
 
-	amConfigPerOrg := make(amConfigsPerOrg, len(allChannelsPerOrg))
-	for orgID, channels := range allChannelsPerOrg {
-		amConfig := &PostableUserConfig{
-			AlertmanagerConfig: PostableApiAlertingConfig{
-				Receivers: make([]*PostableApiReceiver, 0),
-			},
-		}
-		amConfigPerOrg[orgID] = amConfig
+	if m.upgradeCfg.LenientAlertmanagerValidation {
+		receivers, defaultChannels = m.quarantineInvalidReceivers(orgID, receivers, receiversMap, defaultChannels)
+	}
 
-		// Create all newly migrated receivers from legacy notification channels.
-		receiversMap, receivers, err := m.createReceivers(channels)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create receiver in orgId %d: %w", orgID, err)
-		}
+	// No need to create an Alertmanager configuration if there are no receivers left that aren't obsolete.
+	if len(receivers) == 0 {
+		m.mg.Logger.Warn("No available receivers", "orgId", orgID)
+		return amConfig, nil
+	}
 
-		// No need to create an Alertmanager configuration if there are no receivers left that aren't obsolete.
-		if len(receivers) == 0 {
-			m.mg.Logger.Warn("No available receivers", "orgId", orgID)
+	for _, cr := range receivers {
+		amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, cr.receiver)
+		if m.synced.containsChannel(orgID, cr.channel.ID) {
 			continue
 		}
+		m.audit.recordCreate(orgID, auditResourceReceiver, cr.receiver.Name, cr.channel.ID, cr.channel.Uid)
+		m.mappings.addChannel(orgID, cr)
+	}
 
-		for _, cr := range receivers {
-			amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, cr.receiver)
+	// If the organization has default channels build a map of default receivers, used to create alert-specific routes later.
+	defaultReceivers := make(map[string]struct{})
+	for _, c := range defaultChannels {
+		defaultReceivers[c.Name] = struct{}{}
+	}
+	defaultReceiver, defaultRoute, err := m.createDefaultRouteAndReceiver(defaultChannels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create default route & receiver in orgId %d: %w", orgID, err)
+	}
+	amConfig.AlertmanagerConfig.Route = defaultRoute
+	if defaultReceiver != nil {
+		amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, defaultReceiver)
+		// The default receiver of a synced organization was created by its migration.
+		if !m.incremental {
+			m.audit.recordCreate(orgID, auditResourceReceiver, defaultReceiver.Name, 0, "")
 		}
+	}
 
-		defaultReceivers := make(map[string]struct{})
-		defaultChannels, ok := defaultChannelsPerOrg[orgID]
-		if ok {
-			// If the organization has default channels build a map of default receivers, used to create alert-specific routes later.
-			for _, c := range defaultChannels {
-				defaultReceivers[c.Name] = struct{}{}
+	// Contact labels of the alert rules, with the contact points they list, and per folder to build nested
+	// notification policies.
+	contactSets := make(map[string]map[string]any)
+	folderContacts := make(map[string]map[string]any)
+	for ar, channelUids := range rules {
+		filteredReceiverNames := m.filterReceiversForAlert(ar.Title, channelUids, receiversMap, defaultReceivers)
+
+		if len(filteredReceiverNames) != 0 {
+			// Only create a contact label if there are specific receivers, otherwise it defaults to the root-level route.
+			contact := contactListToString(filteredReceiverNames)
+			ar.Labels[ContactLabel] = contact
+
+			contactSets[contact] = filteredReceiverNames
+			if folderContacts[ar.folderTitle] == nil {
+				folderContacts[ar.folderTitle] = make(map[string]any)
 			}
+			folderContacts[ar.folderTitle][contact] = struct{}{}
 		}
-		defaultReceiver, defaultRoute, err := m.createDefaultRouteAndReceiver(defaultChannels)
+	}
+
+	// routesFor creates the routes of the alert rules with the given contact labels.
+	routesFor := func(contacts map[string]any) ([]*Route, error) {
+		names := make(map[string]any)
+		for contact := range contacts {
+			for n := range contactSets[contact] {
+				names[n] = struct{}{}
+			}
+		}
+		return m.createRoutes(receivers, names)
+	}
+	combine := m.upgradeCfg.ContactPointStrategy == setting.ContactPointStrategyCombination
+	if combine {
+		combinations, err := m.createCombinedReceivers(receivers, contactSets)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create default route & receiver in orgId %d: %w", orgID, err)
+			return nil, fmt.Errorf("failed to create combined receivers in orgId %d: %w", orgID, err)
+		}
+		for _, contact := range sortedKeys(combinations) {
+			combination := combinations[contact]
+			if len(combination.channels) == 1 {
+				// The receiver of a single contact point already exists.
+				continue
+			}
+			amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, combination.receiver)
+			m.audit.recordCreate(orgID, auditResourceReceiver, combination.receiver.Name, 0, "")
 		}
-		amConfig.AlertmanagerConfig.Route = defaultRoute
-		if defaultReceiver != nil {
-			amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, defaultReceiver)
+		routesFor = func(contacts map[string]any) ([]*Route, error) {
+			return m.createCombinationRoutes(combinations, contacts)
 		}
+	}
 
+	nested := m.upgradeCfg.NestedNotificationPolicies
+	if nested && m.folderLabelDisabled {
+		m.mg.Logger.Warn("Alert migration warning: cannot create nested notification policies because the folder label is disabled, creating a route per contact point instead", "orgId", orgID, "label", ngModels.FolderTitleLabel)
+		nested = false
+	}
+	switch {
+	case nested:
+		routes, err := m.createFolderRoutes(folderContacts, routesFor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create folder routes in orgId %d: %w", orgID, err)
+		}
+		amConfig.AlertmanagerConfig.Route.Routes = append(amConfig.AlertmanagerConfig.Route.Routes, routes...)
+	case combine:
+		contacts := make(map[string]any, len(contactSets))
+		for contact := range contactSets {
+			contacts[contact] = struct{}{}
+		}
+		routes, err := routesFor(contacts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create routes in orgId %d: %w", orgID, err)
+		}
+		amConfig.AlertmanagerConfig.Route.Routes = append(amConfig.AlertmanagerConfig.Route.Routes, routes...)
+	default:
 		for _, cr := range receivers {
-			route, err := createRoute(cr)
+			route, err := m.createRoute(cr)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create route for receiver %s in orgId %d: %w", cr.receiver.Name, orgID, err)
 			}
 
-			amConfigPerOrg[orgID].AlertmanagerConfig.Route.Routes = append(amConfigPerOrg[orgID].AlertmanagerConfig.Route.Routes, route)
+			amConfig.AlertmanagerConfig.Route.Routes = append(amConfig.AlertmanagerConfig.Route.Routes, route)
 		}
+	}
 
-		for ar, channelUids := range rulesPerOrg[orgID] {
-			filteredReceiverNames := m.filterReceiversForAlert(ar.Title, channelUids, receiversMap, defaultReceivers)
-
-			if len(filteredReceiverNames) != 0 {
-				// Only create a contact label if there are specific receivers, otherwise it defaults to the root-level route.
-				ar.Labels[ContactLabel] = contactListToString(filteredReceiverNames)
-			}
+	if m.upgradeCfg.KeepStateRouting {
+		if err := m.addKeepStateRoutes(orgID, amConfig, rules); err != nil {
+			return nil, fmt.Errorf("failed to create routes of keep state alerts in orgId %d: %w", orgID, err)
 		}
+	}
+
+	// Validate the alertmanager configuration produced, this gives a chance to catch bad configuration at migration time.
+	// Validation between legacy and unified alerting can be different (e.g. due to bug fixes) so this would fail the migration in that case.
+	if err := m.validateAlertmanagerConfig(amConfig); err != nil {
+		return nil, fmt.Errorf("failed to validate AlertmanagerConfig in orgId %d: %w", orgID, err)
+	}
 
-		// Validate the alertmanager configuration produced, this gives a chance to catch bad configuration at migration time.
-		// Validation between legacy and unified alerting can be different (e.g. due to bug fixes) so this would fail the migration in that case.
-		if err := m.validateAlertmanagerConfig(amConfig); err != nil {
-			return nil, fmt.Errorf("failed to validate AlertmanagerConfig in orgId %d: %w", orgID, err)
+	return amConfig, nil
+}
+
+// quarantineInvalidReceivers validates every receiver on its own and leaves out the ones that fail, recording the
+// validation error in the migration status of the organization. The quarantined receivers are removed from receiversMap
+// and from the default channels, so alerts that notify them are routed to the default receiver.
+func (m *migration) quarantineInvalidReceivers(orgID int64, receivers []channelReceiver, receiversMap map[uidOrID]*PostableApiReceiver, defaultChannels []*notificationChannel) ([]channelReceiver, []*notificationChannel) {
+	valid := make([]channelReceiver, 0, len(receivers))
+	quarantined := make(map[*notificationChannel]struct{})
+	for _, cr := range receivers {
+		config := &PostableUserConfig{
+			AlertmanagerConfig: PostableApiAlertingConfig{
+				Receivers: []*PostableApiReceiver{cr.receiver},
+			},
+		}
+		if err := m.validateAlertmanagerConfig(config); err != nil {
+			m.mg.Logger.Warn("Alert migration warning: quarantining receiver that failed validation", "orgId", orgID, "name", cr.receiver.Name, "uid", cr.channel.Uid, "error", err)
+			m.orgStates.recordError(orgID, fmt.Errorf("receiver %q was not migrated: %w", cr.receiver.Name, err))
+			m.reports.recordSkippedChannel(orgID, cr.channel.Uid, cr.channel.Name, err)
+			quarantined[cr.channel] = struct{}{}
+			delete(receiversMap, cr.channel.Uid)
+			delete(receiversMap, cr.channel.ID)
+			continue
 		}
+		valid = append(valid, cr)
+	}
+
+	if len(quarantined) == 0 {
+		return receivers, defaultChannels
 	}
 
-	return amConfigPerOrg, nil
+	validDefaults := make([]*notificationChannel, 0, len(defaultChannels))
+	for _, c := range defaultChannels {
+		if _, ok := quarantined[c]; !ok {
+			validDefaults = append(validDefaults, c)
+		}
+	}
+	return valid, validDefaults
 }
 
 // contactListToString creates a sorted string representation of a given map (set) of receiver names. Each name will be comma-separated and double-quoted. Names should not contain double quotes.
@@ -148,23 +258,7 @@ func quote(s string) string {
 // getNotificationChannelMap returns a map of all channelUIDs to channel config as well as a separate map for just those channels that are default.
 // For any given Organization, all channels in defaultChannelsPerOrg should also exist in channelsPerOrg.
 func (m *migration) getNotificationChannelMap() (channelsPerOrg, defaultChannelsPerOrg, error) {
-	q := `
-	SELECT id,
-		org_id,
-		uid,
-		name,
-		type,
-		disable_resolve_message,
-		is_default,
-		settings,
-		secure_settings,
-        send_reminder,
-		frequency
-	FROM
-		alert_notification
-	`
-	allChannels := []notificationChannel{}
-	err := m.sess.SQL(q).Find(&allChannels)
+	allChannels, err := m.queryNotificationChannels()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -176,7 +270,7 @@ func (m *migration) getNotificationChannelMap() (channelsPerOrg, defaultChannels
 	allChannelsMap := make(channelsPerOrg)
 	defaultChannelsMap := make(defaultChannelsPerOrg)
 	for i, c := range allChannels {
-		if c.Type == "hipchat" || c.Type == "sensu" {
+		if isDiscontinuedChannelType(c.Type) {
 			m.mg.Logger.Error("Alert migration error: discontinued notification channel found", "type", c.Type, "name", c.Name, "uid", c.Uid)
 			continue
 		}
@@ -191,6 +285,42 @@ func (m *migration) getNotificationChannelMap() (channelsPerOrg, defaultChannels
 	return allChannelsMap, defaultChannelsMap, nil
 }
 
+// isDiscontinuedChannelType returns true if the legacy notification channel type has no unified alerting equivalent
+// and no ChannelConverter is registered for it.
+func isDiscontinuedChannelType(chanType string) bool {
+	if _, ok := getChannelConverter(chanType); ok {
+		return false
+	}
+	return chanType == "hipchat" || chanType == "sensu"
+}
+
+// queryNotificationChannels loads all legacy notification channels from the alert_notification table.
+func (m *migration) queryNotificationChannels() ([]notificationChannel, error) {
+	q := `
+	SELECT id,
+		org_id,
+		uid,
+		name,
+		type,
+		disable_resolve_message,
+		is_default,
+		settings,
+		secure_settings,
+        send_reminder,
+		frequency
+	FROM
+		alert_notification
+	WHERE
+	`
+	cond, args := orgCondition("org_id", m.orgID)
+	allChannels := []notificationChannel{}
+	err := m.sess.SQL(q+cond, args...).Find(&allChannels)
+	if err != nil {
+		return nil, err
+	}
+	return allChannels, nil
+}
+
 // Create a notifier (PostableGrafanaReceiver) from a legacy notification channel
 func (m *migration) createNotifier(c *notificationChannel) (*PostableGrafanaReceiver, error) {
 	uid, err := m.determineChannelUid(c)
@@ -198,19 +328,53 @@ func (m *migration) createNotifier(c *notificationChannel) (*PostableGrafanaRece
 		return nil, err
 	}
 
-	settings, secureSettings, err := migrateSettingsToSecureSettings(c.Type, c.Settings, c.SecureSettings)
+	converted, err := convertChannel(c)
 	if err != nil {
 		return nil, err
 	}
 
-	return &PostableGrafanaReceiver{
+	if converted.Type == "slack" {
+		for _, err := range convertSlackMentions(converted.Settings) {
+			m.mg.Logger.Warn("Legacy Slack mention of notification channel cannot be migrated", "name", c.Name, "uid", c.Uid, "reason", err)
+			m.orgStates.recordError(c.OrgID, fmt.Errorf("notification channel %q: %w", c.Name, err))
+		}
+	}
+
+	if err := checkUploadImage(c, m.screenshotCfg); err != nil {
+		m.mg.Logger.Warn("Legacy uploadImage setting of notification channel cannot be honored", "name", c.Name, "uid", c.Uid, "reason", err)
+		m.orgStates.recordError(c.OrgID, fmt.Errorf("notification channel %q: %w", c.Name, err))
+	}
+
+	if c.Type == "webhook" {
+		method, err := convertWebhookHTTPMethod(c)
+		if err != nil {
+			m.mg.Logger.Warn("Legacy httpMethod setting of notification channel is overridden", "name", c.Name, "uid", c.Uid, "reason", err)
+			m.orgStates.recordError(c.OrgID, fmt.Errorf("notification channel %q: %w", c.Name, err))
+		}
+		converted.Settings.Set("httpMethod", method)
+	}
+
+	disableResolve, err := disableResolveMessage(c, m.upgradeCfg.ForceResolveMessageChannelTypes)
+	if err != nil {
+		m.mg.Logger.Warn("Legacy disableResolveMessage setting of notification channel is overridden", "name", c.Name, "uid", c.Uid, "reason", err)
+		m.orgStates.recordError(c.OrgID, fmt.Errorf("notification channel %q: %w", c.Name, err))
+	}
+
+	notifier := &PostableGrafanaReceiver{
 		UID:                   uid,
 		Name:                  c.Name,
-		Type:                  c.Type,
-		DisableResolveMessage: c.DisableResolveMessage,
-		Settings:              settings,
-		SecureSettings:        secureSettings,
-	}, nil
+		Type:                  converted.Type,
+		DisableResolveMessage: disableResolve,
+		Settings:              converted.Settings,
+		SecureSettings:        converted.SecureSettings,
+	}
+
+	if unmapped := unmappedChannelSettings(c, notifier); len(unmapped) > 0 {
+		m.mg.Logger.Warn("Legacy settings of notification channel are not migrated and must be reconfigured manually", "name", c.Name, "uid", c.Uid, "settings", strings.Join(unmapped, ", "))
+		m.orgStates.recordError(c.OrgID, fmt.Errorf("notification channel %q: settings not migrated: %s", c.Name, strings.Join(unmapped, ", ")))
+	}
+
+	return notifier, nil
 }
 
 // Create one receiver for every unique notification channel.
@@ -218,24 +382,22 @@ func (m *migration) createReceivers(allChannels []*notificationChannel) (map[uid
 	receivers := make([]channelReceiver, 0, len(allChannels))
 	receiversMap := make(map[uidOrID]*PostableApiReceiver)
 
-	set := make(map[string]struct{}) // Used to deduplicate sanitized names.
+	names, err := m.newReceiverNames()
+	if err != nil {
+		return nil, nil, err
+	}
 	for _, c := range allChannels {
 		notifier, err := m.createNotifier(c)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		// We remove double quotes because this character will be used as the separator in the ContactLabel. To prevent partial matches in the Route Matcher we choose to sanitize them early on instead of complicating the Matcher regex.
-		sanitizedName := strings.ReplaceAll(c.Name, `"`, `_`)
-		// There can be name collisions after we sanitize. We check for this and attempt to make the name unique again using a short hash of the original name.
-		if _, ok := set[sanitizedName]; ok {
-			sanitizedName = sanitizedName + fmt.Sprintf("_%.3x", md5.Sum([]byte(c.Name)))
+		sanitizedName, renamed := names.assign(c)
+		if renamed {
 			m.mg.Logger.Warn("Alert contains duplicate contact name after sanitization, appending unique suffix", "type", c.Type, "name", c.Name, "new_name", sanitizedName, "uid", c.Uid)
 		}
 		notifier.Name = sanitizedName
 
-		set[sanitizedName] = struct{}{}
-
 		cr := channelReceiver{
 			channel: c,
 			receiver: &PostableApiReceiver{
@@ -259,9 +421,11 @@ func (m *migration) createReceivers(allChannels []*notificationChannel) (map[uid
 	return receiversMap, receivers, nil
 }
 
+// defaultReceiverName is the name of the receiver of the root-level route created for the default channels.
+const defaultReceiverName = "autogen-contact-point-default"
+
 // Create the root-level route with the default receiver. If no new receiver is created specifically for the root-level route, the returned receiver will be nil.
 func (m *migration) createDefaultRouteAndReceiver(defaultChannels []*notificationChannel) (*PostableApiReceiver, *Route, error) {
-	defaultReceiverName := "autogen-contact-point-default"
 	defaultRoute := &Route{
 		Receiver:       defaultReceiverName,
 		Routes:         make([]*Route, 0),
@@ -306,13 +470,30 @@ func (m *migration) createDefaultRouteAndReceiver(defaultChannels []*notificatio
 		// No need to create a new receiver.
 		newDefaultReceiver = nil
 	}
+	repeatInterval = m.boundRepeatInterval(repeatInterval)
 	defaultRoute.RepeatInterval = &repeatInterval
 
 	return newDefaultReceiver, defaultRoute, nil
 }
 
+// boundRepeatInterval raises the repeat interval to the min_repeat_interval setting and lowers it to the
+// max_repeat_interval setting, so that legacy channels that sent reminders every few seconds do not flood their
+// receivers after the migration. DisabledRepeatInterval is kept, as the channels did not send reminders.
+func (m *migration) boundRepeatInterval(repeatInterval model.Duration) model.Duration {
+	if repeatInterval == DisabledRepeatInterval {
+		return repeatInterval
+	}
+	if minInterval := model.Duration(m.upgradeCfg.MinRepeatInterval); minInterval > 0 && repeatInterval < minInterval {
+		return minInterval
+	}
+	if maxInterval := model.Duration(m.upgradeCfg.MaxRepeatInterval); maxInterval > 0 && repeatInterval > maxInterval {
+		return maxInterval
+	}
+	return repeatInterval
+}
+
 // Create one route per contact point, matching based on ContactLabel.
-func createRoute(cr channelReceiver) (*Route, error) {
+func (m *migration) createRoute(cr channelReceiver) (*Route, error) {
 	// We create a regex matcher so that each alert rule need only have a single ContactLabel entry for all contact points it sends to.
 	// For example, if an alert needs to send to contact1 and contact2 it will have ContactLabel=`"contact1","contact2"` and will match both routes looking
 	// for `.*"contact1".*` and `.*"contact2".*`.
@@ -328,6 +509,7 @@ func createRoute(cr channelReceiver) (*Route, error) {
 	if cr.channel.SendReminder {
 		repeatInterval = cr.channel.Frequency
 	}
+	repeatInterval = m.boundRepeatInterval(repeatInterval)
 
 	return &Route{
 		Receiver:       cr.receiver.Name,
@@ -337,6 +519,139 @@ func createRoute(cr channelReceiver) (*Route, error) {
 	}, nil
 }
 
+// Create the routes of the receivers with the given names, in the order of the receivers.
+func (m *migration) createRoutes(receivers []channelReceiver, names map[string]any) ([]*Route, error) {
+	routes := make([]*Route, 0, len(names))
+	for _, cr := range receivers {
+		if _, ok := names[cr.receiver.Name]; !ok {
+			continue
+		}
+		route, err := m.createRoute(cr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create route for receiver %s: %w", cr.receiver.Name, err)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// Create one route per folder, matching the folder label, with the routes of the contact labels of the alert rules of
+// the folder underneath. Alerts of the folder that match none of them fall back to the receiver of the root-level
+// route, as they do without nesting. Routes of alert rules without a folder title stay at the top level.
+func (m *migration) createFolderRoutes(folderContacts map[string]map[string]any, routesFor func(contacts map[string]any) ([]*Route, error)) ([]*Route, error) {
+	titles := sortedKeys(folderContacts)
+
+	routes := make([]*Route, 0, len(titles))
+	for _, title := range titles {
+		contactRoutes, err := routesFor(folderContacts[title])
+		if err != nil {
+			return nil, err
+		}
+
+		if title == "" {
+			routes = append(routes, contactRoutes...)
+			continue
+		}
+		mat, err := labels.NewMatcher(labels.MatchEqual, ngModels.FolderTitleLabel, title)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, &Route{
+			ObjectMatchers: ObjectMatchers{mat},
+			Routes:         contactRoutes,
+		})
+	}
+	return routes, nil
+}
+
+// channelCombination is the receiver of the alert rules that notify the same set of notification channels.
+type channelCombination struct {
+	channels []*notificationChannel
+	receiver *PostableApiReceiver
+}
+
+// Create one receiver per distinct set of contact points used by the alert rules, keyed by their contact label. A set of
+// a single contact point uses its receiver, larger sets get a new receiver with the integrations of all of them.
+func (m *migration) createCombinedReceivers(receivers []channelReceiver, contactSets map[string]map[string]any) (map[string]channelCombination, error) {
+	byName := make(map[string]channelReceiver, len(receivers))
+	for _, cr := range receivers {
+		byName[cr.receiver.Name] = cr
+	}
+
+	combinations := make(map[string]channelCombination, len(contactSets))
+	for _, contact := range sortedKeys(contactSets) {
+		names := sortedKeys(contactSets[contact])
+		if len(names) == 1 {
+			cr := byName[names[0]]
+			combinations[contact] = channelCombination{channels: []*notificationChannel{cr.channel}, receiver: cr.receiver}
+			continue
+		}
+
+		name := strings.Join(names, ", ")
+		if _, ok := byName[name]; ok {
+			name = name + fmt.Sprintf("_%.3x", md5.Sum([]byte(contact)))
+		}
+		combination := channelCombination{
+			receiver: &PostableApiReceiver{Name: name, GrafanaManagedReceivers: make([]*PostableGrafanaReceiver, 0, len(names))},
+		}
+		for _, n := range names {
+			cr := byName[n]
+			notifier, err := m.createNotifier(cr.channel)
+			if err != nil {
+				return nil, err
+			}
+			// Integrations need a UID of their own, distinct from the one of the receiver of the single channel.
+			notifier.UID, err = m.newUid(auditResourceReceiver, cr.channel.OrgID, contact, cr.channel.ID)
+			if err != nil {
+				return nil, err
+			}
+			notifier.Name = n
+			combination.channels = append(combination.channels, cr.channel)
+			combination.receiver.GrafanaManagedReceivers = append(combination.receiver.GrafanaManagedReceivers, notifier)
+		}
+		combinations[contact] = combination
+	}
+	return combinations, nil
+}
+
+// Create one route per contact label, matching it exactly, to the receiver of its combination of channels. The repeat
+// interval is the lowest reminder frequency of the channels, as for the root-level route.
+func (m *migration) createCombinationRoutes(combinations map[string]channelCombination, contacts map[string]any) ([]*Route, error) {
+	routes := make([]*Route, 0, len(contacts))
+	for _, contact := range sortedKeys(contacts) {
+		combination := combinations[contact]
+		mat, err := labels.NewMatcher(labels.MatchEqual, ContactLabel, contact)
+		if err != nil {
+			return nil, err
+		}
+
+		repeatInterval := DisabledRepeatInterval
+		for _, c := range combination.channels {
+			if c.SendReminder && c.Frequency < repeatInterval {
+				repeatInterval = c.Frequency
+			}
+		}
+		repeatInterval = m.boundRepeatInterval(repeatInterval)
+
+		routes = append(routes, &Route{
+			Receiver:       combination.receiver.Name,
+			ObjectMatchers: ObjectMatchers{mat},
+			RepeatInterval: &repeatInterval,
+		})
+	}
+	return routes, nil
+}
+
+// sortedKeys returns the keys of the map in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Filter receivers to select those that were associated to the given rule as channels.
 func (m *migration) filterReceiversForAlert(name string, channelIDs []uidOrID, receivers map[uidOrID]*PostableApiReceiver, defaultReceivers map[string]struct{}) map[string]any {
 	if len(channelIDs) == 0 {
@@ -379,9 +694,15 @@ func (m *migration) filterReceiversForAlert(name string, channelIDs []uidOrID, r
 }
 
 func (m *migration) determineChannelUid(c *notificationChannel) (string, error) {
+	if uid, ok := m.migrated.get(mappingLegacyChannel, c.OrgID, c.ID); ok {
+		// Keep the UID of the contact point created by a previous migration so that it is updated in place.
+		m.seenUIDs.add(uid)
+		return uid, nil
+	}
+
 	legacyUid := c.Uid
 	if legacyUid == "" {
-		newUid, err := m.seenUIDs.generateUid()
+		newUid, err := m.newUid(auditResourceReceiver, c.OrgID, c.ID)
 		if err != nil {
 			return "", err
 		}
@@ -390,7 +711,7 @@ func (m *migration) determineChannelUid(c *notificationChannel) (string, error)
 	}
 
 	if m.seenUIDs.contains(legacyUid) {
-		newUid, err := m.seenUIDs.generateUid()
+		newUid, err := m.newUid(auditResourceReceiver, c.OrgID, c.ID)
 		if err != nil {
 			return "", err
 		}
@@ -401,59 +722,39 @@ func (m *migration) determineChannelUid(c *notificationChannel) (string, error)
 	return legacyUid, nil
 }
 
-// Some settings were migrated from settings to secure settings in between.
-// See https://grafana.com/docs/grafana/latest/installation/upgrading/#ensure-encryption-of-existing-alert-notification-channel-secrets.
-// migrateSettingsToSecureSettings takes care of that.
-func migrateSettingsToSecureSettings(chanType string, settings *simplejson.Json, secureSettings SecureJsonData) (*simplejson.Json, map[string]string, error) {
-	keys := []string{}
-	switch chanType {
-	case "slack":
-		keys = []string{"url", "token"}
-	case "pagerduty":
-		keys = []string{"integrationKey"}
-	case "webhook":
-		keys = []string{"password"}
-	case "prometheus-alertmanager":
-		keys = []string{"basicAuthPassword"}
-	case "opsgenie":
-		keys = []string{"apiKey"}
-	case "telegram":
-		keys = []string{"bottoken"}
-	case "line":
-		keys = []string{"token"}
-	case "pushover":
-		keys = []string{"apiToken", "userKey"}
-	case "threema":
-		keys = []string{"api_secret"}
-	}
-
-	newSecureSettings := secureSettings.Decrypt()
-	cloneSettings := simplejson.New()
-	settingsMap, err := settings.Map()
+// convertChannel converts the legacy notification channel with the ChannelConverter registered for its type and
+// encrypts the resulting secure settings.
+func convertChannel(c *notificationChannel) (*ConvertedChannel, error) {
+	converter, _ := getChannelConverter(c.Type)
+	if converter == nil {
+		return nil, fmt.Errorf("no converter registered for notification channel type %q", c.Type)
+	}
+	converted, err := converter(LegacyChannel{
+		OrgID:          c.OrgID,
+		UID:            c.Uid,
+		Name:           c.Name,
+		Type:           c.Type,
+		Settings:       c.Settings,
+		SecureSettings: c.SecureSettings.Decrypt(),
+	})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	for k, v := range settingsMap {
-		cloneSettings.Set(k, v)
+	if converted == nil {
+		return nil, fmt.Errorf("converter of notification channel type %q returned no channel", c.Type)
 	}
-	for _, k := range keys {
-		if v, ok := newSecureSettings[k]; ok && v != "" {
-			continue
-		}
-
-		sv := cloneSettings.Get(k).MustString()
-		if sv != "" {
-			newSecureSettings[k] = sv
-			cloneSettings.Del(k)
-		}
+	if converted.Type == "" {
+		converted.Type = c.Type
 	}
 
-	encryptedData := GetEncryptedJsonData(newSecureSettings)
+	encryptedData := GetEncryptedJsonData(converted.SecureSettings)
+	secureSettings := make(map[string]string, len(encryptedData))
 	for k, v := range encryptedData {
-		newSecureSettings[k] = base64.StdEncoding.EncodeToString(v)
+		secureSettings[k] = base64.StdEncoding.EncodeToString(v)
 	}
+	converted.SecureSettings = secureSettings
 
-	return cloneSettings, newSecureSettings, nil
+	return converted, nil
 }
 
 // Below is a snapshot of all the config and supporting functions imported
@@ -464,8 +765,6 @@ type PostableUserConfig struct {
 	AlertmanagerConfig PostableApiAlertingConfig `yaml:"alertmanager_config" json:"alertmanager_config"`
 }
 
-type amConfigsPerOrg = map[int64]*PostableUserConfig
-
 type PostableApiAlertingConfig struct {
 	Route     *Route                 `yaml:"route,omitempty" json:"route,omitempty"`
 	Templates []string               `yaml:"templates" json:"templates"`
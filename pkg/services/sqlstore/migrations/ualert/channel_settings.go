@@ -0,0 +1,180 @@
+package ualert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels_config"
+)
+
+// reportedChannelSettings are the legacy notification channel settings that have no unified alerting equivalent, or
+// whose values may not be carried over, and whose behavior the migration reports separately, see checkUploadImage
+// and convertSlackMentions.
+var reportedChannelSettings = map[string]struct{}{
+	"uploadImage":    {},
+	"mentionUsers":   {},
+	"mentionGroups":  {},
+	"mentionChannel": {},
+}
+
+// slackMentionChannels maps the values of the mentionChannel setting of legacy Slack channels to the values the Slack
+// integration accepts.
+var slackMentionChannels = map[string]string{
+	"":         "",
+	"here":     "here",
+	"@here":    "here",
+	"channel":  "channel",
+	"@channel": "channel",
+}
+
+// slackIDPattern matches the IDs of Slack users and user groups, which are the only mentions Slack resolves.
+var slackIDPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+// convertSlackMentions maps the mentionUsers, mentionGroups and mentionChannel settings of a legacy Slack channel to
+// the settings of the Slack integration. Users and groups are written as a comma separated list of IDs, whether they
+// were a list or a string, and without the Slack mention syntax some channels were configured with. It returns an
+// error for every value that cannot be carried over, which is removed so that it does not fail the validation of the
+// Alertmanager configuration.
+func convertSlackMentions(settings *simplejson.Json) []error {
+	var errs []error
+	for _, key := range []string{"mentionUsers", "mentionGroups"} {
+		value, ok := settings.CheckGet(key)
+		if !ok {
+			continue
+		}
+		var entries []string
+		if s, err := value.String(); err == nil {
+			entries = strings.Split(s, ",")
+		} else if a, err := value.StringArray(); err == nil {
+			entries = a
+		} else {
+			errs = append(errs, fmt.Errorf("setting %s is neither a list nor a string, it is not migrated", key))
+			settings.Del(key)
+			continue
+		}
+
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			id := strings.TrimSpace(e)
+			id = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(id, "<@"), "<!subteam^"), "@"), ">")
+			if id == "" {
+				continue
+			}
+			if !slackIDPattern.MatchString(id) {
+				errs = append(errs, fmt.Errorf("value %q of setting %s is not a Slack ID, it is not migrated", strings.TrimSpace(e), key))
+				continue
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			settings.Del(key)
+		} else {
+			settings.Set(key, strings.Join(ids, ","))
+		}
+	}
+
+	if value, ok := settings.CheckGet("mentionChannel"); ok {
+		s, _ := value.String()
+		mention, ok := slackMentionChannels[strings.ToLower(strings.TrimSpace(s))]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("value %q of setting mentionChannel is not supported, only here and channel are, it is not migrated", s))
+			settings.Del("mentionChannel")
+		case mention == "":
+			settings.Del("mentionChannel")
+		default:
+			settings.Set("mentionChannel", mention)
+		}
+	}
+	return errs
+}
+
+var (
+	integrationSchemasOnce sync.Once
+	// integrationSchemas maps the lower-cased integration types to the names of their settings.
+	integrationSchemas map[string]map[string]struct{}
+)
+
+// integrationSettings returns the names of the settings and secure settings of the unified alerting integration type,
+// and false if the type is unknown.
+func integrationSettings(integrationType string) (map[string]struct{}, bool) {
+	integrationSchemasOnce.Do(func() {
+		notifiers := channels_config.GetAvailableNotifiers()
+		integrationSchemas = make(map[string]map[string]struct{}, len(notifiers))
+		for _, n := range notifiers {
+			names := make(map[string]struct{}, len(n.Options))
+			for _, o := range n.Options {
+				names[o.PropertyName] = struct{}{}
+			}
+			integrationSchemas[strings.ToLower(n.Type)] = names
+		}
+	})
+	names, ok := integrationSchemas[strings.ToLower(integrationType)]
+	return names, ok
+}
+
+// unmappedChannelSettings returns the sorted names of the settings of the legacy notification channel that are not
+// settings of the integration it is migrated to, either because the converter dropped them or because unified alerting
+// ignores them. Settings without a value are not returned. It returns nil if the integration type is unknown, such as
+// the types of registered converters.
+func unmappedChannelSettings(c *notificationChannel, notifier *PostableGrafanaReceiver) []string {
+	schema, ok := integrationSettings(notifier.Type)
+	if !ok {
+		return nil
+	}
+
+	migrated := make(map[string]struct{})
+	if settings, err := notifier.Settings.Map(); err == nil {
+		for k := range settings {
+			migrated[k] = struct{}{}
+		}
+	}
+	for k := range notifier.SecureSettings {
+		migrated[k] = struct{}{}
+	}
+
+	legacy := make(map[string]struct{})
+	if settings, err := c.Settings.Map(); err == nil {
+		for k, v := range settings {
+			if v != nil && v != "" {
+				legacy[k] = struct{}{}
+			}
+		}
+	}
+	for k := range c.SecureSettings {
+		legacy[k] = struct{}{}
+	}
+
+	var unmapped []string
+	for k := range legacy {
+		if _, ok := reportedChannelSettings[k]; ok {
+			continue
+		}
+		_, kept := migrated[k]
+		_, known := schema[k]
+		if !kept || !known {
+			unmapped = append(unmapped, k)
+		}
+	}
+	sort.Strings(unmapped)
+	return unmapped
+}
+
+// disableResolveMessage returns whether the integration of the legacy notification channel disables resolved
+// notifications. The flag of the channel is carried over unless its type is one of forceTypes, in which case an error
+// describing the override is returned if the channel disabled them.
+func disableResolveMessage(c *notificationChannel, forceTypes []string) (bool, error) {
+	if !c.DisableResolveMessage {
+		return false, nil
+	}
+	for _, t := range forceTypes {
+		if strings.EqualFold(t, c.Type) {
+			return false, fmt.Errorf("resolve messages of %s channels are sent although the channel disabled them, see the force_resolve_message_channel_types setting", c.Type)
+		}
+	}
+	return true, nil
+}
@@ -1,6 +1,7 @@
 package ualert
 
 import (
+	"sort"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestFilterReceiversForAlert(t *testing.T) {
@@ -190,7 +192,8 @@ func TestCreateRoute(t *testing.T) {
 
 	for _, tt := range tc {
 		t.Run(tt.name, func(t *testing.T) {
-			res, err := createRoute(channelReceiver{
+			m := newTestMigration(t)
+			res, err := m.createRoute(channelReceiver{
 				channel:  tt.channel,
 				receiver: tt.recv,
 			})
@@ -224,6 +227,154 @@ func createNotChannelWithReminder(t *testing.T, uid string, id int64, name strin
 	return &notificationChannel{Uid: uid, ID: id, Name: name, SendReminder: true, Frequency: frequency, Settings: simplejson.New()}
 }
 
+func TestBoundRepeatInterval(t *testing.T) {
+	tc := []struct {
+		name     string
+		min      time.Duration
+		max      time.Duration
+		interval model.Duration
+		expected model.Duration
+	}{
+		{
+			name:     "when no bounds are configured, the repeat interval is unchanged",
+			interval: model.Duration(10 * time.Second),
+			expected: model.Duration(10 * time.Second),
+		},
+		{
+			name:     "when the repeat interval is below the minimum, it is raised to the minimum",
+			min:      time.Minute,
+			interval: model.Duration(10 * time.Second),
+			expected: model.Duration(time.Minute),
+		},
+		{
+			name:     "when the repeat interval is above the maximum, it is lowered to the maximum",
+			max:      24 * time.Hour,
+			interval: model.Duration(48 * time.Hour),
+			expected: model.Duration(24 * time.Hour),
+		},
+		{
+			name:     "when the channel did not send reminders, the repeat interval is not lowered to the maximum",
+			max:      24 * time.Hour,
+			interval: DisabledRepeatInterval,
+			expected: DisabledRepeatInterval,
+		},
+		{
+			name:     "when the repeat interval is within the bounds, it is unchanged",
+			min:      time.Minute,
+			max:      24 * time.Hour,
+			interval: model.Duration(4 * time.Hour),
+			expected: model.Duration(4 * time.Hour),
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMigration(t)
+			m.upgradeCfg.MinRepeatInterval = tt.min
+			m.upgradeCfg.MaxRepeatInterval = tt.max
+			require.Equal(t, tt.expected, m.boundRepeatInterval(tt.interval))
+
+			route, err := m.createRoute(channelReceiver{
+				channel:  &notificationChannel{SendReminder: true, Frequency: tt.interval},
+				receiver: &PostableApiReceiver{Name: "recv1"},
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, *route.RepeatInterval)
+		})
+	}
+}
+
+func TestCreateFolderRoutes(t *testing.T) {
+	receivers := []channelReceiver{
+		{channel: &notificationChannel{}, receiver: &PostableApiReceiver{Name: "recv1"}},
+		{channel: &notificationChannel{}, receiver: &PostableApiReceiver{Name: "recv2"}},
+	}
+	contactRoute := func(name string) *Route {
+		return &Route{
+			Receiver:       name,
+			ObjectMatchers: ObjectMatchers{{Type: labels.MatchRegexp, Name: ContactLabel, Value: `.*"` + name + `".*`}},
+			Continue:       true,
+			RepeatInterval: durationPointer(DisabledRepeatInterval),
+		}
+	}
+
+	m := newTestMigration(t)
+	routesFor := func(names map[string]any) ([]*Route, error) {
+		return m.createRoutes(receivers, names)
+	}
+	res, err := m.createFolderRoutes(map[string]map[string]any{
+		"folder2": {"recv2": struct{}{}},
+		"folder1": {"recv1": struct{}{}, "recv2": struct{}{}},
+		"":        {"recv1": struct{}{}},
+	}, routesFor)
+	require.NoError(t, err)
+
+	expected := []*Route{
+		contactRoute("recv1"),
+		{
+			ObjectMatchers: ObjectMatchers{{Type: labels.MatchEqual, Name: ngModels.FolderTitleLabel, Value: "folder1"}},
+			Routes:         []*Route{contactRoute("recv1"), contactRoute("recv2")},
+		},
+		{
+			ObjectMatchers: ObjectMatchers{{Type: labels.MatchEqual, Name: ngModels.FolderTitleLabel, Value: "folder2"}},
+			Routes:         []*Route{contactRoute("recv2")},
+		},
+	}
+	cOpt := cmpopts.IgnoreUnexported(Route{}, labels.Matcher{})
+	if !cmp.Equal(expected, res, cOpt) {
+		t.Errorf("Unexpected Routes: %v", cmp.Diff(expected, res, cOpt))
+	}
+}
+
+func TestCreateCombinationRoutes(t *testing.T) {
+	channels := []*notificationChannel{
+		createNotChannelWithReminder(t, "uid1", int64(1), "recv1", model.Duration(time.Hour)),
+		createNotChannelWithReminder(t, "uid2", int64(2), "recv2", model.Duration(10*time.Minute)),
+		createNotChannel(t, "uid3", int64(3), "recv3"),
+	}
+	m := newTestMigration(t)
+	_, receivers, err := m.createReceivers(channels)
+	require.NoError(t, err)
+
+	single := contactListToString(map[string]any{"recv3": struct{}{}})
+	combined := contactListToString(map[string]any{"recv1": struct{}{}, "recv2": struct{}{}})
+	contactSets := map[string]map[string]any{
+		single:   {"recv3": struct{}{}},
+		combined: {"recv1": struct{}{}, "recv2": struct{}{}},
+	}
+	combinations, err := m.createCombinedReceivers(receivers, contactSets)
+	require.NoError(t, err)
+
+	require.Same(t, receivers[2].receiver, combinations[single].receiver)
+	recv := combinations[combined].receiver
+	require.Equal(t, "recv1, recv2", recv.Name)
+	require.Len(t, recv.GrafanaManagedReceivers, 2)
+	require.Equal(t, "recv1", recv.GrafanaManagedReceivers[0].Name)
+	require.Equal(t, "recv2", recv.GrafanaManagedReceivers[1].Name)
+	require.NotEqual(t, "uid1", recv.GrafanaManagedReceivers[0].UID)
+	require.NotEqual(t, "uid2", recv.GrafanaManagedReceivers[1].UID)
+
+	res, err := m.createCombinationRoutes(combinations, map[string]any{single: struct{}{}, combined: struct{}{}})
+	require.NoError(t, err)
+
+	expected := []*Route{
+		{
+			Receiver:       "recv1, recv2",
+			ObjectMatchers: ObjectMatchers{{Type: labels.MatchEqual, Name: ContactLabel, Value: combined}},
+			RepeatInterval: durationPointer(model.Duration(10 * time.Minute)),
+		},
+		{
+			Receiver:       "recv3",
+			ObjectMatchers: ObjectMatchers{{Type: labels.MatchEqual, Name: ContactLabel, Value: single}},
+			RepeatInterval: durationPointer(DisabledRepeatInterval),
+		},
+	}
+	cOpt := cmpopts.IgnoreUnexported(Route{}, labels.Matcher{})
+	if !cmp.Equal(expected, res, cOpt) {
+		t.Errorf("Unexpected Routes: %v", cmp.Diff(expected, res, cOpt))
+	}
+}
+
 func TestCreateReceivers(t *testing.T) {
 	tc := []struct {
 		name            string
@@ -361,6 +512,112 @@ func TestCreateReceivers(t *testing.T) {
 	}
 }
 
+func TestCreateReceiversUniqueNames(t *testing.T) {
+	newMigration := func(existing string) *migration {
+		m := newTestMigration(t)
+		m.upgradeCfg.ReceiverNameStrategy = setting.ReceiverNameStrategyUnique
+		m.upgradeCfg.ConflictPolicy = setting.ConflictPolicyMerge
+		if existing != "" {
+			m.conflicts = &orgConflicts{orgID: 1, amConfig: &AlertConfiguration{AlertmanagerConfiguration: existing}}
+		}
+		return m
+	}
+	names := func(recvs []channelReceiver) []string {
+		result := make([]string, 0, len(recvs))
+		for _, cr := range recvs {
+			result = append(result, cr.receiver.Name)
+		}
+		return result
+	}
+
+	t.Run("names that collide after sanitization get a counter", func(t *testing.T) {
+		m := newMigration("")
+		_, recvs, err := m.createReceivers([]*notificationChannel{
+			createNotChannel(t, "uid1", int64(1), "name\"1"),
+			createNotChannel(t, "uid2", int64(2), "name_1"),
+			createNotChannel(t, "uid3", int64(3), "name_1 (2)"),
+			createNotChannel(t, "uid4", int64(4), defaultReceiverName),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"name_1", "name_1 (2)", "name_1 (2) (2)", defaultReceiverName + " (2)"}, names(recvs))
+	})
+
+	t.Run("names of the existing contact points are not reused when the conflicts are merged", func(t *testing.T) {
+		m := newMigration(`{"alertmanager_config": {"receivers": [{"name": "team"}, {"name": "team (2)"}]}}`)
+		_, recvs, err := m.createReceivers([]*notificationChannel{createNotChannel(t, "uid1", int64(1), "team")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"team (3)"}, names(recvs))
+
+		m.upgradeCfg.ConflictPolicy = setting.ConflictPolicyOverwrite
+		_, recvs, err = m.createReceivers([]*notificationChannel{createNotChannel(t, "uid1", int64(1), "team")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"team"}, names(recvs))
+	})
+
+	t.Run("migrated channels keep the name of their contact point", func(t *testing.T) {
+		m := newMigration(`{"alertmanager_config": {"receivers": [{"name": "team"}]}}`)
+		m.incremental = true
+		m.synced = &syncedLegacyIDs{channels: map[[2]int64]string{{0, 1}: "team"}}
+		_, recvs, err := m.createReceivers([]*notificationChannel{
+			createNotChannel(t, "uid2", int64(2), "team"),
+			createNotChannel(t, "uid1", int64(1), "team"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"team (2)", "team"}, names(recvs))
+	})
+}
+
+func TestAddKeepStateRoutes(t *testing.T) {
+	newConfig := func() *PostableUserConfig {
+		return &PostableUserConfig{AlertmanagerConfig: PostableApiAlertingConfig{
+			Receivers: []*PostableApiReceiver{{Name: "team"}},
+			Route:     &Route{Receiver: "team", Routes: []*Route{{Receiver: "team"}}},
+		}}
+	}
+	rules := map[*alertRule][]uidOrID{
+		{UID: "uid2", keepStateAlerts: []string{ErrorAlertName, NoDataAlertName}}: nil,
+		{UID: "uid1", keepStateAlerts: []string{NoDataAlertName}}:                 nil,
+		{UID: "uid3"}: nil,
+	}
+
+	t.Run("routes the alerts of the rules to the configured contact point", func(t *testing.T) {
+		m := newTestMigration(t)
+		m.upgradeCfg.KeepStateReceiver = "team"
+		amConfig := newConfig()
+		require.NoError(t, m.addKeepStateRoutes(1, amConfig, rules))
+
+		routes := amConfig.AlertmanagerConfig.Route.Routes
+		require.Len(t, routes, 3)
+		require.Equal(t, "team", routes[0].Receiver)
+		require.False(t, routes[0].Continue)
+		require.Equal(t, `alertname="DatasourceError"`, routes[0].ObjectMatchers[0].String())
+		require.Equal(t, `rule_uid=~"uid2"`, routes[0].ObjectMatchers[1].String())
+		require.Equal(t, `alertname="DatasourceNoData"`, routes[1].ObjectMatchers[0].String())
+		require.Equal(t, `rule_uid=~"uid1|uid2"`, routes[1].ObjectMatchers[1].String())
+		require.Len(t, amConfig.AlertmanagerConfig.Receivers, 1)
+	})
+
+	t.Run("routes the alerts to a contact point without integrations if the configured one does not exist", func(t *testing.T) {
+		m := newTestMigration(t)
+		m.upgradeCfg.KeepStateReceiver = "unknown"
+		amConfig := newConfig()
+		require.NoError(t, m.addKeepStateRoutes(1, amConfig, rules))
+
+		require.Equal(t, keepStateReceiverName, amConfig.AlertmanagerConfig.Route.Routes[0].Receiver)
+		require.Len(t, amConfig.AlertmanagerConfig.Receivers, 2)
+		require.Equal(t, keepStateReceiverName, amConfig.AlertmanagerConfig.Receivers[1].Name)
+		require.Empty(t, amConfig.AlertmanagerConfig.Receivers[1].GrafanaManagedReceivers)
+	})
+
+	t.Run("adds no route without keep state rules", func(t *testing.T) {
+		m := newTestMigration(t)
+		amConfig := newConfig()
+		require.NoError(t, m.addKeepStateRoutes(1, amConfig, map[*alertRule][]uidOrID{{UID: "uid3"}: nil}))
+		require.Len(t, amConfig.AlertmanagerConfig.Route.Routes, 1)
+		require.Len(t, amConfig.AlertmanagerConfig.Receivers, 1)
+	})
+}
+
 func TestCreateDefaultRouteAndReceiver(t *testing.T) {
 	tc := []struct {
 		name            string
@@ -469,3 +726,350 @@ func TestCreateDefaultRouteAndReceiver(t *testing.T) {
 func durationPointer(d model.Duration) *model.Duration {
 	return &d
 }
+
+func TestCheckUploadImage(t *testing.T) {
+	enabled := setting.UnifiedAlertingScreenshotSettings{Capture: true, UploadExternalImageStorage: true}
+	disabled := setting.UnifiedAlertingScreenshotSettings{}
+
+	tc := []struct {
+		name        string
+		channel     *notificationChannel
+		screenshots setting.UnifiedAlertingScreenshotSettings
+		expErr      bool
+	}{
+		{
+			name:        "images enabled by default and supported",
+			channel:     &notificationChannel{Type: "slack", Settings: simplejson.New()},
+			screenshots: enabled,
+		},
+		{
+			name:        "images enabled by default but screenshots disabled",
+			channel:     &notificationChannel{Type: "slack", Settings: simplejson.New()},
+			screenshots: disabled,
+			expErr:      true,
+		},
+		{
+			name:        "images enabled but not supported by the contact point",
+			channel:     &notificationChannel{Type: "sensugo", Settings: simplejson.New()},
+			screenshots: enabled,
+			expErr:      true,
+		},
+		{
+			name:        "images enabled but linked without external image storage",
+			channel:     &notificationChannel{Type: "slack", Settings: simplejson.New()},
+			screenshots: setting.UnifiedAlertingScreenshotSettings{Capture: true},
+			expErr:      true,
+		},
+		{
+			name:        "images enabled and attached without external image storage",
+			channel:     &notificationChannel{Type: "email", Settings: simplejson.New()},
+			screenshots: setting.UnifiedAlertingScreenshotSettings{Capture: true},
+		},
+		{
+			name:        "images disabled and screenshots enabled",
+			channel:     &notificationChannel{Type: "email", Settings: simplejson.NewFromAny(map[string]any{"uploadImage": false})},
+			screenshots: enabled,
+		},
+		{
+			name:        "images disabled and screenshots disabled",
+			channel:     &notificationChannel{Type: "email", Settings: simplejson.NewFromAny(map[string]any{"uploadImage": false})},
+			screenshots: disabled,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUploadImage(tt.channel, tt.screenshots)
+			if tt.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConvertWebhookHTTPMethod(t *testing.T) {
+	tc := []struct {
+		name      string
+		settings  map[string]any
+		expMethod string
+		expErr    string
+	}{
+		{
+			name:      "method defaults to POST",
+			settings:  map[string]any{"url": "https://hooks.example.com"},
+			expMethod: "POST",
+		},
+		{
+			name:      "method is upper-cased",
+			settings:  map[string]any{"url": "https://hooks.example.com", "httpMethod": "put"},
+			expMethod: "PUT",
+		},
+		{
+			name:      "unsupported method is replaced with POST",
+			settings:  map[string]any{"url": "https://hooks.example.com", "httpMethod": "GET"},
+			expMethod: "POST",
+			expErr:    "http method GET of the webhook channel is not supported, the contact point sends with POST",
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			method, err := convertWebhookHTTPMethod(&notificationChannel{Type: "webhook", Settings: simplejson.NewFromAny(tt.settings)})
+			require.Equal(t, tt.expMethod, method)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateNotifierDisableResolveMessage(t *testing.T) {
+	// Every channel type with a converter, and one converted by the default converter.
+	types := []string{"email"}
+	for chanType := range channelConverters {
+		types = append(types, chanType)
+	}
+	sort.Strings(types)
+
+	for _, chanType := range types {
+		t.Run(chanType, func(t *testing.T) {
+			for _, disabled := range []bool{true, false} {
+				m := newTestMigration(t)
+				c := createNotChannel(t, "uid1", int64(1), "name1")
+				c.Type = chanType
+				c.DisableResolveMessage = disabled
+				notifier, err := m.createNotifier(c)
+				require.NoError(t, err)
+				require.Equal(t, disabled, notifier.DisableResolveMessage)
+			}
+		})
+	}
+
+	t.Run("resolve messages are forced for the configured types", func(t *testing.T) {
+		m := newTestMigration(t)
+		m.upgradeCfg.ForceResolveMessageChannelTypes = []string{"PagerDuty"}
+		pagerduty := &notificationChannel{Uid: "uid1", ID: 1, Name: "pd", Type: "pagerduty", DisableResolveMessage: true, Settings: simplejson.New()}
+		slack := &notificationChannel{Uid: "uid2", ID: 2, Name: "slack", Type: "slack", DisableResolveMessage: true, Settings: simplejson.New()}
+
+		notifier, err := m.createNotifier(pagerduty)
+		require.NoError(t, err)
+		require.False(t, notifier.DisableResolveMessage)
+		notifier, err = m.createNotifier(slack)
+		require.NoError(t, err)
+		require.True(t, notifier.DisableResolveMessage)
+
+		_, err = disableResolveMessage(pagerduty, m.upgradeCfg.ForceResolveMessageChannelTypes)
+		require.ErrorContains(t, err, "force_resolve_message_channel_types")
+		pagerduty.DisableResolveMessage = false
+		_, err = disableResolveMessage(pagerduty, m.upgradeCfg.ForceResolveMessageChannelTypes)
+		require.NoError(t, err)
+	})
+}
+
+func TestRegisterChannelConverter(t *testing.T) {
+	require.True(t, isDiscontinuedChannelType("sensu"))
+
+	RegisterChannelConverter("sensu", func(c LegacyChannel) (*ConvertedChannel, error) {
+		return &ConvertedChannel{
+			Type:           "webhook",
+			Settings:       simplejson.NewFromAny(map[string]any{"url": c.Settings.Get("url").MustString()}),
+			SecureSettings: map[string]string{"password": c.Settings.Get("password").MustString()},
+		}, nil
+	})
+	t.Cleanup(func() { delete(channelConverters, "sensu") })
+
+	require.False(t, isDiscontinuedChannelType("sensu"))
+
+	m := newTestMigration(t)
+	notifier, err := m.createNotifier(&notificationChannel{
+		Uid:      "uid1",
+		Name:     "sensu",
+		Type:     "sensu",
+		Settings: simplejson.NewFromAny(map[string]any{"url": "http://sensu", "password": "secret"}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "webhook", notifier.Type)
+	require.Equal(t, "http://sensu", notifier.Settings.Get("url").MustString())
+	require.Contains(t, notifier.SecureSettings, "password")
+	require.NotEqual(t, "secret", notifier.SecureSettings["password"])
+
+	t.Run("converter returning no channel is an error", func(t *testing.T) {
+		RegisterChannelConverter("sensu", func(c LegacyChannel) (*ConvertedChannel, error) {
+			return nil, nil
+		})
+
+		_, err := m.createNotifier(&notificationChannel{
+			Uid:      "uid2",
+			Name:     "sensu2",
+			Type:     "sensu",
+			Settings: simplejson.NewFromAny(map[string]any{}),
+		})
+		require.ErrorContains(t, err, `converter of notification channel type "sensu" returned no channel`)
+	})
+}
+
+func TestUnmappedChannelSettings(t *testing.T) {
+	tc := []struct {
+		name     string
+		channel  *notificationChannel
+		notifier *PostableGrafanaReceiver
+		expected []string
+	}{
+		{
+			name: "settings of the integration are mapped",
+			channel: &notificationChannel{Type: "slack", Settings: simplejson.NewFromAny(map[string]any{
+				"recipient": "#alerts", "url": "http://slack", "uploadImage": true,
+			})},
+			notifier: &PostableGrafanaReceiver{
+				Type:           "slack",
+				Settings:       simplejson.NewFromAny(map[string]any{"recipient": "#alerts", "uploadImage": true}),
+				SecureSettings: map[string]string{"url": "encrypted"},
+			},
+		},
+		{
+			name: "settings unknown to the integration are not mapped",
+			channel: &notificationChannel{Type: "slack", Settings: simplejson.NewFromAny(map[string]any{
+				"recipient": "#alerts", "autoResolve": true, "mentionTeam": "", "httpMethod": "POST",
+			})},
+			notifier: &PostableGrafanaReceiver{
+				Type:     "slack",
+				Settings: simplejson.NewFromAny(map[string]any{"recipient": "#alerts", "autoResolve": true, "mentionTeam": "", "httpMethod": "POST"}),
+			},
+			expected: []string{"autoResolve", "httpMethod"},
+		},
+		{
+			name:     "settings dropped by the converter are not mapped",
+			channel:  &notificationChannel{Type: "sensu", Settings: simplejson.NewFromAny(map[string]any{"url": "http://sensu", "handler": "default"})},
+			notifier: &PostableGrafanaReceiver{Type: "webhook", Settings: simplejson.NewFromAny(map[string]any{"url": "http://sensu"})},
+			expected: []string{"handler"},
+		},
+		{
+			name:     "integration types are matched case-insensitively",
+			channel:  &notificationChannel{Type: "line", Settings: simplejson.New(), SecureSettings: SecureJsonData{"token": []byte("encrypted")}},
+			notifier: &PostableGrafanaReceiver{Type: "line", Settings: simplejson.New(), SecureSettings: map[string]string{"token": "encrypted"}},
+		},
+		{
+			name:     "unknown integration types are not checked",
+			channel:  &notificationChannel{Type: "custom", Settings: simplejson.NewFromAny(map[string]any{"foo": "bar"})},
+			notifier: &PostableGrafanaReceiver{Type: "custom", Settings: simplejson.New()},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, unmappedChannelSettings(tt.channel, tt.notifier))
+		})
+	}
+}
+
+func TestConvertSlackMentions(t *testing.T) {
+	tc := []struct {
+		name     string
+		settings map[string]any
+		expected map[string]any
+		expErrs  int
+	}{
+		{
+			name:     "valid mentions are kept",
+			settings: map[string]any{"mentionUsers": "U123,W456", "mentionGroups": "S789", "mentionChannel": "here"},
+			expected: map[string]any{"mentionUsers": "U123,W456", "mentionGroups": "S789", "mentionChannel": "here"},
+		},
+		{
+			name:     "lists and mention syntax are converted to comma separated IDs",
+			settings: map[string]any{"mentionUsers": []any{"<@U123>", " @W456 "}, "mentionGroups": "<!subteam^S789>, ", "mentionChannel": "@Channel"},
+			expected: map[string]any{"mentionUsers": "U123,W456", "mentionGroups": "S789", "mentionChannel": "channel"},
+		},
+		{
+			name:     "empty mentions are removed",
+			settings: map[string]any{"recipient": "#alerts", "mentionUsers": " , ", "mentionGroups": "", "mentionChannel": ""},
+			expected: map[string]any{"recipient": "#alerts"},
+		},
+		{
+			name:     "values that are not Slack IDs are removed",
+			settings: map[string]any{"mentionUsers": "U123,john.doe", "mentionGroups": "on-call team"},
+			expected: map[string]any{"mentionUsers": "U123"},
+			expErrs:  2,
+		},
+		{
+			name:     "unsupported channel mentions are removed",
+			settings: map[string]any{"mentionChannel": "everyone", "mentionUsers": 42},
+			expected: map[string]any{},
+			expErrs:  2,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := simplejson.NewFromAny(tt.settings)
+			errs := convertSlackMentions(settings)
+			require.Len(t, errs, tt.expErrs)
+			actual, err := settings.Map()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDisableScreenshots(t *testing.T) {
+	upload := &notificationChannel{ID: 1, Uid: "upload", Type: "email", Settings: simplejson.New()}
+	noUpload := &notificationChannel{ID: 2, Uid: "no-upload", Type: "email", Settings: simplejson.NewFromAny(map[string]any{"uploadImage": false})}
+	channels := []*notificationChannel{upload, noUpload}
+
+	tc := []struct {
+		name            string
+		channelIDs      []uidOrID
+		defaultChannels []*notificationChannel
+		capture         bool
+		expDisabled     bool
+	}{
+		{
+			name:        "all channels without images",
+			channelIDs:  []uidOrID{"no-upload"},
+			capture:     true,
+			expDisabled: true,
+		},
+		{
+			name:        "channel referenced by ID",
+			channelIDs:  []uidOrID{int64(2)},
+			capture:     true,
+			expDisabled: true,
+		},
+		{
+			name:       "channels that disagree",
+			channelIDs: []uidOrID{"upload", "no-upload"},
+			capture:    true,
+		},
+		{
+			name:            "default channel with images",
+			channelIDs:      []uidOrID{"no-upload"},
+			defaultChannels: []*notificationChannel{upload},
+			capture:         true,
+		},
+		{
+			// The alert has no channels of its own and is notified by the default channels.
+			name:            "default channel without images",
+			defaultChannels: []*notificationChannel{noUpload},
+			capture:         true,
+			expDisabled:     true,
+		},
+		{
+			name:       "screenshots disabled",
+			channelIDs: []uidOrID{"no-upload"},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMigration(t)
+			m.screenshotCfg.Capture = tt.capture
+			rule := &alertRule{Annotations: map[string]string{}}
+			m.disableScreenshots(1, map[*alertRule][]uidOrID{rule: tt.channelIDs}, channels, tt.defaultChannels)
+			_, disabled := rule.Annotations[ngModels.ScreenshotsDisabledAnnotation]
+			require.Equal(t, tt.expDisabled, disabled)
+		})
+	}
+}
@@ -8,13 +8,14 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata/interval"
 	"github.com/grafana/grafana/pkg/util"
 )
 
-func transConditions(set dashAlertSettings, orgID int64, dsUIDMap dsUIDLookup) (*condition, error) {
+func transConditions(set dashAlertSettings, orgID int64, dsUIDMap dsUIDLookup, dsMappings *datasourceUIDMappingFile) (*condition, error) {
 	refIDtoCondIdx := make(map[string][]int) // a map of original refIds to their corresponding condition index
 	for i, cond := range set.Conditions {
 		if len(cond.Query.Params) != 3 {
@@ -125,6 +126,11 @@ func transConditions(set dashAlertSettings, orgID int64, dsUIDMap dsUIDLookup) (
 
 			// one could have an alert saved but datasource deleted, so can not require match.
 			dsUID := dsUIDMap.GetUID(orgID, set.Conditions[condIdx].Query.DatasourceID)
+			if dsUID == "" && dsMappings != nil {
+				// A data source re-provisioned with a new ID can still be found by the UID the query model references.
+				dsUID = modelDatasourceUID(set.Conditions[condIdx].Query.Model)
+			}
+			dsUID = dsMappings.remap(orgID, dsUID)
 			queryObj["refId"] = refID
 
 			// See services/alerting/conditions/query.go's newQueryCondition
@@ -337,3 +343,347 @@ func calculateInterval(timeRange legacydata.DataTimeRange, model *simplejson.Jso
 
 	return intvl.Value, nil
 }
+
+// isPrometheusBothTypeQuery checks if the query is a Prometheus query of type 'Both', i.e. with instant and range enabled.
+func isPrometheusBothTypeQuery(queryData map[string]json.RawMessage) bool {
+	var instant, rng bool
+	if raw, ok := queryData["instant"]; !ok || json.Unmarshal(raw, &instant) != nil || !instant {
+		return false
+	}
+	if raw, ok := queryData["range"]; !ok || json.Unmarshal(raw, &rng) != nil || !rng {
+		return false
+	}
+	isPrometheus, err := isPrometheusQuery(queryData)
+	return err == nil && isPrometheus
+}
+
+// canSplitConditionsOf checks if every classic condition that uses the query can be OR'ed with a new condition for
+// the same query. Classic conditions are evaluated from left to right, so this is only the case if the condition is
+// the first one or is itself OR'ed with the preceding conditions.
+func canSplitConditionsOf(conditions []classicConditionJSON, refID string) bool {
+	for i, c := range conditions {
+		if i > 0 && len(c.Query.Params) > 0 && c.Query.Params[0] == refID && c.Operator.Type != "or" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPrometheusBothTypeQueries splits Prometheus 'Both' type queries into a range query and an instant query, and
+// adds a condition for the instant query that is OR'ed with each classic condition of the original query. Queries
+// that cannot be split are left as they are.
+func splitPrometheusBothTypeQueries(l log.Logger, cond condition) (condition, error) {
+	ccIdx := -1
+	refIDs := make(map[string][]int, len(cond.Data))
+	for i, q := range cond.Data {
+		refIDs[q.RefID] = nil
+		if q.RefID == cond.Condition && q.DatasourceUID == expressionDatasourceUID {
+			ccIdx = i
+		}
+	}
+	if ccIdx < 0 {
+		return cond, nil
+	}
+
+	var cc struct {
+		Type       string                 `json:"type"`
+		RefID      string                 `json:"refId"`
+		Conditions []classicConditionJSON `json:"conditions"`
+	}
+	if err := json.Unmarshal(cond.Data[ccIdx].Model, &cc); err != nil {
+		return cond, err
+	}
+	if cc.Type != "classic_conditions" {
+		return cond, nil
+	}
+
+	data := make([]alertQuery, len(cond.Data), len(cond.Data)+1)
+	copy(data, cond.Data)
+	instantRefIDs := make(map[string]string) // a map of the RefIDs of split queries to the RefIDs of their instant query
+	for i, q := range cond.Data {
+		if q.DatasourceUID == expressionDatasourceUID {
+			continue
+		}
+		var queryData map[string]json.RawMessage
+		if err := json.Unmarshal(q.Model, &queryData); err != nil {
+			return cond, err
+		}
+		if !isPrometheusBothTypeQuery(queryData) {
+			continue
+		}
+		if !canSplitConditionsOf(cc.Conditions, q.RefID) {
+			l.Warn("Unable to split Prometheus 'Both' type query because a condition using it is AND'ed with preceding conditions", "refId", q.RefID)
+			continue
+		}
+
+		instantRefID, err := getNewRefID(refIDs)
+		if err != nil {
+			return cond, err
+		}
+		refIDs[instantRefID] = nil
+
+		queryData["instant"] = []byte("false")
+		rangeModel, err := json.Marshal(queryData)
+		if err != nil {
+			return cond, err
+		}
+		queryData["instant"] = []byte("true")
+		queryData["range"] = []byte("false")
+		queryData["refId"], err = json.Marshal(instantRefID)
+		if err != nil {
+			return cond, err
+		}
+		instantModel, err := json.Marshal(queryData)
+		if err != nil {
+			return cond, err
+		}
+
+		data[i].Model = rangeModel
+		instantQuery := q
+		instantQuery.RefID = instantRefID
+		instantQuery.Model = instantModel
+		data = append(data, instantQuery)
+		instantRefIDs[q.RefID] = instantRefID
+	}
+	if len(instantRefIDs) == 0 {
+		return cond, nil
+	}
+
+	conditions := make([]classicConditionJSON, 0, len(cc.Conditions)+len(instantRefIDs))
+	for _, c := range cc.Conditions {
+		conditions = append(conditions, c)
+		if len(c.Query.Params) == 0 {
+			continue
+		}
+		if instantRefID, ok := instantRefIDs[c.Query.Params[0]]; ok {
+			instantCond := c
+			instantCond.Query.Params = []string{instantRefID}
+			instantCond.Operator.Type = "or"
+			conditions = append(conditions, instantCond)
+		}
+	}
+	cc.Conditions = conditions
+
+	ccModel, err := json.Marshal(&cc)
+	if err != nil {
+		return cond, err
+	}
+	data[ccIdx].Model = ccModel
+
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].RefID < data[j].RefID
+	})
+	l.Info("Split Prometheus 'Both' type queries into instant and range queries", "queries", len(instantRefIDs))
+
+	cond.Data = data
+	return cond, nil
+}
+
+// classicReducers maps the reducers of classic conditions to the equivalent reducers of reduce expressions.
+var classicReducers = map[string]string{
+	"avg":   "mean",
+	"min":   "min",
+	"max":   "max",
+	"sum":   "sum",
+	"count": "count",
+	"last":  "last",
+}
+
+// classicEvaluatorParams is the number of parameters of the evaluators of classic conditions that threshold
+// expressions support.
+var classicEvaluatorParams = map[string]int{
+	"gt":            1,
+	"lt":            1,
+	"within_range":  2,
+	"outside_range": 2,
+}
+
+// classicOperators maps the operators of classic conditions to the operators of math expressions.
+var classicOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+}
+
+// convertClassicCondition replaces a classic condition expression that has a single condition on a single query with
+// a reduce expression and a threshold expression, so that the alert rule can be edited in the alert rule editor. Like
+// classic conditions, the reduce expression drops the null values of the series. Classic conditions that cannot be
+// converted are left as they are.
+func convertClassicCondition(l log.Logger, cond condition) (condition, error) {
+	converted, reason, err := classicConditionExpressions(cond, false)
+	if reason != "" {
+		l.Debug("Unable to convert classic condition", "reason", reason)
+	}
+	return converted, err
+}
+
+// convertMultiDimensional replaces a classic condition expression whose conditions all apply to the same query with
+// expressions that create an alert instance per series of the query: a reduce expression per reducer, a threshold
+// expression per condition and, if there are several conditions, a math expression that combines them from left to
+// right like classic conditions do. If the conversion is ambiguous, the condition is left as it is and the reason is
+// returned.
+func convertMultiDimensional(cond condition) (condition, string, error) {
+	return classicConditionExpressions(cond, true)
+}
+
+// classicConditionExpressions converts the classic condition expression of the condition into reduce, threshold and
+// math expressions, or returns the reason why it cannot. Several conditions are only converted if multiple is set.
+func classicConditionExpressions(cond condition, multiple bool) (condition, string, error) {
+	ccIdx := -1
+	refIDs := make(map[string][]int, len(cond.Data))
+	queries := 0
+	for i, q := range cond.Data {
+		refIDs[q.RefID] = nil
+		if q.RefID == cond.Condition && q.DatasourceUID == expressionDatasourceUID {
+			ccIdx = i
+		} else {
+			queries++
+		}
+	}
+	if ccIdx < 0 {
+		return cond, "the condition is not a classic condition", nil
+	}
+
+	var cc struct {
+		Type       string                 `json:"type"`
+		RefID      string                 `json:"refId"`
+		Conditions []classicConditionJSON `json:"conditions"`
+	}
+	if err := json.Unmarshal(cond.Data[ccIdx].Model, &cc); err != nil {
+		return cond, "", err
+	}
+	switch {
+	case cc.Type != "classic_conditions":
+		return cond, "the condition is not a classic condition", nil
+	case len(cc.Conditions) == 0:
+		return cond, "the classic condition has no conditions", nil
+	case len(cc.Conditions) > 1 && !multiple:
+		return cond, "the classic condition has several conditions", nil
+	case queries != 1:
+		return cond, "the conditions use different queries whose series cannot be matched", nil
+	}
+
+	for _, c := range cc.Conditions {
+		if _, ok := classicReducers[c.Reducer.Type]; !ok {
+			return cond, fmt.Sprintf("reducer %q has no reduce expression equivalent", c.Reducer.Type), nil
+		}
+		if params, ok := classicEvaluatorParams[c.Evaluator.Type]; !ok || len(c.Evaluator.Params) < params {
+			return cond, fmt.Sprintf("evaluator %q has no threshold expression equivalent", c.Evaluator.Type), nil
+		}
+		if len(c.Query.Params) == 0 {
+			return cond, "a condition has no query", nil
+		}
+	}
+	for i, c := range cc.Conditions {
+		if _, ok := classicOperators[c.Operator.Type]; i > 0 && !ok {
+			return cond, fmt.Sprintf("operator %q has no math expression equivalent", c.Operator.Type), nil
+		}
+	}
+
+	newExpression := func(model map[string]any) (alertQuery, error) {
+		refID, err := getNewRefID(refIDs)
+		if err != nil {
+			return alertQuery{}, err
+		}
+		refIDs[refID] = nil
+		model["refId"] = refID
+		b, err := json.Marshal(model)
+		if err != nil {
+			return alertQuery{}, err
+		}
+		return alertQuery{RefID: refID, Model: b, DatasourceUID: expressionDatasourceUID}, nil
+	}
+	thresholdModel := func(expression string, c classicConditionJSON) map[string]any {
+		return map[string]any{
+			"type":       "threshold",
+			"expression": expression,
+			"conditions": []map[string]any{{
+				"evaluator": conditionEvalJSON{
+					Type:   c.Evaluator.Type,
+					Params: c.Evaluator.Params[:classicEvaluatorParams[c.Evaluator.Type]],
+				},
+			}},
+		}
+	}
+	reduceModel := func(expression, reducer string) map[string]any {
+		return map[string]any{
+			"type":       "reduce",
+			"expression": expression,
+			"reducer":    reducer,
+			"settings":   map[string]string{"mode": "dropNN"},
+		}
+	}
+
+	data := make([]alertQuery, 0, len(cond.Data)+2*len(cc.Conditions))
+	if len(cc.Conditions) == 1 {
+		// The reduce expression takes the place of the classic condition.
+		c := cc.Conditions[0]
+		reduce := reduceModel(c.Query.Params[0], classicReducers[c.Reducer.Type])
+		reduce["refId"] = cc.RefID
+		b, err := json.Marshal(reduce)
+		if err != nil {
+			return cond, "", err
+		}
+		data = append(data, cond.Data...)
+		data[ccIdx].Model = b
+
+		threshold, err := newExpression(thresholdModel(cc.RefID, c))
+		if err != nil {
+			return cond, "", err
+		}
+		data = append(data, threshold)
+		cond.Condition = threshold.RefID
+	} else {
+		data = append(data, cond.Data[:ccIdx]...)
+		data = append(data, cond.Data[ccIdx+1:]...)
+
+		reduces := make(map[string]string) // a map of the reducers to the RefIDs of their reduce expression
+		var expression strings.Builder
+		for i, c := range cc.Conditions {
+			reducer := classicReducers[c.Reducer.Type]
+			reduceRefID, ok := reduces[reducer]
+			if !ok {
+				reduce, err := newExpression(reduceModel(c.Query.Params[0], reducer))
+				if err != nil {
+					return cond, "", err
+				}
+				data = append(data, reduce)
+				reduceRefID = reduce.RefID
+				reduces[reducer] = reduceRefID
+			}
+
+			threshold, err := newExpression(thresholdModel(reduceRefID, c))
+			if err != nil {
+				return cond, "", err
+			}
+			data = append(data, threshold)
+
+			if i == 0 {
+				expression.WriteString("$" + threshold.RefID)
+				continue
+			}
+			// Classic conditions are evaluated from left to right.
+			e := fmt.Sprintf("(%s) %s $%s", expression.String(), classicOperators[c.Operator.Type], threshold.RefID)
+			if i == 1 {
+				e = fmt.Sprintf("%s %s $%s", expression.String(), classicOperators[c.Operator.Type], threshold.RefID)
+			}
+			expression.Reset()
+			expression.WriteString(e)
+		}
+
+		math, err := newExpression(map[string]any{
+			"type":       "math",
+			"expression": expression.String(),
+		})
+		if err != nil {
+			return cond, "", err
+		}
+		data = append(data, math)
+		cond.Condition = math.RefID
+	}
+
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].RefID < data[j].RefID
+	})
+	cond.Data = data
+	return cond, "", nil
+}
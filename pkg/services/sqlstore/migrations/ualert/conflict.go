@@ -0,0 +1,306 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// migratedRuleTitleSuffix is appended to the title of a migrated alert rule that has the same title as an existing
+// alert rule of its folder when the conflicts are merged.
+const migratedRuleTitleSuffix = " (migrated)"
+
+// orgConflicts are the unified alerting resources that an organization has before it is migrated and that the
+// migration would replace or duplicate.
+type orgConflicts struct {
+	orgID int64
+	// rules maps the folder UID and title of the existing alert rules to their UID.
+	rules map[[2]string]string
+	// amConfig is the saved Alertmanager configuration, nil if the organization has none or the default one.
+	amConfig *AlertConfiguration
+}
+
+// empty returns true if the organization has no conflicting resources. It is true for a nil orgConflicts.
+func (c *orgConflicts) empty() bool {
+	return c == nil || (len(c.rules) == 0 && c.amConfig == nil)
+}
+
+// describe returns a description of every conflicting resource, sorted so that reports are stable.
+func (c *orgConflicts) describe() []string {
+	if c.empty() {
+		return nil
+	}
+	result := make([]string, 0, len(c.rules)+1)
+	for key, uid := range c.rules {
+		result = append(result, fmt.Sprintf("alert rule %q (UID %s) already exists in folder %s", key[1], uid, key[0]))
+	}
+	sort.Strings(result)
+	if c.amConfig != nil {
+		result = append(result, "Alertmanager configuration already exists")
+	}
+	return result
+}
+
+// detectConflicts returns the alert rules and the Alertmanager configuration that the organization has before it is
+// migrated. Alert rules that a previous migration created and that are updated in place are not conflicts.
+func (m *migration) detectConflicts(orgID int64) (*orgConflicts, error) {
+	upserted := make(map[string]struct{})
+	for key, uid := range m.migrated[mappingLegacyAlert] {
+		if key[0] == orgID {
+			upserted[uid] = struct{}{}
+		}
+	}
+
+	var rules []struct {
+		UID          string `xorm:"uid"`
+		NamespaceUID string `xorm:"namespace_uid"`
+		Title        string `xorm:"title"`
+	}
+	if err := m.sess.Table("alert_rule").Where("org_id = ?", orgID).Cols("uid", "namespace_uid", "title").Find(&rules); err != nil {
+		return nil, fmt.Errorf("failed to read existing alert rules: %w", err)
+	}
+	conflicts := &orgConflicts{orgID: orgID, rules: make(map[[2]string]string, len(rules))}
+	for _, r := range rules {
+		if _, ok := upserted[r.UID]; !ok {
+			conflicts.rules[[2]string{r.NamespaceUID, r.Title}] = r.UID
+		}
+	}
+
+	cfg := AlertConfiguration{}
+	exists, err := m.sess.Where("org_id = ? AND "+m.mg.Dialect.Quote("default")+" = ?", orgID, false).Desc("id").Get(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing Alertmanager configuration: %w", err)
+	}
+	if exists {
+		conflicts.amConfig = &cfg
+	}
+	return conflicts, nil
+}
+
+// existingReceiverNames returns the names of the contact points of the existing Alertmanager configuration of the
+// organization being migrated, if the migration keeps it because the conflicts are merged or the migration is
+// incremental.
+func (m *migration) existingReceiverNames() ([]string, error) {
+	if m.conflicts == nil || m.conflicts.amConfig == nil || (m.upgradeCfg.ConflictPolicy != setting.ConflictPolicyMerge && !m.incremental) {
+		return nil, nil
+	}
+	var cfg struct {
+		AlertmanagerConfig struct {
+			Receivers []struct {
+				Name string `json:"name"`
+			} `json:"receivers"`
+		} `json:"alertmanager_config"`
+	}
+	if err := json.Unmarshal([]byte(m.conflicts.amConfig.AlertmanagerConfiguration), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read existing Alertmanager configuration: %w", err)
+	}
+	names := make([]string, 0, len(cfg.AlertmanagerConfig.Receivers))
+	for _, r := range cfg.AlertmanagerConfig.Receivers {
+		names = append(names, r.Name)
+	}
+	return names, nil
+}
+
+// handleConflicts applies the conflict policy to the conflicts of the organization, recording them in its migration
+// status. It returns false if the organization must not be migrated.
+func (m *migration) handleConflicts(conflicts *orgConflicts) (bool, error) {
+	if conflicts.empty() {
+		return true, nil
+	}
+	descriptions := conflicts.describe()
+	policy := m.upgradeCfg.ConflictPolicy
+	m.mg.Logger.Warn("Alert migration warning: organization already has unified alerting resources", "orgID", conflicts.orgID, "policy", policy, "conflicts", strings.Join(descriptions, "; "))
+
+	switch policy {
+	case setting.ConflictPolicyAbort:
+		return false, fmt.Errorf("organisation %d already has unified alerting resources: %s", conflicts.orgID, strings.Join(descriptions, "; "))
+	case setting.ConflictPolicySkip:
+		for _, d := range descriptions {
+			m.orgStates.recordError(conflicts.orgID, fmt.Errorf("conflict: %s, organization not migrated", d))
+		}
+		m.orgStates.markNotMigrated(conflicts.orgID)
+		return false, nil
+	case setting.ConflictPolicyMerge:
+		for _, d := range descriptions {
+			m.orgStates.recordError(conflicts.orgID, fmt.Errorf("conflict: %s, merged", d))
+		}
+	default:
+		for _, d := range descriptions {
+			m.orgStates.recordError(conflicts.orgID, fmt.Errorf("conflict: %s, overwritten", d))
+		}
+		if err := m.deleteConflictingRules(conflicts); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// deleteConflictingRules deletes the existing alert rules of the organization so that the migrated alert rules replace
+// them, like the migrated Alertmanager configuration replaces the existing one.
+func (m *migration) deleteConflictingRules(conflicts *orgConflicts) error {
+	for _, key := range sortedRuleKeys(conflicts.rules) {
+		uid := conflicts.rules[key]
+		m.mg.Logger.Info("Deleting existing alert rule overwritten by the migration", "orgID", conflicts.orgID, "uid", uid, "title", key[1])
+		if err := m.deleteRule(conflicts.orgID, uid); err != nil {
+			return err
+		}
+		m.audit.recordDelete(conflicts.orgID, auditResourceAlertRule, uid, 0, "")
+	}
+	conflicts.rules = make(map[[2]string]string)
+	return nil
+}
+
+// sortedRuleKeys returns the folder UID and title keys of the alert rules, sorted so that deletions are logged in a
+// stable order.
+func sortedRuleKeys(rules map[[2]string]string) [][2]string {
+	keys := make([][2]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// resolveRuleTitle renames the migrated alert rule if an existing alert rule of its folder has the same title and the
+// conflicts are merged, which they always are by the incremental migration. The suffixed title is truncated to the
+// maximum length of the title.
+func (m *migration) resolveRuleTitle(rule *alertRule) {
+	if m.conflicts.empty() || (m.upgradeCfg.ConflictPolicy != setting.ConflictPolicyMerge && !m.incremental) {
+		return
+	}
+	if _, ok := m.conflicts.rules[[2]string{rule.NamespaceUID, rule.Title}]; !ok {
+		return
+	}
+	title := rule.Title
+	for i := 1; ; i++ {
+		suffix := migratedRuleTitleSuffix
+		if i > 1 {
+			suffix = fmt.Sprintf(" (migrated %d)", i)
+		}
+		candidate := title
+		if len(candidate)+len(suffix) > DefaultFieldMaxLength {
+			candidate = candidate[:DefaultFieldMaxLength-len(suffix)]
+		}
+		candidate += suffix
+		if _, ok := m.conflicts.rules[[2]string{rule.NamespaceUID, candidate}]; !ok {
+			rule.Title = candidate
+			break
+		}
+	}
+	m.conflicts.rules[[2]string{rule.NamespaceUID, rule.Title}] = rule.UID
+	m.orgStates.recordError(rule.OrgID, fmt.Errorf("conflict: alert rule %q renamed to %q", title, rule.Title))
+	m.reports.recordRenamedRule(rule.OrgID, rule.UID, title, rule.Title)
+}
+
+// mergeAlertmanagerConfig adds the contact points, notification policies and templates of the migrated Alertmanager
+// configuration to the existing one, and returns the merged configuration. The existing configuration is edited as a
+// generic document to keep the fields that the vendored types do not know. Existing contact points and templates are
+// kept over migrated ones of the same name, and the migrated notification policies are added before the existing ones
+// under the existing root policy.
+func (m *migration) mergeAlertmanagerConfig(orgID int64, existing string, migrated *PostableUserConfig) ([]byte, error) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(existing), &raw); err != nil {
+		return nil, fmt.Errorf("failed to read existing Alertmanager configuration: %w", err)
+	}
+	data, err := json.Marshal(migrated)
+	if err != nil {
+		return nil, err
+	}
+	var add map[string]any
+	if err := json.Unmarshal(data, &add); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		raw = make(map[string]any)
+	}
+
+	templates, _ := raw["template_files"].(map[string]any)
+	if templates == nil {
+		templates = make(map[string]any)
+	}
+	addTemplates, _ := add["template_files"].(map[string]any)
+	for _, name := range sortedKeys(addTemplates) {
+		if _, ok := templates[name]; ok {
+			// The incremental migration adds the templates of the migration again.
+			if m.incremental {
+				continue
+			}
+			m.orgStates.recordError(orgID, fmt.Errorf("conflict: template %q already exists, the migrated template is not added", name))
+			continue
+		}
+		templates[name] = addTemplates[name]
+	}
+	raw["template_files"] = templates
+
+	amConfig, _ := raw["alertmanager_config"].(map[string]any)
+	if amConfig == nil {
+		amConfig = make(map[string]any)
+		raw["alertmanager_config"] = amConfig
+	}
+	addConfig, _ := add["alertmanager_config"].(map[string]any)
+
+	receivers, _ := amConfig["receivers"].([]any)
+	names := make(map[string]struct{}, len(receivers))
+	for _, r := range receivers {
+		receiver, _ := r.(map[string]any)
+		name, _ := receiver["name"].(string)
+		names[name] = struct{}{}
+	}
+	addReceivers, _ := addConfig["receivers"].([]any)
+	for _, r := range addReceivers {
+		receiver, _ := r.(map[string]any)
+		name, _ := receiver["name"].(string)
+		if _, ok := names[name]; ok {
+			// The incremental migration adds the contact points of the channels that were migrated, and the default
+			// contact point, again. The new channels are named after the existing contact points, see receiverNames.
+			if m.incremental && (name == defaultReceiverName || m.synced.containsReceiver(orgID, name)) {
+				continue
+			}
+			m.orgStates.recordError(orgID, fmt.Errorf("conflict: contact point %q already exists, the migrated contact point is not added", name))
+			continue
+		}
+		receivers = append(receivers, r)
+	}
+	amConfig["receivers"] = receivers
+
+	root, _ := amConfig["route"].(map[string]any)
+	addRoot, _ := addConfig["route"].(map[string]any)
+	switch {
+	case root == nil:
+		amConfig["route"] = addRoot
+	case addRoot != nil:
+		routes, _ := root["routes"].([]any)
+		addRoutes, _ := addRoot["routes"].([]any)
+		merged := make([]any, 0, len(addRoutes)+len(routes))
+		merged = append(merged, addRoutes...)
+		for _, r := range routes {
+			if !containsRoute(addRoutes, r) {
+				merged = append(merged, r)
+			}
+		}
+		if len(merged) > 0 {
+			root["routes"] = merged
+		}
+	}
+
+	return json.Marshal(raw)
+}
+
+// containsRoute returns true if the routes contain a route equal to the given one, which is then not added twice.
+func containsRoute(routes []any, route any) bool {
+	for _, r := range routes {
+		if reflect.DeepEqual(r, route) {
+			return true
+		}
+	}
+	return false
+}
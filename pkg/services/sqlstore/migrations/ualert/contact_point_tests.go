@@ -0,0 +1,31 @@
+package ualert
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// TestContactPointsKVNamespace and TestContactPointsKVKey identify the kv_store entries with which the migration
+// requests to test the migrated contact points of an organization. Test notifications can only be sent once the
+// Alertmanager of the organization runs, so unified alerting sends them when it starts and removes the entry of each
+// organization whose contact points were tested.
+const (
+	TestContactPointsKVNamespace = "ngalert.migration.contact_point_tests"
+	TestContactPointsKVKey       = "test_contact_points"
+)
+
+// requestContactPointTests writes the kv_store entry that requests to test the migrated contact points of the organization.
+func requestContactPointTests(sess *xorm.Session, dialect migrator.Dialect, orgID int64) error {
+	exists, err := sess.IsTableExist("kv_store")
+	if err != nil || !exists {
+		return err
+	}
+
+	if err := setKVStoreValue(sess, dialect, orgID, TestContactPointsKVNamespace, TestContactPointsKVKey, "true"); err != nil {
+		return fmt.Errorf("failed to request contact point tests: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package ualert
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// LegacyChannel is the legacy notification channel passed to a ChannelConverter.
+type LegacyChannel struct {
+	OrgID    int64
+	UID      string
+	Name     string
+	Type     string
+	Settings *simplejson.Json
+	// SecureSettings holds the decrypted secure settings of the channel.
+	SecureSettings map[string]string
+}
+
+// ConvertedChannel holds the settings of the unified alerting integration that a legacy notification channel is migrated to.
+type ConvertedChannel struct {
+	// Type is the integration type, it can differ from the type of the legacy channel.
+	Type     string
+	Settings *simplejson.Json
+	// SecureSettings holds the secure settings unencrypted, they are encrypted by the migration.
+	SecureSettings map[string]string
+}
+
+// ChannelConverter converts a legacy notification channel to a unified alerting integration.
+type ChannelConverter func(c LegacyChannel) (*ConvertedChannel, error)
+
+var channelConverters = map[string]ChannelConverter{
+	"slack":                   SecureSettingsConverter("url", "token"),
+	"pagerduty":               SecureSettingsConverter("integrationKey"),
+	"webhook":                 SecureSettingsConverter("password"),
+	"prometheus-alertmanager": SecureSettingsConverter("basicAuthPassword"),
+	"opsgenie":                SecureSettingsConverter("apiKey"),
+	"telegram":                SecureSettingsConverter("bottoken"),
+	"line":                    SecureSettingsConverter("token"),
+	"pushover":                SecureSettingsConverter("apiToken", "userKey"),
+	"threema":                 SecureSettingsConverter("api_secret"),
+}
+
+// defaultChannelConverter is used for the channel types without a registered converter.
+var defaultChannelConverter = SecureSettingsConverter()
+
+// RegisterChannelConverter registers the converter for a legacy notification channel type, replacing the built-in one.
+// Registering a converter for a discontinued type, such as hipchat or sensu, makes the migration convert its channels
+// instead of skipping them. Converters must be registered before the migration runs, typically in an init function.
+func RegisterChannelConverter(chanType string, converter ChannelConverter) {
+	channelConverters[chanType] = converter
+}
+
+// getChannelConverter returns the converter for the legacy notification channel type and whether one is registered.
+func getChannelConverter(chanType string) (ChannelConverter, bool) {
+	if converter, ok := channelConverters[chanType]; ok {
+		return converter, true
+	}
+	return defaultChannelConverter, false
+}
+
+// SecureSettingsConverter returns a ChannelConverter that keeps the channel type and moves the given settings to the
+// secure settings, unless a secure setting with the same key is already set. In the legacy alerting these settings
+// were not always stored encrypted, but unified alerting expects them in the secure settings.
+func SecureSettingsConverter(keys ...string) ChannelConverter {
+	return func(c LegacyChannel) (*ConvertedChannel, error) {
+		secureSettings := make(map[string]string, len(c.SecureSettings))
+		for k, v := range c.SecureSettings {
+			secureSettings[k] = v
+		}
+
+		settings := simplejson.New()
+		settingsMap, err := c.Settings.Map()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range settingsMap {
+			settings.Set(k, v)
+		}
+		for _, k := range keys {
+			if v, ok := secureSettings[k]; ok && v != "" {
+				continue
+			}
+
+			sv := settings.Get(k).MustString()
+			if sv != "" {
+				secureSettings[k] = sv
+				settings.Del(k)
+			}
+		}
+
+		return &ConvertedChannel{
+			Type:           c.Type,
+			Settings:       settings,
+			SecureSettings: secureSettings,
+		}, nil
+	}
+}
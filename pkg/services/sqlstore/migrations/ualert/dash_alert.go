@@ -16,6 +16,8 @@ type dashAlert struct {
 	Frequency   int64
 	For         time.Duration
 	State       string
+	// NewStateDate is the time the legacy alert changed to its current state.
+	NewStateDate time.Time
 
 	Settings       json.RawMessage
 	ParsedSettings *dashAlertSettings
@@ -33,6 +35,7 @@ SELECT id,
 	frequency,
 	%s,
 	state,
+	new_state_date,
 	settings
 FROM
 	alert
@@ -40,26 +43,66 @@ WHERE org_id IN (SELECT id from org)
 	AND dashboard_id IN (SELECT id from dashboard)
 `
 
-// slurpDashAlerts loads all alerts from the alert database table into
-// the dashAlert type. If there are alerts that belong to either organization or dashboard that does not exist, those alerts will not be returned/
-// Additionally it unmarshals the json settings for the alert into the
-// ParsedSettings property of the dash alert.
-func (m *migration) slurpDashAlerts() ([]dashAlert, error) {
-	dashAlerts := []dashAlert{}
-	err := m.sess.SQL(fmt.Sprintf(slurpDashSQL, m.mg.Dialect.Quote("for"))).Find(&dashAlerts)
+// dashAlertBatchSize is the number of legacy alerts that are loaded and migrated at once.
+var dashAlertBatchSize = 1000
+
+// forEachDashAlertBatch loads the legacy alerts of the organization in batches ordered by dashboard and ID, and calls fn
+// with every batch, so that the legacy alerts of large installations are never all held in memory. Alerts that belong
+// to a dashboard that does not exist are not returned. The json settings of the alerts are unmarshalled into the
+// ParsedSettings property.
+func (m *migration) forEachDashAlertBatch(orgID int64, fn func([]dashAlert) error) error {
+	q := fmt.Sprintf(slurpDashSQL, m.mg.Dialect.Quote("for")) +
+		"\tAND org_id = ? AND (dashboard_id > ? OR (dashboard_id = ? AND id > ?))\nORDER BY dashboard_id, id\n" +
+		m.mg.Dialect.Limit(int64(dashAlertBatchSize))
+
+	var lastDashboardID, lastID int64
+	for {
+		dashAlerts := make([]dashAlert, 0, dashAlertBatchSize)
+		if err := m.sess.SQL(q, orgID, lastDashboardID, lastDashboardID, lastID).Find(&dashAlerts); err != nil {
+			return err
+		}
 
-	if err != nil {
-		return nil, err
-	}
+		for i := range dashAlerts {
+			err := json.Unmarshal(dashAlerts[i].Settings, &dashAlerts[i].ParsedSettings)
+			if err != nil {
+				da := dashAlerts[i]
+				return fmt.Errorf("failed to parse alert rule ID:%d, name:'%s', orgID:%d: %w", da.Id, da.Name, da.OrgId, err)
+			}
+		}
 
-	for i := range dashAlerts {
-		err = json.Unmarshal(dashAlerts[i].Settings, &dashAlerts[i].ParsedSettings)
-		if err != nil {
-			da := dashAlerts[i]
-			return nil, fmt.Errorf("failed to parse alert rule ID:%d, name:'%s', orgID:%d: %w", da.Id, da.Name, da.OrgId, err)
+		if len(dashAlerts) > 0 {
+			if err := fn(dashAlerts); err != nil {
+				return err
+			}
 		}
+		if len(dashAlerts) < dashAlertBatchSize {
+			return nil
+		}
+		last := dashAlerts[len(dashAlerts)-1]
+		lastDashboardID, lastID = last.DashboardId, last.Id
 	}
+}
 
+// queryDashAlertOrgIDs returns the IDs of the organizations that have legacy alerts to migrate.
+func (m *migration) queryDashAlertOrgIDs() ([]int64, error) {
+	cond, args := orgCondition("org_id", m.orgID)
+	var orgIDs []int64
+	err := m.sess.SQL(`SELECT DISTINCT org_id FROM alert
+WHERE org_id IN (SELECT id from org)
+	AND dashboard_id IN (SELECT id from dashboard)
+	AND `+cond+`
+ORDER BY org_id`, args...).Find(&orgIDs)
+	return orgIDs, err
+}
+
+// queryDashAlerts loads all alerts from the alert database table without parsing their settings.
+func (m *migration) queryDashAlerts() ([]dashAlert, error) {
+	cond, args := orgCondition("org_id", m.orgID)
+	dashAlerts := []dashAlert{}
+	err := m.sess.SQL(fmt.Sprintf(slurpDashSQL, m.mg.Dialect.Quote("for"))+"\tAND "+cond, args...).Find(&dashAlerts)
+	if err != nil {
+		return nil, err
+	}
 	return dashAlerts, nil
 }
 
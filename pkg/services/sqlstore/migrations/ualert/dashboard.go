@@ -3,6 +3,8 @@ package ualert
 import (
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/slugify"
 )
@@ -39,6 +41,19 @@ func (d *dashboard) setVersion(version int) {
 	d.Data.Set("version", version)
 }
 
+// refreshInterval returns the auto-refresh interval of the dashboard, and false if it does not refresh automatically.
+func (d *dashboard) refreshInterval() (time.Duration, bool) {
+	refresh, err := d.Data.Get("refresh").String()
+	if err != nil || refresh == "" {
+		return 0, false
+	}
+	interval, err := gtime.ParseDuration(refresh)
+	if err != nil || interval <= 0 {
+		return 0, false
+	}
+	return interval, true
+}
+
 // UpdateSlug updates the slug
 func (d *dashboard) updateSlug() {
 	title := d.Data.Get("title").MustString()
@@ -0,0 +1,124 @@
+package ualert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// dashboardLookupBatchSize is the number of dashboards that a single lookup loads.
+var dashboardLookupBatchSize = 100
+
+// dashboardKey identifies a dashboard by its organization and UID.
+type dashboardKey struct {
+	orgID int64
+	uid   string
+}
+
+// dashboardLookupFunc loads the dashboards of the organization with the given UIDs.
+type dashboardLookupFunc func(orgID int64, uids []string) ([]dashboard, error)
+
+// lookupDashboards loads the dashboards of the migrated alerts in batches, running at most limit lookups at the same
+// time. A limit lower than 1 runs one lookup at a time.
+func lookupDashboards(ctx context.Context, keys []dashboardKey, limit int, lookup dashboardLookupFunc) (map[dashboardKey]*dashboard, error) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	uidsPerOrg := make(map[int64][]string)
+	var orgIDs []int64
+	seen := make(map[dashboardKey]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		if _, ok := uidsPerOrg[k.orgID]; !ok {
+			orgIDs = append(orgIDs, k.orgID)
+		}
+		uidsPerOrg[k.orgID] = append(uidsPerOrg[k.orgID], k.uid)
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	dashboards := make(map[dashboardKey]*dashboard, len(seen))
+	sem := semaphore.NewWeighted(int64(limit))
+	for _, orgID := range orgIDs {
+		uids := uidsPerOrg[orgID]
+		for start := 0; start < len(uids); start += dashboardLookupBatchSize {
+			end := start + dashboardLookupBatchSize
+			if end > len(uids) {
+				end = len(uids)
+			}
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return nil, err
+			}
+			go func(orgID int64, uids []string) {
+				defer sem.Release(1)
+				dashes, err := lookup(orgID, uids)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to get dashboards under organisation %d: %w", orgID, err)
+					}
+					return
+				}
+				for i := range dashes {
+					dashboards[dashboardKey{orgID: dashes[i].OrgId, uid: dashes[i].Uid}] = &dashes[i]
+				}
+			}(orgID, uids[start:end])
+		}
+	}
+	// Acquiring the whole semaphore waits for the running lookups.
+	if err := sem.Acquire(ctx, int64(limit)); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return dashboards, nil
+}
+
+// dashboardLookup returns the function that loads the dashboards of the migrated alerts, and the number of lookups
+// that can run at the same time. With the max_concurrent_dashboard_lookups setting, the lookups run on their own
+// sessions outside the transaction of the migration, so that they do not hold it open, and are limited to the
+// connections that the migration leaves free. Otherwise, and with SQLite, whose connections cannot read the tables
+// written by the transaction, they run one at a time in the transaction.
+func (m *migration) dashboardLookup() (dashboardLookupFunc, int) {
+	inTransaction := func(orgID int64, uids []string) ([]dashboard, error) {
+		return findDashboards(m.sess, orgID, uids)
+	}
+
+	limit := m.upgradeCfg.MaxConcurrentDashboardLookups
+	if limit <= 0 || m.mg.Dialect.DriverName() == migrator.SQLite {
+		return inTransaction, 1
+	}
+	// The migration holds a connection for its transaction, and another one for the database lock.
+	if maxOpen := m.mg.DBEngine.DB().DB.Stats().MaxOpenConnections; maxOpen > 0 && limit > maxOpen-2 {
+		limit = maxOpen - 2
+		if limit <= 0 {
+			return inTransaction, 1
+		}
+	}
+
+	return func(orgID int64, uids []string) ([]dashboard, error) {
+		sess := m.mg.DBEngine.NewSession()
+		defer sess.Close()
+		return findDashboards(sess, orgID, uids)
+	}, limit
+}
+
+func findDashboards(sess *xorm.Session, orgID int64, uids []string) ([]dashboard, error) {
+	var dashes []dashboard
+	err := sess.Table("dashboard").Where("org_id = ?", orgID).In("uid", uids).Find(&dashes)
+	return dashes, err
+}
@@ -0,0 +1,221 @@
+package ualert
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// Messages of the dashboard versions written when the legacy alerts of the panels are removed and restored.
+const (
+	panelAlertsRemovedMessage  = "Legacy alerts migrated to Grafana Alerting"
+	panelAlertsRestoredMessage = "Legacy alerts restored from Grafana Alerting"
+)
+
+// rewriteDashboardPanels links the migrated alert rules of the organization to the panel of their legacy alert, by
+// setting the dashboard_uid and panel_id columns that the alert tab of the panels looks up, and removes the legacy
+// alert blocks from the panels, which unified alerting does not use. The blocks are restored from the legacy alerts when
+// rolling back to legacy alerting.
+func (m *migration) rewriteDashboardPanels(orgID int64, rules map[*alertRule][]uidOrID) error {
+	panels := make(map[string]map[int64]struct{})
+	for rule := range rules {
+		dashboardUID := rule.Annotations[ngmodels.DashboardUIDAnnotation]
+		panelID, err := strconv.ParseInt(rule.Annotations[ngmodels.PanelIDAnnotation], 10, 64)
+		if dashboardUID == "" || err != nil {
+			continue
+		}
+		if _, err := m.sess.Exec("UPDATE alert_rule SET dashboard_uid = ?, panel_id = ? WHERE org_id = ? AND uid = ?", dashboardUID, panelID, orgID, rule.UID); err != nil {
+			return fmt.Errorf("failed to link alert rule %s to its panel: %w", rule.UID, err)
+		}
+		if panels[dashboardUID] == nil {
+			panels[dashboardUID] = make(map[int64]struct{})
+		}
+		panels[dashboardUID][panelID] = struct{}{}
+	}
+
+	for dashboardUID, panelIDs := range panels {
+		dash := &dashboard{}
+		exists, err := m.sess.Where("org_id = ? AND uid = ?", orgID, dashboardUID).Get(dash)
+		if err != nil {
+			return fmt.Errorf("failed to get dashboard %s: %w", dashboardUID, err)
+		}
+		if !exists {
+			continue
+		}
+		changed := false
+		forEachPanel(dash.Data, func(panel *simplejson.Json) {
+			if _, ok := panelIDs[panel.Get("id").MustInt64()]; !ok {
+				return
+			}
+			if _, ok := panel.CheckGet("alert"); ok {
+				panel.Del("alert")
+				changed = true
+			}
+		})
+		if !changed {
+			continue
+		}
+		if err := saveDashboardVersion(m.sess, dash, panelAlertsRemovedMessage); err != nil {
+			return fmt.Errorf("failed to remove legacy alerts from dashboard %s: %w", dashboardUID, err)
+		}
+	}
+	return nil
+}
+
+// restoreDashboardPanelAlerts restores the legacy alert blocks that rewriteDashboardPanels removed from the panels of
+// the organization, or of all organizations if orgID is 0, from the settings of their legacy alert. Only the dashboards
+// whose blocks were removed, and not restored since, are changed, and panels that have an alert block are left as they
+// are.
+func restoreDashboardPanelAlerts(sess *xorm.Session, orgID int64) error {
+	exists, err := sess.IsTableExist("alert")
+	if err != nil || !exists {
+		return err
+	}
+
+	type legacyAlert struct {
+		DashboardID int64            `xorm:"dashboard_id"`
+		PanelID     int64            `xorm:"panel_id"`
+		Settings    *simplejson.Json `xorm:"settings"`
+	}
+	cond, args := orgCondition("org_id", orgID)
+	var alerts []legacyAlert
+	if err := sess.SQL("SELECT dashboard_id, panel_id, settings FROM alert WHERE "+cond, args...).Find(&alerts); err != nil {
+		return fmt.Errorf("failed to list legacy alerts: %w", err)
+	}
+	settings := make(map[int64]map[int64]*simplejson.Json)
+	for _, a := range alerts {
+		if a.Settings == nil {
+			continue
+		}
+		if settings[a.DashboardID] == nil {
+			settings[a.DashboardID] = make(map[int64]*simplejson.Json)
+		}
+		settings[a.DashboardID][a.PanelID] = a.Settings
+	}
+
+	for dashboardID, panelSettings := range settings {
+		dash := &dashboard{}
+		exists, err := sess.Where("id = ?", dashboardID).Get(dash)
+		if err != nil {
+			return fmt.Errorf("failed to get dashboard %d: %w", dashboardID, err)
+		}
+		if !exists {
+			continue
+		}
+		removed, err := panelAlertsRemoved(sess, dash)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			continue
+		}
+		changed := false
+		forEachPanel(dash.Data, func(panel *simplejson.Json) {
+			s, ok := panelSettings[panel.Get("id").MustInt64()]
+			if !ok {
+				return
+			}
+			if _, ok := panel.CheckGet("alert"); !ok {
+				panel.Set("alert", s.Interface())
+				changed = true
+			}
+		})
+		if !changed {
+			continue
+		}
+		if err := saveDashboardVersion(sess, dash, panelAlertsRestoredMessage); err != nil {
+			return fmt.Errorf("failed to restore legacy alerts of dashboard %s: %w", dash.Uid, err)
+		}
+	}
+	return nil
+}
+
+// forEachPanel calls fn with every panel of the dashboard, including the panels of collapsed rows and of the rows of
+// the dashboards of the old schema.
+func forEachPanel(data *simplejson.Json, fn func(panel *simplejson.Json)) {
+	var walk func(panels *simplejson.Json)
+	walk = func(panels *simplejson.Json) {
+		for i := range panels.MustArray() {
+			panel := panels.GetIndex(i)
+			fn(panel)
+			if _, ok := panel.CheckGet("panels"); ok {
+				walk(panel.Get("panels"))
+			}
+		}
+	}
+	walk(data.Get("panels"))
+	for i := range data.Get("rows").MustArray() {
+		walk(data.Get("rows").GetIndex(i).Get("panels"))
+	}
+}
+
+// panelAlertsRemoved returns true if rewriteDashboardPanels removed legacy alert blocks from the dashboard, and they
+// were not restored since.
+func panelAlertsRemoved(sess *xorm.Session, dash *dashboard) (bool, error) {
+	version, err := lastPanelAlertsVersion(sess, dash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get versions of dashboard %s: %w", dash.Uid, err)
+	}
+	return version != nil && version.Message == panelAlertsRemovedMessage, nil
+}
+
+// lastPanelAlertsVersion returns the last version of the dashboard written when its legacy alert blocks were removed
+// or restored, nil if there is none.
+func lastPanelAlertsVersion(sess *xorm.Session, dash *dashboard) (*dashver.DashboardVersion, error) {
+	version := &dashver.DashboardVersion{}
+	exists, err := sess.Table("dashboard_version").Where("dashboard_id = ?", dash.Id).
+		In("message", panelAlertsRemovedMessage, panelAlertsRestoredMessage).Desc("version").Limit(1).Get(version)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return version, nil
+}
+
+// saveDashboardVersion writes the data of the dashboard as a new version, based on sqlstore.saveDashboard(). When the
+// legacy alert blocks are removed again by a new migration, the version that restored them is replaced if it is still
+// the current one, so that a migration run again writes a single version. It should be called from inside a
+// transaction.
+func saveDashboardVersion(sess *xorm.Session, dash *dashboard, message string) error {
+	last, err := lastPanelAlertsVersion(sess, dash)
+	if err != nil {
+		return err
+	}
+	if message == panelAlertsRemovedMessage && last != nil && last.Version == dash.Version && last.Message == panelAlertsRestoredMessage {
+		dash.Updated = time.Now()
+		dash.UpdatedBy = FOLDER_CREATED_BY
+		if _, err := sess.ID(dash.Id).Cols("data", "updated", "updated_by").Update(dash); err != nil {
+			return err
+		}
+		last.Created = dash.Updated
+		last.Message = message
+		last.Data = dash.Data
+		_, err := sess.Table("dashboard_version").ID(last.ID).Cols("created", "message", "data").Update(last)
+		return err
+	}
+
+	parentVersion := dash.Version
+	dash.setVersion(dash.Version + 1)
+	dash.Updated = time.Now()
+	dash.UpdatedBy = FOLDER_CREATED_BY
+	if _, err := sess.ID(dash.Id).Cols("data", "version", "updated", "updated_by").Update(dash); err != nil {
+		return err
+	}
+
+	dashVersion := &dashver.DashboardVersion{
+		DashboardID:   dash.Id,
+		ParentVersion: parentVersion,
+		Version:       dash.Version,
+		Created:       time.Now(),
+		CreatedBy:     dash.UpdatedBy,
+		Message:       message,
+		Data:          dash.Data,
+	}
+	_, err = sess.Insert(dashVersion)
+	return err
+}
@@ -0,0 +1,180 @@
+package ualert
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// datasourcePlugin is the part of the plugin.json of a data source plugin that tells whether unified alerting can
+// evaluate its queries: they must be run by the backend of the plugin, which must declare the alerting capability.
+type datasourcePlugin struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	AliasIDs []string `json:"aliasIDs"`
+	Backend  bool     `json:"backend"`
+	Alerting bool     `json:"alerting"`
+}
+
+// datasourcePlugins are the data source plugins found on disk, by ID and alias ID.
+type datasourcePlugins map[string]datasourcePlugin
+
+// loadDatasourcePlugins reads the plugin.json of the core data source plugins and of the plugins installed in the
+// bundled and external plugin directories. It returns nil if no plugin is found, for example if the static files are
+// not available, in which case the data sources cannot be checked.
+func loadDatasourcePlugins(cfg *setting.Cfg) (datasourcePlugins, error) {
+	dirs := []string{cfg.BundledPluginsPath, cfg.PluginsPath}
+	if cfg.StaticRootPath != "" {
+		dirs = append([]string{filepath.Join(cfg.StaticRootPath, "app", "plugins", "datasource")}, dirs...)
+	}
+
+	plugins := make(datasourcePlugins)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || d.Name() != "plugin.json" {
+				return nil
+			}
+			// nolint:gosec
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var p datasourcePlugin
+			if err := json.Unmarshal(b, &p); err != nil || p.Type != "datasource" || p.ID == "" {
+				return nil
+			}
+			plugins[p.ID] = p
+			for _, alias := range p.AliasIDs {
+				plugins[alias] = p
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+	return plugins, nil
+}
+
+// unsupportedReason returns why unified alerting cannot evaluate the queries of data sources of the type, or an empty
+// string if it can.
+func (p datasourcePlugins) unsupportedReason(dsType string) string {
+	plugin, ok := p[dsType]
+	switch {
+	case !ok:
+		return "data source plugin is not installed"
+	case !plugin.Backend:
+		return "data source plugin has no backend, its queries cannot be evaluated by the server"
+	case !plugin.Alerting:
+		return "data source plugin does not support alerting"
+	default:
+		return ""
+	}
+}
+
+// datasourceCompatibility collects the legacy alerts per organization and data source type that unified alerting
+// cannot evaluate.
+type datasourceCompatibility struct {
+	plugins datasourcePlugins
+	// types maps [orgID, data source UID] to the type of the data source.
+	types    map[orgDatasourceKey]string
+	problems map[orgDatasourceTypeKey]*DatasourceValidationProblem
+}
+
+type orgDatasourceKey struct {
+	orgID int64
+	uid   string
+}
+
+type orgDatasourceTypeKey struct {
+	orgID  int64
+	dsType string
+}
+
+// newDatasourceCompatibility loads the types of the data sources of every organization. It returns nil if the data
+// source plugins are unknown.
+func (m *migration) newDatasourceCompatibility(plugins datasourcePlugins) (*datasourceCompatibility, error) {
+	if plugins == nil {
+		return nil, nil
+	}
+	var rows []struct {
+		OrgID int64  `xorm:"org_id"`
+		UID   string `xorm:"uid"`
+		Type  string `xorm:"type"`
+	}
+	if err := m.sess.SQL(`SELECT org_id, uid, type FROM data_source`).Find(&rows); err != nil {
+		return nil, err
+	}
+	types := make(map[orgDatasourceKey]string, len(rows))
+	for _, r := range rows {
+		types[orgDatasourceKey{orgID: r.OrgID, uid: r.UID}] = r.Type
+	}
+	return &datasourceCompatibility{
+		plugins:  plugins,
+		types:    types,
+		problems: make(map[orgDatasourceTypeKey]*DatasourceValidationProblem),
+	}, nil
+}
+
+// check records the alert if unified alerting cannot evaluate the queries of the data source. It is a no-op on a nil
+// datasourceCompatibility.
+func (c *datasourceCompatibility) check(da dashAlert, dsUID string) {
+	if c == nil {
+		return
+	}
+	dsType, ok := c.types[orgDatasourceKey{orgID: da.OrgId, uid: dsUID}]
+	if !ok {
+		return
+	}
+	reason := c.plugins.unsupportedReason(dsType)
+	if reason == "" {
+		return
+	}
+	key := orgDatasourceTypeKey{orgID: da.OrgId, dsType: dsType}
+	p, ok := c.problems[key]
+	if !ok {
+		p = &DatasourceValidationProblem{OrgID: da.OrgId, Type: dsType, Reason: reason}
+		c.problems[key] = p
+	}
+	for _, id := range p.AlertIDs {
+		if id == da.Id {
+			return
+		}
+	}
+	p.AlertIDs = append(p.AlertIDs, da.Id)
+}
+
+// report returns the data source types that unified alerting cannot evaluate, by organization and type.
+func (c *datasourceCompatibility) report() []DatasourceValidationProblem {
+	if c == nil {
+		return nil
+	}
+	problems := make([]DatasourceValidationProblem, 0, len(c.problems))
+	for _, p := range c.problems {
+		problems = append(problems, *p)
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].OrgID != problems[j].OrgID {
+			return problems[i].OrgID < problems[j].OrgID
+		}
+		return problems[i].Type < problems[j].Type
+	})
+	return problems
+}
@@ -0,0 +1,47 @@
+package ualert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestLoadDatasourcePlugins(t *testing.T) {
+	writePlugin := func(t *testing.T, dir, name, pluginJSON string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "plugin.json"), []byte(pluginJSON), 0600))
+	}
+
+	t.Run("no plugins found", func(t *testing.T) {
+		plugins, err := loadDatasourcePlugins(&setting.Cfg{PluginsPath: filepath.Join(t.TempDir(), "missing")})
+		require.NoError(t, err)
+		require.Nil(t, plugins)
+	})
+
+	t.Run("reports unsupported data source types", func(t *testing.T) {
+		static := t.TempDir()
+		core := filepath.Join(static, "app", "plugins", "datasource")
+		writePlugin(t, core, "prometheus", `{"type": "datasource", "id": "prometheus", "backend": true, "alerting": true}`)
+		writePlugin(t, core, "testdata", `{"type": "datasource", "id": "grafana-testdata-datasource", "aliasIDs": ["testdata"], "backend": true, "alerting": true}`)
+		writePlugin(t, core, "zipkin", `{"type": "datasource", "id": "zipkin", "alerting": false}`)
+		writePlugin(t, core, "tempo", `{"type": "datasource", "id": "tempo", "backend": true}`)
+		external := t.TempDir()
+		writePlugin(t, external, "my-panel", `{"type": "panel", "id": "my-panel"}`)
+		writePlugin(t, external, "my-datasource", `{"type": "datasource", "id": "my-datasource", "backend": true, "alerting": true}`)
+
+		plugins, err := loadDatasourcePlugins(&setting.Cfg{StaticRootPath: static, PluginsPath: external})
+		require.NoError(t, err)
+
+		require.Empty(t, plugins.unsupportedReason("prometheus"))
+		require.Empty(t, plugins.unsupportedReason("testdata"))
+		require.Empty(t, plugins.unsupportedReason("my-datasource"))
+		require.Equal(t, "data source plugin has no backend, its queries cannot be evaluated by the server", plugins.unsupportedReason("zipkin"))
+		require.Equal(t, "data source plugin does not support alerting", plugins.unsupportedReason("tempo"))
+		require.Equal(t, "data source plugin is not installed", plugins.unsupportedReason("my-panel"))
+	})
+}
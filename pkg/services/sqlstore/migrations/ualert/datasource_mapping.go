@@ -0,0 +1,108 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// datasourceUIDMappingFile is the file of the datasource_uid_mapping_file setting. It maps the UIDs of data sources
+// that were re-provisioned with new UIDs to their new UIDs, for all organizations and per organization. For example:
+//
+//	uids:
+//	  old-prometheus: new-prometheus
+//	orgs:
+//	  2:
+//	    old-loki: new-loki
+type datasourceUIDMappingFile struct {
+	UIDs map[string]string           `yaml:"uids"`
+	Orgs map[int64]map[string]string `yaml:"orgs"`
+}
+
+// loadDatasourceUIDMappingFile reads and validates the mapping file at path.
+func loadDatasourceUIDMappingFile(path string) (*datasourceUIDMappingFile, error) {
+	// nolint:gosec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read datasource UID mapping file: %w", err)
+	}
+
+	var f datasourceUIDMappingFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse datasource UID mapping file %s: %w", path, err)
+	}
+	for _, uids := range append([]map[string]string{f.UIDs}, orgUIDMappings(f.Orgs)...) {
+		for from, to := range uids {
+			if from == "" || to == "" {
+				return nil, fmt.Errorf("invalid datasource UID mapping file %s: UIDs must not be empty", path)
+			}
+		}
+	}
+	return &f, nil
+}
+
+func orgUIDMappings(orgs map[int64]map[string]string) []map[string]string {
+	result := make([]map[string]string, 0, len(orgs))
+	for _, m := range orgs {
+		result = append(result, m)
+	}
+	return result
+}
+
+// remap returns the new UID of the data source of the organization, whose entries override those for all
+// organizations, or the given UID if it is not mapped. It is a no-op on a nil datasourceUIDMappingFile.
+func (f *datasourceUIDMappingFile) remap(orgID int64, uid string) string {
+	if f == nil || uid == "" {
+		return uid
+	}
+	if to, ok := f.Orgs[orgID][uid]; ok {
+		return to
+	}
+	if to, ok := f.UIDs[uid]; ok {
+		return to
+	}
+	return uid
+}
+
+// remapModelDatasource replaces the UID of the data source reference of the query model, if it is mapped. Data
+// sources referenced by name are left as is.
+func (f *datasourceUIDMappingFile) remapModelDatasource(orgID int64, model map[string]json.RawMessage) error {
+	raw, ok := model["datasource"]
+	if f == nil || !ok {
+		return nil
+	}
+	var ref map[string]any
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		// The data source is referenced by name.
+		return nil
+	}
+	uid, _ := ref["uid"].(string)
+	if remapped := f.remap(orgID, uid); remapped != uid {
+		ref["uid"] = remapped
+		b, err := json.Marshal(ref)
+		if err != nil {
+			return err
+		}
+		model["datasource"] = b
+	}
+	return nil
+}
+
+// modelDatasourceUID returns the UID of the data source reference of the legacy query model, empty if it has none.
+func modelDatasourceUID(model json.RawMessage) string {
+	var m struct {
+		Datasource json.RawMessage `json:"datasource"`
+	}
+	if err := json.Unmarshal(model, &m); err != nil {
+		return ""
+	}
+	var ref struct {
+		UID string `json:"uid"`
+	}
+	if err := json.Unmarshal(m.Datasource, &ref); err != nil {
+		return ""
+	}
+	return ref.UID
+}
@@ -0,0 +1,31 @@
+package ualert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDatasourceUIDMappingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+uids:
+  old-prometheus: new-prometheus
+orgs:
+  2:
+    old-prometheus: new-prometheus-2
+`), 0600))
+
+	f, err := loadDatasourceUIDMappingFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new-prometheus", f.remap(1, "old-prometheus"))
+	assert.Equal(t, "new-prometheus-2", f.remap(2, "old-prometheus"))
+	assert.Equal(t, "other", f.remap(1, "other"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`uids: {"old": ""}`), 0600))
+	_, err = loadDatasourceUIDMappingFile(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package ualert
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// legacyAlertmanagerChannelType is the type of the legacy notification channels that send alerts to an Alertmanager.
+const legacyAlertmanagerChannelType = "prometheus-alertmanager"
+
+// auditResourceDatasource is the resource type of the Alertmanager data sources created by the migration.
+const auditResourceDatasource = "datasource"
+
+// legacyAlertmanagerLabelFormat is the format of the label that the migration adds to the alert rules of the legacy
+// alerts that used a legacy Alertmanager notification channel, with the ID of the channel. The data sources migrated
+// from the channel only receive the alerts with this label.
+const legacyAlertmanagerLabelFormat = "__legacy_alertmanager_%d__"
+
+// migrateAlertmanagerChannels creates an Alertmanager data source per URL of the legacy Alertmanager notification
+// channels of the organization, and returns the channels and default channels without them so that no contact point
+// is created for them. The alert rules of the alerts that used these channels are labelled so that the data sources
+// only receive their alerts, and the organization is configured to send alerts to both the internal and the external
+// Alertmanagers. The alerts that used only these channels are routed to the default contact point.
+func (m *migration) migrateAlertmanagerChannels(orgID int64, channels, defaultChannels []*notificationChannel, rules map[*alertRule][]uidOrID) ([]*notificationChannel, []*notificationChannel, error) {
+	migrated := make(map[*notificationChannel]struct{})
+	remaining := make([]*notificationChannel, 0, len(channels))
+	for _, c := range channels {
+		if c.Type != legacyAlertmanagerChannelType {
+			remaining = append(remaining, c)
+			continue
+		}
+		if err := m.createAlertmanagerDatasources(c); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate notification channel %q to Alertmanager data sources: %w", c.Name, err)
+		}
+		labelAlertmanagerChannelRules(c, rules)
+		migrated[c] = struct{}{}
+	}
+	if len(migrated) == 0 {
+		return channels, defaultChannels, nil
+	}
+	if err := m.sendToExternalAlertmanagers(orgID); err != nil {
+		return nil, nil, fmt.Errorf("failed to enable external Alertmanagers: %w", err)
+	}
+
+	remainingDefaults := make([]*notificationChannel, 0, len(defaultChannels))
+	for _, c := range defaultChannels {
+		if _, ok := migrated[c]; !ok {
+			remainingDefaults = append(remainingDefaults, c)
+		}
+	}
+	m.mg.Logger.Info("Migrated Alertmanager notification channels to data sources", "orgID", orgID, "channels", len(migrated))
+	return remaining, remainingDefaults, nil
+}
+
+// labelAlertmanagerChannelRules adds the label of the legacy Alertmanager notification channel to the alert rules of
+// the alerts that used it, or to all alert rules if it is a default channel.
+func labelAlertmanagerChannelRules(c *notificationChannel, rules map[*alertRule][]uidOrID) {
+	label := fmt.Sprintf(legacyAlertmanagerLabelFormat, c.ID)
+	for rule, channelIDs := range rules {
+		uses := c.IsDefault
+		for _, id := range channelIDs {
+			if id == c.Uid || id == c.ID {
+				uses = true
+				break
+			}
+		}
+		if uses {
+			rule.Labels[label] = "true"
+		}
+	}
+}
+
+// sendToExternalAlertmanagers configures the organization to send its alerts to both the internal and the external
+// Alertmanagers, unless it already has an admin configuration.
+func (m *migration) sendToExternalAlertmanagers(orgID int64) error {
+	exists, err := m.sess.Table("ngalert_configuration").Where("org_id = ?", orgID).Exist()
+	if err != nil || exists {
+		return err
+	}
+	now := time.Now().Unix()
+	_, err = m.sess.Table("ngalert_configuration").Insert(&ngmodels.AdminConfiguration{
+		OrgID:        orgID,
+		SendAlertsTo: ngmodels.AllAlertmanagers,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	return err
+}
+
+// createAlertmanagerDatasources creates an Alertmanager data source that receives the alerts of Grafana for every URL
+// of the legacy Alertmanager notification channel, with its basic authentication credentials. The data sources only
+// receive the alerts with the label of the channel.
+func (m *migration) createAlertmanagerDatasources(c *notificationChannel) error {
+	urls, err := alertmanagerChannelURLs(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Settings.Get("basicAuthUser").MustString()
+	password, ok := c.SecureSettings.DecryptedValue("basicAuthPassword")
+	if !ok {
+		password = c.Settings.Get("basicAuthPassword").MustString()
+	}
+
+	now := time.Now()
+	for i, u := range urls {
+		uid, err := m.generateDatasourceUID(c.OrgID)
+		if err != nil {
+			return err
+		}
+		name := c.Name
+		if len(urls) > 1 {
+			name = fmt.Sprintf("%s %d", c.Name, i+1)
+		}
+		if taken, err := m.sess.Table("data_source").Where("org_id = ? AND name = ?", c.OrgID, name).Exist(); err != nil {
+			return err
+		} else if taken {
+			name = fmt.Sprintf("%s - %s", name, uid)
+		}
+
+		ds := &datasources.DataSource{
+			OrgID:   c.OrgID,
+			Name:    name,
+			Type:    datasources.DS_ALERTMANAGER,
+			Access:  datasources.DS_ACCESS_PROXY,
+			URL:     u,
+			Created: now,
+			Updated: now,
+			UID:     uid,
+			Version: 1,
+			JsonData: simplejson.NewFromAny(map[string]any{
+				"handleGrafanaManagedAlerts":   true,
+				"grafanaManagedAlertsMatchers": fmt.Sprintf(legacyAlertmanagerLabelFormat+`="true"`, c.ID),
+				"implementation":               "prometheus",
+			}),
+			SecureJsonData: map[string][]byte{},
+		}
+		if user != "" {
+			ds.BasicAuth = true
+			ds.BasicAuthUser = user
+			if password != "" {
+				ds.SecureJsonData = GetEncryptedJsonData(map[string]string{
+					"basicAuthPassword": password,
+				})
+			}
+		}
+
+		if _, err := m.sess.Table("data_source").Insert(ds); err != nil {
+			return err
+		}
+		m.audit.recordCreate(c.OrgID, auditResourceDatasource, uid, c.ID, c.Uid)
+	}
+	return nil
+}
+
+// alertmanagerChannelURLs returns the URLs of the legacy Alertmanager notification channel, which can list several
+// URLs separated by commas.
+func alertmanagerChannelURLs(c *notificationChannel) ([]string, error) {
+	var urls []string
+	for _, s := range strings.Split(c.Settings.Get("url").MustString(), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid Alertmanager URL %q", s)
+		}
+		urls = append(urls, s)
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no Alertmanager URL")
+	}
+	return urls, nil
+}
+
+// generateDatasourceUID returns a UID that no data source of the organization has.
+func (m *migration) generateDatasourceUID(orgID int64) (string, error) {
+	for i := 0; i < 3; i++ {
+		uid := util.GenerateShortUID()
+		exists, err := m.sess.Table("data_source").Where("uid = ? AND org_id = ?", uid, orgID).Exist()
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return uid, nil
+		}
+	}
+	return "", datasources.ErrDataSourceFailedGenerateUniqueUid
+}
+
+// deleteMigratedDatasources deletes the Alertmanager data sources that the migration created in the organization, or
+// in all organizations if orgID is 0, and that were not deleted since.
+func deleteMigratedDatasources(sess *xorm.Session, orgID int64) error {
+	exists, err := sess.IsTableExist("alert_migration_audit")
+	if err != nil || !exists {
+		return err
+	}
+
+	created, err := queryLiveAuditEntries(sess)
+	if err != nil {
+		return err
+	}
+	for _, e := range created {
+		if e.ResourceType != auditResourceDatasource || (orgID != 0 && e.OrgID != orgID) {
+			continue
+		}
+		if _, err := sess.Exec("DELETE FROM data_source WHERE org_id = ? AND uid = ?", e.OrgID, e.ResourceUID); err != nil {
+			return fmt.Errorf("failed to delete data source %s: %w", e.ResourceUID, err)
+		}
+	}
+	return nil
+}
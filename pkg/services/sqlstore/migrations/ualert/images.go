@@ -0,0 +1,105 @@
+package ualert
+
+import (
+	"fmt"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// channelTypesWithImages are the types of contact points that attach screenshots to their notifications in unified alerting.
+var channelTypesWithImages = map[string]struct{}{
+	"discord":    {},
+	"email":      {},
+	"googlechat": {},
+	"opsgenie":   {},
+	"pagerduty":  {},
+	"pushover":   {},
+	"slack":      {},
+	"teams":      {},
+	"telegram":   {},
+	"threema":    {},
+	"victorops":  {},
+	"webhook":    {},
+}
+
+// channelTypesWithImageFiles are the types of contact points that attach the image files of the screenshots. The others
+// link the images uploaded to external image storage, see the upload_external_image_storage setting.
+var channelTypesWithImageFiles = map[string]struct{}{
+	"discord":  {},
+	"email":    {},
+	"pushover": {},
+	"telegram": {},
+}
+
+// legacyUploadImage returns the uploadImage setting of the legacy notification channel, which defaults to true.
+func legacyUploadImage(c *notificationChannel) bool {
+	if c.Settings == nil {
+		return true
+	}
+	if value, exists := c.Settings.CheckGet("uploadImage"); exists {
+		return value.MustBool()
+	}
+	return true
+}
+
+// checkUploadImage returns an error if the uploadImage setting of the legacy notification channel cannot be honored
+// by the migrated contact point with the [unified_alerting.screenshots] settings. Unified alerting attaches the
+// screenshots of the dashboard panel of the alert rule if screenshots are enabled and the contact point supports images.
+// The rules whose channels all disabled uploadImage are migrated with screenshots disabled, see disableScreenshots.
+func checkUploadImage(c *notificationChannel, screenshots setting.UnifiedAlertingScreenshotSettings) error {
+	if !legacyUploadImage(c) {
+		return nil
+	}
+	_, supported := channelTypesWithImages[c.Type]
+	_, attachesFiles := channelTypesWithImageFiles[c.Type]
+	switch {
+	case !supported:
+		return fmt.Errorf("images are enabled but contact points of type %q do not attach images", c.Type)
+	case !screenshots.Capture:
+		return fmt.Errorf("images are enabled but capture is disabled in [unified_alerting.screenshots]")
+	case !attachesFiles && !screenshots.UploadExternalImageStorage:
+		return fmt.Errorf("images are enabled but contact points of type %q only link images, and upload_external_image_storage is disabled in [unified_alerting.screenshots]", c.Type)
+	}
+	return nil
+}
+
+// disableScreenshots sets ngmodels.ScreenshotsDisabledAnnotation on the alert rules whose legacy notification channels,
+// including the default channels of the organization, all had uploadImage disabled, so that no screenshots are captured
+// for them when capture is enabled in [unified_alerting.screenshots]. Images cannot be disabled per contact point, so
+// the rules whose channels disagree keep their screenshots.
+func (m *migration) disableScreenshots(orgID int64, rules map[*alertRule][]uidOrID, channels, defaultChannels []*notificationChannel) {
+	if !m.screenshotCfg.Capture {
+		return
+	}
+	byUIDOrID := make(map[uidOrID]*notificationChannel, 2*len(channels))
+	for _, c := range channels {
+		byUIDOrID[c.Uid] = c
+		byUIDOrID[c.ID] = c
+	}
+	for rule, channelIDs := range rules {
+		notified := append([]*notificationChannel(nil), defaultChannels...)
+		for _, id := range channelIDs {
+			if c, ok := byUIDOrID[id]; ok {
+				notified = append(notified, c)
+			}
+		}
+		upload, noUpload := 0, 0
+		for _, c := range notified {
+			if legacyUploadImage(c) {
+				upload++
+			} else {
+				noUpload++
+			}
+		}
+		switch {
+		case noUpload > 0 && upload == 0:
+			if rule.Annotations == nil {
+				rule.Annotations = make(map[string]string)
+			}
+			rule.Annotations[ngmodels.ScreenshotsDisabledAnnotation] = "true"
+		case noUpload > 0:
+			m.mg.Logger.Warn("Legacy uploadImage setting of notification channels cannot be honored for alert rule, screenshots are attached by all its contact points", "orgID", orgID, "rule", rule.Title)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const syncMigTitle = "sync new dashboard alerts to unified alerting"
+
+// addIncrementalSync adds the migration that migrates the legacy alerts and notification channels added to the
+// migrated organizations since their migration. It is not recorded in the migration_log so that it runs at every start.
+// Unless incremental_sync is enabled, it only runs if the scope of the migration was widened or removed since then, to
+// migrate the alerts of the dashboards that were out of scope.
+func addIncrementalSync(mg *migrator.Migrator) {
+	m := newMigration(mg)
+	m.incremental = true
+	// The report, the events and the requests of the migration are only sent for the migration of the organizations.
+	m.reports = nil
+	m.events = nil
+	mg.AddMigration(syncMigTitle, &syncMigration{migration: m})
+}
+
+// syncMigration is the incremental dashboard alert migration. Unlike the migration of all organizations it is not
+// recorded in the migration_log.
+type syncMigration struct {
+	*migration
+}
+
+func (m *syncMigration) SkipMigrationLog() bool {
+	return true
+}
+
+func (m *syncMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	if !m.upgradeCfg.IncrementalSync {
+		migrated, err := scopeMigrated(sess, m.orgID, m.orgStates.scope)
+		if err != nil {
+			return err
+		}
+		if migrated {
+			return nil
+		}
+		mg.Logger.Info("Scope of the alert migration changed, migrating the legacy alerts added to it")
+	}
+	return m.migration.Exec(sess, mg)
+}
+
+// syncedLegacyIDs are the legacy alerts and notification channels of the migrated organizations that a previous run of
+// the migration migrated, read from the alert_migration_mapping table, by [orgID, legacy ID].
+type syncedLegacyIDs struct {
+	alerts map[[2]int64]struct{}
+	// channels maps the migrated notification channels to the name of their contact point.
+	channels map[[2]int64]string
+	// pending are the migrated organizations that have legacy alerts or notification channels to migrate.
+	pending map[int64]struct{}
+}
+
+// loadSyncedLegacyIDs reads the legacy alerts and notification channels that were migrated, and finds the migrated
+// organizations, or the organization of the migration if it is restricted to one, that have new ones.
+func (m *migration) loadSyncedLegacyIDs(channels channelsPerOrg) (*syncedLegacyIDs, error) {
+	synced := &syncedLegacyIDs{
+		alerts:   make(map[[2]int64]struct{}),
+		channels: make(map[[2]int64]string),
+		pending:  make(map[int64]struct{}),
+	}
+	for _, table := range []string{"alert_migration_org_state", "alert_migration_mapping"} {
+		exists, err := m.sess.IsTableExist(table)
+		if err != nil || !exists {
+			return synced, err
+		}
+	}
+
+	cond, args := orgCondition("org_id", m.orgID)
+	var orgIDs []int64
+	if err := m.sess.SQL("SELECT org_id FROM alert_migration_org_state WHERE migrated = ? AND "+cond, append([]any{true}, args...)...).Find(&orgIDs); err != nil {
+		return nil, fmt.Errorf("failed to list migrated organizations: %w", err)
+	}
+	migrated := make(map[int64]struct{}, len(orgIDs))
+	for _, orgID := range orgIDs {
+		migrated[orgID] = struct{}{}
+	}
+
+	var mappings []alertMigrationMapping
+	if err := m.sess.Table("alert_migration_mapping").Where(cond, args...).Cols("org_id", "legacy_type", "legacy_id", "name").Find(&mappings); err != nil {
+		return nil, fmt.Errorf("failed to read migrated legacy alerts and channels: %w", err)
+	}
+	for _, e := range mappings {
+		switch e.LegacyType {
+		case mappingLegacyAlert:
+			synced.alerts[[2]int64{e.OrgID, e.LegacyID}] = struct{}{}
+		case mappingLegacyChannel:
+			synced.channels[[2]int64{e.OrgID, e.LegacyID}] = e.Name
+		}
+	}
+
+	var alerts []struct {
+		OrgID int64 `xorm:"org_id"`
+		ID    int64 `xorm:"id"`
+	}
+	if err := m.sess.SQL("SELECT org_id, id FROM alert WHERE "+cond, args...).Find(&alerts); err != nil {
+		return nil, fmt.Errorf("failed to list legacy alerts: %w", err)
+	}
+	for _, a := range alerts {
+		if _, ok := migrated[a.OrgID]; ok && !synced.containsAlert(a.OrgID, a.ID) {
+			synced.pending[a.OrgID] = struct{}{}
+		}
+	}
+	for orgID, orgChannels := range channels {
+		if _, ok := migrated[orgID]; !ok {
+			continue
+		}
+		for _, c := range orgChannels {
+			if !synced.containsChannel(orgID, c.ID) {
+				synced.pending[orgID] = struct{}{}
+			}
+		}
+	}
+	return synced, nil
+}
+
+// containsAlert returns true if the legacy alert was migrated. It is false for a nil syncedLegacyIDs.
+func (s *syncedLegacyIDs) containsAlert(orgID, alertID int64) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.alerts[[2]int64{orgID, alertID}]
+	return ok
+}
+
+// containsChannel returns true if the legacy notification channel was migrated. It is false for a nil syncedLegacyIDs.
+func (s *syncedLegacyIDs) containsChannel(orgID, channelID int64) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.channels[[2]int64{orgID, channelID}]
+	return ok
+}
+
+// channelName returns the name of the contact point of the legacy notification channel if it was migrated. It is false
+// for a nil syncedLegacyIDs.
+func (s *syncedLegacyIDs) channelName(orgID, channelID int64) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	name, ok := s.channels[[2]int64{orgID, channelID}]
+	return name, ok && name != ""
+}
+
+// containsReceiver returns true if the contact point was migrated from a legacy notification channel by a previous run
+// of the migration. It is false for a nil syncedLegacyIDs.
+func (s *syncedLegacyIDs) containsReceiver(orgID int64, name string) bool {
+	if s == nil {
+		return false
+	}
+	for key, channelName := range s.channels {
+		if key[0] == orgID && channelName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newAlerts returns the legacy alerts that were not migrated.
+func (s *syncedLegacyIDs) newAlerts(dashAlerts []dashAlert) []dashAlert {
+	if s == nil {
+		return dashAlerts
+	}
+	result := make([]dashAlert, 0, len(dashAlerts))
+	for _, da := range dashAlerts {
+		if !s.containsAlert(da.OrgId, da.Id) {
+			result = append(result, da)
+		}
+	}
+	return result
+}
+
+// writeScopes adds the scope of the incremental migration to the scope recorded in the migration state of the migrated
+// organizations, or of the organization if orgID is not 0, as their legacy alerts in the scope are now migrated.
+func (s *migrationOrgStates) writeScopes(sess *xorm.Session, orgID int64) error {
+	cond, args := orgCondition("org_id", orgID)
+	var states []alertMigrationOrgState
+	if err := sess.Where("migrated = ?", true).And(cond, args...).Find(&states); err != nil {
+		return fmt.Errorf("failed to read migrated scopes: %w", err)
+	}
+	for _, state := range states {
+		if _, ok := s.skipped[state.OrgID]; ok {
+			continue
+		}
+		scope, err := mergeMigratedScope(state.Scope, s.scope)
+		if err != nil {
+			return fmt.Errorf("organization %d: %w", state.OrgID, err)
+		}
+		if scope == state.Scope {
+			continue
+		}
+		if _, err := sess.Exec("UPDATE alert_migration_org_state SET scope = ? WHERE id = ?", scope, state.ID); err != nil {
+			return fmt.Errorf("failed to write migrated scope of organization %d: %w", state.OrgID, err)
+		}
+	}
+	return nil
+}
+
+// writeSynced replaces the problems recorded in the migration state of the synced organizations with the problems of
+// the incremental migration, which runs at every start, keeping whether they are in shadow mode.
+func (s *migrationOrgStates) writeSynced(sess *xorm.Session, orgIDs []int64) error {
+	now := time.Now().UTC()
+	for _, orgID := range orgIDs {
+		if _, ok := s.skipped[orgID]; ok {
+			continue
+		}
+		state := alertMigrationOrgState{}
+		exists, err := sess.Where("org_id = ?", orgID).Get(&state)
+		if err != nil {
+			return fmt.Errorf("failed to read migration state of organization %d: %w", orgID, err)
+		}
+		if !exists {
+			continue
+		}
+		state.Errors = ""
+		if errs := s.errors[orgID]; len(errs) > 0 {
+			b, err := json.Marshal(errs)
+			if err != nil {
+				return err
+			}
+			state.Errors = string(b)
+		}
+		state.Updated = now
+		if _, err := sess.ID(state.ID).Cols("errors", "updated").Update(&state); err != nil {
+			return fmt.Errorf("failed to write migration state of organization %d: %w", orgID, err)
+		}
+	}
+	s.errors = nil
+	s.skipped = nil
+	s.notMigrated = nil
+	return nil
+}
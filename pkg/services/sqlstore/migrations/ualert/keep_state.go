@@ -0,0 +1,112 @@
+package ualert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+)
+
+// keepStateReceiverName is the name of the contact point without integrations that the routes of the keep state alerts
+// use if the keep_state_receiver setting is empty or names a contact point that does not exist.
+const keepStateReceiverName = "autogen-contact-point-keep-state"
+
+// keepStateAlertNames returns the names of the alerts that unified alerting sends for the legacy alert instead of
+// keeping its last state, DatasourceError if it kept its state on execution errors and DatasourceNoData if it kept its
+// state on no data.
+func keepStateAlertNames(da dashAlert) []string {
+	var names []string
+	if da.ParsedSettings.ExecutionErrorState == "keep_state" {
+		names = append(names, ErrorAlertName)
+	}
+	if da.ParsedSettings.NoDataState == "keep_state" {
+		names = append(names, NoDataAlertName)
+	}
+	return names
+}
+
+// addKeepStateRoutes adds a route per alert name before the other routes of the configuration, which routes the
+// DatasourceError or DatasourceNoData alerts of the rules that kept their last state in legacy alerting to the
+// keep_state_receiver contact point, without continuing to the other routes.
+func (m *migration) addKeepStateRoutes(orgID int64, amConfig *PostableUserConfig, rules map[*alertRule][]uidOrID) error {
+	ruleUIDs := make(map[string][]string)
+	for rule := range rules {
+		for _, name := range rule.keepStateAlerts {
+			ruleUIDs[name] = append(ruleUIDs[name], rule.UID)
+		}
+	}
+	if len(ruleUIDs) == 0 {
+		return nil
+	}
+
+	receiver, err := m.keepStateReceiver(orgID, amConfig)
+	if err != nil {
+		return err
+	}
+
+	routes := make([]*Route, 0, len(ruleUIDs))
+	for _, name := range sortedKeys(ruleUIDs) {
+		uids := ruleUIDs[name]
+		sort.Strings(uids)
+		quoted := make([]string, 0, len(uids))
+		for _, uid := range uids {
+			quoted = append(quoted, regexp.QuoteMeta(uid))
+		}
+		alertName, err := labels.NewMatcher(labels.MatchEqual, model.AlertNameLabel, name)
+		if err != nil {
+			return err
+		}
+		// The rules have the rule_uid label of getLabelForSilenceMatching.
+		ruleUID, err := labels.NewMatcher(labels.MatchRegexp, "rule_uid", strings.Join(quoted, "|"))
+		if err != nil {
+			return err
+		}
+		routes = append(routes, &Route{Receiver: receiver, ObjectMatchers: ObjectMatchers{alertName, ruleUID}})
+	}
+	amConfig.AlertmanagerConfig.Route.Routes = append(routes, amConfig.AlertmanagerConfig.Route.Routes...)
+	return nil
+}
+
+// keepStateReceiver returns the name of the contact point of the routes of the keep state alerts. It is the one of the
+// keep_state_receiver setting if the migrated configuration or the existing configuration that the migration keeps has
+// it, otherwise a contact point without integrations is added to the configuration.
+func (m *migration) keepStateReceiver(orgID int64, amConfig *PostableUserConfig) (string, error) {
+	existing, err := m.existingReceiverNames()
+	if err != nil {
+		return "", err
+	}
+	hasReceiver := func(name string) bool {
+		for _, r := range amConfig.AlertmanagerConfig.Receivers {
+			if r.Name == name {
+				return true
+			}
+		}
+		for _, n := range existing {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if name := m.upgradeCfg.KeepStateReceiver; name != "" {
+		if hasReceiver(name) {
+			return name, nil
+		}
+		m.mg.Logger.Warn("Alert migration warning: contact point of keep state alerts not found, routing them to a contact point without integrations", "orgId", orgID, "receiver", name)
+		m.orgStates.recordError(orgID, fmt.Errorf("contact point %q of the keep_state_receiver setting not found, the DatasourceError and DatasourceNoData alerts of rules that kept their last state are not delivered", name))
+	}
+
+	if hasReceiver(keepStateReceiverName) {
+		return keepStateReceiverName, nil
+	}
+	amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, &PostableApiReceiver{
+		Name:                    keepStateReceiverName,
+		GrafanaManagedReceivers: []*PostableGrafanaReceiver{},
+	})
+	m.audit.recordCreate(orgID, auditResourceReceiver, keepStateReceiverName, 0, "")
+	return keepStateReceiverName, nil
+}
@@ -0,0 +1,21 @@
+package ualert
+
+import (
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// setKVStoreValue writes the value of the key to the kv_store table, replacing an existing value.
+func setKVStoreValue(sess *xorm.Session, dialect migrator.Dialect, orgID int64, namespace, key, value string) error {
+	keyCol := dialect.Quote("key")
+	if _, err := sess.Exec(fmt.Sprintf("DELETE FROM kv_store WHERE org_id = ? AND namespace = ? AND %s = ?", keyCol), orgID, namespace, key); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	_, err := sess.Exec(fmt.Sprintf("INSERT INTO kv_store (org_id, namespace, %s, value, created, updated) VALUES (?, ?, ?, ?, ?, ?)", keyCol), orgID, namespace, key, value, now, now)
+	return err
+}
@@ -0,0 +1,216 @@
+package ualert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// managedFolderActions are the actions of the managed folder permissions, as granted by the folder permissions service
+// for each permission level.
+var managedFolderActions = map[dashboards.PermissionType][]string{
+	dashboards.PERMISSION_VIEW: {
+		dashboards.ActionDashboardsRead,
+		dashboards.ActionFoldersRead,
+		accesscontrol.ActionAlertingRuleRead,
+	},
+	dashboards.PERMISSION_EDIT: {
+		dashboards.ActionDashboardsRead,
+		dashboards.ActionDashboardsWrite,
+		dashboards.ActionDashboardsDelete,
+		dashboards.ActionDashboardsCreate,
+		dashboards.ActionFoldersRead,
+		dashboards.ActionFoldersWrite,
+		dashboards.ActionFoldersDelete,
+		accesscontrol.ActionAlertingRuleRead,
+		accesscontrol.ActionAlertingRuleCreate,
+		accesscontrol.ActionAlertingRuleUpdate,
+		accesscontrol.ActionAlertingRuleDelete,
+	},
+	dashboards.PERMISSION_ADMIN: {
+		dashboards.ActionDashboardsRead,
+		dashboards.ActionDashboardsWrite,
+		dashboards.ActionDashboardsDelete,
+		dashboards.ActionDashboardsCreate,
+		dashboards.ActionDashboardsPermissionsRead,
+		dashboards.ActionDashboardsPermissionsWrite,
+		dashboards.ActionFoldersRead,
+		dashboards.ActionFoldersWrite,
+		dashboards.ActionFoldersDelete,
+		dashboards.ActionFoldersPermissionsRead,
+		dashboards.ActionFoldersPermissionsWrite,
+		accesscontrol.ActionAlertingRuleRead,
+		accesscontrol.ActionAlertingRuleCreate,
+		accesscontrol.ActionAlertingRuleUpdate,
+		accesscontrol.ActionAlertingRuleDelete,
+	},
+}
+
+// managedPermission is an action of a managed role on a scope.
+type managedPermission struct {
+	RoleID   int64  `xorm:"role_id"`
+	RoleName string `xorm:"role_name"`
+	Action   string `xorm:"action"`
+}
+
+// folderPermissionReport lists the managed permissions that were copied from a dashboard to the folder created for its alerts.
+type folderPermissionReport struct {
+	users           []string
+	serviceAccounts []string
+	teams           []string
+	basicRoles      []string
+}
+
+func (r *folderPermissionReport) add(kind, name string, level dashboards.PermissionType) {
+	entry := fmt.Sprintf("%s:%s", name, level.String())
+	switch kind {
+	case "users":
+		r.users = append(r.users, entry)
+	case "serviceaccounts":
+		r.serviceAccounts = append(r.serviceAccounts, entry)
+	case "teams":
+		r.teams = append(r.teams, entry)
+	case "builtins":
+		r.basicRoles = append(r.basicRoles, entry)
+	}
+}
+
+// copyManagedPermissions grants the managed roles of users, service accounts, teams and basic roles the folder
+// permissions equivalent to their effective permission on the dashboard, including the permissions inherited from
+// the dashboard folder. The permissions of the dashboard ACL are copied by setACL, but once they are migrated to
+// managed roles, changes to the managed permissions are not reflected in the ACL anymore.
+func (m *folderHelper) copyManagedPermissions(dash *dashboard, folder *dashboard) (*folderPermissionReport, error) {
+	report := &folderPermissionReport{}
+	for _, table := range []string{"role", "permission"} {
+		// The managed permissions are created by the access control migrations that run after the first alert migration.
+		exists, err := m.sess.IsTableExist(table)
+		if err != nil || !exists {
+			return report, err
+		}
+	}
+
+	scopes := []any{dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dash.Uid)}
+	if dash.FolderId > 0 {
+		parent := dashboard{}
+		exists, err := m.sess.Where("id=?", dash.FolderId).Cols("uid").Get(&parent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get folder %d: %w", dash.FolderId, err)
+		}
+		if exists {
+			scopes = append(scopes, dashboards.ScopeFoldersProvider.GetResourceScopeUID(parent.Uid))
+		}
+	}
+
+	var permissions []managedPermission
+	err := m.sess.SQL(`SELECT r.id AS role_id, r.name AS role_name, p.action
+		FROM permission AS p
+		INNER JOIN role AS r ON r.id = p.role_id
+		WHERE r.org_id = ? AND r.name LIKE ? AND p.scope IN (?`+strings.Repeat(", ?", len(scopes)-1)+`)`,
+		append([]any{dash.OrgId, "managed:%"}, scopes...)...).Find(&permissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get managed permissions of dashboard %d: %w", dash.Id, err)
+	}
+
+	// The effective permission of every role is the highest one granted on the dashboard or its folder.
+	levels := make(map[int64]dashboards.PermissionType)
+	names := make(map[int64]string)
+	for _, p := range permissions {
+		var level dashboards.PermissionType
+		switch p.Action {
+		case dashboards.ActionDashboardsPermissionsWrite:
+			level = dashboards.PERMISSION_ADMIN
+		case dashboards.ActionDashboardsWrite:
+			level = dashboards.PERMISSION_EDIT
+		case dashboards.ActionDashboardsRead:
+			level = dashboards.PERMISSION_VIEW
+		default:
+			continue
+		}
+		if level > levels[p.RoleID] {
+			levels[p.RoleID] = level
+		}
+		names[p.RoleID] = p.RoleName
+	}
+
+	roleIDs := make([]int64, 0, len(levels))
+	for roleID := range levels {
+		roleIDs = append(roleIDs, roleID)
+	}
+	sort.Slice(roleIDs, func(i, j int) bool { return roleIDs[i] < roleIDs[j] })
+
+	now := time.Now()
+	scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.Uid)
+	for _, roleID := range roleIDs {
+		level := levels[roleID]
+		for _, action := range managedFolderActions[level] {
+			p := accesscontrol.Permission{RoleID: roleID, Action: action, Scope: scope, Created: now, Updated: now}
+			p.Kind, p.Attribute, p.Identifier = p.SplitScope()
+			if _, err := m.sess.Table("permission").Insert(&p); err != nil {
+				return nil, fmt.Errorf("failed to create permission %s for role %s: %w", action, names[roleID], err)
+			}
+		}
+
+		kind, name, err := m.managedRoleSubject(folder.OrgId, names[roleID])
+		if err != nil {
+			return nil, err
+		}
+		report.add(kind, name, level)
+	}
+
+	return report, nil
+}
+
+// managedRoleSubject returns the kind of subject of a managed role, users, serviceaccounts, teams or builtins, and its
+// identifier. Service accounts have managed user roles.
+func (m *folderHelper) managedRoleSubject(orgID int64, roleName string) (string, string, error) {
+	// Managed role names have the format managed:<kind>:<identifier>:permissions.
+	parts := strings.Split(roleName, ":")
+	if len(parts) != 4 {
+		return "", roleName, nil
+	}
+	kind, name := parts[1], parts[2]
+	if kind != "users" {
+		return kind, name, nil
+	}
+
+	isServiceAccount, err := m.sess.Table("user").Where("id = ? AND is_service_account = ?", name, true).Exist()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user %s under organisation %d: %w", name, orgID, err)
+	}
+	if isServiceAccount {
+		return "serviceaccounts", name, nil
+	}
+	return kind, name, nil
+}
+
+// deleteFolderManagedPermissions deletes the managed permissions on the folders created by the migration that match
+// the condition.
+func deleteFolderManagedPermissions(sess *xorm.Session, cond string, args []any) error {
+	exists, err := sess.IsTableExist("permission")
+	if err != nil || !exists {
+		return err
+	}
+
+	var uids []string
+	if err := sess.Table("dashboard").Where("created_by = ? and "+cond, append([]any{FOLDER_CREATED_BY}, args...)...).Cols("uid").Find(&uids); err != nil {
+		return fmt.Errorf("failed to list migrated folders: %w", err)
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+
+	scopes := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		scopes = append(scopes, dashboards.ScopeFoldersProvider.GetResourceScopeUID(uid))
+	}
+	if _, err := sess.Table("permission").In("scope", scopes).Delete(&accesscontrol.Permission{}); err != nil {
+		return fmt.Errorf("failed to delete permissions of migrated folders: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package ualert
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+// Legacy resource types of the alert_migration_mapping table.
+const (
+	mappingLegacyAlert   = "alert"
+	mappingLegacyChannel = "channel"
+)
+
+// alertMigrationMapping is a row of the alert_migration_mapping table. It maps a legacy alert to the migrated alert
+// rule, and a legacy notification channel to the migrated contact point and its integration.
+type alertMigrationMapping struct {
+	ID         int64  `xorm:"pk autoincr 'id'"`
+	OrgID      int64  `xorm:"org_id"`
+	LegacyType string `xorm:"legacy_type"`
+	LegacyID   int64  `xorm:"legacy_id"`
+	LegacyUID  string `xorm:"legacy_uid"`
+	UID        string `xorm:"uid"`
+	Name       string `xorm:"name"`
+}
+
+// migrationMappings collects the legacy ID mappings during the migration so they are written in the same transaction.
+type migrationMappings struct {
+	entries []*alertMigrationMapping
+}
+
+// addAlert maps the legacy alert to the migrated alert rule.
+func (mm *migrationMappings) addAlert(da dashAlert, rule *alertRule) {
+	mm.entries = append(mm.entries, &alertMigrationMapping{
+		OrgID:      da.OrgId,
+		LegacyType: mappingLegacyAlert,
+		LegacyID:   da.Id,
+		UID:        rule.UID,
+		Name:       rule.Title,
+	})
+}
+
+// addChannel maps the legacy notification channel to the migrated contact point.
+func (mm *migrationMappings) addChannel(orgID int64, cr channelReceiver) {
+	uid := ""
+	if len(cr.receiver.GrafanaManagedReceivers) > 0 {
+		uid = cr.receiver.GrafanaManagedReceivers[0].UID
+	}
+	mm.entries = append(mm.entries, &alertMigrationMapping{
+		OrgID:      orgID,
+		LegacyType: mappingLegacyChannel,
+		LegacyID:   cr.channel.ID,
+		LegacyUID:  cr.channel.Uid,
+		UID:        uid,
+		Name:       cr.receiver.Name,
+	})
+}
+
+// write inserts all collected mappings.
+func (mm *migrationMappings) write(sess *xorm.Session) error {
+	for _, e := range mm.entries {
+		if _, err := sess.Insert(e); err != nil {
+			return fmt.Errorf("failed to write mapping of legacy %s %d: %w", e.LegacyType, e.LegacyID, err)
+		}
+	}
+	mm.entries = nil
+	return nil
+}
+
+// deleteMappings deletes the legacy ID mappings of the organization, or of all organizations if orgID is 0, if the
+// alert_migration_mapping table exists.
+func deleteMappings(sess *xorm.Session, orgID int64) error {
+	exists, err := sess.IsTableExist("alert_migration_mapping")
+	if err != nil || !exists {
+		return err
+	}
+	cond, args := orgCondition("org_id", orgID)
+	_, err = sess.Exec(append([]any{"delete from alert_migration_mapping where " + cond}, args...)...)
+	return err
+}
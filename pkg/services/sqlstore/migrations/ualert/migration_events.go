@@ -0,0 +1,146 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// MigrationEventsKVNamespace and MigrationEventsKVKey identify the kv_store entries that hold the MigrationEvents of an
+// organization, or of the migration of all organizations for org 0. The migration cannot publish on the bus, so the
+// events are published by unified alerting when it starts or after it runs the migration itself, from a single
+// instance.
+const (
+	MigrationEventsKVNamespace = "ngalert.migration.events"
+	MigrationEventsKVKey       = "migration_events"
+)
+
+// Types of MigrationEvent.
+const (
+	MigrationEventStarted     = "started"
+	MigrationEventOrgMigrated = "org_migrated"
+	MigrationEventOrgReverted = "org_reverted"
+	MigrationEventFailed      = "failed"
+)
+
+// MigrationEvent is a step of the lifecycle of the migration. The events are only written if the migration commits,
+// except for the failed event of a migration that was rolled back, see recordFailureOnRollback. A failed event is also
+// written for an organization that was not migrated although the migration succeeded, see markNotMigrated.
+type MigrationEvent struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// OrgID is 0 for the start and the failure of the migration of all organizations.
+	OrgID         int64  `json:"orgId"`
+	AlertRules    int    `json:"alertRules,omitempty"`
+	ContactPoints int    `json:"contactPoints,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// migrationEvents collects the events of the migration so they are written in the same transaction. It is nil if the
+// migration publishes no event.
+type migrationEvents struct {
+	events []MigrationEvent
+}
+
+// record records an event of the organization. It is a no-op on a nil migrationEvents.
+func (e *migrationEvents) record(event MigrationEvent) {
+	if e == nil {
+		return
+	}
+	event.Timestamp = time.Now().UTC()
+	e.events = append(e.events, event)
+}
+
+// recordNotMigrated records a failed event for every organization that was not migrated, with its recorded problems.
+// It is a no-op on a nil migrationEvents.
+func (e *migrationEvents) recordNotMigrated(states *migrationOrgStates) {
+	if e == nil || states == nil {
+		return
+	}
+	orgIDs := make([]int64, 0, len(states.notMigrated))
+	for orgID := range states.notMigrated {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+	for _, orgID := range orgIDs {
+		e.record(MigrationEvent{Type: MigrationEventFailed, OrgID: orgID, Error: strings.Join(states.errors[orgID], "; ")})
+	}
+}
+
+// write appends the events to the kv_store entries of their organization, which keep the events of previous runs that
+// were not published yet.
+func (e *migrationEvents) write(sess *xorm.Session, dialect migrator.Dialect) error {
+	if e == nil || len(e.events) == 0 {
+		return nil
+	}
+	exists, err := sess.IsTableExist("kv_store")
+	if err != nil || !exists {
+		return err
+	}
+
+	// The organizations are written in the order of their first event.
+	var orgIDs []int64
+	perOrg := make(map[int64][]MigrationEvent)
+	for _, event := range e.events {
+		if _, ok := perOrg[event.OrgID]; !ok {
+			orgIDs = append(orgIDs, event.OrgID)
+		}
+		perOrg[event.OrgID] = append(perOrg[event.OrgID], event)
+	}
+	for _, orgID := range orgIDs {
+		if err := appendMigrationEvents(sess, dialect, orgID, perOrg[orgID]); err != nil {
+			return fmt.Errorf("failed to write migration events of organization %d: %w", orgID, err)
+		}
+	}
+	e.events = nil
+	return nil
+}
+
+// recordFailureOnRollback records a failed event with the error that the migration of the organization, or of all
+// organizations for org 0, returned, once its transaction is rolled back. The event is written outside of the rolled
+// back transaction, so that the failures of the migrations run at startup and from the API are published alike.
+func recordFailureOnRollback(mg *migrator.Migrator, orgID int64, err error) {
+	event := MigrationEvent{Type: MigrationEventFailed, OrgID: orgID, Error: err.Error()}
+	mg.OnTransactionEnd(func(committed bool) {
+		if committed {
+			return
+		}
+		sess := mg.DBEngine.NewSession()
+		defer sess.Close()
+		if err := recordMigrationEvent(sess, mg.Dialect, event); err != nil {
+			mg.Logger.Error("Alert migration error: failed to record the failure of the migration", "orgID", orgID, "error", err)
+		}
+	})
+}
+
+// recordMigrationEvent writes a single event, for the migrations that only revert an organization.
+func recordMigrationEvent(sess *xorm.Session, dialect migrator.Dialect, event MigrationEvent) error {
+	events := &migrationEvents{}
+	events.record(event)
+	return events.write(sess, dialect)
+}
+
+// appendMigrationEvents appends the events to the kv_store entry of the organization.
+func appendMigrationEvents(sess *xorm.Session, dialect migrator.Dialect, orgID int64, events []MigrationEvent) error {
+	var value string
+	if _, err := sess.Table("kv_store").Where(fmt.Sprintf("org_id = ? AND namespace = ? AND %s = ?", dialect.Quote("key")), orgID, MigrationEventsKVNamespace, MigrationEventsKVKey).Cols("value").Get(&value); err != nil {
+		return err
+	}
+	var existing []MigrationEvent
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &existing); err != nil {
+			return err
+		}
+	}
+	b, err := json.Marshal(append(existing, events...))
+	if err != nil {
+		return err
+	}
+	return setKVStoreValue(sess, dialect, orgID, MigrationEventsKVNamespace, MigrationEventsKVKey, string(b))
+}
@@ -0,0 +1,145 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// MigrationReportKVNamespace and MigrationReportKVKey identify the kv_store entries that hold the MigrationReport of an
+// organization until it is delivered. Unified alerting removes an entry once its report is sent, so an entry that is
+// still present after startup is a report whose delivery failed.
+const (
+	MigrationReportKVNamespace = "ngalert.migration.report"
+	MigrationReportKVKey       = "migration_report"
+)
+
+// MigrationReport summarizes what the migration changed in an organization.
+type MigrationReport struct {
+	OrgID           int64                           `json:"orgId"`
+	Migrated        time.Time                       `json:"migrated"`
+	GrafanaVersion  string                          `json:"grafanaVersion"`
+	AlertRules      int                             `json:"alertRules"`
+	ContactPoints   int                             `json:"contactPoints"`
+	SkippedAlerts   int                             `json:"skippedAlerts"`
+	RenamedRules    []MigrationReportRenamedRule    `json:"renamedRules,omitempty"`
+	SkippedChannels []MigrationReportSkippedChannel `json:"skippedChannels,omitempty"`
+	Warnings        []string                        `json:"warnings,omitempty"`
+}
+
+// MigrationReportRenamedRule is an alert rule renamed because an alert rule of its folder had the same title.
+type MigrationReportRenamedRule struct {
+	UID  string `json:"uid"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MigrationReportSkippedChannel is a notification channel that was not migrated to a contact point.
+type MigrationReportSkippedChannel struct {
+	UID    string `json:"uid"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// migrationReports collects the report of every organization during the migration, so they are written in the same
+// transaction. It is nil if no report is sent.
+type migrationReports struct {
+	reports map[int64]*MigrationReport
+}
+
+// newMigrationReports returns the reports of the migration, or nil if neither the report_webhook_url nor the
+// report_email_org_admins setting is configured.
+func newMigrationReports(cfg setting.UnifiedAlertingUpgradeSettings) *migrationReports {
+	if cfg.ReportWebhookURL == "" && !cfg.ReportEmailOrgAdmins {
+		return nil
+	}
+	return &migrationReports{reports: make(map[int64]*MigrationReport)}
+}
+
+// get returns the report of the organization, creating it if needed.
+func (r *migrationReports) get(orgID int64) *MigrationReport {
+	report, ok := r.reports[orgID]
+	if !ok {
+		report = &MigrationReport{OrgID: orgID}
+		r.reports[orgID] = report
+	}
+	return report
+}
+
+// recordRenamedRule records an alert rule renamed by the migration. It is a no-op on a nil migrationReports.
+func (r *migrationReports) recordRenamedRule(orgID int64, uid, from, to string) {
+	if r == nil {
+		return
+	}
+	report := r.get(orgID)
+	report.RenamedRules = append(report.RenamedRules, MigrationReportRenamedRule{UID: uid, From: from, To: to})
+}
+
+// recordSkippedChannel records a notification channel that was not migrated. It is a no-op on a nil migrationReports.
+func (r *migrationReports) recordSkippedChannel(orgID int64, uid, name string, err error) {
+	if r == nil {
+		return
+	}
+	report := r.get(orgID)
+	report.SkippedChannels = append(report.SkippedChannels, MigrationReportSkippedChannel{UID: uid, Name: name, Reason: err.Error()})
+}
+
+// recordMigrated records the number of alert rules and contact points migrated in the organization. It is a no-op on a
+// nil migrationReports.
+func (r *migrationReports) recordMigrated(orgID int64, alertRules, contactPoints int) {
+	if r == nil {
+		return
+	}
+	report := r.get(orgID)
+	report.AlertRules = alertRules
+	report.ContactPoints = contactPoints
+}
+
+// recordSkippedAlerts records the number of legacy alerts of the organization that failed to migrate and were skipped.
+// It is a no-op on a nil migrationReports.
+func (r *migrationReports) recordSkippedAlerts(orgID int64, skipped int) {
+	if r == nil {
+		return
+	}
+	r.get(orgID).SkippedAlerts = skipped
+}
+
+// write writes the report of every organization that was migrated, with the problems recorded in its migration state,
+// to the kv_store entry from which unified alerting sends it. It must be called before the states are written.
+func (r *migrationReports) write(sess *xorm.Session, dialect migrator.Dialect, states *migrationOrgStates) error {
+	if r == nil || len(r.reports) == 0 {
+		return nil
+	}
+	exists, err := sess.IsTableExist("kv_store")
+	if err != nil || !exists {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for orgID, report := range r.reports {
+		if _, ok := states.skipped[orgID]; ok {
+			continue
+		}
+		if _, ok := states.notMigrated[orgID]; ok {
+			continue
+		}
+		report.Migrated = now
+		report.GrafanaVersion = setting.BuildVersion
+		report.Warnings = states.errors[orgID]
+
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		if err := setKVStoreValue(sess, dialect, orgID, MigrationReportKVNamespace, MigrationReportKVKey, string(b)); err != nil {
+			return fmt.Errorf("failed to write migration report of organization %d: %w", orgID, err)
+		}
+	}
+	r.reports = make(map[int64]*MigrationReport)
+	return nil
+}
@@ -1,8 +1,11 @@
 package ualert_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"xorm.io/xorm"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/alerting/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
@@ -25,6 +29,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 // TestAddDashAlertMigration tests the AddDashAlertMigration wrapper method that decides when to run the migration based on migration status and settings.
@@ -545,72 +550,2040 @@ func TestDashAlertMigration(t *testing.T) {
 			require.Len(t, rules, len(expectedRulesMap))
 			for _, r := range rules {
 				require.Equal(t, expectedRulesMap[r.Title].Labels[ualert.ContactLabel], r.Labels[ualert.ContactLabel])
+
+				audited, err := x.Table("alert_migration_audit").Where("org_id = ? AND resource_type = ? AND resource_uid = ?", orgId, "alert_rule", r.UID).Count()
+				require.NoError(t, err)
+				require.Equal(t, int64(1), audited)
+
+				mapped, err := x.Table("alert_migration_mapping").Where("org_id = ? AND legacy_type = ? AND uid = ?", orgId, "alert", r.UID).Count()
+				require.NoError(t, err)
+				require.Equal(t, int64(1), mapped)
+			}
+		}
+	})
+
+	t.Run("when DashAlertMigration create ContactLabel with sanitized name if name contains double quote", func(t *testing.T) {
+		defer teardown(t, x)
+		legacyChannels := []*models.AlertNotification{
+			createAlertNotification(t, int64(1), "notif\"ier1", "email", emailSettings, false),
+		}
+		alerts := []*models.Alert{
+			createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notif\"ier1"}),
+		}
+		expected := map[int64]map[string]*ngModels.AlertRule{
+			int64(1): {
+				"alert1": {Labels: map[string]string{ualert.ContactLabel: `"notif_ier1"`}},
+			},
+		}
+		setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+		runDashAlertMigrationTestRun(t, x)
+
+		for orgId := range expected {
+			rules := getAlertRules(t, x, orgId)
+			expectedRulesMap := expected[orgId]
+			require.Len(t, rules, len(expectedRulesMap))
+			for _, r := range rules {
+				require.Equal(t, expectedRulesMap[r.Title].Labels[ualert.ContactLabel], r.Labels[ualert.ContactLabel])
+			}
+		}
+	})
+
+	t.Run("when folder is missing put alert in General folder", func(t *testing.T) {
+		o := createOrg(t, 1)
+		folder1 := createDashboard(t, 1, o.ID, "folder-1")
+		folder1.IsFolder = true
+		dash1 := createDashboard(t, 3, o.ID, "dash1")
+		dash1.FolderID = folder1.ID
+		dash2 := createDashboard(t, 4, o.ID, "dash2")
+		dash2.FolderID = 22 // missing folder
+
+		a1 := createAlert(t, o.ID, dash1.ID, int64(1), "alert-1", []string{})
+		a2 := createAlert(t, o.ID, dash2.ID, int64(1), "alert-2", []string{})
+
+		_, err := x.Insert(o, folder1, dash1, dash2, a1, a2)
+		require.NoError(t, err)
+
+		runDashAlertMigrationTestRun(t, x)
+
+		rules := getAlertRules(t, x, o.ID)
+		require.Len(t, rules, 2)
+
+		var generalFolder dashboards.Dashboard
+		_, err = x.Table(&dashboards.Dashboard{}).Where("title = ? AND org_id = ?", ualert.GENERAL_FOLDER, o.ID).Get(&generalFolder)
+		require.NoError(t, err)
+
+		require.NotNil(t, generalFolder)
+
+		for _, rule := range rules {
+			var expectedFolder dashboards.Dashboard
+			if rule.Title == a1.Name {
+				expectedFolder = *folder1
+			} else {
+				expectedFolder = generalFolder
+			}
+			require.Equal(t, expectedFolder.UID, rule.NamespaceUID)
+		}
+	})
+}
+
+// TestDashAlertMigrationTargetFolder tests that all alert rules of an organization with a target folder are migrated into it.
+func TestDashAlertMigrationTargetFolder(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	o := createOrg(t, 1)
+	target := createDashboard(t, 1, o.ID, "target")
+	target.IsFolder = true
+	folder1 := createDashboard(t, 2, o.ID, "folder-1")
+	folder1.IsFolder = true
+	dash1 := createDashboard(t, 3, o.ID, "dash1")
+	dash1.FolderID = folder1.ID
+	dash2 := createDashboard(t, 4, o.ID, "dash2")
+	dash2.HasACL = true
+
+	a1 := createAlert(t, o.ID, dash1.ID, int64(1), "alert-1", []string{})
+	a2 := createAlert(t, o.ID, dash2.ID, int64(1), "alert-2", []string{})
+
+	_, err := x.Insert(o, target, folder1, dash1, dash2, a1, a2)
+	require.NoError(t, err)
+
+	runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+		Upgrade: setting.UnifiedAlertingUpgradeSettings{TargetFolderUIDs: map[int64]string{o.ID: target.UID}},
+	}})
+
+	rules := getAlertRules(t, x, o.ID)
+	require.Len(t, rules, 2)
+	for _, rule := range rules {
+		require.Equal(t, target.UID, rule.NamespaceUID)
+	}
+
+	created, err := x.Table(&dashboards.Dashboard{}).Where("org_id = ? AND created_by = ?", o.ID, ualert.FOLDER_CREATED_BY).Count()
+	require.NoError(t, err)
+	require.Zero(t, created)
+}
+
+// TestDashAlertMigrationManagedPermissions tests that the managed permissions of a dashboard with custom permissions,
+// including the ones inherited from its folder, are granted on the folder created for its alerts.
+func TestDashAlertMigrationManagedPermissions(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	o := createOrg(t, 1)
+	parent := createDashboard(t, 1, o.ID, "parent")
+	parent.IsFolder = true
+	dash := createDashboard(t, 2, o.ID, "dash")
+	dash.FolderID = parent.ID
+	dash.HasACL = true
+	a1 := createAlert(t, o.ID, dash.ID, int64(1), "alert-1", []string{})
+	_, err := x.Insert(o, parent, dash, a1)
+	require.NoError(t, err)
+
+	now := time.Now()
+	grants := []struct {
+		role   string
+		action string
+		scope  string
+	}{
+		{role: "managed:teams:5:permissions", action: dashboards.ActionDashboardsWrite, scope: "dashboards:uid:dash"},
+		{role: "managed:teams:5:permissions", action: dashboards.ActionDashboardsRead, scope: "dashboards:uid:dash"},
+		{role: "managed:users:7:permissions", action: dashboards.ActionDashboardsRead, scope: "folders:uid:parent"},
+		{role: "managed:builtins:viewer:permissions", action: dashboards.ActionDashboardsRead, scope: "dashboards:uid:other"},
+	}
+	roleIDs := make(map[string]int64)
+	for _, g := range grants {
+		if _, ok := roleIDs[g.role]; !ok {
+			role := accesscontrol.Role{OrgID: o.ID, UID: g.role, Name: g.role, Version: 1, Created: now, Updated: now}
+			_, err := x.Table("role").Insert(&role)
+			require.NoError(t, err)
+			roleIDs[g.role] = role.ID
+		}
+		_, err := x.Table("permission").Insert(&accesscontrol.Permission{RoleID: roleIDs[g.role], Action: g.action, Scope: g.scope, Created: now, Updated: now})
+		require.NoError(t, err)
+	}
+
+	runDashAlertMigrationTestRun(t, x)
+
+	rules := getAlertRules(t, x, o.ID)
+	require.Len(t, rules, 1)
+	scope := "folders:uid:" + rules[0].NamespaceUID
+
+	actions := func(role string) []string {
+		var result []string
+		require.NoError(t, x.Table("permission").Where("role_id = ? AND scope = ?", roleIDs[role], scope).Cols("action").Find(&result))
+		sort.Strings(result)
+		return result
+	}
+	require.Contains(t, actions("managed:teams:5:permissions"), dashboards.ActionFoldersWrite)
+	require.Contains(t, actions("managed:teams:5:permissions"), accesscontrol.ActionAlertingRuleUpdate)
+	require.NotContains(t, actions("managed:teams:5:permissions"), dashboards.ActionFoldersPermissionsWrite)
+	require.Equal(t, []string{accesscontrol.ActionAlertingRuleRead, dashboards.ActionDashboardsRead, dashboards.ActionFoldersRead}, actions("managed:users:7:permissions"))
+	require.Empty(t, actions("managed:builtins:viewer:permissions"))
+
+	// Reverting the migration removes the permissions of the folder.
+	mg := migrator.NewMigrator(x, &setting.Cfg{})
+	mg.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
+	require.NoError(t, mg.Start(false, 0))
+	count, err := x.Table("permission").Where("scope = ?", scope).Count()
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+// TestDashAlertMigrationFolderFailure tests that the alerts of a dashboard whose folder cannot be created fail to
+// migrate, or are migrated to the General folder if the fallback is enabled, without failing the migration of the other
+// alerts of the organization.
+func TestDashAlertMigrationFolderFailure(t *testing.T) {
+	run := func(t *testing.T, upgrade setting.UnifiedAlertingUpgradeSettings) (map[string]string, string) {
+		x := setupTestDB(t)
+		t.Cleanup(func() { teardown(t, x) })
+
+		o := createOrg(t, 1)
+		dash1 := createDashboard(t, 1, o.ID, "dash1")
+		dash1.HasACL = true
+		dash2 := createDashboard(t, 2, o.ID, "dash2")
+		dash2.HasACL = true
+		a1 := createAlert(t, o.ID, dash1.ID, int64(1), "alert-1", []string{})
+		a2 := createAlert(t, o.ID, dash2.ID, int64(1), "alert-2", []string{})
+		_, err := x.Insert(o, dash1, dash2, a1, a2)
+		require.NoError(t, err)
+		// A permission without user, team or role cannot be set on the folder of dash1.
+		now := time.Now()
+		_, err = x.Exec("INSERT INTO dashboard_acl (org_id, dashboard_id, permission, created, updated) VALUES (?, ?, ?, ?, ?)", o.ID, dash1.ID, 1, now, now)
+		require.NoError(t, err)
+
+		runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: upgrade}})
+
+		folders := make(map[string]string)
+		var created []dashboards.Dashboard
+		require.NoError(t, x.Table(&dashboards.Dashboard{}).Where("org_id = ? AND is_folder = ?", o.ID, true).Find(&created))
+		for _, f := range created {
+			folders[f.UID] = f.Title
+		}
+		rules := make(map[string]string)
+		for _, r := range getAlertRules(t, x, o.ID) {
+			rules[r.Title] = folders[r.NamespaceUID]
+		}
+
+		var errors string
+		_, err = x.Table("alert_migration_org_state").Where("org_id = ?", o.ID).Cols("errors").Get(&errors)
+		require.NoError(t, err)
+		return rules, errors
+	}
+
+	t.Run("alerts fail to migrate by default", func(t *testing.T) {
+		rules, errors := run(t, setting.UnifiedAlertingUpgradeSettings{SkipFailingAlerts: true})
+		require.Len(t, rules, 1)
+		require.NotContains(t, rules, "alert-1")
+		require.NotEqual(t, ualert.GENERAL_FOLDER, rules["alert-2"])
+		require.NotEmpty(t, rules["alert-2"])
+		require.Contains(t, errors, `alert \"alert-1\"`)
+	})
+
+	t.Run("alerts are migrated to the General folder with the fallback", func(t *testing.T) {
+		rules, errors := run(t, setting.UnifiedAlertingUpgradeSettings{GeneralFolderFallback: true})
+		require.Equal(t, ualert.GENERAL_FOLDER, rules["alert-1"])
+		require.NotEqual(t, ualert.GENERAL_FOLDER, rules["alert-2"])
+		require.NotEmpty(t, rules["alert-2"])
+		require.Contains(t, errors, "they are migrated to the General Alerting folder")
+	})
+}
+
+// TestDashAlertMigrationSkippedAlertRolledBack tests that the folder created for an alert that fails to migrate and is
+// skipped is rolled back with it.
+func TestDashAlertMigrationSkippedAlertRolledBack(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	o := createOrg(t, 1)
+	dash1 := createDashboard(t, 1, o.ID, "dash1")
+	dash1.HasACL = true
+	dash2 := createDashboard(t, 2, o.ID, "dash2")
+	dash2.HasACL = true
+	a1 := createAlert(t, o.ID, dash1.ID, int64(1), "alert-1", []string{})
+	a2 := createAlert(t, o.ID, dash2.ID, int64(2), "alert-2", []string{})
+	_, err := x.Insert(o, dash1, dash2, a1, a2)
+	require.NoError(t, err)
+
+	// The rule group name of the alert of panel 1 is empty, so it fails after its folder is created.
+	runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+		SkipFailingAlerts:     true,
+		RuleGroupNameTemplate: "{{ if ne .PanelID 1 }}group{{ end }}",
+	}}})
+
+	rules := getAlertRules(t, x, o.ID)
+	require.Len(t, rules, 1)
+	require.Equal(t, "alert-2", rules[0].Title)
+
+	var folders []dashboards.Dashboard
+	require.NoError(t, x.Table(&dashboards.Dashboard{}).Where("org_id = ? AND is_folder = ?", o.ID, true).Find(&folders))
+	require.Len(t, folders, 1)
+	require.Equal(t, rules[0].NamespaceUID, folders[0].UID)
+
+	count, err := x.Table("alert_migration_audit").Where("resource_type = ? AND legacy_uid = ?", "folder", "dash1").Count()
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+// TestDashAlertMigrationUpsert tests that re-running the migration with UpsertOnRemigration updates the previously migrated rules in place.
+func TestDashAlertMigrationUpsert(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(2), "alert2", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+		Upgrade: setting.UnifiedAlertingUpgradeSettings{UpsertOnRemigration: true},
+	}}
+	run := func() {
+		_, err := x.Exec("DELETE FROM migration_log WHERE migration_id IN (?, ?)", ualert.RmMigTitle, ualert.MigTitle)
+		require.NoError(t, err)
+
+		alertMigrator := migrator.NewMigrator(x, cfg)
+		alertMigrator.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
+		ualert.AddDashAlertMigration(alertMigrator)
+		require.NoError(t, alertMigrator.Start(false, 0))
+	}
+
+	run()
+	first := getAlertRules(t, x, 1)
+	require.Len(t, first, 2)
+	uids := make(map[string]string, len(first))
+	for _, r := range first {
+		uids[r.Title] = r.UID
+		require.Equal(t, int64(1), r.Version)
+	}
+
+	run()
+	second := getAlertRules(t, x, 1)
+	require.Len(t, second, 2)
+	for _, r := range second {
+		require.Equal(t, uids[r.Title], r.UID)
+		require.Equal(t, int64(2), r.Version)
+	}
+
+	updated, err := x.Table("alert_migration_audit").Where("org_id = ? AND resource_type = ? AND action = ?", 1, "alert_rule", "update").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), updated)
+
+	integrationUID := func() string {
+		amConfig := getAlertmanagerConfig(t, x, 1)
+		for _, r := range amConfig.AlertmanagerConfig.Receivers {
+			if r.Name == "notifier1" {
+				require.Len(t, r.GrafanaManagedReceivers, 1)
+				return r.GrafanaManagedReceivers[0].UID
+			}
+		}
+		require.Fail(t, "contact point notifier1 not migrated")
+		return ""
+	}
+	contactPointUID := integrationUID()
+
+	// Contact points are matched by the legacy ID of their channel, and the alert rules of deleted legacy alerts are
+	// deleted.
+	_, err = x.Exec("UPDATE alert_notification SET uid = ? WHERE org_id = ? AND name = ?", "renamed", 1, "notifier1")
+	require.NoError(t, err)
+	_, err = x.Exec("DELETE FROM alert WHERE org_id = ? AND name = ?", 1, "alert2")
+	require.NoError(t, err)
+	run()
+	third := getAlertRules(t, x, 1)
+	require.Len(t, third, 1)
+	require.Equal(t, uids["alert1"], third[0].UID)
+	require.Equal(t, contactPointUID, integrationUID())
+
+	mappings, err := x.Table("alert_migration_mapping").Where("org_id = ?", 1).Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), mappings)
+}
+
+// TestDashAlertMigrationBackup tests that removing the unified alerting data backs it up, and that the backup restores it.
+func TestDashAlertMigrationBackup(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(2), "alert2", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+	runDashAlertMigrationTestRun(t, x)
+
+	migrated := getAlertRules(t, x, 1)
+	require.Len(t, migrated, 2)
+	amConfig := getAlertmanagerConfig(t, x, 1)
+
+	// The alert instances and the silences are removed with the alert rules.
+	now := time.Now().UTC()
+	_, err := x.Exec("INSERT INTO alert_instance (rule_org_id, rule_uid, labels, labels_hash, current_state, current_state_since, current_state_end, last_eval_time) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		1, migrated[0].UID, "[]", "hash", "Alerting", now.Unix(), now.Unix(), now.Unix())
+	require.NoError(t, err)
+	_, err = x.Exec("INSERT INTO kv_store (org_id, namespace, "+x.Dialect().Quote("key")+", value, created, updated) VALUES (?, ?, ?, ?, ?, ?)",
+		1, ualert.KV_NAMESPACE, "silences", "silence", now, now)
+	require.NoError(t, err)
+
+	_, err = x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.RmMigTitle)
+	require.NoError(t, err)
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+		Upgrade: setting.UnifiedAlertingUpgradeSettings{BackupRemovedData: true, MaxBackupsToKeep: 1},
+	}}
+	mg := migrator.NewMigrator(x, cfg)
+	mg.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
+	require.NoError(t, mg.Start(false, 0))
+	require.Empty(t, getAlertRules(t, x, 1))
+	instances, err := x.Table("alert_instance").Where("rule_org_id = ?", 1).Count()
+	require.NoError(t, err)
+	require.Zero(t, instances)
+
+	sess := x.NewSession()
+	defer sess.Close()
+	backups, err := ualert.ListOrgBackups(sess, 1)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	require.Equal(t, 2, backups[0].Rules)
+	require.Equal(t, 1, backups[0].Folders)
+
+	restored, err := ualert.RestoreOrgBackup(sess, 1, 0, cfg.UnifiedAlerting.Upgrade.MaxBackupsToKeep)
+	require.NoError(t, err)
+	require.Equal(t, backups[0].ID, restored.ID)
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 2)
+	uids := make(map[string]string, len(migrated))
+	for _, r := range migrated {
+		uids[r.Title] = r.UID
+	}
+	for _, r := range rules {
+		require.Equal(t, uids[r.Title], r.UID)
+		exists, err := x.Table("dashboard").Where("org_id = ? AND uid = ? AND is_folder = ?", 1, r.NamespaceUID, true).Exist()
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+	require.Equal(t, amConfig, getAlertmanagerConfig(t, x, 1))
+	instances, err = x.Table("alert_instance").Where("rule_org_id = ? AND rule_uid = ?", 1, migrated[0].UID).Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), instances)
+	var silences string
+	_, err = x.Table("kv_store").Where("org_id = ? AND namespace = ?", 1, ualert.KV_NAMESPACE).Cols("value").Get(&silences)
+	require.NoError(t, err)
+	require.Equal(t, "silence", silences)
+
+	// Restoring backs up the replaced data, keeping the restored backup besides the latest ones.
+	_, err = ualert.RestoreOrgBackup(sess, 1, restored.ID, cfg.UnifiedAlerting.Upgrade.MaxBackupsToKeep)
+	require.NoError(t, err)
+	backups, err = ualert.ListOrgBackups(sess, 1)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, restored.ID, backups[1].ID)
+
+	// Older backups are deleted with their resources.
+	_, err = ualert.RestoreOrgBackup(sess, 1, 0, cfg.UnifiedAlerting.Upgrade.MaxBackupsToKeep)
+	require.NoError(t, err)
+	backups, err = ualert.ListOrgBackups(sess, 1)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.NotEqual(t, restored.ID, backups[1].ID)
+	resources, err := x.Table("alert_migration_backup_resource").Where("backup_id = ?", restored.ID).Count()
+	require.NoError(t, err)
+	require.Zero(t, resources)
+
+	_, err = ualert.RestoreOrgBackup(sess, 2, 0, 0)
+	require.ErrorIs(t, err, ualert.ErrBackupNotFound)
+}
+
+// TestDashAlertMigrationScope tests that only the alerts of the dashboards in the scope of the migration are migrated.
+func TestDashAlertMigrationScope(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(1), "alert2", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+	_, err := x.Exec("INSERT INTO dashboard_tag (dashboard_id, term) VALUES (?, ?)", 2, "pilot")
+	require.NoError(t, err)
+	folder := createDashboard(t, 10, 1, "folder-1")
+	folder.IsFolder = true
+	_, err = x.Insert(folder)
+	require.NoError(t, err)
+	_, err = x.Exec("UPDATE dashboard SET folder_id = ? WHERE id = ?", folder.ID, 2)
+	require.NoError(t, err)
+
+	tc := []struct {
+		desc     string
+		upgrade  setting.UnifiedAlertingUpgradeSettings
+		expected []string
+	}{
+		{
+			desc:     "by dashboard UID",
+			upgrade:  setting.UnifiedAlertingUpgradeSettings{ScopeDashboardUIDs: []string{"dash1-1"}},
+			expected: []string{"alert1"},
+		},
+		{
+			desc:     "by dashboard tag",
+			upgrade:  setting.UnifiedAlertingUpgradeSettings{ScopeDashboardTags: []string{"pilot"}},
+			expected: []string{"alert2"},
+		},
+		{
+			desc:     "by any filter",
+			upgrade:  setting.UnifiedAlertingUpgradeSettings{ScopeDashboardUIDs: []string{"dash1-1"}, ScopeDashboardTags: []string{"pilot"}},
+			expected: []string{"alert1", "alert2"},
+		},
+		{
+			desc:     "by folder ID",
+			upgrade:  setting.UnifiedAlertingUpgradeSettings{ScopeFolderIDs: []int64{10}},
+			expected: []string{"alert2"},
+		},
+		{
+			desc:     "by unknown folder ID",
+			upgrade:  setting.UnifiedAlertingUpgradeSettings{ScopeFolderIDs: []int64{42}},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.desc, func(t *testing.T) {
+			runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: tt.upgrade}})
+
+			var titles []string
+			for _, r := range getAlertRules(t, x, 1) {
+				titles = append(titles, r.Title)
+			}
+			sort.Strings(titles)
+			require.Equal(t, tt.expected, titles)
+		})
+	}
+}
+
+// TestDashAlertMigrationScopeWidened tests that the alerts of the dashboards added to the scope of the migration, or
+// of all dashboards once the scope is removed, are migrated at the next start, leaving the migrated ones as they are.
+func TestDashAlertMigrationScopeWidened(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(1), "alert2", []string{"notifier1"}),
+		createAlert(t, int64(2), int64(3), int64(1), "alert3", []string{}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+	_, err := x.Exec("INSERT INTO dashboard_tag (dashboard_id, term) VALUES (?, ?)", 2, "pilot")
+	require.NoError(t, err)
+
+	scoped := func(upgrade setting.UnifiedAlertingUpgradeSettings) *setting.Cfg {
+		return &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: upgrade}}
+	}
+	restart := func(cfg *setting.Cfg) {
+		m := migrator.NewMigrator(x, cfg)
+		ualert.AddDashAlertMigration(m)
+		require.NoError(t, m.Start(false, 0))
+	}
+	titles := func(orgID int64) []string {
+		var titles []string
+		for _, r := range getAlertRules(t, x, orgID) {
+			titles = append(titles, r.Title)
+		}
+		sort.Strings(titles)
+		return titles
+	}
+
+	runDashAlertMigrationTestRunWithCfg(t, x, scoped(setting.UnifiedAlertingUpgradeSettings{ScopeDashboardUIDs: []string{"dash1-1"}}))
+	require.Equal(t, []string{"alert1"}, titles(1))
+	require.Empty(t, titles(2))
+	migrated := getAlertRules(t, x, 1)[0]
+
+	t.Run("same scope migrates nothing", func(t *testing.T) {
+		restart(scoped(setting.UnifiedAlertingUpgradeSettings{ScopeDashboardUIDs: []string{"dash1-1"}}))
+		require.Equal(t, []string{"alert1"}, titles(1))
+		require.Empty(t, titles(2))
+	})
+
+	t.Run("wider scope migrates the alerts added to it", func(t *testing.T) {
+		restart(scoped(setting.UnifiedAlertingUpgradeSettings{ScopeDashboardUIDs: []string{"dash1-1"}, ScopeDashboardTags: []string{"pilot"}}))
+		require.Equal(t, []string{"alert1", "alert2"}, titles(1))
+		require.Empty(t, titles(2))
+		for _, r := range getAlertRules(t, x, 1) {
+			if r.UID == migrated.UID {
+				require.Equal(t, migrated.Version, r.Version)
 			}
 		}
 	})
 
-	t.Run("when DashAlertMigration create ContactLabel with sanitized name if name contains double quote", func(t *testing.T) {
+	t.Run("removed scope migrates the remaining alerts", func(t *testing.T) {
+		restart(scoped(setting.UnifiedAlertingUpgradeSettings{}))
+		require.Equal(t, []string{"alert1", "alert2"}, titles(1))
+		require.Equal(t, []string{"alert3"}, titles(2))
+
+		var scopes []string
+		require.NoError(t, x.Table("alert_migration_org_state").Where("migrated = ?", true).Cols("scope").Find(&scopes))
+		require.Equal(t, []string{"", ""}, scopes)
+	})
+}
+
+// TestDashAlertMigrationEvaluationIntervalFromDashboardRefresh tests that the pending period of the alert rules is
+// rounded to the evaluation interval derived from the refresh interval of their dashboard.
+func TestDashAlertMigrationEvaluationIntervalFromDashboardRefresh(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+	_, err := x.Exec("UPDATE dashboard SET data = ? WHERE id = ?", `{"refresh":"5m"}`, 1)
+	require.NoError(t, err)
+	_, err = x.Exec("UPDATE alert SET "+x.Dialect().Quote("for")+" = ? WHERE dashboard_id = ?", int64(90*time.Second), 1)
+	require.NoError(t, err)
+
+	runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+		EvaluationIntervalFromDashboardRefresh: true,
+		RoundPendingPeriod:                     true,
+	}}})
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 1)
+	require.EqualValues(t, 300, rules[0].IntervalSeconds)
+	require.Equal(t, 5*time.Minute, rules[0].For)
+}
+
+// TestDashAlertMigrationOrgState tests that the migration records the state of every organization.
+func TestDashAlertMigrationOrgState(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	runDashAlertMigrationTestRun(t, x)
+
+	type orgState struct {
+		OrgID    int64 `xorm:"org_id"`
+		Migrated bool  `xorm:"migrated"`
+	}
+	var states []orgState
+	require.NoError(t, x.Table("alert_migration_org_state").Asc("org_id").Find(&states))
+	require.Equal(t, []orgState{{OrgID: 1, Migrated: true}, {OrgID: 2, Migrated: true}}, states)
+}
+
+// TestDashAlertMigrationWritesEveryOrg tests that the audit entries and the legacy ID mappings, which are written once
+// each organization is migrated, are written for every organization.
+func TestDashAlertMigrationWritesEveryOrg(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(2), "notifier2", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(2), int64(3), int64(1), "alert2", []string{"notifier2"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	runDashAlertMigrationTestRun(t, x)
+
+	for _, orgID := range []int64{1, 2} {
+		mappings, err := x.Table("alert_migration_mapping").Where("org_id = ?", orgID).Count()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, mappings, "org %d", orgID)
+
+		rules, err := x.Table("alert_migration_audit").Where("org_id = ? AND resource_type = ?", orgID, "alert_rule").Count()
+		require.NoError(t, err)
+		require.EqualValues(t, 1, rules, "org %d", orgID)
+	}
+}
+
+// TestDashAlertMigrationLenientValidation tests that receivers failing validation are quarantined in lenient mode
+// while the rest of the organization is migrated.
+func TestDashAlertMigrationLenientValidation(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(1), "notifier2", "email", "", false), // no addresses
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(1), int64(2), "alert2", []string{"notifier2"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{LenientAlertmanagerValidation: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	var receivers []string
+	for _, r := range amConfig.AlertmanagerConfig.Receivers {
+		receivers = append(receivers, r.Name)
+	}
+	require.Equal(t, []string{"notifier1", "autogen-contact-point-default"}, receivers)
+
+	contacts := make(map[string]string)
+	for _, r := range getAlertRules(t, x, 1) {
+		contacts[r.Title] = r.Labels[ualert.ContactLabel]
+	}
+	require.Equal(t, map[string]string{"alert1": `"notifier1"`, "alert2": ""}, contacts)
+
+	var errors string
+	_, err := x.Table("alert_migration_org_state").Where("org_id = ?", 1).Cols("errors").Get(&errors)
+	require.NoError(t, err)
+	require.Contains(t, errors, `receiver \"notifier2\" was not migrated`)
+}
+
+// TestDashAlertMigrationBackfillStateHistory tests that the state changes of the legacy alerts are backfilled to the
+// state history of the migrated alert rules, and removed when the migration is reverted.
+func TestDashAlertMigrationBackfillStateHistory(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+
+	var alertID int64
+	_, err := x.Table("alert").Where("org_id = ? AND name = ?", 1, "alert1").Cols("id").Get(&alertID)
+	require.NoError(t, err)
+	insertAnnotation := func(prev, next, data string, epoch int64) {
+		_, err := x.Exec("INSERT INTO annotation (org_id, alert_id, dashboard_id, panel_id, type, title, text, prev_state, new_state, data, epoch) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			1, alertID, 1, 1, "", "", "", prev, next, data, epoch)
+		require.NoError(t, err)
+	}
+	insertAnnotation("unknown", "alerting", `{"evalMatches":[{"metric":"cpu","value":95}]}`, 1000)
+	insertAnnotation("alerting", "ok", `{}`, 2000)
+	// A duplicate of the state change at the same time is backfilled once.
+	insertAnnotation("alerting", "ok", `{}`, 2000)
+	insertAnnotation("ok", "alerting", `{"error":"query failed"}`, 3000)
+
+	type backfilled struct {
+		AlertID   int64  `xorm:"alert_id"`
+		PrevState string `xorm:"prev_state"`
+		NewState  string `xorm:"new_state"`
+		Text      string `xorm:"text"`
+		Epoch     int64  `xorm:"epoch"`
+	}
+	getBackfilled := func(t *testing.T) []backfilled {
+		var result []backfilled
+		require.NoError(t, x.Table("annotation").Where("type = ?", "alert-migration").Asc("epoch").Find(&result))
+		return result
+	}
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+		Upgrade:      setting.UnifiedAlertingUpgradeSettings{BackfillStateHistory: true},
+		StateHistory: setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "annotations"},
+	}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 1)
+	require.Equal(t, []backfilled{
+		{AlertID: rules[0].ID, PrevState: "Normal", NewState: "Alerting", Text: "alert1 {alertname=alert1} - cpu=95.000000", Epoch: 1000},
+		{AlertID: rules[0].ID, PrevState: "Alerting", NewState: "Normal", Text: "alert1 {alertname=alert1} - ", Epoch: 2000},
+		{AlertID: rules[0].ID, PrevState: "Normal", NewState: "Alerting (Error)", Text: "alert1 {alertname=alert1} - Error", Epoch: 3000},
+	}, getBackfilled(t))
+
+	t.Run("the migration run again replaces the backfilled state history", func(t *testing.T) {
+		runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+		require.Len(t, getBackfilled(t), 3)
+	})
+
+	t.Run("the revert removes the backfilled state history", func(t *testing.T) {
+		runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{})
+		require.Empty(t, getBackfilled(t))
+
+		count, err := x.Table("annotation").Count()
+		require.NoError(t, err)
+		require.EqualValues(t, 4, count)
+	})
+}
+
+// TestDashAlertMigrationSeedAlertInstances tests that the state of the legacy alerts is written as the state of the
+// migrated alert rules.
+func TestDashAlertMigrationSeedAlertInstances(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	since := now.Add(-time.Hour).Truncate(time.Second)
+	alerting := createAlert(t, int64(1), int64(1), int64(1), "alerting", nil)
+	alerting.State, alerting.NewStateDate = models.AlertStateAlerting, since
+	pending := createAlert(t, int64(1), int64(1), int64(2), "pending", nil)
+	pending.State, pending.NewStateDate = models.AlertStatePending, since
+	noData := createAlert(t, int64(1), int64(2), int64(1), "no_data", nil)
+	noData.State = models.AlertStateNoData
+	setupLegacyAlertsTables(t, x, nil, []*models.Alert{alerting, pending, noData})
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{SeedAlertInstances: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	titles := make(map[string]string)
+	for _, r := range getAlertRules(t, x, 1) {
+		titles[r.UID] = r.Title
+	}
+
+	var instances []struct {
+		RuleUID           string `xorm:"rule_uid"`
+		Labels            string `xorm:"labels"`
+		CurrentState      string `xorm:"current_state"`
+		CurrentStateSince int64  `xorm:"current_state_since"`
+	}
+	require.NoError(t, x.Table("alert_instance").Where("rule_org_id = ?", 1).Find(&instances))
+	states := make(map[string]string)
+	for _, i := range instances {
+		states[titles[i.RuleUID]] = i.CurrentState
+		require.Equal(t, since.Unix(), i.CurrentStateSince)
+		require.Contains(t, i.Labels, `["alertname","`+titles[i.RuleUID]+`"]`)
+		require.Contains(t, i.Labels, `["__alert_rule_uid__","`+i.RuleUID+`"]`)
+	}
+	require.Equal(t, map[string]string{"alerting": "Alerting", "pending": "Pending"}, states)
+}
+
+// TestDashAlertMigrationAlertmanagerChannelsToDatasources tests that legacy Alertmanager notification channels are
+// migrated to Alertmanager data sources instead of contact points that only receive the alerts of the channels, and
+// that the data sources are deleted on rollback.
+func TestDashAlertMigrationAlertmanagerChannelsToDatasources(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	am := createAlertNotification(t, int64(1), "am", "prometheus-alertmanager", `{"url":"http://am1:9093, http://am2:9093","basicAuthUser":"user"}`, false)
+	am.SecureSettings = ualert.GetEncryptedJsonData(map[string]string{"basicAuthPassword": "pass"})
+	legacyChannels := []*models.AlertNotification{
+		am,
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"am"}),
+		createAlert(t, int64(1), int64(1), int64(2), "alert2", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{AlertmanagerChannelsToDatasources: true}}}
+	getDatasources := func() []datasources.DataSource {
+		var dss []datasources.DataSource
+		require.NoError(t, x.Table("data_source").Where("org_id = ? AND type = ?", 1, datasources.DS_ALERTMANAGER).Asc("name").Find(&dss))
+		return dss
+	}
+
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	var amID int64
+	_, err := x.Table("alert_notification").Where("org_id = ? AND name = ?", 1, "am").Cols("id").Get(&amID)
+	require.NoError(t, err)
+	label := fmt.Sprintf("__legacy_alertmanager_%d__", amID)
+
+	dss := getDatasources()
+	require.Len(t, dss, 2)
+	for i, ds := range dss {
+		require.Equal(t, fmt.Sprintf("am %d", i+1), ds.Name)
+		require.Equal(t, fmt.Sprintf("http://am%d:9093", i+1), ds.URL)
+		require.True(t, ds.BasicAuth)
+		require.Equal(t, "user", ds.BasicAuthUser)
+		require.True(t, ds.JsonData.Get("handleGrafanaManagedAlerts").MustBool())
+		require.Equal(t, label+`="true"`, ds.JsonData.Get("grafanaManagedAlertsMatchers").MustString())
+		password, ok := ualert.SecureJsonData(ds.SecureJsonData).DecryptedValue("basicAuthPassword")
+		require.True(t, ok)
+		require.Equal(t, "pass", password)
+	}
+
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	names := make([]string, 0, len(amConfig.AlertmanagerConfig.Receivers))
+	for _, r := range amConfig.AlertmanagerConfig.Receivers {
+		names = append(names, r.Name)
+	}
+	require.NotContains(t, names, "am")
+	require.Contains(t, names, "notifier1")
+
+	// Only the alert rule of the alert that used the channel is sent to the data sources.
+	for _, r := range getAlertRules(t, x, 1) {
+		_, ok := r.Labels[label]
+		require.Equal(t, r.Title == "alert1", ok, r.Title)
+	}
+	var sendAlertsTo []int64
+	require.NoError(t, x.Table("ngalert_configuration").Where("org_id = ?", 1).Cols("send_alerts_to").Find(&sendAlertsTo))
+	require.Equal(t, []int64{int64(ngModels.AllAlertmanagers)}, sendAlertsTo)
+
+	// The data sources of the previous migration are deleted when it is rolled back.
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	dss = getDatasources()
+	require.Len(t, dss, 2)
+
+	// The data sources and the admin configuration are backed up when they are removed, and restored.
+	_, err = x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.RmMigTitle)
+	require.NoError(t, err)
+	mg := migrator.NewMigrator(x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+		Upgrade: setting.UnifiedAlertingUpgradeSettings{BackupRemovedData: true},
+	}})
+	mg.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
+	require.NoError(t, mg.Start(false, 0))
+	require.Empty(t, getDatasources())
+
+	sess := x.NewSession()
+	defer sess.Close()
+	restored, err := ualert.RestoreOrgBackup(sess, 1, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, restored.Datasources)
+	restoredDss := getDatasources()
+	require.Len(t, restoredDss, 2)
+	for i, ds := range restoredDss {
+		require.Equal(t, dss[i].UID, ds.UID)
+		require.Equal(t, dss[i].JsonData.MustMap(), ds.JsonData.MustMap())
+	}
+	sendAlertsTo = nil
+	require.NoError(t, x.Table("ngalert_configuration").Where("org_id = ?", 1).Cols("send_alerts_to").Find(&sendAlertsTo))
+	require.Len(t, sendAlertsTo, 1)
+
+	// The restored data sources are deleted by the next rollback.
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	require.Len(t, getDatasources(), 2)
+}
+
+// TestDashAlertMigrationStoreSecretsInSecretsKVStore tests that the migration requests unified alerting to store the
+// secure settings of the migrated contact points in the secrets kvstore, and keeps them encrypted in the configuration
+// until then.
+func TestDashAlertMigrationStoreSecretsInSecretsKVStore(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "slack", slackSettings, true),
+		createAlertNotification(t, int64(1), "notifier2", "email", emailSettings, false),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, nil)
+
+	cfg := &setting.Cfg{
+		UnifiedAlerting:        setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{StoreSecretsInSecretsKVStore: true}},
+		IsFeatureToggleEnabled: func(key string) bool { return key == "disableSecretsCompatibility" },
+	}
+	getRequests := func() []string {
+		var values []string
+		require.NoError(t, x.Table("kv_store").Where("org_id = ? AND namespace = ?", 1, ualert.StoreSecretsKVNamespace).Cols("value").Find(&values))
+		return values
+	}
+
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	var integration *ualert.PostableGrafanaReceiver
+	for _, r := range amConfig.AlertmanagerConfig.Receivers {
+		if r.Name == "notifier1" {
+			integration = r.GrafanaManagedReceivers[0]
+		}
+	}
+	require.NotNil(t, integration)
+	encrypted, err := base64.StdEncoding.DecodeString(integration.SecureSettings["token"])
+	require.NoError(t, err)
+	_, _, _, ok := ualert.ParseSecretsKVReference(encrypted)
+	require.False(t, ok)
+	decrypted, err := util.Decrypt(encrypted, setting.SecretKey)
+	require.NoError(t, err)
+	require.Equal(t, "test", string(decrypted))
+
+	requests := getRequests()
+	require.Len(t, requests, 1)
+	var request ualert.StoreSecretsRequest
+	require.NoError(t, json.Unmarshal([]byte(requests[0]), &request))
+	require.Equal(t, ualert.StoreSecretsRequest{UIDs: []string{integration.UID}, KeepCopy: false}, request)
+
+	// The request of the previous migration is deleted when it is rolled back.
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	require.Len(t, getRequests(), 1)
+
+	t.Run("references are parsed", func(t *testing.T) {
+		orgID, uid, key, ok := ualert.ParseSecretsKVReference(ualert.SecretsKVReference(2, "abc", "url"))
+		require.True(t, ok)
+		require.Equal(t, int64(2), orgID)
+		require.Equal(t, "abc", uid)
+		require.Equal(t, "url", key)
+
+		for _, invalid := range []string{"$secretskv:2:abc", "$secretskv:x:abc:url", "$secretskv:2::url", "$secretskv:2:abc:", "encrypted"} {
+			_, _, _, ok := ualert.ParseSecretsKVReference([]byte(invalid))
+			require.False(t, ok, invalid)
+		}
+	})
+}
+
+// TestDashAlertMigrationAlertBatches tests that the legacy alerts are migrated when they are loaded in several batches.
+func TestDashAlertMigrationAlertBatches(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+	ualert.SetDashAlertBatchSize(t, 2)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(2), "notifier2", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(1), "alert2", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(1), int64(2), "alert3", nil),
+		createAlert(t, int64(1), int64(2), int64(2), "alert4", nil),
+		createAlert(t, int64(1), int64(1), int64(3), "alert5", []string{"notifier1"}),
+		createAlert(t, int64(2), int64(3), int64(1), "alert6", []string{"notifier2"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	runDashAlertMigrationTestRun(t, x)
+
+	contacts := make(map[string]string)
+	for _, orgID := range []int64{1, 2} {
+		for _, r := range getAlertRules(t, x, orgID) {
+			contacts[r.Title] = r.Labels[ualert.ContactLabel]
+		}
+		require.NotNil(t, getAlertmanagerConfig(t, x, orgID))
+	}
+	require.Equal(t, map[string]string{
+		"alert1": `"notifier1"`,
+		"alert2": `"notifier1"`,
+		"alert3": "",
+		"alert4": "",
+		"alert5": `"notifier1"`,
+		"alert6": `"notifier2"`,
+	}, contacts)
+}
+
+// TestDashAlertMigrationRuleBatches tests that the alert rules are inserted in batches with their versions.
+func TestDashAlertMigrationRuleBatches(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+		createAlert(t, int64(1), int64(1), int64(2), "alert2", nil),
+		createAlert(t, int64(1), int64(2), int64(1), "alert3", nil),
+		createAlert(t, int64(2), int64(3), int64(1), "alert4", nil),
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{RuleInsertBatchSize: 2}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	require.Len(t, getAlertRules(t, x, 1), 3)
+	require.Len(t, getAlertRules(t, x, 2), 1)
+	versions, err := x.Table("alert_rule_version").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(4), versions)
+}
+
+// TestDashAlertMigrationShadowMode tests that all rules are created paused and the organization state is marked as
+// shadow when shadow_mode is enabled.
+func TestDashAlertMigrationShadowMode(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	paused := createAlert(t, int64(1), int64(1), int64(2), "alert2", nil)
+	paused.State = "paused"
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+		paused,
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{ShadowMode: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 2)
+	for _, rule := range rules {
+		require.True(t, rule.IsPaused, rule.Title)
+	}
+
+	var shadow bool
+	has, err := x.Table("alert_migration_org_state").Where("org_id = ?", 1).Cols("shadow").Get(&shadow)
+	require.NoError(t, err)
+	require.True(t, has)
+	require.True(t, shadow)
+}
+
+// TestDashAlertMigrationPauseMigratedRules tests that all rules are created paused when pause_migrated_rules is enabled,
+// and that only the rules of legacy alerts that were not paused are labeled as paused by the migration.
+func TestDashAlertMigrationPauseMigratedRules(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	paused := createAlert(t, int64(1), int64(1), int64(2), "alert2", nil)
+	paused.State = "paused"
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+		paused,
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{PauseMigratedRules: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 2)
+	for _, rule := range rules {
+		require.True(t, rule.IsPaused, rule.Title)
+		if rule.Title == "alert1" {
+			require.Equal(t, "true", rule.Labels[ualert.PausedByMigrationLabel])
+		} else {
+			require.NotContains(t, rule.Labels, ualert.PausedByMigrationLabel)
+		}
+	}
+}
+
+// TestDashAlertMigrationNestedNotificationPolicies tests that the routes of the contact points are created under a
+// route per folder when nested_notification_policies is enabled.
+func TestDashAlertMigrationNestedNotificationPolicies(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(1), "notifier2", "slack", slackSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(2), "alert2", nil),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{NestedNotificationPolicies: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	expected := &ualert.Route{
+		Receiver:   "autogen-contact-point-default",
+		GroupByStr: []string{ngModels.FolderTitleLabel, model.AlertNameLabel},
+		Routes: []*ualert.Route{
+			{
+				ObjectMatchers: ualert.ObjectMatchers{{Type: labels.MatchEqual, Name: ngModels.FolderTitleLabel, Value: ualert.GENERAL_FOLDER}},
+				Routes: []*ualert.Route{
+					{Receiver: "notifier1", ObjectMatchers: ualert.ObjectMatchers{{Type: labels.MatchRegexp, Name: ualert.ContactLabel, Value: `.*"notifier1".*`}}, Continue: true, RepeatInterval: durationPointer(ualert.DisabledRepeatInterval)},
+				},
+			},
+		},
+	}
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	cOpt := cmpopts.IgnoreUnexported(ualert.Route{}, labels.Matcher{})
+	if !cmp.Equal(expected, amConfig.AlertmanagerConfig.Route, cOpt) {
+		t.Errorf("Unexpected Route: %v", cmp.Diff(expected, amConfig.AlertmanagerConfig.Route, cOpt))
+	}
+}
+
+// TestDashAlertMigrationContactPointCombinations tests that a contact point and a route matching the contact label
+// exactly are created per distinct set of notification channels when contact_point_strategy is combination.
+func TestDashAlertMigrationContactPointCombinations(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(1), "notifier2", "slack", slackSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1", "notifier2"}),
+		createAlert(t, int64(1), int64(1), int64(2), "alert2", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{ContactPointStrategy: setting.ContactPointStrategyCombination}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	expectedRoute := &ualert.Route{
+		Receiver:   "autogen-contact-point-default",
+		GroupByStr: []string{ngModels.FolderTitleLabel, model.AlertNameLabel},
+		Routes: []*ualert.Route{
+			{Receiver: "notifier1", ObjectMatchers: ualert.ObjectMatchers{{Type: labels.MatchEqual, Name: ualert.ContactLabel, Value: `"notifier1"`}}, RepeatInterval: durationPointer(ualert.DisabledRepeatInterval)},
+			{Receiver: "notifier1, notifier2", ObjectMatchers: ualert.ObjectMatchers{{Type: labels.MatchEqual, Name: ualert.ContactLabel, Value: `"notifier1","notifier2"`}}, RepeatInterval: durationPointer(ualert.DisabledRepeatInterval)},
+		},
+	}
+	expectedReceivers := []*ualert.PostableApiReceiver{
+		{Name: "notifier1", GrafanaManagedReceivers: []*ualert.PostableGrafanaReceiver{{Name: "notifier1", Type: "email"}}},
+		{Name: "notifier2", GrafanaManagedReceivers: []*ualert.PostableGrafanaReceiver{{Name: "notifier2", Type: "slack"}}},
+		{Name: "notifier1, notifier2", GrafanaManagedReceivers: []*ualert.PostableGrafanaReceiver{{Name: "notifier1", Type: "email"}, {Name: "notifier2", Type: "slack"}}},
+		{Name: "autogen-contact-point-default"},
+	}
+
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	cOpt := []cmp.Option{
+		cmpopts.IgnoreUnexported(ualert.PostableApiReceiver{}, ualert.Route{}, labels.Matcher{}),
+		cmpopts.IgnoreFields(ualert.PostableGrafanaReceiver{}, "UID", "Settings", "SecureSettings"),
+		cmpopts.SortSlices(func(a, b *ualert.PostableApiReceiver) bool { return a.Name < b.Name }),
+	}
+	if !cmp.Equal(expectedReceivers, amConfig.AlertmanagerConfig.Receivers, cOpt...) {
+		t.Errorf("Unexpected Receivers: %v", cmp.Diff(expectedReceivers, amConfig.AlertmanagerConfig.Receivers, cOpt...))
+	}
+	if !cmp.Equal(expectedRoute, amConfig.AlertmanagerConfig.Route, cOpt...) {
+		t.Errorf("Unexpected Route: %v", cmp.Diff(expectedRoute, amConfig.AlertmanagerConfig.Route, cOpt...))
+	}
+}
+
+// TestDashAlertMigrationOrphanedAlerts tests that the alerts of dashboards whose folder does not exist are migrated as
+// configured by orphaned_alerts, and that the choice is recorded in the migration status.
+func TestDashAlertMigrationOrphanedAlerts(t *testing.T) {
+	x := setupTestDB(t)
+
+	tc := []struct {
+		name           string
+		orphanedAlerts string
+		expectedFolder string
+		expectedError  string
+	}{
+		{
+			name:           "general migrates the alert to the general folder",
+			orphanedAlerts: setting.OrphanedAlertsGeneral,
+			expectedFolder: ualert.GENERAL_FOLDER,
+			expectedError:  `alert migrated to folder \"General Alerting\"`,
+		},
+		{
+			name:           "folder migrates the alert to the folder for orphaned alerts",
+			orphanedAlerts: setting.OrphanedAlertsFolder,
+			expectedFolder: "Orphans",
+			expectedError:  `alert migrated to folder \"Orphans\"`,
+		},
+		{
+			name:           "skip does not migrate the alert",
+			orphanedAlerts: setting.OrphanedAlertsSkip,
+			expectedError:  "alert not migrated",
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			defer teardown(t, x)
+			setupLegacyAlertsTables(t, x, nil, []*models.Alert{createAlert(t, int64(1), int64(1), int64(1), "alert1", nil)})
+			_, err := x.Exec("UPDATE dashboard SET folder_id = ? WHERE id = ?", 999, 1)
+			require.NoError(t, err)
+
+			cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+				OrphanedAlerts:            tt.orphanedAlerts,
+				OrphanedAlertsFolderTitle: "Orphans",
+			}}}
+			runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+			rules := getAlertRules(t, x, 1)
+			if tt.expectedFolder == "" {
+				require.Empty(t, rules)
+			} else {
+				require.Len(t, rules, 1)
+				folder := dashboards.Dashboard{}
+				_, err := x.Table(&dashboards.Dashboard{}).Where("uid = ? AND org_id = ?", rules[0].NamespaceUID, 1).Get(&folder)
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedFolder, folder.Title)
+				require.True(t, folder.IsFolder)
+			}
+
+			var errors string
+			_, err = x.Table("alert_migration_org_state").Where("org_id = ?", 1).Cols("errors").Get(&errors)
+			require.NoError(t, err)
+			require.Contains(t, errors, "folder 999 of dashboard dash1-1")
+			require.Contains(t, errors, tt.expectedError)
+		})
+	}
+
+	t.Run("fail fails the migration", func(t *testing.T) {
+		defer teardown(t, x)
+		setupLegacyAlertsTables(t, x, nil, []*models.Alert{createAlert(t, int64(1), int64(1), int64(1), "alert1", nil)})
+		_, err := x.Exec("UPDATE dashboard SET folder_id = ? WHERE id = ?", 999, 1)
+		require.NoError(t, err)
+		_, err = x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.MigTitle)
+		require.NoError(t, err)
+
+		cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{OrphanedAlerts: setting.OrphanedAlertsFail}}}
+		alertMigrator := migrator.NewMigrator(x, cfg)
+		alertMigrator.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
+		ualert.AddDashAlertMigration(alertMigrator)
+		require.ErrorContains(t, alertMigrator.Start(false, 0), "folder 999 of dashboard dash1-1")
+	})
+}
+
+// TestDashAlertMigrationSkipFailingAlerts tests that the alerts that fail to migrate are skipped and their errors
+// recorded, unless more alerts fail than allowed.
+func TestDashAlertMigrationSkipFailingAlerts(t *testing.T) {
+	x := setupTestDB(t)
+
+	// The alerts of the orphaned dashboards fail to migrate.
+	setup := func(t *testing.T) {
+		alerts := []*models.Alert{
+			createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+			createAlert(t, int64(1), int64(1), int64(2), "alert2", nil),
+			createAlert(t, int64(1), int64(2), int64(1), "alert3", nil),
+		}
+		setupLegacyAlertsTables(t, x, nil, alerts)
+		_, err := x.Exec("UPDATE dashboard SET folder_id = ? WHERE id = ?", 999, 1)
+		require.NoError(t, err)
+	}
+
+	t.Run("skips the failing alerts", func(t *testing.T) {
+		defer teardown(t, x)
+		setup(t)
+
+		cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+			OrphanedAlerts:    setting.OrphanedAlertsFail,
+			SkipFailingAlerts: true,
+			MaxFailingAlerts:  2,
+		}}}
+		runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+		rules := getAlertRules(t, x, 1)
+		require.Len(t, rules, 1)
+		require.Equal(t, "alert3", rules[0].Title)
+
+		var errors string
+		_, err := x.Table("alert_migration_org_state").Where("org_id = ?", 1).Cols("errors").Get(&errors)
+		require.NoError(t, err)
+		require.Contains(t, errors, `alert \"alert1\"`)
+		require.Contains(t, errors, "of dashboard dash1-1 panel 1 not migrated")
+		require.Contains(t, errors, `alert \"alert2\"`)
+		require.Contains(t, errors, "of dashboard dash1-1 panel 2 not migrated")
+	})
+
+	t.Run("fails the migration if too many alerts fail", func(t *testing.T) {
 		defer teardown(t, x)
+		setup(t)
+		_, err := x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.MigTitle)
+		require.NoError(t, err)
+
+		cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+			OrphanedAlerts:    setting.OrphanedAlertsFail,
+			SkipFailingAlerts: true,
+			MaxFailingAlerts:  1,
+		}}}
+		alertMigrator := migrator.NewMigrator(x, cfg)
+		alertMigrator.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
+		ualert.AddDashAlertMigration(alertMigrator)
+		require.ErrorContains(t, alertMigrator.Start(false, 0), "more than 1 alerts of organisation 1 failed to migrate")
+	})
+}
+
+// TestDashAlertMigrationConflicts tests that the alert rules and the Alertmanager configuration that an organization
+// already has are handled according to the conflict policy.
+func TestDashAlertMigrationConflicts(t *testing.T) {
+	x := setupTestDB(t)
+
+	// The resources of a first migration are made to look like they were created by a user.
+	setup := func(t *testing.T) {
 		legacyChannels := []*models.AlertNotification{
-			createAlertNotification(t, int64(1), "notif\"ier1", "email", emailSettings, false),
+			createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
 		}
 		alerts := []*models.Alert{
-			createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notif\"ier1"}),
-		}
-		expected := map[int64]map[string]*ngModels.AlertRule{
-			int64(1): {
-				"alert1": {Labels: map[string]string{ualert.ContactLabel: `"notif_ier1"`}},
-			},
+			createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
 		}
 		setupLegacyAlertsTables(t, x, legacyChannels, alerts)
 		runDashAlertMigrationTestRun(t, x)
 
-		for orgId := range expected {
-			rules := getAlertRules(t, x, orgId)
-			expectedRulesMap := expected[orgId]
-			require.Len(t, rules, len(expectedRulesMap))
-			for _, r := range rules {
-				require.Equal(t, expectedRulesMap[r.Title].Labels[ualert.ContactLabel], r.Labels[ualert.ContactLabel])
-			}
+		_, err := x.Exec("DELETE FROM alert_migration_audit")
+		require.NoError(t, err)
+		amConfig := getAlertmanagerConfig(t, x, 1)
+		amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, &ualert.PostableApiReceiver{Name: "user-receiver"})
+		raw, err := json.Marshal(amConfig)
+		require.NoError(t, err)
+		_, err = x.Exec("UPDATE alert_configuration SET alertmanager_configuration = ? WHERE org_id = ?", string(raw), 1)
+		require.NoError(t, err)
+	}
+	run := func(t *testing.T, policy string) error {
+		_, err := x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.MigTitle)
+		require.NoError(t, err)
+		cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+			ConflictPolicy: policy,
+		}}}
+		alertMigrator := migrator.NewMigrator(x, cfg)
+		ualert.AddDashAlertMigration(alertMigrator)
+		return alertMigrator.Start(false, 0)
+	}
+	orgState := func(t *testing.T) (bool, string) {
+		var state struct {
+			Migrated bool   `xorm:"migrated"`
+			Errors   string `xorm:"errors"`
 		}
+		_, err := x.Table("alert_migration_org_state").Where("org_id = ?", 1).Get(&state)
+		require.NoError(t, err)
+		return state.Migrated, state.Errors
+	}
+
+	t.Run("merge keeps the existing alert rules and contact points", func(t *testing.T) {
+		defer teardown(t, x)
+		setup(t)
+		require.NoError(t, run(t, setting.ConflictPolicyMerge))
+
+		titles := make([]string, 0)
+		for _, r := range getAlertRules(t, x, 1) {
+			titles = append(titles, r.Title)
+		}
+		require.ElementsMatch(t, []string{"alert1", "alert1 (migrated)"}, titles)
+
+		receivers := make([]string, 0)
+		for _, r := range getAlertmanagerConfig(t, x, 1).AlertmanagerConfig.Receivers {
+			receivers = append(receivers, r.Name)
+		}
+		require.ElementsMatch(t, []string{"autogen-contact-point-default", "notifier1", "user-receiver"}, receivers)
+
+		migrated, errors := orgState(t)
+		require.True(t, migrated)
+		require.Contains(t, errors, "Alertmanager configuration already exists, merged")
+		require.Contains(t, errors, `contact point \"notifier1\" already exists`)
 	})
 
-	t.Run("when folder is missing put alert in General folder", func(t *testing.T) {
-		o := createOrg(t, 1)
-		folder1 := createDashboard(t, 1, o.ID, "folder-1")
-		folder1.IsFolder = true
-		dash1 := createDashboard(t, 3, o.ID, "dash1")
-		dash1.FolderID = folder1.ID
-		dash2 := createDashboard(t, 4, o.ID, "dash2")
-		dash2.FolderID = 22 // missing folder
+	t.Run("overwrite replaces the existing alert rules and contact points", func(t *testing.T) {
+		defer teardown(t, x)
+		setup(t)
+		require.NoError(t, run(t, setting.ConflictPolicyOverwrite))
 
-		a1 := createAlert(t, o.ID, dash1.ID, int64(1), "alert-1", []string{})
-		a2 := createAlert(t, o.ID, dash2.ID, int64(1), "alert-2", []string{})
+		titles := make([]string, 0)
+		for _, r := range getAlertRules(t, x, 1) {
+			titles = append(titles, r.Title)
+		}
+		require.ElementsMatch(t, []string{"alert1"}, titles)
 
-		_, err := x.Insert(o, folder1, dash1, dash2, a1, a2)
+		receivers := make([]string, 0)
+		for _, r := range getAlertmanagerConfig(t, x, 1).AlertmanagerConfig.Receivers {
+			receivers = append(receivers, r.Name)
+		}
+		require.NotContains(t, receivers, "user-receiver")
+
+		migrated, errors := orgState(t)
+		require.True(t, migrated)
+		require.Contains(t, errors, `alert rule \"alert1\"`)
+		require.Contains(t, errors, "Alertmanager configuration already exists, overwritten")
+	})
+
+	t.Run("skip does not migrate the organization", func(t *testing.T) {
+		defer teardown(t, x)
+		setup(t)
+		require.NoError(t, run(t, setting.ConflictPolicySkip))
+
+		require.Len(t, getAlertRules(t, x, 1), 1)
+		migrated, errors := orgState(t)
+		require.False(t, migrated)
+		require.Contains(t, errors, "organization not migrated")
+	})
+
+	t.Run("abort fails the migration", func(t *testing.T) {
+		defer teardown(t, x)
+		setup(t)
+		require.ErrorContains(t, run(t, setting.ConflictPolicyAbort), "organisation 1 already has unified alerting resources")
+	})
+}
+
+// TestDashAlertMigrationReserveReceiverUIDs tests that the UID generated for a contact point is not one used by a
+// contact point of an existing Alertmanager configuration.
+func TestDashAlertMigrationReserveReceiverUIDs(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	channel := createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false)
+	channel.UID = ""
+	setupLegacyAlertsTables(t, x, []*models.AlertNotification{channel}, nil)
+
+	integrationUID := func(t *testing.T, reserve bool) string {
+		runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+			DeterministicUIDs:     true,
+			ReserveGeneratedUIDs:  reserve,
+			UIDGenerationAttempts: 5,
+		}}})
+		for _, r := range getAlertmanagerConfig(t, x, 1).AlertmanagerConfig.Receivers {
+			if r.Name == "notifier1" {
+				require.Len(t, r.GrafanaManagedReceivers, 1)
+				return r.GrafanaManagedReceivers[0].UID
+			}
+		}
+		require.Fail(t, "contact point notifier1 not migrated")
+		return ""
+	}
+
+	// The first migration is kept as the existing configuration, whose contact point has the deterministic UID.
+	existing := integrationUID(t, false)
+	require.NotEqual(t, existing, integrationUID(t, true))
+}
+
+// TestDashAlertMigrationStamp tests that the alert rules, folders and audit entries created by the migration are
+// stamped with the same run of the migration.
+func TestDashAlertMigrationStamp(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+
+	runDashAlertMigrationTestRun(t, x)
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 1)
+	runID := rules[0].Annotations[ualert.MigrationRunIDAnnotation]
+	require.NotEmpty(t, runID)
+	require.Contains(t, rules[0].Annotations, ualert.MigrationVersionAnnotation)
+	_, err := time.Parse(time.RFC3339, rules[0].Annotations[ualert.MigrationTimeAnnotation])
+	require.NoError(t, err)
+
+	var data string
+	has, err := x.Table("dashboard").Where("org_id = ? AND is_folder = ?", 1, true).Cols("data").Get(&data)
+	require.NoError(t, err)
+	require.True(t, has)
+	model, err := simplejson.NewJson([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, runID, model.GetPath("migration", "runId").MustString())
+
+	var runIDs []string
+	require.NoError(t, x.Table("alert_migration_audit").Distinct("run_id").Find(&runIDs))
+	require.Equal(t, []string{runID}, runIDs)
+}
+
+// TestDashAlertMigrationSilencesInDatabase tests that the silences created by the migration are stored in the kv_store
+// table when store_silences_in_database is enabled.
+func TestDashAlertMigrationSilencesInDatabase(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	a := createAlert(t, int64(1), int64(1), int64(1), "alert1", nil)
+	a.Settings.Set("executionErrorState", "keep_state")
+	setupLegacyAlertsTables(t, x, nil, []*models.Alert{a})
+
+	dataPath := t.TempDir()
+	cfg := &setting.Cfg{
+		DataPath:        dataPath,
+		UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{StoreSilencesInDatabase: true}},
+	}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	var value string
+	has, err := x.Table("kv_store").Where("org_id = ? AND namespace = ?", 1, ualert.KV_NAMESPACE).Cols("value").Get(&value)
+	require.NoError(t, err)
+	require.True(t, has)
+	require.NotEmpty(t, value)
+
+	require.NoFileExists(t, filepath.Join(dataPath, "alerting", "1", "silences"))
+}
+
+// TestDashAlertMigrationSecretsRotation tests that the migration requests the rotation of the secrets data key only
+// when it is enabled.
+func TestDashAlertMigrationSecretsRotation(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, nil)
+
+	requested := func() int64 {
+		count, err := x.Table("kv_store").Where("org_id = ? AND namespace = ?", 0, ualert.RotateSecretsKVNamespace).Count()
 		require.NoError(t, err)
+		return count
+	}
 
-		runDashAlertMigrationTestRun(t, x)
+	runDashAlertMigrationTestRun(t, x)
+	require.Zero(t, requested())
 
-		rules := getAlertRules(t, x, o.ID)
-		require.Len(t, rules, 2)
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{RotateSecretsDataKey: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	require.Equal(t, int64(1), requested())
 
-		var generalFolder dashboards.Dashboard
-		_, err = x.Table(&dashboards.Dashboard{}).Where("title = ? AND org_id = ?", ualert.GENERAL_FOLDER, o.ID).Get(&generalFolder)
+	// Running the migration again does not duplicate the request.
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	require.Equal(t, int64(1), requested())
+}
+
+// TestDashAlertMigrationContactPointTestsRequest tests that the migration requests to test the migrated contact points
+// of an organization with a kv_store entry of their own namespace.
+func TestDashAlertMigrationContactPointTestsRequest(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, nil)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{TestContactPoints: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	has, err := x.Table("kv_store").Where("org_id = ? AND namespace = ? AND "+x.Dialect().Quote("key")+" = ?", 1, ualert.TestContactPointsKVNamespace, ualert.TestContactPointsKVKey).Exist()
+	require.NoError(t, err)
+	require.True(t, has)
+
+	count, err := x.Table("kv_store").Where("namespace = ?", ualert.RotateSecretsKVNamespace).Count()
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+// TestDashAlertMigrationReport tests that the migration writes the report of every migrated organization to the
+// kv_store table when it is sent.
+func TestDashAlertMigrationReport(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	// The alerts of the orphaned dashboard fail to migrate.
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(1), int64(2), "alert2", nil),
+		createAlert(t, int64(1), int64(2), int64(1), "alert3", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+	_, err := x.Exec("UPDATE dashboard SET folder_id = ? WHERE id = ?", 999, 1)
+	require.NoError(t, err)
+
+	getReport := func() (ualert.MigrationReport, bool) {
+		var value string
+		has, err := x.Table("kv_store").Where("org_id = ? AND namespace = ? AND "+x.Dialect().Quote("key")+" = ?", 1, ualert.MigrationReportKVNamespace, ualert.MigrationReportKVKey).Cols("value").Get(&value)
 		require.NoError(t, err)
+		var report ualert.MigrationReport
+		if has {
+			require.NoError(t, json.Unmarshal([]byte(value), &report))
+		}
+		return report, has
+	}
 
-		require.NotNil(t, generalFolder)
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{
+		OrphanedAlerts:    setting.OrphanedAlertsFail,
+		SkipFailingAlerts: true,
+	}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	_, has := getReport()
+	require.False(t, has)
+
+	cfg.UnifiedAlerting.Upgrade.ReportWebhookURL = "http://localhost/report"
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	report, has := getReport()
+	require.True(t, has)
+	require.Equal(t, int64(1), report.OrgID)
+	require.Equal(t, 1, report.AlertRules)
+	require.Equal(t, 2, report.ContactPoints)
+	require.Equal(t, 2, report.SkippedAlerts)
+	require.Len(t, report.Warnings, 2)
+}
 
-		for _, rule := range rules {
-			var expectedFolder dashboards.Dashboard
-			if rule.Title == a1.Name {
-				expectedFolder = *folder1
-			} else {
-				expectedFolder = generalFolder
+// TestDashAlertMigrationEvents tests that the migration and the revert of an organization record their lifecycle events,
+// and their failures, to the kv_store table, appended to the events that were not published yet.
+func TestDashAlertMigrationEvents(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	getEvents := func(orgID int64) []ualert.MigrationEvent {
+		var value string
+		_, err := x.Table("kv_store").Where("org_id = ? AND namespace = ? AND "+x.Dialect().Quote("key")+" = ?", orgID, ualert.MigrationEventsKVNamespace, ualert.MigrationEventsKVKey).Cols("value").Get(&value)
+		require.NoError(t, err)
+		var events []ualert.MigrationEvent
+		if value != "" {
+			require.NoError(t, json.Unmarshal([]byte(value), &events))
+		}
+		return events
+	}
+	eventTypes := func(events []ualert.MigrationEvent) []string {
+		types := make([]string, 0, len(events))
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		return types
+	}
+
+	runDashAlertMigrationTestRun(t, x)
+	require.Equal(t, []string{ualert.MigrationEventStarted}, eventTypes(getEvents(0)))
+	events := getEvents(1)
+	require.Equal(t, []string{ualert.MigrationEventOrgMigrated}, eventTypes(events))
+	require.Equal(t, 1, events[0].AlertRules)
+	require.Equal(t, 2, events[0].ContactPoints)
+
+	mg := migrator.NewMigrator(x, &setting.Cfg{})
+	ualert.AddOrgMigration(mg, 1, true)
+	require.NoError(t, mg.Start(false, 0))
+	require.Equal(t, []string{ualert.MigrationEventOrgMigrated, ualert.MigrationEventOrgReverted}, eventTypes(getEvents(1)))
+
+	// The removal of the data that precedes a migration is not recorded as a revert.
+	mg = migrator.NewMigrator(x, &setting.Cfg{})
+	ualert.AddOrgMigration(mg, 1, false)
+	require.NoError(t, mg.Start(false, 0))
+	require.Equal(t, []string{ualert.MigrationEventOrgMigrated, ualert.MigrationEventOrgReverted, ualert.MigrationEventStarted, ualert.MigrationEventOrgMigrated}, eventTypes(getEvents(1)))
+
+	// The failure of a migration is recorded although its transaction is rolled back.
+	mg = migrator.NewMigrator(x, &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+		Upgrade: setting.UnifiedAlertingUpgradeSettings{FolderNameTemplate: "{{"},
+	}})
+	ualert.AddOrgMigration(mg, 1, false)
+	require.Error(t, mg.Start(false, 0))
+	events = getEvents(1)
+	require.Equal(t, []string{ualert.MigrationEventOrgMigrated, ualert.MigrationEventOrgReverted, ualert.MigrationEventStarted, ualert.MigrationEventOrgMigrated, ualert.MigrationEventFailed}, eventTypes(events))
+	require.Contains(t, events[len(events)-1].Error, "failed to parse folder name template")
+}
+
+// TestDashAlertMigrationRewriteDashboardPanels tests that the migrated alert rules are linked to their panel and that
+// the legacy alert blocks are removed from the panels, and restored when rolling back.
+func TestDashAlertMigrationRewriteDashboardPanels(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+		createAlert(t, int64(1), int64(1), int64(2), "alert2", nil),
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+	data := `{"title":"dash1-1","panels":[{"id":1,"alert":{"name":"alert1"}},{"id":10,"type":"row","panels":[{"id":2,"alert":{"name":"alert2"}}]},{"id":3}]}`
+	_, err := x.Exec("UPDATE dashboard SET data = ? WHERE id = ?", data, 1)
+	require.NoError(t, err)
+
+	panelAlerts := func() []int64 {
+		var data string
+		_, err := x.Table("dashboard").Where("id = ?", 1).Cols("data").Get(&data)
+		require.NoError(t, err)
+		dash, err := simplejson.NewJson([]byte(data))
+		require.NoError(t, err)
+		var panelIDs []int64
+		for _, p := range []*simplejson.Json{dash.Get("panels").GetIndex(0), dash.Get("panels").GetIndex(1).Get("panels").GetIndex(0)} {
+			if _, ok := p.CheckGet("alert"); ok {
+				panelIDs = append(panelIDs, p.Get("id").MustInt64())
 			}
-			require.Equal(t, expectedFolder.UID, rule.NamespaceUID)
 		}
-	})
+		return panelIDs
+	}
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{RewriteDashboardPanels: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	require.Empty(t, panelAlerts())
+
+	type rulePanel struct {
+		DashboardUID string `xorm:"dashboard_uid"`
+		PanelID      int64  `xorm:"panel_id"`
+	}
+	var panels []rulePanel
+	require.NoError(t, x.Table("alert_rule").Where("org_id = ?", 1).Cols("dashboard_uid", "panel_id").Asc("panel_id").Find(&panels))
+	require.Equal(t, []rulePanel{{DashboardUID: "dash1-1", PanelID: 1}, {DashboardUID: "dash1-1", PanelID: 2}}, panels)
+
+	versions, err := x.Table("dashboard_version").Where("dashboard_id = ? AND message = ?", 1, "Legacy alerts migrated to Grafana Alerting").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), versions)
+
+	// Rolling back restores the legacy alert blocks.
+	runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{})
+	require.Equal(t, []int64{1, 2}, panelAlerts())
+	before, err := x.Table("dashboard_version").Where("dashboard_id = ?", 1).Count()
+	require.NoError(t, err)
+
+	// Migrating again removes the blocks in the version that restored them.
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	require.Empty(t, panelAlerts())
+	after, err := x.Table("dashboard_version").Where("dashboard_id = ?", 1).Count()
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	// The dashboards that the migration did not change are not changed when rolling back.
+	_, err = x.Exec("DELETE FROM dashboard_version WHERE dashboard_id = ?", 1)
+	require.NoError(t, err)
+	runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{})
+	require.Empty(t, panelAlerts())
+}
+
+// TestDashAlertMigrationSkipOrgIDs tests that the organizations of skip_org_ids are not migrated and are recorded as
+// not migrated, unless they are migrated on their own.
+func TestDashAlertMigrationSkipOrgIDs(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", nil),
+		createAlert(t, int64(2), int64(3), int64(1), "alert2", nil),
+	}
+	setupLegacyAlertsTables(t, x, nil, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{SkipOrgIDs: []int64{2}}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+
+	require.Len(t, getAlertRules(t, x, 1), 1)
+	require.Empty(t, getAlertRules(t, x, 2))
+
+	type orgState struct {
+		Migrated bool   `xorm:"migrated"`
+		Errors   string `xorm:"errors"`
+	}
+	var state orgState
+	_, err := x.Table("alert_migration_org_state").Where("org_id = ?", 1).Cols("migrated", "errors").Get(&state)
+	require.NoError(t, err)
+	require.True(t, state.Migrated)
+	state = orgState{}
+	_, err = x.Table("alert_migration_org_state").Where("org_id = ?", 2).Cols("migrated", "errors").Get(&state)
+	require.NoError(t, err)
+	require.False(t, state.Migrated)
+	require.Contains(t, state.Errors, "organization skipped by the skip_org_ids setting")
+
+	// The organization can be migrated on its own.
+	orgMigrator := migrator.NewMigrator(x, cfg)
+	ualert.AddOrgMigration(orgMigrator, 2, false)
+	require.NoError(t, orgMigrator.Start(false, 0))
+	require.Len(t, getAlertRules(t, x, 2), 1)
+}
+
+// TestDashAlertMigrationIncrementalSync tests that the incremental migration migrates the legacy alerts and
+// notification channels added since the migration, and leaves the migrated ones as they are.
+func TestDashAlertMigrationIncrementalSync(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{IncrementalSync: true}}}
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 1)
+	migrated := rules[0]
+
+	// A contact point created by a user with the name of a new channel is not merged with it.
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	amConfig.AlertmanagerConfig.Receivers = append(amConfig.AlertmanagerConfig.Receivers, &ualert.PostableApiReceiver{Name: "notifier2"})
+	raw, err := json.Marshal(amConfig)
+	require.NoError(t, err)
+	_, err = x.Exec("UPDATE alert_configuration SET alertmanager_configuration = ? WHERE org_id = ?", string(raw), 1)
+	require.NoError(t, err)
+
+	_, err = x.Insert(createAlertNotification(t, int64(1), "notifier2", "slack", slackSettings, false))
+	require.NoError(t, err)
+	_, err = x.Insert(createAlert(t, int64(1), int64(2), int64(1), "alert2", []string{"notifier2"}))
+	require.NoError(t, err)
+
+	sync := func() {
+		syncMigrator := migrator.NewMigrator(x, cfg)
+		ualert.AddDashAlertMigration(syncMigrator)
+		require.NoError(t, syncMigrator.Start(false, 0))
+	}
+	sync()
+
+	rules = getAlertRules(t, x, 1)
+	require.Len(t, rules, 2)
+	titles := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		titles = append(titles, rule.Title)
+		if rule.UID == migrated.UID {
+			require.Equal(t, migrated.Version, rule.Version)
+		}
+	}
+	require.ElementsMatch(t, []string{"alert1", "alert2"}, titles)
+
+	amConfig = getAlertmanagerConfig(t, x, 1)
+	receivers := make([]string, 0, len(amConfig.AlertmanagerConfig.Receivers))
+	for _, r := range amConfig.AlertmanagerConfig.Receivers {
+		receivers = append(receivers, r.Name)
+	}
+	require.Contains(t, receivers, "notifier1")
+	require.Contains(t, receivers, "notifier2")
+	var names []string
+	require.NoError(t, x.Table("alert_migration_mapping").Where("org_id = ? AND legacy_type = ?", 1, "channel").Cols("name").Find(&names))
+	require.Len(t, names, 2)
+	require.NotContains(t, names, "notifier2")
+	for _, name := range names {
+		require.Contains(t, receivers, name)
+	}
+
+	// Nothing is migrated again once the organization is synced.
+	sync()
+	require.Len(t, getAlertRules(t, x, 1), 2)
+	count, err := x.Table("alert_migration_mapping").Where("org_id = ? AND legacy_type = ?", 1, "channel").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
+
+// TestOrgMigration tests that the migration and its revert can run for a single organization.
+func TestOrgMigration(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(2), "notifier2", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(2), int64(3), int64(1), "alert2", []string{"notifier2"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	run := func(orgID int64, revertOnly bool) {
+		mg := migrator.NewMigrator(x, &setting.Cfg{})
+		ualert.AddOrgMigration(mg, orgID, revertOnly)
+		require.NoError(t, mg.Start(false, 0))
+	}
+
+	run(1, false)
+	require.Len(t, getAlertRules(t, x, 1), 1)
+	require.Empty(t, getAlertRules(t, x, 2))
+
+	// Migrating the organization again replaces its data.
+	run(1, false)
+	require.Len(t, getAlertRules(t, x, 1), 1)
+
+	run(2, false)
+	require.Len(t, getAlertRules(t, x, 2), 1)
+
+	run(1, true)
+	require.Empty(t, getAlertRules(t, x, 1))
+	require.Len(t, getAlertRules(t, x, 2), 1)
+	configs, err := x.Table("alert_configuration").Where("org_id = ?", 1).Count()
+	require.NoError(t, err)
+	require.Zero(t, configs)
+}
+
+// TestOrgPartialRevert tests that the partial revert keeps the edited alert rules and the contact points they are routed to.
+func TestOrgPartialRevert(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(1), "notifier2", "email", emailSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(1), "alert2", []string{"notifier2"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	mg := migrator.NewMigrator(x, &setting.Cfg{})
+	ualert.AddOrgMigration(mg, 1, false)
+	require.NoError(t, mg.Start(false, 0))
+	require.Len(t, getAlertRules(t, x, 1), 2)
+
+	_, err := x.Exec("UPDATE alert_rule SET updated = ? WHERE org_id = ? AND title = ?", time.Now().Add(time.Hour), 1, "alert1")
+	require.NoError(t, err)
+
+	mg = migrator.NewMigrator(x, &setting.Cfg{})
+	ualert.AddOrgPartialRevert(mg, 1)
+	require.NoError(t, mg.Start(false, 0))
+
+	rules := getAlertRules(t, x, 1)
+	require.Len(t, rules, 1)
+	require.Equal(t, "alert1", rules[0].Title)
+
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	receivers := make([]string, 0, len(amConfig.AlertmanagerConfig.Receivers))
+	for _, r := range amConfig.AlertmanagerConfig.Receivers {
+		receivers = append(receivers, r.Name)
+	}
+	require.Contains(t, receivers, "notifier1")
+	require.NotContains(t, receivers, "notifier2")
+	require.Contains(t, receivers, amConfig.AlertmanagerConfig.Route.Receiver)
+	for _, r := range amConfig.AlertmanagerConfig.Route.Routes {
+		require.NotEqual(t, "notifier2", r.Receiver)
+	}
+
+	deleted, err := x.Table("alert_migration_audit").Where("org_id = ? AND action = ?", 1, "delete").Cols("resource_uid").Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+}
+
+// TestValidateDashAlertMigration tests that the validation reports problems without writing unified alerting data.
+func TestValidateDashAlertMigration(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(1), "notifier2", "hipchat", "", false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	sess := x.NewSession()
+	defer sess.Close()
+	report, err := ualert.ValidateDashAlertMigration(sess, migrator.NewDialect(x.DriverName()), &setting.Cfg{})
+	require.NoError(t, err)
+
+	require.True(t, report.HasProblems())
+	require.Equal(t, 1, report.AlertCount)
+	require.Equal(t, 2, report.ChannelCount)
+	require.Empty(t, report.Alerts)
+	require.Len(t, report.Channels, 1)
+	require.Equal(t, "notifier2", report.Channels[0].UID)
+
+	require.Len(t, report.AlertSummaries, 1)
+	require.Equal(t, ualert.OutcomeMigrated, report.AlertSummaries[0].Outcome)
+	outcomes := make(map[string]string, len(report.ChannelSummaries))
+	for _, s := range report.ChannelSummaries {
+		outcomes[s.UID] = s.Outcome
+	}
+	require.Len(t, outcomes, 2)
+	require.NotEqual(t, ualert.OutcomeNotMigrated, outcomes["notifier1"])
+	require.Equal(t, ualert.OutcomeNotMigrated, outcomes["notifier2"])
+
+	require.Empty(t, getAlertRules(t, x, 1))
+}
+
+// TestPreviewOrgAlertmanagerConfig tests that the preview of the notification policies of an organization is the
+// Alertmanager configuration that its migration writes, and that the preview writes nothing.
+func TestPreviewOrgAlertmanagerConfig(t *testing.T) {
+	x := setupTestDB(t)
+	defer teardown(t, x)
+
+	legacyChannels := []*models.AlertNotification{
+		createAlertNotification(t, int64(1), "notifier1", "email", emailSettings, false),
+		createAlertNotification(t, int64(1), "notifier2", "slack", slackSettings, false),
+	}
+	alerts := []*models.Alert{
+		createAlert(t, int64(1), int64(1), int64(1), "alert1", []string{"notifier1"}),
+		createAlert(t, int64(1), int64(2), int64(2), "alert2", nil),
+	}
+	setupLegacyAlertsTables(t, x, legacyChannels, alerts)
+
+	cfg := &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Upgrade: setting.UnifiedAlertingUpgradeSettings{NestedNotificationPolicies: true}}}
+	preview := func() *ualert.PostableUserConfig {
+		sess := x.NewSession()
+		defer sess.Close()
+		config, err := ualert.PreviewOrgAlertmanagerConfig(sess, migrator.NewDialect(x.DriverName()), cfg, 1)
+		require.NoError(t, err)
+		return config
+	}
+
+	before := preview()
+	require.Empty(t, getAlertRules(t, x, 1))
+	count, err := x.Table("alert_configuration").Where("org_id = ?", 1).Count()
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	receivers := make([]string, 0, len(before.AlertmanagerConfig.Receivers))
+	for _, r := range before.AlertmanagerConfig.Receivers {
+		receivers = append(receivers, r.Name)
+	}
+	require.ElementsMatch(t, []string{"notifier1", "notifier2", "autogen-contact-point-default"}, receivers)
+
+	runDashAlertMigrationTestRunWithCfg(t, x, cfg)
+	amConfig := getAlertmanagerConfig(t, x, 1)
+	cOpt := cmpopts.IgnoreUnexported(ualert.Route{}, labels.Matcher{})
+	if !cmp.Equal(amConfig.AlertmanagerConfig.Route, before.AlertmanagerConfig.Route, cOpt) {
+		t.Errorf("Unexpected Route: %v", cmp.Diff(amConfig.AlertmanagerConfig.Route, before.AlertmanagerConfig.Route, cOpt))
+	}
+
+	// The preview of a migrated organization is the same.
+	after := preview()
+	if !cmp.Equal(before.AlertmanagerConfig.Route, after.AlertmanagerConfig.Route, cOpt) {
+		t.Errorf("Unexpected Route: %v", cmp.Diff(before.AlertmanagerConfig.Route, after.AlertmanagerConfig.Route, cOpt))
+	}
 }
 
 const (
@@ -762,10 +2735,15 @@ func teardown(t *testing.T, x *xorm.Engine) {
 
 // setupDashAlertMigrationTestRun runs DashAlertMigration for a new test run.
 func runDashAlertMigrationTestRun(t *testing.T, x *xorm.Engine) {
+	runDashAlertMigrationTestRunWithCfg(t, x, &setting.Cfg{})
+}
+
+// runDashAlertMigrationTestRunWithCfg runs DashAlertMigration for a new test run with the given configuration.
+func runDashAlertMigrationTestRunWithCfg(t *testing.T, x *xorm.Engine, cfg *setting.Cfg) {
 	_, errDeleteMig := x.Exec("DELETE FROM migration_log WHERE migration_id = ?", ualert.MigTitle)
 	require.NoError(t, errDeleteMig)
 
-	alertMigrator := migrator.NewMigrator(x, &setting.Cfg{})
+	alertMigrator := migrator.NewMigrator(x, cfg)
 	alertMigrator.AddMigration(ualert.RmMigTitle, &ualert.RmMigration{})
 	ualert.AddDashAlertMigration(alertMigrator)
 
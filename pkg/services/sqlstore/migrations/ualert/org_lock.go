@@ -0,0 +1,89 @@
+package ualert
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// orgLockRetryInterval is the time between two attempts to obtain the lock of an organization.
+var orgLockRetryInterval = time.Second
+
+// lockOrg obtains the database lock of the organization so that Grafana instances that start at the same time do not
+// migrate it concurrently. It waits up to the org_lock_timeout setting for another instance to release the lock, and
+// reports whether that instance migrated the organization in the meantime, in which case it must be skipped. The lock
+// is a no-op if it is disabled or not supported by the database.
+//
+// The lock is taken on a connection of its own and released once the transaction of the migration is committed or
+// rolled back, so that an instance waiting for it sees the organization as migrated.
+func (m *migration) lockOrg(orgID int64) (migrated bool, err error) {
+	if m.upgradeCfg.OrgLockTimeout <= 0 || m.mg.Dialect.DriverName() == migrator.SQLite {
+		return false, nil
+	}
+
+	dbName, err := m.mg.Dialect.GetDBName(m.mg.DBEngine.DataSourceName())
+	if err != nil {
+		return false, err
+	}
+	key, err := database.GenerateAdvisoryLockId(fmt.Sprintf("%s:alerting-migration:%d", dbName, orgID))
+	if err != nil {
+		return false, err
+	}
+
+	// the advisory locks belong to a connection, the transaction pins it until the lock is released
+	lockSess := m.mg.DBEngine.NewSession()
+	if err := lockSess.Begin(); err != nil {
+		lockSess.Close()
+		return false, err
+	}
+	lockCfg := migrator.LockCfg{
+		Session: lockSess,
+		Key:     key,
+		Timeout: 1,
+	}
+	release := func() {
+		if err := m.mg.Dialect.Unlock(lockCfg); err != nil {
+			m.mg.Logger.Error("Failed to unlock the migration of the organization", "orgID", orgID, "error", err)
+		}
+		_ = lockSess.Rollback()
+		lockSess.Close()
+	}
+
+	deadline := time.Now().Add(m.upgradeCfg.OrgLockTimeout)
+	waited := false
+	for {
+		err = m.mg.Dialect.Lock(lockCfg)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, migrator.ErrLockDB) || time.Now().After(deadline) {
+			_ = lockSess.Rollback()
+			lockSess.Close()
+			return false, fmt.Errorf("failed to lock the migration of organization %d: %w", orgID, err)
+		}
+		if !waited {
+			m.mg.Logger.Info("Waiting for another instance to finish the migration of the organization", "orgID", orgID)
+			waited = true
+		}
+		time.Sleep(orgLockRetryInterval)
+	}
+	m.mg.OnTransactionEnd(func(bool) { release() })
+
+	// another instance may have committed its migration of the organization before the lock was obtained, even
+	// without waiting for it
+	return m.migratedSince(orgID, m.started)
+}
+
+// migratedSince reports whether the organization was marked as migrated after the given time. It reads outside of the
+// transaction of the migration, whose snapshot may predate the commit of another instance.
+func (m *migration) migratedSince(orgID int64, since time.Time) (bool, error) {
+	exists, err := m.sess.IsTableExist("alert_migration_org_state")
+	if err != nil || !exists {
+		return false, err
+	}
+	return m.mg.DBEngine.Table("alert_migration_org_state").Where("org_id = ? AND migrated = ? AND updated >= ?", orgID, true, since).Exist()
+}
@@ -0,0 +1,47 @@
+package ualert
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const orgMigTitle = "move dashboard alerts of org %d to unified alerting"
+
+const orgRmMigTitle = "remove unified alerting data of org %d"
+
+// AddOrgMigration adds the migrations that remove the unified alerting data of a single organization and, unless
+// revertOnly is set, migrate its dashboard alerts again. They are meant to run at runtime through a migrator of their
+// own, so that they hold the same database lock as the migrations at startup. They are not recorded in the
+// migration_log and can run any number of times. Unless the conflict policy overwrites the existing data, only the
+// resources of the previous migration that were not edited since are removed before the organization is migrated again.
+func AddOrgMigration(mg *migrator.Migrator, orgID int64, revertOnly bool) {
+	if revertOnly {
+		mg.AddMigration(fmt.Sprintf(orgRmMigTitle, orgID), &rmMigration{orgID: orgID, deleteAll: true})
+		return
+	}
+	var policy string
+	if mg.Cfg != nil {
+		policy = mg.Cfg.UnifiedAlerting.Upgrade.ConflictPolicy
+	}
+	if policy != "" && policy != setting.ConflictPolicyOverwrite {
+		mg.AddMigration(fmt.Sprintf(orgPartialRmMigTitle, orgID), &partialRmMigration{orgID: orgID})
+	} else {
+		mg.AddMigration(fmt.Sprintf(orgRmMigTitle, orgID), &rmMigration{orgID: orgID})
+	}
+
+	m := newMigration(mg)
+	m.orgID = orgID
+	mg.AddMigration(fmt.Sprintf(orgMigTitle, orgID), &orgMigration{migration: m})
+}
+
+// orgMigration is the dashboard alert migration of a single organization. Unlike the migration of all organizations
+// it is not recorded in the migration_log.
+type orgMigration struct {
+	*migration
+}
+
+func (m *orgMigration) SkipMigrationLog() bool {
+	return true
+}
@@ -0,0 +1,163 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// alertMigrationOrgState is a row of the alert_migration_org_state table. It records whether the legacy alerts of an
+// organization are migrated, by which Grafana version, with which scope, and the problems that did not fail the migration.
+type alertMigrationOrgState struct {
+	ID             int64     `xorm:"pk autoincr 'id'"`
+	OrgID          int64     `xorm:"org_id"`
+	Migrated       bool      `xorm:"migrated"`
+	GrafanaVersion string    `xorm:"grafana_version"`
+	Errors         string    `xorm:"errors"`
+	Updated        time.Time `xorm:"updated"`
+	Shadow         bool      `xorm:"shadow"`
+	// Scope are the filters of the scopes the organization was migrated with, empty if all its alerts were migrated.
+	Scope string `xorm:"scope"`
+}
+
+// migrationOrgStates collects the problems per organization during the migration so they are written in the same transaction.
+type migrationOrgStates struct {
+	errors  map[int64][]string
+	skipped map[int64]struct{}
+	// notMigrated are the organizations whose state is written with their errors but not marked as migrated.
+	notMigrated map[int64]struct{}
+	// shadow marks the organizations as migrated in shadow mode, with all their alert rules paused.
+	shadow bool
+	// scope are the filters of the scope of the migration, see scopeFilters.
+	scope []string
+}
+
+// recordError records a problem that does not fail the migration of the organization. It is a no-op on a nil migrationOrgStates.
+func (s *migrationOrgStates) recordError(orgID int64, err error) {
+	if s == nil {
+		return
+	}
+	if s.errors == nil {
+		s.errors = make(map[int64][]string)
+	}
+	s.errors[orgID] = append(s.errors[orgID], err.Error())
+}
+
+// skip excludes the organization from write, because it was migrated by another instance. It is a no-op on a nil
+// migrationOrgStates.
+func (s *migrationOrgStates) skip(orgID int64) {
+	if s == nil {
+		return
+	}
+	if s.skipped == nil {
+		s.skipped = make(map[int64]struct{})
+	}
+	s.skipped[orgID] = struct{}{}
+}
+
+// markNotMigrated writes the state of the organization with its errors, without marking it as migrated. It is a no-op
+// on a nil migrationOrgStates.
+func (s *migrationOrgStates) markNotMigrated(orgID int64) {
+	if s == nil {
+		return
+	}
+	if s.notMigrated == nil {
+		s.notMigrated = make(map[int64]struct{})
+	}
+	s.notMigrated[orgID] = struct{}{}
+}
+
+// write marks the organization, or every organization if orgID is 0, as migrated, replacing the state of a previous migration.
+func (s *migrationOrgStates) write(sess *xorm.Session, orgID int64) error {
+	cond, args := orgCondition("id", orgID)
+	var orgIDs []int64
+	if err := sess.SQL("SELECT id FROM org WHERE "+cond, args...).Find(&orgIDs); err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, orgID := range orgIDs {
+		if _, ok := s.skipped[orgID]; ok {
+			continue
+		}
+		_, notMigrated := s.notMigrated[orgID]
+		scope, err := encodeMigratedScope(s.scope)
+		if err != nil {
+			return err
+		}
+		state := &alertMigrationOrgState{
+			OrgID:          orgID,
+			Migrated:       !notMigrated,
+			GrafanaVersion: setting.BuildVersion,
+			Updated:        now,
+			Shadow:         s.shadow && !notMigrated,
+			Scope:          scope,
+		}
+		if errs := s.errors[orgID]; len(errs) > 0 {
+			b, err := json.Marshal(errs)
+			if err != nil {
+				return err
+			}
+			state.Errors = string(b)
+		}
+
+		if _, err := sess.Exec("DELETE FROM alert_migration_org_state WHERE org_id = ?", orgID); err != nil {
+			return fmt.Errorf("failed to clear migration state of organization %d: %w", orgID, err)
+		}
+		if _, err := sess.Insert(state); err != nil {
+			return fmt.Errorf("failed to write migration state of organization %d: %w", orgID, err)
+		}
+	}
+	s.errors = nil
+	s.skipped = nil
+	s.notMigrated = nil
+	return nil
+}
+
+// appendOrgStateErrors adds the problems to the migration state of the organization once the migration is committed,
+// for the steps that run after its transaction, such as pushing its configuration to an external Alertmanager.
+func appendOrgStateErrors(engine *xorm.Engine, orgID int64, problems []error) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	var state alertMigrationOrgState
+	found, err := engine.Where("org_id = ?", orgID).Get(&state)
+	if err != nil || !found {
+		return err
+	}
+	var errs []string
+	if state.Errors != "" {
+		if err := json.Unmarshal([]byte(state.Errors), &errs); err != nil {
+			return fmt.Errorf("failed to parse migration state of organization %d: %w", orgID, err)
+		}
+	}
+	for _, problem := range problems {
+		errs = append(errs, problem.Error())
+	}
+	b, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	_, err = engine.Exec("UPDATE alert_migration_org_state SET errors = ? WHERE id = ?", string(b), state.ID)
+	return err
+}
+
+// revertOrgStates marks the organization, or every organization if orgID is 0, as not migrated, if the
+// alert_migration_org_state table exists.
+func revertOrgStates(sess *xorm.Session, orgID int64) error {
+	exists, err := sess.IsTableExist("alert_migration_org_state")
+	if err != nil || !exists {
+		return err
+	}
+	cond, args := orgCondition("org_id", orgID)
+	_, err = sess.Table("alert_migration_org_state").Where(cond, args...).Cols("migrated", "grafana_version", "errors", "updated", "shadow", "scope").Update(&alertMigrationOrgState{
+		Migrated:       false,
+		GrafanaVersion: setting.BuildVersion,
+		Updated:        time.Now().UTC(),
+	})
+	return err
+}
@@ -0,0 +1,474 @@
+package ualert
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+	"xorm.io/xorm"
+
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const orgPartialRmMigTitle = "remove unified alerting data of org %d created by the migration"
+
+// AddOrgPartialRevert adds the migration that removes the unified alerting resources of a single organization that the
+// migration created and that were not edited since. Like AddOrgMigration it is meant to run at runtime through a
+// migrator of its own and is not recorded in the migration_log.
+func AddOrgPartialRevert(mg *migrator.Migrator, orgID int64) {
+	mg.AddMigration(fmt.Sprintf(orgPartialRmMigTitle, orgID), &partialRmMigration{orgID: orgID, revertOnly: true})
+}
+
+// partialRmMigration removes the alert rules, folders and contact points that the migration created in an
+// organization, as recorded in the alert_migration_audit table. Unlike rmMigration it keeps:
+//   - alert rules created by users, and migrated alert rules updated after the migration,
+//   - contact points created by users, migrated contact points with integrations added after the migration, and
+//     migrated contact points that the notification policies route kept alert rules to,
+//   - notification policies that do not route to a removed contact point,
+//   - folders that still contain alert rules or dashboards,
+//   - silences, which cannot be told apart from silences created by users.
+//
+// Changes to the settings of an integration are not tracked, a migrated contact point is removed even if they changed.
+type partialRmMigration struct {
+	migrator.MigrationBase
+
+	orgID int64
+	// revertOnly records the revert of the organization, unless the removal precedes a migration.
+	revertOnly bool
+}
+
+func (m *partialRmMigration) SQL(dialect migrator.Dialect) string {
+	return codeMigration
+}
+
+func (m *partialRmMigration) SkipMigrationLog() bool {
+	return true
+}
+
+func (m *partialRmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	if err := m.exec(sess, mg); err != nil {
+		recordFailureOnRollback(mg, m.orgID, err)
+		return err
+	}
+	return nil
+}
+
+func (m *partialRmMigration) exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	exists, err := sess.IsTableExist("alert_migration_audit")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("cannot revert organisation %d partially without the alert_migration_audit table", m.orgID)
+	}
+
+	if mg.Cfg != nil && mg.Cfg.UnifiedAlerting.Upgrade.BackupRemovedData {
+		if err := backupOrgs(sess, m.orgID, mg.Cfg.UnifiedAlerting.Upgrade.MaxBackupsToKeep); err != nil {
+			return err
+		}
+	}
+
+	entries, err := queryLiveAuditEntries(sess)
+	if err != nil {
+		return err
+	}
+	created := make(map[string][]*alertMigrationAudit)
+	for _, e := range entries {
+		if e.OrgID == m.orgID {
+			created[e.ResourceType] = append(created[e.ResourceType], e)
+		}
+	}
+
+	var deleted []*alertMigrationAudit
+	rules, err := m.deleteUneditedRules(sess, created[auditResourceAlertRule])
+	if err != nil {
+		return fmt.Errorf("failed to delete migrated alert rules: %w", err)
+	}
+	deleted = append(deleted, rules...)
+
+	receivers, err := m.deleteUneditedReceivers(sess, created[auditResourceReceiver])
+	if err != nil {
+		return fmt.Errorf("failed to delete migrated contact points: %w", err)
+	}
+	deleted = append(deleted, receivers...)
+
+	folders, err := m.deleteEmptyFolders(sess, created[auditResourceFolder])
+	if err != nil {
+		return fmt.Errorf("failed to delete migrated folders: %w", err)
+	}
+	deleted = append(deleted, folders...)
+
+	now := time.Now().UTC()
+	for _, e := range deleted {
+		e.ID = 0
+		e.Action = auditActionDelete
+		e.Created = now
+		if _, err := sess.Insert(e); err != nil {
+			return fmt.Errorf("failed to write migration audit entry for %s %s: %w", e.ResourceType, e.ResourceUID, err)
+		}
+	}
+
+	if err := m.deleteMappingsOf(sess, deleted); err != nil {
+		return err
+	}
+
+	if err := restoreDashboardPanelAlerts(sess, m.orgID); err != nil {
+		return err
+	}
+
+	if err := revertOrgStates(sess, m.orgID); err != nil {
+		return err
+	}
+
+	if m.revertOnly {
+		return recordMigrationEvent(sess, mg.Dialect, MigrationEvent{Type: MigrationEventOrgReverted, OrgID: m.orgID})
+	}
+	return nil
+}
+
+// deleteUneditedRules deletes the migrated alert rules that were not updated after the migration, with their versions
+// and state, and returns the audit entries of the deleted rules.
+func (m *partialRmMigration) deleteUneditedRules(sess *xorm.Session, created []*alertMigrationAudit) ([]*alertMigrationAudit, error) {
+	if len(created) == 0 {
+		return nil, nil
+	}
+
+	// A rule is migrated before its audit entries are recorded, any later update is made after the migration.
+	var migrated []alertMigrationAudit
+	if err := sess.Where("org_id = ? AND resource_type = ? AND action IN (?, ?)", m.orgID, auditResourceAlertRule, auditActionCreate, auditActionUpdate).Find(&migrated); err != nil {
+		return nil, err
+	}
+	migratedAt := make(map[string]time.Time, len(migrated))
+	for _, e := range migrated {
+		if e.Created.After(migratedAt[e.ResourceUID]) {
+			migratedAt[e.ResourceUID] = e.Created
+		}
+	}
+
+	var rules []struct {
+		ID      int64     `xorm:"id"`
+		UID     string    `xorm:"uid"`
+		Updated time.Time `xorm:"updated"`
+	}
+	if err := sess.Table("alert_rule").Where("org_id = ?", m.orgID).Cols("id", "uid", "updated").Find(&rules); err != nil {
+		return nil, err
+	}
+	unedited := make(map[string]int64, len(rules))
+	for _, r := range rules {
+		if !r.Updated.After(migratedAt[r.UID]) {
+			unedited[r.UID] = r.ID
+		}
+	}
+
+	var deleted []*alertMigrationAudit
+	for _, e := range created {
+		id, ok := unedited[e.ResourceUID]
+		if !ok {
+			continue
+		}
+		if _, err := sess.Exec("delete from alert_rule where org_id = ? and uid = ?", m.orgID, e.ResourceUID); err != nil {
+			return nil, err
+		}
+		if _, err := sess.Exec("delete from alert_rule_version where rule_org_id = ? and rule_uid = ?", m.orgID, e.ResourceUID); err != nil {
+			return nil, err
+		}
+		if _, err := sess.Exec("delete from alert_instance where rule_org_id = ? and rule_uid = ?", m.orgID, e.ResourceUID); err != nil {
+			return nil, err
+		}
+		if _, err := sess.Exec("delete from annotation where org_id = ? and alert_id = ? and type = ?", m.orgID, id, backfilledAnnotationType); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, e)
+	}
+	return deleted, nil
+}
+
+// deleteUneditedReceivers removes the migrated contact points without integrations added after the migration from the
+// Alertmanager configuration, together with the notification policies that route to them. The contact point of the
+// root notification policy and the contact points that the remaining alert rules are routed to are kept. It returns
+// the audit entries of the removed contact points.
+func (m *partialRmMigration) deleteUneditedReceivers(sess *xorm.Session, created []*alertMigrationAudit) ([]*alertMigrationAudit, error) {
+	if len(created) == 0 {
+		return nil, nil
+	}
+
+	cfg := AlertConfiguration{}
+	exists, err := sess.Where("org_id = ?", m.orgID).Desc("id").Get(&cfg)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	// The configuration is edited as a generic document to keep the fields that the vendored types do not know.
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(cfg.AlertmanagerConfiguration), &raw); err != nil {
+		return nil, fmt.Errorf("failed to read Alertmanager configuration: %w", err)
+	}
+	amConfig, _ := raw["alertmanager_config"].(map[string]any)
+	if amConfig == nil {
+		return nil, nil
+	}
+	root, _ := amConfig["route"].(map[string]any)
+	rootReceiver := ""
+	if root != nil {
+		rootReceiver, _ = root["receiver"].(string)
+	}
+
+	rules, err := m.remainingRuleLabels(sess)
+	if err != nil {
+		return nil, err
+	}
+	routed := make(map[string]struct{})
+	if root != nil {
+		routedReceivers(root, rootReceiver, nil, rules, routed)
+	}
+
+	var mappings []alertMigrationMapping
+	if err := sess.Where("org_id = ? AND legacy_type = ?", m.orgID, mappingLegacyChannel).Find(&mappings); err != nil {
+		return nil, err
+	}
+	integrations := make(map[string]string, len(mappings))
+	for _, mp := range mappings {
+		integrations[mp.Name] = mp.UID
+	}
+
+	auditByName := make(map[string]*alertMigrationAudit, len(created))
+	for _, e := range created {
+		auditByName[e.ResourceUID] = e
+	}
+
+	removed := make(map[string]struct{})
+	var deleted []*alertMigrationAudit
+	receivers, _ := amConfig["receivers"].([]any)
+	kept := make([]any, 0, len(receivers))
+	for _, r := range receivers {
+		receiver, _ := r.(map[string]any)
+		name, _ := receiver["name"].(string)
+		e, ok := auditByName[name]
+		if _, used := routed[name]; !ok || used || name == rootReceiver || !uneditedReceiver(receiver, e, integrations[name]) {
+			kept = append(kept, r)
+			continue
+		}
+		removed[name] = struct{}{}
+		deleted = append(deleted, e)
+	}
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+	amConfig["receivers"] = kept
+	if root != nil {
+		pruneRoutes(root, removed)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	_, err = sess.Exec("update alert_configuration set alertmanager_configuration = ?, configuration_hash = ? where id = ?",
+		string(data), fmt.Sprintf("%x", md5.Sum(data)), cfg.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update Alertmanager configuration: %w", err)
+	}
+	return deleted, nil
+}
+
+// uneditedReceiver returns true if no integration was added to the contact point after the migration. The contact
+// point of a notification channel has the single integration of the channel, other contact points created by the
+// migration have no integration of a channel of their own.
+func uneditedReceiver(receiver map[string]any, e *alertMigrationAudit, integrationUID string) bool {
+	if e.LegacyID == 0 {
+		return true
+	}
+	configs, _ := receiver["grafana_managed_receiver_configs"].([]any)
+	for _, c := range configs {
+		integration, _ := c.(map[string]any)
+		if uid, _ := integration["uid"].(string); uid != integrationUID {
+			return false
+		}
+	}
+	return true
+}
+
+// remainingRuleLabels returns the labels of the alert rules of the organization, with the labels added to their alerts
+// that notification policies created by the migration match.
+func (m *partialRmMigration) remainingRuleLabels(sess *xorm.Session) ([]map[string]string, error) {
+	var rules []struct {
+		Title       string            `xorm:"title"`
+		Labels      map[string]string `xorm:"labels"`
+		FolderTitle string            `xorm:"folder_title"`
+	}
+	err := sess.SQL("SELECT r.title, r.labels, f.title AS folder_title FROM alert_rule r LEFT JOIN dashboard f ON f.org_id = r.org_id AND f.uid = r.namespace_uid WHERE r.org_id = ?", m.orgID).Find(&rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remaining alert rules: %w", err)
+	}
+	result := make([]map[string]string, 0, len(rules))
+	for _, r := range rules {
+		lbls := make(map[string]string, len(r.Labels)+2)
+		for k, v := range r.Labels {
+			lbls[k] = v
+		}
+		lbls[model.AlertNameLabel] = r.Title
+		lbls[ngModels.FolderTitleLabel] = r.FolderTitle
+		result = append(result, lbls)
+	}
+	return result, nil
+}
+
+// routedReceivers adds the contact points of the route and its child routes that any of the alert rules with the
+// given labels is routed to. Continue is not taken into account, an alert rule can be routed to every matching route.
+func routedReceivers(route map[string]any, receiver string, matchers labels.Matchers, rules []map[string]string, routed map[string]struct{}) {
+	if r, _ := route["receiver"].(string); r != "" {
+		receiver = r
+	}
+	matchers = append(matchers[:len(matchers):len(matchers)], routeMatchers(route)...)
+
+	matched := false
+	for _, lbls := range rules {
+		if matchesAll(matchers, lbls) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return
+	}
+	routed[receiver] = struct{}{}
+
+	routes, _ := route["routes"].([]any)
+	for _, r := range routes {
+		if child, _ := r.(map[string]any); child != nil {
+			routedReceivers(child, receiver, matchers, rules, routed)
+		}
+	}
+}
+
+var matchTypes = map[string]labels.MatchType{
+	"=":  labels.MatchEqual,
+	"!=": labels.MatchNotEqual,
+	"=~": labels.MatchRegexp,
+	"!~": labels.MatchNotRegexp,
+}
+
+// routeMatchers returns the object matchers of the route. Matchers that cannot be read never match.
+func routeMatchers(route map[string]any) labels.Matchers {
+	raw, _ := route["object_matchers"].([]any)
+	result := make(labels.Matchers, 0, len(raw))
+	for _, r := range raw {
+		parts, _ := r.([]any)
+		var fields [3]string
+		for i := 0; i < len(parts) && i < len(fields); i++ {
+			fields[i], _ = parts[i].(string)
+		}
+		matchType, ok := matchTypes[fields[1]]
+		mat, err := labels.NewMatcher(matchType, fields[0], fields[2])
+		if !ok || err != nil || len(parts) != 3 {
+			mat, _ = labels.NewMatcher(labels.MatchNotRegexp, fields[0], ".*")
+		}
+		result = append(result, mat)
+	}
+	return result
+}
+
+func matchesAll(matchers labels.Matchers, lbls map[string]string) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneRoutes removes the child routes of the route that route to a removed contact point, and those left without
+// a contact point and child routes.
+func pruneRoutes(route map[string]any, removed map[string]struct{}) {
+	routes, _ := route["routes"].([]any)
+	if len(routes) == 0 {
+		return
+	}
+	kept := make([]any, 0, len(routes))
+	for _, r := range routes {
+		child, _ := r.(map[string]any)
+		if child == nil {
+			continue
+		}
+		receiver, _ := child["receiver"].(string)
+		if _, ok := removed[receiver]; ok {
+			continue
+		}
+		children, _ := child["routes"].([]any)
+		pruneRoutes(child, removed)
+		if remaining, _ := child["routes"].([]any); receiver == "" && len(children) > 0 && len(remaining) == 0 {
+			continue
+		}
+		kept = append(kept, child)
+	}
+	if len(kept) == 0 {
+		delete(route, "routes")
+		return
+	}
+	route["routes"] = kept
+}
+
+// deleteEmptyFolders deletes the migrated folders without alert rules and dashboards, with their permissions, and
+// returns the audit entries of the deleted folders.
+func (m *partialRmMigration) deleteEmptyFolders(sess *xorm.Session, created []*alertMigrationAudit) ([]*alertMigrationAudit, error) {
+	var deleted []*alertMigrationAudit
+	for _, e := range created {
+		folder := dashboard{}
+		exists, err := sess.Where("org_id = ? AND uid = ? AND is_folder = ?", m.orgID, e.ResourceUID, true).Get(&folder)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		rules, err := sess.Table("alert_rule").Where("org_id = ? AND namespace_uid = ?", m.orgID, folder.Uid).Count()
+		if err != nil {
+			return nil, err
+		}
+		dashboards, err := sess.Table("dashboard").Where("org_id = ? AND folder_id = ?", m.orgID, folder.Id).Count()
+		if err != nil {
+			return nil, err
+		}
+		if rules > 0 || dashboards > 0 {
+			continue
+		}
+
+		if _, err := sess.Exec("delete from dashboard_acl where dashboard_id = ?", folder.Id); err != nil {
+			return nil, err
+		}
+		if err := deleteFolderManagedPermissions(sess, "id = ?", []any{folder.Id}); err != nil {
+			return nil, err
+		}
+		if _, err := sess.Exec("delete from dashboard where id = ?", folder.Id); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, e)
+	}
+	return deleted, nil
+}
+
+// deleteMappingsOf deletes the legacy ID mappings of the deleted alert rules and contact points.
+func (m *partialRmMigration) deleteMappingsOf(sess *xorm.Session, deleted []*alertMigrationAudit) error {
+	exists, err := sess.IsTableExist("alert_migration_mapping")
+	if err != nil || !exists {
+		return err
+	}
+	for _, e := range deleted {
+		var err error
+		switch e.ResourceType {
+		case auditResourceAlertRule:
+			_, err = sess.Exec("delete from alert_migration_mapping where org_id = ? and legacy_type = ? and uid = ?", m.orgID, mappingLegacyAlert, e.ResourceUID)
+		case auditResourceReceiver:
+			_, err = sess.Exec("delete from alert_migration_mapping where org_id = ? and legacy_type = ? and name = ?", m.orgID, mappingLegacyChannel, e.ResourceUID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete mapping of %s %s: %w", e.ResourceType, e.ResourceUID, err)
+		}
+	}
+	return nil
+}
@@ -47,22 +47,41 @@ type dashboardACL struct {
 func (p dashboardACL) TableName() string { return "dashboard_acl" }
 
 type folderHelper struct {
-	sess *xorm.Session
-	mg   *migrator.Migrator
+	sess  *xorm.Session
+	mg    *migrator.Migrator
+	audit *migrationAudit
+	// deterministicUIDs derives the folder UIDs from the organisation and title instead of generating random ones.
+	deterministicUIDs bool
+	// stamp is added to the JSON model of the created folders.
+	stamp migrationStamp
+	// uids avoids the uids generated by the migration and, if configured, the uids taken by existing data. If nil,
+	// folder uids are generated without checks.
+	uids *uidSet
 }
 
 // getOrCreateGeneralFolder returns the general folder under the specific organisation
 // If the general folder does not exist it creates it.
 func (m *folderHelper) getOrCreateGeneralFolder(orgID int64) (*dashboard, error) {
+	return m.getOrCreateFolder(orgID, GENERAL_FOLDER)
+}
+
+// getOrCreateFolder returns the folder with the given title under the specific organisation
+// If the folder does not exist it creates it.
+func (m *folderHelper) getOrCreateFolder(orgID int64, title string) (*dashboard, error) {
 	// there is a unique constraint on org_id, folder_id, title
 	// there are no nested folders so the parent folder id is always 0
-	dashboard := dashboard{OrgId: orgID, FolderId: 0, Title: GENERAL_FOLDER}
+	dashboard := dashboard{OrgId: orgID, FolderId: 0, Title: title, IsFolder: true}
 	has, err := m.sess.Get(&dashboard)
 	if err != nil {
 		return nil, err
 	} else if !has {
 		// create folder
-		return m.createGeneralFolder(orgID)
+		f, err := m.createFolder(orgID, title)
+		if err != nil {
+			return nil, err
+		}
+		m.audit.recordCreate(orgID, auditResourceFolder, f.Uid, 0, "")
+		return f, nil
 	}
 	return &dashboard, nil
 }
@@ -71,6 +90,22 @@ func (m *folderHelper) createGeneralFolder(orgID int64) (*dashboard, error) {
 	return m.createFolder(orgID, GENERAL_FOLDER)
 }
 
+// getFolderByUID returns the folder with the given UID under the organisation.
+func (m *folderHelper) getFolderByUID(orgID int64, uid string) (*dashboard, error) {
+	folder := dashboard{}
+	exists, err := m.sess.Where("org_id=? AND uid=?", orgID, uid).Get(&folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder %s: %w", uid, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("folder with uid %s not found", uid)
+	}
+	if !folder.IsFolder {
+		return nil, fmt.Errorf("uid %s is a dashboard not a folder", uid)
+	}
+	return &folder, nil
+}
+
 // returns the folder of the given dashboard (if exists)
 func (m *folderHelper) getFolder(dash dashboard, da dashAlert) (dashboard, error) {
 	// get folder if exists
@@ -90,19 +125,51 @@ func (m *folderHelper) getFolder(dash dashboard, da dashAlert) (dashboard, error
 	return folder, nil
 }
 
+// newFolderUid generates the uid of a new folder. If deterministicUIDs is enabled it is derived from the organisation
+// and title, and only if that uid is not available from the attempt number as well.
+func (m *folderHelper) newFolderUid(orgID int64, title string) (string, error) {
+	if m.uids == nil {
+		if m.deterministicUIDs {
+			return deterministicUid(auditResourceFolder, orgID, title), nil
+		}
+		return util.GenerateShortUID(), nil
+	}
+	if !m.deterministicUIDs {
+		return m.uids.generateUid()
+	}
+	uid := deterministicUid(auditResourceFolder, orgID, title)
+	ok, err := m.uids.available(uid)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		m.uids.add(uid)
+		return uid, nil
+	}
+	return m.uids.generateDeterministicUid(auditResourceFolder, orgID, title)
+}
+
 // based on sqlstore.saveDashboard()
 // it should be called from inside a transaction
 func (m *folderHelper) createFolder(orgID int64, title string) (*dashboard, error) {
+	model := map[string]any{
+		"title": title,
+	}
+	if stamp := m.stamp.model(); stamp != nil {
+		model["migration"] = stamp
+	}
 	cmd := saveFolderCommand{
-		OrgId:    orgID,
-		FolderId: 0,
-		IsFolder: true,
-		Dashboard: simplejson.NewFromAny(map[string]any{
-			"title": title,
-		}),
+		OrgId:     orgID,
+		FolderId:  0,
+		IsFolder:  true,
+		Dashboard: simplejson.NewFromAny(model),
 	}
 	dash := cmd.getDashboardModel()
-	dash.setUid(util.GenerateShortUID())
+	uid, err := m.newFolderUid(orgID, title)
+	if err != nil {
+		return nil, err
+	}
+	dash.setUid(uid)
 
 	parentVersion := dash.Version
 	dash.setVersion(1)
@@ -134,6 +201,46 @@ func (m *folderHelper) createFolder(orgID int64, title string) (*dashboard, erro
 	return dash, nil
 }
 
+// createDashboardFolder creates the folder of the alerts of a dashboard with custom permissions, and assigns it the
+// permissions of the dashboard, included default and inherited. The statements run in a savepoint that is rolled back
+// if one of them fails, so that the failure leaves neither a folder without the permissions of the dashboard nor, on
+// PostgreSQL, an aborted transaction, and the migration of the organization can continue.
+func (m *folderHelper) createDashboardFolder(dash *dashboard, title string) (*dashboard, []*dashboardACL, *folderPermissionReport, error) {
+	if _, err := m.sess.Exec("SAVEPOINT ualert_folder"); err != nil {
+		return nil, nil, nil, err
+	}
+	f, permissions, report, err := m.createFolderWithPermissions(dash, title)
+	if err != nil {
+		if _, rbErr := m.sess.Exec("ROLLBACK TO SAVEPOINT ualert_folder"); rbErr != nil {
+			return nil, nil, nil, fmt.Errorf("%w, and failed to roll back: %s", err, rbErr)
+		}
+		return nil, nil, nil, err
+	}
+	if _, err := m.sess.Exec("RELEASE SAVEPOINT ualert_folder"); err != nil {
+		return nil, nil, nil, err
+	}
+	return f, permissions, report, nil
+}
+
+func (m *folderHelper) createFolderWithPermissions(dash *dashboard, title string) (*dashboard, []*dashboardACL, *folderPermissionReport, error) {
+	f, err := m.createFolder(dash.OrgId, title)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+	permissions, err := m.getACL(dash.OrgId, dash.Id)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get dashboard %d under organisation %d permissions: %w", dash.Id, dash.OrgId, err)
+	}
+	if err := m.setACL(f.OrgId, f.Id, permissions); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to set folder %d under organisation %d permissions: %w", f.Id, f.OrgId, err)
+	}
+	report, err := m.copyManagedPermissions(dash, f)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to copy managed permissions to folder %d under organisation %d: %w", f.Id, f.OrgId, err)
+	}
+	return f, permissions, report, nil
+}
+
 // based on SQLStore.UpdateDashboardACL()
 // it should be called from inside a transaction
 func (m *folderHelper) setACL(orgID int64, dashboardID int64, items []*dashboardACL) error {
@@ -0,0 +1,158 @@
+package ualert
+
+import (
+	"fmt"
+	"text/template"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// PreviewOrgAlertmanagerConfig returns the Alertmanager configuration, with its notification policy tree, that the
+// dashboard alert migration would write for the organization from its current legacy alerts and notification channels.
+// Nothing is written, so that it can be called before and after the organization is migrated.
+func PreviewOrgAlertmanagerConfig(sess *xorm.Session, dialect migrator.Dialect, cfg *setting.Cfg, orgID int64) (*PostableUserConfig, error) {
+	mg := &migrator.Migrator{
+		Dialect: dialect,
+		Logger:  log.New("ualert.preview"),
+		Cfg:     cfg,
+	}
+	m := newMigration(mg)
+	m.sess = sess
+	m.mg = mg
+	m.orgID = orgID
+	return m.previewAlertmanagerConfig()
+}
+
+// previewAlertmanagerConfig builds the Alertmanager configuration of the organization of the migration with
+// setupAlertmanagerConfig. The alert rules are not converted, only their title and folder are needed to route them.
+func (m *migration) previewAlertmanagerConfig() (*PostableUserConfig, error) {
+	if m.upgradeCfg.FolderNameTemplate != "" {
+		tmpl, err := template.New("folder_name_template").Parse(m.upgradeCfg.FolderNameTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse folder name template: %w", err)
+		}
+		m.folderNameTmpl = tmpl
+	}
+
+	if err := m.loadTemplateMappings(); err != nil {
+		return nil, err
+	}
+
+	if err := m.loadDatasourceUIDMappings(); err != nil {
+		return nil, err
+	}
+
+	scope, err := newMigrationScope(m.sess, m.upgradeCfg)
+	if err != nil {
+		return nil, err
+	}
+	m.scope = scope
+
+	channelsPerOrg, defaultChannelsPerOrg, err := m.getNotificationChannelMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification channels: %w", err)
+	}
+	channels, defaultChannels := channelsPerOrg[m.orgID], defaultChannelsPerOrg[m.orgID]
+	// The Alertmanager channels are migrated to data sources rather than contact points.
+	if m.upgradeCfg.AlertmanagerChannelsToDatasources {
+		channels = withoutAlertmanagerChannels(channels)
+		defaultChannels = withoutAlertmanagerChannels(defaultChannels)
+	}
+
+	folderTitles := make(map[int64]string)
+	rules := make(map[*alertRule][]uidOrID)
+	err = m.forEachDashAlertBatch(m.orgID, func(dashAlerts []dashAlert) error {
+		for _, da := range dashAlerts {
+			title, ok := folderTitles[da.DashboardId]
+			if !ok {
+				title, err = m.previewFolderTitle(da)
+				if err != nil {
+					return err
+				}
+				folderTitles[da.DashboardId] = title
+			}
+			if title == "" {
+				continue
+			}
+			rule := &alertRule{OrgID: da.OrgId, Title: da.Name, Labels: map[string]string{}, folderTitle: title}
+			if m.upgradeCfg.KeepStateRouting {
+				// The routes of the keep state alerts match the UID of the rule, which is only known once it is created.
+				rule.UID = fmt.Sprintf("<alert %d>", da.Id)
+				rule.keepStateAlerts = keepStateAlertNames(da)
+			}
+			rules[rule] = extractChannelIDs(da)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(channels) == 0 {
+		return &PostableUserConfig{}, nil
+	}
+	return m.setupAlertmanagerConfig(m.orgID, channels, defaultChannels, rules)
+}
+
+// previewFolderTitle returns the title of the folder that the migration puts the alerts of the dashboard of the legacy
+// alert in, without creating it, or an empty string if they are not migrated. The folder is resolved like the
+// migration does with alertFolder, and an alert whose folder fails is skipped if the migration skips failing alerts.
+func (m *migration) previewFolderTitle(da dashAlert) (string, error) {
+	dash := dashboard{}
+	exists, err := m.sess.Where("org_id=? AND id=?", da.OrgId, da.DashboardId).Get(&dash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get dashboard %d under organisation %d: %w", da.DashboardId, da.OrgId, err)
+	}
+	if !exists || !m.scope.contains(&dash) {
+		return "", nil
+	}
+
+	folder, err := m.alertFolder(m.mg.Logger, previewFolders{helper: folderHelper{sess: m.sess, mg: m.mg}}, &dash, da)
+	if err != nil {
+		if m.upgradeCfg.SkipFailingAlerts || m.incremental {
+			return "", nil
+		}
+		return "", err
+	}
+	if folder == nil {
+		return "", nil
+	}
+	return folder.Title, nil
+}
+
+// previewFolders gets the existing folders of the alerts, and only the titles of the folders that the migration
+// would create, see previewFolderTitle.
+type previewFolders struct {
+	helper folderHelper
+}
+
+func (p previewFolders) targetFolder(orgID int64, uid string) (*dashboard, error) {
+	return p.helper.getFolderByUID(orgID, uid)
+}
+
+func (p previewFolders) permissionsFolder(_ log.Logger, dash *dashboard, title string) (*dashboard, error) {
+	return &dashboard{OrgId: dash.OrgId, Title: title, IsFolder: true}, nil
+}
+
+func (p previewFolders) orphanFolder(orgID int64, title string) (*dashboard, error) {
+	return &dashboard{OrgId: orgID, Title: title, IsFolder: true}, nil
+}
+
+func (p previewFolders) generalFolder(orgID int64) (*dashboard, error) {
+	return &dashboard{OrgId: orgID, Title: GENERAL_FOLDER, IsFolder: true}, nil
+}
+
+// withoutAlertmanagerChannels returns the notification channels that are not legacy Alertmanager channels.
+func withoutAlertmanagerChannels(channels []*notificationChannel) []*notificationChannel {
+	result := make([]*notificationChannel, 0, len(channels))
+	for _, c := range channels {
+		if c.Type != legacyAlertmanagerChannelType {
+			result = append(result, c)
+		}
+	}
+	return result
+}
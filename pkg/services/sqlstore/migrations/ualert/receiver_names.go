@@ -0,0 +1,77 @@
+package ualert
+
+import (
+	"crypto/md5"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// receiverNames assigns the names of the contact points migrated from the notification channels of an organization,
+// according to the receiver_name_strategy setting.
+type receiverNames struct {
+	unique bool
+	// taken are the names in use: those assigned by the migration and, with the unique strategy or in the incremental
+	// migration, those of the existing contact points of the organization that the migration keeps.
+	taken  map[string]struct{}
+	synced *syncedLegacyIDs
+}
+
+// newReceiverNames returns the receiver names of the organization being migrated.
+func (m *migration) newReceiverNames() (*receiverNames, error) {
+	n := &receiverNames{
+		unique: m.upgradeCfg.ReceiverNameStrategy == setting.ReceiverNameStrategyUnique,
+		taken:  make(map[string]struct{}),
+		synced: m.synced,
+	}
+	// The new channels of a synced organization must not take the names of its contact points either, or they
+	// would be merged into them.
+	if !n.unique && !m.incremental {
+		return n, nil
+	}
+	n.taken[defaultReceiverName] = struct{}{}
+	existing, err := m.existingReceiverNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range existing {
+		n.taken[name] = struct{}{}
+	}
+	return n, nil
+}
+
+// assign returns the name of the contact point of the notification channel, and whether it differs from the name of
+// the channel for another reason than the removal of double quotes. Double quotes are replaced because they are the
+// separator of the ContactLabel, and would otherwise cause partial matches of the route matchers.
+func (n *receiverNames) assign(c *notificationChannel) (string, bool) {
+	// The contact point of a channel migrated by a previous run is kept with its name.
+	if name, ok := n.synced.channelName(c.OrgID, c.ID); ok {
+		n.taken[name] = struct{}{}
+		return name, false
+	}
+
+	sanitized := strings.ReplaceAll(c.Name, `"`, `_`)
+	base := sanitized
+	if !n.unique {
+		name := sanitized
+		if _, ok := n.taken[name]; ok {
+			name = name + fmt.Sprintf("_%.3x", md5.Sum([]byte(c.Name)))
+		}
+		if _, ok := n.taken[name]; !ok {
+			n.taken[name] = struct{}{}
+			return name, name != sanitized
+		}
+		// The suffixed name is taken by an existing contact point of a synced organization as well.
+		base = name
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := n.taken[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s (%d)", base, i)
+	}
+	n.taken[name] = struct{}{}
+	return name, name != sanitized
+}
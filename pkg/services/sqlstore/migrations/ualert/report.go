@@ -0,0 +1,117 @@
+package ualert
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Severities of the rows of a ValidationReport exported as CSV. The summary rows hold the outcome of every legacy alert
+// and notification channel as their reason.
+const (
+	reportSeverityError   = "error"
+	reportSeverityWarning = "warning"
+	reportSeveritySummary = "summary"
+)
+
+// reportCSVHeader is the header row of a ValidationReport exported as CSV.
+var reportCSVHeader = []string{"severity", "kind", "org_id", "alert_id", "dashboard_id", "panel_id", "channel_uid", "channel_type", "name", "reason"}
+
+// WriteJSON writes the report as indented JSON.
+func (r *ValidationReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the report as CSV with one summary row per alert and notification channel, followed by one row per
+// alert or notification channel problem or warning, per conflict, and per alert of an unsupported data source type.
+func (r *ValidationReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportCSVHeader); err != nil {
+		return err
+	}
+
+	for _, s := range r.AlertSummaries {
+		row := []string{reportSeveritySummary, "alert", strconv.FormatInt(s.OrgID, 10), strconv.FormatInt(s.AlertID, 10), strconv.FormatInt(s.DashboardID, 10), strconv.FormatInt(s.PanelID, 10), "", "", s.Name, s.Outcome}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, s := range r.ChannelSummaries {
+		row := []string{reportSeveritySummary, "channel", strconv.FormatInt(s.OrgID, 10), "", "", "", s.UID, s.Type, s.Name, s.Outcome}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	alertRows := func(severity string, problems []AlertValidationProblem) error {
+		for _, p := range problems {
+			row := []string{
+				severity,
+				"alert",
+				strconv.FormatInt(p.OrgID, 10),
+				strconv.FormatInt(p.AlertID, 10),
+				strconv.FormatInt(p.DashboardID, 10),
+				strconv.FormatInt(p.PanelID, 10),
+				"",
+				"",
+				p.Name,
+				p.Reason,
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	channelRows := func(severity string, problems []ChannelValidationProblem) error {
+		for _, p := range problems {
+			row := []string{
+				severity,
+				"channel",
+				strconv.FormatInt(p.OrgID, 10),
+				"",
+				"",
+				"",
+				p.UID,
+				p.Type,
+				p.Name,
+				p.Reason,
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := alertRows(reportSeverityError, r.Alerts); err != nil {
+		return err
+	}
+	if err := alertRows(reportSeverityWarning, r.AlertWarnings); err != nil {
+		return err
+	}
+	if err := channelRows(reportSeverityError, r.Channels); err != nil {
+		return err
+	}
+	if err := channelRows(reportSeverityWarning, r.ChannelWarnings); err != nil {
+		return err
+	}
+	for _, c := range r.Conflicts {
+		if err := cw.Write([]string{reportSeverityWarning, "conflict", strconv.FormatInt(c.OrgID, 10), "", "", "", "", "", "", c.Reason}); err != nil {
+			return err
+		}
+	}
+	for _, d := range r.Datasources {
+		for _, alertID := range d.AlertIDs {
+			if err := cw.Write([]string{reportSeverityError, "datasource", strconv.FormatInt(d.OrgID, 10), strconv.FormatInt(alertID, 10), "", "", "", "", d.Type, d.Reason}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
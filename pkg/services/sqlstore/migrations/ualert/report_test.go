@@ -0,0 +1,58 @@
+package ualert
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationReportExport(t *testing.T) {
+	report := &ValidationReport{
+		AlertCount:   2,
+		ChannelCount: 1,
+		Alerts: []AlertValidationProblem{
+			{OrgID: 1, AlertID: 2, DashboardID: 3, PanelID: 4, Name: "alert", Reason: "datasource with ID 5 not found"},
+		},
+		ChannelWarnings: []ChannelValidationProblem{
+			{OrgID: 1, UID: "uid", Name: "slack, with comma", Type: "slack", Reason: "no images"},
+		},
+		Conflicts: []ConflictValidationProblem{
+			{OrgID: 2, Reason: "Alertmanager configuration already exists"},
+		},
+		Datasources: []DatasourceValidationProblem{
+			{OrgID: 1, Type: "jaeger", AlertIDs: []int64{6, 7}, Reason: "data source plugin does not support alerting"},
+		},
+		AlertSummaries: []AlertSummary{
+			{OrgID: 1, AlertID: 2, DashboardID: 3, PanelID: 4, Name: "alert", Outcome: OutcomeNotMigrated, Reasons: []string{"datasource with ID 5 not found"}},
+			{OrgID: 1, AlertID: 8, DashboardID: 3, PanelID: 5, Name: "other", Outcome: OutcomeMigrated},
+		},
+		ChannelSummaries: []ChannelSummary{
+			{OrgID: 1, UID: "uid", Name: "slack, with comma", Type: "slack", Outcome: OutcomeMigratedWithWarnings, Reasons: []string{"no images"}},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteJSON(&buf))
+
+		var got ValidationReport
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Equal(t, *report, got)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteCSV(&buf))
+		require.Equal(t, "severity,kind,org_id,alert_id,dashboard_id,panel_id,channel_uid,channel_type,name,reason\n"+
+			"summary,alert,1,2,3,4,,,alert,not_migrated\n"+
+			"summary,alert,1,8,3,5,,,other,migrated\n"+
+			"summary,channel,1,,,,uid,slack,\"slack, with comma\",migrated_with_warnings\n"+
+			"error,alert,1,2,3,4,,,alert,datasource with ID 5 not found\n"+
+			"warning,channel,1,,,,uid,slack,\"slack, with comma\",no images\n"+
+			"warning,conflict,2,,,,,,,Alertmanager configuration already exists\n"+
+			"error,datasource,1,6,,,,,jaeger,data source plugin does not support alerting\n"+
+			"error,datasource,1,7,,,,,jaeger,data source plugin does not support alerting\n", buf.String())
+	})
+}
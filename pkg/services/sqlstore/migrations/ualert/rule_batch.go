@@ -0,0 +1,107 @@
+package ualert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const (
+	// ruleBatchMaxRetries is the number of times a batch of alert rules is retried when its transaction deadlocks.
+	ruleBatchMaxRetries = 3
+	// ruleBatchRetryDelay is the delay before the first retry of a batch, it doubles with every retry.
+	ruleBatchRetryDelay = 100 * time.Millisecond
+)
+
+// insertRuleBatches inserts the alert rules of the organization in batches of the rule_insert_batch_size setting.
+// On PostgreSQL, where a failed statement aborts the transaction, every batch is inserted in its own transaction that
+// is retried if it deadlocks. A batch that fails is inserted rule by rule with insertRule, and the failure is recorded
+// in the migration status of the organization.
+func (m *migration) insertRuleBatches(mg *migrator.Migrator, orgID int64, rules []*alertRule) error {
+	// Sort the rules so the batches do not depend on the iteration order of the map they were collected from.
+	sort.Slice(rules, func(i, j int) bool { return rules[i].UID < rules[j].UID })
+
+	size := m.upgradeCfg.RuleInsertBatchSize
+	for start := 0; start < len(rules); start += size {
+		end := start + size
+		if end > len(rules) {
+			end = len(rules)
+		}
+		batch := rules[start:end]
+
+		err := m.insertRuleBatch(mg, batch)
+		if err == nil {
+			continue
+		}
+
+		var batchErr ruleBatchError
+		if !errors.As(err, &batchErr) {
+			return err
+		}
+		m.mg.Logger.Warn("Alert migration warning: failed to insert batch of alert rules, inserting them one by one", "orgId", orgID, "first", start+1, "last", end, "error", err)
+		m.orgStates.recordError(orgID, fmt.Errorf("batch of alert rules %d to %d was inserted rule by rule: %w", start+1, end, batchErr.err))
+		for _, rule := range batch {
+			if err := m.insertRule(mg, rule); err != nil {
+				return fmt.Errorf("failed to insert alert rule %s of organization %d: %w", rule.UID, orgID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ruleBatchError is returned by insertRuleBatch when no alert rule of the batch was inserted, so that the batch can be
+// inserted rule by rule.
+type ruleBatchError struct {
+	err error
+}
+
+func (e ruleBatchError) Error() string {
+	return e.err.Error()
+}
+
+func (e ruleBatchError) Unwrap() error {
+	return e.err
+}
+
+// insertRuleBatch inserts the alert rules and their first versions with one statement each.
+func (m *migration) insertRuleBatch(mg *migrator.Migrator, batch []*alertRule) error {
+	insert := func(sess *xorm.Session) error {
+		if _, err := sess.InsertMulti(batch); err != nil {
+			return ruleBatchError{err: err}
+		}
+		versions := make([]*alertRuleVersion, 0, len(batch))
+		for _, rule := range batch {
+			versions = append(versions, rule.makeVersion())
+		}
+		if _, err := sess.InsertMulti(versions); err != nil {
+			return fmt.Errorf("failed to insert alert rule versions: %w", err)
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(mg.Dialect.DriverName(), migrator.Postgres) {
+		return insert(m.sess)
+	}
+
+	delay := ruleBatchRetryDelay
+	for retry := 0; ; retry++ {
+		err := mg.InTransaction(insert)
+		if err != nil && retry < ruleBatchMaxRetries && mg.Dialect.IsDeadlock(err) {
+			m.mg.Logger.Warn("Alert migration warning: batch of alert rules deadlocked, retrying", "retry", retry+1, "error", err)
+			time.Sleep(delay)
+			delay *= 2
+			continue
+		}
+		if err != nil && !errors.As(err, new(ruleBatchError)) {
+			// The transaction is rolled back, so none of the alert rules is inserted.
+			err = ruleBatchError{err: err}
+		}
+		return err
+	}
+}
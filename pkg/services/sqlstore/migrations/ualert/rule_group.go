@@ -0,0 +1,138 @@
+package ualert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// dashboardGroupKey identifies the rule group of the alert rules of a dashboard.
+type dashboardGroupKey struct {
+	orgID        int64
+	namespaceUID string
+	dashboardUID string
+}
+
+// groupNameKey identifies a rule group name in a folder.
+type groupNameKey struct {
+	orgID        int64
+	namespaceUID string
+	name         string
+}
+
+// dashboardRuleGroups puts the migrated alert rules of each dashboard into a single rule group named after the dashboard.
+type dashboardRuleGroups struct {
+	rules map[dashboardGroupKey][]*alertRule
+	// named are the rules of the rule groups named by the rule_group_name_template setting.
+	named map[groupNameKey][]*alertRule
+	names map[dashboardGroupKey]string
+	// used maps the group names in use to the UID of the dashboard they belong to.
+	used map[groupNameKey]string
+}
+
+func newDashboardRuleGroups() *dashboardRuleGroups {
+	return &dashboardRuleGroups{
+		rules: make(map[dashboardGroupKey][]*alertRule),
+		named: make(map[groupNameKey][]*alertRule),
+		names: make(map[dashboardGroupKey]string),
+		used:  make(map[groupNameKey]string),
+	}
+}
+
+// add assigns the rule to the rule group of the dashboard. If another dashboard with the same title has alerts in the
+// same folder, the dashboard UID is appended to the group name.
+func (g *dashboardRuleGroups) add(dash *dashboard, rule *alertRule) {
+	key := dashboardGroupKey{orgID: rule.OrgID, namespaceUID: rule.NamespaceUID, dashboardUID: dash.Uid}
+	name, ok := g.names[key]
+	if !ok {
+		name = normalizeRuleName(dash.Title, dash.Uid)
+		nameKey := groupNameKey{orgID: rule.OrgID, namespaceUID: rule.NamespaceUID, name: name}
+		if _, taken := g.used[nameKey]; taken {
+			name = normalizeRuleName(dash.Title+" - "+dash.Uid, dash.Uid)
+			nameKey.name = name
+		}
+		g.used[nameKey] = dash.Uid
+		g.names[key] = name
+	}
+	rule.RuleGroup = name
+	g.rules[key] = append(g.rules[key], rule)
+}
+
+// addNamed assigns the rule to the rule group with the given name in its folder, whatever the dashboard of the rule.
+func (g *dashboardRuleGroups) addNamed(rule *alertRule, name string) {
+	key := groupNameKey{orgID: rule.OrgID, namespaceUID: rule.NamespaceUID, name: name}
+	rule.RuleGroup = name
+	g.named[key] = append(g.named[key], rule)
+}
+
+// apply sets the rule group index and the shared evaluation interval of the rules of each group. If intervalSeconds is
+// zero, the shortest interval of the rules of the group is used. A configured interval is rounded to the base interval
+// of the scheduler.
+func (g *dashboardRuleGroups) apply(intervalSeconds, baseIntervalSeconds int64) {
+	groups := make([][]*alertRule, 0, len(g.rules)+len(g.named))
+	for _, rules := range g.rules {
+		groups = append(groups, rules)
+	}
+	for _, rules := range g.named {
+		groups = append(groups, rules)
+	}
+	for _, rules := range groups {
+		interval := intervalSeconds
+		if interval <= 0 {
+			for _, rule := range rules {
+				if interval <= 0 || rule.IntervalSeconds < interval {
+					interval = rule.IntervalSeconds
+				}
+			}
+		} else {
+			interval = ruleAdjustInterval(interval, baseIntervalSeconds)
+		}
+		for i, rule := range rules {
+			rule.RuleGroupIndex = i + 1
+			rule.IntervalSeconds = interval
+		}
+	}
+}
+
+// ruleGroupNameData is the data available to the rule_group_name_template setting.
+type ruleGroupNameData struct {
+	DashboardTitle string
+	DashboardUID   string
+	PanelTitle     string
+	PanelID        int64
+	FolderTitle    string
+	AlertName      string
+	OrgID          int64
+}
+
+// ruleGroupName returns the name of the rule group of the alert rule of the legacy alert, rendered from the
+// rule_group_name_template setting. A name longer than DefaultFieldMaxLength is truncated and suffixed with a hash of
+// the full name, so that distinct long names do not end up in the same rule group.
+func (m *migration) ruleGroupName(dash *dashboard, da dashAlert, rule *alertRule) (string, error) {
+	data := ruleGroupNameData{
+		DashboardTitle: dash.Title,
+		DashboardUID:   dash.Uid,
+		PanelID:        da.PanelId,
+		FolderTitle:    rule.folderTitle,
+		AlertName:      da.Name,
+		OrgID:          da.OrgId,
+	}
+	if dash.Data != nil {
+		forEachPanel(dash.Data, func(panel *simplejson.Json) {
+			if panel.Get("id").MustInt64() == da.PanelId {
+				data.PanelTitle = panel.Get("title").MustString()
+			}
+		})
+	}
+
+	var buf strings.Builder
+	if err := m.ruleGroupNameTmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render rule group name template: %w", err)
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", fmt.Errorf("rule group name template rendered an empty name")
+	}
+	return normalizeRuleName(name, deterministicUid(name)), nil
+}
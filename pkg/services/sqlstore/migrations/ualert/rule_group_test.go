@@ -0,0 +1,118 @@
+package ualert
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestDashboardRuleGroups(t *testing.T) {
+	t.Run("rules of a dashboard share a group and the shortest interval", func(t *testing.T) {
+		g := newDashboardRuleGroups()
+		dash := &dashboard{Uid: "dash-1", Title: "Dashboard"}
+		r1 := &alertRule{OrgID: 1, NamespaceUID: "folder", IntervalSeconds: 60}
+		r2 := &alertRule{OrgID: 1, NamespaceUID: "folder", IntervalSeconds: 30}
+		g.add(dash, r1)
+		g.add(dash, r2)
+		g.apply(0, 10)
+
+		require.Equal(t, "Dashboard", r1.RuleGroup)
+		require.Equal(t, "Dashboard", r2.RuleGroup)
+		require.Equal(t, 1, r1.RuleGroupIndex)
+		require.Equal(t, 2, r2.RuleGroupIndex)
+		require.Equal(t, int64(30), r1.IntervalSeconds)
+		require.Equal(t, int64(30), r2.IntervalSeconds)
+	})
+
+	t.Run("configured interval is used for all groups", func(t *testing.T) {
+		g := newDashboardRuleGroups()
+		r := &alertRule{OrgID: 1, NamespaceUID: "folder", IntervalSeconds: 60}
+		g.add(&dashboard{Uid: "dash-1", Title: "Dashboard"}, r)
+		g.apply(125, 10)
+
+		require.Equal(t, int64(120), r.IntervalSeconds)
+	})
+
+	t.Run("configured interval is rounded to the base interval of the scheduler", func(t *testing.T) {
+		g := newDashboardRuleGroups()
+		r := &alertRule{OrgID: 1, NamespaceUID: "folder", IntervalSeconds: 60}
+		g.add(&dashboard{Uid: "dash-1", Title: "Dashboard"}, r)
+		g.apply(125, 30)
+
+		require.Equal(t, int64(120), r.IntervalSeconds)
+	})
+
+	t.Run("dashboards with the same title in a folder get distinct groups", func(t *testing.T) {
+		g := newDashboardRuleGroups()
+		r1 := &alertRule{OrgID: 1, NamespaceUID: "folder"}
+		r2 := &alertRule{OrgID: 1, NamespaceUID: "folder"}
+		r3 := &alertRule{OrgID: 1, NamespaceUID: "other"}
+		g.add(&dashboard{Uid: "dash-1", Title: "Dashboard"}, r1)
+		g.add(&dashboard{Uid: "dash-2", Title: "Dashboard"}, r2)
+		g.add(&dashboard{Uid: "dash-3", Title: "Dashboard"}, r3)
+
+		require.Equal(t, "Dashboard", r1.RuleGroup)
+		require.Equal(t, "Dashboard - dash-2", r2.RuleGroup)
+		require.Equal(t, "Dashboard", r3.RuleGroup)
+	})
+	t.Run("rules with the same name share a group across dashboards", func(t *testing.T) {
+		g := newDashboardRuleGroups()
+		r1 := &alertRule{OrgID: 1, NamespaceUID: "folder", IntervalSeconds: 60}
+		r2 := &alertRule{OrgID: 1, NamespaceUID: "folder", IntervalSeconds: 30}
+		r3 := &alertRule{OrgID: 1, NamespaceUID: "other", IntervalSeconds: 60}
+		g.addNamed(r1, "Team")
+		g.addNamed(r2, "Team")
+		g.addNamed(r3, "Team")
+		g.apply(0, 10)
+
+		require.Equal(t, "Team", r1.RuleGroup)
+		require.Equal(t, 2, r2.RuleGroupIndex)
+		require.Equal(t, int64(30), r1.IntervalSeconds)
+		require.Equal(t, 1, r3.RuleGroupIndex)
+		require.Equal(t, int64(60), r3.IntervalSeconds)
+	})
+}
+
+func TestRuleGroupName(t *testing.T) {
+	dash := &dashboard{
+		Uid:   "dash-1",
+		Title: "Dashboard",
+		Data: simplejson.NewFromAny(map[string]any{
+			"panels": []any{
+				map[string]any{"id": 1, "title": "CPU"},
+				map[string]any{"id": 2, "title": "Memory"},
+			},
+		}),
+	}
+	da := dashAlert{OrgId: 1, PanelId: 2, Name: "High memory"}
+	rule := &alertRule{folderTitle: "Team A"}
+
+	t.Run("template is rendered with the dashboard, panel, folder and alert", func(t *testing.T) {
+		m := &migration{ruleGroupNameTmpl: template.Must(template.New("").Parse("{{.FolderTitle}}/{{.DashboardTitle}} - {{.PanelTitle}} ({{.PanelID}}) {{.AlertName}}"))}
+		name, err := m.ruleGroupName(dash, da, rule)
+		require.NoError(t, err)
+		require.Equal(t, "Team A/Dashboard - Memory (2) High memory", name)
+	})
+
+	t.Run("long names are truncated with a hash of the full name", func(t *testing.T) {
+		m := &migration{ruleGroupNameTmpl: template.Must(template.New("").Parse(strings.Repeat("x", DefaultFieldMaxLength) + "{{.PanelID}}"))}
+		name, err := m.ruleGroupName(dash, da, rule)
+		require.NoError(t, err)
+		require.Len(t, name, DefaultFieldMaxLength)
+
+		other, err := m.ruleGroupName(dash, dashAlert{OrgId: 1, PanelId: 1}, rule)
+		require.NoError(t, err)
+		require.Len(t, other, DefaultFieldMaxLength)
+		require.NotEqual(t, name, other)
+	})
+
+	t.Run("empty names are rejected", func(t *testing.T) {
+		m := &migration{ruleGroupNameTmpl: template.Must(template.New("").Parse("{{.PanelTitle}}"))}
+		_, err := m.ruleGroupName(dash, dashAlert{OrgId: 1, PanelId: 3}, rule)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,144 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// migrationScope restricts the migration to the alerts of some dashboards, see setting.UnifiedAlertingUpgradeSettings.
+type migrationScope struct {
+	dashboardUIDs map[string]struct{}
+	folderIDs     map[int64]struct{}
+	// taggedDashboardIDs holds the IDs of the dashboards that have any of the configured tags.
+	taggedDashboardIDs map[int64]struct{}
+}
+
+// newMigrationScope returns the scope configured in the upgrade settings, or nil if the migration is not restricted.
+func newMigrationScope(sess *xorm.Session, cfg setting.UnifiedAlertingUpgradeSettings) (*migrationScope, error) {
+	if len(cfg.ScopeDashboardUIDs) == 0 && len(cfg.ScopeDashboardTags) == 0 && len(cfg.ScopeFolderIDs) == 0 {
+		return nil, nil
+	}
+
+	s := &migrationScope{
+		dashboardUIDs:      make(map[string]struct{}, len(cfg.ScopeDashboardUIDs)),
+		folderIDs:          make(map[int64]struct{}, len(cfg.ScopeFolderIDs)),
+		taggedDashboardIDs: make(map[int64]struct{}),
+	}
+	for _, uid := range cfg.ScopeDashboardUIDs {
+		s.dashboardUIDs[uid] = struct{}{}
+	}
+	for _, id := range cfg.ScopeFolderIDs {
+		s.folderIDs[id] = struct{}{}
+	}
+
+	if len(cfg.ScopeDashboardTags) > 0 {
+		var ids []int64
+		if err := sess.Table("dashboard_tag").In("term", cfg.ScopeDashboardTags).Distinct("dashboard_id").Find(&ids); err != nil {
+			return nil, fmt.Errorf("failed to get dashboards by tag: %w", err)
+		}
+		for _, id := range ids {
+			s.taggedDashboardIDs[id] = struct{}{}
+		}
+	}
+
+	return s, nil
+}
+
+// contains returns true if the alerts of the dashboard are migrated. A nil scope contains all dashboards.
+func (s *migrationScope) contains(dash *dashboard) bool {
+	if s == nil {
+		return true
+	}
+	if _, ok := s.dashboardUIDs[dash.Uid]; ok {
+		return true
+	}
+	if _, ok := s.folderIDs[dash.FolderId]; ok {
+		return true
+	}
+	_, ok := s.taggedDashboardIDs[dash.Id]
+	return ok
+}
+
+// scopeFilters returns the filters of the scope configured in the upgrade settings, such as "dashboard_uid:abc", or nil
+// if the migration is not restricted. They are recorded in the migration state of the migrated organizations, so that
+// the alerts of the dashboards added to the scope are migrated by the incremental migration.
+func scopeFilters(cfg setting.UnifiedAlertingUpgradeSettings) []string {
+	var filters []string
+	for _, uid := range cfg.ScopeDashboardUIDs {
+		filters = append(filters, "dashboard_uid:"+uid)
+	}
+	for _, tag := range cfg.ScopeDashboardTags {
+		filters = append(filters, "dashboard_tag:"+tag)
+	}
+	for _, id := range cfg.ScopeFolderIDs {
+		filters = append(filters, "folder_id:"+strconv.FormatInt(id, 10))
+	}
+	return filters
+}
+
+// mergeMigratedScope adds the filters to the scope recorded in the migration state of an organization. An empty scope
+// means that the alerts of all dashboards were migrated, as do nil filters.
+func mergeMigratedScope(recorded string, filters []string) (string, error) {
+	if recorded == "" || len(filters) == 0 {
+		return "", nil
+	}
+	var merged []string
+	if err := json.Unmarshal([]byte(recorded), &merged); err != nil {
+		return "", fmt.Errorf("failed to parse migrated scope: %w", err)
+	}
+	seen := make(map[string]struct{}, len(merged))
+	for _, f := range merged {
+		seen[f] = struct{}{}
+	}
+	for _, f := range filters {
+		if _, ok := seen[f]; !ok {
+			seen[f] = struct{}{}
+			merged = append(merged, f)
+		}
+	}
+	return encodeMigratedScope(merged)
+}
+
+// encodeMigratedScope returns the scope recorded in the migration state of an organization migrated with the filters.
+func encodeMigratedScope(filters []string) (string, error) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+	sorted := append([]string(nil), filters...)
+	sort.Strings(sorted)
+	b, err := json.Marshal(sorted)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// scopeMigrated returns true if every migrated organization, or the organization if orgID is not 0, was migrated with
+// a scope that includes the filters. It is false if the scope of the migration was widened or removed since then.
+func scopeMigrated(sess *xorm.Session, orgID int64, filters []string) (bool, error) {
+	exists, err := sess.IsTableExist("alert_migration_org_state")
+	if err != nil || !exists {
+		return true, err
+	}
+	cond, args := orgCondition("org_id", orgID)
+	var states []alertMigrationOrgState
+	if err := sess.Where("migrated = ?", true).And(cond, args...).Find(&states); err != nil {
+		return false, fmt.Errorf("failed to read migrated scopes: %w", err)
+	}
+	for _, state := range states {
+		merged, err := mergeMigratedScope(state.Scope, filters)
+		if err != nil {
+			return false, fmt.Errorf("organization %d: %w", state.OrgID, err)
+		}
+		if merged != state.Scope {
+			return false, nil
+		}
+	}
+	return true, nil
+}
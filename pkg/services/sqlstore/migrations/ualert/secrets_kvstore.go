@@ -0,0 +1,119 @@
+package ualert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+// IntegrationSecretType is the type of the items of the secrets kvstore that hold the secure settings of a migrated
+// integration, as a JSON object. Their namespace is the UID of the integration.
+const IntegrationSecretType = "alerting-integration"
+
+// secretsKVReferencePrefix prefixes the secure settings of the integrations that reference the secrets kvstore item
+// holding their value, followed by the organization ID, the UID of the integration and the key of the secure setting
+// separated by colons.
+const secretsKVReferencePrefix = "$secretskv:"
+
+// StoreSecretsKVNamespace and StoreSecretsKVKey identify the kv_store entries with which the migration requests to
+// store the secure settings of the migrated integrations of an organization in the secrets kvstore. The migration
+// cannot use the secrets service nor the secrets kvstore, so the secrets are stored by unified alerting when it starts.
+const (
+	StoreSecretsKVNamespace = "ngalert.migration.secrets_kvstore"
+	StoreSecretsKVKey       = "store_secrets"
+)
+
+// StoreSecretsRequest is the value of the kv_store entry that requests to store the secure settings of the migrated
+// integrations of an organization in the secrets kvstore.
+type StoreSecretsRequest struct {
+	// UIDs are the migrated integrations that have secure settings.
+	UIDs []string `json:"uids"`
+	// KeepCopy keeps the encrypted secure settings in the configuration, otherwise they are replaced with references to
+	// the secrets kvstore.
+	KeepCopy bool `json:"keepCopy"`
+}
+
+// SecretsKVReference returns the reference to the secure setting of the integration in the secrets kvstore, which
+// replaces its value in the configuration.
+func SecretsKVReference(orgID int64, uid, key string) []byte {
+	return []byte(fmt.Sprintf("%s%d:%s:%s", secretsKVReferencePrefix, orgID, uid, key))
+}
+
+// ParseSecretsKVReference returns the organization ID, the UID of the integration and the key of a secure setting that
+// references the secrets kvstore, and false if the secure setting is not a reference.
+func ParseSecretsKVReference(value []byte) (int64, string, string, bool) {
+	if !bytes.HasPrefix(value, []byte(secretsKVReferencePrefix)) {
+		return 0, "", "", false
+	}
+	orgStr, rest, ok := strings.Cut(string(value[len(secretsKVReferencePrefix):]), ":")
+	if !ok {
+		return 0, "", "", false
+	}
+	sep := strings.LastIndex(rest, ":")
+	if sep <= 0 || sep == len(rest)-1 {
+		return 0, "", "", false
+	}
+	orgID, err := strconv.ParseInt(orgStr, 10, 64)
+	if err != nil {
+		return 0, "", "", false
+	}
+	return orgID, rest[:sep], rest[sep+1:], true
+}
+
+// requestStoreSecrets writes the kv_store entry that requests to store the secure settings of the integrations of the
+// configuration in the secrets kvstore, keeping a copy in the configuration if keepCopy is set.
+func (m *migration) requestStoreSecrets(orgID int64, amConfig *PostableUserConfig, keepCopy bool) error {
+	exists, err := m.sess.IsTableExist("kv_store")
+	if err != nil || !exists {
+		return err
+	}
+
+	request := StoreSecretsRequest{KeepCopy: keepCopy}
+	for _, r := range amConfig.AlertmanagerConfig.Receivers {
+		for _, gr := range r.GrafanaManagedReceivers {
+			if len(gr.SecureSettings) > 0 {
+				request.UIDs = append(request.UIDs, gr.UID)
+			}
+		}
+	}
+	if len(request.UIDs) == 0 {
+		return nil
+	}
+	sort.Strings(request.UIDs)
+
+	b, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if err := setKVStoreValue(m.sess, m.mg.Dialect, orgID, StoreSecretsKVNamespace, StoreSecretsKVKey, string(b)); err != nil {
+		return fmt.Errorf("failed to request storing the secrets of the contact points in the secrets kvstore: %w", err)
+	}
+	return nil
+}
+
+// deleteMigratedSecrets deletes the secrets of the integrations that unified alerting stored in the secrets kvstore
+// for the organization, or for all organizations if orgID is 0, and the pending requests to store them.
+func deleteMigratedSecrets(sess *xorm.Session, orgID int64) error {
+	cond, args := orgCondition("org_id", orgID)
+	exists, err := sess.IsTableExist("kv_store")
+	if err != nil {
+		return err
+	}
+	if exists {
+		if _, err := sess.Exec(append([]any{"DELETE FROM kv_store WHERE namespace = ? AND " + cond, StoreSecretsKVNamespace}, args...)...); err != nil {
+			return err
+		}
+	}
+
+	exists, err = sess.IsTableExist("secrets")
+	if err != nil || !exists {
+		return err
+	}
+	_, err = sess.Exec(append([]any{"DELETE FROM secrets WHERE type = ? AND " + cond, IntegrationSecretType}, args...)...)
+	return err
+}
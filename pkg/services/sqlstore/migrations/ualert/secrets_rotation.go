@@ -0,0 +1,30 @@
+package ualert
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// RotateSecretsKVNamespace and RotateSecretsKVKey identify the kv_store entry with which the migration requests the
+// rotation of the data key of the migrated contact point secrets. The migration cannot use the secrets service, so the
+// rotation is done by unified alerting when it starts.
+const (
+	RotateSecretsKVNamespace = "ngalert.migration"
+	RotateSecretsKVKey       = "rotate_secrets_data_key"
+)
+
+// requestSecretsRotation writes the kv_store entry that requests the rotation of the migrated contact point secrets.
+func requestSecretsRotation(sess *xorm.Session, dialect migrator.Dialect) error {
+	exists, err := sess.IsTableExist("kv_store")
+	if err != nil || !exists {
+		return err
+	}
+
+	if err := setKVStoreValue(sess, dialect, 0, RotateSecretsKVNamespace, RotateSecretsKVKey, "true"); err != nil {
+		return fmt.Errorf("failed to request secrets rotation: %w", err)
+	}
+	return nil
+}
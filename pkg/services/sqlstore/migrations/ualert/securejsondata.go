@@ -1,6 +1,7 @@
 package ualert
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -44,6 +45,20 @@ func (s SecureJsonData) Decrypt() map[string]string {
 	return decrypted
 }
 
+// tryDecrypt is like Decrypt but returns an error instead of exiting when a value cannot be decrypted.
+func (s SecureJsonData) tryDecrypt() (map[string]string, error) {
+	decrypted := make(map[string]string)
+	for key, data := range s {
+		decryptedData, err := util.Decrypt(data, setting.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secure setting %q: %w", key, err)
+		}
+
+		decrypted[key] = string(decryptedData)
+	}
+	return decrypted, nil
+}
+
 // GetEncryptedJsonData returns map where all keys are encrypted.
 func GetEncryptedJsonData(sjd map[string]string) SecureJsonData {
 	encrypted := make(SecureJsonData)
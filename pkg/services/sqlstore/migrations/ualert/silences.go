@@ -2,6 +2,7 @@ package ualert
 
 import (
 	"bytes"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -19,6 +20,10 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
+// silencesKVKey is the key of the silences in the kv_store namespace of the Alertmanager, it should be the same as
+// 'silencesFilename' in pkg/services/ngalert/notifier/alertmanager.go.
+const silencesKVKey = "silences"
+
 const (
 	// Should be the same as 'NoDataAlertName' in pkg/services/schedule/compat.go.
 	NoDataAlertName = "DatasourceNoData"
@@ -62,6 +67,7 @@ func (m *migration) addErrorSilence(da dashAlert, rule *alertRule) error {
 		m.silences[da.OrgId] = make([]*pb.MeshSilence, 0)
 	}
 	m.silences[da.OrgId] = append(m.silences[da.OrgId], s)
+	m.audit.recordCreate(da.OrgId, auditResourceSilence, s.Silence.Id, da.Id, "")
 	return nil
 }
 
@@ -102,28 +108,67 @@ func (m *migration) addNoDataSilence(da dashAlert, rule *alertRule) error {
 		m.silences[da.OrgId] = make([]*pb.MeshSilence, 0)
 	}
 	m.silences[da.OrgId] = append(m.silences[da.OrgId], s)
+	m.audit.recordCreate(da.OrgId, auditResourceSilence, s.Silence.Id, da.Id, "")
 	return nil
 }
 
-func (m *migration) writeSilencesFile(orgID int64) error {
-	var buf bytes.Buffer
-	orgSilences, ok := m.silences[orgID]
-	if !ok {
+// writeSilences writes the silences of the organization to the silences file of its Alertmanager, or to the database
+// if the store_silences_in_database setting is enabled.
+func (m *migration) writeSilences(orgID int64) error {
+	if !m.upgradeCfg.StoreSilencesInDatabase {
+		return m.writeSilencesFile(orgID)
+	}
+
+	exists, err := m.sess.IsTableExist("kv_store")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		m.mg.Logger.Warn("Cannot store silences in the database before the kv_store table is created, writing the silences file", "orgId", orgID)
+		return m.writeSilencesFile(orgID)
+	}
+	return m.writeSilencesKV(orgID)
+}
+
+// writeSilencesKV stores the silences of the organization in the kv_store table in the format of the Alertmanager file
+// store, which writes them to the silences file of every instance when the Alertmanager of the organization starts.
+func (m *migration) writeSilencesKV(orgID int64) error {
+	if _, ok := m.silences[orgID]; !ok {
 		return nil
 	}
+	b, err := m.encodeSilences(orgID)
+	if err != nil {
+		return err
+	}
+	return setKVStoreValue(m.sess, m.mg.Dialect, orgID, KV_NAMESPACE, silencesKVKey, base64.StdEncoding.EncodeToString(b))
+}
 
-	for _, e := range orgSilences {
+// encodeSilences returns the silences of the organization as length-delimited protobuf messages.
+func (m *migration) encodeSilences(orgID int64) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, e := range m.silences[orgID] {
 		if _, err := pbutil.WriteDelimited(&buf, e); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	return buf.Bytes(), nil
+}
+
+func (m *migration) writeSilencesFile(orgID int64) error {
+	if _, ok := m.silences[orgID]; !ok {
+		return nil
+	}
+	b, err := m.encodeSilences(orgID)
+	if err != nil {
+		return err
+	}
 
 	f, err := openReplace(silencesFileNameForOrg(m.mg, orgID))
 	if err != nil {
 		return err
 	}
 
-	if _, err := io.Copy(f, bytes.NewReader(buf.Bytes())); err != nil {
+	if _, err := io.Copy(f, bytes.NewReader(b)); err != nil {
 		return err
 	}
 
@@ -0,0 +1,58 @@
+package ualert
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Private annotations that identify the alert rules created by the migration, in addition to the __alertId__
+// annotation that holds the ID of the legacy alert.
+const (
+	// MigrationVersionAnnotation is the version of Grafana that migrated the alert rule.
+	MigrationVersionAnnotation = "__migration_version__"
+	// MigrationRunIDAnnotation is the ID of the run of the migration that created the alert rule.
+	MigrationRunIDAnnotation = "__migration_run_id__"
+	// MigrationTimeAnnotation is the time the migration that created the alert rule started, in RFC 3339 format.
+	MigrationTimeAnnotation = "__migration_time__"
+)
+
+// migrationStamp identifies a run of the migration on the resources it creates. Alert rules carry it in annotations and
+// folders in the "migration" key of their JSON model. Contact points cannot carry metadata, they are identified by the
+// run ID of their entry in the alert_migration_audit table.
+type migrationStamp struct {
+	Version string
+	RunID   string
+	Time    time.Time
+}
+
+func newMigrationStamp(started time.Time) migrationStamp {
+	return migrationStamp{
+		Version: setting.BuildVersion,
+		RunID:   util.GenerateShortUID(),
+		Time:    started,
+	}
+}
+
+// annotate adds the stamp to the annotations of an alert rule. It is a no-op on the zero stamp.
+func (s migrationStamp) annotate(annotations map[string]string) {
+	if s.RunID == "" {
+		return
+	}
+	annotations[MigrationVersionAnnotation] = s.Version
+	annotations[MigrationRunIDAnnotation] = s.RunID
+	annotations[MigrationTimeAnnotation] = s.Time.Format(time.RFC3339)
+}
+
+// model returns the stamp as stored in the JSON model of a folder, or nil for the zero stamp.
+func (s migrationStamp) model() map[string]any {
+	if s.RunID == "" {
+		return nil
+	}
+	return map[string]any{
+		"version": s.Version,
+		"runId":   s.RunID,
+		"time":    s.Time.Format(time.RFC3339),
+	}
+}
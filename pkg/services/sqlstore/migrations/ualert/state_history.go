@@ -0,0 +1,593 @@
+package ualert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"xorm.io/xorm"
+
+	legacymodels "github.com/grafana/grafana/pkg/services/alerting/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// backfilledAnnotationType is the type of the annotations that the migration writes to the state history of the
+// migrated alert rules, so that they are removed when the migration is reverted.
+const backfilledAnnotationType = "alert-migration"
+
+// Backends of [unified_alerting.state_history] that the state history is backfilled to.
+const (
+	stateHistoryBackendAnnotations = "annotations"
+	stateHistoryBackendLoki        = "loki"
+	stateHistoryBackendMultiple    = "multiple"
+)
+
+// lokiPushTimeout bounds the requests that read and push the backfilled state history of an organization in Loki.
+const lokiPushTimeout = 30 * time.Second
+
+// legacyAnnotation is a row of the annotation table that records a state change of a legacy alert.
+type legacyAnnotation struct {
+	ID          int64  `xorm:"id"`
+	AlertID     int64  `xorm:"alert_id"`
+	DashboardID int64  `xorm:"dashboard_id"`
+	PanelID     int64  `xorm:"panel_id"`
+	PrevState   string `xorm:"prev_state"`
+	NewState    string `xorm:"new_state"`
+	Epoch       int64  `xorm:"epoch"`
+	Data        string `xorm:"data"`
+}
+
+// backfilledAnnotation is a row of the annotation table written by the annotations backend of the state history.
+type backfilledAnnotation struct {
+	ID          int64  `xorm:"pk autoincr 'id'"`
+	OrgID       int64  `xorm:"org_id"`
+	AlertID     int64  `xorm:"alert_id"`
+	DashboardID int64  `xorm:"dashboard_id"`
+	PanelID     int64  `xorm:"panel_id"`
+	Type        string `xorm:"type"`
+	Title       string `xorm:"title"`
+	Text        string `xorm:"text"`
+	PrevState   string `xorm:"prev_state"`
+	NewState    string `xorm:"new_state"`
+	Data        string `xorm:"data"`
+	Epoch       int64  `xorm:"epoch"`
+	EpochEnd    int64  `xorm:"epoch_end"`
+	Created     int64  `xorm:"created"`
+	Updated     int64  `xorm:"updated"`
+}
+
+func (a backfilledAnnotation) TableName() string {
+	return "annotation"
+}
+
+// legacyAnnotationData is the data of the annotation of a legacy alert state change.
+type legacyAnnotationData struct {
+	EvalMatches []struct {
+		Metric string   `json:"metric"`
+		Value  *float64 `json:"value"`
+	} `json:"evalMatches"`
+	Error  string `json:"error"`
+	NoData bool   `json:"noData"`
+}
+
+// stateHistoryKey identifies a state history entry by the UID of its alert rule and its time in milliseconds.
+type stateHistoryKey struct {
+	ruleUID string
+	epoch   int64
+}
+
+// stateHistoryEntry is a state change of a migrated alert rule converted from the annotation of the legacy alert.
+type stateHistoryEntry struct {
+	rule       *alertRule
+	annotation legacyAnnotation
+	previous   string
+	current    string
+	data       legacyAnnotationData
+}
+
+// values returns the values of the series that matched the legacy alert condition.
+func (e stateHistoryEntry) values() map[string]float64 {
+	values := make(map[string]float64, len(e.data.EvalMatches))
+	for _, match := range e.data.EvalMatches {
+		if match.Value != nil {
+			values[match.Metric] = *match.Value
+		}
+	}
+	return values
+}
+
+// labels returns the labels of the alert instance of the migrated alert rule, without the private ones.
+func (e stateHistoryEntry) labels() map[string]string {
+	labels := map[string]string{model.AlertNameLabel: e.rule.Title}
+	for k, v := range e.rule.Labels {
+		if !strings.HasPrefix(k, "__") || !strings.HasSuffix(k, "__") {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// formatStateHistoryState converts a legacy alert state to the state of the state history, formatted with its reason
+// like the state history does, and returns false if the state has no equivalent. Legacy alerts keep their configured
+// state on errors and missing data, which unified alerting records as the reason.
+func formatStateHistoryState(legacyState string, data legacyAnnotationData) (string, bool) {
+	var state string
+	switch legacymodels.AlertStateType(legacyState) {
+	case legacymodels.AlertStateOK, legacymodels.AlertStateUnknown:
+		state = "Normal"
+	case legacymodels.AlertStatePaused:
+		return "Normal (" + ngmodels.StateReasonPaused + ")", true
+	case legacymodels.AlertStatePending:
+		state = "Pending"
+	case legacymodels.AlertStateAlerting:
+		state = "Alerting"
+	case legacymodels.AlertStateNoData:
+		return "NoData", true
+	default:
+		return "", false
+	}
+	switch {
+	case data.Error != "":
+		state += " (" + ngmodels.StateReasonError + ")"
+	case data.NoData:
+		state += " (NoData)"
+	}
+	return state, true
+}
+
+// backfillStateHistory converts the annotations of the state changes of the migrated legacy alerts whose mappings are not
+// written yet to state history entries of their alert rules, written to the backends. The entries are pushed to Loki
+// once the migration is committed, and those that cannot be are recorded in the migration status without failing the
+// migration.
+func (m *migration) backfillStateHistory(backends []string) error {
+	legacyIDs := make(map[int64]map[int64]string)
+	for _, e := range m.mappings.entries {
+		if e.LegacyType != mappingLegacyAlert {
+			continue
+		}
+		if legacyIDs[e.OrgID] == nil {
+			legacyIDs[e.OrgID] = make(map[int64]string)
+		}
+		legacyIDs[e.OrgID][e.LegacyID] = e.UID
+	}
+
+	orgIDs := make([]int64, 0, len(legacyIDs))
+	for orgID := range legacyIDs {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+
+	for _, orgID := range orgIDs {
+		entries, err := m.stateHistoryEntries(orgID, legacyIDs[orgID])
+		if err != nil {
+			return err
+		}
+		for _, backend := range backends {
+			switch backend {
+			case stateHistoryBackendAnnotations:
+				if err := m.writeStateHistoryAnnotations(orgID, entries); err != nil {
+					return err
+				}
+			case stateHistoryBackendLoki:
+				m.scheduleStateHistoryPush(orgID, entries)
+			}
+		}
+		m.mg.Logger.Info("State history backfilled", "orgID", orgID, "entries", len(entries), "backends", strings.Join(backends, ", "))
+	}
+	return nil
+}
+
+// stateHistoryBackends returns the backends of [unified_alerting.state_history] that are enabled and supported.
+func (m *migration) stateHistoryBackends() []string {
+	cfg := m.stateHistoryCfg
+	if !cfg.Enabled {
+		return nil
+	}
+	backends := []string{cfg.Backend}
+	if strings.EqualFold(cfg.Backend, stateHistoryBackendMultiple) {
+		backends = append([]string{cfg.MultiPrimary}, cfg.MultiSecondaries...)
+	}
+	result := make([]string, 0, len(backends))
+	for _, b := range backends {
+		b = strings.ToLower(b)
+		if b == stateHistoryBackendAnnotations || b == stateHistoryBackendLoki {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// stateHistoryEntries reads the annotations of the state changes of the legacy alerts of the organization and converts
+// them to state history entries of the alert rules they were migrated to, sorted by time. A rule has at most one entry
+// per timestamp, the first annotation of the legacy alert at that time.
+func (m *migration) stateHistoryEntries(orgID int64, legacyIDs map[int64]string) ([]stateHistoryEntry, error) {
+	uids := make([]any, 0, len(legacyIDs))
+	alertIDs := make([]any, 0, len(legacyIDs))
+	for legacyID, uid := range legacyIDs {
+		uids = append(uids, uid)
+		alertIDs = append(alertIDs, legacyID)
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	var rules []*alertRule
+	if err := m.sess.Where("org_id = ?", orgID).In("uid", uids...).Find(&rules); err != nil {
+		return nil, fmt.Errorf("failed to read migrated alert rules: %w", err)
+	}
+	rulesByUID := make(map[string]*alertRule, len(rules))
+	for _, r := range rules {
+		rulesByUID[r.UID] = r
+	}
+
+	var annotations []legacyAnnotation
+	err := m.sess.Table("annotation").
+		Where("org_id = ? AND type <> ?", orgID, backfilledAnnotationType).
+		In("alert_id", alertIDs...).
+		Cols("id", "alert_id", "dashboard_id", "panel_id", "prev_state", "new_state", "epoch", "data").
+		Asc("epoch", "id").
+		Find(&annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy alert annotations: %w", err)
+	}
+
+	entries := make([]stateHistoryEntry, 0, len(annotations))
+	seen := make(map[stateHistoryKey]struct{}, len(annotations))
+	for _, a := range annotations {
+		rule, ok := rulesByUID[legacyIDs[a.AlertID]]
+		if !ok {
+			continue
+		}
+		key := stateHistoryKey{ruleUID: rule.UID, epoch: a.Epoch}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		var data legacyAnnotationData
+		if a.Data != "" {
+			if err := json.Unmarshal([]byte(a.Data), &data); err != nil {
+				m.mg.Logger.Warn("Failed to parse the data of a legacy alert annotation, ignoring it", "annotationID", a.ID, "error", err)
+			}
+		}
+		current, ok := formatStateHistoryState(a.NewState, data)
+		if !ok {
+			continue
+		}
+		previous, ok := formatStateHistoryState(a.PrevState, legacyAnnotationData{})
+		if !ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		entries = append(entries, stateHistoryEntry{rule: rule, annotation: a, previous: previous, current: current, data: data})
+	}
+	return entries, nil
+}
+
+// writeStateHistoryAnnotations writes the entries as annotations of the alert rules, like the annotations backend of
+// the state history does, replacing those of a previous migration.
+func (m *migration) writeStateHistoryAnnotations(orgID int64, entries []stateHistoryEntry) error {
+	if _, err := m.sess.Exec("DELETE FROM annotation WHERE org_id = ? AND type = ?", orgID, backfilledAnnotationType); err != nil {
+		return fmt.Errorf("failed to delete previously backfilled state history: %w", err)
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	for _, e := range entries {
+		data := make(map[string]any, 1)
+		var value string
+		switch {
+		case e.data.Error != "":
+			data["error"] = e.data.Error
+			value = "Error"
+		case e.data.NoData || strings.HasPrefix(e.current, "NoData"):
+			data["noData"] = true
+			value = "No data"
+		default:
+			values := e.values()
+			data["values"] = values
+			pairs := make([]string, 0, len(values))
+			for _, k := range sortedKeys(values) {
+				pairs = append(pairs, fmt.Sprintf("%s=%f", k, values[k]))
+			}
+			value = strings.Join(pairs, ", ")
+		}
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		labels := e.labels()
+		pairs := make([]string, 0, len(labels))
+		for _, k := range sortedKeys(labels) {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", k, labels[k]))
+		}
+
+		_, err = m.sess.Insert(&backfilledAnnotation{
+			OrgID:       orgID,
+			AlertID:     e.rule.ID,
+			DashboardID: e.annotation.DashboardID,
+			PanelID:     e.annotation.PanelID,
+			Type:        backfilledAnnotationType,
+			Text:        fmt.Sprintf("%s {%s} - %s", e.rule.Title, strings.Join(pairs, ", "), value),
+			PrevState:   e.previous,
+			NewState:    e.current,
+			Data:        string(b),
+			Epoch:       e.annotation.Epoch,
+			EpochEnd:    e.annotation.Epoch,
+			Created:     now,
+			Updated:     now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write backfilled state history: %w", err)
+		}
+	}
+	return nil
+}
+
+// lokiStateHistoryEntry is a line of the Loki backend of the state history.
+type lokiStateHistoryEntry struct {
+	SchemaVersion  int                `json:"schemaVersion"`
+	Previous       string             `json:"previous"`
+	Current        string             `json:"current"`
+	Error          string             `json:"error,omitempty"`
+	Values         map[string]float64 `json:"values"`
+	Condition      string             `json:"condition"`
+	DashboardUID   string             `json:"dashboardUID"`
+	PanelID        int64              `json:"panelID"`
+	Fingerprint    string             `json:"fingerprint"`
+	RuleUID        string             `json:"ruleUID"`
+	InstanceLabels map[string]string  `json:"labels"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// scheduleStateHistoryPush pushes the entries of the organization to the Loki backend of the state history once the
+// transaction of the migration is committed, so that a rolled back migration is never pushed and Loki does not hold
+// the transaction open. A failed push is recorded in the migration state of the organization.
+func (m *migration) scheduleStateHistoryPush(orgID int64, entries []stateHistoryEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	m.mg.OnTransactionEnd(func(committed bool) {
+		if !committed {
+			return
+		}
+		if err := m.pushStateHistoryToLoki(orgID, entries); err != nil {
+			m.mg.Logger.Warn("Alert migration warning: failed to backfill the state history to Loki", "orgID", orgID, "error", err)
+			if err := appendOrgStateErrors(m.mg.DBEngine, orgID, []error{fmt.Errorf("state history not backfilled to Loki: %w", err)}); err != nil {
+				m.mg.Logger.Error("Alert migration error: failed to record the problems of the state history backfill", "orgID", orgID, "error", err)
+			}
+		}
+	})
+}
+
+// pushStateHistoryToLoki pushes the entries to the Loki backend of the state history, in one stream per rule group
+// like the Loki backend does. The entries whose rule already has an entry at the same time in Loki, pushed by a
+// previous migration, are left out, so that running the migration again does not duplicate them.
+func (m *migration) pushStateHistoryToLoki(orgID int64, entries []stateHistoryEntry) error {
+	existing, err := m.queryLokiStateHistory(orgID, entries)
+	if err != nil {
+		return fmt.Errorf("failed to query the state history in Loki: %w", err)
+	}
+	pending := make([]stateHistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := existing[stateHistoryKey{ruleUID: e.rule.UID, epoch: e.annotation.Epoch}]; !ok {
+			pending = append(pending, e)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	entries = pending
+
+	cfg := m.stateHistoryCfg
+	write := cfg.LokiWriteURL
+	if write == "" {
+		write = cfg.LokiRemoteURL
+	}
+	if write == "" {
+		return fmt.Errorf("either write path URL or remote Loki URL must be provided")
+	}
+	writeURL, err := url.Parse(write)
+	if err != nil {
+		return fmt.Errorf("failed to parse loki remote write URL: %w", err)
+	}
+
+	streams := make(map[[2]string]*lokiStream)
+	keys := make([][2]string, 0)
+	for _, e := range entries {
+		key := [2]string{e.rule.NamespaceUID, e.rule.RuleGroup}
+		s, ok := streams[key]
+		if !ok {
+			labels := make(map[string]string, len(cfg.ExternalLabels)+4)
+			for k, v := range cfg.ExternalLabels {
+				labels[k] = v
+			}
+			labels["from"] = "state-history"
+			labels["orgID"] = fmt.Sprint(e.rule.OrgID)
+			labels["group"] = e.rule.RuleGroup
+			labels["folderUID"] = e.rule.NamespaceUID
+			s = &lokiStream{Stream: labels}
+			streams[key] = s
+			keys = append(keys, key)
+		}
+
+		labels := e.labels()
+		entry := lokiStateHistoryEntry{
+			SchemaVersion:  1,
+			Previous:       e.previous,
+			Current:        e.current,
+			Error:          e.data.Error,
+			Values:         e.values(),
+			Condition:      e.rule.Condition,
+			DashboardUID:   e.rule.Annotations[ngmodels.DashboardUIDAnnotation],
+			PanelID:        e.annotation.PanelID,
+			Fingerprint:    fmt.Sprintf("%016x", model.LabelsToSignature(labels)),
+			RuleUID:        e.rule.UID,
+			InstanceLabels: labels,
+		}
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		ts := time.UnixMilli(e.annotation.Epoch).UnixNano()
+		s.Values = append(s.Values, [2]string{fmt.Sprint(ts), string(b)})
+	}
+
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range keys {
+		body.Streams = append(body.Streams, streams[key])
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, writeURL.JoinPath("/loki/api/v1/push").String(), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create Loki request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = m.doLokiRequest(req)
+	return err
+}
+
+// lokiQueryLimit is the number of entries that a query of the state history in Loki returns at most. Longer ranges
+// are read in several queries.
+const lokiQueryLimit = 5000
+
+// queryLokiStateHistory returns the state history entries of the organization in Loki, of the rules of the entries
+// and between the first and the last entry.
+func (m *migration) queryLokiStateHistory(orgID int64, entries []stateHistoryEntry) (map[stateHistoryKey]struct{}, error) {
+	cfg := m.stateHistoryCfg
+	read := cfg.LokiReadURL
+	if read == "" {
+		read = cfg.LokiRemoteURL
+	}
+	if read == "" {
+		return nil, fmt.Errorf("either read path URL or remote Loki URL must be provided")
+	}
+	readURL, err := url.Parse(read)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse loki remote read URL: %w", err)
+	}
+
+	rules := make(map[string]struct{})
+	start, end := entries[0].annotation.Epoch, entries[0].annotation.Epoch
+	for _, e := range entries {
+		rules[e.rule.UID] = struct{}{}
+		if e.annotation.Epoch < start {
+			start = e.annotation.Epoch
+		}
+		if e.annotation.Epoch > end {
+			end = e.annotation.Epoch
+		}
+	}
+
+	existing := make(map[stateHistoryKey]struct{})
+	from, to := time.UnixMilli(start).UnixNano(), time.UnixMilli(end).UnixNano()+1
+	for from < to {
+		values := url.Values{}
+		values.Set("query", fmt.Sprintf(`{from="state-history",orgID=%q}`, fmt.Sprint(orgID)))
+		values.Set("start", fmt.Sprint(from))
+		values.Set("end", fmt.Sprint(to))
+		values.Set("limit", fmt.Sprint(lokiQueryLimit))
+		values.Set("direction", "forward")
+		queryURL := readURL.JoinPath("/loki/api/v1/query_range")
+		queryURL.RawQuery = values.Encode()
+		req, err := http.NewRequest(http.MethodGet, queryURL.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Loki request: %w", err)
+		}
+		body, err := m.doLokiRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var res struct {
+			Data struct {
+				Result []struct {
+					Values [][2]string `json:"values"`
+				} `json:"result"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			return nil, fmt.Errorf("failed to parse Loki response: %w", err)
+		}
+		var count int
+		last := from
+		for _, stream := range res.Data.Result {
+			for _, v := range stream.Values {
+				count++
+				ts, err := strconv.ParseInt(v[0], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse timestamp of Loki entry: %w", err)
+				}
+				if ts > last {
+					last = ts
+				}
+				var line struct {
+					RuleUID string `json:"ruleUID"`
+				}
+				if err := json.Unmarshal([]byte(v[1]), &line); err != nil {
+					continue
+				}
+				if _, ok := rules[line.RuleUID]; ok {
+					existing[stateHistoryKey{ruleUID: line.RuleUID, epoch: time.Unix(0, ts).UnixMilli()}] = struct{}{}
+				}
+			}
+		}
+		if count < lokiQueryLimit {
+			break
+		}
+		// The entries at the last time may continue in the next query, reading them again is harmless.
+		if last == from {
+			last++
+		}
+		from = last
+	}
+	return existing, nil
+}
+
+// doLokiRequest sends the request to Loki with the authentication and tenant of [unified_alerting.state_history] and
+// returns the body of the response.
+func (m *migration) doLokiRequest(req *http.Request) ([]byte, error) {
+	cfg := m.stateHistoryCfg
+	if cfg.LokiBasicAuthUsername != "" || cfg.LokiBasicAuthPassword != "" {
+		req.SetBasicAuth(cfg.LokiBasicAuthUsername, cfg.LokiBasicAuthPassword)
+	}
+	if cfg.LokiTenantID != "" {
+		req.Header.Add("X-Scope-OrgID", cfg.LokiTenantID)
+	}
+
+	client := &http.Client{Timeout: lokiPushTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("received a non-200 response from loki, status: %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// deleteBackfilledStateHistory deletes the annotations that the migration wrote to the state history of the
+// organization, or of all organizations if orgID is 0.
+func deleteBackfilledStateHistory(sess *xorm.Session, orgID int64) error {
+	cond, args := orgCondition("org_id", orgID)
+	_, err := sess.Exec(append([]any{"DELETE FROM annotation WHERE type = ? AND " + cond, backfilledAnnotationType}, args...)...)
+	return err
+}
@@ -24,9 +24,83 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("add last_applied column to alert_configuration_history", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_configuration_history"}, &migrator.Column{
 		Name: "last_applied", Type: migrator.DB_Int, Nullable: false, Default: "0",
 	}))
+
+	addAlertMigrationAuditMigrations(mg)
+
+	addAlertMigrationMappingMigrations(mg)
+
+	addAlertMigrationOrgStateMigrations(mg)
+
+	mg.AddMigration("add shadow column to alert_migration_org_state", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_migration_org_state"}, &migrator.Column{
+		Name: "shadow", Type: migrator.DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add run_id column to alert_migration_audit", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_migration_audit"}, &migrator.Column{
+		Name: "run_id", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: true,
+	}))
+
+	addAlertMigrationBackupMigrations(mg)
+
+	mg.AddMigration("add contact_point_tests column to alert_migration_org_state", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_migration_org_state"}, &migrator.Column{
+		Name: "contact_point_tests", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add scope column to alert_migration_org_state", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_migration_org_state"}, &migrator.Column{
+		Name: "scope", Type: migrator.DB_Text, Nullable: true,
+	}))
 	// End of migration log, add new migrations above this line.
 }
 
+// addAlertMigrationAuditMigrations creates the table that records the resources created and deleted by the dashboard alert migration.
+func addAlertMigrationAuditMigrations(mg *migrator.Migrator) {
+	auditTable := migrator.Table{
+		Name: "alert_migration_audit",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "action", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "resource_type", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "resource_uid", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "legacy_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "legacy_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "resource_type", "resource_uid"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "legacy_id"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_migration_audit table", migrator.NewAddTableMigration(auditTable))
+	mg.AddMigration("add index on org_id, resource_type and resource_uid to alert_migration_audit table", migrator.NewAddIndexMigration(auditTable, auditTable.Indices[0]))
+	mg.AddMigration("add index on org_id and legacy_id to alert_migration_audit table", migrator.NewAddIndexMigration(auditTable, auditTable.Indices[1]))
+}
+
+// addAlertMigrationMappingMigrations creates the table that maps legacy alert and notification channel IDs to the
+// alert rules and contact points created by the dashboard alert migration.
+func addAlertMigrationMappingMigrations(mg *migrator.Migrator) {
+	mappingTable := migrator.Table{
+		Name: "alert_migration_mapping",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "legacy_type", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "legacy_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "legacy_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "legacy_type", "legacy_id"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"org_id", "legacy_type", "legacy_uid"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_migration_mapping table", migrator.NewAddTableMigration(mappingTable))
+	mg.AddMigration("add unique index on org_id, legacy_type and legacy_id to alert_migration_mapping table", migrator.NewAddIndexMigration(mappingTable, mappingTable.Indices[0]))
+	mg.AddMigration("add index on org_id, legacy_type and legacy_uid to alert_migration_mapping table", migrator.NewAddIndexMigration(mappingTable, mappingTable.Indices[1]))
+}
+
 // historicalTableMigrations contains those migrations that existed prior to creating the improved messaging around migration immutability.
 func historicalTableMigrations(mg *migrator.Migrator) {
 	// DO NOT EDIT
@@ -568,3 +642,61 @@ func (c extractAlertmanagerConfigurationHistory) Exec(sess *xorm.Session, migrat
 	}
 	return nil
 }
+
+// addAlertMigrationBackupMigrations creates the tables that keep the unified alerting data of organizations removed
+// before a re-migration, so that it can be restored. A backup is a row of alert_migration_backup and a row of
+// alert_migration_backup_resource per resource.
+func addAlertMigrationBackupMigrations(mg *migrator.Migrator) {
+	backupTable := migrator.Table{
+		Name: "alert_migration_backup",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "data", Type: migrator.DB_MediumText, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_migration_backup table", migrator.NewAddTableMigration(backupTable))
+	mg.AddMigration("add index on org_id to alert_migration_backup table", migrator.NewAddIndexMigration(backupTable, backupTable.Indices[0]))
+
+	resourceTable := migrator.Table{
+		Name: "alert_migration_backup_resource",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "backup_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "resource_type", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "data", Type: migrator.DB_MediumText, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"backup_id"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_migration_backup_resource table", migrator.NewAddTableMigration(resourceTable))
+	mg.AddMigration("add index on backup_id to alert_migration_backup_resource table", migrator.NewAddIndexMigration(resourceTable, resourceTable.Indices[0]))
+}
+
+// addAlertMigrationOrgStateMigrations creates the table that records the state of the dashboard alert migration per organization.
+func addAlertMigrationOrgStateMigrations(mg *migrator.Migrator) {
+	stateTable := migrator.Table{
+		Name: "alert_migration_org_state",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "migrated", Type: migrator.DB_Bool, Nullable: false},
+			{Name: "grafana_version", Type: migrator.DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "errors", Type: migrator.DB_Text, Nullable: true},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_migration_org_state table", migrator.NewAddTableMigration(stateTable))
+	mg.AddMigration("add unique index on org_id to alert_migration_org_state table", migrator.NewAddIndexMigration(stateTable, stateTable.Indices[0]))
+}
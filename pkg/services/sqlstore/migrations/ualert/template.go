@@ -15,10 +15,12 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state/template"
 )
 
-// Token contains either a string literal or a variable.
+// Token contains either a string literal, a variable, or template syntax that replaced a variable or a macro.
 type Token struct {
 	Literal  string
 	Variable string
+	// Template is copied into the migrated template as is.
+	Template string
 }
 
 func (t Token) IsLiteral() bool {
@@ -29,20 +31,32 @@ func (t Token) IsVariable() bool {
 	return t.Variable != ""
 }
 
+func (t Token) IsTemplate() bool {
+	return t.Template != ""
+}
+
 func (t Token) String() string {
 	if t.IsLiteral() {
 		return t.Literal
 	} else if t.IsVariable() {
 		return t.Variable
+	} else if t.IsTemplate() {
+		return t.Template
 	} else {
 		panic("empty token")
 	}
 }
 
 func MigrateTmpl(l log.Logger, oldTmpl string) string {
+	return migrateTmpl(l, oldTmpl, nil)
+}
+
+// migrateTmpl is MigrateTmpl with the variables and macros of the mapping replaced by their templates.
+func migrateTmpl(l log.Logger, oldTmpl string, mapping *templateMapping) string {
 	var newTmpl string
 
 	tokens := tokenizeTmpl(l, oldTmpl)
+	tokens = mapping.apply(tokens)
 	tokens = escapeLiterals(tokens)
 
 	if anyVariableToken(tokens) {
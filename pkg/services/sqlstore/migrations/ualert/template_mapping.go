@@ -0,0 +1,162 @@
+package ualert
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateMappingFile is the file of the template_mapping_file setting. It maps variables and macros of legacy alert
+// messages to unified alerting template syntax, for all organizations and per organization. For example:
+//
+//	variables:
+//	  host: '{{ $labels.instance }}'
+//	macros:
+//	  '[[team]]': '{{ $labels.team }}'
+//	orgs:
+//	  2:
+//	    variables:
+//	      host: '{{ $labels.host }}'
+type templateMappingFile struct {
+	templateMapping `yaml:",inline"`
+	Orgs            map[int64]templateMapping `yaml:"orgs"`
+}
+
+// templateMapping maps variables and macros of legacy alert messages to unified alerting template syntax.
+type templateMapping struct {
+	// Variables maps the names of ${name} variables to the templates that replace them.
+	Variables map[string]string `yaml:"variables"`
+	// Macros maps strings in the text of legacy messages to the templates that replace them.
+	Macros map[string]string `yaml:"macros"`
+}
+
+// loadTemplateMappingFile reads and validates the mapping file at path.
+func loadTemplateMappingFile(path string) (*templateMappingFile, error) {
+	// nolint:gosec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template mapping file: %w", err)
+	}
+
+	var f templateMappingFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse template mapping file %s: %w", path, err)
+	}
+	for _, m := range append([]templateMapping{f.templateMapping}, orgMappings(f.Orgs)...) {
+		if _, ok := m.Macros[""]; ok {
+			return nil, fmt.Errorf("invalid template mapping file %s: macros must not be empty", path)
+		}
+	}
+	return &f, nil
+}
+
+func orgMappings(orgs map[int64]templateMapping) []templateMapping {
+	result := make([]templateMapping, 0, len(orgs))
+	for _, m := range orgs {
+		result = append(result, m)
+	}
+	return result
+}
+
+// forOrg returns the mapping of the organization, whose entries override those for all organizations.
+// It returns nil if f is nil.
+func (f *templateMappingFile) forOrg(orgID int64) *templateMapping {
+	if f == nil {
+		return nil
+	}
+	result := &templateMapping{
+		Variables: make(map[string]string, len(f.Variables)),
+		Macros:    make(map[string]string, len(f.Macros)),
+	}
+	for _, m := range []templateMapping{f.templateMapping, f.Orgs[orgID]} {
+		for k, v := range m.Variables {
+			result.Variables[k] = v
+		}
+		for k, v := range m.Macros {
+			result.Macros[k] = v
+		}
+	}
+	return result
+}
+
+// apply replaces the mapped variables and the macros in the literals of the tokens with template tokens.
+// It is a no-op on a nil templateMapping.
+func (m *templateMapping) apply(tokens []Token) []Token {
+	if m == nil {
+		return tokens
+	}
+
+	// Longer macros are replaced first so that a macro can contain another one.
+	macros := make([]string, 0, len(m.Macros))
+	for k := range m.Macros {
+		macros = append(macros, k)
+	}
+	sort.Slice(macros, func(i, j int) bool {
+		if len(macros[i]) != len(macros[j]) {
+			return len(macros[i]) > len(macros[j])
+		}
+		return macros[i] < macros[j]
+	})
+
+	result := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		switch {
+		case token.IsVariable():
+			if tmpl, ok := m.Variables[token.Variable]; ok {
+				token = Token{Template: tmpl}
+			}
+			result = append(result, token)
+		case token.IsLiteral() && len(macros) > 0:
+			result = append(result, replaceMacros(token.Literal, macros, m.Macros)...)
+		default:
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// replaceMacros splits the literal into literal tokens and template tokens for the macros it contains.
+func replaceMacros(literal string, macros []string, templates map[string]string) []Token {
+	var result []Token
+	for literal != "" {
+		pos, macro := -1, ""
+		for _, k := range macros {
+			if i := strings.Index(literal, k); i >= 0 && (pos < 0 || i < pos) {
+				pos, macro = i, k
+			}
+		}
+		if pos < 0 {
+			result = append(result, Token{Literal: literal})
+			break
+		}
+		if pos > 0 {
+			result = append(result, Token{Literal: literal[:pos]})
+		}
+		if tmpl := templates[macro]; tmpl != "" {
+			result = append(result, Token{Template: tmpl})
+		}
+		literal = literal[pos+len(macro):]
+	}
+	return result
+}
+
+// unmappedVariables returns the sorted names of the variables of the legacy message that the mapping does not replace.
+func (m *templateMapping) unmappedVariables(tokens []Token) []string {
+	seen := make(map[string]struct{})
+	var result []string
+	for _, token := range m.apply(tokens) {
+		if !token.IsVariable() {
+			continue
+		}
+		if _, ok := seen[token.Variable]; ok {
+			continue
+		}
+		seen[token.Variable] = struct{}{}
+		result = append(result, token.Variable)
+	}
+	sort.Strings(result)
+	return result
+}
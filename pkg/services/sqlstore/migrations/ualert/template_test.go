@@ -2,10 +2,13 @@ package ualert
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTokenString(t *testing.T) {
@@ -313,6 +316,81 @@ func TestMigrateTmpl(t *testing.T) {
 	}
 }
 
+func TestMigrateTmplWithMapping(t *testing.T) {
+	file := &templateMappingFile{
+		templateMapping: templateMapping{
+			Variables: map[string]string{"host": "{{ $labels.instance }}"},
+			Macros:    map[string]string{"[[team]]": "{{ $labels.team }}", "[[team]]-oncall": "{{ $labels.team }} on call"},
+		},
+		Orgs: map[int64]templateMapping{
+			2: {Variables: map[string]string{"host": "{{ $labels.host }}"}},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		orgID    int64
+		input    string
+		expected string
+		unmapped []string
+	}{
+		{
+			name:     "mapped variable",
+			orgID:    1,
+			input:    "${host} is down",
+			expected: "{{ $labels.instance }} is down",
+		},
+		{
+			name:     "mapped variable of the organization",
+			orgID:    2,
+			input:    "${host} is down",
+			expected: "{{ $labels.host }} is down",
+		},
+		{
+			name:     "unmapped variable",
+			orgID:    1,
+			input:    "${host} is down in ${region}",
+			expected: withDeduplicateMap("{{ $labels.instance }} is down in {{$mergedLabels.region}}"),
+			unmapped: []string{"region"},
+		},
+		{
+			name:     "macros, longest first",
+			orgID:    1,
+			input:    "page [[team]]-oncall, cc [[team]]",
+			expected: "page {{ $labels.team }} on call, cc {{ $labels.team }}",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mapping := file.forOrg(tc.orgID)
+			assert.Equal(t, tc.expected, migrateTmpl(log.NewNopLogger(), tc.input, mapping))
+			assert.Equal(t, tc.unmapped, mapping.unmappedVariables(tokenizeTmpl(log.NewNopLogger(), tc.input)))
+		})
+	}
+}
+
+func TestLoadTemplateMappingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+variables:
+  host: '{{ $labels.instance }}'
+orgs:
+  2:
+    macros:
+      '[[team]]': '{{ $labels.team }}'
+`), 0600))
+
+	f, err := loadTemplateMappingFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "{{ $labels.instance }}"}, f.Variables)
+	assert.Equal(t, map[string]string{"[[team]]": "{{ $labels.team }}"}, f.Orgs[2].Macros)
+
+	require.NoError(t, os.WriteFile(path, []byte(`macros: {"": "x"}`), 0600))
+	_, err = loadTemplateMappingFile(path)
+	require.Error(t, err)
+}
+
 func withDeduplicateMap(input string) string {
 	// hardcode function name to fail tests if it changes
 	funcName := "mergeLabelValues"
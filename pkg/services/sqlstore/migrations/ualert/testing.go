@@ -23,3 +23,12 @@ func newTestMigration(t *testing.T) *migration {
 		silences: make(map[int64][]*silencepb.MeshSilence),
 	}
 }
+
+// SetDashAlertBatchSize changes the number of legacy alerts that are loaded at once for the duration of the test.
+func SetDashAlertBatchSize(t *testing.T, size int) {
+	t.Helper()
+
+	old := dashAlertBatchSize
+	dashAlertBatchSize = size
+	t.Cleanup(func() { dashAlertBatchSize = old })
+}
@@ -2,19 +2,25 @@ package ualert
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	alertingNotify "github.com/grafana/alerting/notify"
 	pb "github.com/prometheus/alertmanager/silence/silencepb"
 	"xorm.io/xorm"
 
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/setting"
@@ -74,11 +80,11 @@ func AddDashAlertMigration(mg *migrator.Migrator) {
 		if err != nil {
 			mg.Logger.Error("Alert migration error: could not clear alert migration for removing data", "error", err)
 		}
-		mg.AddMigration(migTitle, &migration{
-			// We deduplicate for case-insensitive matching in MySQL-compatible backend flavours because they use case-insensitive collation.
-			seenUIDs: uidSet{set: make(map[string]struct{}), caseInsensitive: mg.Dialect.SupportEngine()},
-			silences: make(map[int64][]*pb.MeshSilence),
-		})
+		mg.AddMigration(migTitle, newMigration(mg))
+	// If unified alerting is enabled and the upgrade migration has been run, new legacy alerts are synced, or those
+	// added to the scope of the migration since it ran
+	case mg.Cfg.UnifiedAlerting.IsEnabled():
+		addIncrementalSync(mg)
 	// If unified alerting is disabled and upgrade migration has been run
 	case !mg.Cfg.UnifiedAlerting.IsEnabled() && migrationRun:
 		// If legacy alerting is also disabled, there is nothing to do
@@ -229,23 +235,160 @@ type migration struct {
 	mg   *migrator.Migrator
 
 	seenUIDs uidSet
-	silences map[int64][]*pb.MeshSilence
+	// receiverUIDs are the UIDs of the contact points of the existing Alertmanager configurations, read by uidTaken
+	// when it is first called.
+	receiverUIDs map[string]struct{}
+	silences     map[int64][]*pb.MeshSilence
+
+	// upgradeCfg holds the options from the [unified_alerting.upgrade] section.
+	upgradeCfg setting.UnifiedAlertingUpgradeSettings
+	// stateHistoryCfg holds the options from the [unified_alerting.state_history] section, used to backfill the state
+	// history of the migrated alert rules.
+	stateHistoryCfg setting.UnifiedAlertingStateHistorySettings
+	// baseInterval is the base interval of the scheduler, the evaluation intervals of the migrated rules are multiples of it.
+	baseInterval time.Duration
+	// audit collects the resources created by the migration for the alert_migration_audit table.
+	audit *migrationAudit
+	// mappings collects the legacy IDs of the migrated alerts and channels for the alert_migration_mapping table.
+	mappings *migrationMappings
+	// orgStates collects the problems recorded per organization for the alert_migration_org_state table.
+	orgStates *migrationOrgStates
+	// migrated holds the resources of a previous migration that are updated in place when UpsertOnRemigration is enabled.
+	migrated migratedResources
+	// folderLabelDisabled is set if the grafana_folder label is disabled, in which case notification policies cannot
+	// match the folder of the alert rules.
+	folderLabelDisabled bool
+	// screenshotCfg is used to check whether the uploadImage setting of legacy notification channels can be honored.
+	screenshotCfg setting.UnifiedAlertingScreenshotSettings
+	// folderNameTmpl is the parsed folder_name_template setting, nil if not configured.
+	folderNameTmpl *template.Template
+	// ruleGroupNameTmpl is the parsed rule_group_name_template setting, nil if not configured.
+	ruleGroupNameTmpl *template.Template
+	// templateMappings is the loaded template_mapping_file setting, nil if not configured.
+	templateMappings *templateMappingFile
+	// dsUIDMappings is the loaded datasource_uid_mapping_file setting, nil if not configured.
+	dsUIDMappings *datasourceUIDMappingFile
+	upsertedRules map[*alertRule]struct{}
+	// orgID restricts the migration to a single organization, 0 migrates all organizations.
+	orgID int64
+	// scope restricts the migration to the alerts of some dashboards, nil migrates the alerts of all dashboards.
+	scope *migrationScope
+	// started is the time the migration started, used to detect organizations migrated by another instance meanwhile.
+	started time.Time
+	// stamp identifies this run of the migration on the resources it creates.
+	stamp migrationStamp
+	// conflicts are the existing unified alerting resources of the organization being migrated.
+	conflicts *orgConflicts
+	// secretsCompatibilityDisabled is set if the disableSecretsCompatibility feature toggle is enabled, in which case
+	// the secure settings stored in the secrets kvstore are not kept in the configuration.
+	secretsCompatibilityDisabled bool
+	// reports collects the summary of the migration of every organization sent to its admins, nil if none is sent.
+	reports *migrationReports
+	// events collects the lifecycle events of the migration published by unified alerting, nil if none is published.
+	events *migrationEvents
+	// incremental restricts the migration to the legacy alerts and notification channels added to the migrated
+	// organizations since their migration, see the incremental_sync setting.
+	incremental bool
+	// synced are the legacy alerts and notification channels that were migrated, nil unless the migration is incremental.
+	synced *syncedLegacyIDs
+}
+
+// newMigration creates the dashboard alert migration using the settings of the given migrator.
+func newMigration(mg *migrator.Migrator) *migration {
+	return &migration{
+		// We deduplicate for case-insensitive matching in MySQL-compatible backend flavours because they use case-insensitive collation.
+		seenUIDs:            uidSet{set: make(map[string]struct{}), caseInsensitive: mg.Dialect.SupportEngine(), attempts: mg.Cfg.UnifiedAlerting.Upgrade.UIDGenerationAttempts},
+		silences:            make(map[int64][]*pb.MeshSilence),
+		upgradeCfg:          mg.Cfg.UnifiedAlerting.Upgrade,
+		stateHistoryCfg:     mg.Cfg.UnifiedAlerting.StateHistory,
+		baseInterval:        mg.Cfg.UnifiedAlerting.BaseInterval,
+		screenshotCfg:       mg.Cfg.UnifiedAlerting.Screenshots,
+		audit:               &migrationAudit{},
+		mappings:            &migrationMappings{},
+		orgStates:           &migrationOrgStates{shadow: mg.Cfg.UnifiedAlerting.Upgrade.ShadowMode, scope: scopeFilters(mg.Cfg.UnifiedAlerting.Upgrade)},
+		upsertedRules:       make(map[*alertRule]struct{}),
+		folderLabelDisabled: mg.Cfg.UnifiedAlerting.ReservedLabels.IsReservedLabelDisabled(ngmodels.FolderTitleLabel),
+		secretsCompatibilityDisabled: mg.Cfg.IsFeatureToggleEnabled != nil &&
+			mg.Cfg.IsFeatureToggleEnabled(featuremgmt.FlagDisableSecretsCompatibility),
+		reports: newMigrationReports(mg.Cfg.UnifiedAlerting.Upgrade),
+		events:  &migrationEvents{},
+	}
+}
+
+// baseIntervalSeconds returns the base interval of the scheduler in seconds, or the default base interval if it is not configured.
+func (m *migration) baseIntervalSeconds() int64 {
+	if m.baseInterval <= 0 {
+		return int64(setting.SchedulerBaseInterval.Seconds())
+	}
+	return int64(m.baseInterval.Seconds())
 }
 
 func (m *migration) SQL(dialect migrator.Dialect) string {
 	return codeMigration
 }
 
-//nolint:gocyclo
 func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	if err := m.exec(sess, mg); err != nil {
+		recordFailureOnRollback(mg, m.orgID, err)
+		return err
+	}
+	return nil
+}
+
+//nolint:gocyclo
+func (m *migration) exec(sess *xorm.Session, mg *migrator.Migrator) error {
 	m.sess = sess
 	m.mg = mg
+	m.started = time.Now().UTC()
+	m.stamp = newMigrationStamp(m.started)
+	if m.upgradeCfg.ReserveGeneratedUIDs {
+		m.receiverUIDs = nil
+		m.seenUIDs.taken = m.uidTaken
+	}
+	if m.audit != nil {
+		m.audit.runID = m.stamp.RunID
+	}
+
+	if m.upgradeCfg.FolderNameTemplate != "" {
+		tmpl, err := template.New("folder_name_template").Parse(m.upgradeCfg.FolderNameTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to parse folder name template: %w", err)
+		}
+		m.folderNameTmpl = tmpl
+	}
 
-	dashAlerts, err := m.slurpDashAlerts()
+	if m.upgradeCfg.RuleGroupNameTemplate != "" {
+		tmpl, err := template.New("rule_group_name_template").Parse(m.upgradeCfg.RuleGroupNameTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to parse rule group name template: %w", err)
+		}
+		m.ruleGroupNameTmpl = tmpl
+	}
+
+	if err := m.loadTemplateMappings(); err != nil {
+		return err
+	}
+
+	if err := m.loadDatasourceUIDMappings(); err != nil {
+		return err
+	}
+
+	// The incremental migration only adds the new legacy alerts and channels, there is nothing to update in place.
+	if m.upgradeCfg.UpsertOnRemigration && !m.incremental {
+		migrated, err := m.loadMigratedResources()
+		if err != nil {
+			return err
+		}
+		m.migrated = migrated
+	}
+
+	scope, err := newMigrationScope(sess, m.upgradeCfg)
 	if err != nil {
 		return err
 	}
-	mg.Logger.Info("Alerts found to migrate", "alerts", len(dashAlerts))
+	m.scope = scope
+
+	m.events.record(MigrationEvent{Type: MigrationEventStarted, OrgID: m.orgID})
 
 	// [orgID, dataSourceId] -> UID
 	dsIDMap, err := m.slurpDSIDs()
@@ -259,191 +402,471 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		return err
 	}
 
-	// cache for folders created for dashboards that have custom permissions
-	folderCache := make(map[string]*dashboard)
-	// cache for the general folders
-	generalFolderCache := make(map[int64]*dashboard)
+	// rule groups and rules of the organization being migrated
+	var ruleGroups *dashboardRuleGroups
+	var rulesPerOrg map[int64]map[*alertRule][]uidOrID
 
-	folderHelper := folderHelper{
-		sess: sess,
-		mg:   mg,
-	}
+	folders := newMigrationFolders(m, folderHelper{
+		sess:              sess,
+		mg:                mg,
+		audit:             m.audit,
+		deterministicUIDs: m.upgradeCfg.DeterministicUIDs,
+		stamp:             m.stamp,
+		uids:              &m.seenUIDs,
+	})
 
-	gf := func(dash dashboard, da dashAlert) (*dashboard, error) {
-		f, ok := generalFolderCache[dash.OrgId]
+	lookup, lookupLimit := m.dashboardLookup()
+
+	migrateAlert := func(da dashAlert, dashboards map[dashboardKey]*dashboard) error {
+		l := mg.Logger.New("ruleID", da.Id, "ruleName", da.Name, "dashboardUID", da.DashboardUID, "orgID", da.OrgId)
+		l.Debug("Migrating alert rule to Unified Alerting")
+
+		found, ok := dashboards[dashboardKey{orgID: da.OrgId, uid: da.DashboardUID}]
 		if !ok {
-			// get or create general folder
-			f, err = folderHelper.getOrCreateGeneralFolder(dash.OrgId)
-			if err != nil {
-				return nil, MigrationError{
-					Err:     fmt.Errorf("failed to get or create general folder under organisation %d: %w", dash.OrgId, err),
-					AlertId: da.Id,
-				}
+			return MigrationError{
+				Err:     fmt.Errorf("dashboard with UID %v under organisation %d not found", da.DashboardUID, da.OrgId),
+				AlertId: da.Id,
 			}
-			generalFolderCache[dash.OrgId] = f
 		}
-		// No need to assign default permissions to general folder
-		// because they are included to the query result if it's a folder with no permissions
-		// https://github.com/grafana/grafana/blob/076e2ce06a6ecf15804423fcc8dca1b620a321e5/pkg/services/sqlstore/dashboard_acl.go#L109
-		return f, nil
-	}
+		dash := *found
 
-	// Per org map of newly created rules to which notification channels it should send to.
-	rulesPerOrg := make(map[int64]map[*alertRule][]uidOrID)
+		if !m.scope.contains(&dash) {
+			l.Info("Skip alert because its dashboard is not in the scope of the migration")
+			return nil
+		}
 
-	for _, da := range dashAlerts {
-		l := mg.Logger.New("ruleID", da.Id, "ruleName", da.Name, "dashboardUID", da.DashboardUID, "orgID", da.OrgId)
-		l.Debug("Migrating alert rule to Unified Alerting")
-		newCond, err := transConditions(*da.ParsedSettings, da.OrgId, dsIDMap)
+		newCond, err := transConditions(*da.ParsedSettings, da.OrgId, dsIDMap, m.dsUIDMappings)
 		if err != nil {
 			return err
 		}
 
-		da.DashboardUID = dashIDMap[[2]int64{da.OrgId, da.DashboardId}]
-
-		// get dashboard
-		dash := dashboard{}
-		exists, err := m.sess.Where("org_id=? AND uid=?", da.OrgId, da.DashboardUID).Get(&dash)
+		folder, err := m.alertFolder(l, folders, &dash, da)
 		if err != nil {
 			return MigrationError{
-				Err:     fmt.Errorf("failed to get dashboard %s under organisation %d: %w", da.DashboardUID, da.OrgId, err),
+				Err:     err,
 				AlertId: da.Id,
 			}
 		}
-		if !exists {
+		if folder == nil {
+			return nil
+		}
+		if folder.Uid == "" {
 			return MigrationError{
-				Err:     fmt.Errorf("dashboard with UID %v under organisation %d not found: %w", da.DashboardUID, da.OrgId, err),
+				Err:     fmt.Errorf("empty folder identifier"),
 				AlertId: da.Id,
 			}
 		}
+		// The evaluation interval derived from the refresh interval of the dashboard replaces the frequency of the
+		// legacy alert, so that the alert rule is made with it.
+		if m.upgradeCfg.EvaluationIntervalFromDashboardRefresh {
+			if interval, ok := m.dashboardEvaluationInterval(&dash); ok {
+				da.Frequency = interval
+			}
+		}
+		rule, err := m.makeAlertRule(l, *newCond, da, folder.Uid)
+		if err != nil {
+			return fmt.Errorf("failed to migrate alert rule '%s' [ID:%d, DashboardUID:%s, orgID:%d]: %w", da.Name, da.Id, da.DashboardUID, da.OrgId, err)
+		}
+		rule.folderTitle = folder.Title
+		rule.legacyState, rule.legacyStateSince = da.State, da.NewStateDate
+		m.resolveRuleTitle(rule)
 
-		var folder *dashboard
-		switch {
-		case dash.HasACL:
-			folderName := getAlertFolderNameFromDashboard(&dash)
-			f, ok := folderCache[folderName]
-			if !ok {
-				l.Info("Create a new folder for alerts that belongs to dashboard because it has custom permissions", "folder", folderName)
-				// create folder and assign the permissions of the dashboard (included default and inherited)
-				f, err = folderHelper.createFolder(dash.OrgId, folderName)
-				if err != nil {
-					return MigrationError{
-						Err:     fmt.Errorf("failed to create folder: %w", err),
-						AlertId: da.Id,
-					}
-				}
-				permissions, err := folderHelper.getACL(dash.OrgId, dash.Id)
-				if err != nil {
-					return MigrationError{
-						Err:     fmt.Errorf("failed to get dashboard %d under organisation %d permissions: %w", dash.Id, dash.OrgId, err),
-						AlertId: da.Id,
-					}
-				}
-				err = folderHelper.setACL(f.OrgId, f.Id, permissions)
-				if err != nil {
-					return MigrationError{
-						Err:     fmt.Errorf("failed to set folder %d under organisation %d permissions: %w", f.Id, f.OrgId, err),
-						AlertId: da.Id,
-					}
-				}
-				folderCache[folderName] = f
+		if _, ok := rulesPerOrg[rule.OrgID]; !ok {
+			rulesPerOrg[rule.OrgID] = make(map[*alertRule][]uidOrID)
+		}
+		if _, ok := rulesPerOrg[rule.OrgID][rule]; ok {
+			return MigrationError{
+				Err:     fmt.Errorf("duplicate generated rule UID"),
+				AlertId: da.Id,
 			}
-			folder = f
-		case dash.FolderId > 0:
-			// get folder if exists
-			f, err := folderHelper.getFolder(dash, da)
+		}
+
+		var groupName string
+		if m.ruleGroupNameTmpl != nil {
+			groupName, err = m.ruleGroupName(&dash, da, rule)
 			if err != nil {
-				// If folder does not exist then the dashboard is an orphan and we migrate the alert to the general folder.
-				l.Warn("Failed to find folder for dashboard. Migrate rule to the default folder", "rule_name", da.Name, "dashboard_uid", da.DashboardUID, "missing_folder_id", dash.FolderId)
-				folder, err = gf(dash, da)
-				if err != nil {
+				return MigrationError{
+					Err:     err,
+					AlertId: da.Id,
+				}
+			}
+		}
+
+		if _, ok := m.migrated.get(mappingLegacyAlert, da.OrgId, da.Id); ok {
+			m.upsertedRules[rule] = struct{}{}
+			m.audit.recordUpdate(rule.OrgID, auditResourceAlertRule, rule.UID, da.Id, "")
+		} else {
+			m.audit.recordCreate(rule.OrgID, auditResourceAlertRule, rule.UID, da.Id, "")
+		}
+		m.mappings.addAlert(da, rule)
+
+		if m.ruleGroupNameTmpl != nil {
+			ruleGroups.addNamed(rule, groupName)
+		} else if ruleGroups != nil {
+			ruleGroups.add(&dash, rule)
+		}
+
+		rulesPerOrg[rule.OrgID][rule] = extractChannelIDs(da)
+		return nil
+	}
+
+	// number of alerts of the organization being migrated that failed and were skipped
+	var failedAlerts int
+
+	migrateAlerts := func(dashAlerts []dashAlert) error {
+		keys := make([]dashboardKey, 0, len(dashAlerts))
+		for i := range dashAlerts {
+			dashAlerts[i].DashboardUID = dashIDMap[[2]int64{dashAlerts[i].OrgId, dashAlerts[i].DashboardId}]
+			keys = append(keys, dashboardKey{orgID: dashAlerts[i].OrgId, uid: dashAlerts[i].DashboardUID})
+		}
+		dashboards, err := lookupDashboards(context.Background(), keys, lookupLimit, lookup)
+		if err != nil {
+			return err
+		}
+
+		// The incremental migration retries the failing alerts at the next start rather than failing it.
+		failSoft := m.upgradeCfg.SkipFailingAlerts || m.incremental
+		for _, da := range dashAlerts {
+			if !failSoft {
+				if err := migrateAlert(da, dashboards); err != nil {
 					return err
 				}
-			} else {
-				folder = &f
+				continue
 			}
-		default:
-			folder, err = gf(dash, da)
-			if err != nil {
+
+			// The alert is migrated in a savepoint that is rolled back if it fails, so that it is skipped without the
+			// folders it created and, on PostgreSQL, without aborting the transaction of the migration. The folders and
+			// audit entries that it added to the caches of the migration are dropped with it.
+			if _, err := sess.Exec("SAVEPOINT ualert_alert"); err != nil {
 				return err
 			}
+			auditSize := m.audit.size()
+			restoreFolders := folders.forgetCreated()
+			err := migrateAlert(da, dashboards)
+			if err == nil {
+				if _, err := sess.Exec("RELEASE SAVEPOINT ualert_alert"); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, rbErr := sess.Exec("ROLLBACK TO SAVEPOINT ualert_alert"); rbErr != nil {
+				return fmt.Errorf("%w, and failed to roll back: %s", err, rbErr)
+			}
+			m.audit.truncate(auditSize)
+			restoreFolders()
+
+			// In the fail-soft mode the alert is skipped and the error recorded, unless too many alerts failed.
+			failedAlerts++
+			mg.Logger.Warn("Alert migration warning: failed to migrate alert, skipping", "ruleID", da.Id, "ruleName", da.Name, "dashboardUID", da.DashboardUID, "panelID", da.PanelId, "orgID", da.OrgId, "error", err)
+			m.orgStates.recordError(da.OrgId, fmt.Errorf("alert %q (ID %d) of dashboard %s panel %d not migrated: %w", da.Name, da.Id, da.DashboardUID, da.PanelId, err))
+			if limit := m.upgradeCfg.MaxFailingAlerts; limit > 0 && failedAlerts > limit {
+				return fmt.Errorf("more than %d alerts of organisation %d failed to migrate: %w", limit, da.OrgId, err)
+			}
 		}
+		return nil
+	}
 
-		if folder.Uid == "" {
-			return MigrationError{
-				Err:     fmt.Errorf("empty folder identifier"),
-				AlertId: da.Id,
+	channelsPerOrg, defaultChannelsPerOrg, err := m.getNotificationChannelMap()
+	if err != nil {
+		return fmt.Errorf("failed to load notification channels: %w", err)
+	}
+
+	orgIDs, err := m.queryDashAlertOrgIDs()
+	if err != nil {
+		return err
+	}
+	// Organizations without legacy alerts get an Alertmanager configuration with their notification channels.
+	for orgID := range channelsPerOrg {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+
+	if m.incremental {
+		m.synced, err = m.loadSyncedLegacyIDs(channelsPerOrg)
+		if err != nil {
+			return err
+		}
+	}
+	// organizations whose new legacy alerts and notification channels are migrated
+	var syncedOrgs []int64
+
+	// The organizations of the skip_org_ids setting keep their legacy alerts until they are migrated on their own.
+	skippedOrgs := make(map[int64]struct{})
+	if m.orgID == 0 {
+		for _, orgID := range m.upgradeCfg.SkipOrgIDs {
+			skippedOrgs[orgID] = struct{}{}
+			m.orgStates.recordError(orgID, errors.New("organization skipped by the skip_org_ids setting"))
+			m.orgStates.markNotMigrated(orgID)
+		}
+	}
+
+	var historyBackends []string
+	if m.upgradeCfg.BackfillStateHistory {
+		historyBackends = m.stateHistoryBackends()
+		if len(historyBackends) == 0 {
+			mg.Logger.Warn("State history is not backfilled because it is disabled or has no supported backend", "backend", m.stateHistoryCfg.Backend)
+		}
+	}
+
+	alertCount := 0
+	for i, orgID := range orgIDs {
+		if i > 0 && orgID == orgIDs[i-1] {
+			continue
+		}
+		if _, ok := skippedOrgs[orgID]; ok {
+			mg.Logger.Info("Organization is in the skip_org_ids setting, skipping", "orgID", orgID)
+			continue
+		}
+		if m.incremental {
+			if _, ok := m.synced.pending[orgID]; !ok {
+				continue
 			}
 		}
-		rule, err := m.makeAlertRule(l, *newCond, da, folder.Uid)
+
+		// the lock is held until the transaction of the migration ends, see lockOrg
+		migrated, err := m.lockOrg(orgID)
 		if err != nil {
-			return fmt.Errorf("failed to migrate alert rule '%s' [ID:%d, DashboardUID:%s, orgID:%d]: %w", da.Name, da.Id, da.DashboardUID, da.OrgId, err)
+			return err
+		}
+		if migrated {
+			mg.Logger.Info("Organization was migrated by another instance, skipping", "orgID", orgID)
+			m.orgStates.skip(orgID)
+			continue
 		}
 
-		if _, ok := rulesPerOrg[rule.OrgID]; !ok {
-			rulesPerOrg[rule.OrgID] = make(map[*alertRule][]uidOrID)
+		m.conflicts, err = m.detectConflicts(orgID)
+		if err != nil {
+			return err
 		}
-		if _, ok := rulesPerOrg[rule.OrgID][rule]; !ok {
-			rulesPerOrg[rule.OrgID][rule] = extractChannelIDs(da)
+		// The unified alerting resources of a synced organization are those of its migration, the new ones are merged.
+		if m.incremental {
+			mg.Logger.Info("Migrating new legacy alerts and notification channels of organization", "orgID", orgID)
+			syncedOrgs = append(syncedOrgs, orgID)
 		} else {
-			return MigrationError{
-				Err:     fmt.Errorf("duplicate generated rule UID"),
-				AlertId: da.Id,
+			migrate, err := m.handleConflicts(m.conflicts)
+			if err != nil {
+				return err
 			}
+			if !migrate {
+				mg.Logger.Info("Organization has conflicting unified alerting resources, skipping", "orgID", orgID)
+				continue
+			}
+			if m.migrated != nil {
+				if err := m.prepareOrgUpsert(orgID); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Per org map of newly created rules to which notification channels it should send to.
+		rulesPerOrg = map[int64]map[*alertRule][]uidOrID{orgID: make(map[*alertRule][]uidOrID)}
+		failedAlerts = 0
+		if m.upgradeCfg.GroupRulesByDashboard || m.ruleGroupNameTmpl != nil {
+			ruleGroups = newDashboardRuleGroups()
+		}
+
+		err = m.forEachDashAlertBatch(orgID, func(dashAlerts []dashAlert) error {
+			dashAlerts = m.synced.newAlerts(dashAlerts)
+			alertCount += len(dashAlerts)
+			return migrateAlerts(dashAlerts)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := m.finishOrgMigration(mg, orgID, rulesPerOrg[orgID], ruleGroups, channelsPerOrg[orgID], defaultChannelsPerOrg[orgID]); err != nil {
+			return err
+		}
+		if failedAlerts > 0 {
+			m.reports.recordSkippedAlerts(orgID, failedAlerts)
+		}
+		rulesPerOrg, ruleGroups = nil, nil
+		if err := m.flushOrg(orgID, historyBackends); err != nil {
+			return err
+		}
+	}
+	mg.Logger.Info("Alerts migrated", "alerts", alertCount)
+
+	if err := m.audit.write(m.sess); err != nil {
+		return err
+	}
+
+	if err := m.mappings.write(m.sess); err != nil {
+		return err
+	}
+
+	if err := m.reports.write(m.sess, m.mg.Dialect, m.orgStates); err != nil {
+		return err
+	}
+
+	m.events.recordNotMigrated(m.orgStates)
+	if err := m.events.write(m.sess, m.mg.Dialect); err != nil {
+		return err
+	}
+
+	if m.incremental {
+		if err := m.orgStates.writeScopes(m.sess, m.orgID); err != nil {
+			return err
 		}
+		return m.orgStates.writeSynced(m.sess, syncedOrgs)
 	}
 
-	for orgID := range rulesPerOrg {
-		if err := m.writeSilencesFile(orgID); err != nil {
-			m.mg.Logger.Error("Alert migration error: failed to write silence file", "err", err)
+	if err := m.orgStates.write(m.sess, m.orgID); err != nil {
+		return err
+	}
+
+	if m.upgradeCfg.RotateSecretsDataKey {
+		if err := requestSecretsRotation(m.sess, m.mg.Dialect); err != nil {
+			return err
 		}
 	}
 
-	amConfigPerOrg, err := m.setupAlertmanagerConfigs(rulesPerOrg)
-	if err != nil {
+	return nil
+}
+
+// finishOrgMigration completes the migration of the organization once all its legacy alerts are converted: it applies
+// the rule groups and pending periods, writes the silences, inserts the alert rules and writes the Alertmanager
+// configuration migrated from the notification channels.
+// flushOrg writes the audit entries and the legacy ID mappings of the migrated organization, once the state history of
+// its alert rules is backfilled to the backends, and releases its alert rules and silences, so that the memory of the
+// migration does not grow with the number of organizations.
+func (m *migration) flushOrg(orgID int64, historyBackends []string) error {
+	if len(historyBackends) > 0 {
+		if err := m.backfillStateHistory(historyBackends); err != nil {
+			return err
+		}
+	}
+	if err := m.audit.write(m.sess); err != nil {
+		return err
+	}
+	if err := m.mappings.write(m.sess); err != nil {
 		return err
 	}
+	delete(m.silences, orgID)
+	m.upsertedRules = make(map[*alertRule]struct{})
+	return nil
+}
 
-	err = m.insertRules(mg, rulesPerOrg)
-	if err != nil {
+func (m *migration) finishOrgMigration(mg *migrator.Migrator, orgID int64, rules map[*alertRule][]uidOrID, ruleGroups *dashboardRuleGroups, channels, defaultChannels []*notificationChannel) error {
+	if ruleGroups != nil {
+		ruleGroups.apply(int64(m.upgradeCfg.GroupEvaluationInterval.Seconds()), m.baseIntervalSeconds())
+	}
+
+	// The pending period is adjusted once the evaluation intervals of the rules are final.
+	for rule := range rules {
+		rule.For = duration(adjustPendingPeriod(m.upgradeCfg, time.Duration(rule.For), rule.IntervalSeconds))
+	}
+
+	m.disableScreenshots(orgID, rules, channels, defaultChannels)
+
+	// The silences of the Alertmanager would be replaced by those of the new alert rules.
+	if m.incremental && len(m.silences[orgID]) > 0 {
+		m.mg.Logger.Warn("Alert migration warning: silences of new paused legacy alerts are not written by the incremental migration", "orgID", orgID, "silences", len(m.silences[orgID]))
+		m.orgStates.recordError(orgID, fmt.Errorf("%d silences of new legacy alerts not created", len(m.silences[orgID])))
+	} else if len(rules) > 0 {
+		if err := m.writeSilences(orgID); err != nil {
+			m.mg.Logger.Error("Alert migration error: failed to write silences", "err", err)
+		}
+	}
+
+	if m.upgradeCfg.AlertmanagerChannelsToDatasources {
+		var err error
+		channels, defaultChannels, err = m.migrateAlertmanagerChannels(orgID, channels, defaultChannels, rules)
+		if err != nil {
+			return err
+		}
+	}
+
+	var amConfig *PostableUserConfig
+	if len(channels) > 0 {
+		var err error
+		amConfig, err = m.setupAlertmanagerConfig(orgID, channels, defaultChannels, rules)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := m.insertRules(mg, map[int64]map[*alertRule][]uidOrID{orgID: rules}); err != nil {
 		return err
 	}
 
-	for orgID, amConfig := range amConfigPerOrg {
-		if err := m.writeAlertmanagerConfig(orgID, amConfig); err != nil {
+	if m.upgradeCfg.SeedAlertInstances {
+		if err := m.seedAlertInstances(rules); err != nil {
 			return err
 		}
 	}
 
+	if m.upgradeCfg.RewriteDashboardPanels {
+		if err := m.rewriteDashboardPanels(orgID, rules); err != nil {
+			return err
+		}
+	}
+
+	if amConfig == nil {
+		m.reports.recordMigrated(orgID, len(rules), 0)
+		m.events.record(MigrationEvent{Type: MigrationEventOrgMigrated, OrgID: orgID, AlertRules: len(rules)})
+		return nil
+	}
+	m.reports.recordMigrated(orgID, len(rules), len(amConfig.AlertmanagerConfig.Receivers))
+	m.events.record(MigrationEvent{Type: MigrationEventOrgMigrated, OrgID: orgID, AlertRules: len(rules), ContactPoints: len(amConfig.AlertmanagerConfig.Receivers)})
+	if err := m.writeAlertmanagerConfig(orgID, amConfig); err != nil {
+		return err
+	}
+
+	if m.upgradeCfg.TestContactPoints && !m.incremental {
+		return requestContactPointTests(m.sess, m.mg.Dialect, orgID)
+	}
+	return nil
+}
+
+// loadTemplateMappings loads the template mapping file, if configured.
+func (m *migration) loadTemplateMappings() error {
+	if m.upgradeCfg.TemplateMappingFile == "" {
+		return nil
+	}
+	f, err := loadTemplateMappingFile(m.upgradeCfg.TemplateMappingFile)
+	if err != nil {
+		return err
+	}
+	m.templateMappings = f
+	return nil
+}
+
+// loadDatasourceUIDMappings loads the datasource UID mapping file, if configured.
+func (m *migration) loadDatasourceUIDMappings() error {
+	if m.upgradeCfg.DatasourceUIDMappingFile == "" {
+		return nil
+	}
+	f, err := loadDatasourceUIDMappingFile(m.upgradeCfg.DatasourceUIDMappingFile)
+	if err != nil {
+		return err
+	}
+	m.dsUIDMappings = f
 	return nil
 }
 
 func (m *migration) insertRules(mg *migrator.Migrator, rulesPerOrg map[int64]map[*alertRule][]uidOrID) error {
-	for _, rules := range rulesPerOrg {
+	for orgID, rules := range rulesPerOrg {
+		toInsert := make([]*alertRule, 0, len(rules))
 		for rule := range rules {
-			var err error
-			if strings.HasPrefix(mg.Dialect.DriverName(), migrator.Postgres) {
-				err = mg.InTransaction(func(sess *xorm.Session) error {
-					_, err := sess.Insert(rule)
+			if _, ok := m.upsertedRules[rule]; ok {
+				if err := m.updateRule(rule); err != nil {
 					return err
-				})
-			} else {
-				_, err = m.sess.Insert(rule)
+				}
+				continue
 			}
-			if err != nil {
-				// TODO better error handling, if constraint
-				rule.Title += fmt.Sprintf(" %v", rule.UID)
-				rule.RuleGroup += fmt.Sprintf(" %v", rule.UID)
+			toInsert = append(toInsert, rule)
+		}
 
-				_, err = m.sess.Insert(rule)
-				if err != nil {
-					return err
-				}
+		if m.upgradeCfg.RuleInsertBatchSize > 0 {
+			if err := m.insertRuleBatches(mg, orgID, toInsert); err != nil {
+				return err
 			}
+			continue
+		}
 
-			// create entry in alert_rule_version
-			_, err = m.sess.Insert(rule.makeVersion())
-			if err != nil {
+		for _, rule := range toInsert {
+			if err := m.insertRule(mg, rule); err != nil {
 				return err
 			}
 		}
@@ -451,12 +874,56 @@ func (m *migration) insertRules(mg *migrator.Migrator, rulesPerOrg map[int64]map
 	return nil
 }
 
+// insertRule inserts the alert rule and its first version. If the insert fails, the title and the rule group are made
+// unique with the rule UID before trying again.
+func (m *migration) insertRule(mg *migrator.Migrator, rule *alertRule) error {
+	var err error
+	if strings.HasPrefix(mg.Dialect.DriverName(), migrator.Postgres) {
+		err = mg.InTransaction(func(sess *xorm.Session) error {
+			_, err := sess.Insert(rule)
+			return err
+		})
+	} else {
+		_, err = m.sess.Insert(rule)
+	}
+	if err != nil {
+		// TODO better error handling, if constraint
+		rule.Title += fmt.Sprintf(" %v", rule.UID)
+		if !m.upgradeCfg.GroupRulesByDashboard && m.ruleGroupNameTmpl == nil {
+			rule.RuleGroup += fmt.Sprintf(" %v", rule.UID)
+		}
+
+		_, err = m.sess.Insert(rule)
+		if err != nil {
+			return err
+		}
+	}
+
+	// create entry in alert_rule_version
+	_, err = m.sess.Insert(rule.makeVersion())
+	return err
+}
+
 func (m *migration) writeAlertmanagerConfig(orgID int64, amConfig *PostableUserConfig) error {
+	if m.upgradeCfg.StoreSecretsInSecretsKVStore {
+		if err := m.requestStoreSecrets(orgID, amConfig, !m.secretsCompatibilityDisabled); err != nil {
+			return err
+		}
+	}
+
 	rawAmConfig, err := json.Marshal(amConfig)
 	if err != nil {
 		return err
 	}
 
+	// The existing configuration is replaced unless the conflicts are merged or the migration is incremental.
+	if existing := m.conflicts; existing != nil && existing.orgID == orgID && existing.amConfig != nil && (m.upgradeCfg.ConflictPolicy == setting.ConflictPolicyMerge || m.incremental) {
+		rawAmConfig, err = m.mergeAlertmanagerConfig(orgID, existing.amConfig.AlertmanagerConfiguration, amConfig)
+		if err != nil {
+			return err
+		}
+	}
+
 	// remove an existing configuration, which could have been left during switching back to legacy alerting
 	_, _ = m.sess.Delete(AlertConfiguration{OrgID: orgID})
 
@@ -472,6 +939,10 @@ func (m *migration) writeAlertmanagerConfig(orgID int64, amConfig *PostableUserC
 		return err
 	}
 
+	if m.upgradeCfg.PushAlertmanagerConfigURL != "" {
+		m.schedulePushAlertmanagerConfig(orgID, rawAmConfig)
+	}
+
 	return nil
 }
 
@@ -531,6 +1002,12 @@ type AlertConfiguration struct {
 // rmMigration removes Grafana 8 alert data
 type rmMigration struct {
 	migrator.MigrationBase
+
+	// orgID restricts the removal to a single organization, 0 removes the data of all organizations.
+	orgID int64
+	// deleteAll deletes the alert rules and folders even if UpsertOnRemigration is enabled, because no migration
+	// follows to update them.
+	deleteAll bool
 }
 
 func (m *rmMigration) SQL(dialect migrator.Dialect) string {
@@ -538,37 +1015,76 @@ func (m *rmMigration) SQL(dialect migrator.Dialect) string {
 }
 
 func (m *rmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
-	_, err := sess.Exec("delete from alert_rule")
-	if err != nil {
+	if err := m.exec(sess, mg); err != nil {
+		recordFailureOnRollback(mg, m.orgID, err)
 		return err
 	}
+	return nil
+}
 
-	_, err = sess.Exec("delete from alert_rule_version")
-	if err != nil {
+func (m *rmMigration) exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	if mg.Cfg != nil && mg.Cfg.UnifiedAlerting.Upgrade.BackupRemovedData {
+		if err := backupOrgs(sess, m.orgID, mg.Cfg.UnifiedAlerting.Upgrade.MaxBackupsToKeep); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteMigratedDatasources(sess, m.orgID); err != nil {
 		return err
 	}
 
-	_, err = sess.Exec("delete from dashboard_acl where dashboard_id IN (select id from dashboard where created_by = ?)", FOLDER_CREATED_BY)
-	if err != nil {
+	// Alert rules and folders, and the legacy ID mappings of the alert rules and contact points, are kept so that the
+	// next migration can update them in place.
+	upsert := !m.deleteAll && mg.Cfg != nil && mg.Cfg.UnifiedAlerting.Upgrade.UpsertOnRemigration
+	if upsert {
+		if err := recordDeleteAll(sess, m.orgID, auditResourceAlertRule, auditResourceFolder); err != nil {
+			return err
+		}
+	} else {
+		if err := recordDeleteAll(sess, m.orgID); err != nil {
+			return err
+		}
+
+		if err := deleteRulesAndFolders(sess, m.orgID); err != nil {
+			return err
+		}
+	}
+
+	if !upsert {
+		if err := deleteMappings(sess, m.orgID); err != nil {
+			return err
+		}
+	}
+
+	if err := revertOrgStates(sess, m.orgID); err != nil {
 		return err
 	}
 
-	_, err = sess.Exec("delete from dashboard where created_by = ?", FOLDER_CREATED_BY)
-	if err != nil {
+	if err := deleteBackfilledStateHistory(sess, m.orgID); err != nil {
 		return err
 	}
 
-	_, err = sess.Exec("delete from alert_configuration")
+	if err := deleteMigratedSecrets(sess, m.orgID); err != nil {
+		return err
+	}
+
+	if err := restoreDashboardPanelAlerts(sess, m.orgID); err != nil {
+		return err
+	}
+
+	cond, args := orgCondition("org_id", m.orgID)
+	_, err := sess.Exec(append([]any{"delete from alert_configuration where " + cond}, args...)...)
 	if err != nil {
 		return err
 	}
 
-	_, err = sess.Exec("delete from ngalert_configuration")
+	_, err = sess.Exec(append([]any{"delete from ngalert_configuration where " + cond}, args...)...)
 	if err != nil {
 		return err
 	}
 
-	_, err = sess.Exec("delete from alert_instance")
+	ruleCond, ruleArgs := orgCondition("rule_org_id", m.orgID)
+	_, err = sess.Exec(append([]any{"delete from alert_instance where " + ruleCond}, ruleArgs...)...)
 	if err != nil {
 		return err
 	}
@@ -579,25 +1095,87 @@ func (m *rmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 	}
 
 	if exists {
-		_, err = sess.Exec("delete from kv_store where namespace = ?", KV_NAMESPACE)
+		_, err = sess.Exec(append([]any{"delete from kv_store where namespace = ? and " + cond, KV_NAMESPACE}, args...)...)
 		if err != nil {
 			return err
 		}
 	}
 
-	files, err := getSilenceFileNamesForAllOrgs(mg)
-	if err != nil {
-		return err
+	var files []string
+	if m.orgID != 0 {
+		files = []string{silencesFileNameForOrg(mg, m.orgID)}
+	} else {
+		files, err = getSilenceFileNamesForAllOrgs(mg)
+		if err != nil {
+			return err
+		}
 	}
 	for _, f := range files {
-		if err := os.Remove(f); err != nil {
+		if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
 			mg.Logger.Error("Alert migration error: failed to remove silence file", "file", f, "err", err)
 		}
 	}
 
+	// The removal that precedes a migration is not a revert of the organization.
+	if m.deleteAll {
+		return recordMigrationEvent(sess, mg.Dialect, MigrationEvent{Type: MigrationEventOrgReverted, OrgID: m.orgID})
+	}
 	return nil
 }
 
+// forgetCachedFolders returns a function that removes from the cache the folders cached since forgetCachedFolders was
+// called, whose creation was rolled back.
+func forgetCachedFolders[K comparable](cache map[K]*dashboard) func() {
+	cached := make(map[K]struct{}, len(cache))
+	for k := range cache {
+		cached[k] = struct{}{}
+	}
+	return func() {
+		for k := range cache {
+			if _, ok := cached[k]; !ok {
+				delete(cache, k)
+			}
+		}
+	}
+}
+
+// deleteRulesAndFolders deletes the alert rules and the folders created by the migration of the organization, or of all
+// organizations if orgID is 0.
+func deleteRulesAndFolders(sess *xorm.Session, orgID int64) error {
+	cond, args := orgCondition("org_id", orgID)
+	_, err := sess.Exec(append([]any{"delete from alert_rule where " + cond}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	ruleCond, ruleArgs := orgCondition("rule_org_id", orgID)
+	_, err = sess.Exec(append([]any{"delete from alert_rule_version where " + ruleCond}, ruleArgs...)...)
+	if err != nil {
+		return err
+	}
+
+	_, err = sess.Exec(append([]any{"delete from dashboard_acl where dashboard_id IN (select id from dashboard where created_by = ? and " + cond + ")", FOLDER_CREATED_BY}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteFolderManagedPermissions(sess, cond, args); err != nil {
+		return err
+	}
+
+	_, err = sess.Exec(append([]any{"delete from dashboard where created_by = ? and " + cond, FOLDER_CREATED_BY}, args...)...)
+	return err
+}
+
+// orgCondition returns the SQL condition that restricts a statement to the rows of the organization in the given
+// column, or a condition that matches all rows if orgID is 0.
+func orgCondition(column string, orgID int64) (string, []any) {
+	if orgID == 0 {
+		return "1 = 1", nil
+	}
+	return column + " = ?", []any{orgID}
+}
+
 // rmMigrationWithoutLogging is similar migration to rmMigration
 // but is not recorded in the migration_log table so that it can rerun in the future
 type rmMigrationWithoutLogging = rmMigration
@@ -747,6 +1325,74 @@ func getAlertFolderNameFromDashboard(dash *dashboard) string {
 	return fmt.Sprintf(DASHBOARD_FOLDER, title, dash.Uid) // include UID to the name to avoid collision
 }
 
+// folderNameData is the data available to the folder_name_template setting.
+type folderNameData struct {
+	DashboardTitle string
+	DashboardUID   string
+	OrgID          int64
+}
+
+// getAlertFolderNameFromTemplate generates a folder name for alerts that belong to a dashboard using the given template.
+// If the resulting string exceeds MaxFolderName, the dashboard title is stripped so that the name fits. If the name
+// still does not fit, e.g. because the template renders the title conditionally, the default folder name is used.
+func getAlertFolderNameFromTemplate(tmpl *template.Template, dash *dashboard) (string, error) {
+	render := func(title string) (string, error) {
+		var buf strings.Builder
+		err := tmpl.Execute(&buf, folderNameData{DashboardTitle: title, DashboardUID: dash.Uid, OrgID: dash.OrgId})
+		return buf.String(), err
+	}
+
+	name, err := render(dash.Title)
+	if err != nil {
+		return "", fmt.Errorf("failed to render folder name template: %w", err)
+	}
+	if len(name) <= MaxFolderName {
+		return name, nil
+	}
+
+	withoutTitle, err := render("")
+	if err != nil {
+		return "", fmt.Errorf("failed to render folder name template: %w", err)
+	}
+	if len(withoutTitle) > MaxFolderName {
+		return "", fmt.Errorf("folder name %q exceeds the maximum length of %d", withoutTitle, MaxFolderName)
+	}
+
+	// The template can render the title more than once, each occurrence is stripped.
+	occurrences := 1
+	if len(dash.Title) > 0 && len(name)-len(withoutTitle) > len(dash.Title) {
+		occurrences = (len(name) - len(withoutTitle)) / len(dash.Title)
+	}
+	name, err = render(truncateString(dash.Title, (MaxFolderName-len(withoutTitle))/occurrences))
+	if err != nil {
+		return "", fmt.Errorf("failed to render folder name template: %w", err)
+	}
+	if len(name) > MaxFolderName {
+		return getAlertFolderNameFromDashboard(dash), nil
+	}
+	return name, nil
+}
+
+// truncateString returns the longest prefix of s that is at most maxLen bytes long without splitting a rune.
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
+	return s[:maxLen]
+}
+
+// folderName returns the name of the folder for alerts that belong to the dashboard, using the folder_name_template
+// setting if it is configured.
+func (m *migration) folderName(dash *dashboard) (string, error) {
+	if m.folderNameTmpl == nil {
+		return getAlertFolderNameFromDashboard(dash), nil
+	}
+	return getAlertFolderNameFromTemplate(m.folderNameTmpl, dash)
+}
+
 // CreateDefaultFoldersForAlertingMigration creates a folder dedicated for alerting if no folders exist
 func CreateDefaultFoldersForAlertingMigration(mg *migrator.Migrator) {
 	if !mg.Cfg.UnifiedAlerting.IsEnabled() {
@@ -888,8 +1534,15 @@ func (c updateRulesOrderInGroup) Exec(sess *xorm.Session, migrator *migrator.Mig
 type uidSet struct {
 	set             map[string]struct{}
 	caseInsensitive bool
+	// taken checks whether a uid is already used by existing data. If nil, only the uids of the set are avoided.
+	taken func(uid string) (bool, error)
+	// attempts is the number of uids tried before giving up, defaultUidAttempts if 0.
+	attempts int
 }
 
+// defaultUidAttempts is the number of uids a uidSet tries by default before giving up.
+const defaultUidAttempts = 5
+
 // contains checks whether the given uid has already been generated in this uidSet.
 func (s *uidSet) contains(uid string) bool {
 	dedup := uid
@@ -900,6 +1553,29 @@ func (s *uidSet) contains(uid string) bool {
 	return seen
 }
 
+// available checks whether the given uid is neither contained in this uidSet nor taken by existing data.
+func (s *uidSet) available(uid string) (bool, error) {
+	if s.contains(uid) {
+		return false, nil
+	}
+	if s.taken == nil {
+		return true, nil
+	}
+	taken, err := s.taken(uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether uid %s is taken: %w", uid, err)
+	}
+	return !taken, nil
+}
+
+// maxAttempts returns the number of uids to try before giving up.
+func (s *uidSet) maxAttempts() int {
+	if s.attempts <= 0 {
+		return defaultUidAttempts
+	}
+	return s.attempts
+}
+
 // add adds the given uid to the uidSet.
 func (s *uidSet) add(uid string) {
 	dedup := uid
@@ -909,13 +1585,36 @@ func (s *uidSet) add(uid string) {
 	s.set[dedup] = struct{}{}
 }
 
-// generateUid will generate a new unique uid that is not already contained in the uidSet.
-// If it fails to create one that has not already been generated it will make multiple, but not unlimited, attempts.
+// generateUid will generate a new unique uid that is not already contained in the uidSet nor taken by existing data.
+// If it fails to create one that is available it will make multiple, but not unlimited, attempts.
 // If all attempts are exhausted an error will be returned.
 func (s *uidSet) generateUid() (string, error) {
-	for i := 0; i < 5; i++ {
+	for i := 0; i < s.maxAttempts(); i++ {
 		gen := util.GenerateShortUID()
-		if !s.contains(gen) {
+		ok, err := s.available(gen)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			s.add(gen)
+			return gen, nil
+		}
+	}
+
+	return "", errors.New("failed to generate UID")
+}
+
+// generateDeterministicUid will generate a new unique uid that is derived from the given parts and not already
+// contained in the uidSet nor taken by existing data. The same parts always result in the same uid as long as the
+// uidSet contains the same uids and the same uids are taken.
+func (s *uidSet) generateDeterministicUid(parts ...any) (string, error) {
+	for i := 0; i < s.maxAttempts(); i++ {
+		gen := deterministicUid(append(parts, i)...)
+		ok, err := s.available(gen)
+		if err != nil {
+			return "", err
+		}
+		if ok {
 			s.add(gen)
 			return gen, nil
 		}
@@ -923,3 +1622,61 @@ func (s *uidSet) generateUid() (string, error) {
 
 	return "", errors.New("failed to generate UID")
 }
+
+// deterministicUid returns a short uid derived from the hash of the given parts.
+// It has the same length as the uids generated by util.GenerateShortUID.
+func deterministicUid(parts ...any) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintln(parts...)))
+	return hex.EncodeToString(sum[:])[:14]
+}
+
+// uidTaken checks whether the given uid is used by an existing alert rule, folder, dashboard or contact point.
+func (m *migration) uidTaken(uid string) (bool, error) {
+	for _, table := range []string{"alert_rule", "dashboard"} {
+		exists, err := m.sess.Table(table).Where("uid = ?", uid).Exist()
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	if m.receiverUIDs == nil {
+		uids, err := m.existingReceiverUIDs()
+		if err != nil {
+			return false, err
+		}
+		m.receiverUIDs = uids
+	}
+	_, exists := m.receiverUIDs[uid]
+	return exists, nil
+}
+
+// existingReceiverUIDs returns the UIDs of the contact points of the Alertmanager configurations of all organizations.
+// Contact points are stored in the configuration rather than in a table of their own, so they are read once.
+func (m *migration) existingReceiverUIDs() (map[string]struct{}, error) {
+	var configs []string
+	if err := m.sess.Table("alert_configuration").Cols("alertmanager_configuration").Find(&configs); err != nil {
+		return nil, fmt.Errorf("failed to read Alertmanager configurations: %w", err)
+	}
+	uids := make(map[string]struct{})
+	for _, raw := range configs {
+		var cfg PostableUserConfig
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			return nil, fmt.Errorf("failed to read Alertmanager configuration: %w", err)
+		}
+		for _, receiver := range cfg.AlertmanagerConfig.Receivers {
+			for _, integration := range receiver.GrafanaManagedReceivers {
+				if integration.UID != "" {
+					uids[integration.UID] = struct{}{}
+				}
+			}
+		}
+	}
+	return uids, nil
+}
+
+// newUid generates the uid for a migrated resource. If DeterministicUIDs is enabled it is derived from the given parts.
+func (m *migration) newUid(parts ...any) (string, error) {
+	if m.upgradeCfg.DeterministicUIDs {
+		return m.seenUIDs.generateDeterministicUid(parts...)
+	}
+	return m.seenUIDs.generateUid()
+}
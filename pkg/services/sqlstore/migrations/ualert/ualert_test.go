@@ -1,17 +1,26 @@
 package ualert
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"strings"
 	"testing"
+	"text/template"
+	"unicode/utf8"
 
 	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -173,6 +182,57 @@ func Test_getAlertFolderNameFromDashboard(t *testing.T) {
 	})
 }
 
+func Test_getAlertFolderNameFromTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse("[{{.OrgID}}] {{.DashboardTitle}} ({{.DashboardUID}})"))
+
+	t.Run("should render the template", func(t *testing.T) {
+		dash := &dashboard{OrgId: 2, Uid: "dash-uid", Title: "TEST"}
+		folder, err := getAlertFolderNameFromTemplate(tmpl, dash)
+		require.NoError(t, err)
+		require.Equal(t, "[2] TEST (dash-uid)", folder)
+	})
+
+	t.Run("should cut title to the length", func(t *testing.T) {
+		dash := &dashboard{OrgId: 2, Uid: "dash-uid", Title: strings.Repeat("a", MaxFolderName)}
+		folder, err := getAlertFolderNameFromTemplate(tmpl, dash)
+		require.NoError(t, err)
+		require.Len(t, folder, MaxFolderName)
+		require.True(t, strings.HasSuffix(folder, "(dash-uid)"))
+	})
+
+	t.Run("should cut title rendered more than once to the length", func(t *testing.T) {
+		tmpl := template.Must(template.New("").Parse("{{.DashboardTitle}} {{.DashboardTitle}}"))
+		dash := &dashboard{OrgId: 2, Uid: "dash-uid", Title: strings.Repeat("a", 200)}
+		folder, err := getAlertFolderNameFromTemplate(tmpl, dash)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(folder), MaxFolderName)
+		require.Equal(t, strings.Repeat("a", 127)+" "+strings.Repeat("a", 127), folder)
+	})
+
+	t.Run("should not split multibyte characters of the title", func(t *testing.T) {
+		dash := &dashboard{OrgId: 12, Uid: "dash-uid", Title: strings.Repeat("é", MaxFolderName)}
+		folder, err := getAlertFolderNameFromTemplate(tmpl, dash)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(folder), MaxFolderName)
+		require.True(t, utf8.ValidString(folder))
+		require.True(t, strings.HasSuffix(folder, "é (dash-uid)"))
+	})
+
+	t.Run("should use the default name if the title cannot be cut to the length", func(t *testing.T) {
+		tmpl := template.Must(template.New("").Parse(`{{if .DashboardTitle}}` + strings.Repeat("a", 200) + `{{end}}{{.DashboardTitle}}`))
+		dash := &dashboard{OrgId: 2, Uid: "dash-uid", Title: strings.Repeat("a", 100)}
+		folder, err := getAlertFolderNameFromTemplate(tmpl, dash)
+		require.NoError(t, err)
+		require.Equal(t, getAlertFolderNameFromDashboard(dash), folder)
+	})
+
+	t.Run("should fail if the name is too long without the title", func(t *testing.T) {
+		tmpl := template.Must(template.New("").Parse(strings.Repeat("a", MaxFolderName) + " {{.DashboardUID}}"))
+		_, err := getAlertFolderNameFromTemplate(tmpl, &dashboard{Uid: "dash-uid", Title: "TEST"})
+		require.Error(t, err)
+	})
+}
+
 func Test_shortUIDCaseInsensitiveConflicts(t *testing.T) {
 	s := uidSet{
 		set:             make(map[string]struct{}),
@@ -192,3 +252,286 @@ func Test_shortUIDCaseInsensitiveConflicts(t *testing.T) {
 
 	require.Equal(t, len(s.set), len(deduped))
 }
+
+func Test_deterministicUID(t *testing.T) {
+	newSet := func() *uidSet {
+		return &uidSet{set: make(map[string]struct{}), caseInsensitive: true}
+	}
+
+	t.Run("same parts generate the same uid", func(t *testing.T) {
+		first, err := newSet().generateDeterministicUid("alert_rule", 1, 2, 3, 4)
+		require.NoError(t, err)
+		second, err := newSet().generateDeterministicUid("alert_rule", 1, 2, 3, 4)
+		require.NoError(t, err)
+		require.Equal(t, first, second)
+		require.NoError(t, util.ValidateUID(first))
+	})
+
+	t.Run("different parts generate different uids", func(t *testing.T) {
+		s := newSet()
+		first, err := s.generateDeterministicUid("alert_rule", 1, 2, 3, 4)
+		require.NoError(t, err)
+		second, err := s.generateDeterministicUid("alert_rule", 1, 2, 3, 5)
+		require.NoError(t, err)
+		require.NotEqual(t, first, second)
+	})
+
+	t.Run("conflicting uid generates a new one", func(t *testing.T) {
+		s := newSet()
+		first, err := s.generateDeterministicUid("receiver", 1, 1)
+		require.NoError(t, err)
+		second, err := s.generateDeterministicUid("receiver", 1, 1)
+		require.NoError(t, err)
+		require.NotEqual(t, first, second)
+	})
+}
+
+func Test_uidSetTaken(t *testing.T) {
+	t.Run("uid taken by existing data generates a new one", func(t *testing.T) {
+		existing := deterministicUid("alert_rule", 1, 0)
+		s := &uidSet{set: make(map[string]struct{}), taken: func(uid string) (bool, error) {
+			return uid == existing, nil
+		}}
+		uid, err := s.generateDeterministicUid("alert_rule", 1)
+		require.NoError(t, err)
+		require.Equal(t, deterministicUid("alert_rule", 1, 1), uid)
+	})
+
+	t.Run("fails after the configured attempts", func(t *testing.T) {
+		calls := 0
+		s := &uidSet{set: make(map[string]struct{}), attempts: 3, taken: func(uid string) (bool, error) {
+			calls++
+			return true, nil
+		}}
+		_, err := s.generateUid()
+		require.Error(t, err)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the error of the check", func(t *testing.T) {
+		s := &uidSet{set: make(map[string]struct{}), taken: func(uid string) (bool, error) {
+			return false, errors.New("boom")
+		}}
+		_, err := s.generateUid()
+		require.ErrorContains(t, err, "boom")
+	})
+}
+
+func Test_pushAlertmanagerConfig(t *testing.T) {
+	encrypted, err := util.Encrypt([]byte("secret"), setting.SecretKey)
+	require.NoError(t, err)
+	password := base64.StdEncoding.EncodeToString(encrypted)
+	rawAmConfig := []byte(`{"template_files":{"a":"{{ define \"a\" }}a{{ end }}"},"alertmanager_config":{"route":{"receiver":"autogen-contact-point-default","routes":[{"receiver":"hook","object_matchers":[["__contacts__","=~",".*\"hook\".*"]]}]},"receivers":[{"name":"autogen-contact-point-default"},{"name":"hook","grafana_managed_receiver_configs":[{"uid":"u1","name":"hook","type":"webhook","disableResolveMessage":true,"settings":{"url":"http://hook","username":"user"},"secureSettings":{"password":"` + password + `"}},{"uid":"u2","name":"mail","type":"email","settings":{"addresses":"a@example.com"}}]}]}}`)
+
+	type request struct {
+		path     string
+		tenantID string
+		username string
+		body     []byte
+	}
+	newServer := func(t *testing.T, status int) (*httptest.Server, *[]request) {
+		var requests []request
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			username, _, _ := r.BasicAuth()
+			requests = append(requests, request{path: r.URL.Path, tenantID: r.Header.Get("X-Scope-OrgID"), username: username, body: body})
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, &requests
+	}
+
+	t.Run("pushes to the tenant of the organization", func(t *testing.T) {
+		srv, requests := newServer(t, http.StatusCreated)
+		m := newTestMigration(t)
+		m.upgradeCfg.PushAlertmanagerConfigURL = srv.URL + "/alertmanager"
+		m.upgradeCfg.PushAlertmanagerConfigTenantIDs = map[int64]string{2: "team-b"}
+		m.upgradeCfg.PushAlertmanagerConfigBasicAuthUsername = "user"
+
+		_, err := m.pushAlertmanagerConfig(1, rawAmConfig)
+		require.NoError(t, err)
+		_, err = m.pushAlertmanagerConfig(2, rawAmConfig)
+		require.NoError(t, err)
+		require.Len(t, *requests, 2)
+		require.Equal(t, "1", (*requests)[0].tenantID)
+		require.Equal(t, "team-b", (*requests)[1].tenantID)
+
+		r := (*requests)[0]
+		require.Equal(t, "/alertmanager/api/v1/alerts", r.path)
+		require.Equal(t, "user", r.username)
+	})
+
+	t.Run("translates the Grafana-managed receivers with their secrets decrypted", func(t *testing.T) {
+		srv, requests := newServer(t, http.StatusCreated)
+		m := newTestMigration(t)
+		m.upgradeCfg.PushAlertmanagerConfigURL = srv.URL
+
+		problems, err := m.pushAlertmanagerConfig(1, rawAmConfig)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		require.ErrorContains(t, problems[0], `integration "mail" of contact point "hook"`)
+
+		require.Len(t, *requests, 1)
+		var body struct {
+			TemplateFiles      map[string]string `yaml:"template_files"`
+			AlertmanagerConfig string            `yaml:"alertmanager_config"`
+		}
+		require.NoError(t, yaml.Unmarshal((*requests)[0].body, &body))
+		require.Equal(t, map[string]string{"a": `{{ define "a" }}a{{ end }}`}, body.TemplateFiles)
+		require.NotContains(t, body.AlertmanagerConfig, "grafana_managed_receiver_configs")
+		require.NotContains(t, body.AlertmanagerConfig, "object_matchers")
+
+		var amConfig struct {
+			Route struct {
+				Receiver string `yaml:"receiver"`
+				Routes   []struct {
+					Matchers []string `yaml:"matchers"`
+				} `yaml:"routes"`
+			} `yaml:"route"`
+			Templates []string `yaml:"templates"`
+			Receivers []struct {
+				Name           string `yaml:"name"`
+				WebhookConfigs []struct {
+					URL          string `yaml:"url"`
+					SendResolved bool   `yaml:"send_resolved"`
+					HTTPConfig   struct {
+						BasicAuth struct {
+							Username string `yaml:"username"`
+							Password string `yaml:"password"`
+						} `yaml:"basic_auth"`
+					} `yaml:"http_config"`
+				} `yaml:"webhook_configs"`
+			} `yaml:"receivers"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(body.AlertmanagerConfig), &amConfig))
+		require.Equal(t, "autogen-contact-point-default", amConfig.Route.Receiver)
+		require.Equal(t, []string{`__contacts__=~".*\"hook\".*"`}, amConfig.Route.Routes[0].Matchers)
+		require.Equal(t, []string{"a"}, amConfig.Templates)
+		require.Len(t, amConfig.Receivers, 2)
+		hook := amConfig.Receivers[1]
+		require.Equal(t, "hook", hook.Name)
+		require.Len(t, hook.WebhookConfigs, 1)
+		require.Equal(t, "http://hook", hook.WebhookConfigs[0].URL)
+		require.False(t, hook.WebhookConfigs[0].SendResolved)
+		require.Equal(t, "user", hook.WebhookConfigs[0].HTTPConfig.BasicAuth.Username)
+		require.Equal(t, "secret", hook.WebhookConfigs[0].HTTPConfig.BasicAuth.Password)
+	})
+
+	t.Run("returns the error of the Alertmanager", func(t *testing.T) {
+		srv, _ := newServer(t, http.StatusBadRequest)
+		m := newTestMigration(t)
+		m.upgradeCfg.PushAlertmanagerConfigURL = srv.URL
+
+		_, err := m.pushAlertmanagerConfig(1, rawAmConfig)
+		require.ErrorContains(t, err, "status: 400")
+	})
+}
+
+func Test_pushStateHistoryToLoki(t *testing.T) {
+	var pushed []lokiStream
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loki/api/v1/query_range":
+			query = r.URL.Query().Get("query")
+			// The entry of r1 at 1000ms was pushed by a previous migration.
+			_, _ = w.Write([]byte(`{"data":{"result":[{"stream":{"from":"state-history"},"values":[["1000000000","{\"ruleUID\":\"r1\"}"],["2000000000","{\"ruleUID\":\"other\"}"]]}]}}`))
+		case "/loki/api/v1/push":
+			var body struct {
+				Streams []lokiStream `json:"streams"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			pushed = append(pushed, body.Streams...)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	m := newTestMigration(t)
+	m.stateHistoryCfg.LokiRemoteURL = srv.URL
+	rule := &alertRule{OrgID: 1, UID: "r1", Title: "r1", RuleGroup: "group", NamespaceUID: "folder"}
+	entries := []stateHistoryEntry{
+		{rule: rule, annotation: legacyAnnotation{Epoch: 1000}, previous: "Normal", current: "Alerting"},
+		{rule: rule, annotation: legacyAnnotation{Epoch: 2000}, previous: "Alerting", current: "Normal"},
+	}
+
+	require.NoError(t, m.pushStateHistoryToLoki(1, entries))
+	require.Equal(t, `{from="state-history",orgID="1"}`, query)
+	require.Len(t, pushed, 1)
+	require.Len(t, pushed[0].Values, 1)
+	require.Equal(t, "2000000000", pushed[0].Values[0][0])
+
+	// Nothing is pushed once all the entries are in Loki.
+	pushed = nil
+	require.NoError(t, m.pushStateHistoryToLoki(1, entries[:1]))
+	require.Empty(t, pushed)
+}
+
+func Test_lookupDashboards(t *testing.T) {
+	batchSize := dashboardLookupBatchSize
+	t.Cleanup(func() { dashboardLookupBatchSize = batchSize })
+	dashboardLookupBatchSize = 2
+
+	keys := []dashboardKey{
+		{orgID: 1, uid: "a"}, {orgID: 1, uid: "b"}, {orgID: 1, uid: "c"}, {orgID: 1, uid: "a"},
+		{orgID: 2, uid: "a"}, {orgID: 2, uid: "b"},
+	}
+	found := func(orgID int64, uids []string) []dashboard {
+		dashes := make([]dashboard, 0, len(uids))
+		for _, uid := range uids {
+			dashes = append(dashes, dashboard{OrgId: orgID, Uid: uid})
+		}
+		return dashes
+	}
+
+	t.Run("runs at most limit lookups at the same time", func(t *testing.T) {
+		started := make(chan []string)
+		release := make(chan struct{})
+		lookup := func(orgID int64, uids []string) ([]dashboard, error) {
+			started <- uids
+			<-release
+			return found(orgID, uids), nil
+		}
+
+		var (
+			dashboards map[dashboardKey]*dashboard
+			err        error
+		)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			dashboards, err = lookupDashboards(context.Background(), keys, 2, lookup)
+		}()
+
+		// The batches are org 1 [a b], org 1 [c] and org 2 [a b]: two start, the third waits for one of them to finish.
+		require.Equal(t, []string{"a", "b"}, <-started)
+		require.Equal(t, []string{"c"}, <-started)
+		select {
+		case uids := <-started:
+			t.Fatalf("lookup of %v started while two lookups were running", uids)
+		default:
+		}
+		release <- struct{}{}
+		require.Equal(t, []string{"a", "b"}, <-started)
+		close(release)
+		<-done
+
+		require.NoError(t, err)
+		require.Len(t, dashboards, 5)
+		require.Equal(t, "c", dashboards[dashboardKey{orgID: 1, uid: "c"}].Uid)
+		require.Equal(t, int64(2), dashboards[dashboardKey{orgID: 2, uid: "b"}].OrgId)
+	})
+
+	t.Run("returns the error of a lookup", func(t *testing.T) {
+		lookup := func(orgID int64, uids []string) ([]dashboard, error) {
+			if orgID == 2 {
+				return nil, errors.New("boom")
+			}
+			return found(orgID, uids), nil
+		}
+		_, err := lookupDashboards(context.Background(), keys, 1, lookup)
+		require.ErrorContains(t, err, "failed to get dashboards under organisation 2: boom")
+	})
+}
@@ -0,0 +1,161 @@
+package ualert
+
+import (
+	"fmt"
+)
+
+// migratedResources maps the [orgID, legacyID] of previously migrated legacy alerts and notification channels, by
+// their mapping type, and of the dashboards whose alerts were migrated to a folder of their own, by the folder audit
+// resource type, to the UID of the resource created for them.
+type migratedResources map[string]map[[2]int64]string
+
+// get returns the resource of the given type that a previous migration created for the legacy ID.
+func (r migratedResources) get(resourceType string, orgID, legacyID int64) (string, bool) {
+	uid, ok := r[resourceType][[2]int64{orgID, legacyID}]
+	return uid, ok
+}
+
+// set records the resource of the given type that a previous migration created for the legacy ID.
+func (r migratedResources) set(resourceType string, orgID, legacyID int64, uid string) {
+	if _, ok := r[resourceType]; !ok {
+		r[resourceType] = make(map[[2]int64]string)
+	}
+	r[resourceType][[2]int64{orgID, legacyID}] = uid
+}
+
+// loadMigratedResources reads the resources of previous migrations that still exist. The alert rules and contact
+// points are read from the alert_migration_mapping table, which the migration of the organizations replaces, and the
+// folders from the alert_migration_audit table. Alert rules and folders are only returned if they are still present in
+// the database.
+func (m *migration) loadMigratedResources() (migratedResources, error) {
+	type orgUID struct {
+		OrgID int64  `xorm:"org_id"`
+		UID   string `xorm:"uid"`
+	}
+	cond, args := orgCondition("org_id", m.orgID)
+	var rules []orgUID
+	if err := m.sess.SQL("SELECT org_id, uid FROM alert_rule WHERE "+cond, args...).Find(&rules); err != nil {
+		return nil, fmt.Errorf("failed to read existing alert rules: %w", err)
+	}
+	existingRules := make(map[orgUID]struct{}, len(rules))
+	for _, r := range rules {
+		existingRules[r] = struct{}{}
+	}
+
+	result := make(migratedResources)
+	exists, err := m.sess.IsTableExist("alert_migration_mapping")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		var mappings []alertMigrationMapping
+		if err := m.sess.Table("alert_migration_mapping").Where(cond, args...).Find(&mappings); err != nil {
+			return nil, fmt.Errorf("failed to read legacy ID mappings: %w", err)
+		}
+		for _, e := range mappings {
+			if e.UID == "" {
+				continue
+			}
+			if e.LegacyType == mappingLegacyAlert {
+				if _, ok := existingRules[orgUID{OrgID: e.OrgID, UID: e.UID}]; !ok {
+					continue
+				}
+			}
+			result.set(e.LegacyType, e.OrgID, e.LegacyID, e.UID)
+		}
+	}
+
+	entries, err := queryLiveAuditEntries(m.sess)
+	if err != nil {
+		return nil, err
+	}
+	var folders []orgUID
+	if err := m.sess.SQL("SELECT org_id, uid FROM dashboard WHERE is_folder = ? AND "+cond, append([]any{true}, args...)...).Find(&folders); err != nil {
+		return nil, fmt.Errorf("failed to read existing folders: %w", err)
+	}
+	existingFolders := make(map[orgUID]struct{}, len(folders))
+	for _, f := range folders {
+		existingFolders[f] = struct{}{}
+	}
+	for _, e := range entries {
+		// The general alerting folder has no legacy ID and cannot be matched.
+		if e.ResourceType != auditResourceFolder || e.LegacyID == 0 {
+			continue
+		}
+		if _, ok := existingFolders[orgUID{OrgID: e.OrgID, UID: e.ResourceUID}]; ok {
+			result.set(auditResourceFolder, e.OrgID, e.LegacyID, e.ResourceUID)
+		}
+	}
+	return result, nil
+}
+
+// prepareOrgUpsert deletes the alert rules that a previous migration created for legacy alerts of the organization that
+// no longer exist, and the legacy ID mappings of the organization, which its migration writes again for the resources
+// it updates in place.
+func (m *migration) prepareOrgUpsert(orgID int64) error {
+	var legacyIDs []int64
+	if err := m.sess.Table("alert").Where("org_id = ?", orgID).Cols("id").Find(&legacyIDs); err != nil {
+		return fmt.Errorf("failed to read legacy alerts: %w", err)
+	}
+	legacy := make(map[int64]struct{}, len(legacyIDs))
+	for _, id := range legacyIDs {
+		legacy[id] = struct{}{}
+	}
+
+	for key, uid := range m.migrated[mappingLegacyAlert] {
+		if key[0] != orgID {
+			continue
+		}
+		if _, ok := legacy[key[1]]; ok {
+			continue
+		}
+		m.mg.Logger.Info("Deleting alert rule of a previous migration whose legacy alert no longer exists", "orgID", orgID, "alertID", key[1], "uid", uid)
+		if err := m.deleteRule(orgID, uid); err != nil {
+			return err
+		}
+		m.audit.recordDelete(orgID, auditResourceAlertRule, uid, key[1], "")
+		delete(m.migrated[mappingLegacyAlert], key)
+	}
+
+	return deleteMappings(m.sess, orgID)
+}
+
+// deleteRule deletes the alert rule together with its versions and instances.
+func (m *migration) deleteRule(orgID int64, uid string) error {
+	if _, err := m.sess.Exec("delete from alert_rule where org_id = ? and uid = ?", orgID, uid); err != nil {
+		return fmt.Errorf("failed to delete alert rule %s: %w", uid, err)
+	}
+	if _, err := m.sess.Exec("delete from alert_rule_version where rule_org_id = ? and rule_uid = ?", orgID, uid); err != nil {
+		return fmt.Errorf("failed to delete versions of alert rule %s: %w", uid, err)
+	}
+	if _, err := m.sess.Exec("delete from alert_instance where rule_org_id = ? and rule_uid = ?", orgID, uid); err != nil {
+		return fmt.Errorf("failed to delete instances of alert rule %s: %w", uid, err)
+	}
+	return nil
+}
+
+// updateRule replaces the alert rule with the same UID that a previous migration created, and records a new version.
+func (m *migration) updateRule(rule *alertRule) error {
+	existing := alertRule{}
+	has, err := m.sess.Table("alert_rule").Cols("id", "version").Where("org_id = ? AND uid = ?", rule.OrgID, rule.UID).Get(&existing)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rule %s: %w", rule.UID, err)
+	}
+	if !has {
+		return fmt.Errorf("alert rule %s not found", rule.UID)
+	}
+
+	rule.ID = existing.ID
+	rule.Version = existing.Version + 1
+	if _, err := m.sess.ID(rule.ID).AllCols().Update(rule); err != nil {
+		return fmt.Errorf("failed to update alert rule %s: %w", rule.UID, err)
+	}
+
+	version := rule.makeVersion()
+	version.ParentVersion = existing.Version
+	version.Version = rule.Version
+	if _, err := m.sess.Insert(version); err != nil {
+		return fmt.Errorf("failed to insert version of alert rule %s: %w", rule.UID, err)
+	}
+	return nil
+}
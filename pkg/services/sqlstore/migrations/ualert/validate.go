@@ -0,0 +1,438 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ValidationReport lists the legacy alerts and notification channels that the dashboard alert migration cannot convert.
+type ValidationReport struct {
+	// AlertCount and ChannelCount are the number of legacy alerts and notification channels that were checked.
+	AlertCount   int                        `json:"alertCount"`
+	ChannelCount int                        `json:"channelCount"`
+	Alerts       []AlertValidationProblem   `json:"alerts"`
+	Channels     []ChannelValidationProblem `json:"channels"`
+	// AlertWarnings lists the legacy alerts that can be migrated but not unchanged, for example because they are renamed.
+	AlertWarnings []AlertValidationProblem `json:"alertWarnings"`
+	// ChannelWarnings lists the notification channels that can be migrated but not with the same behavior.
+	ChannelWarnings []ChannelValidationProblem `json:"channelWarnings"`
+	// Conflicts lists the unified alerting resources that organizations already have, handled according to the
+	// conflict_policy setting.
+	Conflicts []ConflictValidationProblem `json:"conflicts"`
+	// Datasources lists the data source types, per organization, whose queries unified alerting cannot evaluate. They
+	// are only checked if the data source plugins are found on disk.
+	Datasources []DatasourceValidationProblem `json:"datasources"`
+	// AlertSummaries and ChannelSummaries are the outcome of every legacy alert and notification channel.
+	AlertSummaries   []AlertSummary   `json:"alertSummaries"`
+	ChannelSummaries []ChannelSummary `json:"channelSummaries"`
+}
+
+// Outcomes of the legacy alerts and notification channels in a ValidationReport.
+const (
+	OutcomeMigrated             = "migrated"
+	OutcomeMigratedWithWarnings = "migrated_with_warnings"
+	OutcomeNotMigrated          = "not_migrated"
+)
+
+// AlertSummary is the outcome of the migration of a legacy alert, with the problems and warnings that decide it.
+type AlertSummary struct {
+	OrgID       int64    `json:"orgId"`
+	AlertID     int64    `json:"alertId"`
+	DashboardID int64    `json:"dashboardId"`
+	PanelID     int64    `json:"panelId"`
+	Name        string   `json:"name"`
+	Outcome     string   `json:"outcome"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// ChannelSummary is the outcome of the migration of a legacy notification channel, with the problems and warnings
+// that decide it.
+type ChannelSummary struct {
+	OrgID   int64    `json:"orgId"`
+	UID     string   `json:"uid"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Outcome string   `json:"outcome"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// AlertValidationProblem describes why a legacy alert cannot be migrated.
+type AlertValidationProblem struct {
+	OrgID       int64  `json:"orgId"`
+	AlertID     int64  `json:"alertId"`
+	DashboardID int64  `json:"dashboardId"`
+	PanelID     int64  `json:"panelId"`
+	Name        string `json:"name"`
+	Reason      string `json:"reason"`
+}
+
+// ChannelValidationProblem describes why a legacy notification channel cannot be migrated.
+type ChannelValidationProblem struct {
+	OrgID  int64  `json:"orgId"`
+	UID    string `json:"uid"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// ConflictValidationProblem describes a unified alerting resource that an organization has before it is migrated.
+type ConflictValidationProblem struct {
+	OrgID  int64  `json:"orgId"`
+	Reason string `json:"reason"`
+}
+
+// DatasourceValidationProblem describes a data source type of an organization whose queries unified alerting cannot
+// evaluate, with the legacy alerts that query data sources of the type.
+type DatasourceValidationProblem struct {
+	OrgID    int64   `json:"orgId"`
+	Type     string  `json:"type"`
+	AlertIDs []int64 `json:"alertIds"`
+	Reason   string  `json:"reason"`
+}
+
+// HasProblems returns true if at least one legacy alert or notification channel cannot be migrated, or if a data
+// source type is not supported.
+func (r *ValidationReport) HasProblems() bool {
+	return len(r.Alerts) > 0 || len(r.Channels) > 0 || len(r.Datasources) > 0
+}
+
+func (r *ValidationReport) addAlertProblem(da dashAlert, err error) {
+	r.Alerts = append(r.Alerts, newAlertValidationProblem(da, err))
+}
+
+func (r *ValidationReport) addAlertWarning(da dashAlert, err error) {
+	r.AlertWarnings = append(r.AlertWarnings, newAlertValidationProblem(da, err))
+}
+
+func newAlertValidationProblem(da dashAlert, err error) AlertValidationProblem {
+	return AlertValidationProblem{
+		OrgID:       da.OrgId,
+		AlertID:     da.Id,
+		DashboardID: da.DashboardId,
+		PanelID:     da.PanelId,
+		Name:        da.Name,
+		Reason:      err.Error(),
+	}
+}
+
+func (r *ValidationReport) addChannelProblem(c *notificationChannel, err error) {
+	r.Channels = append(r.Channels, ChannelValidationProblem{
+		OrgID:  c.OrgID,
+		UID:    c.Uid,
+		Name:   c.Name,
+		Type:   c.Type,
+		Reason: err.Error(),
+	})
+}
+
+func (r *ValidationReport) addChannelWarning(c *notificationChannel, err error) {
+	r.ChannelWarnings = append(r.ChannelWarnings, ChannelValidationProblem{
+		OrgID:  c.OrgID,
+		UID:    c.Uid,
+		Name:   c.Name,
+		Type:   c.Type,
+		Reason: err.Error(),
+	})
+}
+
+// summarize adds the outcome of every legacy alert and notification channel from the problems and warnings of the report.
+func (r *ValidationReport) summarize(dashAlerts []dashAlert, channels []notificationChannel) {
+	type reasons struct {
+		problems, warnings []string
+	}
+	outcome := func(rs reasons) (string, []string) {
+		switch {
+		case len(rs.problems) > 0:
+			return OutcomeNotMigrated, append(rs.problems, rs.warnings...)
+		case len(rs.warnings) > 0:
+			return OutcomeMigratedWithWarnings, rs.warnings
+		}
+		return OutcomeMigrated, nil
+	}
+
+	alerts := make(map[[2]int64]reasons)
+	for _, p := range r.Alerts {
+		rs := alerts[[2]int64{p.OrgID, p.AlertID}]
+		rs.problems = append(rs.problems, p.Reason)
+		alerts[[2]int64{p.OrgID, p.AlertID}] = rs
+	}
+	for _, d := range r.Datasources {
+		for _, alertID := range d.AlertIDs {
+			rs := alerts[[2]int64{d.OrgID, alertID}]
+			rs.problems = append(rs.problems, fmt.Sprintf("data source type %q: %s", d.Type, d.Reason))
+			alerts[[2]int64{d.OrgID, alertID}] = rs
+		}
+	}
+	for _, p := range r.AlertWarnings {
+		rs := alerts[[2]int64{p.OrgID, p.AlertID}]
+		rs.warnings = append(rs.warnings, p.Reason)
+		alerts[[2]int64{p.OrgID, p.AlertID}] = rs
+	}
+	for _, da := range dashAlerts {
+		o, rs := outcome(alerts[[2]int64{da.OrgId, da.Id}])
+		r.AlertSummaries = append(r.AlertSummaries, AlertSummary{
+			OrgID:       da.OrgId,
+			AlertID:     da.Id,
+			DashboardID: da.DashboardId,
+			PanelID:     da.PanelId,
+			Name:        da.Name,
+			Outcome:     o,
+			Reasons:     rs,
+		})
+	}
+
+	type channelKey struct {
+		orgID int64
+		uid   string
+	}
+	chans := make(map[channelKey]reasons)
+	for _, p := range r.Channels {
+		rs := chans[channelKey{p.OrgID, p.UID}]
+		rs.problems = append(rs.problems, p.Reason)
+		chans[channelKey{p.OrgID, p.UID}] = rs
+	}
+	for _, p := range r.ChannelWarnings {
+		rs := chans[channelKey{p.OrgID, p.UID}]
+		rs.warnings = append(rs.warnings, p.Reason)
+		chans[channelKey{p.OrgID, p.UID}] = rs
+	}
+	for _, c := range channels {
+		o, rs := outcome(chans[channelKey{c.OrgID, c.Uid}])
+		r.ChannelSummaries = append(r.ChannelSummaries, ChannelSummary{
+			OrgID:   c.OrgID,
+			UID:     c.Uid,
+			Name:    c.Name,
+			Type:    c.Type,
+			Outcome: o,
+			Reasons: rs,
+		})
+	}
+}
+
+// ValidateDashAlertMigration runs the conversion steps of the dashboard alert migration for every legacy alert and
+// notification channel without writing anything to the database, and reports those that cannot be migrated.
+func ValidateDashAlertMigration(sess *xorm.Session, dialect migrator.Dialect, cfg *setting.Cfg) (*ValidationReport, error) {
+	mg := &migrator.Migrator{
+		Dialect: dialect,
+		Logger:  log.New("ualert.validate"),
+		Cfg:     cfg,
+	}
+	m := newMigration(mg)
+	m.sess = sess
+	m.mg = mg
+	return m.validate()
+}
+
+// validate checks every legacy alert with transConditions and every legacy notification channel with createNotifier
+// and validateAlertmanagerConfig, collecting the problems instead of failing on the first one.
+func (m *migration) validate() (*ValidationReport, error) {
+	report := &ValidationReport{}
+
+	dashAlerts, err := m.queryDashAlerts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load legacy alerts: %w", err)
+	}
+
+	dsIDMap, err := m.slurpDSIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load datasources: %w", err)
+	}
+
+	if err := m.loadTemplateMappings(); err != nil {
+		return nil, err
+	}
+
+	if err := m.loadDatasourceUIDMappings(); err != nil {
+		return nil, err
+	}
+
+	var plugins datasourcePlugins
+	if m.mg.Cfg != nil {
+		plugins, err = loadDatasourcePlugins(m.mg.Cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load data source plugins: %w", err)
+		}
+	}
+	compat, err := m.newDatasourceCompatibility(plugins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load datasources: %w", err)
+	}
+
+	type orgDatasource struct {
+		orgID int64
+		uid   string
+	}
+	dsUIDs := make(map[orgDatasource]struct{}, len(dsIDMap))
+	for key, uid := range dsIDMap {
+		dsUIDs[orgDatasource{orgID: key[0], uid: uid}] = struct{}{}
+	}
+
+	report.AlertCount = len(dashAlerts)
+	for _, da := range dashAlerts {
+		if len(da.Name) > DefaultFieldMaxLength {
+			report.addAlertWarning(da, fmt.Errorf("alert name is longer than %d characters and is truncated", DefaultFieldMaxLength))
+		}
+
+		if mapping := m.templateMappings.forOrg(da.OrgId); mapping != nil {
+			if unmapped := mapping.unmappedVariables(tokenizeTmpl(m.mg.Logger, da.Message)); len(unmapped) > 0 {
+				report.addAlertWarning(da, fmt.Errorf("message uses variables that are not in the template mapping: %s", strings.Join(unmapped, ", ")))
+			}
+		}
+
+		if err := json.Unmarshal(da.Settings, &da.ParsedSettings); err != nil {
+			report.addAlertProblem(da, fmt.Errorf("failed to parse alert settings: %w", err))
+			continue
+		}
+		if da.ParsedSettings == nil {
+			report.addAlertProblem(da, fmt.Errorf("alert has no settings"))
+			continue
+		}
+
+		for _, cond := range da.ParsedSettings.Conditions {
+			uid := dsIDMap.GetUID(da.OrgId, cond.Query.DatasourceID)
+			if uid == "" && m.dsUIDMappings != nil {
+				uid = modelDatasourceUID(cond.Query.Model)
+			}
+			if uid == "" {
+				switch m.upgradeCfg.MissingDatasource {
+				case setting.MissingDatasourceFallback:
+					fallback := m.upgradeCfg.MissingDatasourceFallbackUID
+					if _, ok := dsUIDs[orgDatasource{orgID: da.OrgId, uid: fallback}]; !ok {
+						report.addAlertProblem(da, fmt.Errorf("datasource with ID %d not found and fallback datasource with UID %s not found", cond.Query.DatasourceID, fallback))
+					} else {
+						report.addAlertWarning(da, fmt.Errorf("datasource with ID %d not found, query migrated to the fallback datasource", cond.Query.DatasourceID))
+					}
+				case setting.MissingDatasourcePause:
+					report.addAlertWarning(da, fmt.Errorf("datasource with ID %d not found, alert migrated paused", cond.Query.DatasourceID))
+				default:
+					report.addAlertProblem(da, fmt.Errorf("datasource with ID %d not found", cond.Query.DatasourceID))
+				}
+				continue
+			}
+			remapped := m.dsUIDMappings.remap(da.OrgId, uid)
+			if _, ok := dsUIDs[orgDatasource{orgID: da.OrgId, uid: remapped}]; !ok {
+				if remapped != uid {
+					report.addAlertProblem(da, fmt.Errorf("datasource with UID %s, mapped from %s, not found", remapped, uid))
+				} else {
+					report.addAlertProblem(da, fmt.Errorf("datasource with UID %s not found", uid))
+				}
+				continue
+			}
+			compat.check(da, remapped)
+		}
+
+		cond, err := transConditions(*da.ParsedSettings, da.OrgId, dsIDMap, m.dsUIDMappings)
+		if err != nil {
+			report.addAlertProblem(da, fmt.Errorf("failed to translate conditions: %w", err))
+			continue
+		}
+		if m.upgradeCfg.MultiDimensionalRules && !m.upgradeCfg.ConvertClassicConditions {
+			if _, reason, err := convertMultiDimensional(*cond); err != nil {
+				report.addAlertProblem(da, fmt.Errorf("failed to convert classic condition: %w", err))
+			} else if reason != "" {
+				report.addAlertWarning(da, fmt.Errorf("not converted to a multi-dimensional alert rule: %s", reason))
+			}
+		}
+	}
+
+	channels, err := m.queryNotificationChannels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification channels: %w", err)
+	}
+
+	report.ChannelCount = len(channels)
+	for i := range channels {
+		c := &channels[i]
+		if isDiscontinuedChannelType(c.Type) {
+			report.addChannelProblem(c, fmt.Errorf("notification channel type %q is discontinued", c.Type))
+			continue
+		}
+
+		// createNotifier exits the process when secure settings cannot be decrypted, so check them upfront.
+		if _, err := c.SecureSettings.tryDecrypt(); err != nil {
+			report.addChannelProblem(c, err)
+			continue
+		}
+
+		if m.upgradeCfg.AlertmanagerChannelsToDatasources && c.Type == legacyAlertmanagerChannelType {
+			if _, err := alertmanagerChannelURLs(c); err != nil {
+				report.addChannelProblem(c, err)
+			}
+			continue
+		}
+
+		notifier, err := m.createNotifier(c)
+		if err != nil {
+			report.addChannelProblem(c, fmt.Errorf("failed to create notifier: %w", err))
+			continue
+		}
+
+		config := &PostableUserConfig{
+			AlertmanagerConfig: PostableApiAlertingConfig{
+				Receivers: []*PostableApiReceiver{
+					{Name: c.Name, GrafanaManagedReceivers: []*PostableGrafanaReceiver{notifier}},
+				},
+			},
+		}
+		if err := m.validateAlertmanagerConfig(config); err != nil {
+			if m.upgradeCfg.LenientAlertmanagerValidation {
+				// The receiver is quarantined and its alerts are routed to the default receiver.
+				report.addChannelWarning(c, fmt.Errorf("contact point is not migrated: %w", err))
+			} else {
+				report.addChannelProblem(c, err)
+			}
+			continue
+		}
+
+		if err := checkUploadImage(c, m.screenshotCfg); err != nil {
+			report.addChannelWarning(c, err)
+		}
+		if _, err := disableResolveMessage(c, m.upgradeCfg.ForceResolveMessageChannelTypes); err != nil {
+			report.addChannelWarning(c, err)
+		}
+		if c.Type == "webhook" {
+			if _, err := convertWebhookHTTPMethod(c); err != nil {
+				report.addChannelWarning(c, err)
+			}
+		}
+		if unmapped := unmappedChannelSettings(c, notifier); len(unmapped) > 0 {
+			report.addChannelWarning(c, fmt.Errorf("settings not migrated: %s", strings.Join(unmapped, ", ")))
+		}
+	}
+
+	report.Datasources = compat.report()
+	report.summarize(dashAlerts, channels)
+
+	seen := make(map[int64]struct{})
+	orgIDs := make([]int64, 0)
+	for _, da := range dashAlerts {
+		orgIDs = append(orgIDs, da.OrgId)
+	}
+	for _, c := range channels {
+		orgIDs = append(orgIDs, c.OrgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+	for _, orgID := range orgIDs {
+		if _, ok := seen[orgID]; ok {
+			continue
+		}
+		seen[orgID] = struct{}{}
+		conflicts, err := m.detectConflicts(orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range conflicts.describe() {
+			report.Conflicts = append(report.Conflicts, ConflictValidationProblem{OrgID: orgID, Reason: d})
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,28 @@
+package ualert
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// convertWebhookHTTPMethod returns the httpMethod setting of the webhook integration that the legacy webhook channel is
+// migrated to. The other settings of legacy webhook channels, url, username and password, have the same names and
+// meaning in the webhook integration. Both send with the notifications service of Grafana, through the proxy of its
+// environment and with the certificates of the system, so the transport is unchanged. The notifications service only
+// sends with POST and PUT, the method of the channel is upper-cased and defaults to POST, and an error is returned if
+// the channel had another method, with which it could not send, as the integration sends with POST instead.
+func convertWebhookHTTPMethod(c *notificationChannel) (string, error) {
+	if c.Settings == nil {
+		return http.MethodPost, nil
+	}
+	method := strings.ToUpper(strings.TrimSpace(c.Settings.Get("httpMethod").MustString()))
+	switch method {
+	case "":
+		return http.MethodPost, nil
+	case http.MethodPost, http.MethodPut:
+		return method, nil
+	default:
+		return http.MethodPost, fmt.Errorf("http method %s of the webhook channel is not supported, the contact point sends with %s", method, http.MethodPost)
+	}
+}
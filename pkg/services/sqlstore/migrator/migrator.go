@@ -30,6 +30,8 @@ type Migrator struct {
 	isLocked     atomic.Bool
 	logMap       map[string]MigrationLog
 	tableName    string
+	// transactionEndHooks are called once the transaction of the running migration is committed or rolled back.
+	transactionEndHooks []func(committed bool)
 }
 
 type MigrationLog struct {
@@ -227,6 +229,7 @@ func (mg *Migrator) run() (err error) {
 			}
 			return err
 		})
+		mg.endTransaction(err == nil)
 		if err != nil {
 			return fmt.Errorf("%v: %w", fmt.Sprintf("migration failed (id = %s)", m.Id()), err)
 		}
@@ -281,6 +284,21 @@ func (mg *Migrator) exec(m Migration, sess *xorm.Session) error {
 	return nil
 }
 
+// OnTransactionEnd registers f to be called once the transaction of the running code migration is committed or
+// rolled back, committed reports which. It lets a migration hold a lock on another connection until its changes are
+// visible to the other sessions, or run work that must not be undone by a rollback.
+func (mg *Migrator) OnTransactionEnd(f func(committed bool)) {
+	mg.transactionEndHooks = append(mg.transactionEndHooks, f)
+}
+
+func (mg *Migrator) endTransaction(committed bool) {
+	hooks := mg.transactionEndHooks
+	mg.transactionEndHooks = nil
+	for _, f := range hooks {
+		f(committed)
+	}
+}
+
 type dbTransactionFunc func(sess *xorm.Session) error
 
 func (mg *Migrator) InTransaction(callback dbTransactionFunc) error {
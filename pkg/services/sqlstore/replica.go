@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const readReplicaSection = "database_replica"
+
+type readReplicaKey struct{}
+
+// WithReadReplica returns a context allowing the reads made with WithReadReplicaDbSession to be
+// served by the read replica of the database. Only use it for reads that tolerate stale data,
+// such as listing resources for a read-only request, as the replica may lag behind the primary database.
+func WithReadReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readReplicaKey{}, true)
+}
+
+func readReplicaAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(readReplicaKey{}).(bool)
+	return allowed
+}
+
+// WithReadReplicaDbSession behaves like WithDbSession, but runs the callback on the read replica of
+// the database if one is configured, the context allows it, see WithReadReplica, and the context holds
+// no transaction. The callback must only read from the database.
+func (ss *SQLStore) WithReadReplicaDbSession(ctx context.Context, callback DBTransactionFunc) error {
+	if ss.replica == nil || !readReplicaAllowed(ctx) || ctx.Value(ContextSessionKey{}) != nil {
+		return ss.WithDbSession(ctx, callback)
+	}
+
+	return ss.replica.withDbSession(ctx, ss.replica.engine, callback)
+}
+
+// initReadReplica connects to the read replica configured in the [database_replica] section, if any.
+func (ss *SQLStore) initReadReplica() error {
+	sec := ss.Cfg.Raw.Section(readReplicaSection)
+	if sec.Key("url").String() == "" && sec.Key("host").String() == "" && sec.Key("connection_string").String() == "" {
+		return nil
+	}
+
+	replica := &SQLStore{
+		Cfg:          ss.Cfg,
+		CacheService: ss.CacheService,
+		log:          log.New("sqlstore.replica"),
+		bus:          ss.bus,
+		tracer:       ss.tracer,
+		readReplica:  true,
+	}
+
+	if err := replica.readConfig(); err != nil {
+		return err
+	}
+	if replica.dbCfg.Type != migrator.MySQL && replica.dbCfg.Type != migrator.Postgres {
+		return fmt.Errorf("read replica of type %q is not supported, only %q and %q are", replica.dbCfg.Type, migrator.MySQL, migrator.Postgres)
+	}
+	if replica.dbCfg.Type != ss.dbCfg.Type {
+		return fmt.Errorf("read replica of type %q does not match the database of type %q", replica.dbCfg.Type, ss.dbCfg.Type)
+	}
+
+	if err := replica.initEngine(nil); err != nil {
+		return fmt.Errorf("%v: %w", "failed to connect to read replica", err)
+	}
+	replica.Dialect = migrator.NewDialect(replica.engine.DriverName())
+
+	ss.replica = replica
+	return nil
+}
+
+// readReplicaConfig returns the [database_replica] section, completed with the keys of the [database]
+// section it does not set. The url and connection_string keys of the [database] section are not used,
+// as they would take precedence over the host of the replica.
+func readReplicaConfig(raw *ini.File) (*ini.Section, error) {
+	merged, err := ini.Empty().NewSection("database")
+	if err != nil {
+		return nil, err
+	}
+
+	set := func(k *ini.Key) error {
+		if merged.HasKey(k.Name()) {
+			merged.Key(k.Name()).SetValue(k.Value())
+			return nil
+		}
+		_, err := merged.NewKey(k.Name(), k.Value())
+		return err
+	}
+
+	for _, k := range raw.Section("database").Keys() {
+		if k.Name() == "url" || k.Name() == "connection_string" {
+			continue
+		}
+		if err := set(k); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, k := range raw.Section(readReplicaSection).Keys() {
+		if k.Value() == "" {
+			continue
+		}
+		if err := set(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/VividCortex/mysqlerr"
@@ -56,6 +57,9 @@ type SQLStore struct {
 	tracer                       tracing.Tracer
 	recursiveQueriesAreSupported *bool
 	recursiveQueriesMu           sync.Mutex
+	// replica is the store of the read replica of the database, if one is configured, see WithReadReplicaDbSession.
+	replica     *SQLStore
+	readReplica bool
 }
 
 func ProvideService(cfg *setting.Cfg, cacheService *localcache.CacheService, migrations registry.DatabaseMigrator, bus bus.Bus, tracer tracing.Tracer) (*SQLStore, error) {
@@ -77,6 +81,10 @@ func ProvideService(cfg *setting.Cfg, cacheService *localcache.CacheService, mig
 	}
 	s.tracer = tracer
 
+	if err := s.initReadReplica(); err != nil {
+		return nil, err
+	}
+
 	// initialize and register metrics wrapper around the *sql.DB
 	db := s.engine.DB().DB
 
@@ -467,6 +475,12 @@ func (ss *SQLStore) ensureTransactionIsolationCompatibility(engine *xorm.Engine,
 // readConfig initializes the SQLStore from its configuration.
 func (ss *SQLStore) readConfig() error {
 	sec := ss.Cfg.Raw.Section("database")
+	if ss.readReplica {
+		var err error
+		if sec, err = readReplicaConfig(ss.Cfg.Raw); err != nil {
+			return err
+		}
+	}
 
 	cfgURL := sec.Key("url").String()
 	if len(cfgURL) != 0 {
@@ -611,7 +625,6 @@ func InitTestDBWithCfg(t ITestDB, opts ...InitTestDBOpt) (*SQLStore, *setting.Cf
 	return store, store.Cfg
 }
 
-//nolint:gocyclo
 func initTestDB(testCfg *setting.Cfg, migration registry.DatabaseMigrator, opts ...InitTestDBOpt) (*SQLStore, error) {
 	testSQLStoreMutex.Lock()
 	defer testSQLStoreMutex.Unlock()
@@ -620,119 +633,160 @@ func initTestDB(testCfg *setting.Cfg, migration registry.DatabaseMigrator, opts
 		opts = []InitTestDBOpt{{EnsureDefaultOrgAndUser: false, FeatureFlags: []string{}}}
 	}
 
-	features := make([]string, len(featuresEnabledDuringTests))
-	copy(features, featuresEnabledDuringTests)
-	for _, opt := range opts {
-		if len(opt.FeatureFlags) > 0 {
-			features = append(features, opt.FeatureFlags...)
-		}
-	}
+	features := testFeatureFlags(opts)
 
 	if testSQLStore == nil {
-		dbType := migrator.SQLite
+		dbType := testDBType()
 
-		// environment variable present for test db?
-		if db, present := os.LookupEnv("GRAFANA_TEST_DB"); present {
-			dbType = db
-		}
-
-		// set test db config
-		cfg := setting.NewCfg()
-		cfg.IsFeatureToggleEnabled = func(key string) bool {
-			for _, enabledFeature := range features {
-				if enabledFeature == key {
-					return true
-				}
-			}
-			return false
-		}
-
-		sec, err := cfg.Raw.NewSection("database")
-		if err != nil {
-			return nil, err
-		}
-
-		if _, err := sec.NewKey("type", dbType); err != nil {
-			return nil, err
-		}
+		var testDB sqlutil.TestDB
 		switch dbType {
 		case "mysql":
-			if _, err := sec.NewKey("connection_string", sqlutil.MySQLTestDB().ConnStr); err != nil {
-				return nil, err
-			}
+			testDB = sqlutil.MySQLTestDB()
 		case "postgres":
-			if _, err := sec.NewKey("connection_string", sqlutil.PostgresTestDB().ConnStr); err != nil {
-				return nil, err
-			}
+			testDB = sqlutil.PostgresTestDB()
 		default:
-			if _, err := sec.NewKey("connection_string", sqlutil.SQLite3TestDB().ConnStr); err != nil {
-				return nil, err
-			}
+			testDB = sqlutil.SQLite3TestDB()
 		}
 
 		// useful if you already have a database that you want to use for tests.
 		// cannot just set it on testSQLStore as it overrides the config in Init
-		if _, present := os.LookupEnv("SKIP_MIGRATIONS"); present {
-			if _, err := sec.NewKey("skip_migrations", "true"); err != nil {
-				return nil, err
-			}
+		_, skipMigrations := os.LookupEnv("SKIP_MIGRATIONS")
+
+		store, err := newTestSQLStore(testCfg, migration, dbType, testDB.ConnStr, skipMigrations, features, opts...)
+		if err != nil {
+			return nil, err
 		}
 
-		if testCfg.Raw.HasSection("database") {
-			testSec, err := testCfg.Raw.GetSection("database")
-			if err == nil {
-				// copy from testCfg to the Cfg keys that do not exist
-				for _, k := range testSec.Keys() {
-					if sec.HasKey(k.Name()) {
-						continue
-					}
-					if _, err := sec.NewKey(k.Name(), k.Value()); err != nil {
-						return nil, err
-					}
-				}
+		testSQLStore = store
+		return testSQLStore, nil
+	}
+
+	testSQLStore.Cfg.IsFeatureToggleEnabled = func(key string) bool {
+		for _, enabledFeature := range features {
+			if enabledFeature == key {
+				return true
 			}
 		}
+		return false
+	}
 
-		// need to get engine to clean db before we init
-		engine, err := xorm.NewEngine(dbType, sec.Key("connection_string").String())
-		if err != nil {
-			return nil, err
+	if err := testSQLStore.Dialect.TruncateDBTables(testSQLStore.GetEngine()); err != nil {
+		return nil, err
+	}
+	if err := testSQLStore.Reset(); err != nil {
+		return nil, err
+	}
+
+	return testSQLStore, nil
+}
+
+// ITestDBWithCleanup is an ITestDB that can register functions to call when the test finishes, such as testing.T.
+type ITestDBWithCleanup interface {
+	ITestDB
+	Cleanup(func())
+}
+
+var isolatedTestDBCount atomic.Int64
+
+// InitIsolatedTestDB initializes a test DB dedicated to the test, unlike InitTestDB which resets
+// and returns the DB shared by the tests of the package, so that the tests using it can run with
+// t.Parallel(). The DB is created for the test and dropped when the test finishes, and every test
+// runs the full set of migrations on its DB, which takes longer than resetting the shared one.
+// With MySQL and Postgres, the test database user must be allowed to create and drop databases,
+// the MySQL user of devenv/docker/blocks/mysql_tests and of the CI is granted it on the databases
+// named grafana_test_*. With SQLite the DB is stored in a temporary file.
+//
+// It does not take testSQLStoreMutex, which only guards testSQLStore: every isolated DB has its own
+// database, engine and configuration, and its name is unique thanks to isolatedTestDBCount.
+func InitIsolatedTestDB(t ITestDBWithCleanup, opts ...InitTestDBOpt) *SQLStore {
+	t.Helper()
+	store, cleanup, err := initIsolatedTestDB(setting.NewCfg(), &migrations.OSSMigrations{}, opts...)
+	if err != nil {
+		t.Fatalf("failed to initialize isolated sql store: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := cleanup(); err != nil {
+			t.Logf("failed to clean up isolated sql store: %s", err)
 		}
+	})
+	return store
+}
 
-		engine.DatabaseTZ = time.UTC
-		engine.TZLocation = time.UTC
+func initIsolatedTestDB(testCfg *setting.Cfg, migration registry.DatabaseMigrator, opts ...InitTestDBOpt) (*SQLStore, func() error, error) {
+	if len(opts) == 0 {
+		opts = []InitTestDBOpt{{EnsureDefaultOrgAndUser: false, FeatureFlags: []string{}}}
+	}
+
+	dbType := testDBType()
+	// the process id keeps the name unique across the test binaries of the packages, which run concurrently
+	name := fmt.Sprintf("grafana_test_%d_%d", os.Getpid(), isolatedTestDBCount.Add(1))
 
-		tracer := tracing.InitializeTracerForTest()
-		bus := bus.ProvideBus(tracer)
-		testSQLStore, err = newSQLStore(cfg, localcache.New(5*time.Minute, 10*time.Minute), engine, migration, bus, tracer, opts...)
+	var testDB, serverDB sqlutil.TestDB
+	var sqliteDir string
+	switch dbType {
+	case "mysql":
+		testDB, serverDB = sqlutil.MySQLTestDBWithName(name), sqlutil.MySQLTestDB()
+	case "postgres":
+		testDB, serverDB = sqlutil.PostgresTestDBWithName(name), sqlutil.PostgresTestDB()
+	default:
+		// a shared in-memory SQLite database would be dropped whenever the pool closes its last connection,
+		// and would fail with SQLITE_LOCKED when two connections use the same table
+		var err error
+		sqliteDir, err = os.MkdirTemp("", name)
 		if err != nil {
-			return nil, err
+			return nil, nil, fmt.Errorf("failed to create test database %s: %w", name, err)
 		}
+		sqlitePath := filepath.Join(sqliteDir, "grafana.db")
+		testDB = sqlutil.SQLite3TestDBWithPath(sqlitePath)
+		// the store checks the file of the database at the configured path
+		testCfg.Raw.Section("database").Key("path").SetValue(sqlitePath)
+	}
 
-		if err := testSQLStore.Migrate(false); err != nil {
-			return nil, err
+	execOnServer := func(sql string) error {
+		if serverDB.ConnStr == "" {
+			return nil
 		}
 
-		if err := testSQLStore.Dialect.TruncateDBTables(engine); err != nil {
-			return nil, err
+		engine, err := xorm.NewEngine(dbType, serverDB.ConnStr)
+		if err != nil {
+			return err
 		}
+		defer func() { _ = engine.Close() }()
 
-		if err := testSQLStore.Reset(); err != nil {
-			return nil, err
-		}
+		_, err = engine.Exec(sql)
+		return err
+	}
 
-		// Make sure the changes are synced, so they get shared with eventual other DB connections
-		// XXX: Why is this only relevant when not skipping migrations?
-		if !testSQLStore.dbCfg.SkipMigrations {
-			if err := testSQLStore.Sync(); err != nil {
-				return nil, err
-			}
+	if err := execOnServer("CREATE DATABASE " + name); err != nil {
+		return nil, nil, fmt.Errorf("failed to create test database %s: %w", name, err)
+	}
+	dropDB := func() error {
+		if sqliteDir != "" {
+			return os.RemoveAll(sqliteDir)
 		}
+		return execOnServer("DROP DATABASE IF EXISTS " + name)
+	}
 
-		return testSQLStore, nil
+	store, err := newTestSQLStore(testCfg, migration, dbType, testDB.ConnStr, false, testFeatureFlags(opts), opts...)
+	if err != nil {
+		_ = dropDB()
+		return nil, nil, err
 	}
 
-	testSQLStore.Cfg.IsFeatureToggleEnabled = func(key string) bool {
+	return store, func() error {
+		if err := store.engine.Close(); err != nil {
+			return err
+		}
+		return dropDB()
+	}, nil
+}
+
+// newTestSQLStore connects to the test database, migrates it, and empties it.
+func newTestSQLStore(testCfg *setting.Cfg, migration registry.DatabaseMigrator, dbType, connStr string,
+	skipMigrations bool, features []string, opts ...InitTestDBOpt) (*SQLStore, error) {
+	// set test db config
+	cfg := setting.NewCfg()
+	cfg.IsFeatureToggleEnabled = func(key string) bool {
 		for _, enabledFeature := range features {
 			if enabledFeature == key {
 				return true
@@ -741,14 +795,95 @@ func initTestDB(testCfg *setting.Cfg, migration registry.DatabaseMigrator, opts
 		return false
 	}
 
-	if err := testSQLStore.Dialect.TruncateDBTables(testSQLStore.GetEngine()); err != nil {
+	sec, err := cfg.Raw.NewSection("database")
+	if err != nil {
 		return nil, err
 	}
-	if err := testSQLStore.Reset(); err != nil {
+
+	if _, err := sec.NewKey("type", dbType); err != nil {
+		return nil, err
+	}
+	if _, err := sec.NewKey("connection_string", connStr); err != nil {
 		return nil, err
 	}
+	if skipMigrations {
+		if _, err := sec.NewKey("skip_migrations", "true"); err != nil {
+			return nil, err
+		}
+	}
 
-	return testSQLStore, nil
+	if testCfg.Raw.HasSection("database") {
+		testSec, err := testCfg.Raw.GetSection("database")
+		if err == nil {
+			// copy from testCfg to the Cfg keys that do not exist
+			for _, k := range testSec.Keys() {
+				if sec.HasKey(k.Name()) {
+					continue
+				}
+				if _, err := sec.NewKey(k.Name(), k.Value()); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// need to get engine to clean db before we init
+	engine, err := xorm.NewEngine(dbType, sec.Key("connection_string").String())
+	if err != nil {
+		return nil, err
+	}
+
+	engine.DatabaseTZ = time.UTC
+	engine.TZLocation = time.UTC
+
+	tracer := tracing.InitializeTracerForTest()
+	bus := bus.ProvideBus(tracer)
+	store, err := newSQLStore(cfg, localcache.New(5*time.Minute, 10*time.Minute), engine, migration, bus, tracer, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Migrate(false); err != nil {
+		return nil, err
+	}
+
+	if err := store.Dialect.TruncateDBTables(engine); err != nil {
+		return nil, err
+	}
+
+	if err := store.Reset(); err != nil {
+		return nil, err
+	}
+
+	// Make sure the changes are synced, so they get shared with eventual other DB connections
+	// XXX: Why is this only relevant when not skipping migrations?
+	if !store.dbCfg.SkipMigrations {
+		if err := store.Sync(); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+func testDBType() string {
+	// environment variable present for test db?
+	if db, present := os.LookupEnv("GRAFANA_TEST_DB"); present {
+		return db
+	}
+
+	return migrator.SQLite
+}
+
+func testFeatureFlags(opts []InitTestDBOpt) []string {
+	features := make([]string, len(featuresEnabledDuringTests))
+	copy(features, featuresEnabledDuringTests)
+	for _, opt := range opts {
+		if len(opt.FeatureFlags) > 0 {
+			features = append(features, opt.FeatureFlags...)
+		}
+	}
+	return features
 }
 
 func IsTestDbMySQL() bool {
@@ -177,6 +177,57 @@ func TestIntegrationIsUniqueConstraintViolation(t *testing.T) {
 	}
 }
 
+func TestIntegrationInitIsolatedTestDB(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	countOrgs := func(t *testing.T, store *SQLStore) int64 {
+		t.Helper()
+		var count int64
+		err := store.WithDbSession(context.Background(), func(sess *DBSession) error {
+			var err error
+			count, err = sess.Table("org").Count()
+			return err
+		})
+		require.NoError(t, err)
+		return count
+	}
+
+	for i := 0; i < 2; i++ {
+		t.Run("isolated test db should not see the data of the other tests", func(t *testing.T) {
+			t.Parallel()
+
+			store := InitIsolatedTestDB(t)
+			require.Zero(t, countOrgs(t, store))
+
+			err := store.WithDbSession(context.Background(), func(sess *DBSession) error {
+				now := time.Now()
+				_, err := sess.Insert(&org.Org{Name: "isolated org", Created: now, Updated: now})
+				return err
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 1, countOrgs(t, store))
+		})
+	}
+
+	t.Run("isolated test db should keep its data when its connections are closed", func(t *testing.T) {
+		t.Parallel()
+
+		store := InitIsolatedTestDB(t)
+		err := store.WithDbSession(context.Background(), func(sess *DBSession) error {
+			now := time.Now()
+			_, err := sess.Insert(&org.Org{Name: "isolated org", Created: now, Updated: now})
+			return err
+		})
+		require.NoError(t, err)
+
+		// closes the idle connections of the pool
+		store.GetEngine().DB().SetMaxIdleConns(0)
+		require.EqualValues(t, 1, countOrgs(t, store))
+	})
+}
+
 func makeSQLStoreTestConfig(t *testing.T, tc sqlStoreTest) *setting.Cfg {
 	t.Helper()
 
@@ -19,7 +19,20 @@ func SQLite3TestDB() TestDB {
 	}
 }
 
+// SQLite3TestDBWithPath returns a database stored in the file at path. Unlike a shared in-memory database, it is
+// not dropped when its last connection is closed, and its connections do not lock the tables of each other.
+func SQLite3TestDBWithPath(path string) TestDB {
+	return TestDB{
+		DriverName: "sqlite3",
+		ConnStr:    fmt.Sprintf("file:%s?cache=private&mode=rwc", path),
+	}
+}
+
 func MySQLTestDB() TestDB {
+	return MySQLTestDBWithName("grafana_tests")
+}
+
+func MySQLTestDBWithName(name string) TestDB {
 	host := os.Getenv("MYSQL_HOST")
 	if host == "" {
 		host = "localhost"
@@ -28,7 +41,7 @@ func MySQLTestDB() TestDB {
 	if port == "" {
 		port = "3306"
 	}
-	conn_str := fmt.Sprintf("grafana:password@tcp(%s:%s)/grafana_tests?collation=utf8mb4_unicode_ci&sql_mode='ANSI_QUOTES'&parseTime=true", host, port)
+	conn_str := fmt.Sprintf("grafana:password@tcp(%s:%s)/%s?collation=utf8mb4_unicode_ci&sql_mode='ANSI_QUOTES'&parseTime=true", host, port, name)
 	return TestDB{
 		DriverName: "mysql",
 		ConnStr:    conn_str,
@@ -36,6 +49,10 @@ func MySQLTestDB() TestDB {
 }
 
 func PostgresTestDB() TestDB {
+	return PostgresTestDBWithName("grafanatest")
+}
+
+func PostgresTestDBWithName(name string) TestDB {
 	host := os.Getenv("POSTGRES_HOST")
 	if host == "" {
 		host = "localhost"
@@ -44,8 +61,8 @@ func PostgresTestDB() TestDB {
 	if port == "" {
 		port = "5432"
 	}
-	connStr := fmt.Sprintf("user=grafanatest password=grafanatest host=%s port=%s dbname=grafanatest sslmode=disable",
-		host, port)
+	connStr := fmt.Sprintf("user=grafanatest password=grafanatest host=%s port=%s dbname=%s sslmode=disable",
+		host, port, name)
 	return TestDB{
 		DriverName: "postgres",
 		ConnStr:    connStr,
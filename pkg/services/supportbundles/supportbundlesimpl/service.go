@@ -88,6 +88,7 @@ func ProvideService(
 	s.bundleRegistry.RegisterSupportItemCollector(settingsCollector(settings))
 	s.bundleRegistry.RegisterSupportItemCollector(dbCollector(sql))
 	s.bundleRegistry.RegisterSupportItemCollector(pluginInfoCollector(pluginStore, pluginSettings, s.log))
+	s.bundleRegistry.RegisterSupportItemCollector(features.SupportBundleCollector())
 
 	return s, nil
 }
@@ -1,7 +1,11 @@
 package setting
 
 import (
+	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/ini.v1"
 
@@ -44,3 +48,241 @@ func ReadFeatureTogglesFromInitFile(featureTogglesSection *ini.Section) (map[str
 	}
 	return featureToggles, nil
 }
+
+// FeatureToggleTargeting lists the users, teams and organization roles a feature toggle is enabled for, although it
+// is not enabled for the whole instance.
+type FeatureToggleTargeting struct {
+	UserIDs []int64  `json:"userIds,omitempty"`
+	TeamIDs []int64  `json:"teamIds,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+// Suffixes of the keys of [feature_toggles.targeting], which are named after the feature toggle they target.
+const (
+	featureToggleTargetingUsers = "_users"
+	featureToggleTargetingTeams = "_teams"
+	featureToggleTargetingRoles = "_roles"
+)
+
+// ReadFeatureToggleTargetingFromInitFile reads the targeting of the feature toggles from [feature_toggles.targeting],
+// where `<toggle>_users` and `<toggle>_teams` list user and team IDs, and `<toggle>_roles` lists organization roles.
+func ReadFeatureToggleTargetingFromInitFile(targetingSection *ini.Section) (map[string]FeatureToggleTargeting, error) {
+	targeting := make(map[string]FeatureToggleTargeting)
+	for _, v := range targetingSection.Keys() {
+		key := v.Name()
+		var suffix string
+		for _, s := range []string{featureToggleTargetingUsers, featureToggleTargetingTeams, featureToggleTargetingRoles} {
+			if strings.HasSuffix(key, s) {
+				suffix = s
+			}
+		}
+		name := strings.TrimSuffix(key, suffix)
+		if suffix == "" || name == "" {
+			return targeting, fmt.Errorf("invalid key %s in [feature_toggles.targeting], expected <toggle>_users, <toggle>_teams or <toggle>_roles", key)
+		}
+
+		t := targeting[name]
+		values := util.SplitString(v.Value())
+		switch suffix {
+		case featureToggleTargetingUsers, featureToggleTargetingTeams:
+			ids := make([]int64, 0, len(values))
+			for _, value := range values {
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return targeting, fmt.Errorf("invalid ID %s of %s in [feature_toggles.targeting]: %w", value, key, err)
+				}
+				ids = append(ids, id)
+			}
+			if suffix == featureToggleTargetingUsers {
+				t.UserIDs = append(t.UserIDs, ids...)
+			} else {
+				t.TeamIDs = append(t.TeamIDs, ids...)
+			}
+		case featureToggleTargetingRoles:
+			t.Roles = append(t.Roles, values...)
+		}
+		targeting[name] = t
+	}
+	return targeting, nil
+}
+
+// ConfigFiles returns the configuration files Grafana was started with, in the order they were applied.
+func (cfg *Cfg) ConfigFiles() []string {
+	return append([]string(nil), configFiles...)
+}
+
+// ReloadFeatureTogglesSection reads the [feature_toggles] section of the configuration files again, layered like on
+// startup and with the environment overrides applied, so the toggles can be updated without a restart.
+func ReloadFeatureTogglesSection(files []string) (*ini.Section, error) {
+	merged := ini.Empty()
+	section := merged.Section("feature_toggles")
+	for i, file := range files {
+		f, err := ini.Load(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", file, err)
+		}
+		for _, key := range f.Section("feature_toggles").Keys() {
+			// Like on startup, empty values only apply from the defaults.
+			if key.Value() == "" && i > 0 {
+				continue
+			}
+			if _, err := section.NewKey(key.Name(), key.Value()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, key := range section.Keys() {
+		if v := os.Getenv(EnvKey("feature_toggles", key.Name())); v != "" {
+			key.SetValue(v)
+		}
+	}
+	return section, nil
+}
+
+// FeatureToggleSchedule is the time window a feature toggle is enabled in. The toggle is enabled from StartAt and until
+// EndAt, when they are set, and disabled outside of the window.
+type FeatureToggleSchedule struct {
+	StartAt *time.Time
+	EndAt   *time.Time
+}
+
+// Suffixes of the keys of [feature_toggles.schedule], which are named after the feature toggle they schedule.
+const (
+	featureToggleScheduleStartAt = "_start_at"
+	featureToggleScheduleEndAt   = "_end_at"
+)
+
+// ReadFeatureToggleScheduleFromInitFile reads the schedules of the feature toggles from [feature_toggles.schedule],
+// where `<toggle>_start_at` and `<toggle>_end_at` are RFC 3339 times.
+func ReadFeatureToggleScheduleFromInitFile(scheduleSection *ini.Section) (map[string]FeatureToggleSchedule, error) {
+	schedules := make(map[string]FeatureToggleSchedule)
+	for _, v := range scheduleSection.Keys() {
+		key := v.Name()
+		var name string
+		switch {
+		case strings.HasSuffix(key, featureToggleScheduleStartAt):
+			name = strings.TrimSuffix(key, featureToggleScheduleStartAt)
+		case strings.HasSuffix(key, featureToggleScheduleEndAt):
+			name = strings.TrimSuffix(key, featureToggleScheduleEndAt)
+		}
+		if name == "" {
+			return schedules, fmt.Errorf("invalid key %s in [feature_toggles.schedule], expected <toggle>_start_at or <toggle>_end_at", key)
+		}
+		if strings.TrimSpace(v.Value()) == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(v.Value()))
+		if err != nil {
+			return schedules, fmt.Errorf("invalid time %s of %s in [feature_toggles.schedule], expected RFC 3339: %w", v.Value(), key, err)
+		}
+
+		schedule := schedules[name]
+		if strings.HasSuffix(key, featureToggleScheduleStartAt) {
+			schedule.StartAt = &at
+		} else {
+			schedule.EndAt = &at
+		}
+		schedules[name] = schedule
+	}
+	for name, schedule := range schedules {
+		if schedule.StartAt != nil && schedule.EndAt != nil && !schedule.EndAt.After(*schedule.StartAt) {
+			return schedules, fmt.Errorf("invalid schedule of %s in [feature_toggles.schedule], the end must be after the start", name)
+		}
+	}
+	return schedules, nil
+}
+
+// FeatureToggleExperiment splits the users or organizations a feature toggle is enabled for between variants.
+type FeatureToggleExperiment struct {
+	Variants []FeatureToggleVariant
+	// Unit is what is assigned to a variant, "user" or "org".
+	Unit string
+}
+
+// FeatureToggleVariant is a variant of an experiment, assigned to a share of the units proportional to its weight.
+type FeatureToggleVariant struct {
+	Name   string
+	Weight int
+}
+
+// Units of FeatureToggleExperiment.
+const (
+	FeatureToggleExperimentUnitUser = "user"
+	FeatureToggleExperimentUnitOrg  = "org"
+)
+
+// Suffixes of the keys of [feature_toggles.experiments], which are named after the feature toggle of the experiment.
+const (
+	featureToggleExperimentVariants = "_variants"
+	featureToggleExperimentUnit     = "_unit"
+)
+
+// ReadFeatureToggleExperimentsFromInitFile reads the experiments of the feature toggles from
+// [feature_toggles.experiments], where `<toggle>_variants` lists the variants as name:weight, and `<toggle>_unit` is
+// user or org.
+func ReadFeatureToggleExperimentsFromInitFile(experimentsSection *ini.Section) (map[string]FeatureToggleExperiment, error) {
+	experiments := make(map[string]FeatureToggleExperiment)
+	for _, v := range experimentsSection.Keys() {
+		key := v.Name()
+		switch {
+		case strings.HasSuffix(key, featureToggleExperimentVariants) && key != featureToggleExperimentVariants:
+			name := strings.TrimSuffix(key, featureToggleExperimentVariants)
+			experiment := experiments[name]
+			for _, variant := range util.SplitString(v.Value()) {
+				variantName, weight, ok := strings.Cut(variant, ":")
+				w, err := strconv.Atoi(weight)
+				if !ok || variantName == "" || err != nil || w <= 0 {
+					return experiments, fmt.Errorf("invalid variant %s of %s in [feature_toggles.experiments], expected name:weight with a positive weight", variant, key)
+				}
+				experiment.Variants = append(experiment.Variants, FeatureToggleVariant{Name: variantName, Weight: w})
+			}
+			experiments[name] = experiment
+		case strings.HasSuffix(key, featureToggleExperimentUnit) && key != featureToggleExperimentUnit:
+			name := strings.TrimSuffix(key, featureToggleExperimentUnit)
+			unit := strings.ToLower(strings.TrimSpace(v.Value()))
+			if unit != FeatureToggleExperimentUnitUser && unit != FeatureToggleExperimentUnitOrg {
+				return experiments, fmt.Errorf("invalid unit %s of %s in [feature_toggles.experiments], expected user or org", v.Value(), key)
+			}
+			experiment := experiments[name]
+			experiment.Unit = unit
+			experiments[name] = experiment
+		default:
+			return experiments, fmt.Errorf("invalid key %s in [feature_toggles.experiments], expected <toggle>_variants or <toggle>_unit", key)
+		}
+	}
+	for name, experiment := range experiments {
+		if len(experiment.Variants) < 2 {
+			return experiments, fmt.Errorf("invalid experiment %s in [feature_toggles.experiments], it needs at least two variants", name)
+		}
+		if experiment.Unit == "" {
+			experiment.Unit = FeatureToggleExperimentUnitUser
+			experiments[name] = experiment
+		}
+	}
+	return experiments, nil
+}
+
+// featureToggleSubsections are the subsections of [feature_toggles] that do not configure a single toggle.
+var featureToggleSubsections = map[string]bool{
+	"targeting":   true,
+	"schedule":    true,
+	"experiments": true,
+}
+
+// ReadFeatureToggleConfigsFromInitFile reads the parameters of the feature toggles from the [feature_toggles.<toggle>]
+// sections, by toggle name.
+func ReadFeatureToggleConfigsFromInitFile(iniFile *ini.File) map[string]map[string]string {
+	configs := make(map[string]map[string]string)
+	for _, section := range iniFile.Sections() {
+		name, ok := strings.CutPrefix(section.Name(), "feature_toggles.")
+		if !ok || name == "" || featureToggleSubsections[name] {
+			continue
+		}
+		values := make(map[string]string, len(section.Keys()))
+		for _, v := range section.Keys() {
+			values[v.Name()] = v.Value()
+		}
+		configs[name] = values
+	}
+	return configs
+}
@@ -3,6 +3,7 @@ package setting
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/ini.v1"
@@ -78,3 +79,230 @@ func TestFeatureToggles(t *testing.T) {
 		}
 	}
 }
+
+func TestFeatureToggleTargeting(t *testing.T) {
+	testCases := []struct {
+		name              string
+		conf              map[string]string
+		expectErr         bool
+		err               error
+		expectedTargeting map[string]FeatureToggleTargeting
+	}{
+		{
+			name: "can parse users, teams and roles",
+			conf: map[string]string{
+				"scenes_users": "1,2",
+				"scenes_teams": "3",
+				"topnav_roles": "Editor Admin",
+			},
+			expectedTargeting: map[string]FeatureToggleTargeting{
+				"scenes": {UserIDs: []int64{1, 2}, TeamIDs: []int64{3}},
+				"topnav": {Roles: []string{"Editor", "Admin"}},
+			},
+		},
+		{
+			name: "invalid ID should return syntax error",
+			conf: map[string]string{
+				"scenes_users": "1,admin",
+			},
+			expectErr: true,
+			err:       strconv.ErrSyntax,
+		},
+		{
+			name: "unknown key should return error",
+			conf: map[string]string{
+				"scenes_user": "1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "key without feature toggle should return error",
+			conf: map[string]string{
+				"_users": "1",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		f := ini.Empty()
+
+		section, _ := f.NewSection("feature_toggles.targeting")
+		for k, v := range tc.conf {
+			_, err := section.NewKey(k, v)
+			require.ErrorIs(t, err, nil)
+		}
+
+		targeting, err := ReadFeatureToggleTargetingFromInitFile(section)
+		if tc.expectErr {
+			require.Error(t, err, tc.name)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err, tc.name)
+			}
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expectedTargeting, targeting, tc.name)
+	}
+}
+
+func TestFeatureToggleSchedule(t *testing.T) {
+	startAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	endAt := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name              string
+		conf              map[string]string
+		expectErr         bool
+		expectedSchedules map[string]FeatureToggleSchedule
+	}{
+		{
+			name: "can parse start and end times",
+			conf: map[string]string{
+				"scenes_start_at": "2024-01-01T09:00:00Z",
+				"scenes_end_at":   "2024-01-02T09:00:00Z",
+				"topnav_end_at":   "2024-01-02T10:00:00+01:00",
+				"panels_start_at": "",
+			},
+			expectedSchedules: map[string]FeatureToggleSchedule{
+				"scenes": {StartAt: &startAt, EndAt: &endAt},
+				"topnav": {EndAt: &endAt},
+			},
+		},
+		{
+			name: "invalid time should return error",
+			conf: map[string]string{
+				"scenes_start_at": "tomorrow",
+			},
+			expectErr: true,
+		},
+		{
+			name: "end before start should return error",
+			conf: map[string]string{
+				"scenes_start_at": "2024-01-02T09:00:00Z",
+				"scenes_end_at":   "2024-01-01T09:00:00Z",
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown key should return error",
+			conf: map[string]string{
+				"scenes_at": "2024-01-01T09:00:00Z",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		f := ini.Empty()
+
+		section, _ := f.NewSection("feature_toggles.schedule")
+		for k, v := range tc.conf {
+			_, err := section.NewKey(k, v)
+			require.ErrorIs(t, err, nil)
+		}
+
+		schedules, err := ReadFeatureToggleScheduleFromInitFile(section)
+		if tc.expectErr {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Len(t, schedules, len(tc.expectedSchedules), tc.name)
+		for name, expected := range tc.expectedSchedules {
+			schedule := schedules[name]
+			require.Equal(t, expected.StartAt == nil, schedule.StartAt == nil, tc.name)
+			require.Equal(t, expected.EndAt == nil, schedule.EndAt == nil, tc.name)
+			if expected.StartAt != nil {
+				require.True(t, expected.StartAt.Equal(*schedule.StartAt), tc.name)
+			}
+			if expected.EndAt != nil {
+				require.True(t, expected.EndAt.Equal(*schedule.EndAt), tc.name)
+			}
+		}
+	}
+}
+
+func TestFeatureToggleExperiments(t *testing.T) {
+	testCases := []struct {
+		name                string
+		conf                map[string]string
+		expectErr           bool
+		expectedExperiments map[string]FeatureToggleExperiment
+	}{
+		{
+			name: "can parse variants and units",
+			conf: map[string]string{
+				"scenes_variants": "control:50, treatment:50",
+				"topnav_variants": "a:1 b:2 c:1",
+				"topnav_unit":     "org",
+			},
+			expectedExperiments: map[string]FeatureToggleExperiment{
+				"scenes": {Variants: []FeatureToggleVariant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}}, Unit: FeatureToggleExperimentUnitUser},
+				"topnav": {Variants: []FeatureToggleVariant{{Name: "a", Weight: 1}, {Name: "b", Weight: 2}, {Name: "c", Weight: 1}}, Unit: FeatureToggleExperimentUnitOrg},
+			},
+		},
+		{
+			name: "invalid weight should return error",
+			conf: map[string]string{
+				"scenes_variants": "control:50, treatment:none",
+			},
+			expectErr: true,
+		},
+		{
+			name: "single variant should return error",
+			conf: map[string]string{
+				"scenes_variants": "treatment:100",
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid unit should return error",
+			conf: map[string]string{
+				"scenes_variants": "control:50, treatment:50",
+				"scenes_unit":     "team",
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown key should return error",
+			conf: map[string]string{
+				"scenes": "control:50, treatment:50",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		f := ini.Empty()
+
+		section, _ := f.NewSection("feature_toggles.experiments")
+		for k, v := range tc.conf {
+			_, err := section.NewKey(k, v)
+			require.ErrorIs(t, err, nil)
+		}
+
+		experiments, err := ReadFeatureToggleExperimentsFromInitFile(section)
+		if tc.expectErr {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expectedExperiments, experiments, tc.name)
+	}
+}
+
+func TestFeatureToggleConfigs(t *testing.T) {
+	f := ini.Empty()
+	_, err := f.Section("feature_toggles").NewKey("lokiQuerySplitting", "true")
+	require.NoError(t, err)
+	_, err = f.Section("feature_toggles.lokiQuerySplitting").NewKey("interval", "12h")
+	require.NoError(t, err)
+	_, err = f.Section("feature_toggles.schedule").NewKey("lokiQuerySplitting_start_at", "2024-01-01T09:00:00Z")
+	require.NoError(t, err)
+	f.Section("feature_toggles.empty")
+
+	require.Equal(t, map[string]map[string]string{
+		"lokiQuerySplitting": {"interval": "12h"},
+		"empty":              {},
+	}, ReadFeatureToggleConfigsFromInitFile(f))
+}
@@ -1,6 +1,8 @@
 package setting
 
 import (
+	"time"
+
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -10,8 +12,56 @@ type FeatureMgmtSettings struct {
 	AllowEditing       bool
 	UpdateWebhook      string
 	UpdateWebhookToken string
+
+	// RemoteSourceURL is the URL of the document that holds the states of the toggles managed by a central service,
+	// either an HTTP endpoint or an object in object storage.
+	RemoteSourceURL string
+	// RemoteSourceSignatureURL is the URL of the detached signature of the document, for sources that cannot send it
+	// in a header.
+	RemoteSourceSignatureURL string
+	// RemoteSourcePublicKey is the base64 encoded ed25519 public key that the document must be signed with, it is
+	// required with RemoteSourceURL.
+	RemoteSourcePublicKey    string
+	RemoteSourcePollInterval time.Duration
+
+	// ChangeWebhookURLs are notified of the changes of the toggles at runtime, with bodies signed with
+	// ChangeWebhookSecret.
+	ChangeWebhookURLs   []string
+	ChangeWebhookSecret string
+
+	// HotReload enables polling the configuration files every HotReloadInterval, to apply the changes of the toggles
+	// that do not require a restart.
+	HotReload         bool
+	HotReloadInterval time.Duration
+
+	// StrictToggles fails startup if [feature_toggles] configures toggles that are not registered.
+	StrictToggles bool
+
+	// RequestOverridesSecret enables overriding the toggles per request in development mode, with overrides signed
+	// with the secret.
+	RequestOverridesSecret string
+
+	// EvaluationTracing records the toggles evaluated during each request in its trace span and debug logs.
+	EvaluationTracing bool
+
+	// RuntimeStateStore is where the states of the toggles changed at runtime are stored, FeatureToggleRuntimeStoreMemory
+	// or FeatureToggleRuntimeStoreDatabase to share them between instances, which poll them every
+	// RuntimeStateSyncInterval.
+	RuntimeStateStore        string
+	RuntimeStateSyncInterval time.Duration
+
+	// StaleExperimentalReleases and StalePreviewReleases are the number of releases toggles can stay in the
+	// experimental and preview stages before they are reported as stale, 0 to never report them.
+	StaleExperimentalReleases int
+	StalePreviewReleases      int
 }
 
+// Stores of the states of the toggles changed at runtime.
+const (
+	FeatureToggleRuntimeStoreMemory   = "memory"
+	FeatureToggleRuntimeStoreDatabase = "database"
+)
+
 func (cfg *Cfg) readFeatureManagementConfig() {
 	section := cfg.Raw.Section("feature_management")
 
@@ -35,4 +85,19 @@ func (cfg *Cfg) readFeatureManagementConfig() {
 	cfg.FeatureManagement.AllowEditing = cfg.SectionWithEnvOverrides("feature_management").Key("allow_editing").MustBool(false)
 	cfg.FeatureManagement.UpdateWebhook = cfg.SectionWithEnvOverrides("feature_management").Key("update_webhook").MustString("")
 	cfg.FeatureManagement.UpdateWebhookToken = cfg.SectionWithEnvOverrides("feature_management").Key("update_webhook_token").MustString("")
+	cfg.FeatureManagement.RemoteSourceURL = cfg.SectionWithEnvOverrides("feature_management").Key("remote_source_url").MustString("")
+	cfg.FeatureManagement.RemoteSourceSignatureURL = cfg.SectionWithEnvOverrides("feature_management").Key("remote_source_signature_url").MustString("")
+	cfg.FeatureManagement.RemoteSourcePublicKey = cfg.SectionWithEnvOverrides("feature_management").Key("remote_source_public_key").MustString("")
+	cfg.FeatureManagement.RemoteSourcePollInterval = cfg.SectionWithEnvOverrides("feature_management").Key("remote_source_poll_interval").MustDuration(time.Minute)
+	cfg.FeatureManagement.ChangeWebhookURLs = util.SplitString(cfg.SectionWithEnvOverrides("feature_management").Key("change_webhook_urls").MustString(""))
+	cfg.FeatureManagement.ChangeWebhookSecret = cfg.SectionWithEnvOverrides("feature_management").Key("change_webhook_secret").MustString("")
+	cfg.FeatureManagement.HotReload = cfg.SectionWithEnvOverrides("feature_management").Key("hot_reload").MustBool(false)
+	cfg.FeatureManagement.HotReloadInterval = cfg.SectionWithEnvOverrides("feature_management").Key("hot_reload_interval").MustDuration(10 * time.Second)
+	cfg.FeatureManagement.StrictToggles = cfg.SectionWithEnvOverrides("feature_management").Key("strict_toggles").MustBool(false)
+	cfg.FeatureManagement.RequestOverridesSecret = cfg.SectionWithEnvOverrides("feature_management").Key("request_overrides_secret").MustString("")
+	cfg.FeatureManagement.EvaluationTracing = cfg.SectionWithEnvOverrides("feature_management").Key("evaluation_tracing").MustBool(false)
+	cfg.FeatureManagement.RuntimeStateStore = cfg.SectionWithEnvOverrides("feature_management").Key("runtime_state_store").In(FeatureToggleRuntimeStoreMemory, []string{FeatureToggleRuntimeStoreMemory, FeatureToggleRuntimeStoreDatabase})
+	cfg.FeatureManagement.RuntimeStateSyncInterval = cfg.SectionWithEnvOverrides("feature_management").Key("runtime_state_sync_interval").MustDuration(10 * time.Second)
+	cfg.FeatureManagement.StaleExperimentalReleases = cfg.SectionWithEnvOverrides("feature_management").Key("stale_experimental_releases").MustInt(3)
+	cfg.FeatureManagement.StalePreviewReleases = cfg.SectionWithEnvOverrides("feature_management").Key("stale_preview_releases").MustInt(6)
 }
@@ -24,10 +24,17 @@ type GlobalQuota struct {
 	Correlations int64 `target:"correlations"`
 }
 
+// FolderQuota are the default limits of a folder and its subtree.
+type FolderQuota struct {
+	Dashboard int64 `target:"dashboard"`
+	AlertRule int64 `target:"alert_rule"`
+}
+
 type QuotaSettings struct {
 	Enabled bool
 	Org     OrgQuota
 	User    UserQuota
+	Folder  FolderQuota
 	Global  GlobalQuota
 }
 
@@ -38,9 +45,11 @@ func (cfg *Cfg) readQuotaSettings() {
 
 	var alertOrgQuota int64
 	var alertGlobalQuota int64
+	alertFolderQuota := int64(-1)
 	if cfg.UnifiedAlerting.IsEnabled() {
 		alertOrgQuota = quota.Key("org_alert_rule").MustInt64(100)
 		alertGlobalQuota = quota.Key("global_alert_rule").MustInt64(-1)
+		alertFolderQuota = quota.Key("folder_alert_rule").MustInt64(-1)
 	}
 	// per ORG Limits
 	cfg.Quota.Org = OrgQuota{
@@ -56,6 +65,12 @@ func (cfg *Cfg) readQuotaSettings() {
 		Org: quota.Key("user_org").MustInt64(10),
 	}
 
+	// per Folder limits, including the subfolders
+	cfg.Quota.Folder = FolderQuota{
+		Dashboard: quota.Key("folder_dashboard").MustInt64(-1),
+		AlertRule: alertFolderQuota,
+	}
+
 	// Global Limits
 	cfg.Quota.Global = GlobalQuota{
 		User:         quota.Key("global_user").MustInt64(-1),
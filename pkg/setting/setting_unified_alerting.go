@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
@@ -96,6 +97,7 @@ type UnifiedAlertingSettings struct {
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
 	StateHistory                  UnifiedAlertingStateHistorySettings
 	RemoteAlertmanager            RemoteAlertmanagerSettings
+	Upgrade                       UnifiedAlertingUpgradeSettings
 	// MaxStateSaveConcurrency controls the number of goroutines (per rule) that can save alert state in parallel.
 	MaxStateSaveConcurrency int
 }
@@ -109,6 +111,263 @@ type RemoteAlertmanagerSettings struct {
 	Password string
 }
 
+// Values of the array_alert_rule_tags setting.
+const (
+	ArrayAlertRuleTagsIgnore = "ignore"
+	ArrayAlertRuleTagsIndex  = "index"
+	ArrayAlertRuleTagsKey    = "key"
+)
+
+// Values of the contact_point_strategy setting.
+const (
+	ContactPointStrategyChannel     = "channel"
+	ContactPointStrategyCombination = "combination"
+)
+
+// Values of the orphaned_alerts setting.
+const (
+	OrphanedAlertsGeneral = "general"
+	OrphanedAlertsSkip    = "skip"
+	OrphanedAlertsFolder  = "folder"
+	OrphanedAlertsFail    = "fail"
+)
+
+// Values of the missing_datasource setting.
+const (
+	MissingDatasourceKeep     = "keep"
+	MissingDatasourceFallback = "fallback"
+	MissingDatasourcePause    = "pause"
+)
+
+// Values of the conflict_policy setting.
+const (
+	ConflictPolicyOverwrite = "overwrite"
+	ConflictPolicyMerge     = "merge"
+	ConflictPolicySkip      = "skip"
+	ConflictPolicyAbort     = "abort"
+)
+
+// Values of the receiver_name_strategy setting.
+const (
+	ReceiverNameStrategyHash   = "hash"
+	ReceiverNameStrategyUnique = "unique"
+)
+
+// UnifiedAlertingUpgradeSettings contains the options that change how legacy alerts
+// and notification channels are migrated to unified alerting.
+type UnifiedAlertingUpgradeSettings struct {
+	// DisableKeepStateSilences stops the migration from creating silences for the DatasourceError and
+	// DatasourceNoData alerts of rules that used 'Keep Last State' in legacy alerting.
+	DisableKeepStateSilences bool
+	// UpsertOnRemigration keeps the alert rules and folders created by a previous migration when rolling back to
+	// legacy alerting, and updates them and the contact points in place on the next migration instead of recreating
+	// them. The alert rules of the legacy alerts deleted meanwhile are deleted.
+	UpsertOnRemigration bool
+	// DeterministicUIDs derives the UIDs of migrated alert rules, folders and contact points from the legacy IDs
+	// instead of generating random ones, so that migrations in different environments produce identical UIDs.
+	DeterministicUIDs bool
+	// FolderNameTemplate is the text/template used to name the folders created for the alerts of dashboards with
+	// custom permissions. If empty, the folders are named "<dashboard title> Alerts - <dashboard UID>".
+	FolderNameTemplate string
+	// TargetFolderUIDs maps organization IDs to the UID of an existing folder. All alert rules of these organizations
+	// are migrated into that folder instead of the folders of their dashboards or the folders created for them.
+	TargetFolderUIDs map[int64]string
+	// GroupRulesByDashboard puts the migrated alert rules of a dashboard into a single rule group named after the
+	// dashboard, instead of one rule group per alert rule.
+	GroupRulesByDashboard bool
+	// GroupEvaluationInterval is the evaluation interval of the rule groups created by GroupRulesByDashboard.
+	// If zero, the shortest interval of the alerts of the dashboard is used.
+	GroupEvaluationInterval time.Duration
+	// MinPendingPeriod is the minimum pending period (For) of the migrated alert rules. Legacy alerts with a shorter
+	// For duration, including those without one, are migrated with this pending period.
+	MinPendingPeriod time.Duration
+	// RoundPendingPeriod rounds the pending period of the migrated alert rules up to a multiple of their evaluation
+	// interval, which is the precision with which the pending period is evaluated.
+	RoundPendingPeriod bool
+	// SplitPrometheusBothQueries splits Prometheus queries of type 'Both' into an instant and a range query, and ORs
+	// the classic conditions that use them, instead of converting them to range queries.
+	SplitPrometheusBothQueries bool
+	// ScopeDashboardUIDs, ScopeDashboardTags and ScopeFolderIDs restrict the migration to the alerts of the listed
+	// dashboards, of the dashboards with any of the tags, and of the dashboards in the listed folders. An alert is
+	// migrated if its dashboard matches any of them. If none is set, the alerts of all dashboards are migrated. The
+	// scope of each migrated organization is recorded, so widening or removing it migrates the alerts added to it.
+	ScopeDashboardUIDs []string
+	ScopeDashboardTags []string
+	ScopeFolderIDs     []int64
+	// ArrayAlertRuleTags is how legacy alert rule tags stored as a JSON array are migrated to labels, one of
+	// ArrayAlertRuleTagsIgnore, ArrayAlertRuleTagsIndex and ArrayAlertRuleTagsKey.
+	ArrayAlertRuleTags string
+	// TemplateMappingFile is the path of a YAML file that maps variables and macros of legacy alert messages to
+	// unified alerting template syntax, for all organizations and per organization.
+	TemplateMappingFile string
+	// DatasourceUIDMappingFile is the path of a YAML file that maps the UIDs of data sources re-provisioned with new
+	// UIDs to their new UIDs, for all organizations and per organization, applied to the queries of the alert rules.
+	DatasourceUIDMappingFile string
+	// RotateSecretsDataKey rotates the data keys of envelope encryption after the migration and re-encrypts the secure
+	// settings of the migrated contact points with a new data key of the current encryption provider, verifying that
+	// they can be decrypted, so that the cutover doubles as a secrets rotation.
+	RotateSecretsDataKey bool
+	// LenientAlertmanagerValidation quarantines the receivers that fail the validation of the Alertmanager
+	// configuration instead of failing the migration of the organization. Alerts of quarantined receivers are routed
+	// to the default receiver and the validation errors are recorded in the migration status of the organization.
+	LenientAlertmanagerValidation bool
+	// RuleInsertBatchSize is the number of alert rules inserted per statement. 0 inserts the alert rules one by one.
+	RuleInsertBatchSize int
+	// StoreSilencesInDatabase writes the silences created by the migration to the database, where the Alertmanager
+	// of every instance loads them from, instead of the silences file of the organization in the data path.
+	StoreSilencesInDatabase bool
+	// OrgLockTimeout is how long the migration waits for the database lock of an organization that is being migrated by
+	// another Grafana instance. 0 disables the lock.
+	OrgLockTimeout time.Duration
+	// ShadowMode creates all migrated alert rules paused, so that they can be validated before they send
+	// notifications, and keeps the legacy alerts of the organization evaluated meanwhile. The organization is switched
+	// to the migrated rules by activating its migration.
+	ShadowMode bool
+	// PauseMigratedRules creates all migrated alert rules paused, to prevent a burst of notifications right after
+	// the upgrade. The rules that are paused only because of this setting are labeled with the __paused_by_migration__
+	// label and can be resumed together.
+	PauseMigratedRules bool
+	// MinRepeatInterval and MaxRepeatInterval bound the repeat interval of the migrated notification policies, which
+	// is otherwise the reminder frequency of the legacy notification channel. The year of the channels that did not
+	// send reminders is not bounded. 0 does not bound the repeat interval.
+	MinRepeatInterval time.Duration
+	MaxRepeatInterval time.Duration
+	// NestedNotificationPolicies builds a notification policy per folder of the migrated alert rules, matching the
+	// grafana_folder label, with the routes of the contact points used in the folder underneath, instead of a flat list
+	// of routes per contact point under the root policy.
+	NestedNotificationPolicies bool
+	// ContactPointStrategy is how the notification channels of the legacy alerts are routed to, one of
+	// ContactPointStrategyChannel, a route per channel matching the contact label with a regular expression, and
+	// ContactPointStrategyCombination, a receiver and a route per distinct set of channels matching the contact label exactly.
+	ContactPointStrategy string
+	// OrphanedAlerts is how the alerts of dashboards whose folder does not exist are migrated, one of
+	// OrphanedAlertsGeneral, to the General Alerting folder, OrphanedAlertsSkip, not migrated, OrphanedAlertsFolder, to
+	// the folder titled OrphanedAlertsFolderTitle, and OrphanedAlertsFail, failing the migration.
+	OrphanedAlerts            string
+	OrphanedAlertsFolderTitle string
+	// GeneralFolderFallback migrates the alerts of a dashboard with custom permissions to the General Alerting folder,
+	// without the permissions of the dashboard, if the folder for them cannot be created. Otherwise they fail to
+	// migrate.
+	GeneralFolderFallback bool
+	// SkipFailingAlerts makes the migration skip the alerts that fail to migrate, recording the errors in the migration
+	// status of their organization, instead of failing the migration of the organization.
+	SkipFailingAlerts bool
+	// MaxFailingAlerts is the number of alerts of an organization that can be skipped with SkipFailingAlerts before the
+	// migration of the organization fails, 0 for no limit.
+	MaxFailingAlerts int
+	// ReserveGeneratedUIDs checks the UIDs generated for alert rules, folders and contact points against the alert
+	// rules, dashboards and contact points of the Alertmanager configurations that already exist, and generates another
+	// one if a UID is taken.
+	ReserveGeneratedUIDs bool
+	// UIDGenerationAttempts is the number of UIDs tried for a migrated resource before the migration fails.
+	UIDGenerationAttempts int
+	// BackupRemovedData stores the unified alerting data of an organization in the alert_migration_backup tables before
+	// it is removed to roll back or re-migrate the organization.
+	BackupRemovedData bool
+	// MaxBackupsToKeep is the number of backups kept per organization with BackupRemovedData, 0 for all of them.
+	MaxBackupsToKeep int
+	// ConflictPolicy is how the migration handles an organization that already has alert rules or an Alertmanager
+	// configuration that the migration did not create, one of ConflictPolicyOverwrite, deleting the alert rules and
+	// replacing the Alertmanager configuration, ConflictPolicyMerge, keeping the existing alert rules and adding the
+	// migrated contact points and notification policies to the existing Alertmanager configuration, ConflictPolicySkip,
+	// not migrating the organization, and ConflictPolicyAbort, failing the migration. The conflicts are recorded in the
+	// migration status.
+	ConflictPolicy string
+	// MissingDatasource is how the queries of legacy alerts whose data source no longer exists are migrated, one of
+	// MissingDatasourceKeep, without a data source, MissingDatasourceFallback, to the data source of
+	// MissingDatasourceFallbackUID, and MissingDatasourcePause, without a data source in a paused alert rule annotated
+	// with the queries that have none.
+	MissingDatasource            string
+	MissingDatasourceFallbackUID string
+	// BackfillStateHistory converts the state changes of the legacy alerts, recorded in their annotations, to state
+	// history entries of the migrated alert rules in the backends of [unified_alerting.state_history].
+	BackfillStateHistory bool
+	// ConvertClassicConditions restricts the conversion of MultiDimensionalRules to the legacy alerts that have a single
+	// condition on a single query, converted into a reduce and a threshold expression, which can be edited in the
+	// alert rule editor. Without MultiDimensionalRules the classic conditions are kept, since the converted alert rules
+	// create an alert instance per series instead of a single one.
+	ConvertClassicConditions bool
+	// MultiDimensionalRules converts the legacy alerts whose conditions all apply to the same query into alert rules
+	// that create an alert instance per series, instead of a single one for all series. The alerts for which the
+	// conversion is ambiguous are migrated with a classic condition and recorded in the migration status.
+	MultiDimensionalRules bool
+	// SeedAlertInstances writes the state of the legacy alerts that are alerting, pending or ok as the state of the
+	// migrated alert rules, so that they do not start pending again after the migration.
+	SeedAlertInstances bool
+	// PushAlertmanagerConfigURL is the URL of a Mimir or Cortex Alertmanager that the migrated Alertmanager
+	// configuration of every organization is also pushed to, through its configuration API, once the migration is
+	// committed. A vanilla Alertmanager has no configuration API. If empty, the configuration is only written to the
+	// database.
+	PushAlertmanagerConfigURL string
+	// PushAlertmanagerConfigTenantIDs maps organization IDs to the tenant that their configuration is pushed to. The
+	// tenant of the other organizations is their ID.
+	PushAlertmanagerConfigTenantIDs map[int64]string
+	// PushAlertmanagerConfigBasicAuthUsername and PushAlertmanagerConfigBasicAuthPassword authenticate the pushes.
+	PushAlertmanagerConfigBasicAuthUsername string
+	PushAlertmanagerConfigBasicAuthPassword string
+	// AlertmanagerChannelsToDatasources migrates the legacy Alertmanager notification channels to Alertmanager data
+	// sources that receive the alerts of Grafana, an external Alertmanager per URL of the channel, instead of contact
+	// points. The external Alertmanagers only receive the alerts of the alert rules migrated from the alerts that used
+	// the channel.
+	AlertmanagerChannelsToDatasources bool
+	// MaxConcurrentDashboardLookups is the maximum number of queries that load the dashboards of the migrated alerts at
+	// the same time, on their own connections outside the transaction of the migration, so that the migration does not
+	// starve the other queries of a busy database. 0 loads them one batch at a time in the transaction.
+	MaxConcurrentDashboardLookups int
+	// TestContactPoints sends a test notification through every migrated contact point when unified alerting starts
+	// after the migration, and records whether it was delivered in the migration status of the organization.
+	TestContactPoints bool
+	// StoreSecretsInSecretsKVStore stores the secure settings of the migrated contact points in the secrets kvstore.
+	// If the disableSecretsCompatibility feature toggle is enabled, the configuration of the contact points only
+	// references them, otherwise it keeps an encrypted copy. Unified alerting stores them when it starts after the
+	// migration.
+	StoreSecretsInSecretsKVStore bool
+	// ReportWebhookURL is the URL that the MigrationReport of every migrated organization is posted to as JSON when
+	// unified alerting starts after the migration.
+	ReportWebhookURL string
+	// ReportEmailOrgAdmins emails the report of every migrated organization to its admins.
+	ReportEmailOrgAdmins bool
+	// EvaluationIntervalFromDashboardRefresh sets the evaluation interval of the migrated alert rules to the refresh
+	// interval of their dashboard, raised to MinEvaluationInterval and lowered to MaxEvaluationInterval, instead of the
+	// frequency of the legacy alert. The alerts of dashboards without a refresh interval keep their frequency. 0 does
+	// not bound the interval.
+	EvaluationIntervalFromDashboardRefresh bool
+	MinEvaluationInterval                  time.Duration
+	MaxEvaluationInterval                  time.Duration
+	// RewriteDashboardPanels links the migrated alert rules to the panel of their legacy alert, so that they are listed
+	// in the alert tab of the panel, and removes the legacy alert blocks from the panels. The blocks are restored when
+	// rolling back to legacy alerting.
+	RewriteDashboardPanels bool
+	// SkipOrgIDs lists the organizations that the migration of all organizations skips, so that they keep their
+	// legacy alerts until they are migrated on their own. They are recorded as not migrated in the migration status.
+	// Legacy alerting keeps evaluating their alerts while unified alerting is enabled, which is disabled for them.
+	SkipOrgIDs []int64
+	// IncrementalSync migrates, at every start, the legacy alerts and notification channels added to the migrated
+	// organizations since their migration, without changing the resources that were already migrated.
+	IncrementalSync bool
+	// RuleGroupNameTemplate is the text/template used to name the rule groups of the migrated alert rules. The alert
+	// rules whose names are equal in a folder share a rule group. If empty, every alert rule gets a rule group named
+	// after it, or after its dashboard if GroupRulesByDashboard is enabled.
+	RuleGroupNameTemplate string
+	// ReceiverNameStrategy is how the names of the contact points migrated from notification channels are made unique,
+	// one of ReceiverNameStrategyHash, a short hash of the channel name appended to names that collide within the
+	// migration, and ReceiverNameStrategyUnique, a counter appended until the name is not used by another migrated
+	// contact point nor by a contact point of the existing Alertmanager configuration that the migration keeps.
+	ReceiverNameStrategy string
+	// KeepStateRouting routes the DatasourceError and DatasourceNoData alerts of migrated rules that used 'Keep Last
+	// State' in legacy alerting to KeepStateReceiver with dedicated notification policies, instead of silencing them.
+	KeepStateRouting bool
+	// KeepStateReceiver is the contact point of the notification policies created by KeepStateRouting. If empty, a
+	// contact point without integrations is created, which drops the alerts.
+	KeepStateReceiver string
+	// MigrationEventsAnnotations creates an organization annotation for every lifecycle event of the migration that
+	// unified alerting publishes on the bus.
+	MigrationEventsAnnotations bool
+	// ForceResolveMessageChannelTypes are the legacy notification channel types whose integrations send resolved
+	// notifications even if the legacy channel disabled them, for the types where the flag was commonly misconfigured.
+	ForceResolveMessageChannelTypes []string
+}
+
 type UnifiedAlertingScreenshotSettings struct {
 	Capture                    bool
 	CaptureTimeout             time.Duration
@@ -397,6 +656,173 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
+	upgrade := iniFile.Section("unified_alerting.upgrade")
+	uaCfgUpgrade := UnifiedAlertingUpgradeSettings{
+		DisableKeepStateSilences:                upgrade.Key("disable_keep_state_silences").MustBool(false),
+		UpsertOnRemigration:                     upgrade.Key("upsert_on_remigration").MustBool(false),
+		DeterministicUIDs:                       upgrade.Key("deterministic_uids").MustBool(false),
+		FolderNameTemplate:                      upgrade.Key("folder_name_template").MustString(""),
+		GroupRulesByDashboard:                   upgrade.Key("group_rules_by_dashboard").MustBool(false),
+		RoundPendingPeriod:                      upgrade.Key("round_pending_period").MustBool(false),
+		SplitPrometheusBothQueries:              upgrade.Key("split_prometheus_both_queries").MustBool(false),
+		ScopeDashboardUIDs:                      util.SplitString(upgrade.Key("scope_dashboard_uids").MustString("")),
+		ArrayAlertRuleTags:                      upgrade.Key("array_alert_rule_tags").In(ArrayAlertRuleTagsIgnore, []string{ArrayAlertRuleTagsIgnore, ArrayAlertRuleTagsIndex, ArrayAlertRuleTagsKey}),
+		TemplateMappingFile:                     upgrade.Key("template_mapping_file").MustString(""),
+		RotateSecretsDataKey:                    upgrade.Key("rotate_secrets_data_key").MustBool(false),
+		LenientAlertmanagerValidation:           upgrade.Key("lenient_alertmanager_validation").MustBool(false),
+		RuleInsertBatchSize:                     upgrade.Key("rule_insert_batch_size").MustInt(0),
+		StoreSilencesInDatabase:                 upgrade.Key("store_silences_in_database").MustBool(false),
+		ShadowMode:                              upgrade.Key("shadow_mode").MustBool(false),
+		PauseMigratedRules:                      upgrade.Key("pause_migrated_rules").MustBool(false),
+		NestedNotificationPolicies:              upgrade.Key("nested_notification_policies").MustBool(false),
+		ContactPointStrategy:                    upgrade.Key("contact_point_strategy").In(ContactPointStrategyChannel, []string{ContactPointStrategyChannel, ContactPointStrategyCombination}),
+		OrphanedAlerts:                          upgrade.Key("orphaned_alerts").In(OrphanedAlertsGeneral, []string{OrphanedAlertsGeneral, OrphanedAlertsSkip, OrphanedAlertsFolder, OrphanedAlertsFail}),
+		OrphanedAlertsFolderTitle:               valueAsString(upgrade, "orphaned_alerts_folder", "Orphaned Alerts"),
+		GeneralFolderFallback:                   upgrade.Key("general_folder_fallback").MustBool(false),
+		SkipFailingAlerts:                       upgrade.Key("skip_failing_alerts").MustBool(false),
+		MaxFailingAlerts:                        upgrade.Key("max_failing_alerts").MustInt(0),
+		ReserveGeneratedUIDs:                    upgrade.Key("reserve_generated_uids").MustBool(false),
+		UIDGenerationAttempts:                   upgrade.Key("uid_generation_attempts").MustInt(5),
+		BackupRemovedData:                       upgrade.Key("backup_removed_data").MustBool(false),
+		MaxBackupsToKeep:                        upgrade.Key("max_backups_to_keep").MustInt(5),
+		ConflictPolicy:                          upgrade.Key("conflict_policy").In(ConflictPolicyOverwrite, []string{ConflictPolicyOverwrite, ConflictPolicyMerge, ConflictPolicySkip, ConflictPolicyAbort}),
+		DatasourceUIDMappingFile:                upgrade.Key("datasource_uid_mapping_file").MustString(""),
+		MissingDatasource:                       upgrade.Key("missing_datasource").In(MissingDatasourceKeep, []string{MissingDatasourceKeep, MissingDatasourceFallback, MissingDatasourcePause}),
+		MissingDatasourceFallbackUID:            upgrade.Key("missing_datasource_fallback_uid").MustString(""),
+		BackfillStateHistory:                    upgrade.Key("backfill_state_history").MustBool(false),
+		ConvertClassicConditions:                upgrade.Key("convert_classic_conditions").MustBool(false),
+		MultiDimensionalRules:                   upgrade.Key("multi_dimensional_rules").MustBool(false),
+		SeedAlertInstances:                      upgrade.Key("seed_alert_instances").MustBool(false),
+		PushAlertmanagerConfigURL:               upgrade.Key("push_alertmanager_config_url").MustString(""),
+		PushAlertmanagerConfigBasicAuthUsername: upgrade.Key("push_alertmanager_config_basic_auth_username").MustString(""),
+		PushAlertmanagerConfigBasicAuthPassword: upgrade.Key("push_alertmanager_config_basic_auth_password").MustString(""),
+		AlertmanagerChannelsToDatasources:       upgrade.Key("alertmanager_channels_to_datasources").MustBool(false),
+		MaxConcurrentDashboardLookups:           upgrade.Key("max_concurrent_dashboard_lookups").MustInt(0),
+		TestContactPoints:                       upgrade.Key("test_contact_points").MustBool(false),
+		StoreSecretsInSecretsKVStore:            upgrade.Key("store_secrets_in_secrets_kvstore").MustBool(false),
+		ReportWebhookURL:                        upgrade.Key("report_webhook_url").MustString(""),
+		ReportEmailOrgAdmins:                    upgrade.Key("report_email_org_admins").MustBool(false),
+		EvaluationIntervalFromDashboardRefresh:  upgrade.Key("evaluation_interval_from_dashboard_refresh").MustBool(false),
+		RewriteDashboardPanels:                  upgrade.Key("rewrite_dashboard_panels").MustBool(false),
+		IncrementalSync:                         upgrade.Key("incremental_sync").MustBool(false),
+		RuleGroupNameTemplate:                   upgrade.Key("rule_group_name_template").MustString(""),
+		ReceiverNameStrategy:                    upgrade.Key("receiver_name_strategy").In(ReceiverNameStrategyHash, []string{ReceiverNameStrategyHash, ReceiverNameStrategyUnique}),
+		KeepStateRouting:                        upgrade.Key("keep_state_routing").MustBool(false),
+		KeepStateReceiver:                       upgrade.Key("keep_state_receiver").MustString(""),
+		MigrationEventsAnnotations:              upgrade.Key("migration_events_annotations").MustBool(false),
+	}
+	if uaCfgUpgrade.ConvertClassicConditions && !uaCfgUpgrade.MultiDimensionalRules {
+		cfg.Logger.Warn("Setting 'convert_classic_conditions' has no effect without 'multi_dimensional_rules', classic conditions are kept since the converted alert rules create an alert instance per series")
+	}
+	if uaCfgUpgrade.FolderNameTemplate != "" {
+		if _, err := template.New("folder_name_template").Parse(uaCfgUpgrade.FolderNameTemplate); err != nil {
+			return fmt.Errorf("failed to parse setting 'folder_name_template' as template: %w", err)
+		}
+	}
+	uaCfgUpgrade.TargetFolderUIDs = make(map[int64]string)
+	for _, pair := range util.SplitString(upgrade.Key("target_folder_uids").MustString("")) {
+		orgStr, folderUID, ok := strings.Cut(pair, ":")
+		if !ok || folderUID == "" {
+			return fmt.Errorf("invalid value %q for setting 'target_folder_uids': expected <org ID>:<folder UID>", pair)
+		}
+		orgID, err := strconv.ParseInt(orgStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid organization ID %q in setting 'target_folder_uids': %w", orgStr, err)
+		}
+		uaCfgUpgrade.TargetFolderUIDs[orgID] = folderUID
+	}
+	uaCfgUpgrade.GroupEvaluationInterval, err = gtime.ParseDuration(valueAsString(upgrade, "group_evaluation_interval", "0s"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'group_evaluation_interval' as duration: %w", err)
+	}
+	uaCfgUpgrade.MinPendingPeriod, err = gtime.ParseDuration(valueAsString(upgrade, "min_pending_period", "0s"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'min_pending_period' as duration: %w", err)
+	}
+	uaCfgUpgrade.ScopeDashboardTags = util.SplitString(upgrade.Key("scope_dashboard_tags").MustString(""))
+	for _, s := range util.SplitString(upgrade.Key("scope_folder_ids").MustString("")) {
+		folderID, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid folder ID %q in setting 'scope_folder_ids': %w", s, err)
+		}
+		uaCfgUpgrade.ScopeFolderIDs = append(uaCfgUpgrade.ScopeFolderIDs, folderID)
+	}
+	if uaCfgUpgrade.RuleInsertBatchSize < 0 {
+		return fmt.Errorf("invalid value %d for setting 'rule_insert_batch_size': expected 0 or a positive number", uaCfgUpgrade.RuleInsertBatchSize)
+	}
+	uaCfgUpgrade.OrgLockTimeout, err = gtime.ParseDuration(valueAsString(upgrade, "org_lock_timeout", "5m"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'org_lock_timeout' as duration: %w", err)
+	}
+	uaCfgUpgrade.MinRepeatInterval, err = gtime.ParseDuration(valueAsString(upgrade, "min_repeat_interval", "0s"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'min_repeat_interval' as duration: %w", err)
+	}
+	uaCfgUpgrade.MaxRepeatInterval, err = gtime.ParseDuration(valueAsString(upgrade, "max_repeat_interval", "0s"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'max_repeat_interval' as duration: %w", err)
+	}
+	if uaCfgUpgrade.MinRepeatInterval < 0 || uaCfgUpgrade.MaxRepeatInterval < 0 {
+		return errors.New("settings 'min_repeat_interval' and 'max_repeat_interval' must not be negative")
+	}
+	if uaCfgUpgrade.MaxRepeatInterval > 0 && uaCfgUpgrade.MinRepeatInterval > uaCfgUpgrade.MaxRepeatInterval {
+		return fmt.Errorf("setting 'min_repeat_interval' (%s) must not exceed 'max_repeat_interval' (%s)", uaCfgUpgrade.MinRepeatInterval, uaCfgUpgrade.MaxRepeatInterval)
+	}
+	if uaCfgUpgrade.MaxFailingAlerts < 0 {
+		return fmt.Errorf("invalid value %d for setting 'max_failing_alerts': expected 0 or a positive number", uaCfgUpgrade.MaxFailingAlerts)
+	}
+	if uaCfgUpgrade.UIDGenerationAttempts < 1 {
+		return fmt.Errorf("invalid value %d for setting 'uid_generation_attempts': expected a positive number", uaCfgUpgrade.UIDGenerationAttempts)
+	}
+	if uaCfgUpgrade.MissingDatasource == MissingDatasourceFallback && uaCfgUpgrade.MissingDatasourceFallbackUID == "" {
+		return errors.New("setting 'missing_datasource_fallback_uid' must be set if 'missing_datasource' is fallback")
+	}
+	uaCfgUpgrade.PushAlertmanagerConfigTenantIDs = make(map[int64]string)
+	for _, pair := range util.SplitString(upgrade.Key("push_alertmanager_config_tenant_ids").MustString("")) {
+		orgStr, tenantID, ok := strings.Cut(pair, ":")
+		if !ok || tenantID == "" {
+			return fmt.Errorf("invalid value %q for setting 'push_alertmanager_config_tenant_ids': expected <org ID>:<tenant ID>", pair)
+		}
+		orgID, err := strconv.ParseInt(orgStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid organization ID %q in setting 'push_alertmanager_config_tenant_ids': %w", orgStr, err)
+		}
+		uaCfgUpgrade.PushAlertmanagerConfigTenantIDs[orgID] = tenantID
+	}
+	if uaCfgUpgrade.MaxConcurrentDashboardLookups < 0 {
+		return fmt.Errorf("invalid value %d for setting 'max_concurrent_dashboard_lookups': expected 0 or a positive number", uaCfgUpgrade.MaxConcurrentDashboardLookups)
+	}
+	uaCfgUpgrade.MinEvaluationInterval, err = gtime.ParseDuration(valueAsString(upgrade, "min_evaluation_interval", "0s"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'min_evaluation_interval' as duration: %w", err)
+	}
+	uaCfgUpgrade.MaxEvaluationInterval, err = gtime.ParseDuration(valueAsString(upgrade, "max_evaluation_interval", "0s"))
+	if err != nil {
+		return fmt.Errorf("failed to parse setting 'max_evaluation_interval' as duration: %w", err)
+	}
+	if uaCfgUpgrade.MinEvaluationInterval < 0 || uaCfgUpgrade.MaxEvaluationInterval < 0 {
+		return errors.New("settings 'min_evaluation_interval' and 'max_evaluation_interval' must not be negative")
+	}
+	if uaCfgUpgrade.MaxEvaluationInterval > 0 && uaCfgUpgrade.MinEvaluationInterval > uaCfgUpgrade.MaxEvaluationInterval {
+		return fmt.Errorf("setting 'min_evaluation_interval' (%s) must not exceed 'max_evaluation_interval' (%s)", uaCfgUpgrade.MinEvaluationInterval, uaCfgUpgrade.MaxEvaluationInterval)
+	}
+	for _, s := range util.SplitString(upgrade.Key("skip_org_ids").MustString("")) {
+		orgID, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || orgID <= 0 {
+			return fmt.Errorf("invalid organization ID %q in setting 'skip_org_ids': expected a positive number", s)
+		}
+		uaCfgUpgrade.SkipOrgIDs = append(uaCfgUpgrade.SkipOrgIDs, orgID)
+		// Legacy alerting keeps running the skipped organizations, unified alerting must not run them as well.
+		uaCfg.DisabledOrgs[orgID] = struct{}{}
+	}
+	if uaCfgUpgrade.RuleGroupNameTemplate != "" {
+		if _, err := template.New("rule_group_name_template").Parse(uaCfgUpgrade.RuleGroupNameTemplate); err != nil {
+			return fmt.Errorf("failed to parse setting 'rule_group_name_template' as template: %w", err)
+		}
+	}
+	uaCfgUpgrade.ForceResolveMessageChannelTypes = util.SplitString(upgrade.Key("force_resolve_message_channel_types").MustString(""))
+	uaCfg.Upgrade = uaCfgUpgrade
+
 	uaCfg.MaxStateSaveConcurrency = ua.Key("max_state_save_concurrency").MustInt(1)
 
 	cfg.UnifiedAlerting = uaCfg
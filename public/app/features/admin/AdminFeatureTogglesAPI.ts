@@ -48,6 +48,11 @@ type FeatureToggle = {
   description?: string;
   enabled: boolean;
   readOnly?: boolean;
+  owner?: string;
+  frontend?: boolean;
+  deprecated?: boolean;
+  removalVersion?: string;
+  replacedBy?: string;
 };
 
 export const { useGetFeatureTogglesQuery, useUpdateFeatureTogglesMutation } = togglesApi;
@@ -81,7 +81,12 @@ export function AdminFeatureTogglesTable({ featureToggles }: Props) {
     {
       id: 'description',
       header: 'Description',
-      cell: ({ cell: { value } }: CellProps<FeatureToggle, string>) => <div>{value}</div>,
+      cell: ({ row }: CellProps<FeatureToggle, string>) => (
+        <div>
+          {row.original.description}
+          {row.original.deprecated && <div>{deprecationNotice(row.original)}</div>}
+        </div>
+      ),
       sortType: sortByDescription,
     },
     {
@@ -111,3 +116,14 @@ export function AdminFeatureTogglesTable({ featureToggles }: Props) {
     </>
   );
 }
+
+function deprecationNotice(toggle: FeatureToggle): string {
+  let notice = 'Deprecated';
+  if (toggle.removalVersion) {
+    notice += `, will be removed in Grafana ${toggle.removalVersion}`;
+  }
+  if (toggle.replacedBy) {
+    notice += `. Use ${toggle.replacedBy} instead`;
+  }
+  return notice + '.';
+}
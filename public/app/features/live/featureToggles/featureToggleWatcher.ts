@@ -0,0 +1,87 @@
+import { Unsubscribable } from 'rxjs';
+
+import {
+  FeatureToggles,
+  isLiveChannelMessageEvent,
+  isLiveChannelStatusEvent,
+  LiveChannelEvent,
+  LiveChannelScope,
+} from '@grafana/data';
+import { config, getBackendSrv, getGrafanaLiveSrv } from '@grafana/runtime';
+
+/** Sent by the server when the frontend only feature toggles change, and on subscription */
+export interface FeatureToggleNotification {
+  version: number;
+}
+
+let subscription: Unsubscribable | undefined;
+
+/** How long to wait before fetching the toggles again from an instance that did not sync the change yet */
+const retryDelayMs = 10000;
+
+/**
+ * Applies the changes of the frontend only feature toggles to the config, so they are picked up without reloading.
+ * The server sends the current version on subscription, which reconciles the changes missed while disconnected.
+ */
+export function watchFeatureToggles() {
+  const live = getGrafanaLiveSrv();
+  if (!live || subscription) {
+    return;
+  }
+
+  subscription = live
+    .getStream<FeatureToggleNotification>({
+      scope: LiveChannelScope.Grafana,
+      namespace: 'featuretoggles',
+      path: 'changes',
+    })
+    .subscribe({
+      next: (event: LiveChannelEvent<FeatureToggleNotification>) => {
+        if (isLiveChannelMessageEvent(event)) {
+          onFeatureTogglesChanged(event.message);
+        } else if (isLiveChannelStatusEvent(event) && event.message) {
+          onFeatureTogglesChanged(event.message);
+        }
+      },
+    });
+}
+
+/**
+ * Fetches the feature toggles enabled for the user when the notification is newer than the applied state. The
+ * versions only grow, so older notifications, sent by an instance that is behind, are ignored.
+ */
+export async function onFeatureTogglesChanged(notification: FeatureToggleNotification, attempts = 3) {
+  if (notification.version <= config.featureTogglesVersion) {
+    return;
+  }
+
+  const settings = await getBackendSrv().get<{ featureToggles: FeatureToggles; featureTogglesVersion: number }>(
+    '/api/frontend/settings'
+  );
+  applyFeatureToggles(settings.featureToggles, settings.featureTogglesVersion);
+
+  // The instance that served the settings did not sync the change yet
+  if (settings.featureTogglesVersion < notification.version && attempts > 1) {
+    setTimeout(() => onFeatureTogglesChanged(notification, attempts - 1), retryDelayMs);
+  }
+}
+
+export function applyFeatureToggles(toggles: FeatureToggles, version: number) {
+  // The settings may come from an instance that did not apply the change yet, or a newer change was applied meanwhile
+  if (version <= config.featureTogglesVersion) {
+    return;
+  }
+  config.featureTogglesVersion = version;
+
+  const featureToggles = config.featureToggles as Record<string, boolean>;
+  for (const name of Object.keys(featureToggles)) {
+    if (!toggles[name as keyof FeatureToggles]) {
+      delete featureToggles[name];
+    }
+  }
+  for (const [name, enabled] of Object.entries(toggles)) {
+    if (enabled) {
+      featureToggles[name as keyof FeatureToggles] = true;
+    }
+  }
+}
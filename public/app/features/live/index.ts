@@ -6,6 +6,7 @@ import { loadUrlToken } from '../../core/utils/urlToken';
 
 import { CentrifugeService } from './centrifuge/service';
 import { CentrifugeServiceWorkerProxy } from './centrifuge/serviceWorkerProxy';
+import { watchFeatureToggles } from './featureToggles/featureToggleWatcher';
 import { GrafanaLiveService } from './live';
 
 export function initGrafanaLive() {
@@ -28,6 +29,10 @@ export function initGrafanaLive() {
       backendSrv: getBackendSrv(),
     })
   );
+
+  if (config.liveEnabled) {
+    watchFeatureToggles();
+  }
 }
 
 export function getGrafanaLiveCentrifugeSrv() {